@@ -1,12 +1,14 @@
 package main
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 
 	"github.com/josestg/swe-be-mono/internal/app"
 	"github.com/josestg/swe-be-mono/internal/app/adminrestful"
 	"github.com/josestg/swe-be-mono/internal/config"
+	"github.com/josestg/swe-be-mono/pkg/logkit"
 )
 
 // These variables are set by the build process.
@@ -18,15 +20,42 @@ var (
 )
 
 func main() {
-	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{}))
-	slog.SetDefault(log)
+	flags, err := config.ParseFlags(buildName, os.Args[1:])
+	if err != nil {
+		slog.Error("failed to parse flags", "error", err)
+		os.Exit(1)
+	}
+
+	if flags.PrintVersion {
+		fmt.Println(config.Version(buildName, buildTime, buildVersion))
+		return
+	}
 
 	cfg, err := config.New(buildName, buildTime, buildVersion)
 	if err != nil {
-		log.Error("failed to create app info", "error", err)
+		slog.Error("failed to create config", "error", err)
 		os.Exit(1)
 	}
 
+	if flags.PrintConfig != "" {
+		if err := cfg.Print(os.Stdout, flags.PrintConfig); err != nil {
+			slog.Error("failed to print config", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	log, err := logkit.New(os.Stderr, cfg.Log)
+	if err != nil {
+		slog.Error("failed to create logger", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(log)
+
+	if flags.Port != 0 && cfg.HttpServer.Addr != "" {
+		log.Warn("the -port flag is ignored since HTTP_SERVER_ADDR is set", "addr", cfg.HttpServer.Addr)
+	}
+
 	if err := app.Run(log, cfg, adminrestful.AppFactory); err != nil {
 		log.Error("app run failed", "error", err)
 		os.Exit(1)
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/josestg/swe-be-mono/internal/app"
+	"github.com/josestg/swe-be-mono/internal/config"
+	"github.com/josestg/swe-be-mono/pkg/logkit"
+)
+
+// These variables are set by the build process.
+// see: https://stackoverflow.com/questions/11354518/golang-application-auto-build-versioning/11355611#11355611.
+var (
+	buildName    = "unset"
+	buildTime    = "unset"
+	buildVersion = "unset"
+)
+
+const usage = `Usage: dbmigrate [flags] <command>
+
+Commands:
+  up      apply all the pending migrations.
+  down    roll back the last applied migrations, see -steps.
+  status  show the state of every migration.
+  seed    run the seeds, the pending migrations are applied first.
+
+Flags:
+`
+
+func main() {
+	fs := flag.NewFlagSet("dbmigrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "report the migrations without executing them.")
+	steps := fs.Int("steps", 1, "the number of migrations rolled back by down.")
+	printConfig := fs.String("print-config", "", "print the effective configuration as json or yaml with the secrets redacted, then exit.")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(os.Args[1:]) // exits on error.
+
+	if fs.NArg() != 1 && *printConfig == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.New(buildName, buildTime, buildVersion)
+	if err != nil {
+		slog.Error("failed to create config", "error", err)
+		os.Exit(1)
+	}
+
+	if *printConfig != "" {
+		if err := cfg.Print(os.Stdout, *printConfig); err != nil {
+			slog.Error("failed to print config", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	log, err := logkit.New(os.Stderr, cfg.Log)
+	if err != nil {
+		slog.Error("failed to create logger", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(log)
+
+	if err := run(log, cfg.Database, fs.Arg(0), *dryRun, *steps); err != nil {
+		log.Error("migration failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(log *slog.Logger, cfg config.Database, command string, dryRun bool, steps int) error {
+	if !cfg.Enabled() {
+		return errors.New("database is not configured, see the DB_POSTGRE_* variables")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// the migrations may run longer than the queries of the application.
+	cfg.QueryTimeout = 0
+
+	connectCtx, cancelConnect := context.WithTimeout(ctx, time.Minute)
+	defer cancelConnect()
+
+	db, err := app.OpenDatabase(connectCtx, log, cfg)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Error("close database failed", "error", err)
+		}
+	}()
+
+	migrator, err := app.NewMigrator(log, db, dryRun)
+	if err != nil {
+		return fmt.Errorf("create migrator: %w", err)
+	}
+
+	switch command {
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	case "up":
+		applied, err := migrator.Up(ctx)
+		log.Info("migrations applied", "count", len(applied), "dry_run", dryRun)
+		return err
+	case "down":
+		rolledBack, err := migrator.Down(ctx, steps)
+		log.Info("migrations rolled back", "count", len(rolledBack), "dry_run", dryRun)
+		return err
+	case "seed":
+		if dryRun {
+			return errors.New("seed doesn't support -dry-run")
+		}
+		if _, err := migrator.Up(ctx); err != nil {
+			return err
+		}
+
+		seeder, err := app.NewSeeder(log, db)
+		if err != nil {
+			return fmt.Errorf("create seeder: %w", err)
+		}
+		return seeder.Run(ctx)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied() {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	}
+}
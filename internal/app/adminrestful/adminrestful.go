@@ -1,14 +1,23 @@
 package adminrestful
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/josestg/swe-be-mono/internal/domain/rbac"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/internal/httphandler"
 	"github.com/josestg/swe-be-mono/internal/httpmiddleware"
 
 	"github.com/josestg/swe-be-mono/internal/app"
 	"github.com/josestg/swe-be-mono/internal/config"
 	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/jwtkit"
 )
 
 // BasePath is the base path for the admin-restful application.
@@ -16,16 +25,64 @@ const BasePath = "/swe-be-mono-admins"
 
 // App is the admin-restful application.
 type App struct {
-	cfg *config.Config
-	log *slog.Logger
+	cfg   *config.Config
+	log   *slog.Logger
+	users *user.Service  // nil if the database or the auth tokens aren't configured.
+	roles *rbac.Service  // nil if the database or the auth tokens aren't configured.
+	keys  *jwtkit.KeySet // nil if the database or the auth tokens aren't configured.
 }
 
 // AppFactory is the factory for creating the admin-restful application.
 func AppFactory(cfg *config.Config) app.App {
-	return &App{
+	a := &App{
 		cfg: cfg,
 		log: slog.Default(),
 	}
+
+	// the database-backed APIs are only served when the database is configured.
+	if cfg.Database.Enabled() {
+		if err := a.initDomains(cfg); err != nil {
+			a.log.Error("init domains failed, the database-backed APIs are disabled", "error", err)
+			a.users, a.roles, a.keys = nil, nil, nil
+		}
+	}
+	return a
+}
+
+// initDomains creates the services of the domains stored in the database, and the key set verifying the access
+// tokens of the admins. It fails if the auth tokens aren't configured, because the admin APIs manage all the users
+// and are never served without authentication.
+func (a *App) initDomains(cfg *config.Config) error {
+	if !cfg.AuthToken.Enabled() {
+		return errors.New("the auth tokens aren't configured, see AUTH_TOKEN_KEYS")
+	}
+
+	db, err := app.NewDatabase(cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	repo, err := user.NewRepository(db)
+	if err != nil {
+		return err
+	}
+	a.users = user.NewService(repo)
+
+	if a.roles, err = rbac.NewService(db, a.users); err != nil {
+		return fmt.Errorf("create rbac service: %w", err)
+	}
+
+	if a.keys, err = cfg.AuthToken.KeySet(); err != nil {
+		return fmt.Errorf("create auth token key set: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := a.roles.Bootstrap(ctx, cfg.RBAC); err != nil {
+		// the existing admins can still manage the roles, so it isn't fatal.
+		a.log.Error("bootstrap admin role failed", "error", err)
+	}
+	return nil
 }
 
 // DocHandler returns the handler for the admin-restful documentation.
@@ -34,16 +91,38 @@ func (a *App) DocHandler() http.Handler { return _docHandler }
 // BasePath returns the base path for the application.
 func (a *App) BasePath() string { return BasePath }
 
-// APIHandler returns the handler for the admin-restful APIs.
+// APIHandler returns the handler for the admin-restful APIs. The admins are authenticated by the access tokens of
+// the enduser-restful application, and authorized by the permissions of their roles, see httpmiddleware.Authorize.
 func (a *App) APIHandler() http.Handler {
-	mid := httpkit.ReduceMuxMiddleware(
-		httpmiddleware.LogAndErrHandling(a.log.WithGroup("request")),
-	)
+	mid := []httpkit.MuxMiddleware{httpmiddleware.LogAndErrHandling(a.log.WithGroup("request"))}
+	if a.users == nil {
+		return httpkit.NewServeMux(httpkit.Opts.Middleware(httpkit.ReduceMuxMiddleware(mid...)))
+	}
 
-	mux := httpkit.NewServeMux(httpkit.Opts.Middleware(mid))
+	mid = append(mid,
+		httpmiddleware.JWTAuth(httpmiddleware.JWTAuthConfig{
+			KeySet:   a.keys,
+			Issuer:   a.cfg.AuthToken.Issuer,
+			Audience: a.cfg.AuthToken.Audience,
+		}),
+		httpmiddleware.ResolvePermissions(httpmiddleware.PermissionResolverFunc(a.permissions)),
+		httpmiddleware.Authorize(),
+	)
+	mux := httpkit.NewServeMux(httpkit.Opts.Middleware(httpkit.ReduceMuxMiddleware(mid...)))
+	httphandler.ServeUser(mux, a.users)
+	httphandler.ServeRole(mux, a.roles)
 	return mux
 }
 
+// permissions resolves the permissions granted to the user of the access token by the roles.
+func (a *App) permissions(ctx context.Context, p httpmiddleware.Principal) ([]string, error) {
+	id, err := idkit.ParseUUID(p.ID)
+	if err != nil {
+		return nil, nil // not a user, e.g. a token of another subject.
+	}
+	return a.roles.Permissions(ctx, id)
+}
+
 // _docHandler is the default handler for docs endpoint.
 var _docHandler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 	msg := `{"message": "please run with 'swagger_docs_enabled' build tag to enable swagger docs"}`
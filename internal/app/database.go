@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/josestg/swe-be-mono/internal/config"
+	"github.com/josestg/swe-be-mono/pkg/healthkit"
+	"github.com/josestg/swe-be-mono/pkg/migratekit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+	"github.com/josestg/swe-be-mono/resources/migrations"
+	"github.com/josestg/swe-be-mono/resources/seeds"
+	_ "github.com/lib/pq" // the PostgreSQL driver.
+)
+
+// OpenDatabase opens the database connection and waits until the database is reachable, see sqlxkit.OpenWithRetry.
+func OpenDatabase(ctx context.Context, log *slog.Logger, cfg config.Database) (sqlxkit.Conn, error) {
+	policy := sqlxkit.RetryPolicy{
+		OnRetry: func(attempt int, err error, wait time.Duration) {
+			log.Warn("database is unreachable, retrying", "attempt", attempt, "wait", wait, "error", err)
+		},
+	}
+
+	return sqlxkit.OpenWithRetry(ctx, cfg.Driver, cfg.DSN, policy, databaseOptions(cfg))
+}
+
+// NewDatabase opens the database connection pool without connecting, so the application starts even if the database
+// is still booting, and registers its health check, see RegisterHealthCheck.
+func NewDatabase(cfg config.Database) (sqlxkit.Conn, error) {
+	db, err := sqlxkit.Open(cfg.Driver, cfg.DSN, databaseOptions(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	RegisterHealthCheck("database", 2*time.Second, healthkit.CheckerFunc(db.PingContext))
+	return db, nil
+}
+
+// databaseOptions returns the sqlxkit options of the database configuration.
+func databaseOptions(cfg config.Database) sqlxkit.Option {
+	return func(c *sqlxkit.Config) {
+		c.MaxOpenConnections = cfg.MaxOpenConnections
+		c.MaxIdleConnections = cfg.MaxIdleConnections
+		c.QueryTimeout = cfg.QueryTimeout
+	}
+}
+
+// NewMigrator creates the migrator of the embedded migrations, logging every applied or rolled back migration.
+func NewMigrator(log *slog.Logger, db sqlxkit.DB, dryRun bool) (*migratekit.Migrator, error) {
+	return migratekit.New(db, migrations.Postgre(), migratekit.Config{
+		DryRun: dryRun,
+		OnMigrate: func(m migratekit.Migration, direction migratekit.Direction, elapsed time.Duration) {
+			log.Info("database migrated",
+				"migration", m.String(),
+				"direction", direction.String(),
+				"dry_run", dryRun,
+				"elapsed", elapsed,
+			)
+		},
+	})
+}
+
+// migrateOnStartup applies the pending migrations before serving.
+func migrateOnStartup(log *slog.Logger, cfg config.Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db, err := OpenDatabase(ctx, log, cfg)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Error("close database failed", "error", err)
+		}
+	}()
+
+	migrator, err := NewMigrator(log, db, false)
+	if err != nil {
+		return fmt.Errorf("create migrator: %w", err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("apply migrations: %w", err)
+	}
+	log.Info("database is up to date", "applied", len(applied))
+	return nil
+}
+
+// NewSeeder creates the seeder of the embedded seeds followed by the extra seeds, e.g. the fixtures of a test,
+// logging every seed run.
+func NewSeeder(log *slog.Logger, db sqlxkit.DB, extra ...migratekit.Seed) (*migratekit.Seeder, error) {
+	embedded, err := migratekit.LoadSeeds(seeds.Postgre())
+	if err != nil {
+		return nil, err
+	}
+
+	onSeed := func(s migratekit.Seed, elapsed time.Duration) {
+		log.Info("database seeded", "seed", s.Name, "elapsed", elapsed)
+	}
+	return migratekit.NewSeeder(db, onSeed, append(embedded, extra...)...), nil
+}
@@ -1,15 +1,27 @@
 package enduserrestful
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/josestg/swe-be-mono/internal/domain/auth"
+	"github.com/josestg/swe-be-mono/internal/domain/identity"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/internal/httphandler"
 	"github.com/josestg/swe-be-mono/internal/httpmiddleware"
 
 	"github.com/josestg/swe-be-mono/internal/config"
 
 	"github.com/josestg/swe-be-mono/internal/app"
+	"github.com/josestg/swe-be-mono/pkg/healthkit"
 	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/passwd"
+	"github.com/josestg/swe-be-mono/pkg/ratekit"
+	"github.com/josestg/swe-be-mono/pkg/rediskit"
+	"github.com/josestg/swe-be-mono/pkg/sessionkit"
 )
 
 // BasePath is the base path for the enduser-restful application.
@@ -17,16 +29,108 @@ const BasePath = "/swe-be-mono-endusers"
 
 // App is the enduser-restful application.
 type App struct {
-	cfg *config.Config
-	log *slog.Logger
+	cfg       *config.Config
+	log       *slog.Logger
+	sessions  *sessionkit.Manager
+	rateLimit ratekit.Store
+	users     *user.Service      // nil if the database or the auth tokens aren't configured.
+	auth      *auth.Service      // nil if the database or the auth tokens aren't configured.
+	tokens    *auth.TokenService // nil if the database or the auth tokens aren't configured.
+	totp      *auth.TOTPService  // nil if the auth APIs are disabled or the TOTP isn't configured.
+	oidc      *identity.Service  // nil if the auth APIs are disabled or no provider is configured.
 }
 
 // AppFactory is the factory for creating the enduser-restful application.
 func AppFactory(cfg *config.Config) app.App {
-	return &App{
-		cfg: cfg,
-		log: slog.Default(),
+	var (
+		sessionStore   sessionkit.Store = sessionkit.NewMemoryStore()
+		rateLimitStore ratekit.Store    = ratekit.NewMemoryStore()
+	)
+
+	// share the state across instances when Redis is available.
+	if cfg.Redis.Enabled() {
+		client := rediskit.New(cfg.Redis)
+		sessionStore = sessionkit.NewRedisStore(client, "enduser:session:")
+		rateLimitStore = ratekit.NewRedisStore(client, "enduser:ratelimit:")
+		app.RegisterHealthCheck("redis", 2*time.Second, healthkit.CheckerFunc(func(ctx context.Context) error {
+			return rediskit.Ping(ctx, client)
+		}))
+	}
+
+	a := &App{
+		cfg:       cfg,
+		log:       slog.Default(),
+		sessions:  sessionkit.NewManager(sessionStore, cfg.Session),
+		rateLimit: rateLimitStore,
+	}
+
+	// the auth APIs are only served when both the database and the auth tokens are configured.
+	if cfg.Database.Enabled() && cfg.AuthToken.Enabled() {
+		if err := a.initDomains(cfg); err != nil {
+			a.log.Error("init domains failed, the auth APIs are disabled", "error", err)
+		}
+	}
+	return a
+}
+
+// initDomains creates the services of the domains stored in the database.
+func (a *App) initDomains(cfg *config.Config) error {
+	db, err := app.NewDatabase(cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	repo, err := user.NewRepository(db)
+	if err != nil {
+		return err
+	}
+	users := user.NewService(repo)
+
+	tokens, err := auth.NewTokenService(db, cfg.AuthToken)
+	if err != nil {
+		return fmt.Errorf("create token service: %w", err)
 	}
+
+	hasher, err := newPasswordHasher(cfg.PasswordPepper)
+	if err != nil {
+		return fmt.Errorf("create password hasher: %w", err)
+	}
+
+	var totp *auth.TOTPService
+	if cfg.AuthTOTP.Enabled() {
+		if totp, err = auth.NewTOTPService(db, users, cfg.AuthTOTP); err != nil {
+			return fmt.Errorf("create totp service: %w", err)
+		}
+	}
+
+	authService, err := auth.NewService(db, users, tokens, auth.ServiceConfig{Hasher: hasher, TOTP: totp})
+	if err != nil {
+		return err
+	}
+
+	var oidc *identity.Service
+	if cfg.OIDC.Enabled() {
+		if oidc, err = identity.NewService(db, users, tokens, totp, identity.NewProviders(cfg.OIDC)); err != nil {
+			return fmt.Errorf("create identity service: %w", err)
+		}
+	}
+
+	a.users, a.auth, a.tokens, a.totp, a.oidc = users, authService, tokens, totp, oidc
+	return nil
+}
+
+// newPasswordHasher creates the hasher of the passwords by Argon2id, still accepting the legacy hashes which are
+// upgraded on login, and peppered if the peppers are configured.
+func newPasswordHasher(pepper passwd.PepperConfig) (*passwd.Hasher, error) {
+	var hc passwd.HashComparer = passwd.NewMulti(passwd.Argon2idDefault)
+	if pepper.Enabled() {
+		peppered, err := passwd.NewPeppered(hc, pepper)
+		if err != nil {
+			return nil, err
+		}
+		hc = peppered
+	}
+	return passwd.NewHasher(hc), nil
 }
 
 // DocHandler returns the handler for the enduser-restful documentation.
@@ -39,9 +143,39 @@ func (a *App) BasePath() string { return BasePath }
 func (a *App) APIHandler() http.Handler {
 	mid := httpkit.ReduceMuxMiddleware(
 		httpmiddleware.LogAndErrHandling(a.log.WithGroup("request")),
+		httpmiddleware.RateLimit(httpmiddleware.RateLimitConfig{
+			Group: "api",
+			Limit: a.cfg.RateLimit,
+			Store: a.rateLimit,
+			Key:   httpmiddleware.RateLimitKeys.ClientIP(),
+		}),
+		a.sessions.Middleware(),
 	)
 
 	mux := httpkit.NewServeMux(httpkit.Opts.Middleware(mid))
+	if a.auth != nil {
+		// the authenticated routes are also limited by the user, which is only known after the authentication.
+		authn := httpkit.ReduceMuxMiddleware(
+			httpmiddleware.JWTAuth(httpmiddleware.JWTAuthConfig{
+				KeySet:   a.tokens.KeySet(),
+				Issuer:   a.cfg.AuthToken.Issuer,
+				Audience: a.cfg.AuthToken.Audience,
+			}),
+			httpmiddleware.RateLimit(httpmiddleware.RateLimitConfig{
+				Group: "user",
+				Limit: a.cfg.RateLimit,
+				Store: a.rateLimit,
+				Key:   httpmiddleware.RateLimitKeys.Principal(),
+			}),
+		)
+		httphandler.ServeAuth(mux, a.auth, a.tokens, a.users, authn)
+		if a.totp != nil {
+			httphandler.ServeTOTP(mux, a.totp, authn)
+		}
+		if a.oidc != nil {
+			httphandler.ServeOIDC(mux, a.oidc)
+		}
+	}
 	return mux
 }
 
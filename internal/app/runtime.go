@@ -1,10 +1,13 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/josestg/swe-be-mono/internal/httpmiddleware"
 
@@ -12,7 +15,15 @@ import (
 
 	"github.com/josestg/swe-be-mono/internal/httphandler"
 
+	"github.com/josestg/swe-be-mono/pkg/accesslog"
+	"github.com/josestg/swe-be-mono/pkg/healthkit"
 	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/i18nkit"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/tracekit"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/text/language"
 )
 
 // Run is the entrypoint of the for the application.
@@ -20,13 +31,44 @@ func Run(log *slog.Logger, cfg *config.Config, factory Factory) error {
 	log.Info("app started", "app", cfg.AppInfo)
 	defer log.Info("app stopped", "app", cfg.AppInfo)
 
-	router := newRouter(cfg, factory)
+	shutdownTracing, err := tracekit.Setup(cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("setup tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Error("shutdown tracing failed", "error", err)
+		}
+	}()
+
+	accessLog := httpkit.ReduceNetMiddleware()
+	if cfg.AccessLog.Enabled {
+		w, err := accesslog.Open(cfg.AccessLog)
+		if err != nil {
+			return fmt.Errorf("open access log: %w", err)
+		}
+		defer func() {
+			if err := w.Close(); err != nil {
+				log.Error("close access log failed", "error", err)
+			}
+		}()
+		accessLog = w.Middleware(func(err error) { log.Error("write access log failed", "error", err) })
+	}
+
+	if cfg.Database.Enabled() && cfg.Database.MigrateOnStartup {
+		if err := migrateOnStartup(log, cfg.Database); err != nil {
+			return fmt.Errorf("migrate database: %w", err)
+		}
+	}
+
+	_health.SetCacheTTL(cfg.Health.CacheTTL)
+	router := newRouter(log, cfg, factory, accessLog)
 	return listenAndServe(log, cfg.HttpServer, router)
 }
 
 // newRouter returns the complete http.Handler for the application.
 // Including the Application APIs, Documentation and System APIs.
-func newRouter(cfg *config.Config, factory Factory) http.Handler {
+func newRouter(log *slog.Logger, cfg *config.Config, factory Factory, accessLog httpkit.NetMiddleware) http.Handler {
 	app := factory.New(cfg)
 
 	// dynamically get the path prefix for the application.
@@ -35,47 +77,145 @@ func newRouter(cfg *config.Config, factory Factory) http.Handler {
 	// mid is a root level middleware for the application.
 	mid := httpkit.ReduceNetMiddleware(
 		httpmiddleware.CORS(cfg.HttpCORS),
-		httpkit.LogEntryRecorder,
+		httpkit.RequestID(idkit.UUIDv4),
+		httpkit.IDProvider(idkit.UUIDv4),
+		httpkit.ReportErrors(_errorReporter),
+		httpkit.ResolveClientIP(cfg.HttpTrustedProxies),
+		httpkit.RequestLogger(log),
+		_catalog.Middleware(),
+		httpkit.NewLogEntryRecorder(cfg.HttpLogRecorder),
+		accessLog,
+		tracekit.HTTPMiddleware(tracekit.TracerProvider()),
 	)
 
 	// mux in here is a root mux for splitting the traffic to different handlers based on the path prefix.
 	mux := http.NewServeMux()
 	mux.Handle(prefix+"/docs/", app.DocHandler())
 	mux.Handle(prefix+"/api/v1/", http.StripPrefix(prefix, mid.Then(app.APIHandler())))
-	mux.Handle(prefix+"/system/", http.StripPrefix(prefix, mid.Then(systemHandler(cfg.AppInfo))))
+	mux.Handle(prefix+"/system/", http.StripPrefix(prefix, mid.Then(systemHandler(log, cfg, _health))))
 	return mux
 }
 
-// systemHandler is a handler for serving system information and health checks.
-func systemHandler(info config.AppInfo) http.Handler {
+// _errorReporter is the reporter for the unresolved errors and the recovered panics, nil disables the reporting.
+var _errorReporter httpkit.ErrorReporter
+
+// SetErrorReporter sets the reporter for the unresolved errors and the recovered panics of all applications, e.g.
+// for forwarding them to Sentry. It must be called before Run.
+func SetErrorReporter(reporter httpkit.ErrorReporter) { _errorReporter = reporter }
+
+// _catalog is the message catalog for localizing the responses, e.g. the Problem Details.
+var _catalog = i18nkit.NewCatalog(language.English)
+
+// SetCatalog sets the message catalog for localizing the responses of all applications by the Accept-Language
+// request header. It must be called before Run.
+func SetCatalog(catalog *i18nkit.Catalog) { _catalog = catalog }
+
+// _health is the registry of the health checks of the application dependencies.
+var _health = healthkit.NewRegistry(healthkit.Config{})
+
+// RegisterHealthCheck registers the health check of an application dependency, e.g. a database, a cache or a queue,
+// for the readiness probe. It is usually called by the application factory.
+func RegisterHealthCheck(name string, timeout time.Duration, checker healthkit.Checker) {
+	_health.Register(name, timeout, checker)
+}
+
+// systemHandler is a handler for serving system information, health checks and, if enabled, runtime diagnostics.
+func systemHandler(log *slog.Logger, cfg *config.Config, health *healthkit.Registry) http.Handler {
 	mux := httpkit.NewServeMux()
-	httphandler.ServeSystem(mux, info)
+	auth := systemAuth(log, cfg.SystemDebug)
+	httphandler.ServeSystem(mux, cfg.AppInfo, cfg.Log.Level, health, log, auth...)
+
+	if cfg.SystemDebug.Enabled {
+		// the effective configuration is only served behind the API key, even redacted it reveals the topology.
+		var redacted any
+		mid := auth
+		if mid != nil {
+			redacted = cfg.Redacted()
+		} else {
+			mid = []httpkit.MuxMiddleware{httpmiddleware.LogAndErrHandling(log.WithGroup("request"))}
+		}
+		httphandler.ServeDebug(mux, redacted, mid...)
+	}
 	return mux
 }
 
+// systemAuth returns the middlewares authenticating the system routes by the SystemDebug.APIKey, nil if it isn't set.
+func systemAuth(log *slog.Logger, cfg config.SystemDebug) []httpkit.MuxMiddleware {
+	if cfg.APIKey == "" {
+		return nil
+	}
+
+	id, secret, _ := strings.Cut(cfg.APIKey, ".")
+	store := httpmiddleware.NewMemoryAPIKeyStore(httpmiddleware.APIKey{
+		ID:    id,
+		Owner: "system-debug",
+		Hash:  httpmiddleware.HashAPIKeySecret(secret),
+	})
+	return []httpkit.MuxMiddleware{
+		httpmiddleware.LogAndErrHandling(log.WithGroup("request")),
+		httpmiddleware.APIKeyAuth(store),
+	}
+}
+
+// withH2C serves HTTP/2 over cleartext TCP by the handler if RunConfig.H2C is enabled, the prior knowledge and the
+// upgrade from HTTP/1.1 are both supported.
+func withH2C(cfg httpkit.RunConfig, h http.Handler) http.Handler {
+	if !cfg.H2C {
+		return h
+	}
+	return h2c.NewHandler(h, &http2.Server{})
+}
+
 // listenAndServe starts the http server and gracefully shutdowns on signals received.
 func listenAndServe(log *slog.Logger, cfg httpkit.RunConfig, mux http.Handler) error {
+	mux = withH2C(cfg, mux)
+
+	addr := cfg.Addr
+	if addr == "" {
+		addr = fmt.Sprintf("0.0.0.0:%d", cfg.Port)
+	}
+
 	srv := http.Server{
-		Addr:         fmt.Sprintf("0.0.0.0:%d", cfg.Port),
+		Addr:         addr,
 		Handler:      mux,
 		ReadTimeout:  cfg.RequestReadTimeout,
 		WriteTimeout: cfg.RequestWriteTimeout,
 	}
 
-	run := httpkit.NewGracefulRunner(&srv,
+	opts := []httpkit.RunOption{
 		httpkit.RunOpts.WaitTimeout(cfg.ShutdownTimeout),
 		httpkit.RunOpts.Signals(syscall.SIGINT, syscall.SIGTERM),
-		httpkit.RunOpts.EventListener(func(evt httpkit.RunEvent, data string) {
-			switch evt {
+		httpkit.RunOpts.OnEvent(func(data httpkit.RunEventData) {
+			switch evt := data.(type) {
 			default:
-				log.Info(data)
-			case httpkit.RunEventAddr:
-				log.Info("http server listening", "addr", data)
-			case httpkit.RunEventSignal:
-				log.Info("http server received shutdown signal", "signal", data)
+				log.Info(evt.String())
+			case httpkit.RunAddr:
+				log.Info("http server listening", "addr", evt.Addr.String())
+			case httpkit.RunSignal:
+				log.Info("http server received shutdown signal", "signal", evt.Signal.String())
+			case httpkit.RunError:
+				log.Error(evt.Message, "error", evt.Err)
 			}
 		}),
-	)
+		// stop receiving new requests from the load balancer before shutting down.
+		httpkit.RunOpts.OnShutdown(func(context.Context) error {
+			_health.SetReady(false)
+			return nil
+		}),
+		httpkit.RunOpts.DrainDelay(cfg.DrainDelay),
+	}
+
+	if len(cfg.AutoTLSHosts) > 0 {
+		opts = append(opts, httpkit.RunOpts.AutoTLS(cfg.AutoTLSHosts, cfg.AutoTLSCacheDir))
+		if cfg.AutoTLSFallbackCert != "" {
+			opts = append(opts, httpkit.RunOpts.AutoTLSFallback(cfg.AutoTLSFallbackCert, cfg.AutoTLSFallbackKey))
+		}
+	}
+
+	if cfg.Upgrade {
+		opts = append(opts, httpkit.RunOpts.Upgrade(0))
+	}
 
+	run := httpkit.NewGracefulRunner(&srv, opts...)
 	return run.ListenAndServe()
 }
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"golang.org/x/net/http2"
+)
+
+func TestWithH2C(t *testing.T) {
+	proto := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte(r.Proto)) })
+
+	// the client talks HTTP/2 over cleartext TCP by the prior knowledge.
+	h2cClient := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return new(net.Dialer).DialContext(ctx, network, addr)
+		},
+	}}
+
+	tests := []struct {
+		name    string
+		enabled bool
+		client  *http.Client
+		want    string
+		wantErr bool
+	}{
+		{name: "h2c", enabled: true, client: h2cClient, want: "HTTP/2.0"},
+		{name: "http/1.1 on h2c", enabled: true, client: http.DefaultClient, want: "HTTP/1.1"},
+		{name: "h2c disabled", enabled: false, client: h2cClient, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(withH2C(httpkit.RunConfig{H2C: tt.enabled}, proto))
+			t.Cleanup(srv.Close)
+
+			res, err := tt.client.Get(srv.URL)
+			if tt.wantErr {
+				expectTrue(t, err != nil)
+				return
+			}
+			expectTrue(t, err == nil)
+			t.Cleanup(func() { _ = res.Body.Close() })
+
+			body, err := io.ReadAll(res.Body)
+			expectTrue(t, err == nil && string(body) == tt.want && res.Proto == tt.want)
+		})
+	}
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
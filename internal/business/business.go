@@ -1,8 +1,39 @@
 package business
 
+import (
+	"net/http"
+
+	"github.com/josestg/swe-be-mono/pkg/problemmap"
+)
+
 // Set of Problem Details type for business errors.
 const (
 	PDTypeUserNotFound      = "https://httpstatuses.com/user-not-found"
 	PDTypeEmailAlreadyTaken = "https://httpstatuses.com/email-already-taken"
 	PDTypeInvalidArguments  = "https://httpstatuses.com/invalid-arguments"
+	PDTypeUnauthenticated   = "https://httpstatuses.com/unauthenticated"
+	PDTypeForbidden         = "https://httpstatuses.com/forbidden"
+	PDTypeTooManyRequests   = "https://httpstatuses.com/too-many-requests"
+	PDTypeOTPRequired       = "https://httpstatuses.com/otp-required"
+	PDTypeTOTPNotSetUp      = "https://httpstatuses.com/totp-not-set-up"
+	PDTypeTOTPAlreadyActive = "https://httpstatuses.com/totp-already-active"
+	PDTypeProviderNotFound  = "https://httpstatuses.com/provider-not-found"
+	PDTypeRoleNotFound      = "https://httpstatuses.com/role-not-found"
+	PDTypeRoleAlreadyExists = "https://httpstatuses.com/role-already-exists"
 )
+
+// init registers the status codes of the business errors for the error handling middleware.
+func init() {
+	problemmap.Register(PDTypeUserNotFound, http.StatusNotFound)
+	problemmap.Register(PDTypeEmailAlreadyTaken, http.StatusConflict)
+	problemmap.Register(PDTypeInvalidArguments, http.StatusBadRequest)
+	problemmap.Register(PDTypeUnauthenticated, http.StatusUnauthorized)
+	problemmap.Register(PDTypeForbidden, http.StatusForbidden)
+	problemmap.Register(PDTypeTooManyRequests, http.StatusTooManyRequests)
+	problemmap.Register(PDTypeOTPRequired, http.StatusUnauthorized)
+	problemmap.Register(PDTypeTOTPNotSetUp, http.StatusNotFound)
+	problemmap.Register(PDTypeTOTPAlreadyActive, http.StatusConflict)
+	problemmap.Register(PDTypeProviderNotFound, http.StatusNotFound)
+	problemmap.Register(PDTypeRoleNotFound, http.StatusNotFound)
+	problemmap.Register(PDTypeRoleAlreadyExists, http.StatusConflict)
+}
@@ -1,51 +1,230 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/josestg/swe-be-mono/internal/domain/auth"
+	"github.com/josestg/swe-be-mono/internal/domain/identity"
+	"github.com/josestg/swe-be-mono/internal/domain/rbac"
+	"github.com/josestg/swe-be-mono/internal/kernel"
+	"github.com/josestg/swe-be-mono/pkg/accesslog"
 	"github.com/josestg/swe-be-mono/pkg/env"
+	"github.com/josestg/swe-be-mono/pkg/healthkit"
 	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/logkit"
+	"github.com/josestg/swe-be-mono/pkg/passwd"
+	"github.com/josestg/swe-be-mono/pkg/ratekit"
+	"github.com/josestg/swe-be-mono/pkg/rediskit"
+	"github.com/josestg/swe-be-mono/pkg/sessionkit"
+	"github.com/josestg/swe-be-mono/pkg/tracekit"
 	"github.com/rs/cors"
 )
 
 // Config is a central configuration for the application.
 type Config struct {
-	AppInfo    AppInfo
-	HttpCORS   cors.Options
-	HttpServer httpkit.RunConfig
+	AppInfo            AppInfo
+	Log                logkit.Config
+	HttpCORS           cors.Options
+	HttpServer         httpkit.RunConfig
+	HttpTrustedProxies []netip.Prefix
+	HttpLogRecorder    httpkit.LogRecorderConfig
+	AccessLog          accesslog.Config
+	Health             healthkit.Config
+	SystemDebug        SystemDebug
+	Tracing            tracekit.Config
+	Session            sessionkit.Config
+	Redis              rediskit.Config
+	Database           Database
+	RateLimit          ratekit.Limit
+	PasswordPepper     passwd.PepperConfig
+	PaginationCursor   kernel.CursorConfig
+	AuthToken          auth.TokenConfig
+	AuthTOTP           auth.TOTPConfig
+	OIDC               identity.Config
+	RBAC               rbac.Config
 }
 
-// New creates a new Config.
+// New creates a new Config. The values are taken in the order of precedence:
+//
+//  1. the environment variables, including the ones overridden by ParseFlags.
+//  2. the .env files of DotenvFiles.
+//  3. the config file of CONFIG_FILE, if set, see env.LoadFile.
+//  4. the defaults.
+//
+// Then the secret references are resolved, see resolveSecrets, and the Config is validated, see Validate.
 func New(appName, buildTime, buildVersion string) (*Config, error) {
+	if err := env.LoadDotenv(DotenvFiles(appName)...); err != nil {
+		return nil, fmt.Errorf("load dotenv: %w", err)
+	}
+
+	if path := env.String("CONFIG_FILE", ""); path != "" {
+		if err := env.LoadFile(path); err != nil {
+			return nil, fmt.Errorf("load config file: %w", err)
+		}
+	}
+
+	if err := resolveSecrets(); err != nil {
+		return nil, fmt.Errorf("resolve secrets: %w", err)
+	}
+
 	appInfo, err := NewAppInfo(appName, buildTime, buildVersion)
 	if err != nil {
 		return nil, fmt.Errorf("create app info: %w", err)
 	}
 
+	trustedProxies, err := httpkit.ParseTrustedProxies(env.StringList("HTTP_TRUSTED_PROXIES", nil))
+	if err != nil {
+		return nil, fmt.Errorf("parse trusted proxies: %w", err)
+	}
+
+	var c env.Collector
 	cfg := &Config{
 		AppInfo: appInfo,
-		HttpServer: httpkit.RunConfig{
-			Port:                env.Int("HTTP_SERVER_PORT", 8080),
-			ShutdownTimeout:     env.Duration("HTTP_SERVER_SHUTDOWN_TIMEOUT", 5*time.Second),
-			RequestReadTimeout:  env.Duration("HTTP_SERVER_REQUEST_READ_TIMEOUT", 5*time.Second),
-			RequestWriteTimeout: env.Duration("HTTP_SERVER_REQUEST_WRITE_TIMEOUT", 10*time.Second),
-		},
 		HttpCORS: cors.Options{
-			AllowedOrigins:     env.StringList("HTTP_CORS_ALLOWED_ORIGINS", []string{"*"}),
-			AllowedMethods:     env.StringList("HTTP_CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "PATCH"}),
-			AllowedHeaders:     env.StringList("HTTP_CORS_ALLOWED_HEADERS", []string{"*"}),
-			AllowCredentials:   env.Bool("HTTP_CORS_ALLOW_CREDENTIALS", false),
-			MaxAge:             env.Int("HTTP_CORS_MAX_AGE", 0),
-			OptionsPassthrough: env.Bool("HTTP_CORS_OPTIONS_PASSTHROUGH", false),
-			Debug:              env.Bool("HTTP_CORS_DEBUG", false),
+			AllowedOrigins:     c.StringList("HTTP_CORS_ALLOWED_ORIGINS", []string{"*"}),
+			AllowedMethods:     c.StringList("HTTP_CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "PATCH"}),
+			AllowedHeaders:     c.StringList("HTTP_CORS_ALLOWED_HEADERS", []string{"*"}),
+			AllowCredentials:   c.Bool("HTTP_CORS_ALLOW_CREDENTIALS", false),
+			MaxAge:             c.Int("HTTP_CORS_MAX_AGE", 0),
+			OptionsPassthrough: c.Bool("HTTP_CORS_OPTIONS_PASSTHROUGH", false),
+			Debug:              c.Bool("HTTP_CORS_DEBUG", false),
 		},
+		HttpTrustedProxies: trustedProxies,
+		HttpLogRecorder: httpkit.LogRecorderConfig{
+			Redaction:           httpkit.DefaultRedaction,
+			DiscardContentTypes: httpkit.DefaultDiscardContentTypes,
+		},
+		Tracing: tracekit.Config{
+			ServiceName:    appInfo.Name,
+			ServiceVersion: appInfo.BuildVersion,
+		},
+		Database: Database{
+			Driver: DatabaseDriver,
+			DSN:    postgreDSN(),
+		},
+		RateLimit: ratekit.PerMinute(600),
+	}
+
+	c.Load(&cfg.Log, env.Prefix("LOG"))
+	c.Load(&cfg.HttpServer, env.Prefix("HTTP_SERVER"))
+	c.Load(&cfg.HttpLogRecorder, env.Prefix("HTTP_LOG"))
+	c.Load(&cfg.AccessLog, env.Prefix("ACCESS_LOG"))
+	c.Load(&cfg.Health, env.Prefix("HEALTH"))
+	c.Load(&cfg.SystemDebug, env.Prefix("SYSTEM_DEBUG"))
+	c.Load(&cfg.Tracing, env.Prefix("TRACING"))
+	c.Load(&cfg.Session, env.Prefix("SESSION"))
+	c.Load(&cfg.Redis, env.Prefix("REDIS"))
+	c.Load(&cfg.Database, env.Prefix("DB"))
+	c.Load(&cfg.RateLimit, env.Prefix("RATE_LIMIT"))
+	c.Load(&cfg.PasswordPepper, env.Prefix("PASSWORD"))
+	c.Load(&cfg.PaginationCursor, env.Prefix("PAGINATION_CURSOR"))
+	c.Load(&cfg.AuthToken, env.Prefix("AUTH_TOKEN"))
+	c.Load(&cfg.AuthTOTP, env.Prefix("AUTH_TOTP"))
+	c.Load(&cfg.OIDC, env.Prefix("OIDC"))
+	c.Load(&cfg.RBAC, env.Prefix("RBAC"))
+	if err := c.Err(); err != nil {
+		return nil, fmt.Errorf("load env: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
 }
 
+// DotenvFiles returns the .env files loaded by New in the order of precedence, either DOTENV_FILES or the .env of
+// the working directory followed by the one of the application in cmd/<app>/config, so running from the repository
+// root picks up the application defaults.
+func DotenvFiles(appName string) []string {
+	return env.StringList("DOTENV_FILES", []string{".env", filepath.Join("cmd", appName, "config", ".env")})
+}
+
+// resolveSecrets replaces the variables holding a reference of the secret managers by the secrets, e.g.
+// DB_POSTGRE_PASSWORD=vault://secret/data/db#password. The managers are configured by the VAULT_*, AWS_* and GCP_*
+// variables, and the resolving is limited by SECRETS_RESOLVE_TIMEOUT. The AWS manager only supports the static
+// credentials of AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+func resolveSecrets() error {
+	var c env.Collector
+	var vault env.VaultConfig
+	var aws env.AWSConfig
+	var gcp env.GCPConfig
+	c.Load(&vault, env.Prefix("VAULT"))
+	c.Load(&aws, env.Prefix("AWS"))
+	c.Load(&gcp, env.Prefix("GCP"))
+	timeout := c.Duration("SECRETS_RESOLVE_TIMEOUT", 10*time.Second)
+	if err := c.Err(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return env.ResolveEnv(ctx, map[string]env.Provider{
+		env.SchemeVault:             env.NewVaultProvider(vault),
+		env.SchemeAWSSecretsManager: env.NewAWSSecretsManagerProvider(aws),
+		env.SchemeGCPSecretManager:  env.NewGCPSecretManagerProvider(gcp),
+	})
+}
+
+// SystemDebug is the configuration of the runtime diagnostics under /system/debug, e.g. pprof and expvar, and the
+// effective configuration under /system/config.
+type SystemDebug struct {
+	// Enabled mounts the diagnostics, they are disabled by default since the profiles expose the internals.
+	Enabled bool `env:"ENABLED"`
+
+	// APIKey is the key in the format of "<id>.<secret>" that must be sent in the X-API-Key header for accessing the
+	// diagnostics and changing the log level. Empty means no authentication for the diagnostics, e.g. when the system
+	// endpoints aren't exposed publicly, and neither the log level can be changed nor the effective configuration is
+	// served then.
+	APIKey string `env:"API_KEY,secret"`
+}
+
+// DatabaseDriver is the name of the sql driver of the database.
+const DatabaseDriver = "postgres"
+
+// Database is the configuration of the database connection.
+type Database struct {
+	Driver             string // the name of the sql driver, see DatabaseDriver.
+	DSN                string `env:"-,secret"`                               // the connection string, empty if the database isn't configured.
+	MaxOpenConnections int    `env:"POSTGRE_MAX_OPEN_CONNECTIONS"`           // the maximum open connections, zero means unlimited.
+	MaxIdleConnections int    `env:"POSTGRE_MAX_IDLE_CONNECTIONS,default=2"` // the maximum idle connections.
+
+	// QueryTimeout limits the duration of each query without a deadline, zero means no timeout.
+	QueryTimeout time.Duration `env:"POSTGRE_QUERY_TIMEOUT"`
+
+	// MigrateOnStartup applies the pending migrations before serving. Prefer running bin/dbmigrate from a deployment
+	// job when there are many replicas, since the migrations aren't coordinated between the instances.
+	MigrateOnStartup bool `env:"MIGRATE_ON_STARTUP"`
+}
+
+// Enabled reports whether the database is configured.
+func (d Database) Enabled() bool { return d.DSN != "" }
+
+// postgreDSN builds the PostgreSQL connection string from the DB_POSTGRE_* variables, empty if the host isn't set.
+func postgreDSN() string {
+	host := env.String("DB_POSTGRE_HOST", "")
+	if host == "" {
+		return ""
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(env.String("DB_POSTGRE_USER", ""), env.String("DB_POSTGRE_PASSWORD", "")),
+		Host:     net.JoinHostPort(host, env.String("DB_POSTGRE_PORT", "5432")),
+		Path:     "/" + env.String("DB_POSTGRE_DATABASE", ""),
+		RawQuery: env.String("DB_POSTGRE_CONN_QUERY", ""),
+	}
+	return dsn.String()
+}
+
 // AppInfo describes the basic information of the application.
 type AppInfo struct {
 	// Name is the name of the application.
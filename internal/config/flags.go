@@ -0,0 +1,53 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// Flags is the command-line flags shared by the application binaries.
+type Flags struct {
+	Port         int    // the port of -port, zero if not given.
+	PrintConfig  string // the format of Config.Print, empty for serving.
+	PrintVersion bool   // print the version, then exit.
+}
+
+// _flagEnv maps the flags overriding the configuration to their variables.
+var _flagEnv = map[string]string{
+	"port":      "HTTP_SERVER_PORT",
+	"log-level": "LOG_LEVEL",
+	"config":    "CONFIG_FILE",
+}
+
+// ParseFlags parses the command-line arguments without the program name, it exits on an invalid flag or -help. The
+// flags given explicitly override their variables, e.g. -port overrides HTTP_SERVER_PORT, so they take precedence
+// over the environment when New reads it. Like HTTP_SERVER_PORT, -port is ignored when HTTP_SERVER_ADDR is set.
+func ParseFlags(appName string, args []string) (Flags, error) {
+	var f Flags
+	fs := flag.NewFlagSet(appName, flag.ExitOnError)
+	fs.IntVar(&f.Port, "port", 0, "the port to listen to, overrides HTTP_SERVER_PORT. Ignored when HTTP_SERVER_ADDR is set.")
+	fs.String("log-level", "", "the minimum log level, e.g. debug, info, warn or error, overrides LOG_LEVEL.")
+	fs.String("config", "", "the path of the YAML, TOML or JSON config file, overrides CONFIG_FILE.")
+	fs.StringVar(&f.PrintConfig, "print-config", "",
+		"print the effective configuration as json or yaml with the secrets redacted, then exit.")
+	fs.BoolVar(&f.PrintVersion, "version", false, "print the version, then exit.")
+	_ = fs.Parse(args) // exits on error.
+
+	var err error
+	fs.Visit(func(fl *flag.Flag) {
+		key, ok := _flagEnv[fl.Name]
+		if !ok || err != nil {
+			return
+		}
+		if setErr := os.Setenv(key, fl.Value.String()); setErr != nil {
+			err = fmt.Errorf("apply flag -%s: %w", fl.Name, setErr)
+		}
+	})
+	return f, err
+}
+
+// Version returns the version line printed by the -version flag.
+func Version(appName, buildTime, buildVersion string) string {
+	return fmt.Sprintf("%s %s (built at %s)", appName, buildVersion, buildTime)
+}
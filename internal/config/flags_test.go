@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	// registers the restoration of the variables overridden by the flags.
+	t.Setenv("HTTP_SERVER_PORT", "8080")
+	t.Setenv("LOG_LEVEL", "info")
+	t.Setenv("CONFIG_FILE", "")
+	os.Unsetenv("CONFIG_FILE")
+
+	f, err := ParseFlags("testing", []string{"-port", "9090", "-log-level", "debug", "-print-config", "yaml"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if f.Port != 9090 || f.PrintConfig != PrintYAML || f.PrintVersion {
+		t.Errorf("expected the parsed flags, got %+v", f)
+	}
+
+	tests := []struct {
+		key    string
+		want   string
+		exists bool
+	}{
+		{key: "HTTP_SERVER_PORT", want: "9090", exists: true},
+		{key: "LOG_LEVEL", want: "debug", exists: true},
+		{key: "CONFIG_FILE", exists: false}, // not given, so not overridden.
+	}
+	for _, tt := range tests {
+		got, exists := os.LookupEnv(tt.key)
+		if got != tt.want || exists != tt.exists {
+			t.Errorf("expected %s=%q (set: %t), got %q (set: %t)", tt.key, tt.want, tt.exists, got, exists)
+		}
+	}
+}
+
+func TestParseFlags_Defaults(t *testing.T) {
+	t.Setenv("HTTP_SERVER_PORT", "8080")
+
+	f, err := ParseFlags("testing", []string{"-version"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if f != (Flags{PrintVersion: true}) {
+		t.Errorf("expected only -version, got %+v", f)
+	}
+	if got := os.Getenv("HTTP_SERVER_PORT"); got != "8080" {
+		t.Errorf("expected the variable is kept without -port, got %q", got)
+	}
+}
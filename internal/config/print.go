@@ -0,0 +1,39 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/josestg/swe-be-mono/pkg/env"
+	"gopkg.in/yaml.v3"
+)
+
+// The formats of Config.Print.
+const (
+	PrintJSON = "json"
+	PrintYAML = "yaml"
+)
+
+// Redacted returns the effective configuration, i.e. the defaults overridden by the environment, with the secrets
+// redacted, see env.Dump.
+func (c *Config) Redacted() any { return env.Dump(c) }
+
+// Print writes the redacted configuration in the format, either PrintJSON or PrintYAML.
+func (c *Config) Print(w io.Writer, format string) error {
+	switch format {
+	case PrintJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(c.Redacted())
+	case PrintYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(c.Redacted()); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("unknown print format %q, must be either %s or %s", format, PrintJSON, PrintYAML)
+	}
+}
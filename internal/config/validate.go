@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/josestg/swe-be-mono/internal/domain/identity"
+	"github.com/josestg/swe-be-mono/pkg/accesslog"
+	"github.com/josestg/swe-be-mono/pkg/logkit"
+	"github.com/josestg/swe-be-mono/pkg/tracekit"
+)
+
+// ErrInvalid is returned by Validate when the configuration has violations.
+var ErrInvalid = errors.New("invalid config")
+
+// Validate checks the ranges of the values and the constraints between them, all violations are reported at once
+// by their variable names, so they can be fixed in one go.
+func (c *Config) Validate() error {
+	var v violations
+
+	srv := c.HttpServer
+	v.check(srv.Addr != "" || (srv.Port >= 1 && srv.Port <= 65535), "HTTP_SERVER_PORT",
+		"must be in the range of [1, 65535], got %d", srv.Port)
+	v.check(srv.ShutdownTimeout > 0, "HTTP_SERVER_SHUTDOWN_TIMEOUT", "must be positive, got %s", srv.ShutdownTimeout)
+	v.check(srv.DrainDelay >= 0, "HTTP_SERVER_DRAIN_DELAY", "must not be negative, got %s", srv.DrainDelay)
+	v.check(srv.RequestReadTimeout > 0, "HTTP_SERVER_REQUEST_READ_TIMEOUT", "must be positive, got %s",
+		srv.RequestReadTimeout)
+	v.check(srv.RequestWriteTimeout > 0, "HTTP_SERVER_REQUEST_WRITE_TIMEOUT", "must be positive, got %s",
+		srv.RequestWriteTimeout)
+	v.check(srv.RequestWriteTimeout >= srv.RequestReadTimeout, "HTTP_SERVER_REQUEST_WRITE_TIMEOUT",
+		"must not be less than HTTP_SERVER_REQUEST_READ_TIMEOUT %s, got %s", srv.RequestReadTimeout,
+		srv.RequestWriteTimeout)
+	v.check(len(srv.AutoTLSHosts) == 0 || srv.AutoTLSCacheDir != "", "HTTP_SERVER_AUTO_TLS_CACHE_DIR",
+		"must be set when HTTP_SERVER_AUTO_TLS_HOSTS is set")
+	v.check((srv.AutoTLSFallbackCert == "") == (srv.AutoTLSFallbackKey == ""), "HTTP_SERVER_AUTO_TLS_FALLBACK_KEY",
+		"must be set together with HTTP_SERVER_AUTO_TLS_FALLBACK_CERT")
+
+	v.check(c.HttpCORS.MaxAge >= 0, "HTTP_CORS_MAX_AGE", "must not be negative, got %d", c.HttpCORS.MaxAge)
+
+	v.oneOf(c.Log.Format, "LOG_FORMAT", logkit.FormatText, logkit.FormatJSON)
+
+	if c.AccessLog.Enabled {
+		v.oneOf(c.AccessLog.Format, "ACCESS_LOG_FORMAT", accesslog.FormatJSON, accesslog.FormatCombined,
+			accesslog.FormatTemplate)
+		v.check(c.AccessLog.Format != accesslog.FormatTemplate || c.AccessLog.Template != "", "ACCESS_LOG_TEMPLATE",
+			"must be set when ACCESS_LOG_FORMAT is %s", accesslog.FormatTemplate)
+		v.check(c.AccessLog.MaxSize >= 0, "ACCESS_LOG_MAX_SIZE", "must not be negative, got %d", c.AccessLog.MaxSize)
+		v.check(c.AccessLog.MaxBackups >= 0, "ACCESS_LOG_MAX_BACKUPS", "must not be negative, got %d",
+			c.AccessLog.MaxBackups)
+	}
+
+	v.check(c.Health.CacheTTL >= 0, "HEALTH_CACHE_TTL", "must not be negative, got %s", c.Health.CacheTTL)
+
+	id, secret, ok := strings.Cut(c.SystemDebug.APIKey, ".")
+	v.check(c.SystemDebug.APIKey == "" || (ok && id != "" && secret != ""), "SYSTEM_DEBUG_API_KEY",
+		"must be in the format of <id>.<secret>")
+
+	if c.Tracing.Enabled {
+		v.oneOf(c.Tracing.Exporter, "TRACING_EXPORTER", tracekit.ExporterNone, tracekit.ExporterStdout)
+		v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO",
+			"must be in the range of [0, 1], got %g", c.Tracing.SampleRatio)
+	}
+
+	v.check(c.Session.TTL > 0, "SESSION_TTL", "must be positive, got %s", c.Session.TTL)
+
+	v.check(c.Redis.DB >= 0, "REDIS_DB", "must not be negative, got %d", c.Redis.DB)
+
+	db := c.Database
+	v.check(db.MaxOpenConnections >= 0, "DB_POSTGRE_MAX_OPEN_CONNECTIONS", "must not be negative, got %d",
+		db.MaxOpenConnections)
+	v.check(db.MaxIdleConnections >= 0, "DB_POSTGRE_MAX_IDLE_CONNECTIONS", "must not be negative, got %d",
+		db.MaxIdleConnections)
+	v.check(db.MaxOpenConnections == 0 || db.MaxIdleConnections <= db.MaxOpenConnections,
+		"DB_POSTGRE_MAX_IDLE_CONNECTIONS", "must not be greater than DB_POSTGRE_MAX_OPEN_CONNECTIONS %d, got %d",
+		db.MaxOpenConnections, db.MaxIdleConnections)
+	v.check(db.QueryTimeout >= 0, "DB_POSTGRE_QUERY_TIMEOUT", "must not be negative, got %s", db.QueryTimeout)
+	v.check(db.Enabled() || !db.MigrateOnStartup, "DB_MIGRATE_ON_STARTUP",
+		"requires the database, see DB_POSTGRE_HOST")
+
+	rate := c.RateLimit
+	v.check(rate.Rate > 0, "RATE_LIMIT_RATE", "must be positive, got %d", rate.Rate)
+	v.check(rate.Period > 0, "RATE_LIMIT_PERIOD", "must be positive, got %s", rate.Period)
+	v.check(rate.Burst >= 0, "RATE_LIMIT_BURST", "must not be negative, got %d", rate.Burst)
+
+	if pepper := c.PasswordPepper; pepper.Enabled() {
+		err := pepper.Validate()
+		v.check(err == nil, "PASSWORD_PEPPERS", "%v", err)
+	}
+
+	cursor := c.PaginationCursor.Secret
+	v.check(cursor == "" || len(cursor) >= 32, "PAGINATION_CURSOR_SECRET", "must be at least 32 bytes, got %d",
+		len(cursor))
+
+	token := c.AuthToken
+	if token.Enabled() {
+		err := token.Validate()
+		v.check(err == nil, "AUTH_TOKEN_KEYS", "%v", err)
+	}
+	v.check(token.AccessTTL >= 0, "AUTH_TOKEN_ACCESS_TTL", "must not be negative, got %s", token.AccessTTL)
+	v.check(token.RefreshTTL >= 0, "AUTH_TOKEN_REFRESH_TTL", "must not be negative, got %s", token.RefreshTTL)
+
+	totp := c.AuthTOTP.Key
+	v.check(totp == "" || len(totp) >= 32, "AUTH_TOTP_KEY", "must be at least 32 bytes, got %d", len(totp))
+	attempts, lockout := c.AuthTOTP.MaxAttempts, c.AuthTOTP.Lockout
+	v.check(attempts >= 0, "AUTH_TOTP_MAX_ATTEMPTS", "must not be negative, got %d", attempts)
+	v.check(lockout >= 0, "AUTH_TOTP_LOCKOUT", "must not be negative, got %s", lockout)
+
+	v.oidcProvider("OIDC_GOOGLE", c.OIDC.Google)
+	v.oidcProvider("OIDC_GITHUB", c.OIDC.GitHub)
+
+	if len(v) > 0 {
+		return fmt.Errorf("%w:\n%w", ErrInvalid, errors.Join(v...))
+	}
+	return nil
+}
+
+// violations collects the violations of Validate.
+type violations []error
+
+// check adds the violation of the variable if the condition doesn't hold.
+func (v *violations) check(ok bool, key, format string, args ...any) {
+	if !ok {
+		*v = append(*v, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+	}
+}
+
+// oneOf checks the value is one of the allowed values.
+func (v *violations) oneOf(value, key string, allowed ...string) {
+	v.check(slices.Contains(allowed, value), key, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+}
+
+// oidcProvider checks the client of the enabled provider is complete.
+func (v *violations) oidcProvider(prefix string, p identity.ProviderConfig) {
+	if !p.Enabled() {
+		return
+	}
+	v.check(p.ClientSecret != "", prefix+"_CLIENT_SECRET", "must be set when %s_CLIENT_ID is set", prefix)
+	v.check(p.RedirectURL != "", prefix+"_REDIRECT_URL", "must be set when %s_CLIENT_ID is set", prefix)
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/swe-be-mono/internal/domain/identity"
+	"github.com/josestg/swe-be-mono/pkg/accesslog"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/logkit"
+	"github.com/josestg/swe-be-mono/pkg/ratekit"
+	"github.com/josestg/swe-be-mono/pkg/sessionkit"
+	"github.com/josestg/swe-be-mono/pkg/tracekit"
+)
+
+// validConfig returns the Config with the defaults, which passes Validate.
+func validConfig() Config {
+	return Config{
+		Log: logkit.Config{Format: logkit.FormatText},
+		HttpServer: httpkit.RunConfig{
+			Port:                8080,
+			ShutdownTimeout:     5 * time.Second,
+			RequestReadTimeout:  5 * time.Second,
+			RequestWriteTimeout: 10 * time.Second,
+		},
+		Session:   sessionkit.Config{TTL: 24 * time.Hour},
+		Database:  Database{Driver: DatabaseDriver, MaxIdleConnections: 2},
+		RateLimit: ratekit.PerMinute(600),
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if cfg := validConfig(); cfg.Validate() != nil {
+		t.Fatalf("expected the defaults are valid, got %v", cfg.Validate())
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(c *Config)
+		keys   []string // the variables of the violations in order.
+	}{
+		{"port out of range", func(c *Config) { c.HttpServer.Port = 65536 }, []string{"HTTP_SERVER_PORT"}},
+		{"zero shutdown timeout", func(c *Config) {
+			c.HttpServer.ShutdownTimeout = 0
+		}, []string{"HTTP_SERVER_SHUTDOWN_TIMEOUT"}},
+		{"negative drain delay", func(c *Config) {
+			c.HttpServer.DrainDelay = -time.Second
+		}, []string{"HTTP_SERVER_DRAIN_DELAY"}},
+		{"zero read timeout", func(c *Config) {
+			c.HttpServer.RequestReadTimeout = 0
+		}, []string{"HTTP_SERVER_REQUEST_READ_TIMEOUT"}},
+		{"zero write timeout", func(c *Config) {
+			c.HttpServer.RequestWriteTimeout = 0
+		}, []string{"HTTP_SERVER_REQUEST_WRITE_TIMEOUT", "HTTP_SERVER_REQUEST_WRITE_TIMEOUT"}},
+		{"write timeout less than read timeout", func(c *Config) {
+			c.HttpServer.RequestWriteTimeout = time.Second
+		}, []string{"HTTP_SERVER_REQUEST_WRITE_TIMEOUT"}},
+		{"auto tls without cache dir", func(c *Config) {
+			c.HttpServer.AutoTLSHosts = []string{"example.com"}
+		}, []string{"HTTP_SERVER_AUTO_TLS_CACHE_DIR"}},
+		{"auto tls fallback cert without key", func(c *Config) {
+			c.HttpServer.AutoTLSFallbackCert = "cert.pem"
+		}, []string{"HTTP_SERVER_AUTO_TLS_FALLBACK_KEY"}},
+		{"negative cors max age", func(c *Config) { c.HttpCORS.MaxAge = -1 }, []string{"HTTP_CORS_MAX_AGE"}},
+		{"unknown log format", func(c *Config) { c.Log.Format = "xml" }, []string{"LOG_FORMAT"}},
+		{"unknown access log format", func(c *Config) {
+			c.AccessLog = accesslog.Config{Enabled: true, Format: "xml"}
+		}, []string{"ACCESS_LOG_FORMAT"}},
+		{"access log template without template", func(c *Config) {
+			c.AccessLog = accesslog.Config{Enabled: true, Format: accesslog.FormatTemplate}
+		}, []string{"ACCESS_LOG_TEMPLATE"}},
+		{"negative access log max size", func(c *Config) {
+			c.AccessLog = accesslog.Config{Enabled: true, Format: accesslog.FormatJSON, MaxSize: -1}
+		}, []string{"ACCESS_LOG_MAX_SIZE"}},
+		{"negative access log max backups", func(c *Config) {
+			c.AccessLog = accesslog.Config{Enabled: true, Format: accesslog.FormatJSON, MaxBackups: -1}
+		}, []string{"ACCESS_LOG_MAX_BACKUPS"}},
+		{"negative health cache ttl", func(c *Config) { c.Health.CacheTTL = -time.Second }, []string{"HEALTH_CACHE_TTL"}},
+		{"malformed system debug api key", func(c *Config) {
+			c.SystemDebug.APIKey = "secret"
+		}, []string{"SYSTEM_DEBUG_API_KEY"}},
+		{"unknown tracing exporter", func(c *Config) {
+			c.Tracing = tracekit.Config{Enabled: true, Exporter: "zipkin"}
+		}, []string{"TRACING_EXPORTER"}},
+		{"tracing sample ratio out of range", func(c *Config) {
+			c.Tracing = tracekit.Config{Enabled: true, Exporter: tracekit.ExporterStdout, SampleRatio: 2}
+		}, []string{"TRACING_SAMPLE_RATIO"}},
+		{"zero session ttl", func(c *Config) { c.Session.TTL = 0 }, []string{"SESSION_TTL"}},
+		{"negative redis db", func(c *Config) { c.Redis.DB = -1 }, []string{"REDIS_DB"}},
+		{"negative max open connections", func(c *Config) {
+			c.Database.MaxOpenConnections = -1
+		}, []string{"DB_POSTGRE_MAX_OPEN_CONNECTIONS", "DB_POSTGRE_MAX_IDLE_CONNECTIONS"}},
+		{"negative max idle connections", func(c *Config) {
+			c.Database.MaxIdleConnections = -1
+		}, []string{"DB_POSTGRE_MAX_IDLE_CONNECTIONS"}},
+		{"max idle connections greater than max open connections", func(c *Config) {
+			c.Database.MaxOpenConnections = 1
+		}, []string{"DB_POSTGRE_MAX_IDLE_CONNECTIONS"}},
+		{"negative query timeout", func(c *Config) {
+			c.Database.QueryTimeout = -time.Second
+		}, []string{"DB_POSTGRE_QUERY_TIMEOUT"}},
+		{"migrate on startup without database", func(c *Config) {
+			c.Database.MigrateOnStartup = true
+		}, []string{"DB_MIGRATE_ON_STARTUP"}},
+		{"zero rate", func(c *Config) { c.RateLimit.Rate = 0 }, []string{"RATE_LIMIT_RATE"}},
+		{"zero rate period", func(c *Config) { c.RateLimit.Period = 0 }, []string{"RATE_LIMIT_PERIOD"}},
+		{"negative rate burst", func(c *Config) { c.RateLimit.Burst = -1 }, []string{"RATE_LIMIT_BURST"}},
+		{"empty pepper", func(c *Config) {
+			c.PasswordPepper.Peppers = map[string]string{"1": ""}
+		}, []string{"PASSWORD_PEPPERS"}},
+		{"short pagination cursor secret", func(c *Config) {
+			c.PaginationCursor.Secret = "short"
+		}, []string{"PAGINATION_CURSOR_SECRET"}},
+		{"malformed auth token key", func(c *Config) {
+			c.AuthToken.Keys = map[string]string{"1": "HS256:short"}
+		}, []string{"AUTH_TOKEN_KEYS"}},
+		{"negative access ttl", func(c *Config) { c.AuthToken.AccessTTL = -time.Second }, []string{"AUTH_TOKEN_ACCESS_TTL"}},
+		{"negative refresh ttl", func(c *Config) {
+			c.AuthToken.RefreshTTL = -time.Second
+		}, []string{"AUTH_TOKEN_REFRESH_TTL"}},
+		{"short totp key", func(c *Config) { c.AuthTOTP.Key = "short" }, []string{"AUTH_TOTP_KEY"}},
+		{"negative totp max attempts", func(c *Config) {
+			c.AuthTOTP.MaxAttempts = -1
+		}, []string{"AUTH_TOTP_MAX_ATTEMPTS"}},
+		{"negative totp lockout", func(c *Config) { c.AuthTOTP.Lockout = -time.Second }, []string{"AUTH_TOTP_LOCKOUT"}},
+		{"oidc without client secret", func(c *Config) {
+			c.OIDC.Google = identity.ProviderConfig{ClientID: "id", RedirectURL: "https://example.com/callback"}
+		}, []string{"OIDC_GOOGLE_CLIENT_SECRET"}},
+		{"oidc without redirect url", func(c *Config) {
+			c.OIDC.GitHub = identity.ProviderConfig{ClientID: "id", ClientSecret: "secret"}
+		}, []string{"OIDC_GITHUB_REDIRECT_URL"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.mutate(&cfg)
+
+			err := cfg.Validate()
+			if !errors.Is(err, ErrInvalid) {
+				t.Fatalf("expected %v, got %v", ErrInvalid, err)
+			}
+
+			violations := strings.Split(err.Error(), "\n")[1:]
+			if len(violations) != len(tt.keys) {
+				t.Fatalf("expected the violations of %v, got %q", tt.keys, violations)
+			}
+			for i, key := range tt.keys {
+				if !strings.HasPrefix(violations[i], key+": ") {
+					t.Errorf("expected the violations of %v, got %q", tt.keys, violations)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_All(t *testing.T) {
+	cfg := validConfig()
+	cfg.HttpServer.Port = 0
+	cfg.Session.TTL = 0
+	cfg.RateLimit.Rate = 0
+
+	err := cfg.Validate()
+	if !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected %v, got %v", ErrInvalid, err)
+	}
+	for _, key := range []string{"HTTP_SERVER_PORT", "SESSION_TTL", "RATE_LIMIT_RATE"} {
+		if !strings.Contains(err.Error(), "\n"+key+": ") {
+			t.Errorf("expected the violation of %s, got %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Package auth is the domain of the authentication: the registration, the login by the password and the optional
+// TOTP, the issuance of the access tokens and the rotation of the refresh tokens.
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+)
+
+// ErrInvalidCredentials is the cause of the login failures, the unknown email and the wrong password aren't
+// distinguished, so the response doesn't reveal whether the account exists.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrInvalidRefreshToken is the cause of the refresh failures of the unknown, expired or revoked refresh tokens.
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// ErrRefreshTokenReused is the cause of the refresh failures of the already rotated refresh tokens, which means the
+// token is likely stolen, so its whole family is revoked.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// Credential is the password of a user, stored in the user_credentials table.
+type Credential struct {
+	UserID       idkit.UUID `sql:"user_id"`
+	PasswordHash string     `sql:"password_hash"` // see passwd.Hasher.
+	UpdatedAt    time.Time  `sql:"updated_at"`    // UTC.
+}
+
+// RefreshToken is an issued refresh token, stored in the refresh_tokens table. Only the hash of the token is stored,
+// so the leaked table can't be used for refreshing.
+type RefreshToken struct {
+	ID         idkit.UUID     `sql:"id"`
+	UserID     idkit.UUID     `sql:"user_id"`
+	FamilyID   idkit.UUID     `sql:"family_id"`   // the ID of the first token of the rotation chain.
+	TokenHash  string         `sql:"token_hash"`  // hex SHA-256 of the token.
+	CreatedAt  time.Time      `sql:"created_at"`  // UTC.
+	ExpiresAt  time.Time      `sql:"expires_at"`  // UTC.
+	RevokedAt  sql.NullTime   `sql:"revoked_at"`  // UTC, set when the token is rotated or revoked.
+	ReplacedBy idkit.NullUUID `sql:"replaced_by"` // the ID of the token rotated to.
+}
+
+// RegisterReq represents the request for registering a user.
+// swagger:model auth.RegisterReq
+type RegisterReq struct {
+	Email    string `json:"email" validate:"required,email,max=320" example:"alice@example.com"`
+	Name     string `json:"name" validate:"required,max=100" example:"Alice"`
+	Password string `json:"password" validate:"required" example:"correct horse battery staple"` // see passwd.Policy.
+} //@name auth.RegisterReq
+
+// LoginReq represents the request for logging in by the password.
+// swagger:model auth.LoginReq
+type LoginReq struct {
+	Email    string `json:"email" validate:"required,email" example:"alice@example.com"`
+	Password string `json:"password" validate:"required" example:"correct horse battery staple"`
+	OTP      string `json:"otp,omitempty" validate:"max=32" example:"123456"` // required if the TOTP is active.
+} //@name auth.LoginReq
+
+// RefreshReq represents the request for refreshing or revoking by the refresh token.
+// swagger:model auth.RefreshReq
+type RefreshReq struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=128"`
+} //@name auth.RefreshReq
+
+// TokenRes represents the issued tokens, like the OAuth 2.0 token response.
+// swagger:model auth.TokenRes
+type TokenRes struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type" example:"Bearer"`
+	ExpiresIn        int64  `json:"expires_in" example:"900"` // seconds.
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in" example:"2592000"` // seconds.
+} //@name auth.TokenRes
+
+// Res converts the tokens to their response model, the expiries are relative to now.
+func (p TokenPair) Res(now time.Time) TokenRes {
+	return TokenRes{
+		AccessToken:      p.Access.Value,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(p.Access.ExpiresAt.Sub(now).Seconds()),
+		RefreshToken:     p.Refresh.Value,
+		RefreshExpiresIn: int64(p.Refresh.ExpiresAt.Sub(now).Seconds()),
+	}
+}
+
+// unauthenticated creates an error that is mapped to 401 Unauthorized.
+func unauthenticated(detail string, cause error) error {
+	pd := problemdetail.New(business.PDTypeUnauthenticated,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("Unauthenticated"),
+		problemdetail.WithDetail(detail),
+	)
+	return fmt.Errorf("%w: %w", pd, cause)
+}
@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+// CredentialRepository stores the credentials in the user_credentials table. The methods return sqlxkit.ErrNotFound
+// if there is no such credential, e.g. the user is created by the admins without a password.
+type CredentialRepository struct {
+	crud *sqlxkit.Repository[Credential]
+}
+
+// NewCredentialRepository creates a new CredentialRepository.
+func NewCredentialRepository(db sqlxkit.DB) (*CredentialRepository, error) {
+	crud, err := sqlxkit.NewRepository[Credential](db, "user_credentials", "user_id")
+	if err != nil {
+		return nil, fmt.Errorf("create crud repository: %w", err)
+	}
+	return &CredentialRepository{crud: crud}, nil
+}
+
+// Insert inserts the credential.
+func (r *CredentialRepository) Insert(ctx context.Context, c Credential) error {
+	return sqlxkit.TranslateError(r.crud.Insert(ctx, c))
+}
+
+// Update updates the credential of the user.
+func (r *CredentialRepository) Update(ctx context.Context, c Credential) error {
+	return sqlxkit.TranslateError(r.crud.Update(ctx, c))
+}
+
+// Get gets the credential of the user.
+func (r *CredentialRepository) Get(ctx context.Context, userID idkit.UUID) (Credential, error) {
+	return r.crud.GetByID(ctx, userID)
+}
+
+// RefreshTokenRepository stores the refresh tokens in the refresh_tokens table. The methods return
+// sqlxkit.ErrNotFound if there is no such refresh token.
+type RefreshTokenRepository struct {
+	db          sqlxkit.DB
+	crud        *sqlxkit.Repository[RefreshToken]
+	getByHashQ  string
+	revokeQ     string
+	revokeFamQ  string
+	revokeUserQ string
+}
+
+// NewRefreshTokenRepository creates a new RefreshTokenRepository.
+func NewRefreshTokenRepository(db sqlxkit.DB) (*RefreshTokenRepository, error) {
+	crud, err := sqlxkit.NewRepository[RefreshToken](db, "refresh_tokens", "id")
+	if err != nil {
+		return nil, fmt.Errorf("create crud repository: %w", err)
+	}
+
+	const (
+		columns = "id, user_id, family_id, token_hash, created_at, expires_at, revoked_at, replaced_by"
+		active  = " AND revoked_at IS NULL"
+	)
+	return &RefreshTokenRepository{
+		db:          db,
+		crud:        crud,
+		getByHashQ:  db.Rebind("SELECT " + columns + " FROM refresh_tokens WHERE token_hash = ?"),
+		revokeQ:     db.Rebind("UPDATE refresh_tokens SET revoked_at = ?, replaced_by = ? WHERE id = ?" + active),
+		revokeFamQ:  db.Rebind("UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ?" + active),
+		revokeUserQ: db.Rebind("UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ?" + active),
+	}, nil
+}
+
+// Insert inserts the refresh token.
+func (r *RefreshTokenRepository) Insert(ctx context.Context, t RefreshToken) error {
+	return sqlxkit.TranslateError(r.crud.Insert(ctx, t))
+}
+
+// GetByHash gets the refresh token by the hash of the token.
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, hash string) (RefreshToken, error) {
+	t, err := sqlxkit.One[RefreshToken](ctx, sqlxkit.TxOrDB(ctx, r.db), r.getByHashQ, hash)
+	if err != nil {
+		return t, fmt.Errorf("get refresh token by hash: %w", err)
+	}
+	return t, nil
+}
+
+// Revoke revokes the refresh token identified by the id, recording the token it is replaced by if rotated. It returns
+// sqlxkit.ErrNotFound if there is no such token or it is already revoked, so only one of the concurrent rotations of
+// the same token wins.
+func (r *RefreshTokenRepository) Revoke(
+	ctx context.Context,
+	id idkit.UUID,
+	replacedBy idkit.NullUUID,
+	at time.Time,
+) error {
+	res, err := sqlxkit.TxOrDB(ctx, r.db).ExecContext(ctx, r.revokeQ, at, replacedBy, id)
+	if err != nil {
+		return fmt.Errorf("revoke refresh token: %w", err)
+	}
+	return expectAffected("revoke refresh token", res)
+}
+
+// RevokeFamily revokes the active refresh tokens of the rotation chain.
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID idkit.UUID, at time.Time) error {
+	if _, err := sqlxkit.TxOrDB(ctx, r.db).ExecContext(ctx, r.revokeFamQ, at, familyID); err != nil {
+		return fmt.Errorf("revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+// RevokeUser revokes the active refresh tokens of the user, e.g. on the password change.
+func (r *RefreshTokenRepository) RevokeUser(ctx context.Context, userID idkit.UUID, at time.Time) error {
+	if _, err := sqlxkit.TxOrDB(ctx, r.db).ExecContext(ctx, r.revokeUserQ, at, userID); err != nil {
+		return fmt.Errorf("revoke refresh tokens of user: %w", err)
+	}
+	return nil
+}
+
+// TOTPRepository stores the TOTP secrets in the user_totp table. The methods return sqlxkit.ErrNotFound if there is
+// no such secret.
+type TOTPRepository struct {
+	db             sqlxkit.DB
+	crud           *sqlxkit.Repository[TOTPCredential]
+	confirmQ       string
+	useStepQ       string
+	failQ          string
+	resetFailuresQ string
+}
+
+// NewTOTPRepository creates a new TOTPRepository.
+func NewTOTPRepository(db sqlxkit.DB) (*TOTPRepository, error) {
+	crud, err := sqlxkit.NewRepository[TOTPCredential](db, "user_totp", "user_id")
+	if err != nil {
+		return nil, fmt.Errorf("create crud repository: %w", err)
+	}
+	return &TOTPRepository{
+		db:       db,
+		crud:     crud,
+		confirmQ: db.Rebind("UPDATE user_totp SET confirmed_at = ? WHERE user_id = ? AND confirmed_at IS NULL"),
+		useStepQ: db.Rebind("UPDATE user_totp SET last_step = ? WHERE user_id = ? AND last_step < ?"),
+		failQ: db.Rebind(
+			"UPDATE user_totp SET " +
+				"failed_attempts = CASE WHEN failed_attempts + 1 >= ? THEN 0 ELSE failed_attempts + 1 END, " +
+				"locked_until = CASE WHEN failed_attempts + 1 >= ? THEN ? ELSE locked_until END " +
+				"WHERE user_id = ?",
+		),
+		resetFailuresQ: db.Rebind("UPDATE user_totp SET failed_attempts = 0, locked_until = NULL WHERE user_id = ?"),
+	}, nil
+}
+
+// Insert inserts the TOTP secret.
+func (r *TOTPRepository) Insert(ctx context.Context, c TOTPCredential) error {
+	return sqlxkit.TranslateError(r.crud.Insert(ctx, c))
+}
+
+// Update updates the TOTP secret of the user.
+func (r *TOTPRepository) Update(ctx context.Context, c TOTPCredential) error {
+	return sqlxkit.TranslateError(r.crud.Update(ctx, c))
+}
+
+// Delete deletes the TOTP secret of the user.
+func (r *TOTPRepository) Delete(ctx context.Context, userID idkit.UUID) error {
+	return r.crud.Delete(ctx, userID)
+}
+
+// Get gets the TOTP secret of the user.
+func (r *TOTPRepository) Get(ctx context.Context, userID idkit.UUID) (TOTPCredential, error) {
+	return r.crud.GetByID(ctx, userID)
+}
+
+// Confirm confirms the TOTP secret of the user. It returns sqlxkit.ErrNotFound if there is no such secret or it is
+// already confirmed.
+func (r *TOTPRepository) Confirm(ctx context.Context, userID idkit.UUID, at time.Time) error {
+	return r.exec(ctx, "confirm totp", r.confirmQ, at, userID)
+}
+
+// UseStep records the time step of the used TOTP code. It returns sqlxkit.ErrNotFound if the step isn't after the
+// last used one, i.e. the code is replayed.
+func (r *TOTPRepository) UseStep(ctx context.Context, userID idkit.UUID, step int64) error {
+	return r.exec(ctx, "use totp step", r.useStepQ, step, userID, step)
+}
+
+// Fail counts a wrong code of the user, the codes are locked out until lockUntil, and the count is reset, once the
+// count reaches maxAttempts. It returns sqlxkit.ErrNotFound if there is no such secret.
+func (r *TOTPRepository) Fail(ctx context.Context, userID idkit.UUID, maxAttempts int, lockUntil time.Time) error {
+	return r.exec(ctx, "count totp failure", r.failQ, maxAttempts, maxAttempts, lockUntil, userID)
+}
+
+// ResetFailures resets the count of the wrong codes of the user. It returns sqlxkit.ErrNotFound if there is no such
+// secret.
+func (r *TOTPRepository) ResetFailures(ctx context.Context, userID idkit.UUID) error {
+	return r.exec(ctx, "reset totp failures", r.resetFailuresQ, userID)
+}
+
+// exec executes the update query, it returns sqlxkit.ErrNotFound if no rows are affected.
+func (r *TOTPRepository) exec(ctx context.Context, op, query string, args ...any) error {
+	res, err := sqlxkit.TxOrDB(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return expectAffected(op, res)
+}
+
+// RecoveryCodeRepository stores the recovery codes in the user_recovery_codes table.
+type RecoveryCodeRepository struct {
+	db         sqlxkit.DB
+	crud       *sqlxkit.Repository[RecoveryCode]
+	useQ       string
+	deleteAllQ string
+}
+
+// NewRecoveryCodeRepository creates a new RecoveryCodeRepository.
+func NewRecoveryCodeRepository(db sqlxkit.DB) (*RecoveryCodeRepository, error) {
+	crud, err := sqlxkit.NewRepository[RecoveryCode](db, "user_recovery_codes", "code_hash")
+	if err != nil {
+		return nil, fmt.Errorf("create crud repository: %w", err)
+	}
+	return &RecoveryCodeRepository{
+		db:   db,
+		crud: crud,
+		useQ: db.Rebind(
+			"UPDATE user_recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL",
+		),
+		deleteAllQ: db.Rebind("DELETE FROM user_recovery_codes WHERE user_id = ?"),
+	}, nil
+}
+
+// Insert inserts the recovery code.
+func (r *RecoveryCodeRepository) Insert(ctx context.Context, c RecoveryCode) error {
+	return sqlxkit.TranslateError(r.crud.Insert(ctx, c))
+}
+
+// Use marks the recovery code of the user as used. It returns sqlxkit.ErrNotFound if there is no such code or it is
+// already used.
+func (r *RecoveryCodeRepository) Use(ctx context.Context, userID idkit.UUID, hash string, at time.Time) error {
+	res, err := sqlxkit.TxOrDB(ctx, r.db).ExecContext(ctx, r.useQ, at, userID, hash)
+	if err != nil {
+		return fmt.Errorf("use recovery code: %w", err)
+	}
+	return expectAffected("use recovery code", res)
+}
+
+// DeleteAll deletes all the recovery codes of the user.
+func (r *RecoveryCodeRepository) DeleteAll(ctx context.Context, userID idkit.UUID) error {
+	if _, err := sqlxkit.TxOrDB(ctx, r.db).ExecContext(ctx, r.deleteAllQ, userID); err != nil {
+		return fmt.Errorf("delete recovery codes: %w", err)
+	}
+	return nil
+}
+
+// expectAffected returns sqlxkit.ErrNotFound if the query affected no rows.
+func expectAffected(op string, res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: get affected rows: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, sqlxkit.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/pkg/passwd"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+// ServiceConfig is the configuration of the Service.
+type ServiceConfig struct {
+	Hasher *passwd.Hasher // Hashes and compares the passwords. Default passwd.Default().
+	Policy *passwd.Policy // Validates the passwords on registration. Default passwd.DefaultPolicy.
+	TOTP   *TOTPService   // Verifies the second factor on login if set. Default nil, the 2FA is disabled.
+}
+
+// withDefaults returns a copy of the config with default values for unset fields.
+func (c ServiceConfig) withDefaults() ServiceConfig {
+	if c.Hasher == nil {
+		c.Hasher = passwd.Default()
+	}
+	if c.Policy == nil {
+		c.Policy = &passwd.DefaultPolicy
+	}
+	return c
+}
+
+// Service registers the users with a password and logs them in, issuing the tokens by the TokenService. The
+// requests are expected to be validated by the caller, e.g. by the validate tags of RegisterReq and LoginReq.
+type Service struct {
+	db     sqlxkit.DB
+	users  *user.Service
+	creds  *CredentialRepository
+	tokens *TokenService
+	cfg    ServiceConfig
+	now    func() time.Time
+}
+
+// NewService creates a new Service, the users and their credentials are stored in the same database.
+func NewService(db sqlxkit.DB, users *user.Service, tokens *TokenService, cfg ServiceConfig) (*Service, error) {
+	creds, err := NewCredentialRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		db:     db,
+		users:  users,
+		creds:  creds,
+		tokens: tokens,
+		cfg:    cfg.withDefaults(),
+		now:    time.Now,
+	}, nil
+}
+
+// Register creates a user with the password. It returns passwd.Violations if the password violates the policy, or
+// the PDTypeEmailAlreadyTaken problem if the email is taken.
+func (s *Service) Register(ctx context.Context, req RegisterReq) (user.User, error) {
+	if err := s.cfg.Policy.ValidateContext(ctx, req.Password); err != nil {
+		return user.User{}, fmt.Errorf("validate password: %w", err)
+	}
+
+	// hash before the transaction, so the slow hashing doesn't hold it.
+	hash, err := s.cfg.Hasher.Hash(passwd.Password(req.Password))
+	if err != nil {
+		return user.User{}, fmt.Errorf("hash password: %w", err)
+	}
+
+	var u user.User
+	err = sqlxkit.ExecTransaction(ctx, s.db, func(ctx context.Context, _ sqlxkit.Tx) (context.Context, error) {
+		u, err = s.users.Create(ctx, user.CreateReq{Email: req.Email, Name: req.Name})
+		if err != nil {
+			return ctx, err
+		}
+		return ctx, s.creds.Insert(ctx, Credential{UserID: u.ID, PasswordHash: hash, UpdatedAt: u.CreatedAt})
+	})
+	if err != nil {
+		return user.User{}, fmt.Errorf("register user: %w", err)
+	}
+	return u, nil
+}
+
+// Login verifies the email and the password, and issues the tokens of the user. It returns the
+// PDTypeUnauthenticated problem wrapping ErrInvalidCredentials if either is wrong. If the TOTP of the user is active,
+// it returns the PDTypeOTPRequired problem if the OTP is empty, or the PDTypeUnauthenticated problem wrapping
+// ErrInvalidOTP if it is wrong. The password hash is upgraded if the hasher prefers another algorithm or cost, see
+// passwd.Hasher.NeedsRehash.
+func (s *Service) Login(ctx context.Context, req LoginReq) (TokenPair, error) {
+	u, err := s.users.GetByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			s.cfg.Hasher.CompareDummy()
+			return TokenPair{}, invalidCredentials(err)
+		}
+		return TokenPair{}, fmt.Errorf("get user: %w", err)
+	}
+
+	cred, err := s.creds.Get(ctx, u.ID)
+	if err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			s.cfg.Hasher.CompareDummy()
+			return TokenPair{}, invalidCredentials(err)
+		}
+		return TokenPair{}, fmt.Errorf("get credential: %w", err)
+	}
+
+	hash := passwd.Password(cred.PasswordHash)
+	if err := s.cfg.Hasher.Compare(hash, req.Password); err != nil {
+		return TokenPair{}, invalidCredentials(err)
+	}
+
+	// verified after the password, so the response doesn't reveal whether the 2FA is active to the strangers.
+	if s.cfg.TOTP != nil {
+		if err := s.cfg.TOTP.VerifyLogin(ctx, u.ID, req.OTP); err != nil {
+			return TokenPair{}, err
+		}
+	}
+
+	if s.cfg.Hasher.NeedsRehash(hash) {
+		// best-effort, the login succeeds regardless and the rehash is retried on the next login.
+		_ = s.rehash(ctx, cred, req.Password)
+	}
+
+	pair, err := s.tokens.Issue(ctx, u.ID)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("issue tokens: %w", err)
+	}
+	return pair, nil
+}
+
+// rehash updates the credential by the hash of the plain password.
+func (s *Service) rehash(ctx context.Context, cred Credential, plain string) error {
+	hash, err := s.cfg.Hasher.Hash(passwd.Password(plain))
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+
+	cred.PasswordHash = hash
+	cred.UpdatedAt = s.now().UTC().Truncate(time.Microsecond)
+	return s.creds.Update(ctx, cred)
+}
+
+// invalidCredentials creates an error that is mapped to 401 Unauthorized.
+func invalidCredentials(cause error) error {
+	return unauthenticated("the email or the password is incorrect", fmt.Errorf("%w: %w", ErrInvalidCredentials, cause))
+}
@@ -0,0 +1,143 @@
+//go:build cgo
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/pkg/passwd"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit/sqlxkittest"
+	"github.com/josestg/swe-be-mono/resources/migrations"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// _cheapArgon2id keeps the tests fast, the hashes are still valid Argon2id hashes.
+var _cheapArgon2id = passwd.Argon2id{Memory: 64, Time: 1, Parallelism: 1}
+
+func newTestService(t *testing.T, preferred passwd.HashComparer) *Service {
+	t.Helper()
+	db, teardown := sqlxkittest.Setup(t, migrations.Postgre())
+	t.Cleanup(teardown)
+
+	repo, err := user.NewRepository(db)
+	expectNoError(t, err)
+
+	tokens, err := NewTokenService(db, TokenConfig{Keys: map[string]string{"k1": "HS256:" + testSecret}, Issuer: "test"})
+	expectNoError(t, err)
+
+	svc, err := NewService(db, user.NewService(repo), tokens, ServiceConfig{
+		Hasher: passwd.NewHasher(passwd.NewMulti(preferred)),
+	})
+	expectNoError(t, err)
+	return svc
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, _cheapArgon2id)
+
+	alice, err := svc.Register(ctx, RegisterReq{Email: "Alice@Example.com", Name: "Alice", Password: "Tr0ub4dor&3x"})
+	expectNoError(t, err)
+	expectTrue(t, alice.Email == "alice@example.com")
+
+	_, err = svc.Register(ctx, RegisterReq{Email: "alice@example.com", Name: "Alice", Password: "Tr0ub4dor&3x"})
+	expectProblem(t, err, business.PDTypeEmailAlreadyTaken)
+
+	var violations passwd.Violations
+	_, err = svc.Register(ctx, RegisterReq{Email: "bob@example.com", Name: "Bob", Password: "password"})
+	expectTrue(t, errors.As(err, &violations))
+
+	// the user isn't created if the password is rejected.
+	_, err = svc.users.GetByEmail(ctx, "bob@example.com")
+	expectProblem(t, err, business.PDTypeUserNotFound)
+
+	pair, err := svc.Login(ctx, LoginReq{Email: "ALICE@example.com", Password: "Tr0ub4dor&3x"})
+	expectNoError(t, err)
+	expectTrue(t, pair.Access.ExpiresAt.After(time.Now()))
+	expectTrue(t, pair.Refresh.ExpiresAt.After(pair.Access.ExpiresAt))
+
+	var c claims
+	token, err := svc.tokens.KeySet().Parse(pair.Access.Value, &c, jwt.WithIssuer("test"))
+	expectNoError(t, err)
+	expectTrue(t, token.Header["kid"] == "k1" && c.Subject == alice.ID.String())
+
+	_, err = svc.Login(ctx, LoginReq{Email: "alice@example.com", Password: "wrong"})
+	expectProblem(t, err, business.PDTypeUnauthenticated)
+	expectTrue(t, errors.Is(err, ErrInvalidCredentials))
+
+	_, err = svc.Login(ctx, LoginReq{Email: "nobody@example.com", Password: "Tr0ub4dor&3x"})
+	expectProblem(t, err, business.PDTypeUnauthenticated)
+	expectTrue(t, errors.Is(err, ErrInvalidCredentials))
+}
+
+func TestService_Login_WithoutCredential(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, _cheapArgon2id)
+
+	// e.g. created by the admins.
+	_, err := svc.users.Create(ctx, user.CreateReq{Email: "alice@example.com", Name: "Alice"})
+	expectNoError(t, err)
+
+	_, err = svc.Login(ctx, LoginReq{Email: "alice@example.com", Password: "Tr0ub4dor&3x"})
+	expectTrue(t, errors.Is(err, ErrInvalidCredentials))
+}
+
+func TestService_Login_Rehash(t *testing.T) {
+	ctx := context.Background()
+	legacy := newTestService(t, _cheapArgon2id)
+
+	alice, err := legacy.Register(ctx, RegisterReq{Email: "alice@example.com", Name: "Alice", Password: "Tr0ub4dor&3x"})
+	expectNoError(t, err)
+
+	// the same database, hashing by the stronger parameters.
+	svc := *legacy
+	svc.cfg.Hasher = passwd.NewHasher(passwd.NewMulti(passwd.Argon2id{Memory: 128, Time: 1, Parallelism: 1}))
+
+	before, err := svc.creds.Get(ctx, alice.ID)
+	expectNoError(t, err)
+	expectTrue(t, svc.cfg.Hasher.NeedsRehash(passwd.Password(before.PasswordHash)))
+
+	_, err = svc.Login(ctx, LoginReq{Email: "alice@example.com", Password: "Tr0ub4dor&3x"})
+	expectNoError(t, err)
+
+	after, err := svc.creds.Get(ctx, alice.ID)
+	expectNoError(t, err)
+	expectTrue(t, after.PasswordHash != before.PasswordHash)
+	expectTrue(t, !svc.cfg.Hasher.NeedsRehash(passwd.Password(after.PasswordHash)))
+
+	_, err = svc.Login(ctx, LoginReq{Email: "alice@example.com", Password: "Tr0ub4dor&3x"})
+	expectNoError(t, err)
+}
+
+func expectProblem(t *testing.T, err error, pdType string) {
+	t.Helper()
+	var pd problemdetail.ProblemDetailer
+	if !errors.As(err, &pd) {
+		t.Fatalf("expected problem %s, got %v", pdType, err)
+	}
+	if pd.Kind() != pdType {
+		t.Fatalf("expected problem %s, got %s", pdType, pd.Kind())
+	}
+}
+
+func expectNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
@@ -0,0 +1,304 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/jwtkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+// TokenConfig is the configuration of the tokens issued by TokenService, the verifiers must share the same keys,
+// issuer and audience, see httpmiddleware.JWTAuthConfig. The keys should be given by the secret manager references,
+// e.g. AUTH_TOKEN_KEYS=vault://secret/data/auth#keys.
+type TokenConfig struct {
+	// Keys are the signing keys by their key ID in the form of <alg>:<key>, e.g. 2026-10=EdDSA:<base64 PKCS#8>, see
+	// jwtkit.ParseSigningKey. The retired keys are kept until the access tokens signed by them expire.
+	Keys map[string]string `env:"KEYS,secret"`
+
+	// KeyID is the ID of the key signing the new tokens, default is the only key if there is one.
+	KeyID string `env:"KEY_ID"`
+
+	Issuer     string        `env:"ISSUER"`                   // The iss claim, omitted if empty.
+	Audience   string        `env:"AUDIENCE"`                 // The aud claim, omitted if empty.
+	AccessTTL  time.Duration `env:"ACCESS_TTL,default=15m"`   // The lifetime of the access tokens. Default 15 minutes.
+	RefreshTTL time.Duration `env:"REFRESH_TTL,default=720h"` // The lifetime of the refresh tokens. Default 30 days.
+}
+
+// withDefaults returns a copy of the config with default values for unset fields.
+func (c TokenConfig) withDefaults() TokenConfig {
+	if c.KeyID == "" && len(c.Keys) == 1 {
+		for id := range c.Keys {
+			c.KeyID = id
+		}
+	}
+	if c.AccessTTL <= 0 {
+		c.AccessTTL = 15 * time.Minute
+	}
+	if c.RefreshTTL <= 0 {
+		c.RefreshTTL = 30 * 24 * time.Hour
+	}
+	return c
+}
+
+// Enabled reports whether the tokens can be issued, i.e. the keys are configured.
+func (c TokenConfig) Enabled() bool { return len(c.Keys) > 0 }
+
+// Validate checks the keys can be parsed, the HMAC secrets are at least 32 bytes, and the current key is configured.
+func (c TokenConfig) Validate() error {
+	_, err := c.KeySet()
+	return err
+}
+
+// KeySet creates the key set of the keys, signing by the current key.
+func (c TokenConfig) KeySet() (*jwtkit.KeySet, error) {
+	c = c.withDefaults()
+	var (
+		errs   []error
+		active jwtkit.SigningKey
+		others []jwtkit.SigningKey
+	)
+	for id, s := range c.Keys {
+		key, err := jwtkit.ParseSigningKey(id, s)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if secret, ok := key.Key.([]byte); ok && len(secret) < 32 {
+			errs = append(errs, fmt.Errorf("key %q: the secret must be at least 32 bytes, got %d", id, len(secret)))
+			continue
+		}
+		if id == c.KeyID {
+			active = key
+		} else {
+			others = append(others, key)
+		}
+	}
+	if _, ok := c.Keys[c.KeyID]; !ok {
+		errs = append(errs, fmt.Errorf("key %q is not configured", c.KeyID))
+	}
+	if len(errs) > 0 {
+		slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+		return nil, errors.Join(errs...)
+	}
+	return jwtkit.NewKeySet(active, others...)
+}
+
+// Token is an issued token, either an access or a refresh token.
+type Token struct {
+	Value     string
+	ExpiresAt time.Time
+}
+
+// TokenPair is a pair of the issued access and refresh tokens.
+type TokenPair struct {
+	Access  Token
+	Refresh Token
+}
+
+// claims is the claims of the access tokens, compatible with httpmiddleware.JWTClaims.
+type claims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope,omitempty"`
+}
+
+// TokenService issues the short-lived access tokens, signed by the key set, and the opaque refresh tokens, stored
+// server-side for revocation. A refresh token is rotated on every refresh: it is revoked and replaced by a new one of
+// the same family. Refreshing by a rotated token revokes the whole family, since either the client or an attacker
+// holds a stolen token, and they can't be told apart.
+type TokenService struct {
+	db      sqlxkit.DB
+	keys    *jwtkit.KeySet
+	refresh *RefreshTokenRepository
+	cfg     TokenConfig
+	now     func() time.Time
+}
+
+// NewTokenService creates a new TokenService, it returns an error if the config is invalid.
+func NewTokenService(db sqlxkit.DB, cfg TokenConfig) (*TokenService, error) {
+	cfg = cfg.withDefaults()
+	keys, err := cfg.KeySet()
+	if err != nil {
+		return nil, fmt.Errorf("create key set: %w", err)
+	}
+
+	refresh, err := NewRefreshTokenRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenService{
+		db:      db,
+		keys:    keys,
+		refresh: refresh,
+		cfg:     cfg,
+		now:     time.Now,
+	}, nil
+}
+
+// KeySet returns the key set signing the access tokens, e.g. for verifying them by httpmiddleware.JWTAuth, or for
+// rotating the keys at runtime.
+func (s *TokenService) KeySet() *jwtkit.KeySet { return s.keys }
+
+// Issue issues the tokens of the user, starting a new family of the refresh tokens, e.g. on login.
+func (s *TokenService) Issue(ctx context.Context, userID idkit.UUID) (TokenPair, error) {
+	id, err := idkit.FromContext(ctx).Request(ctx)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("request refresh token id: %w", err)
+	}
+	return s.issue(ctx, userID, idkit.UUID(id), idkit.UUID(id))
+}
+
+// Refresh issues new tokens by the refresh token, which is rotated. It returns the PDTypeUnauthenticated problem
+// wrapping ErrInvalidRefreshToken if the token is unknown, expired or revoked, or wrapping ErrRefreshTokenReused if
+// it is already rotated, in which case its family is revoked.
+func (s *TokenService) Refresh(ctx context.Context, refresh string) (TokenPair, error) {
+	old, err := s.refresh.GetByHash(ctx, hashRefreshToken(refresh))
+	if err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return TokenPair{}, invalidRefreshToken(err)
+		}
+		return TokenPair{}, fmt.Errorf("get refresh token: %w", err)
+	}
+
+	now := s.timestamp()
+	if old.ReplacedBy.Valid {
+		return TokenPair{}, s.reused(ctx, old, now)
+	}
+	if old.RevokedAt.Valid || !now.Before(old.ExpiresAt) {
+		return TokenPair{}, invalidRefreshToken(fmt.Errorf("refresh token %s is revoked or expired", old.ID))
+	}
+
+	id, err := idkit.FromContext(ctx).Request(ctx)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("request refresh token id: %w", err)
+	}
+
+	var pair TokenPair
+	err = sqlxkit.ExecTransaction(ctx, s.db, func(ctx context.Context, _ sqlxkit.Tx) (context.Context, error) {
+		replacedBy := idkit.NullUUID{UUID: idkit.UUID(id), Valid: true}
+		if err := s.refresh.Revoke(ctx, old.ID, replacedBy, now); err != nil {
+			return ctx, err
+		}
+		pair, err = s.issue(ctx, old.UserID, idkit.UUID(id), old.FamilyID)
+		return ctx, err
+	})
+	if err != nil {
+		// lost the race against a concurrent rotation of the same token.
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return TokenPair{}, s.reused(ctx, old, now)
+		}
+		return TokenPair{}, fmt.Errorf("rotate refresh token: %w", err)
+	}
+	return pair, nil
+}
+
+// Revoke revokes the family of the refresh token, e.g. on logout. The unknown and the already revoked tokens are
+// ignored, so the logout is idempotent.
+func (s *TokenService) Revoke(ctx context.Context, refresh string) error {
+	t, err := s.refresh.GetByHash(ctx, hashRefreshToken(refresh))
+	if err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("get refresh token: %w", err)
+	}
+	return s.refresh.RevokeFamily(ctx, t.FamilyID, s.timestamp())
+}
+
+// RevokeUser revokes all the refresh tokens of the user, e.g. on the password change. The issued access tokens remain
+// valid until they expire.
+func (s *TokenService) RevokeUser(ctx context.Context, userID idkit.UUID) error {
+	return s.refresh.RevokeUser(ctx, userID, s.timestamp())
+}
+
+// issue signs an access token and inserts a refresh token of the family.
+func (s *TokenService) issue(ctx context.Context, userID, id, familyID idkit.UUID) (TokenPair, error) {
+	now := s.timestamp()
+	access, err := s.sign(userID.String(), now)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	value, err := newRefreshToken()
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	t := RefreshToken{
+		ID:        id,
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashRefreshToken(value),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.cfg.RefreshTTL),
+	}
+	if err := s.refresh.Insert(ctx, t); err != nil {
+		return TokenPair{}, fmt.Errorf("insert refresh token: %w", err)
+	}
+	return TokenPair{Access: access, Refresh: Token{Value: value, ExpiresAt: t.ExpiresAt}}, nil
+}
+
+// sign signs an access token of the subject, e.g. the user ID.
+func (s *TokenService) sign(subject string, now time.Time) (Token, error) {
+	exp := now.Add(s.cfg.AccessTTL)
+	c := claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.cfg.Issuer,
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(exp),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	if s.cfg.Audience != "" {
+		c.Audience = jwt.ClaimStrings{s.cfg.Audience}
+	}
+
+	value, err := s.keys.Sign(c)
+	if err != nil {
+		return Token{}, fmt.Errorf("sign access token: %w", err)
+	}
+	return Token{Value: value, ExpiresAt: exp}, nil
+}
+
+// reused revokes the family of the reused refresh token, and returns the error of the refresh.
+func (s *TokenService) reused(ctx context.Context, t RefreshToken, now time.Time) error {
+	if err := s.refresh.RevokeFamily(ctx, t.FamilyID, now); err != nil {
+		return fmt.Errorf("revoke reused refresh token family: %w", err)
+	}
+	cause := fmt.Errorf("%w: refresh token %s of family %s", ErrRefreshTokenReused, t.ID, t.FamilyID)
+	return unauthenticated("the refresh token is invalid", cause)
+}
+
+// timestamp returns the current time in UTC, truncated to the precision of the database.
+func (s *TokenService) timestamp() time.Time { return s.now().UTC().Truncate(time.Microsecond) }
+
+// newRefreshToken generates an opaque refresh token of 256 random bits.
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate refresh token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashRefreshToken returns the hex SHA-256 of the refresh token. A fast hash is enough, since the token is random.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// invalidRefreshToken creates an error that is mapped to 401 Unauthorized.
+func invalidRefreshToken(cause error) error {
+	return unauthenticated("the refresh token is invalid", fmt.Errorf("%w: %w", ErrInvalidRefreshToken, cause))
+}
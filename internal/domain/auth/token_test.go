@@ -0,0 +1,121 @@
+//go:build cgo
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit/sqlxkittest"
+	"github.com/josestg/swe-be-mono/resources/migrations"
+)
+
+func newTestTokenService(t *testing.T) (*TokenService, idkit.UUID) {
+	t.Helper()
+	db, teardown := sqlxkittest.Setup(t, migrations.Postgre())
+	t.Cleanup(teardown)
+
+	repo, err := user.NewRepository(db)
+	expectNoError(t, err)
+
+	u, err := user.NewService(repo).Create(context.Background(), user.CreateReq{Email: "alice@example.com", Name: "Alice"})
+	expectNoError(t, err)
+
+	svc, err := NewTokenService(db, TokenConfig{Keys: map[string]string{"k1": "HS256:" + testSecret}})
+	expectNoError(t, err)
+	return svc, u.ID
+}
+
+func TestTokenConfig_Validate(t *testing.T) {
+	valid := []TokenConfig{
+		{Keys: map[string]string{"k1": "HS256:" + testSecret}},
+		{Keys: map[string]string{"k1": "HS256:" + testSecret, "k2": "HS512:" + testSecret}, KeyID: "k2"},
+	}
+	for _, cfg := range valid {
+		expectNoError(t, cfg.Validate())
+	}
+
+	invalid := []TokenConfig{
+		{Keys: map[string]string{"k1": "HS256:short"}},
+		{Keys: map[string]string{"k1": "none:" + testSecret}},
+		{Keys: map[string]string{"k1": "HS256:" + testSecret, "k2": "HS256:" + testSecret}}, // which one is current?
+		{Keys: map[string]string{"k1": "HS256:" + testSecret}, KeyID: "k2"},
+	}
+	for _, cfg := range invalid {
+		expectTrue(t, cfg.Validate() != nil)
+	}
+}
+
+func TestTokenService_Refresh(t *testing.T) {
+	ctx := context.Background()
+	svc, userID := newTestTokenService(t)
+
+	first, err := svc.Issue(ctx, userID)
+	expectNoError(t, err)
+
+	second, err := svc.Refresh(ctx, first.Refresh.Value)
+	expectNoError(t, err)
+	expectTrue(t, second.Refresh.Value != first.Refresh.Value)
+
+	third, err := svc.Refresh(ctx, second.Refresh.Value)
+	expectNoError(t, err)
+
+	_, err = svc.Refresh(ctx, "unknown")
+	expectProblem(t, err, business.PDTypeUnauthenticated)
+	expectTrue(t, errors.Is(err, ErrInvalidRefreshToken))
+
+	// reusing a rotated token revokes the family, including the latest token.
+	_, err = svc.Refresh(ctx, first.Refresh.Value)
+	expectProblem(t, err, business.PDTypeUnauthenticated)
+	expectTrue(t, errors.Is(err, ErrRefreshTokenReused))
+
+	_, err = svc.Refresh(ctx, third.Refresh.Value)
+	expectTrue(t, errors.Is(err, ErrInvalidRefreshToken))
+
+	// the other families aren't affected.
+	other, err := svc.Issue(ctx, userID)
+	expectNoError(t, err)
+	_, err = svc.Refresh(ctx, other.Refresh.Value)
+	expectNoError(t, err)
+}
+
+func TestTokenService_Refresh_Expired(t *testing.T) {
+	ctx := context.Background()
+	svc, userID := newTestTokenService(t)
+
+	pair, err := svc.Issue(ctx, userID)
+	expectNoError(t, err)
+
+	svc.now = func() time.Time { return time.Now().Add(svc.cfg.RefreshTTL) }
+	_, err = svc.Refresh(ctx, pair.Refresh.Value)
+	expectTrue(t, errors.Is(err, ErrInvalidRefreshToken))
+}
+
+func TestTokenService_Revoke(t *testing.T) {
+	ctx := context.Background()
+	svc, userID := newTestTokenService(t)
+
+	first, err := svc.Issue(ctx, userID)
+	expectNoError(t, err)
+	second, err := svc.Issue(ctx, userID)
+	expectNoError(t, err)
+
+	expectNoError(t, svc.Revoke(ctx, first.Refresh.Value))
+	expectNoError(t, svc.Revoke(ctx, first.Refresh.Value)) // idempotent.
+	expectNoError(t, svc.Revoke(ctx, "unknown"))
+
+	_, err = svc.Refresh(ctx, first.Refresh.Value)
+	expectTrue(t, errors.Is(err, ErrInvalidRefreshToken))
+
+	second, err = svc.Refresh(ctx, second.Refresh.Value)
+	expectNoError(t, err)
+
+	expectNoError(t, svc.RevokeUser(ctx, userID))
+	_, err = svc.Refresh(ctx, second.Refresh.Value)
+	expectTrue(t, errors.Is(err, ErrInvalidRefreshToken))
+}
@@ -0,0 +1,492 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/otpkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+// RecoveryCodeCount is the number of the recovery codes generated on confirming the TOTP.
+const RecoveryCodeCount = 10
+
+// ErrInvalidOTP is the cause of the failures of the wrong, expired or replayed TOTP codes and the unknown or used
+// recovery codes.
+var ErrInvalidOTP = errors.New("invalid one-time password")
+
+// TOTPConfig is the configuration of the TOTP two-factor authentication, which is opt-in for the users.
+type TOTPConfig struct {
+	// Key is the key for encrypting the TOTP secrets at rest by AES-256-GCM, it should be at least 32 random bytes,
+	// e.g. in base64. Changing it invalidates all the secrets.
+	Key string `env:"KEY,secret"`
+
+	// Issuer is the name of the service shown by the authenticator apps. Default swe-be-mono.
+	Issuer string `env:"ISSUER,default=swe-be-mono"`
+
+	// MaxAttempts is the number of the consecutive wrong codes of a user before the codes are locked out. Default 5.
+	MaxAttempts int `env:"MAX_ATTEMPTS,default=5"`
+
+	// Lockout is how long the codes of a user are rejected after too many wrong codes. Default 15 minutes.
+	Lockout time.Duration `env:"LOCKOUT,default=15m"`
+}
+
+// withDefaults returns a copy of the config with default values for unset fields.
+func (c TOTPConfig) withDefaults() TOTPConfig {
+	if c.Issuer == "" {
+		c.Issuer = "swe-be-mono"
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.Lockout <= 0 {
+		c.Lockout = 15 * time.Minute
+	}
+	return c
+}
+
+// Enabled reports whether the TOTP is available, i.e. the key is set.
+func (c TOTPConfig) Enabled() bool { return c.Key != "" }
+
+// TOTPCredential is the TOTP secret of a user, stored in the user_totp table.
+type TOTPCredential struct {
+	UserID      idkit.UUID   `sql:"user_id"`
+	Secret      string       `sql:"secret"`       // AES-GCM sealed, base64.
+	LastStep    int64        `sql:"last_step"`    // the last used time step, see otpkit.TOTP.Validate.
+	ConfirmedAt sql.NullTime `sql:"confirmed_at"` // UTC, the TOTP is required on login once it is confirmed.
+	CreatedAt   time.Time    `sql:"created_at"`   // UTC.
+
+	FailedAttempts int          `sql:"failed_attempts"` // the consecutive wrong codes, reset by a right one.
+	LockedUntil    sql.NullTime `sql:"locked_until"`    // UTC, the codes are rejected until then.
+}
+
+// RecoveryCode is a single-use recovery code of a user, stored in the user_recovery_codes table.
+type RecoveryCode struct {
+	UserID    idkit.UUID   `sql:"user_id"`
+	CodeHash  string       `sql:"code_hash"`  // see otpkit.HashRecoveryCode.
+	UsedAt    sql.NullTime `sql:"used_at"`    // UTC.
+	CreatedAt time.Time    `sql:"created_at"` // UTC.
+}
+
+// TOTPCodeReq represents the request carrying a one-time password.
+// swagger:model auth.TOTPCodeReq
+type TOTPCodeReq struct {
+	Code string `json:"code" validate:"required,max=32" example:"123456"` // the TOTP code or a recovery code.
+} //@name auth.TOTPCodeReq
+
+// TOTPSetup is a provisioned, not yet confirmed, TOTP secret.
+// swagger:model auth.TOTPSetup
+type TOTPSetup struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"` // base32, for entering it manually.
+	URI    string `json:"uri"`                               // the otpauth:// URI, the payload of the QR code.
+} //@name auth.TOTPSetup
+
+// RecoveryCodesRes represents the generated recovery codes, they are shown only once.
+// swagger:model auth.RecoveryCodesRes
+type RecoveryCodesRes struct {
+	Codes []string `json:"codes" example:"ABCD-EFGH-IJKL-MNOP"`
+} //@name auth.RecoveryCodesRes
+
+// TOTPService manages the TOTP two-factor authentication of the users: the secret is provisioned by Setup, and the
+// TOTP is activated by Confirm with a code of the authenticator app, which also generates the recovery codes. Once
+// activated, Service.Login requires a TOTP code or a recovery code.
+type TOTPService struct {
+	db    sqlxkit.DB
+	users *user.Service
+	totps *TOTPRepository
+	codes *RecoveryCodeRepository
+	otp   *otpkit.TOTP
+	aead  cipher.AEAD
+	cfg   TOTPConfig
+	now   func() time.Time
+}
+
+// NewTOTPService creates a new TOTPService, it returns an error if the key is empty.
+func NewTOTPService(db sqlxkit.DB, users *user.Service, cfg TOTPConfig) (*TOTPService, error) {
+	if !cfg.Enabled() {
+		return nil, errors.New("totp key is not set")
+	}
+
+	key := sha256.Sum256([]byte(cfg.Key))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm: %w", err)
+	}
+
+	totps, err := NewTOTPRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := NewRecoveryCodeRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TOTPService{
+		db:    db,
+		users: users,
+		totps: totps,
+		codes: codes,
+		otp:   otpkit.New(otpkit.Config{}),
+		aead:  aead,
+		cfg:   cfg.withDefaults(),
+		now:   time.Now,
+	}, nil
+}
+
+// Setup provisions a new TOTP secret of the user, replacing the unconfirmed one if any. It returns the
+// PDTypeTOTPAlreadyActive problem if the TOTP is already confirmed.
+func (s *TOTPService) Setup(ctx context.Context, userID idkit.UUID) (TOTPSetup, error) {
+	u, err := s.users.Get(ctx, userID)
+	if err != nil {
+		return TOTPSetup{}, fmt.Errorf("get user: %w", err)
+	}
+
+	existing, err := s.totps.Get(ctx, userID)
+	found := err == nil
+	if err != nil && !errors.Is(err, sqlxkit.ErrNotFound) {
+		return TOTPSetup{}, fmt.Errorf("get totp: %w", err)
+	}
+	if found && existing.ConfirmedAt.Valid {
+		return TOTPSetup{}, totpAlreadyActive(errors.New("totp is confirmed"))
+	}
+
+	secret, err := otpkit.GenerateSecret()
+	if err != nil {
+		return TOTPSetup{}, err
+	}
+	sealed, err := s.seal(userID, secret)
+	if err != nil {
+		return TOTPSetup{}, err
+	}
+
+	cred := TOTPCredential{UserID: userID, Secret: sealed, CreatedAt: s.timestamp()}
+	if found {
+		// a new secret doesn't lift the lockout.
+		cred.FailedAttempts, cred.LockedUntil = existing.FailedAttempts, existing.LockedUntil
+		err = s.totps.Update(ctx, cred)
+	} else {
+		err = s.totps.Insert(ctx, cred)
+	}
+	if err != nil {
+		return TOTPSetup{}, fmt.Errorf("save totp: %w", err)
+	}
+
+	return TOTPSetup{
+		Secret: otpkit.EncodeSecret(secret),
+		URI:    s.otp.URI(secret, s.cfg.Issuer, u.Email),
+	}, nil
+}
+
+// Confirm activates the TOTP of the user by a code of the authenticator app, and generates the recovery codes. It
+// returns ErrInvalidOTP if the code is wrong, the PDTypeTOTPNotSetUp problem if the secret isn't provisioned, or the
+// PDTypeTOTPAlreadyActive problem if it is already confirmed. Like the other verifications of the codes, it returns
+// the PDTypeTooManyRequests problem while the codes of the user are locked out.
+func (s *TOTPService) Confirm(ctx context.Context, userID idkit.UUID, code string) ([]string, error) {
+	cred, err := s.get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if cred.ConfirmedAt.Valid {
+		return nil, totpAlreadyActive(errors.New("totp is confirmed"))
+	}
+	if err := s.guard(ctx, cred, func() error { return s.verifyTOTP(ctx, cred, code) }); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = sqlxkit.ExecTransaction(ctx, s.db, func(ctx context.Context, _ sqlxkit.Tx) (context.Context, error) {
+		if err := s.totps.Confirm(ctx, userID, s.timestamp()); err != nil {
+			return ctx, err
+		}
+		codes, err = s.replaceRecoveryCodes(ctx, userID)
+		return ctx, err
+	})
+	if err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return nil, totpAlreadyActive(err) // confirmed concurrently.
+		}
+		return nil, fmt.Errorf("confirm totp: %w", err)
+	}
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, verified by a TOTP code or a recovery code. It
+// returns ErrInvalidOTP if the code is wrong, or the PDTypeTOTPNotSetUp problem if the TOTP isn't active.
+func (s *TOTPService) RegenerateRecoveryCodes(ctx context.Context, userID idkit.UUID, code string) ([]string, error) {
+	cred, err := s.getActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verify(ctx, cred, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = sqlxkit.ExecTransaction(ctx, s.db, func(ctx context.Context, _ sqlxkit.Tx) (context.Context, error) {
+		codes, err = s.replaceRecoveryCodes(ctx, userID)
+		return ctx, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("regenerate recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// Disable deactivates the TOTP of the user, verified by a TOTP code or a recovery code, and deletes the recovery
+// codes. It returns ErrInvalidOTP if the code is wrong, or the PDTypeTOTPNotSetUp problem if the TOTP isn't active.
+func (s *TOTPService) Disable(ctx context.Context, userID idkit.UUID, code string) error {
+	cred, err := s.getActive(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.verify(ctx, cred, code); err != nil {
+		return err
+	}
+
+	err = sqlxkit.ExecTransaction(ctx, s.db, func(ctx context.Context, _ sqlxkit.Tx) (context.Context, error) {
+		if err := s.totps.Delete(ctx, userID); err != nil {
+			return ctx, err
+		}
+		return ctx, s.codes.DeleteAll(ctx, userID)
+	})
+	if err != nil {
+		return fmt.Errorf("disable totp: %w", err)
+	}
+	return nil
+}
+
+// Active reports whether the TOTP of the user is confirmed, i.e. it is required on sign-in.
+func (s *TOTPService) Active(ctx context.Context, userID idkit.UUID) (bool, error) {
+	cred, err := s.totps.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("get totp: %w", err)
+	}
+	return cred.ConfirmedAt.Valid, nil
+}
+
+// VerifyLogin verifies the second factor of the user on sign-in, if the TOTP is active. It returns the
+// PDTypeOTPRequired problem if the code is empty, the PDTypeUnauthenticated problem wrapping ErrInvalidOTP if it is
+// wrong, or the PDTypeTooManyRequests problem while the codes of the user are locked out after too many wrong ones.
+func (s *TOTPService) VerifyLogin(ctx context.Context, userID idkit.UUID, code string) error {
+	cred, err := s.totps.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("get totp: %w", err)
+	}
+	if !cred.ConfirmedAt.Valid {
+		return nil
+	}
+
+	if code == "" {
+		return otpRequired()
+	}
+	if err := s.verify(ctx, cred, code); err != nil {
+		if errors.Is(err, ErrInvalidOTP) {
+			return unauthenticated("the one-time password is incorrect", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// verify verifies the code as a recovery code if it looks like one, otherwise as a TOTP code. The used recovery
+// code is consumed.
+func (s *TOTPService) verify(ctx context.Context, cred TOTPCredential, code string) error {
+	return s.guard(ctx, cred, func() error { return s.verifyCode(ctx, cred, code) })
+}
+
+// guard runs the verification of a code unless the codes of the user are locked out. The wrong codes are counted,
+// and the codes are locked out for the lockout duration once the max attempts is reached, so the codes can't be
+// brute-forced. A right code resets the count.
+func (s *TOTPService) guard(ctx context.Context, cred TOTPCredential, verify func() error) error {
+	now := s.timestamp()
+	if cred.LockedUntil.Valid && now.Before(cred.LockedUntil.Time) {
+		return totpLocked(cred.LockedUntil.Time)
+	}
+
+	err := verify()
+	switch {
+	case errors.Is(err, ErrInvalidOTP):
+		lockUntil := now.Add(s.cfg.Lockout)
+		if failErr := s.totps.Fail(ctx, cred.UserID, s.cfg.MaxAttempts, lockUntil); failErr != nil {
+			return errors.Join(err, fmt.Errorf("count failed attempt: %w", failErr))
+		}
+		return err
+	case err != nil:
+		return err
+	case cred.FailedAttempts > 0:
+		if err := s.totps.ResetFailures(ctx, cred.UserID); err != nil {
+			return fmt.Errorf("reset failed attempts: %w", err)
+		}
+	}
+	return nil
+}
+
+// verifyCode verifies the code as a recovery code if it looks like one, otherwise as a TOTP code.
+func (s *TOTPService) verifyCode(ctx context.Context, cred TOTPCredential, code string) error {
+	if !otpkit.IsRecoveryCode(code) {
+		return s.verifyTOTP(ctx, cred, code)
+	}
+
+	err := s.codes.Use(ctx, cred.UserID, otpkit.HashRecoveryCode(code), s.timestamp())
+	if err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return fmt.Errorf("%w: unknown or used recovery code", ErrInvalidOTP)
+		}
+		return fmt.Errorf("use recovery code: %w", err)
+	}
+	return nil
+}
+
+// verifyTOTP verifies the TOTP code, and records its time step, so the code can't be replayed.
+func (s *TOTPService) verifyTOTP(ctx context.Context, cred TOTPCredential, code string) error {
+	secret, err := s.open(cred)
+	if err != nil {
+		return err
+	}
+
+	step, ok := s.otp.Validate(secret, code, s.now())
+	if !ok {
+		return fmt.Errorf("%w: wrong or expired totp code", ErrInvalidOTP)
+	}
+	if err := s.totps.UseStep(ctx, cred.UserID, step); err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return fmt.Errorf("%w: replayed totp code", ErrInvalidOTP)
+		}
+		return fmt.Errorf("use totp step: %w", err)
+	}
+	return nil
+}
+
+// replaceRecoveryCodes deletes the recovery codes of the user and generates new ones, it should run in a
+// transaction.
+func (s *TOTPService) replaceRecoveryCodes(ctx context.Context, userID idkit.UUID) ([]string, error) {
+	codes, err := otpkit.NewRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.codes.DeleteAll(ctx, userID); err != nil {
+		return nil, err
+	}
+	now := s.timestamp()
+	for _, code := range codes {
+		rc := RecoveryCode{UserID: userID, CodeHash: otpkit.HashRecoveryCode(code), CreatedAt: now}
+		if err := s.codes.Insert(ctx, rc); err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// get gets the TOTP of the user, it returns the PDTypeTOTPNotSetUp problem if there is none.
+func (s *TOTPService) get(ctx context.Context, userID idkit.UUID) (TOTPCredential, error) {
+	cred, err := s.totps.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return cred, totpNotSetUp(err)
+		}
+		return cred, fmt.Errorf("get totp: %w", err)
+	}
+	return cred, nil
+}
+
+// getActive gets the confirmed TOTP of the user, it returns the PDTypeTOTPNotSetUp problem if there is none.
+func (s *TOTPService) getActive(ctx context.Context, userID idkit.UUID) (TOTPCredential, error) {
+	cred, err := s.get(ctx, userID)
+	if err != nil {
+		return cred, err
+	}
+	if !cred.ConfirmedAt.Valid {
+		return cred, totpNotSetUp(errors.New("totp is not confirmed"))
+	}
+	return cred, nil
+}
+
+// seal encrypts the secret, bound to the user, so the sealed secrets can't be swapped between the users.
+func (s *TOTPService) seal(userID idkit.UUID, secret []byte) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := s.aead.Seal(nonce, nonce, secret, userID[:])
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts the secret of the credential.
+func (s *TOTPService) open(cred TOTPCredential) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(cred.Secret)
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return nil, fmt.Errorf("decode totp secret of user %s: malformed", cred.UserID)
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	secret, err := s.aead.Open(nil, nonce, ciphertext, cred.UserID[:])
+	if err != nil {
+		return nil, fmt.Errorf("decrypt totp secret of user %s: %w", cred.UserID, err)
+	}
+	return secret, nil
+}
+
+// timestamp returns the current time in UTC, truncated to the precision of the database.
+func (s *TOTPService) timestamp() time.Time { return s.now().UTC().Truncate(time.Microsecond) }
+
+// otpRequired creates an error that is mapped to 401 Unauthorized, asking for the one-time password.
+func otpRequired() error {
+	return problemdetail.New(business.PDTypeOTPRequired,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("OTP Required"),
+		problemdetail.WithDetail("the code of the authenticator app or a recovery code is required"),
+	)
+}
+
+// totpLocked creates an error that is mapped to 429 Too Many Requests.
+func totpLocked(until time.Time) error {
+	return problemdetail.New(business.PDTypeTooManyRequests,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("Too Many Requests"),
+		problemdetail.WithDetail(fmt.Sprintf("too many wrong one-time passwords, try again after %s",
+			until.Format(time.RFC3339))),
+	)
+}
+
+// totpNotSetUp creates an error that is mapped to 404 Not Found.
+func totpNotSetUp(cause error) error {
+	pd := problemdetail.New(business.PDTypeTOTPNotSetUp,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("TOTP Not Set Up"),
+		problemdetail.WithDetail("the two-factor authentication is not set up"),
+	)
+	return fmt.Errorf("%w: %w", pd, cause)
+}
+
+// totpAlreadyActive creates an error that is mapped to 409 Conflict.
+func totpAlreadyActive(cause error) error {
+	pd := problemdetail.New(business.PDTypeTOTPAlreadyActive,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("TOTP Already Active"),
+		problemdetail.WithDetail("the two-factor authentication is already active, disable it first"),
+	)
+	return fmt.Errorf("%w: %w", pd, cause)
+}
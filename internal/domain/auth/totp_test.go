@@ -0,0 +1,168 @@
+//go:build cgo
+
+package auth
+
+import (
+	"context"
+	"encoding/base32"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/pkg/otpkit"
+)
+
+// newTestTOTPService creates a Service with the TOTP, and the clock of the TOTP is set to the returned pointer.
+func newTestTOTPService(t *testing.T) (*Service, *TOTPService, *time.Time) {
+	t.Helper()
+	svc := newTestService(t, _cheapArgon2id)
+
+	totp, err := NewTOTPService(svc.db, svc.users, TOTPConfig{Key: testSecret, Issuer: "Acme"})
+	expectNoError(t, err)
+
+	now := time.Unix(1_700_000_000, 0)
+	totp.now = func() time.Time { return now }
+	svc.cfg.TOTP = totp
+	return svc, totp, &now
+}
+
+// totpCode returns the code of the base32 secret at the time.
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	b, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	expectNoError(t, err)
+	return otpkit.New(otpkit.Config{}).Code(b, at)
+}
+
+func TestTOTPService(t *testing.T) {
+	ctx := context.Background()
+	svc, totp, now := newTestTOTPService(t)
+
+	alice, err := svc.Register(ctx, RegisterReq{Email: "alice@example.com", Name: "Alice", Password: "Tr0ub4dor&3x"})
+	expectNoError(t, err)
+	login := LoginReq{Email: "alice@example.com", Password: "Tr0ub4dor&3x"}
+
+	_, err = totp.Confirm(ctx, alice.ID, "123456")
+	expectProblem(t, err, business.PDTypeTOTPNotSetUp)
+
+	setup, err := totp.Setup(ctx, alice.ID)
+	expectNoError(t, err)
+
+	uri, err := url.Parse(setup.URI)
+	expectNoError(t, err)
+	expectTrue(t, uri.Query().Get("secret") == setup.Secret && uri.Path == "/Acme:alice@example.com")
+
+	// the TOTP isn't required until it is confirmed.
+	_, err = svc.Login(ctx, login)
+	expectNoError(t, err)
+
+	_, err = totp.Confirm(ctx, alice.ID, "000000")
+	expectTrue(t, errors.Is(err, ErrInvalidOTP))
+
+	codes, err := totp.Confirm(ctx, alice.ID, totpCode(t, setup.Secret, *now))
+	expectNoError(t, err)
+	expectTrue(t, len(codes) == RecoveryCodeCount)
+
+	_, err = totp.Setup(ctx, alice.ID)
+	expectProblem(t, err, business.PDTypeTOTPAlreadyActive)
+
+	_, err = svc.Login(ctx, login)
+	expectProblem(t, err, business.PDTypeOTPRequired)
+
+	// the code used by the confirmation can't be replayed.
+	login.OTP = totpCode(t, setup.Secret, *now)
+	_, err = svc.Login(ctx, login)
+	expectProblem(t, err, business.PDTypeUnauthenticated)
+	expectTrue(t, errors.Is(err, ErrInvalidOTP))
+
+	*now = now.Add(30 * time.Second)
+	login.OTP = totpCode(t, setup.Secret, *now)
+	_, err = svc.Login(ctx, login)
+	expectNoError(t, err)
+
+	// the recovery codes are single-use, and accepted however they are typed.
+	login.OTP = " " + otpkit.NormalizeRecoveryCode(codes[0])
+	_, err = svc.Login(ctx, login)
+	expectNoError(t, err)
+	_, err = svc.Login(ctx, login)
+	expectTrue(t, errors.Is(err, ErrInvalidOTP))
+
+	regenerated, err := totp.RegenerateRecoveryCodes(ctx, alice.ID, codes[1])
+	expectNoError(t, err)
+	_, err = totp.RegenerateRecoveryCodes(ctx, alice.ID, codes[2]) // replaced.
+	expectTrue(t, errors.Is(err, ErrInvalidOTP))
+
+	expectNoError(t, totp.Disable(ctx, alice.ID, regenerated[0]))
+	expectProblem(t, totp.Disable(ctx, alice.ID, regenerated[1]), business.PDTypeTOTPNotSetUp)
+
+	login.OTP = ""
+	_, err = svc.Login(ctx, login)
+	expectNoError(t, err)
+}
+
+func TestTOTPService_SecretBoundToUser(t *testing.T) {
+	ctx := context.Background()
+	svc, totp, now := newTestTOTPService(t)
+
+	alice, err := svc.users.Create(ctx, user.CreateReq{Email: "alice@example.com", Name: "Alice"})
+	expectNoError(t, err)
+	bob, err := svc.users.Create(ctx, user.CreateReq{Email: "bob@example.com", Name: "Bob"})
+	expectNoError(t, err)
+
+	setup, err := totp.Setup(ctx, alice.ID)
+	expectNoError(t, err)
+	_, err = totp.Setup(ctx, bob.ID)
+	expectNoError(t, err)
+
+	// the sealed secret of alice copied to bob can't be opened.
+	cred, err := totp.totps.Get(ctx, alice.ID)
+	expectNoError(t, err)
+	cred.UserID = bob.ID
+	expectNoError(t, totp.totps.Update(ctx, cred))
+
+	_, err = totp.Confirm(ctx, bob.ID, totpCode(t, setup.Secret, *now))
+	expectTrue(t, err != nil && !errors.Is(err, ErrInvalidOTP))
+}
+
+func TestTOTPService_Lockout(t *testing.T) {
+	ctx := context.Background()
+	svc, totp, now := newTestTOTPService(t)
+	totp.cfg.MaxAttempts = 3
+
+	alice, err := svc.Register(ctx, RegisterReq{Email: "alice@example.com", Name: "Alice", Password: "Tr0ub4dor&3x"})
+	expectNoError(t, err)
+
+	setup, err := totp.Setup(ctx, alice.ID)
+	expectNoError(t, err)
+	_, err = totp.Confirm(ctx, alice.ID, totpCode(t, setup.Secret, *now))
+	expectNoError(t, err)
+
+	login := LoginReq{Email: "alice@example.com", Password: "Tr0ub4dor&3x", OTP: "000000"}
+
+	// a right code resets the count of the wrong ones.
+	for i := 0; i < 2; i++ {
+		_, err = svc.Login(ctx, login)
+		expectTrue(t, errors.Is(err, ErrInvalidOTP))
+	}
+	*now = now.Add(30 * time.Second)
+	_, err = svc.Login(ctx, LoginReq{Email: login.Email, Password: login.Password, OTP: totpCode(t, setup.Secret, *now)})
+	expectNoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = svc.Login(ctx, login)
+		expectTrue(t, errors.Is(err, ErrInvalidOTP))
+	}
+
+	// even the right code is rejected while locked out.
+	*now = now.Add(30 * time.Second)
+	_, err = svc.Login(ctx, LoginReq{Email: login.Email, Password: login.Password, OTP: totpCode(t, setup.Secret, *now)})
+	expectProblem(t, err, business.PDTypeTooManyRequests)
+	expectProblem(t, totp.Disable(ctx, alice.ID, "000000"), business.PDTypeTooManyRequests)
+
+	*now = now.Add(15 * time.Minute)
+	_, err = svc.Login(ctx, LoginReq{Email: login.Email, Password: login.Password, OTP: totpCode(t, setup.Secret, *now)})
+	expectNoError(t, err)
+}
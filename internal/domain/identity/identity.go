@@ -0,0 +1,110 @@
+// Package identity is the domain of the external identities: the sign-in by the OAuth 2.0 and OpenID Connect
+// providers, e.g. Google and GitHub, and linking their users to the local users.
+package identity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+)
+
+// ErrUnverifiedEmail is the cause of the failed sign-ins of the external users without a verified email, which can't
+// be linked to the local users safely.
+var ErrUnverifiedEmail = errors.New("the email isn't verified by the provider")
+
+// ErrProviderAuthorization is the cause of the failed sign-ins rejected by the provider, e.g. an expired or replayed
+// authorization code.
+var ErrProviderAuthorization = errors.New("the provider rejected the authorization")
+
+// ErrSignInExpired is the cause of the failed completions of the pending sign-ins that have expired, see
+// PendingSignIn.
+var ErrSignInExpired = errors.New("the pending sign-in has expired")
+
+// PendingSignInTTL is how long a pending sign-in waits for the one-time password of the user.
+const PendingSignInTTL = 5 * time.Minute
+
+// Config is the configuration of the providers, a provider is enabled when its client is configured.
+type Config struct {
+	Google ProviderConfig `env:"GOOGLE"`
+	GitHub ProviderConfig `env:"GITHUB"`
+}
+
+// Enabled reports whether any provider is enabled.
+func (c Config) Enabled() bool { return c.Google.Enabled() || c.GitHub.Enabled() }
+
+// ProviderConfig is the configuration of the client registered at a provider.
+type ProviderConfig struct {
+	ClientID     string `env:"CLIENT_ID"`
+	ClientSecret string `env:"CLIENT_SECRET,secret"`
+
+	// RedirectURL is the callback URL registered at the provider, i.e. the callback route of the provider, e.g.
+	// https://api.example.com/swe-be-mono-endusers/auth/oidc/google/callback.
+	RedirectURL string `env:"REDIRECT_URL"`
+}
+
+// Enabled reports whether the client is configured.
+func (c ProviderConfig) Enabled() bool { return c.ClientID != "" }
+
+// Identity is a user of a provider linked to a local user, stored in the user_identities table. A local user has at
+// most one identity per provider.
+type Identity struct {
+	Provider  string     `sql:"provider"` // the name of the provider, e.g. google.
+	Subject   string     `sql:"subject"`  // the stable ID of the user at the provider.
+	UserID    idkit.UUID `sql:"user_id"`
+	Email     string     `sql:"email"`      // the verified email at the provider when linked, lowercased.
+	CreatedAt time.Time  `sql:"created_at"` // UTC.
+}
+
+// Profile is the user authenticated by a provider.
+type Profile struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string // may be empty.
+}
+
+// PendingSignIn is returned as the error of Service.SignIn when the TOTP of the local user is active, the sign-in
+// is completed by Service.CompleteSignIn with the one-time password of the user before it expires.
+type PendingSignIn struct {
+	UserID    idkit.UUID
+	ExpiresAt time.Time
+}
+
+// Error implements error.
+func (p *PendingSignIn) Error() string { return "the sign-in requires the one-time password" }
+
+// Res converts the pending sign-in to its response model.
+func (p *PendingSignIn) Res(now time.Time) PendingSignInRes {
+	return PendingSignInRes{OTPRequired: true, ExpiresIn: int64(p.ExpiresAt.Sub(now).Seconds())}
+}
+
+// PendingSignInRes represents a sign-in waiting for the one-time password.
+// swagger:model identity.PendingSignInRes
+type PendingSignInRes struct {
+	OTPRequired bool  `json:"otp_required" example:"true"`
+	ExpiresIn   int64 `json:"expires_in" example:"300"` // seconds.
+} //@name identity.PendingSignInRes
+
+// unauthenticated creates an error that is mapped to 401 Unauthorized.
+func unauthenticated(detail string, cause error) error {
+	pd := problemdetail.New(business.PDTypeUnauthenticated,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("Unauthenticated"),
+		problemdetail.WithDetail(detail),
+	)
+	return fmt.Errorf("%w: %w", pd, cause)
+}
+
+// providerNotFound creates an error that is mapped to 404 Not Found.
+func providerNotFound(name string) error {
+	return problemdetail.New(business.PDTypeProviderNotFound,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("Provider Not Found"),
+		problemdetail.WithDetail(fmt.Sprintf("provider %s does not exist or is disabled", name)),
+	)
+}
@@ -0,0 +1,215 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/josestg/swe-be-mono/pkg/oidckit"
+	"golang.org/x/sync/singleflight"
+)
+
+// Provider is an OAuth 2.0 or OpenID Connect provider authenticating the users by the authorization code flow with
+// PKCE.
+type Provider interface {
+	// AuthCodeURL returns the URL redirecting the user to the provider, see oidckit.Client.AuthCodeURL.
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+
+	// Profile exchanges the authorization code of the callback, and returns the authenticated user. The verifier and
+	// the nonce are the ones given to AuthCodeURL.
+	Profile(ctx context.Context, code, verifier, nonce string) (Profile, error)
+}
+
+// Names of the built-in providers, the path parameter of the routes.
+const (
+	Google = "google"
+	GitHub = "github"
+)
+
+// GoogleIssuer is the issuer of Google, see oidckit.Discover.
+const GoogleIssuer = "https://accounts.google.com"
+
+// GitHubEndpoints are the endpoints of the GitHub OAuth apps, which aren't OpenID Connect providers.
+var GitHubEndpoints = oidckit.Endpoints{
+	AuthURL:     "https://github.com/login/oauth/authorize",
+	TokenURL:    "https://github.com/login/oauth/access_token",
+	UserInfoURL: "https://api.github.com/user",
+}
+
+// NewProviders creates the enabled providers of the config by their names.
+func NewProviders(cfg Config) map[string]Provider {
+	providers := make(map[string]Provider)
+	if c := cfg.Google; c.Enabled() {
+		providers[Google] = NewOIDCProvider(Google, GoogleIssuer, oidckit.Config{
+			ClientID:     c.ClientID,
+			ClientSecret: c.ClientSecret,
+			RedirectURL:  c.RedirectURL,
+			Scopes:       []string{"openid", "email", "profile"},
+		})
+	}
+	if c := cfg.GitHub; c.Enabled() {
+		providers[GitHub] = NewGitHubProvider(GitHub, oidckit.Config{
+			ClientID:     c.ClientID,
+			ClientSecret: c.ClientSecret,
+			RedirectURL:  c.RedirectURL,
+			Endpoints:    GitHubEndpoints,
+		})
+	}
+	return providers
+}
+
+// OIDCProvider is an OpenID Connect provider, the user is authenticated by the ID token. The endpoints are discovered
+// on the first use, and rediscovered on the next use if it fails. The concurrent discoveries share a single request.
+// OIDCProvider is concurrent-safe.
+type OIDCProvider struct {
+	name   string
+	issuer string
+	cfg    oidckit.Config
+
+	group  singleflight.Group
+	mu     sync.Mutex
+	client *oidckit.Client // nil until discovered.
+}
+
+// NewOIDCProvider creates a new OIDCProvider of the issuer, the endpoints of the config are ignored.
+func NewOIDCProvider(name, issuer string, cfg oidckit.Config) *OIDCProvider {
+	return &OIDCProvider{name: name, issuer: issuer, cfg: cfg}
+}
+
+// AuthCodeURL implements Provider.
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	client, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return client.AuthCodeURL(state, nonce, verifier), nil
+}
+
+// Profile implements Provider.
+func (p *OIDCProvider) Profile(ctx context.Context, code, verifier, nonce string) (Profile, error) {
+	client, err := p.discover(ctx)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	token, err := client.Exchange(ctx, code, verifier)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	claims, err := client.VerifyIDToken(ctx, token.IDToken, nonce)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	return Profile{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
+
+// discover returns the client of the discovered endpoints.
+func (p *OIDCProvider) discover(ctx context.Context) (*oidckit.Client, error) {
+	p.mu.Lock()
+	client := p.client
+	p.mu.Unlock()
+	if client != nil {
+		return client, nil
+	}
+
+	ch := p.group.DoChan("", func() (any, error) {
+		// the waiting callers share the request, so it outlives the one that started it.
+		endpoints, err := oidckit.Discover(context.WithoutCancel(ctx), p.cfg.HTTPClient, p.issuer)
+		if err != nil {
+			return nil, fmt.Errorf("discover %s: %w", p.name, err)
+		}
+
+		cfg := p.cfg
+		cfg.Endpoints = endpoints
+		client := oidckit.NewClient(cfg)
+
+		p.mu.Lock()
+		p.client = client
+		p.mu.Unlock()
+		return client, nil
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*oidckit.Client), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// GitHubProvider is the provider of the GitHub OAuth apps, the user is fetched by the GitHub REST API: the user of
+// Endpoints.UserInfoURL and its emails under the /emails path. GitHubProvider is concurrent-safe.
+type GitHubProvider struct {
+	name   string
+	client *oidckit.Client
+}
+
+// NewGitHubProvider creates a new GitHubProvider, the endpoints of the config are usually GitHubEndpoints. The
+// scopes default to read:user and user:email.
+func NewGitHubProvider(name string, cfg oidckit.Config) *GitHubProvider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"read:user", "user:email"}
+	}
+	return &GitHubProvider{name: name, client: oidckit.NewClient(cfg)}
+}
+
+// AuthCodeURL implements Provider, the nonce is ignored since GitHub issues no ID tokens.
+func (p *GitHubProvider) AuthCodeURL(_ context.Context, state, _, verifier string) (string, error) {
+	return p.client.AuthCodeURL(state, "", verifier), nil
+}
+
+// Profile implements Provider, the email is the verified primary email of the user, if any.
+func (p *GitHubProvider) Profile(ctx context.Context, code, verifier, _ string) (Profile, error) {
+	token, err := p.client.Exchange(ctx, code, verifier)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	userURL := p.client.Endpoints().UserInfoURL
+	var u struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := p.client.UserInfo(ctx, userURL, token.AccessToken, &u); err != nil {
+		return Profile{}, err
+	}
+	if u.ID == 0 {
+		return Profile{}, errors.New("github: the user has no id")
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.client.UserInfo(ctx, strings.TrimSuffix(userURL, "/")+"/emails", token.AccessToken, &emails); err != nil {
+		return Profile{}, err
+	}
+
+	profile := Profile{Provider: p.name, Subject: strconv.FormatInt(u.ID, 10), Name: u.Name}
+	if profile.Name == "" {
+		profile.Name = u.Login
+	}
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			profile.Email, profile.EmailVerified = e.Email, true
+			break
+		}
+	}
+	return profile, nil
+}
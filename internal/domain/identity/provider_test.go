@@ -0,0 +1,150 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/oidckit"
+)
+
+func TestGitHubProvider(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("code") != "good-code" || r.PostFormValue("code_verifier") != "verifier" {
+			// GitHub reports the errors by 200 OK.
+			_, _ = w.Write([]byte(`{"error": "bad_verification_code"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token": "access", "token_type": "bearer"}`))
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 42, "login": "alice"})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[
+			{"email": "alice@users.noreply.github.com", "primary": false, "verified": true},
+			{"email": "alice@example.com", "primary": true, "verified": true}
+		]`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	p := NewGitHubProvider(GitHub, oidckit.Config{
+		ClientID:    "client",
+		RedirectURL: "https://app.example.com/callback",
+		Endpoints: oidckit.Endpoints{
+			AuthURL:     srv.URL + "/login/oauth/authorize",
+			TokenURL:    srv.URL + "/login/oauth/access_token",
+			UserInfoURL: srv.URL + "/user",
+		},
+	})
+
+	ctx := context.Background()
+	raw, err := p.AuthCodeURL(ctx, "state", "nonce", "verifier")
+	expectNoError(t, err)
+	u, err := url.Parse(raw)
+	expectNoError(t, err)
+	q := u.Query()
+	expectTrue(t, q.Get("scope") == "read:user user:email" && q.Get("state") == "state" && !q.Has("nonce"))
+
+	_, err = p.Profile(ctx, "bad-code", "verifier", "")
+	expectTrue(t, err != nil)
+
+	profile, err := p.Profile(ctx, "good-code", "verifier", "")
+	expectNoError(t, err)
+	expectTrue(t, profile == Profile{
+		Provider:      GitHub,
+		Subject:       "42",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		Name:          "alice",
+	})
+}
+
+func TestOIDCProvider_Discover(t *testing.T) {
+	var discovered atomic.Int32
+	release := make(chan struct{})
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if discovered.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		<-release
+		_ = json.NewEncoder(w).Encode(oidckit.Endpoints{
+			Issuer:   srv.URL,
+			AuthURL:  srv.URL + "/authorize",
+			TokenURL: srv.URL + "/token",
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewOIDCProvider("example", srv.URL, oidckit.Config{ClientID: "client"})
+	ctx := context.Background()
+
+	// the failed discovery is retried on the next use.
+	_, err := p.AuthCodeURL(ctx, "state", "nonce", "verifier")
+	expectTrue(t, err != nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if u, err := p.AuthCodeURL(ctx, "state", "nonce", "verifier"); !strings.HasPrefix(u, srv.URL+"/authorize") {
+				t.Errorf("expected the discovered authorization endpoint, got %q, %v", u, err)
+			}
+		}()
+	}
+	for discovered.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the waiting caller gives up on its own context.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = p.AuthCodeURL(canceled, "state", "nonce", "verifier")
+	expectTrue(t, errors.Is(err, context.Canceled))
+
+	close(release)
+	wg.Wait()
+	expectTrue(t, discovered.Load() == 2)
+}
+
+func TestDisplayName(t *testing.T) {
+	expectTrue(t, displayName(Profile{Name: " Alice ", Email: "alice@example.com"}) == "Alice")
+	expectTrue(t, displayName(Profile{Email: "alice@example.com"}) == "alice")
+
+	long := make([]rune, maxNameLen+1)
+	for i := range long {
+		long[i] = 'é'
+	}
+	expectTrue(t, displayName(Profile{Name: string(long)}) == string(long[:maxNameLen]))
+}
+
+func expectNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("expect no error; got an error: %v", err)
+	}
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expect true; got false")
+	}
+}
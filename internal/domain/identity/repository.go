@@ -0,0 +1,46 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+// Repository stores the identities in the user_identities table. The methods return sqlxkit.ErrNotFound if there is
+// no such identity and sqlxkit.ErrDuplicate if the identity or the provider of the user is already linked, see
+// sqlxkit.TranslateError.
+type Repository struct {
+	db   sqlxkit.DB
+	crud *sqlxkit.Repository[Identity]
+	getQ string
+}
+
+// NewRepository creates a new Repository.
+func NewRepository(db sqlxkit.DB) (*Repository, error) {
+	crud, err := sqlxkit.NewRepository[Identity](db, "user_identities", "subject")
+	if err != nil {
+		return nil, fmt.Errorf("create crud repository: %w", err)
+	}
+
+	const columns = "provider, subject, user_id, email, created_at"
+	return &Repository{
+		db:   db,
+		crud: crud,
+		getQ: db.Rebind("SELECT " + columns + " FROM user_identities WHERE provider = ? AND subject = ?"),
+	}, nil
+}
+
+// Insert inserts the identity.
+func (r *Repository) Insert(ctx context.Context, i Identity) error {
+	return sqlxkit.TranslateError(r.crud.Insert(ctx, i))
+}
+
+// Get gets the identity by the provider and the subject.
+func (r *Repository) Get(ctx context.Context, provider, subject string) (Identity, error) {
+	i, err := sqlxkit.One[Identity](ctx, sqlxkit.TxOrDB(ctx, r.db), r.getQ, provider, subject)
+	if err != nil {
+		return i, fmt.Errorf("get identity: %w", err)
+	}
+	return i, nil
+}
@@ -0,0 +1,191 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/josestg/swe-be-mono/internal/domain/auth"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+// maxNameLen is the maximum length of the names of the users, see user.CreateReq.
+const maxNameLen = 100
+
+// Service signs the users in by the providers, and issues the tokens by the auth.TokenService. The sign-in by a
+// provider replaces the password, but not the TOTP: the users whose TOTP is active complete the sign-in with the
+// one-time password, see PendingSignIn.
+type Service struct {
+	db        sqlxkit.DB
+	repo      *Repository
+	users     *user.Service
+	tokens    *auth.TokenService
+	totp      *auth.TOTPService // nil if the TOTP isn't configured.
+	providers map[string]Provider
+	now       func() time.Time
+}
+
+// NewService creates a new Service of the providers by their names, see NewProviders. The users and their
+// identities are stored in the same database. The totp is nil if the TOTP isn't configured.
+func NewService(
+	db sqlxkit.DB,
+	users *user.Service,
+	tokens *auth.TokenService,
+	totp *auth.TOTPService,
+	providers map[string]Provider,
+) (*Service, error) {
+	repo, err := NewRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		db:        db,
+		repo:      repo,
+		users:     users,
+		tokens:    tokens,
+		totp:      totp,
+		providers: providers,
+		now:       time.Now,
+	}, nil
+}
+
+// AuthCodeURL returns the URL redirecting the user to the provider, see Provider.AuthCodeURL. It returns the
+// PDTypeProviderNotFound problem if there is no such provider.
+func (s *Service) AuthCodeURL(ctx context.Context, provider, state, nonce, verifier string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", providerNotFound(provider)
+	}
+
+	u, err := p.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", fmt.Errorf("get auth code url: %w", err)
+	}
+	return u, nil
+}
+
+// SignIn exchanges the authorization code of the provider, and issues the tokens of the local user linked to the
+// external user. The external user is linked on the first sign-in to the local user of the same email, or to a new
+// user if there is none. It returns the PDTypeProviderNotFound problem if there is no such provider, or the
+// PDTypeUnauthenticated problem wrapping ErrProviderAuthorization if the provider rejects the code, or
+// ErrUnverifiedEmail if the email of the external user isn't verified, or if the local user is linked to another
+// user of the provider. If the TOTP of the local user is active, the tokens aren't issued and the *PendingSignIn
+// error is returned instead, see CompleteSignIn.
+func (s *Service) SignIn(ctx context.Context, provider, code, verifier, nonce string) (auth.TokenPair, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return auth.TokenPair{}, providerNotFound(provider)
+	}
+
+	profile, err := p.Profile(ctx, code, verifier, nonce)
+	if err != nil {
+		return auth.TokenPair{}, unauthenticated(
+			fmt.Sprintf("the authorization by %s failed", provider),
+			fmt.Errorf("%w: %w", ErrProviderAuthorization, err),
+		)
+	}
+
+	userID, err := s.link(ctx, profile)
+	if err != nil {
+		return auth.TokenPair{}, err
+	}
+
+	if s.totp != nil {
+		active, err := s.totp.Active(ctx, userID)
+		if err != nil {
+			return auth.TokenPair{}, fmt.Errorf("check totp: %w", err)
+		}
+		if active {
+			return auth.TokenPair{}, &PendingSignIn{UserID: userID, ExpiresAt: s.now().Add(PendingSignInTTL)}
+		}
+	}
+	return s.issue(ctx, userID)
+}
+
+// CompleteSignIn completes the pending sign-in by the one-time password of the user, see auth.TOTPService.VerifyLogin,
+// and issues the tokens. It returns the PDTypeUnauthenticated problem wrapping ErrSignInExpired if the sign-in has
+// expired, or the problems of auth.TOTPService.VerifyLogin if the code is missing or wrong.
+func (s *Service) CompleteSignIn(ctx context.Context, pending PendingSignIn, code string) (auth.TokenPair, error) {
+	if s.totp == nil || !s.now().Before(pending.ExpiresAt) {
+		return auth.TokenPair{}, unauthenticated("the sign-in has expired, sign in again", ErrSignInExpired)
+	}
+
+	if err := s.totp.VerifyLogin(ctx, pending.UserID, code); err != nil {
+		return auth.TokenPair{}, err
+	}
+	return s.issue(ctx, pending.UserID)
+}
+
+// issue issues the tokens of the user.
+func (s *Service) issue(ctx context.Context, userID idkit.UUID) (auth.TokenPair, error) {
+	pair, err := s.tokens.Issue(ctx, userID)
+	if err != nil {
+		return auth.TokenPair{}, fmt.Errorf("issue tokens: %w", err)
+	}
+	return pair, nil
+}
+
+// link returns the ID of the local user linked to the external user, linking it if it isn't yet.
+func (s *Service) link(ctx context.Context, p Profile) (idkit.UUID, error) {
+	i, err := s.repo.Get(ctx, p.Provider, p.Subject)
+	if err == nil {
+		return i.UserID, nil
+	}
+	if !errors.Is(err, sqlxkit.ErrNotFound) {
+		return idkit.NilUUID, err
+	}
+
+	// the email links the identity to the existing user, so it must be owned by the external user.
+	if p.Email == "" || !p.EmailVerified {
+		return idkit.NilUUID, unauthenticated(
+			fmt.Sprintf("the email of the %s account must be verified", p.Provider),
+			ErrUnverifiedEmail,
+		)
+	}
+
+	var userID idkit.UUID
+	err = sqlxkit.ExecTransaction(ctx, s.db, func(ctx context.Context, _ sqlxkit.Tx) (context.Context, error) {
+		u, err := s.users.GetByEmail(ctx, p.Email)
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			u, err = s.users.Create(ctx, user.CreateReq{Email: p.Email, Name: displayName(p)})
+		}
+		if err != nil {
+			return ctx, err
+		}
+
+		userID = u.ID
+		return ctx, s.repo.Insert(ctx, Identity{
+			Provider:  p.Provider,
+			Subject:   p.Subject,
+			UserID:    u.ID,
+			Email:     u.Email,
+			CreatedAt: s.now().UTC().Truncate(time.Microsecond),
+		})
+	})
+	if err != nil {
+		if errors.Is(err, sqlxkit.ErrDuplicate) {
+			detail := fmt.Sprintf("the user of %s is already linked to another %s account", p.Email, p.Provider)
+			return idkit.NilUUID, unauthenticated(detail, err)
+		}
+		return idkit.NilUUID, fmt.Errorf("link identity: %w", err)
+	}
+	return userID, nil
+}
+
+// displayName returns the name of the new user, the local part of the email if the provider has no name.
+func displayName(p Profile) string {
+	name := strings.TrimSpace(p.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(p.Email, "@")
+	}
+	if utf8.RuneCountInString(name) > maxNameLen {
+		name = string([]rune(name)[:maxNameLen])
+	}
+	return name
+}
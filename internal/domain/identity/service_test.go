@@ -0,0 +1,167 @@
+//go:build cgo
+
+package identity
+
+import (
+	"context"
+	"encoding/base32"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/internal/domain/auth"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/pkg/otpkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit/sqlxkittest"
+	"github.com/josestg/swe-be-mono/resources/migrations"
+)
+
+// fakeProvider authenticates the profiles by their codes.
+type fakeProvider map[string]Profile
+
+func (p fakeProvider) AuthCodeURL(_ context.Context, state, _, _ string) (string, error) {
+	return "https://provider.example.com/authorize?state=" + state, nil
+}
+
+func (p fakeProvider) Profile(_ context.Context, code, _, _ string) (Profile, error) {
+	profile, ok := p[code]
+	if !ok {
+		return Profile{}, errors.New("invalid code")
+	}
+	return profile, nil
+}
+
+// newTestService creates a Service of the provider, with the TOTP if withTOTP is true.
+func newTestService(t *testing.T, provider fakeProvider, withTOTP bool) *Service {
+	t.Helper()
+	db, teardown := sqlxkittest.Setup(t, migrations.Postgre())
+	t.Cleanup(teardown)
+
+	repo, err := user.NewRepository(db)
+	expectNoError(t, err)
+	users := user.NewService(repo)
+
+	keys := map[string]string{"k1": "HS256:0123456789abcdef0123456789abcdef"}
+	tokens, err := auth.NewTokenService(db, auth.TokenConfig{Keys: keys})
+	expectNoError(t, err)
+
+	var totp *auth.TOTPService
+	if withTOTP {
+		totp, err = auth.NewTOTPService(db, users, auth.TOTPConfig{Key: "0123456789abcdef0123456789abcdef"})
+		expectNoError(t, err)
+	}
+
+	svc, err := NewService(db, users, tokens, totp, map[string]Provider{Google: provider})
+	expectNoError(t, err)
+	return svc
+}
+
+func TestService_SignIn(t *testing.T) {
+	ctx := context.Background()
+	alice := Profile{Provider: Google, Subject: "1", Email: "Alice@Example.com", EmailVerified: true, Name: "Alice"}
+	svc := newTestService(t, fakeProvider{
+		"alice":    alice,
+		"alice-2":  {Provider: Google, Subject: "2", Email: "alice@example.com", EmailVerified: true},
+		"bob":      {Provider: Google, Subject: "3", Email: "bob@example.com", EmailVerified: true},
+		"mallory":  {Provider: Google, Subject: "4", Email: "bob@example.com"},
+		"no-email": {Provider: Google, Subject: "5"},
+	}, false)
+
+	url, err := svc.AuthCodeURL(ctx, Google, "state", "nonce", "verifier")
+	expectNoError(t, err)
+	expectTrue(t, url == "https://provider.example.com/authorize?state=state")
+
+	_, err = svc.AuthCodeURL(ctx, GitHub, "state", "nonce", "verifier")
+	expectProblem(t, err, business.PDTypeProviderNotFound)
+	_, err = svc.SignIn(ctx, GitHub, "alice", "verifier", "nonce")
+	expectProblem(t, err, business.PDTypeProviderNotFound)
+
+	_, err = svc.SignIn(ctx, Google, "unknown", "verifier", "nonce")
+	expectProblem(t, err, business.PDTypeUnauthenticated)
+	expectTrue(t, errors.Is(err, ErrProviderAuthorization))
+
+	// a new user is created on the first sign-in, and signed in afterward.
+	_, err = svc.SignIn(ctx, Google, "alice", "verifier", "nonce")
+	expectNoError(t, err)
+	u, err := svc.users.GetByEmail(ctx, "alice@example.com")
+	expectNoError(t, err)
+	expectTrue(t, u.Name == "Alice")
+
+	i, err := svc.repo.Get(ctx, Google, "1")
+	expectNoError(t, err)
+	expectTrue(t, i.UserID == u.ID && i.Email == "alice@example.com")
+
+	_, err = svc.SignIn(ctx, Google, "alice", "verifier", "nonce")
+	expectNoError(t, err)
+
+	// the user is linked to one account per provider.
+	_, err = svc.SignIn(ctx, Google, "alice-2", "verifier", "nonce")
+	expectProblem(t, err, business.PDTypeUnauthenticated)
+
+	// an existing user is linked by the email.
+	bob, err := svc.users.Create(ctx, user.CreateReq{Email: "bob@example.com", Name: "Bob"})
+	expectNoError(t, err)
+	_, err = svc.SignIn(ctx, Google, "bob", "verifier", "nonce")
+	expectNoError(t, err)
+	i, err = svc.repo.Get(ctx, Google, "3")
+	expectNoError(t, err)
+	expectTrue(t, i.UserID == bob.ID)
+
+	// the unverified emails can't take over the users.
+	_, err = svc.SignIn(ctx, Google, "mallory", "verifier", "nonce")
+	expectProblem(t, err, business.PDTypeUnauthenticated)
+	expectTrue(t, errors.Is(err, ErrUnverifiedEmail))
+
+	_, err = svc.SignIn(ctx, Google, "no-email", "verifier", "nonce")
+	expectTrue(t, errors.Is(err, ErrUnverifiedEmail))
+}
+
+func TestService_SignIn_TOTP(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, fakeProvider{
+		"alice": {Provider: Google, Subject: "1", Email: "alice@example.com", EmailVerified: true},
+	}, true)
+
+	// an existing user with the active TOTP can't be signed in by the provider alone.
+	alice, err := svc.users.Create(ctx, user.CreateReq{Email: "alice@example.com", Name: "Alice"})
+	expectNoError(t, err)
+	setup, err := svc.totp.Setup(ctx, alice.ID)
+	expectNoError(t, err)
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(setup.Secret)
+	expectNoError(t, err)
+	totp := otpkit.New(otpkit.Config{})
+	codes, err := svc.totp.Confirm(ctx, alice.ID, totp.Code(secret, time.Now().Add(-30*time.Second)))
+	expectNoError(t, err)
+
+	_, err = svc.SignIn(ctx, Google, "alice", "verifier", "nonce")
+	var pending *PendingSignIn
+	expectTrue(t, errors.As(err, &pending))
+	expectTrue(t, pending.UserID == alice.ID)
+
+	_, err = svc.CompleteSignIn(ctx, *pending, "")
+	expectProblem(t, err, business.PDTypeOTPRequired)
+	_, err = svc.CompleteSignIn(ctx, *pending, "000000")
+	expectProblem(t, err, business.PDTypeUnauthenticated)
+
+	pair, err := svc.CompleteSignIn(ctx, *pending, totp.Code(secret, time.Now()))
+	expectNoError(t, err)
+	expectTrue(t, pair.Access.Value != "")
+
+	expired := PendingSignIn{UserID: alice.ID, ExpiresAt: time.Now().Add(-time.Second)}
+	_, err = svc.CompleteSignIn(ctx, expired, codes[0])
+	expectProblem(t, err, business.PDTypeUnauthenticated)
+	expectTrue(t, errors.Is(err, ErrSignInExpired))
+}
+
+func expectProblem(t *testing.T, err error, pdType string) {
+	t.Helper()
+	var pd problemdetail.ProblemDetailer
+	if !errors.As(err, &pd) {
+		t.Fatalf("expected problem %s, got %v", pdType, err)
+	}
+	if pd.Kind() != pdType {
+		t.Fatalf("expected problem %s, got %s", pdType, pd.Kind())
+	}
+}
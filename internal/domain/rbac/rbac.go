@@ -0,0 +1,122 @@
+// Package rbac is the domain of the role-based access control: the roles, their permissions and the assignments of
+// the roles to the users. The permissions are checked like the scopes of the tokens, see httpmiddleware.Authorize.
+package rbac
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+)
+
+// Set of the permissions, the values are the scopes required by the routes.
+const (
+	PermUsersRead  = "users:read"
+	PermUsersWrite = "users:write"
+	PermRolesRead  = "roles:read"
+	PermRolesWrite = "roles:write"
+)
+
+// Permissions is the catalog of the permissions, the roles can only grant these.
+var Permissions = []string{PermUsersRead, PermUsersWrite, PermRolesRead, PermRolesWrite}
+
+// AdminRole is the name of the role granting all the permissions, see Service.Bootstrap.
+const AdminRole = "admin"
+
+// Config is the configuration of the role-based access control.
+type Config struct {
+	// AdminUserIDs are the IDs of the users assigned the AdminRole on startup while it has no users, so the first
+	// admins don't need the admin APIs. The users are identified by the IDs rather than the emails, because the emails
+	// of the self-registered users aren't verified. The users that don't exist yet are skipped.
+	AdminUserIDs []idkit.UUID `env:"ADMIN_USER_IDS"`
+}
+
+// Role is the entity of a role, stored in the roles table and its permissions in the role_permissions table.
+type Role struct {
+	ID          idkit.UUID `sql:"id"`
+	Name        string     `sql:"name"` // unique.
+	Description string     `sql:"description"`
+	CreatedAt   time.Time  `sql:"created_at"` // UTC.
+	UpdatedAt   time.Time  `sql:"updated_at"` // UTC.
+	Permissions []string   `sql:"-"`          // sorted.
+}
+
+// Res converts the role to its response model.
+func (r Role) Res() RoleRes {
+	perms := r.Permissions
+	if perms == nil {
+		perms = []string{}
+	}
+	return RoleRes{
+		ID:          r.ID.String(),
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: perms,
+		CreatedAt:   r.CreatedAt.UnixMilli(),
+		UpdatedAt:   r.UpdatedAt.UnixMilli(),
+	}
+}
+
+// rolePermission is a permission granted by a role, stored in the role_permissions table.
+type rolePermission struct {
+	RoleID     idkit.UUID `sql:"role_id"`
+	Permission string     `sql:"permission"`
+}
+
+// Assignment is a role assigned to a user, stored in the user_roles table.
+type Assignment struct {
+	UserID    idkit.UUID `sql:"user_id"`
+	RoleID    idkit.UUID `sql:"role_id"`
+	CreatedAt time.Time  `sql:"created_at"` // UTC.
+}
+
+// RoleReq represents the request for creating or updating a role, all fields are replaced on update.
+// swagger:model rbac.RoleReq
+type RoleReq struct {
+	Name        string   `json:"name" validate:"required,max=64" example:"support"`
+	Description string   `json:"description" validate:"max=255" example:"Reads the user accounts."`
+	Permissions []string `json:"permissions" validate:"max=64,dive,required,max=64" example:"users:read"`
+} //@name rbac.RoleReq
+
+// RoleRes represents a role.
+// swagger:model rbac.RoleRes
+type RoleRes struct {
+	ID          string   `json:"id" example:"f8635b1a-3524-4441-8c22-10edf1c45407"`
+	Name        string   `json:"name" example:"support"`
+	Description string   `json:"description" example:"Reads the user accounts."`
+	Permissions []string `json:"permissions" example:"users:read"`
+	CreatedAt   int64    `json:"created_at" example:"1700000000000"` // unix milliseconds.
+	UpdatedAt   int64    `json:"updated_at" example:"1700000000000"` // unix milliseconds.
+} //@name rbac.RoleRes
+
+// notFound creates an error that is mapped to 404 Not Found.
+func notFound(id idkit.UUID, cause error) error {
+	pd := problemdetail.New(business.PDTypeRoleNotFound,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("Role Not Found"),
+		problemdetail.WithDetail(fmt.Sprintf("role %s does not exist", id)),
+	)
+	return fmt.Errorf("%w: %w", pd, cause)
+}
+
+// nameTaken creates an error that is mapped to 409 Conflict.
+func nameTaken(name string, cause error) error {
+	pd := problemdetail.New(business.PDTypeRoleAlreadyExists,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("Role Already Exists"),
+		problemdetail.WithDetail(fmt.Sprintf("role %s already exists", name)),
+	)
+	return fmt.Errorf("%w: %w", pd, cause)
+}
+
+// unknownPermissions creates an error that is mapped to 400 Bad Request.
+func unknownPermissions(perms []string) error {
+	return problemdetail.New(business.PDTypeInvalidArguments,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("Unknown Permissions"),
+		problemdetail.WithDetail(fmt.Sprintf("unknown permissions: %s", strings.Join(perms, ", "))),
+	)
+}
@@ -0,0 +1,177 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+// Repository stores the roles, their permissions and their assignments. The methods return sqlxkit.ErrNotFound if
+// there is no such role and sqlxkit.ErrDuplicate if the name is taken, see sqlxkit.TranslateError. The permissions
+// of the roles aren't loaded by the methods returning the roles, see Permissions.
+type Repository struct {
+	db           sqlxkit.DB
+	roles        *sqlxkit.Repository[Role]
+	perms        *sqlxkit.Repository[rolePermission]
+	assignments  *sqlxkit.Repository[Assignment]
+	getByNameQ   string
+	listQ        string
+	permsQ       string
+	deletePermsQ string
+	userRolesQ   string
+	userPermsQ   string
+	unassignQ    string
+	countUsersQ  string
+}
+
+// NewRepository creates a new Repository.
+func NewRepository(db sqlxkit.DB) (*Repository, error) {
+	roles, err := sqlxkit.NewRepository[Role](db, "roles", "id")
+	if err != nil {
+		return nil, fmt.Errorf("create roles crud repository: %w", err)
+	}
+	perms, err := sqlxkit.NewRepository[rolePermission](db, "role_permissions", "role_id")
+	if err != nil {
+		return nil, fmt.Errorf("create role permissions crud repository: %w", err)
+	}
+	assignments, err := sqlxkit.NewRepository[Assignment](db, "user_roles", "role_id")
+	if err != nil {
+		return nil, fmt.Errorf("create user roles crud repository: %w", err)
+	}
+
+	const columns = "roles.id, roles.name, roles.description, roles.created_at, roles.updated_at"
+	return &Repository{
+		db:           db,
+		roles:        roles,
+		perms:        perms,
+		assignments:  assignments,
+		getByNameQ:   db.Rebind("SELECT " + columns + " FROM roles WHERE name = ?"),
+		listQ:        "SELECT " + columns + " FROM roles ORDER BY name",
+		permsQ:       db.Rebind("SELECT permission FROM role_permissions WHERE role_id = ? ORDER BY permission"),
+		deletePermsQ: db.Rebind("DELETE FROM role_permissions WHERE role_id = ?"),
+		userRolesQ: db.Rebind("SELECT " + columns + " FROM roles JOIN user_roles ON user_roles.role_id = roles.id" +
+			" WHERE user_roles.user_id = ? ORDER BY roles.name"),
+		userPermsQ: db.Rebind("SELECT DISTINCT role_permissions.permission FROM role_permissions" +
+			" JOIN user_roles ON user_roles.role_id = role_permissions.role_id" +
+			" WHERE user_roles.user_id = ? ORDER BY role_permissions.permission"),
+		unassignQ:   db.Rebind("DELETE FROM user_roles WHERE user_id = ? AND role_id = ?"),
+		countUsersQ: db.Rebind("SELECT COUNT(*) FROM user_roles WHERE role_id = ?"),
+	}, nil
+}
+
+// Insert inserts the role, without its permissions.
+func (r *Repository) Insert(ctx context.Context, role Role) error {
+	return sqlxkit.TranslateError(r.roles.Insert(ctx, role))
+}
+
+// Update updates the role identified by its ID, without its permissions.
+func (r *Repository) Update(ctx context.Context, role Role) error {
+	return sqlxkit.TranslateError(r.roles.Update(ctx, role))
+}
+
+// Delete deletes the role identified by the id, its permissions and assignments are deleted by the cascade.
+func (r *Repository) Delete(ctx context.Context, id idkit.UUID) error {
+	return r.roles.Delete(ctx, id)
+}
+
+// Get gets the role identified by the id.
+func (r *Repository) Get(ctx context.Context, id idkit.UUID) (Role, error) {
+	return r.roles.GetByID(ctx, id)
+}
+
+// GetByName gets the role by its name.
+func (r *Repository) GetByName(ctx context.Context, name string) (Role, error) {
+	role, err := sqlxkit.One[Role](ctx, sqlxkit.TxOrDB(ctx, r.db), r.getByNameQ, name)
+	if err != nil {
+		return role, fmt.Errorf("get role by name: %w", err)
+	}
+	return role, nil
+}
+
+// List lists all the roles ordered by the name.
+func (r *Repository) List(ctx context.Context) ([]Role, error) {
+	roles, err := sqlxkit.All[Role](ctx, sqlxkit.TxOrDB(ctx, r.db), r.listQ)
+	if err != nil {
+		return nil, fmt.Errorf("list roles: %w", err)
+	}
+	return roles, nil
+}
+
+// Permissions lists the sorted permissions of the role.
+func (r *Repository) Permissions(ctx context.Context, roleID idkit.UUID) ([]string, error) {
+	perms, err := sqlxkit.All[string](ctx, sqlxkit.TxOrDB(ctx, r.db), r.permsQ, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("list role permissions: %w", err)
+	}
+	return perms, nil
+}
+
+// SetPermissions replaces the permissions of the role, it should run in a transaction, see sqlxkit.ExecTransaction.
+func (r *Repository) SetPermissions(ctx context.Context, roleID idkit.UUID, perms []string) error {
+	if _, err := sqlxkit.TxOrDB(ctx, r.db).ExecContext(ctx, r.deletePermsQ, roleID); err != nil {
+		return fmt.Errorf("delete role permissions: %w", err)
+	}
+	for _, p := range perms {
+		if err := r.perms.Insert(ctx, rolePermission{RoleID: roleID, Permission: p}); err != nil {
+			return sqlxkit.TranslateError(err)
+		}
+	}
+	return nil
+}
+
+// UserRoles lists the roles assigned to the user ordered by the name.
+func (r *Repository) UserRoles(ctx context.Context, userID idkit.UUID) ([]Role, error) {
+	roles, err := sqlxkit.All[Role](ctx, sqlxkit.TxOrDB(ctx, r.db), r.userRolesQ, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list user roles: %w", err)
+	}
+	return roles, nil
+}
+
+// UserPermissions lists the sorted distinct permissions granted to the user by the roles.
+func (r *Repository) UserPermissions(ctx context.Context, userID idkit.UUID) ([]string, error) {
+	perms, err := sqlxkit.All[string](ctx, sqlxkit.TxOrDB(ctx, r.db), r.userPermsQ, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list user permissions: %w", err)
+	}
+	return perms, nil
+}
+
+// Assign assigns the role to the user. It returns sqlxkit.ErrDuplicate if it is already assigned, or
+// sqlxkit.ErrFKViolation if the user or the role doesn't exist.
+func (r *Repository) Assign(ctx context.Context, a Assignment) error {
+	return sqlxkit.TranslateError(r.assignments.Insert(ctx, a))
+}
+
+// Unassign unassigns the role from the user. It returns sqlxkit.ErrNotFound if the role isn't assigned.
+func (r *Repository) Unassign(ctx context.Context, userID, roleID idkit.UUID) error {
+	res, err := sqlxkit.TxOrDB(ctx, r.db).ExecContext(ctx, r.unassignQ, userID, roleID)
+	if err != nil {
+		return fmt.Errorf("unassign role: %w", err)
+	}
+	return expectAffected("unassign role", res)
+}
+
+// CountUsers counts the users assigned the role.
+func (r *Repository) CountUsers(ctx context.Context, roleID idkit.UUID) (int, error) {
+	n, err := sqlxkit.One[int](ctx, sqlxkit.TxOrDB(ctx, r.db), r.countUsersQ, roleID)
+	if err != nil {
+		return 0, fmt.Errorf("count role users: %w", err)
+	}
+	return n, nil
+}
+
+// expectAffected returns sqlxkit.ErrNotFound if the query affected no rows.
+func expectAffected(op string, res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: get affected rows: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, sqlxkit.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+// Service is the business rules of the roles and their assignments. The requests are expected to be validated by the
+// caller, e.g. by the validate tags of RoleReq.
+type Service struct {
+	db    sqlxkit.DB
+	repo  *Repository
+	users *user.Service
+	now   func() time.Time
+}
+
+// NewService creates a new Service, the roles are stored in the same database as the users.
+func NewService(db sqlxkit.DB, users *user.Service) (*Service, error) {
+	repo, err := NewRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{db: db, repo: repo, users: users, now: time.Now}, nil
+}
+
+// CreateRole creates a role, the ID is requested from the idkit provider in the context, see idkit.FromContext. It
+// returns the PDTypeInvalidArguments problem if any permission isn't in Permissions, or the PDTypeRoleAlreadyExists
+// problem if the name is taken.
+func (s *Service) CreateRole(ctx context.Context, req RoleReq) (Role, error) {
+	perms, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return Role{}, err
+	}
+
+	id, err := idkit.FromContext(ctx).Request(ctx)
+	if err != nil {
+		return Role{}, fmt.Errorf("request role id: %w", err)
+	}
+
+	now := s.timestamp()
+	role := Role{
+		ID:          idkit.UUID(id),
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Permissions: perms,
+	}
+
+	err = sqlxkit.ExecTransaction(ctx, s.db, func(ctx context.Context, _ sqlxkit.Tx) (context.Context, error) {
+		if err := s.repo.Insert(ctx, role); err != nil {
+			return ctx, err
+		}
+		return ctx, s.repo.SetPermissions(ctx, role.ID, perms)
+	})
+	if err != nil {
+		if errors.Is(err, sqlxkit.ErrDuplicate) {
+			return Role{}, nameTaken(role.Name, err)
+		}
+		return Role{}, fmt.Errorf("create role: %w", err)
+	}
+	return role, nil
+}
+
+// ListRoles lists all the roles ordered by the name.
+func (s *Service) ListRoles(ctx context.Context) ([]Role, error) {
+	roles, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.withPermissions(ctx, roles)
+}
+
+// GetRole gets a role by the id. It returns the PDTypeRoleNotFound problem if there is no such role.
+func (s *Service) GetRole(ctx context.Context, id idkit.UUID) (Role, error) {
+	role, err := s.repo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return Role{}, notFound(id, err)
+		}
+		return Role{}, fmt.Errorf("get role: %w", err)
+	}
+
+	if role.Permissions, err = s.repo.Permissions(ctx, id); err != nil {
+		return Role{}, err
+	}
+	return role, nil
+}
+
+// UpdateRole replaces the name, the description and the permissions of the role. It returns the
+// PDTypeInvalidArguments problem if any permission isn't in Permissions, the PDTypeRoleNotFound problem if there is
+// no such role, or the PDTypeRoleAlreadyExists problem if the name is taken by another role.
+func (s *Service) UpdateRole(ctx context.Context, id idkit.UUID, req RoleReq) (Role, error) {
+	perms, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return Role{}, err
+	}
+
+	var role Role
+	err = sqlxkit.ExecTransaction(ctx, s.db, func(ctx context.Context, _ sqlxkit.Tx) (context.Context, error) {
+		if role, err = s.repo.Get(ctx, id); err != nil {
+			return ctx, err
+		}
+
+		role.Name = strings.TrimSpace(req.Name)
+		role.Description = strings.TrimSpace(req.Description)
+		role.UpdatedAt = s.timestamp()
+		role.Permissions = perms
+		if err := s.repo.Update(ctx, role); err != nil {
+			return ctx, err
+		}
+		return ctx, s.repo.SetPermissions(ctx, id, perms)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sqlxkit.ErrNotFound):
+			return Role{}, notFound(id, err)
+		case errors.Is(err, sqlxkit.ErrDuplicate):
+			return Role{}, nameTaken(strings.TrimSpace(req.Name), err)
+		}
+		return Role{}, fmt.Errorf("update role: %w", err)
+	}
+	return role, nil
+}
+
+// DeleteRole deletes a role by the id, unassigning it from the users. It returns the PDTypeRoleNotFound problem if
+// there is no such role.
+func (s *Service) DeleteRole(ctx context.Context, id idkit.UUID) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return notFound(id, err)
+		}
+		return fmt.Errorf("delete role: %w", err)
+	}
+	return nil
+}
+
+// UserRoles lists the roles assigned to the user ordered by the name. It returns the PDTypeUserNotFound problem if
+// there is no such user.
+func (s *Service) UserRoles(ctx context.Context, userID idkit.UUID) ([]Role, error) {
+	if _, err := s.users.Get(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	roles, err := s.repo.UserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withPermissions(ctx, roles)
+}
+
+// AssignRole assigns the role to the user, assigning an assigned role is a no-op. It returns the PDTypeUserNotFound
+// or the PDTypeRoleNotFound problem if there is no such user or role.
+func (s *Service) AssignRole(ctx context.Context, userID, roleID idkit.UUID) error {
+	if _, err := s.users.Get(ctx, userID); err != nil {
+		return err
+	}
+	if _, err := s.repo.Get(ctx, roleID); err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return notFound(roleID, err)
+		}
+		return fmt.Errorf("get role: %w", err)
+	}
+
+	err := s.repo.Assign(ctx, Assignment{UserID: userID, RoleID: roleID, CreatedAt: s.timestamp()})
+	if err != nil && !errors.Is(err, sqlxkit.ErrDuplicate) {
+		return fmt.Errorf("assign role: %w", err)
+	}
+	return nil
+}
+
+// UnassignRole unassigns the role from the user, unassigning an unassigned role is a no-op.
+func (s *Service) UnassignRole(ctx context.Context, userID, roleID idkit.UUID) error {
+	if err := s.repo.Unassign(ctx, userID, roleID); err != nil && !errors.Is(err, sqlxkit.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Permissions lists the sorted permissions granted to the user by the roles, e.g. for
+// httpmiddleware.ResolvePermissions. The unknown users have no permissions.
+func (s *Service) Permissions(ctx context.Context, userID idkit.UUID) ([]string, error) {
+	return s.repo.UserPermissions(ctx, userID)
+}
+
+// Bootstrap creates the AdminRole if it doesn't exist and grants it all the Permissions. While the AdminRole has no
+// users, it is assigned to the users of Config.AdminUserIDs, so the config can't grant the admin to anyone once the
+// admins manage the roles. The IDs of the users that don't exist are skipped.
+func (s *Service) Bootstrap(ctx context.Context, cfg Config) error {
+	role, err := s.repo.GetByName(ctx, AdminRole)
+	switch {
+	case errors.Is(err, sqlxkit.ErrNotFound):
+		req := RoleReq{Name: AdminRole, Description: "Grants all the permissions.", Permissions: Permissions}
+		if role, err = s.CreateRole(ctx, req); err != nil {
+			return fmt.Errorf("bootstrap: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("bootstrap: %w", err)
+	default:
+		err := sqlxkit.ExecTransaction(ctx, s.db, func(ctx context.Context, _ sqlxkit.Tx) (context.Context, error) {
+			return ctx, s.repo.SetPermissions(ctx, role.ID, Permissions)
+		})
+		if err != nil {
+			return fmt.Errorf("bootstrap: grant admin permissions: %w", err)
+		}
+	}
+
+	n, err := s.repo.CountUsers(ctx, role.ID)
+	if err != nil {
+		return fmt.Errorf("bootstrap: %w", err)
+	}
+	if n > 0 {
+		return nil
+	}
+
+	for _, id := range cfg.AdminUserIDs {
+		err := s.AssignRole(ctx, id, role.ID)
+		if err != nil && !errors.Is(err, sqlxkit.ErrNotFound) {
+			return fmt.Errorf("bootstrap: %w", err)
+		}
+	}
+	return nil
+}
+
+// withPermissions loads the permissions of the roles.
+func (s *Service) withPermissions(ctx context.Context, roles []Role) ([]Role, error) {
+	for i := range roles {
+		perms, err := s.repo.Permissions(ctx, roles[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		roles[i].Permissions = perms
+	}
+	return roles, nil
+}
+
+// timestamp returns the current time in UTC truncated to microseconds, the precision of the database.
+func (s *Service) timestamp() time.Time { return s.now().UTC().Truncate(time.Microsecond) }
+
+// normalizePermissions sorts and deduplicates the permissions. It returns the PDTypeInvalidArguments problem if any
+// permission isn't in Permissions.
+func normalizePermissions(perms []string) ([]string, error) {
+	var unknown []string
+	normalized := make([]string, 0, len(perms))
+	for _, p := range perms {
+		p = strings.TrimSpace(p)
+		if !slices.Contains(Permissions, p) {
+			unknown = append(unknown, p)
+			continue
+		}
+		normalized = append(normalized, p)
+	}
+	if len(unknown) > 0 {
+		return nil, unknownPermissions(unknown)
+	}
+
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
@@ -0,0 +1,187 @@
+//go:build cgo
+
+package rbac
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit/sqlxkittest"
+	"github.com/josestg/swe-be-mono/resources/migrations"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	db, teardown := sqlxkittest.Setup(t, migrations.Postgre())
+	t.Cleanup(teardown)
+
+	repo, err := user.NewRepository(db)
+	expectNoError(t, err)
+
+	svc, err := NewService(db, user.NewService(repo))
+	expectNoError(t, err)
+	return svc
+}
+
+func TestService_Roles(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	support, err := svc.CreateRole(ctx, RoleReq{
+		Name:        " support ",
+		Description: "Reads the users.",
+		Permissions: []string{PermUsersRead, PermRolesRead, PermUsersRead},
+	})
+	expectNoError(t, err)
+	expectTrue(t, support.Name == "support")
+	expectTrue(t, slices.Equal(support.Permissions, []string{PermRolesRead, PermUsersRead}))
+
+	_, err = svc.CreateRole(ctx, RoleReq{Name: "support"})
+	expectProblem(t, err, business.PDTypeRoleAlreadyExists)
+
+	_, err = svc.CreateRole(ctx, RoleReq{Name: "root", Permissions: []string{"*"}})
+	expectProblem(t, err, business.PDTypeInvalidArguments)
+
+	got, err := svc.GetRole(ctx, support.ID)
+	expectNoError(t, err)
+	expectTrue(t, got.Description == "Reads the users.")
+	expectTrue(t, slices.Equal(got.Permissions, support.Permissions))
+
+	editor, err := svc.CreateRole(ctx, RoleReq{Name: "editor", Permissions: []string{PermUsersWrite}})
+	expectNoError(t, err)
+
+	roles, err := svc.ListRoles(ctx)
+	expectNoError(t, err)
+	expectTrue(t, len(roles) == 2 && roles[0].Name == "editor" && roles[1].Name == "support")
+	expectTrue(t, slices.Equal(roles[0].Permissions, []string{PermUsersWrite}))
+
+	updated, err := svc.UpdateRole(ctx, support.ID, RoleReq{Name: "helpdesk", Permissions: []string{PermUsersRead}})
+	expectNoError(t, err)
+	got, err = svc.GetRole(ctx, support.ID)
+	expectNoError(t, err)
+	expectTrue(t, got.Name == "helpdesk" && slices.Equal(got.Permissions, []string{PermUsersRead}))
+	expectTrue(t, got.UpdatedAt.Equal(updated.UpdatedAt))
+
+	_, err = svc.UpdateRole(ctx, support.ID, RoleReq{Name: "editor"})
+	expectProblem(t, err, business.PDTypeRoleAlreadyExists)
+	_, err = svc.UpdateRole(ctx, idkit.NilUUID, RoleReq{Name: "nobody"})
+	expectProblem(t, err, business.PDTypeRoleNotFound)
+
+	expectNoError(t, svc.DeleteRole(ctx, editor.ID))
+	expectProblem(t, svc.DeleteRole(ctx, editor.ID), business.PDTypeRoleNotFound)
+	_, err = svc.GetRole(ctx, editor.ID)
+	expectProblem(t, err, business.PDTypeRoleNotFound)
+}
+
+func TestService_Assignments(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	alice, err := svc.users.Create(ctx, user.CreateReq{Email: "alice@example.com", Name: "Alice"})
+	expectNoError(t, err)
+
+	reader, err := svc.CreateRole(ctx, RoleReq{Name: "reader", Permissions: []string{PermUsersRead, PermRolesRead}})
+	expectNoError(t, err)
+	writer, err := svc.CreateRole(ctx, RoleReq{Name: "writer", Permissions: []string{PermUsersRead, PermUsersWrite}})
+	expectNoError(t, err)
+
+	perms, err := svc.Permissions(ctx, alice.ID)
+	expectNoError(t, err)
+	expectTrue(t, len(perms) == 0)
+
+	expectNoError(t, svc.AssignRole(ctx, alice.ID, reader.ID))
+	expectNoError(t, svc.AssignRole(ctx, alice.ID, reader.ID)) // idempotent.
+	expectNoError(t, svc.AssignRole(ctx, alice.ID, writer.ID))
+	expectProblem(t, svc.AssignRole(ctx, idkit.NilUUID, reader.ID), business.PDTypeUserNotFound)
+	expectProblem(t, svc.AssignRole(ctx, alice.ID, idkit.NilUUID), business.PDTypeRoleNotFound)
+
+	perms, err = svc.Permissions(ctx, alice.ID)
+	expectNoError(t, err)
+	expectTrue(t, slices.Equal(perms, []string{PermRolesRead, PermUsersRead, PermUsersWrite}))
+
+	roles, err := svc.UserRoles(ctx, alice.ID)
+	expectNoError(t, err)
+	expectTrue(t, len(roles) == 2 && roles[0].ID == reader.ID && len(roles[0].Permissions) == 2)
+	_, err = svc.UserRoles(ctx, idkit.NilUUID)
+	expectProblem(t, err, business.PDTypeUserNotFound)
+
+	expectNoError(t, svc.UnassignRole(ctx, alice.ID, writer.ID))
+	expectNoError(t, svc.UnassignRole(ctx, alice.ID, writer.ID)) // idempotent.
+	perms, err = svc.Permissions(ctx, alice.ID)
+	expectNoError(t, err)
+	expectTrue(t, slices.Equal(perms, []string{PermRolesRead, PermUsersRead}))
+
+	// deleting the role revokes its permissions.
+	expectNoError(t, svc.DeleteRole(ctx, reader.ID))
+	perms, err = svc.Permissions(ctx, alice.ID)
+	expectNoError(t, err)
+	expectTrue(t, len(perms) == 0)
+}
+
+func TestService_Bootstrap(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	alice, err := svc.users.Create(ctx, user.CreateReq{Email: "alice@example.com", Name: "Alice"})
+	expectNoError(t, err)
+	mallory, err := svc.users.Create(ctx, user.CreateReq{Email: "mallory@example.com", Name: "Mallory"})
+	expectNoError(t, err)
+
+	cfg := Config{AdminUserIDs: []idkit.UUID{alice.ID, idkit.NilUUID}}
+	expectNoError(t, svc.Bootstrap(ctx, cfg))
+
+	all := slices.Clone(Permissions)
+	slices.Sort(all)
+
+	perms, err := svc.Permissions(ctx, alice.ID)
+	expectNoError(t, err)
+	expectTrue(t, slices.Equal(perms, all))
+
+	// the permissions of the admin role are restored on the next bootstrap.
+	admin, err := svc.repo.GetByName(ctx, AdminRole)
+	expectNoError(t, err)
+	_, err = svc.UpdateRole(ctx, admin.ID, RoleReq{Name: AdminRole, Permissions: []string{PermUsersRead}})
+	expectNoError(t, err)
+
+	// once the admin role has users, the config can't assign it anymore.
+	cfg.AdminUserIDs = append(cfg.AdminUserIDs, mallory.ID)
+	expectNoError(t, svc.Bootstrap(ctx, cfg))
+	perms, err = svc.Permissions(ctx, alice.ID)
+	expectNoError(t, err)
+	expectTrue(t, slices.Equal(perms, all))
+
+	perms, err = svc.Permissions(ctx, mallory.ID)
+	expectNoError(t, err)
+	expectTrue(t, len(perms) == 0)
+}
+
+func expectProblem(t *testing.T, err error, pdType string) {
+	t.Helper()
+	var pd problemdetail.ProblemDetailer
+	if !errors.As(err, &pd) {
+		t.Fatalf("expected problem %s, got %v", pdType, err)
+	}
+	if pd.Kind() != pdType {
+		t.Fatalf("expected problem %s, got %s", pdType, pd.Kind())
+	}
+}
+
+func expectNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("expect no error; got an error: %v", err)
+	}
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expect true; got false")
+	}
+}
@@ -7,12 +7,68 @@ type Status string //@name system.Status
 // Status constants.
 const (
 	StatusHealthy   Status = "healthy"
+	StatusDegraded  Status = "degraded"
 	StatusUnhealthy Status = "unhealthy"
 )
 
 // HealthRes represents the health status of a service.
 // swagger:model system.HealthRes
 type HealthRes struct {
-	Name   string `json:"name"`
-	Status Status `json:"status"`
+	Name      string  `json:"name"`
+	Status    Status  `json:"status"`
+	LatencyMs float64 `json:"latency_ms" example:"1.25"`
+	CheckedAt int64   `json:"checked_at" example:"1700000000000"`        // unix milliseconds, earlier if cached.
+	Error     string  `json:"error,omitempty" example:"check unhealthy"` // a generic reason, the error itself is logged.
 } //@name system.HealthRes
+
+// HealthReport represents the overall health status of the application and its dependencies.
+// swagger:model system.HealthReport
+type HealthReport struct {
+	Status Status      `json:"status"`
+	Checks []HealthRes `json:"checks"`
+} //@name system.HealthReport
+
+// LogLevel represents the minimum level of the application logger.
+// swagger:model system.LogLevel
+type LogLevel struct {
+	Level string `json:"level" example:"INFO"`
+} //@name system.LogLevel
+
+// RuntimeStats represents the statistics of the Go runtime, the garbage collector and the heap.
+// swagger:model system.RuntimeStats
+type RuntimeStats struct {
+	Goroutines    int     `json:"goroutines"`
+	CPUs          int     `json:"cpus"`
+	HeapAlloc     uint64  `json:"heap_alloc"`   // bytes of the allocated heap objects.
+	HeapInuse     uint64  `json:"heap_inuse"`   // bytes of the in-use heap spans.
+	HeapObjects   uint64  `json:"heap_objects"` // the number of the allocated heap objects.
+	Sys           uint64  `json:"sys"`          // bytes of the memory obtained from the OS.
+	NumGC         uint32  `json:"num_gc"`
+	NextGC        uint64  `json:"next_gc"`                         // the target heap size of the next GC cycle.
+	LastGC        int64   `json:"last_gc" example:"1700000000000"` // unix milliseconds, zero if never.
+	PauseTotalMs  float64 `json:"pause_total_ms"`
+	GCCPUFraction float64 `json:"gc_cpu_fraction"`
+} //@name system.RuntimeStats
+
+// BuildInfo represents the build metadata embedded in the binary by the Go toolchain.
+// swagger:model system.BuildInfo
+type BuildInfo struct {
+	GoVersion   string `json:"go_version" example:"go1.21.3"`
+	Path        string `json:"path" example:"github.com/josestg/swe-be-mono/cmd/enduser-restful"`
+	Version     string `json:"version" example:"(devel)"` // the version of the main module.
+	VCS         string `json:"vcs,omitempty" example:"git"`
+	VCSRevision string `json:"vcs_revision,omitempty"`
+	VCSTime     string `json:"vcs_time,omitempty" example:"2024-01-02T15:04:05Z"`
+	VCSModified bool   `json:"vcs_modified"` // the working tree had uncommitted changes when built.
+} //@name system.BuildInfo
+
+// RuntimeInfo represents the runtime environment of the application.
+// swagger:model system.RuntimeInfo
+type RuntimeInfo struct {
+	GOOS       string `json:"goos" example:"linux"`
+	GOARCH     string `json:"goarch" example:"amd64"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	NumCPU     int    `json:"num_cpu"`
+	StartedAt  int64  `json:"started_at" example:"1700000000000"` // unix milliseconds.
+	Uptime     string `json:"uptime" example:"72h3m0.5s"`
+} //@name system.RuntimeInfo
@@ -0,0 +1,83 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+// Repository stores the users in the users table. The methods return sqlxkit.ErrNotFound if there is no such user and
+// sqlxkit.ErrDuplicate if the email is taken, see sqlxkit.TranslateError.
+type Repository struct {
+	db          sqlxkit.DB
+	crud        *sqlxkit.Repository[User]
+	getByEmailQ string
+	listQ       string
+	countQ      string
+}
+
+// NewRepository creates a new Repository.
+func NewRepository(db sqlxkit.DB) (*Repository, error) {
+	crud, err := sqlxkit.NewRepository[User](db, "users", "id")
+	if err != nil {
+		return nil, fmt.Errorf("create crud repository: %w", err)
+	}
+
+	const columns = "id, email, name, created_at, updated_at"
+	return &Repository{
+		db:          db,
+		crud:        crud,
+		getByEmailQ: db.Rebind("SELECT " + columns + " FROM users WHERE email = ?"),
+		listQ:       db.Rebind("SELECT " + columns + " FROM users ORDER BY created_at, id LIMIT ? OFFSET ?"),
+		countQ:      "SELECT COUNT(*) FROM users",
+	}, nil
+}
+
+// Insert inserts the user.
+func (r *Repository) Insert(ctx context.Context, u User) error {
+	return sqlxkit.TranslateError(r.crud.Insert(ctx, u))
+}
+
+// Update updates the user identified by its ID.
+func (r *Repository) Update(ctx context.Context, u User) error {
+	return sqlxkit.TranslateError(r.crud.Update(ctx, u))
+}
+
+// Delete deletes the user identified by the id.
+func (r *Repository) Delete(ctx context.Context, id idkit.UUID) error {
+	return r.crud.Delete(ctx, id)
+}
+
+// Get gets the user identified by the id.
+func (r *Repository) Get(ctx context.Context, id idkit.UUID) (User, error) {
+	return r.crud.GetByID(ctx, id)
+}
+
+// GetByEmail gets the user by the lowercased email.
+func (r *Repository) GetByEmail(ctx context.Context, email string) (User, error) {
+	u, err := sqlxkit.One[User](ctx, sqlxkit.TxOrDB(ctx, r.db), r.getByEmailQ, email)
+	if err != nil {
+		return u, fmt.Errorf("get user by email: %w", err)
+	}
+	return u, nil
+}
+
+// List lists the users ordered by the creation time, skipping the first offset users.
+func (r *Repository) List(ctx context.Context, limit, offset int) ([]User, error) {
+	users, err := sqlxkit.All[User](ctx, sqlxkit.TxOrDB(ctx, r.db), r.listQ, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	return users, nil
+}
+
+// Count counts all users.
+func (r *Repository) Count(ctx context.Context) (int64, error) {
+	n, err := sqlxkit.One[int64](ctx, sqlxkit.TxOrDB(ctx, r.db), r.countQ)
+	if err != nil {
+		return 0, fmt.Errorf("count users: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,133 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+// Service is the business rules of the user accounts. The requests are expected to be validated by the caller, e.g.
+// by the validate tags of CreateReq and UpdateReq.
+type Service struct {
+	repo *Repository
+	now  func() time.Time
+}
+
+// NewService creates a new Service.
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo, now: time.Now}
+}
+
+// Create creates a user, the ID is requested from the idkit provider in the context, see idkit.FromContext. It
+// returns the PDTypeEmailAlreadyTaken problem if the email is taken.
+func (s *Service) Create(ctx context.Context, req CreateReq) (User, error) {
+	id, err := idkit.FromContext(ctx).Request(ctx)
+	if err != nil {
+		return User{}, fmt.Errorf("request user id: %w", err)
+	}
+
+	now := s.timestamp()
+	u := User{
+		ID:        idkit.UUID(id),
+		Email:     normalizeEmail(req.Email),
+		Name:      strings.TrimSpace(req.Name),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.repo.Insert(ctx, u); err != nil {
+		if errors.Is(err, sqlxkit.ErrDuplicate) {
+			return User{}, emailTaken(u.Email, err)
+		}
+		return User{}, fmt.Errorf("insert user: %w", err)
+	}
+	return u, nil
+}
+
+// Get gets a user by the id. It returns the PDTypeUserNotFound problem if there is no such user.
+func (s *Service) Get(ctx context.Context, id idkit.UUID) (User, error) {
+	u, err := s.repo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return User{}, notFound(id, err)
+		}
+		return User{}, fmt.Errorf("get user: %w", err)
+	}
+	return u, nil
+}
+
+// GetByEmail gets a user by the email, case-insensitively. It returns the PDTypeUserNotFound problem if there is no
+// such user.
+func (s *Service) GetByEmail(ctx context.Context, email string) (User, error) {
+	email = normalizeEmail(email)
+	u, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return User{}, notFound(email, err)
+		}
+		return User{}, fmt.Errorf("get user by email: %w", err)
+	}
+	return u, nil
+}
+
+// List lists a page of the users ordered by the creation time, along with the total number of the users. The page is
+// 1-based.
+func (s *Service) List(ctx context.Context, page, perPage int) ([]User, int64, error) {
+	users, err := s.repo.List(ctx, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// Update replaces the email and the name of a user. It returns the PDTypeUserNotFound problem if there is no such
+// user, or the PDTypeEmailAlreadyTaken problem if the email is taken by another user.
+func (s *Service) Update(ctx context.Context, id idkit.UUID, req UpdateReq) (User, error) {
+	u, err := s.Get(ctx, id)
+	if err != nil {
+		return User{}, err
+	}
+
+	u.Email = normalizeEmail(req.Email)
+	u.Name = strings.TrimSpace(req.Name)
+	u.UpdatedAt = s.timestamp()
+
+	if err := s.repo.Update(ctx, u); err != nil {
+		switch {
+		case errors.Is(err, sqlxkit.ErrDuplicate):
+			return User{}, emailTaken(u.Email, err)
+		case errors.Is(err, sqlxkit.ErrNotFound): // deleted in between.
+			return User{}, notFound(id, err)
+		default:
+			return User{}, fmt.Errorf("update user: %w", err)
+		}
+	}
+	return u, nil
+}
+
+// Delete deletes a user by the id. It returns the PDTypeUserNotFound problem if there is no such user.
+func (s *Service) Delete(ctx context.Context, id idkit.UUID) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, sqlxkit.ErrNotFound) {
+			return notFound(id, err)
+		}
+		return fmt.Errorf("delete user: %w", err)
+	}
+	return nil
+}
+
+// timestamp returns the current time in UTC, truncated to the precision of the PostgreSQL timestamps.
+func (s *Service) timestamp() time.Time { return s.now().UTC().Truncate(time.Microsecond) }
+
+// normalizeEmail normalizes the email for the uniqueness, e.g. Alice@Example.com and alice@example.com are the same.
+func normalizeEmail(email string) string { return strings.ToLower(strings.TrimSpace(email)) }
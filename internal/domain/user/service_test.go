@@ -0,0 +1,125 @@
+//go:build cgo
+
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit/sqlxkittest"
+	"github.com/josestg/swe-be-mono/resources/migrations"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	db, teardown := sqlxkittest.Setup(t, migrations.Postgre())
+	t.Cleanup(teardown)
+
+	repo, err := NewRepository(db)
+	expectNoError(t, err)
+
+	svc := NewService(repo)
+	svc.now = func() time.Time { return time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC) }
+	return svc
+}
+
+func TestService(t *testing.T) {
+	ctx := idkit.WithProvider(context.Background(), idkit.NewSequentialUUIDProvider(0))
+	svc := newTestService(t)
+
+	alice, err := svc.Create(ctx, CreateReq{Email: " Alice@Example.com ", Name: " Alice "})
+	expectNoError(t, err)
+	expectTrue(t, alice.Email == "alice@example.com")
+	expectTrue(t, alice.Name == "Alice")
+	expectTrue(t, alice.ID.String() == "00000000-0000-4000-8000-000000000001")
+
+	got, err := svc.Get(ctx, alice.ID)
+	expectNoError(t, err)
+	expectTrue(t, got == alice)
+
+	_, err = svc.Create(ctx, CreateReq{Email: "ALICE@example.com", Name: "Impostor"})
+	expectProblem(t, err, business.PDTypeEmailAlreadyTaken)
+
+	bob, err := svc.Create(ctx, CreateReq{Email: "bob@example.com", Name: "Bob"})
+	expectNoError(t, err)
+
+	users, total, err := svc.List(ctx, 1, 1)
+	expectNoError(t, err)
+	expectTrue(t, total == 2)
+	expectTrue(t, len(users) == 1 && users[0].ID == alice.ID)
+
+	users, _, err = svc.List(ctx, 2, 1)
+	expectNoError(t, err)
+	expectTrue(t, len(users) == 1 && users[0].ID == bob.ID)
+
+	users, _, err = svc.List(ctx, 3, 1)
+	expectNoError(t, err)
+	expectTrue(t, len(users) == 0)
+
+	_, err = svc.Update(ctx, bob.ID, UpdateReq{Email: "alice@example.com", Name: "Bob"})
+	expectProblem(t, err, business.PDTypeEmailAlreadyTaken)
+
+	svc.now = func() time.Time { return time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC) }
+	bob, err = svc.Update(ctx, bob.ID, UpdateReq{Email: "Robert@Example.com", Name: "Robert"})
+	expectNoError(t, err)
+	expectTrue(t, bob.Email == "robert@example.com")
+	expectTrue(t, bob.UpdatedAt.After(bob.CreatedAt))
+
+	got, err = svc.Get(ctx, bob.ID)
+	expectNoError(t, err)
+	expectTrue(t, got == bob)
+
+	expectNoError(t, svc.Delete(ctx, bob.ID))
+	expectProblem(t, svc.Delete(ctx, bob.ID), business.PDTypeUserNotFound)
+
+	_, err = svc.Get(ctx, bob.ID)
+	expectProblem(t, err, business.PDTypeUserNotFound)
+
+	_, err = svc.Update(ctx, bob.ID, UpdateReq{Email: "bob@example.com", Name: "Bob"})
+	expectProblem(t, err, business.PDTypeUserNotFound)
+}
+
+func TestRepository_GetByEmail(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	alice, err := svc.Create(ctx, CreateReq{Email: "alice@example.com", Name: "Alice"})
+	expectNoError(t, err)
+
+	got, err := svc.repo.GetByEmail(ctx, "alice@example.com")
+	expectNoError(t, err)
+	expectTrue(t, got == alice)
+
+	_, err = svc.repo.GetByEmail(ctx, "bob@example.com")
+	expectTrue(t, err != nil)
+}
+
+func expectProblem(t *testing.T, err error, pdType string) {
+	t.Helper()
+	var pd problemdetail.ProblemDetailer
+	if !errors.As(err, &pd) {
+		t.Fatalf("expected problem %s, got %v", pdType, err)
+	}
+	if pd.Kind() != pdType {
+		t.Fatalf("expected problem %s, got %s", pdType, pd.Kind())
+	}
+}
+
+func expectNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
@@ -0,0 +1,75 @@
+// Package user is the domain of the user accounts: the entity, its storage and the business rules.
+package user
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+)
+
+// User is the entity of a user account, stored in the users table.
+type User struct {
+	ID        idkit.UUID `sql:"id"`
+	Email     string     `sql:"email"` // lowercased, unique.
+	Name      string     `sql:"name"`
+	CreatedAt time.Time  `sql:"created_at"` // UTC.
+	UpdatedAt time.Time  `sql:"updated_at"` // UTC.
+}
+
+// Res converts the user to its response model.
+func (u User) Res() UserRes {
+	return UserRes{
+		ID:        u.ID.String(),
+		Email:     u.Email,
+		Name:      u.Name,
+		CreatedAt: u.CreatedAt.UnixMilli(),
+		UpdatedAt: u.UpdatedAt.UnixMilli(),
+	}
+}
+
+// CreateReq represents the request for creating a user.
+// swagger:model user.CreateReq
+type CreateReq struct {
+	Email string `json:"email" validate:"required,email,max=320" example:"alice@example.com"`
+	Name  string `json:"name" validate:"required,max=100" example:"Alice"`
+} //@name user.CreateReq
+
+// UpdateReq represents the request for updating a user, all fields are replaced.
+// swagger:model user.UpdateReq
+type UpdateReq struct {
+	Email string `json:"email" validate:"required,email,max=320" example:"alice@example.com"`
+	Name  string `json:"name" validate:"required,max=100" example:"Alice"`
+} //@name user.UpdateReq
+
+// UserRes represents a user account.
+// swagger:model user.UserRes
+type UserRes struct {
+	ID        string `json:"id" example:"f8635b1a-3524-4441-8c22-10edf1c45407"`
+	Email     string `json:"email" example:"alice@example.com"`
+	Name      string `json:"name" example:"Alice"`
+	CreatedAt int64  `json:"created_at" example:"1700000000000"` // unix milliseconds.
+	UpdatedAt int64  `json:"updated_at" example:"1700000000000"` // unix milliseconds.
+} //@name user.UserRes
+
+// notFound creates an error that is mapped to 404 Not Found, the user is identified by the ID or the email.
+func notFound(user any, cause error) error {
+	pd := problemdetail.New(business.PDTypeUserNotFound,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("User Not Found"),
+		problemdetail.WithDetail(fmt.Sprintf("user %s does not exist", user)),
+	)
+	return fmt.Errorf("%w: %w", pd, cause)
+}
+
+// emailTaken creates an error that is mapped to 409 Conflict.
+func emailTaken(email string, cause error) error {
+	pd := problemdetail.New(business.PDTypeEmailAlreadyTaken,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("Email Already Taken"),
+		problemdetail.WithDetail(fmt.Sprintf("email %s is already taken by another user", email)),
+	)
+	return fmt.Errorf("%w: %w", pd, cause)
+}
@@ -0,0 +1,247 @@
+package httphandler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/josestg/swe-be-mono/internal/domain/auth"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/internal/kernel"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/passwd"
+)
+
+// Auth is a handler for the registration, the issuance of the tokens and the profile of the authenticated user.
+type Auth struct {
+	auth     *auth.Service
+	tokens   *auth.TokenService
+	users    *user.Service
+	validate *validator.Validate
+}
+
+// ServeAuth registers the auth handler to the given mux. The authn middleware authenticates the requests of the
+// profile, e.g. httpmiddleware.JWTAuth verifying the access tokens by the key set of the tokens.
+func ServeAuth(
+	mux *httpkit.ServeMux,
+	authService *auth.Service,
+	tokens *auth.TokenService,
+	users *user.Service,
+	authn httpkit.MuxMiddleware,
+) {
+	h := &Auth{
+		auth:     authService,
+		tokens:   tokens,
+		users:    users,
+		validate: httpkit.NewValidator(),
+	}
+	mux.Route(h.Register())
+	mux.Route(h.Token())
+	mux.Route(h.Refresh())
+	mux.Route(h.Revoke())
+	mux.Route(h.Me(), authn)
+}
+
+// Register returns the route for registering a user.
+//
+//	@Tags			Enduser
+//	@Summary		Register a user.
+//	@Description	Creates a user account with the password, the email is lowercased and must be unique. The password
+//	@Description	violations of the policy are reported as the errors of the password field.
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		auth.RegisterReq	true	"The user to register."
+//	@Success		201		{object}	kernel.HttpRes[user.UserRes]
+//	@Failure		400		{object}	httpkit.ValidationProblem
+//	@Failure		409		{object}	problemdetail.ProblemDetail
+//	@Failure		422		{object}	httpkit.ValidationProblem
+//	@Router			/auth/register [post]
+func (h *Auth) Register() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPost,
+		Path:    "/auth/register",
+		Handler: h.register,
+		Name:    "auth.register",
+	}
+}
+
+// Token returns the route for logging in by the password.
+//
+//	@Tags			Enduser
+//	@Summary		Log in.
+//	@Description	Verifies the email and the password, and issues a short-lived access token for the Authorization
+//	@Description	header and a refresh token for renewing it. If the two-factor authentication is active, the otp is
+//	@Description	required: the code of the authenticator app or a recovery code.
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		auth.LoginReq	true	"The credentials."
+//	@Success		200		{object}	kernel.HttpRes[auth.TokenRes]
+//	@Failure		400		{object}	httpkit.ValidationProblem
+//	@Failure		401		{object}	problemdetail.ProblemDetail
+//	@Failure		422		{object}	httpkit.ValidationProblem
+//	@Failure		429		{object}	problemdetail.ProblemDetail
+//	@Router			/auth/token [post]
+func (h *Auth) Token() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPost,
+		Path:    "/auth/token",
+		Handler: h.token,
+		Name:    "auth.token",
+	}
+}
+
+// Refresh returns the route for refreshing the tokens.
+//
+//	@Tags			Enduser
+//	@Summary		Refresh the tokens.
+//	@Description	Issues new tokens by the refresh token, which is rotated: it can't be used again. Reusing a rotated
+//	@Description	refresh token revokes all the refresh tokens descending from the same login.
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		auth.RefreshReq	true	"The refresh token."
+//	@Success		200		{object}	kernel.HttpRes[auth.TokenRes]
+//	@Failure		400		{object}	httpkit.ValidationProblem
+//	@Failure		401		{object}	problemdetail.ProblemDetail
+//	@Failure		422		{object}	httpkit.ValidationProblem
+//	@Router			/auth/refresh [post]
+func (h *Auth) Refresh() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPost,
+		Path:    "/auth/refresh",
+		Handler: h.refresh,
+		Name:    "auth.refresh",
+	}
+}
+
+// Revoke returns the route for revoking the refresh token.
+//
+//	@Tags			Enduser
+//	@Summary		Log out.
+//	@Description	Revokes the refresh token and all the refresh tokens descending from the same login. The unknown
+//	@Description	tokens are ignored. The issued access tokens remain valid until they expire.
+//	@Accept			json
+//	@Param			body	body	auth.RefreshReq	true	"The refresh token."
+//	@Success		204
+//	@Failure		400	{object}	httpkit.ValidationProblem
+//	@Failure		422	{object}	httpkit.ValidationProblem
+//	@Router			/auth/revoke [post]
+func (h *Auth) Revoke() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPost,
+		Path:    "/auth/revoke",
+		Handler: h.revoke,
+		Name:    "auth.revoke",
+	}
+}
+
+// Me returns the route for getting the authenticated user.
+//
+//	@Tags			Enduser
+//	@Summary		The authenticated user.
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			fields	query		string	false	"The comma-separated fields of the user, e.g. id,email."
+//	@Success		200	{object}	kernel.HttpRes[user.UserRes]
+//	@Failure		401	{object}	problemdetail.ProblemDetail
+//	@Failure		404	{object}	problemdetail.ProblemDetail
+//	@Router			/me [get]
+func (h *Auth) Me() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/me",
+		Handler: h.me,
+		Name:    "me",
+	}
+}
+
+func (h *Auth) register(w http.ResponseWriter, r *http.Request) error {
+	var req auth.RegisterReq
+	if err := readValidRequest(r, h.validate, &req); err != nil {
+		return err
+	}
+
+	u, err := h.auth.Register(r.Context(), req)
+	if err != nil {
+		var violations passwd.Violations
+		if errors.As(err, &violations) {
+			return passwordProblem(violations, err)
+		}
+		return fmt.Errorf("register: %w", err)
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), u.Res()).Code(http.StatusCreated).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *Auth) token(w http.ResponseWriter, r *http.Request) error {
+	var req auth.LoginReq
+	if err := readValidRequest(r, h.validate, &req); err != nil {
+		return err
+	}
+
+	pair, err := h.auth.Login(r.Context(), req)
+	if err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), pair.Res(time.Now())).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *Auth) refresh(w http.ResponseWriter, r *http.Request) error {
+	var req auth.RefreshReq
+	if err := readValidRequest(r, h.validate, &req); err != nil {
+		return err
+	}
+
+	pair, err := h.tokens.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		return fmt.Errorf("refresh: %w", err)
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), pair.Res(time.Now())).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *Auth) revoke(w http.ResponseWriter, r *http.Request) error {
+	var req auth.RefreshReq
+	if err := readValidRequest(r, h.validate, &req); err != nil {
+		return err
+	}
+
+	if err := h.tokens.Revoke(r.Context(), req.RefreshToken); err != nil {
+		return fmt.Errorf("revoke: %w", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *Auth) me(w http.ResponseWriter, r *http.Request) error {
+	id, err := subjectUUID(r)
+	if err != nil {
+		return fmt.Errorf("me: %w", err)
+	}
+
+	u, err := h.users.Get(r.Context(), id)
+	if err != nil {
+		return fmt.Errorf("me: %w", err)
+	}
+
+	res, err := kernel.SelectFields(kernel.NewHttpResBuilderCtx(r.Context(), u.Res()).Build(), kernel.RequestedFields(r))
+	if err != nil {
+		return fmt.Errorf("me: %w", err)
+	}
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+// passwordProblem converts the password violations to the errors of the password field.
+func passwordProblem(violations passwd.Violations, err error) error {
+	fields := make([]httpkit.FieldError, 0, len(violations))
+	for _, v := range violations {
+		fields = append(fields, httpkit.FieldError{Field: "password", Code: v.Code, Message: v.Message})
+	}
+	return fmt.Errorf("%w: %w", httpkit.NewValidationProblem(fields...), err)
+}
@@ -0,0 +1,118 @@
+//go:build cgo
+
+package httphandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/josestg/swe-be-mono/internal/domain/auth"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/internal/httpmiddleware"
+	"github.com/josestg/swe-be-mono/internal/kernel"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/passwd"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit/sqlxkittest"
+	"github.com/josestg/swe-be-mono/resources/migrations"
+)
+
+func TestAuth(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+
+	db, teardown := sqlxkittest.Setup(t, migrations.Postgre())
+	t.Cleanup(teardown)
+
+	repo, err := user.NewRepository(db)
+	expectTrue(t, err == nil)
+	users := user.NewService(repo)
+
+	tokens, err := auth.NewTokenService(db, auth.TokenConfig{Keys: map[string]string{"k1": "HS256:" + secret}})
+	expectTrue(t, err == nil)
+
+	hasher := passwd.NewHasher(passwd.Argon2id{Memory: 64, Time: 1, Parallelism: 1})
+	authService, err := auth.NewService(db, users, tokens, auth.ServiceConfig{Hasher: hasher})
+	expectTrue(t, err == nil)
+
+	mux := newTestMux()
+	authn := httpmiddleware.JWTAuth(httpmiddleware.JWTAuthConfig{KeySet: tokens.KeySet()})
+	ServeAuth(mux, authService, tokens, users, authn)
+
+	do := func(method, path, body, token string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	register := `{"email": "alice@example.com", "name": "Alice", "password": "Tr0ub4dor&3x"}`
+	rec := do(http.MethodPost, "/auth/register", register, "")
+	expectTrue(t, rec.Code == http.StatusCreated)
+
+	var registered kernel.HttpRes[user.UserRes]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&registered) == nil)
+
+	rec = do(http.MethodPost, "/auth/register", register, "")
+	expectTrue(t, rec.Code == http.StatusConflict)
+
+	rec = do(http.MethodPost, "/auth/register", `{"email": "bob@example.com", "name": "Bob", "password": "short"}`, "")
+	expectTrue(t, rec.Code == http.StatusUnprocessableEntity)
+
+	var problem httpkit.ValidationProblem
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&problem) == nil)
+	expectTrue(t, len(problem.Errors) > 0 && problem.Errors[0].Field == "password")
+
+	rec = do(http.MethodPost, "/auth/token", `{"email": "alice@example.com", "password": "wrong"}`, "")
+	expectTrue(t, rec.Code == http.StatusUnauthorized)
+
+	rec = do(http.MethodPost, "/auth/token", `{"email": "alice@example.com", "password": "Tr0ub4dor&3x"}`, "")
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	var token kernel.HttpRes[auth.TokenRes]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&token) == nil)
+	expectTrue(t, token.Data.TokenType == "Bearer" && token.Data.ExpiresIn > 0)
+	expectTrue(t, token.Data.RefreshToken != "" && token.Data.RefreshExpiresIn > token.Data.ExpiresIn)
+
+	rec = do(http.MethodGet, "/me", "", "")
+	expectTrue(t, rec.Code == http.StatusUnauthorized)
+
+	rec = do(http.MethodGet, "/me", "", token.Data.AccessToken)
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	var me kernel.HttpRes[user.UserRes]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&me) == nil)
+	expectTrue(t, me.Data.ID == registered.Data.ID)
+
+	rec = do(http.MethodGet, "/me?fields=id,email", "", token.Data.AccessToken)
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	var partial kernel.HttpRes[map[string]any]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&partial) == nil)
+	expectTrue(t, len(partial.Data) == 2 && partial.Data["id"] == registered.Data.ID)
+
+	refresh := `{"refresh_token": "` + token.Data.RefreshToken + `"}`
+	rec = do(http.MethodPost, "/auth/refresh", refresh, "")
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	var refreshed kernel.HttpRes[auth.TokenRes]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&refreshed) == nil)
+	expectTrue(t, refreshed.Data.RefreshToken != token.Data.RefreshToken)
+
+	rec = do(http.MethodGet, "/me", "", refreshed.Data.AccessToken)
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	// the rotated refresh token can't be used again.
+	rec = do(http.MethodPost, "/auth/refresh", refresh, "")
+	expectTrue(t, rec.Code == http.StatusUnauthorized)
+
+	rec = do(http.MethodPost, "/auth/refresh", `{}`, "")
+	expectTrue(t, rec.Code == http.StatusUnprocessableEntity)
+
+	rec = do(http.MethodPost, "/auth/revoke", `{"refresh_token": "`+refreshed.Data.RefreshToken+`"}`, "")
+	expectTrue(t, rec.Code == http.StatusNoContent)
+}
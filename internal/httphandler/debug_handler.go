@@ -0,0 +1,147 @@
+package httphandler
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/josestg/swe-be-mono/internal/domain/system"
+	"github.com/josestg/swe-be-mono/internal/kernel"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+// Debug is a handler for serving the runtime diagnostics for profiling production instances.
+type Debug struct {
+	config any
+}
+
+// ServeDebug registers the debug handler to the given mux, the middlewares are applied to all debug routes, e.g.
+// for requiring authentication. The config is the effective configuration served by Config, its secrets must be
+// redacted already, see config.Config.Redacted. Config isn't registered if the config is nil, e.g. when the routes
+// aren't authenticated.
+func ServeDebug(mux *httpkit.ServeMux, config any, mid ...httpkit.MuxMiddleware) {
+	dbg := &Debug{config: config}
+	for _, route := range dbg.Pprof() {
+		mux.Route(route, mid...)
+	}
+	mux.Route(dbg.Vars(), mid...)
+	mux.Route(dbg.Runtime(), mid...)
+	if config != nil {
+		mux.Route(dbg.Config(), mid...)
+	}
+}
+
+// Pprof returns the routes of net/http/pprof, e.g. /system/debug/pprof/heap or /system/debug/pprof/profile?seconds=5.
+// The CPU profile and the trace longer than the server write timeout are rejected by pprof.
+func (h *Debug) Pprof() []httpkit.Route {
+	return []httpkit.Route{
+		{Method: http.MethodGet, Path: "/system/debug/pprof/*profile", Handler: h.pprof},
+		{Method: http.MethodPost, Path: "/system/debug/pprof/symbol", Handler: h.pprof},
+	}
+}
+
+// Vars returns the exported variables of expvar, including the memstats and the command line.
+//
+//	@Tags			System
+//	@Summary		Exported variables.
+//	@Description	Returns the variables exported by the expvar package.
+//	@Produce		json
+//	@Success		200
+//	@Router			/system/debug/vars [get]
+func (h *Debug) Vars() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/system/debug/vars",
+		Handler: h.vars,
+	}
+}
+
+// Runtime returns the summary of the Go runtime, the garbage collector and the heap.
+//
+//	@Tags			System
+//	@Summary		Runtime statistics.
+//	@Description	Returns the statistics of the Go runtime, the garbage collector and the heap.
+//	@Produce		json
+//	@Success		200	{object}	kernel.HttpRes[system.RuntimeStats]
+//	@Router			/system/debug/runtime [get]
+func (h *Debug) Runtime() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/system/debug/runtime",
+		Handler: h.runtime,
+	}
+}
+
+// Config returns the effective configuration of the application, the secrets are redacted.
+//
+//	@Tags			System
+//	@Summary		Effective configuration.
+//	@Description	Returns the configuration resolved from the defaults and the environment, the secrets are redacted.
+//	@Description	It is only served when SYSTEM_DEBUG_API_KEY is set.
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	kernel.HttpRes[object]
+//	@Router			/system/config [get]
+func (h *Debug) Config() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/system/config",
+		Handler: h.configuration,
+	}
+}
+
+func (h *Debug) pprof(w http.ResponseWriter, r *http.Request) error {
+	// pprof.Index serves the named profiles only under /debug/pprof/.
+	r.URL.Path = strings.TrimPrefix(r.URL.Path, "/system")
+
+	switch strings.TrimPrefix(r.URL.Path, "/debug/pprof/") {
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Index(w, r)
+	}
+	return nil
+}
+
+func (h *Debug) vars(w http.ResponseWriter, r *http.Request) error {
+	expvar.Handler().ServeHTTP(w, r)
+	return nil
+}
+
+func (h *Debug) runtime(w http.ResponseWriter, r *http.Request) error {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := system.RuntimeStats{
+		Goroutines:    runtime.NumGoroutine(),
+		CPUs:          runtime.NumCPU(),
+		HeapAlloc:     mem.HeapAlloc,
+		HeapInuse:     mem.HeapInuse,
+		HeapObjects:   mem.HeapObjects,
+		Sys:           mem.Sys,
+		NumGC:         mem.NumGC,
+		NextGC:        mem.NextGC,
+		PauseTotalMs:  float64(mem.PauseTotalNs) / float64(time.Millisecond),
+		GCCPUFraction: mem.GCCPUFraction,
+	}
+	if mem.LastGC > 0 {
+		stats.LastGC = time.Unix(0, int64(mem.LastGC)).UnixMilli()
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), stats).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *Debug) configuration(w http.ResponseWriter, r *http.Request) error {
+	res := kernel.NewHttpResBuilderCtx(r.Context(), h.config).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
@@ -0,0 +1,108 @@
+//go:build cgo
+
+package httphandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/josestg/swe-be-mono/internal/domain/system"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+func TestDebug_Config(t *testing.T) {
+	mux := newTestMux()
+	ServeDebug(mux, map[string]string{"Password": "[REDACTED]"})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/system/config", nil))
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	var res struct {
+		Data map[string]string `json:"data"`
+	}
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&res) == nil)
+	expectTrue(t, res.Data["Password"] == "[REDACTED]")
+
+	mux = newTestMux()
+	ServeDebug(mux, nil)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/system/config", nil))
+	expectTrue(t, rec.Code == http.StatusNotFound)
+}
+
+func TestDebug_Runtime(t *testing.T) {
+	mux := newTestMux()
+	ServeDebug(mux, nil)
+	runtime.GC()
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/system/debug/runtime", nil))
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	var res struct {
+		Data system.RuntimeStats `json:"data"`
+	}
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&res) == nil)
+	expectTrue(t, res.Data.Goroutines > 0 && res.Data.CPUs == runtime.NumCPU())
+	expectTrue(t, res.Data.HeapAlloc > 0 && res.Data.Sys > 0)
+	expectTrue(t, res.Data.NumGC > 0 && res.Data.LastGC > 0)
+}
+
+func TestDebug_Vars(t *testing.T) {
+	mux := newTestMux()
+	ServeDebug(mux, nil)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/system/debug/vars", nil))
+	expectTrue(t, rec.Code == http.StatusOK)
+	expectTrue(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json"))
+
+	var vars map[string]json.RawMessage
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&vars) == nil)
+	_, hasCmdline := vars["cmdline"]
+	_, hasMemstats := vars["memstats"]
+	expectTrue(t, hasCmdline && hasMemstats)
+}
+
+func TestDebug_Pprof(t *testing.T) {
+	mux := newTestMux()
+	ServeDebug(mux, nil)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/system/debug/pprof/", nil))
+	expectTrue(t, rec.Code == http.StatusOK)
+	expectTrue(t, strings.Contains(rec.Body.String(), "goroutine"))
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/system/debug/pprof/goroutine?debug=1", nil))
+	expectTrue(t, rec.Code == http.StatusOK)
+	expectTrue(t, strings.Contains(rec.Body.String(), "goroutine profile"))
+}
+
+func TestDebug_Middlewares(t *testing.T) {
+	deny := func(httpkit.Handler) httpkit.Handler {
+		return httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			w.WriteHeader(http.StatusUnauthorized)
+			return nil
+		})
+	}
+
+	mux := newTestMux()
+	ServeDebug(mux, map[string]string{}, deny)
+
+	// the middlewares guard all debug routes.
+	paths := []string{"/system/debug/pprof/", "/system/debug/vars", "/system/debug/runtime", "/system/config"}
+	for _, path := range paths {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected %s is guarded, got %d", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package httphandler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/josestg/swe-be-mono/internal/domain/auth"
+	"github.com/josestg/swe-be-mono/internal/domain/identity"
+	"github.com/josestg/swe-be-mono/internal/kernel"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/oidckit"
+	"github.com/josestg/swe-be-mono/pkg/sessionkit"
+)
+
+// Set of the session keys of the pending sign-in, see identity.PendingSignIn.
+const (
+	oidcPendingUserKey    = "oidc.pending.user"
+	oidcPendingExpiresKey = "oidc.pending.expires"
+)
+
+// OIDC is a handler for the sign-in by the OAuth 2.0 and OpenID Connect providers. The state, the nonce and the
+// PKCE code verifier of the authorization requests, and the sign-ins pending for the one-time password, are kept in
+// the session, so the routes must be behind sessionkit.Manager.Middleware.
+type OIDC struct {
+	identities *identity.Service
+	validate   *validator.Validate
+}
+
+// ServeOIDC registers the OIDC handler to the given mux.
+func ServeOIDC(mux *httpkit.ServeMux, identities *identity.Service) {
+	h := &OIDC{
+		identities: identities,
+		validate:   httpkit.NewValidator(),
+	}
+	mux.Route(h.Authorize())
+	mux.Route(h.Callback())
+	mux.Route(h.OTP())
+}
+
+// Authorize returns the route for starting the sign-in by a provider.
+//
+//	@Tags			Enduser
+//	@Summary		Sign in by a provider.
+//	@Description	Redirects the user to the provider, e.g. google or github, by the authorization code flow with
+//	@Description	PKCE. The provider redirects the user back to the callback route.
+//	@Param			provider	path	string	true	"The name of the provider."
+//	@Success		302
+//	@Failure		404	{object}	problemdetail.ProblemDetail
+//	@Router			/auth/oidc/{provider}/authorize [get]
+func (h *OIDC) Authorize() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/auth/oidc/:provider/authorize",
+		Handler: h.authorize,
+		Name:    "auth.oidc.authorize",
+	}
+}
+
+// Callback returns the route for completing the sign-in by a provider.
+//
+//	@Tags			Enduser
+//	@Summary		Complete the sign-in by a provider.
+//	@Description	Exchanges the authorization code, and issues the tokens of the user linked to the user of the
+//	@Description	provider. On the first sign-in, the user of the provider is linked to the user of the same email, or
+//	@Description	to a new user if there is none, so the email must be verified by the provider. If the TOTP of the
+//	@Description	user is active, the sign-in is pending until it is completed by the OTP route.
+//	@Produce		json
+//	@Param			provider	path		string	true	"The name of the provider."
+//	@Param			code		query		string	true	"The authorization code."
+//	@Param			state		query		string	true	"The state of the authorization request."
+//	@Success		200			{object}	kernel.HttpRes[auth.TokenRes]
+//	@Success		202			{object}	kernel.HttpRes[identity.PendingSignInRes]
+//	@Failure		400			{object}	httpkit.ValidationProblem
+//	@Failure		401			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Router			/auth/oidc/{provider}/callback [get]
+func (h *OIDC) Callback() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/auth/oidc/:provider/callback",
+		Handler: h.callback,
+		Name:    "auth.oidc.callback",
+	}
+}
+
+// OTP returns the route for completing the sign-in pending for the one-time password.
+//
+//	@Tags			Enduser
+//	@Summary		Complete the sign-in by the one-time password.
+//	@Description	Verifies the code of the authenticator app or a recovery code of the user of the pending sign-in,
+//	@Description	and issues the tokens.
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		auth.TOTPCodeReq	true	"The one-time password."
+//	@Success		200		{object}	kernel.HttpRes[auth.TokenRes]
+//	@Failure		400		{object}	httpkit.ValidationProblem
+//	@Failure		401		{object}	problemdetail.ProblemDetail
+//	@Failure		422		{object}	httpkit.ValidationProblem
+//	@Failure		429		{object}	problemdetail.ProblemDetail
+//	@Router			/auth/oidc/otp [post]
+func (h *OIDC) OTP() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPost,
+		Path:    "/auth/oidc/otp",
+		Handler: h.otp,
+		Name:    "auth.oidc.otp",
+	}
+}
+
+func (h *OIDC) authorize(w http.ResponseWriter, r *http.Request) error {
+	sess, err := session(r)
+	if err != nil {
+		return fmt.Errorf("authorize: %w", err)
+	}
+
+	var params [3]string // the state, the nonce and the verifier.
+	for i := range params {
+		if params[i], err = oidckit.NewVerifier(); err != nil {
+			return fmt.Errorf("authorize: %w", err)
+		}
+	}
+
+	provider := httpkit.PathParams(r).ByName("provider")
+	u, err := h.identities.AuthCodeURL(r.Context(), provider, params[0], params[1], params[2])
+	if err != nil {
+		return fmt.Errorf("authorize: %w", err)
+	}
+
+	keys := oidcSessionKeys(provider)
+	for i, key := range keys {
+		sess.Set(key, params[i])
+	}
+	http.Redirect(w, r, u, http.StatusFound)
+	return nil
+}
+
+func (h *OIDC) callback(w http.ResponseWriter, r *http.Request) error {
+	sess, err := session(r)
+	if err != nil {
+		return fmt.Errorf("callback: %w", err)
+	}
+
+	provider := httpkit.PathParams(r).ByName("provider")
+	keys := oidcSessionKeys(provider)
+	state, _ := sess.Get(keys[0])
+	nonce, _ := sess.Get(keys[1])
+	verifier, _ := sess.Get(keys[2])
+
+	q := r.URL.Query()
+	got := q.Get("state")
+	if state == "" || subtle.ConstantTimeCompare([]byte(got), []byte(state)) != 1 {
+		return fmt.Errorf("callback: state mismatch: %w", httpkit.NewInvalidRequestProblem(httpkit.FieldError{
+			Field:   "state",
+			Code:    "state",
+			Message: "must be the state of the authorization request of this session",
+		}))
+	}
+
+	if e := q.Get("error"); e != "" {
+		return fmt.Errorf("callback: %s: %w", e, httpkit.NewInvalidRequestProblem(httpkit.FieldError{
+			Field:   "error",
+			Code:    e,
+			Message: "the provider denied the authorization",
+		}))
+	}
+	if q.Get("code") == "" {
+		return fmt.Errorf("callback: %w", httpkit.NewInvalidRequestProblem(httpkit.FieldError{
+			Field:   "code",
+			Code:    "required",
+			Message: "is required",
+		}))
+	}
+
+	pair, err := h.identities.SignIn(r.Context(), provider, q.Get("code"), verifier, nonce)
+	var pending *identity.PendingSignIn
+	if err != nil && !errors.As(err, &pending) {
+		return fmt.Errorf("callback: %w", err)
+	}
+
+	// the authorization request is single-use.
+	for _, key := range keys {
+		sess.Delete(key)
+	}
+
+	if pending != nil {
+		// the pending sign-in is bound to a new session ID, not the one issued before the authentication.
+		sess.Regenerate()
+		sess.Set(oidcPendingUserKey, pending.UserID.String())
+		sess.Set(oidcPendingExpiresKey, strconv.FormatInt(pending.ExpiresAt.Unix(), 10))
+		res := kernel.NewHttpResBuilderCtx(r.Context(), pending.Res(time.Now())).Code(http.StatusAccepted).Build()
+		return httpkit.WriteJSON(w, res, res.Code)
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), pair.Res(time.Now())).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *OIDC) otp(w http.ResponseWriter, r *http.Request) error {
+	sess, err := session(r)
+	if err != nil {
+		return fmt.Errorf("otp: %w", err)
+	}
+
+	var req auth.TOTPCodeReq
+	if err := readValidRequest(r, h.validate, &req); err != nil {
+		return err
+	}
+
+	pending, ok := pendingSignIn(sess)
+	if !ok {
+		return fmt.Errorf("otp: %w", httpkit.NewInvalidRequestProblem(httpkit.FieldError{
+			Field:   "session",
+			Code:    "pending",
+			Message: "must have a sign-in pending for the one-time password",
+		}))
+	}
+
+	pair, err := h.identities.CompleteSignIn(r.Context(), pending, req.Code)
+	if err != nil {
+		return fmt.Errorf("otp: %w", err)
+	}
+
+	sess.Delete(oidcPendingUserKey)
+	sess.Delete(oidcPendingExpiresKey)
+	sess.Regenerate()
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), pair.Res(time.Now())).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+// pendingSignIn gets the sign-in pending for the one-time password from the session.
+func pendingSignIn(sess *sessionkit.Session) (identity.PendingSignIn, bool) {
+	user, _ := sess.Get(oidcPendingUserKey)
+	expires, _ := sess.Get(oidcPendingExpiresKey)
+
+	id, err := idkit.ParseUUID(user)
+	if err != nil {
+		return identity.PendingSignIn{}, false
+	}
+	sec, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return identity.PendingSignIn{}, false
+	}
+	return identity.PendingSignIn{UserID: id, ExpiresAt: time.Unix(sec, 0)}, true
+}
+
+// oidcSessionKeys returns the session keys of the state, the nonce and the verifier of the provider.
+func oidcSessionKeys(provider string) [3]string {
+	prefix := "oidc." + provider + "."
+	return [3]string{prefix + "state", prefix + "nonce", prefix + "verifier"}
+}
+
+// session gets the session of the request.
+func session(r *http.Request) (*sessionkit.Session, error) {
+	sess, ok := sessionkit.FromContext(r.Context())
+	if !ok {
+		return nil, errors.New("missing session, is the route behind the session middleware?")
+	}
+	return sess, nil
+}
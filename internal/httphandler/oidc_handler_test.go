@@ -0,0 +1,161 @@
+//go:build cgo
+
+package httphandler
+
+import (
+	"context"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/swe-be-mono/internal/domain/auth"
+	"github.com/josestg/swe-be-mono/internal/domain/identity"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/internal/kernel"
+	"github.com/josestg/swe-be-mono/pkg/oidckit"
+	"github.com/josestg/swe-be-mono/pkg/otpkit"
+	"github.com/josestg/swe-be-mono/pkg/sessionkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit/sqlxkittest"
+	"github.com/josestg/swe-be-mono/resources/migrations"
+)
+
+// fakeProvider authenticates alice by the code "good-code" of the last authorization request.
+type fakeProvider struct {
+	nonce, challenge string
+}
+
+func (p *fakeProvider) AuthCodeURL(_ context.Context, state, nonce, verifier string) (string, error) {
+	p.nonce, p.challenge = nonce, oidckit.Challenge(verifier)
+	return "https://provider.example.com/authorize?state=" + url.QueryEscape(state), nil
+}
+
+func (p *fakeProvider) Profile(_ context.Context, code, verifier, nonce string) (identity.Profile, error) {
+	if code != "good-code" || oidckit.Challenge(verifier) != p.challenge || nonce != p.nonce {
+		return identity.Profile{}, errors.New("invalid grant")
+	}
+	return identity.Profile{
+		Provider:      identity.Google,
+		Subject:       "1",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		Name:          "Alice",
+	}, nil
+}
+
+func TestOIDC(t *testing.T) {
+	db, teardown := sqlxkittest.Setup(t, migrations.Postgre())
+	t.Cleanup(teardown)
+
+	repo, err := user.NewRepository(db)
+	expectTrue(t, err == nil)
+	users := user.NewService(repo)
+
+	keys := map[string]string{"k1": "HS256:0123456789abcdef0123456789abcdef"}
+	tokens, err := auth.NewTokenService(db, auth.TokenConfig{Keys: keys})
+	expectTrue(t, err == nil)
+
+	totp, err := auth.NewTOTPService(db, users, auth.TOTPConfig{Key: "0123456789abcdef0123456789abcdef"})
+	expectTrue(t, err == nil)
+
+	providers := map[string]identity.Provider{identity.Google: &fakeProvider{}}
+	identities, err := identity.NewService(db, users, tokens, totp, providers)
+	expectTrue(t, err == nil)
+
+	sessions := sessionkit.NewManager(sessionkit.NewMemoryStore(), sessionkit.Config{})
+	mux := newTestMux(sessions.Middleware())
+	ServeOIDC(mux, identities)
+
+	var cookies []*http.Cookie
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		mux.ServeHTTP(rec, req)
+		if c := rec.Result().Cookies(); len(c) > 0 {
+			cookies = c
+		}
+		return rec
+	}
+	do := func(path string) *httptest.ResponseRecorder { return send(http.MethodGet, path, "") }
+
+	rec := do("/auth/oidc/unknown/authorize")
+	expectTrue(t, rec.Code == http.StatusNotFound)
+
+	rec = do("/auth/oidc/google/authorize")
+	expectTrue(t, rec.Code == http.StatusFound)
+	location, err := url.Parse(rec.Header().Get("Location"))
+	expectTrue(t, err == nil)
+	state := location.Query().Get("state")
+	expectTrue(t, state != "")
+
+	rec = do("/auth/oidc/google/callback?code=good-code&state=another")
+	expectTrue(t, rec.Code == http.StatusBadRequest)
+
+	rec = do("/auth/oidc/google/callback?code=bad-code&state=" + url.QueryEscape(state))
+	expectTrue(t, rec.Code == http.StatusUnauthorized)
+
+	rec = do("/auth/oidc/google/callback?code=good-code&state=" + url.QueryEscape(state))
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	var token kernel.HttpRes[auth.TokenRes]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&token) == nil)
+	expectTrue(t, token.Data.AccessToken != "" && token.Data.RefreshToken != "")
+
+	// the state is single-use.
+	rec = do("/auth/oidc/google/callback?code=good-code&state=" + url.QueryEscape(state))
+	expectTrue(t, rec.Code == http.StatusBadRequest)
+
+	// the state of another session is rejected.
+	rec = do("/auth/oidc/google/authorize")
+	expectTrue(t, rec.Code == http.StatusFound)
+	cookies = nil
+	location, _ = url.Parse(rec.Header().Get("Location"))
+	rec = do("/auth/oidc/google/callback?code=good-code&state=" + url.QueryEscape(location.Query().Get("state")))
+	expectTrue(t, rec.Code == http.StatusBadRequest)
+
+	// the user with the active TOTP completes the sign-in by the one-time password.
+	alice, err := users.GetByEmail(context.Background(), "alice@example.com")
+	expectTrue(t, err == nil)
+	setup, err := totp.Setup(context.Background(), alice.ID)
+	expectTrue(t, err == nil)
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(setup.Secret)
+	expectTrue(t, err == nil)
+	otp := otpkit.New(otpkit.Config{})
+	_, err = totp.Confirm(context.Background(), alice.ID, otp.Code(secret, time.Now().Add(-30*time.Second)))
+	expectTrue(t, err == nil)
+
+	rec = send(http.MethodPost, "/auth/oidc/otp", `{"code": "123456"}`)
+	expectTrue(t, rec.Code == http.StatusBadRequest)
+
+	rec = do("/auth/oidc/google/authorize")
+	location, _ = url.Parse(rec.Header().Get("Location"))
+	anonymousID := cookies[0].Value
+	rec = do("/auth/oidc/google/callback?code=good-code&state=" + url.QueryEscape(location.Query().Get("state")))
+	expectTrue(t, rec.Code == http.StatusAccepted)
+
+	// the pending sign-in isn't bound to the session ID issued before the authentication.
+	pendingID := cookies[0].Value
+	expectTrue(t, pendingID != anonymousID)
+
+	var pending kernel.HttpRes[identity.PendingSignInRes]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&pending) == nil)
+	expectTrue(t, pending.Data.OTPRequired && pending.Data.ExpiresIn > 0)
+
+	rec = send(http.MethodPost, "/auth/oidc/otp", `{"code": "`+otp.Code(secret, time.Now())+`"}`)
+	expectTrue(t, rec.Code == http.StatusOK)
+	expectTrue(t, cookies[0].Value != pendingID)
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&token) == nil)
+	expectTrue(t, token.Data.AccessToken != "")
+
+	// the pending sign-in is single-use.
+	rec = send(http.MethodPost, "/auth/oidc/otp", `{"code": "`+otp.Code(secret, time.Now())+`"}`)
+	expectTrue(t, rec.Code == http.StatusBadRequest)
+}
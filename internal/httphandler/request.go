@@ -0,0 +1,75 @@
+package httphandler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/josestg/swe-be-mono/internal/httpmiddleware"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+)
+
+// readValidRequest reads the JSON body into req and validates it by its validate tags.
+func readValidRequest(r *http.Request, validate *validator.Validate, req any) error {
+	if err := httpkit.ReadJSON(r.Body, req); err != nil {
+		return fmt.Errorf("read json: %w: %w", httpkit.NewInvalidRequestProblem(), err)
+	}
+	if err := validate.Struct(req); err != nil {
+		return httpkit.ValidationProblemFrom(err)
+	}
+	return nil
+}
+
+// pathUUID parses the path parameter as a UUID, it returns the PDTypeInvalidRequest problem if it is malformed.
+func pathUUID(r *http.Request, name string) (idkit.UUID, error) {
+	id, err := idkit.ParseUUID(httpkit.PathParams(r).ByName(name))
+	if err != nil {
+		return idkit.NilUUID, fmt.Errorf("%w: %w", httpkit.NewInvalidRequestProblem(httpkit.FieldError{
+			Field:   name,
+			Code:    "uuid",
+			Message: "must be a valid UUID",
+		}), err)
+	}
+	return id, nil
+}
+
+// queryInt parses the query parameter as an integer within [lo, hi], or at least lo if hi is zero. It returns def if
+// the parameter is absent, or the PDTypeInvalidRequest problem if it is malformed or out of range.
+func queryInt(r *http.Request, name string, def, lo, hi int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err == nil && n >= lo && (hi == 0 || n <= hi) {
+		return n, nil
+	}
+
+	msg := fmt.Sprintf("must be an integer of at least %d", lo)
+	if hi > 0 {
+		msg = fmt.Sprintf("must be an integer between %d and %d", lo, hi)
+	}
+	return 0, fmt.Errorf("%w: invalid query %s=%q", httpkit.NewInvalidRequestProblem(httpkit.FieldError{
+		Field:   name,
+		Code:    "range",
+		Message: msg,
+	}), name, s)
+}
+
+// subjectUUID gets the subject of the JWT claims of the authenticated request as a UUID, e.g. the user ID.
+func subjectUUID(r *http.Request) (idkit.UUID, error) {
+	claims, ok := httpmiddleware.JWTClaimsFromContext(r.Context())
+	if !ok {
+		return idkit.NilUUID, errors.New("missing jwt claims, is the route authenticated?")
+	}
+
+	id, err := idkit.ParseUUID(claims.Subject)
+	if err != nil {
+		return idkit.NilUUID, fmt.Errorf("parse subject: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,390 @@
+package httphandler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/josestg/swe-be-mono/internal/domain/rbac"
+	"github.com/josestg/swe-be-mono/internal/httpmiddleware"
+	"github.com/josestg/swe-be-mono/internal/kernel"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+)
+
+// Role is a handler for managing the roles and their assignments to the users.
+type Role struct {
+	roles    *rbac.Service
+	validate *validator.Validate
+}
+
+// ServeRole registers the role handler to the given mux. The routes declare the required permissions by
+// httpmiddleware.MetaScopes, which are checked by httpmiddleware.Authorize if the mux uses it.
+func ServeRole(mux *httpkit.ServeMux, roles *rbac.Service) {
+	h := &Role{
+		roles:    roles,
+		validate: httpkit.NewValidator(),
+	}
+	mux.Route(h.Permissions())
+	mux.Route(h.Create())
+	mux.Route(h.List())
+	mux.Route(h.Get())
+	mux.Route(h.Update())
+	mux.Route(h.Delete())
+	mux.Route(h.UserRoles())
+	mux.Route(h.Assign())
+	mux.Route(h.Unassign())
+}
+
+// Permissions returns the route for listing the permissions.
+//
+//	@Tags			Admin
+//	@Summary		List the permissions.
+//	@Description	Returns the permissions that the roles can grant.
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	kernel.HttpRes[[]string]
+//	@Failure		401	{object}	problemdetail.ProblemDetail
+//	@Failure		403	{object}	problemdetail.ProblemDetail
+//	@Router			/permissions [get]
+func (h *Role) Permissions() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/permissions",
+		Handler: h.permissions,
+		Name:    "permissions.list",
+		Meta:    map[string]any{httpmiddleware.MetaScopes: []string{rbac.PermRolesRead}},
+	}
+}
+
+// Create returns the route for creating a role.
+//
+//	@Tags			Admin
+//	@Summary		Create a role.
+//	@Description	Creates a role granting the permissions, the name must be unique.
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			body	body		rbac.RoleReq	true	"The role to create."
+//	@Success		201		{object}	kernel.HttpRes[rbac.RoleRes]
+//	@Failure		400		{object}	httpkit.ValidationProblem
+//	@Failure		401		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		409		{object}	problemdetail.ProblemDetail
+//	@Failure		422		{object}	httpkit.ValidationProblem
+//	@Router			/roles [post]
+func (h *Role) Create() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPost,
+		Path:    "/roles",
+		Handler: h.create,
+		Name:    "roles.create",
+		Meta:    map[string]any{httpmiddleware.MetaScopes: []string{rbac.PermRolesWrite}},
+	}
+}
+
+// List returns the route for listing the roles.
+//
+//	@Tags			Admin
+//	@Summary		List the roles.
+//	@Description	Returns all the roles ordered by the name.
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	kernel.HttpRes[[]rbac.RoleRes]
+//	@Failure		401	{object}	problemdetail.ProblemDetail
+//	@Failure		403	{object}	problemdetail.ProblemDetail
+//	@Router			/roles [get]
+func (h *Role) List() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/roles",
+		Handler: h.list,
+		Name:    "roles.list",
+		Meta:    map[string]any{httpmiddleware.MetaScopes: []string{rbac.PermRolesRead}},
+	}
+}
+
+// Get returns the route for getting a role.
+//
+//	@Tags			Admin
+//	@Summary		Get a role.
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string	true	"The role ID."	format(uuid)
+//	@Success		200	{object}	kernel.HttpRes[rbac.RoleRes]
+//	@Failure		400	{object}	httpkit.ValidationProblem
+//	@Failure		401	{object}	problemdetail.ProblemDetail
+//	@Failure		403	{object}	problemdetail.ProblemDetail
+//	@Failure		404	{object}	problemdetail.ProblemDetail
+//	@Router			/roles/{id} [get]
+func (h *Role) Get() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/roles/:id",
+		Handler: h.get,
+		Name:    "roles.get",
+		Meta:    map[string]any{httpmiddleware.MetaScopes: []string{rbac.PermRolesRead}},
+	}
+}
+
+// Update returns the route for updating a role.
+//
+//	@Tags			Admin
+//	@Summary		Update a role.
+//	@Description	Replaces the name, the description and the permissions of a role, the name must be unique.
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string			true	"The role ID."	format(uuid)
+//	@Param			body	body		rbac.RoleReq	true	"The new values."
+//	@Success		200		{object}	kernel.HttpRes[rbac.RoleRes]
+//	@Failure		400		{object}	httpkit.ValidationProblem
+//	@Failure		401		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
+//	@Failure		409		{object}	problemdetail.ProblemDetail
+//	@Failure		422		{object}	httpkit.ValidationProblem
+//	@Router			/roles/{id} [put]
+func (h *Role) Update() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPut,
+		Path:    "/roles/:id",
+		Handler: h.update,
+		Name:    "roles.update",
+		Meta:    map[string]any{httpmiddleware.MetaScopes: []string{rbac.PermRolesWrite}},
+	}
+}
+
+// Delete returns the route for deleting a role.
+//
+//	@Tags			Admin
+//	@Summary		Delete a role.
+//	@Description	Deletes a role, unassigning it from the users.
+//	@Security		ApiKeyAuth
+//	@Param			id	path	string	true	"The role ID."	format(uuid)
+//	@Success		204
+//	@Failure		400	{object}	httpkit.ValidationProblem
+//	@Failure		401	{object}	problemdetail.ProblemDetail
+//	@Failure		403	{object}	problemdetail.ProblemDetail
+//	@Failure		404	{object}	problemdetail.ProblemDetail
+//	@Router			/roles/{id} [delete]
+func (h *Role) Delete() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodDelete,
+		Path:    "/roles/:id",
+		Handler: h.delete,
+		Name:    "roles.delete",
+		Meta:    map[string]any{httpmiddleware.MetaScopes: []string{rbac.PermRolesWrite}},
+	}
+}
+
+// UserRoles returns the route for listing the roles of a user.
+//
+//	@Tags			Admin
+//	@Summary		List the roles of a user.
+//	@Description	Returns the roles assigned to a user ordered by the name.
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string	true	"The user ID."	format(uuid)
+//	@Success		200	{object}	kernel.HttpRes[[]rbac.RoleRes]
+//	@Failure		400	{object}	httpkit.ValidationProblem
+//	@Failure		401	{object}	problemdetail.ProblemDetail
+//	@Failure		403	{object}	problemdetail.ProblemDetail
+//	@Failure		404	{object}	problemdetail.ProblemDetail
+//	@Router			/users/{id}/roles [get]
+func (h *Role) UserRoles() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/users/:id/roles",
+		Handler: h.userRoles,
+		Name:    "users.roles.list",
+		Meta:    map[string]any{httpmiddleware.MetaScopes: []string{rbac.PermRolesRead}},
+	}
+}
+
+// Assign returns the route for assigning a role to a user.
+//
+//	@Tags			Admin
+//	@Summary		Assign a role to a user.
+//	@Description	Assigns a role to a user, assigning an assigned role succeeds too.
+//	@Security		ApiKeyAuth
+//	@Param			id		path	string	true	"The user ID."	format(uuid)
+//	@Param			role_id	path	string	true	"The role ID."	format(uuid)
+//	@Success		204
+//	@Failure		400	{object}	httpkit.ValidationProblem
+//	@Failure		401	{object}	problemdetail.ProblemDetail
+//	@Failure		403	{object}	problemdetail.ProblemDetail
+//	@Failure		404	{object}	problemdetail.ProblemDetail
+//	@Router			/users/{id}/roles/{role_id} [put]
+func (h *Role) Assign() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPut,
+		Path:    "/users/:id/roles/:role_id",
+		Handler: h.assign,
+		Name:    "users.roles.assign",
+		Meta:    map[string]any{httpmiddleware.MetaScopes: []string{rbac.PermRolesWrite}},
+	}
+}
+
+// Unassign returns the route for unassigning a role from a user.
+//
+//	@Tags			Admin
+//	@Summary		Unassign a role from a user.
+//	@Description	Unassigns a role from a user, unassigning an unassigned role succeeds too.
+//	@Security		ApiKeyAuth
+//	@Param			id		path	string	true	"The user ID."	format(uuid)
+//	@Param			role_id	path	string	true	"The role ID."	format(uuid)
+//	@Success		204
+//	@Failure		400	{object}	httpkit.ValidationProblem
+//	@Failure		401	{object}	problemdetail.ProblemDetail
+//	@Failure		403	{object}	problemdetail.ProblemDetail
+//	@Router			/users/{id}/roles/{role_id} [delete]
+func (h *Role) Unassign() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodDelete,
+		Path:    "/users/:id/roles/:role_id",
+		Handler: h.unassign,
+		Name:    "users.roles.unassign",
+		Meta:    map[string]any{httpmiddleware.MetaScopes: []string{rbac.PermRolesWrite}},
+	}
+}
+
+func (h *Role) permissions(w http.ResponseWriter, r *http.Request) error {
+	res := kernel.NewHttpResBuilderCtx(r.Context(), rbac.Permissions).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *Role) create(w http.ResponseWriter, r *http.Request) error {
+	var req rbac.RoleReq
+	if err := readValidRequest(r, h.validate, &req); err != nil {
+		return err
+	}
+
+	role, err := h.roles.CreateRole(r.Context(), req)
+	if err != nil {
+		return fmt.Errorf("create role: %w", err)
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), role.Res()).Code(http.StatusCreated).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *Role) list(w http.ResponseWriter, r *http.Request) error {
+	roles, err := h.roles.ListRoles(r.Context())
+	if err != nil {
+		return fmt.Errorf("list roles: %w", err)
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), rolesRes(roles)).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *Role) get(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	role, err := h.roles.GetRole(r.Context(), id)
+	if err != nil {
+		return fmt.Errorf("get role: %w", err)
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), role.Res()).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *Role) update(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req rbac.RoleReq
+	if err := readValidRequest(r, h.validate, &req); err != nil {
+		return err
+	}
+
+	role, err := h.roles.UpdateRole(r.Context(), id, req)
+	if err != nil {
+		return fmt.Errorf("update role: %w", err)
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), role.Res()).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *Role) delete(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.roles.DeleteRole(r.Context(), id); err != nil {
+		return fmt.Errorf("delete role: %w", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *Role) userRoles(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	roles, err := h.roles.UserRoles(r.Context(), id)
+	if err != nil {
+		return fmt.Errorf("list user roles: %w", err)
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), rolesRes(roles)).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *Role) assign(w http.ResponseWriter, r *http.Request) error {
+	userID, roleID, err := assignmentIDs(r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.roles.AssignRole(r.Context(), userID, roleID); err != nil {
+		return fmt.Errorf("assign role: %w", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *Role) unassign(w http.ResponseWriter, r *http.Request) error {
+	userID, roleID, err := assignmentIDs(r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.roles.UnassignRole(r.Context(), userID, roleID); err != nil {
+		return fmt.Errorf("unassign role: %w", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// assignmentIDs parses the user ID and the role ID of the path.
+func assignmentIDs(r *http.Request) (userID, roleID idkit.UUID, err error) {
+	if userID, err = pathUUID(r, "id"); err != nil {
+		return userID, roleID, err
+	}
+	roleID, err = pathUUID(r, "role_id")
+	return userID, roleID, err
+}
+
+// rolesRes converts the roles to their response models.
+func rolesRes(roles []rbac.Role) []rbac.RoleRes {
+	items := make([]rbac.RoleRes, 0, len(roles))
+	for _, role := range roles {
+		items = append(items, role.Res())
+	}
+	return items
+}
@@ -0,0 +1,140 @@
+//go:build cgo
+
+package httphandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/josestg/swe-be-mono/internal/domain/auth"
+	"github.com/josestg/swe-be-mono/internal/domain/rbac"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/internal/httpmiddleware"
+	"github.com/josestg/swe-be-mono/internal/kernel"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit/sqlxkittest"
+	"github.com/josestg/swe-be-mono/resources/migrations"
+)
+
+func TestRole(t *testing.T) {
+	db, teardown := sqlxkittest.Setup(t, migrations.Postgre())
+	t.Cleanup(teardown)
+
+	repo, err := user.NewRepository(db)
+	expectTrue(t, err == nil)
+	users := user.NewService(repo)
+
+	roles, err := rbac.NewService(db, users)
+	expectTrue(t, err == nil)
+
+	const secret = "0123456789abcdef0123456789abcdef"
+	tokens, err := auth.NewTokenService(db, auth.TokenConfig{Keys: map[string]string{"k1": "HS256:" + secret}})
+	expectTrue(t, err == nil)
+
+	ctx := idkit.WithProvider(context.Background(), idkit.NewSequentialUUIDProvider(0))
+	alice, err := users.Create(ctx, user.CreateReq{Email: "alice@example.com", Name: "Alice"})
+	expectTrue(t, err == nil)
+	bob, err := users.Create(ctx, user.CreateReq{Email: "bob@example.com", Name: "Bob"})
+	expectTrue(t, err == nil)
+	expectTrue(t, roles.Bootstrap(ctx, rbac.Config{AdminUserIDs: []idkit.UUID{alice.ID}}) == nil)
+
+	resolve := func(ctx context.Context, p httpmiddleware.Principal) ([]string, error) {
+		id, err := idkit.ParseUUID(p.ID)
+		if err != nil {
+			return nil, nil
+		}
+		return roles.Permissions(ctx, id)
+	}
+	mux := newTestMux(
+		httpmiddleware.JWTAuth(httpmiddleware.JWTAuthConfig{KeySet: tokens.KeySet()}),
+		httpmiddleware.ResolvePermissions(httpmiddleware.PermissionResolverFunc(resolve)),
+		httpmiddleware.Authorize(),
+	)
+	ServeUser(mux, users)
+	ServeRole(mux, roles)
+
+	token := func(id idkit.UUID) string {
+		pair, err := tokens.Issue(ctx, id)
+		expectTrue(t, err == nil)
+		return pair.Access.Value
+	}
+	adminToken, bobToken := token(alice.ID), token(bob.ID)
+
+	do := func(method, path, body, token string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(idkit.WithProvider(req.Context(), idkit.NewSequentialUUIDProvider(100)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/users", "", bobToken)
+	expectTrue(t, rec.Code == http.StatusForbidden)
+
+	rec = do(http.MethodGet, "/roles", "", adminToken)
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	var list kernel.HttpRes[[]rbac.RoleRes]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&list) == nil)
+	expectTrue(t, len(list.Data) == 1 && list.Data[0].Name == rbac.AdminRole)
+
+	rec = do(http.MethodPost, "/roles", `{"name": "support", "permissions": ["users:read"]}`, adminToken)
+	expectTrue(t, rec.Code == http.StatusCreated)
+
+	var created kernel.HttpRes[rbac.RoleRes]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&created) == nil)
+	expectTrue(t, created.Data.Name == "support" && len(created.Data.Permissions) == 1)
+
+	rec = do(http.MethodPost, "/roles", `{"name": "support"}`, adminToken)
+	expectTrue(t, rec.Code == http.StatusConflict)
+
+	rec = do(http.MethodPost, "/roles", `{"name": "root", "permissions": ["*"]}`, adminToken)
+	expectTrue(t, rec.Code == http.StatusBadRequest)
+
+	rec = do(http.MethodPost, "/roles", `{"name": "auditor"}`, bobToken)
+	expectTrue(t, rec.Code == http.StatusForbidden)
+
+	assignment := "/users/" + bob.ID.String() + "/roles/" + created.Data.ID
+	rec = do(http.MethodPut, assignment, "", adminToken)
+	expectTrue(t, rec.Code == http.StatusNoContent)
+
+	rec = do(http.MethodPut, "/users/"+bob.ID.String()+"/roles/"+idkit.NilUUID.String(), "", adminToken)
+	expectTrue(t, rec.Code == http.StatusNotFound)
+
+	// the permissions are resolved per request, so the same token is granted the new role.
+	rec = do(http.MethodGet, "/users", "", bobToken)
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	rec = do(http.MethodDelete, "/users/"+alice.ID.String(), "", bobToken)
+	expectTrue(t, rec.Code == http.StatusForbidden)
+
+	rec = do(http.MethodGet, "/users/"+bob.ID.String()+"/roles", "", adminToken)
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	var assigned kernel.HttpRes[[]rbac.RoleRes]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&assigned) == nil)
+	expectTrue(t, len(assigned.Data) == 1 && assigned.Data[0].ID == created.Data.ID)
+
+	rec = do(http.MethodPut, "/roles/"+created.Data.ID, `{"name": "support", "permissions": []}`, adminToken)
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	rec = do(http.MethodGet, "/users", "", bobToken)
+	expectTrue(t, rec.Code == http.StatusForbidden)
+
+	rec = do(http.MethodDelete, assignment, "", adminToken)
+	expectTrue(t, rec.Code == http.StatusNoContent)
+
+	rec = do(http.MethodDelete, "/roles/"+created.Data.ID, "", adminToken)
+	expectTrue(t, rec.Code == http.StatusNoContent)
+
+	rec = do(http.MethodGet, "/roles/"+created.Data.ID, "", adminToken)
+	expectTrue(t, rec.Code == http.StatusNotFound)
+
+	rec = do(http.MethodGet, "/roles/not-a-uuid", "", adminToken)
+	expectTrue(t, rec.Code == http.StatusBadRequest)
+}
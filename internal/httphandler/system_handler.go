@@ -1,33 +1,71 @@
 package httphandler
 
 import (
+	"fmt"
+	"log/slog"
 	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
 
 	"github.com/josestg/swe-be-mono/internal/config"
 	"github.com/josestg/swe-be-mono/internal/domain/system"
 	"github.com/josestg/swe-be-mono/internal/kernel"
+	"github.com/josestg/swe-be-mono/pkg/healthkit"
 	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/logkit"
 )
 
 // System is a handler for serving system information and health checks.
 type System struct {
-	app config.AppInfo
+	app       config.AppInfo
+	build     system.BuildInfo
+	startedAt time.Time
+	logLevel  *slog.LevelVar
+	health    *healthkit.Registry
+	log       *slog.Logger
 }
 
-// ServeSystem registers the system handler to the given mux.
-func ServeSystem(mux *httpkit.ServeMux, app config.AppInfo) {
-	sys := &System{app: app}
+// InfoRes represents the application information with its build and runtime metadata.
+// swagger:model httphandler.InfoRes
+type InfoRes struct {
+	config.AppInfo
+	Build   system.BuildInfo   `json:"build"`
+	Runtime system.RuntimeInfo `json:"runtime"`
+} //@name httphandler.InfoRes
+
+// ServeSystem registers the system handler to the given mux. The auth middlewares authenticate SetLogLevel, which
+// isn't served without them, since anyone could switch the application to the debug logging otherwise. The errors of
+// the failed health checks are logged to the logger instead of being served, since the health routes are public.
+func ServeSystem(mux *httpkit.ServeMux, app config.AppInfo, logLevel *slog.LevelVar, health *healthkit.Registry,
+	log *slog.Logger, auth ...httpkit.MuxMiddleware) {
+	sys := &System{
+		app:       app,
+		build:     readBuildInfo(),
+		startedAt: time.Now(),
+		logLevel:  logLevel,
+		health:    health,
+		log:       log,
+	}
 	mux.Route(sys.Info())
 	mux.Route(sys.Health())
+	mux.Route(sys.Live())
+	mux.Route(sys.Ready())
+	mux.Route(sys.LogLevel())
+	if len(auth) > 0 {
+		mux.Route(sys.SetLogLevel(), auth...)
+	}
 }
 
 // Info returns the application information.
 //
 //	@Tags			System
 //	@Summary		Application information.
-//	@Description	Returns the application information.
+//	@Description	Returns the application information, the build metadata, e.g. the VCS revision, and the runtime
+//	@Description	environment, e.g. GOMAXPROCS and the uptime. The versions of the dependencies aren't served, since
+//	@Description	they tell which known vulnerabilities the application may have.
 //	@Produce		json
-//	@Success		200	{object}	kernel.HttpRes[config.AppInfo]
+//	@Success		200	{object}	kernel.HttpRes[httphandler.InfoRes]
 //	@Router			/system/info [get]
 func (h *System) Info() httpkit.Route {
 	return httpkit.Route{
@@ -41,35 +79,213 @@ func (h *System) Info() httpkit.Route {
 //
 //	@Tags			System
 //	@Summary		Application health.
-//	@Description	Returns the application health status.
+//	@Description	Returns the health status of the application dependencies, regardless of the readiness.
 //	@Produce		json
-//	@Success		200	{object}	kernel.HttpRes[[]system.HealthRes]
+//	@Success		200	{object}	kernel.HttpRes[system.HealthReport]
+//	@Failure		503	{object}	kernel.HttpRes[system.HealthReport]
 //	@Router			/system/health [get]
 func (h *System) Health() httpkit.Route {
 	return httpkit.Route{
 		Method:  http.MethodGet,
 		Path:    "/system/health",
-		Handler: h.health,
+		Handler: h.healthCheck,
 	}
 }
 
-func (h *System) info(w http.ResponseWriter, _ *http.Request) error {
-	res := kernel.NewHttpResBuilder(h.app).Build()
-	return httpkit.WriteJSON(w, res, res.Code)
+// Live returns the application liveness for the liveness probe.
+//
+//	@Tags			System
+//	@Summary		Application liveness.
+//	@Description	Returns healthy as long as the application is able to serve requests, the dependencies aren't checked.
+//	@Produce		json
+//	@Success		200	{object}	kernel.HttpRes[system.HealthReport]
+//	@Router			/system/live [get]
+func (h *System) Live() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/system/live",
+		Handler: h.live,
+	}
 }
 
-func (h *System) health(w http.ResponseWriter, _ *http.Request) error {
-	dependencies := []system.HealthRes{
-		{
-			Name:   "HTTP Server",
-			Status: system.StatusHealthy,
-		},
-		{
-			Name:   "MySQL",
-			Status: system.StatusUnhealthy,
+// Ready returns the application readiness for the readiness probe.
+//
+//	@Tags			System
+//	@Summary		Application readiness.
+//	@Description	Returns healthy if the application isn't shutting down and all dependencies are healthy, or degraded
+//	@Description	if only the non-critical ones aren't. The check results are cached for HEALTH_CACHE_TTL.
+//	@Produce		json
+//	@Success		200	{object}	kernel.HttpRes[system.HealthReport]
+//	@Failure		503	{object}	kernel.HttpRes[system.HealthReport]
+//	@Router			/system/ready [get]
+func (h *System) Ready() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/system/ready",
+		Handler: h.ready,
+	}
+}
+
+// LogLevel returns the minimum level of the application logger.
+//
+//	@Tags			System
+//	@Summary		Application log level.
+//	@Description	Returns the minimum level of the application logger.
+//	@Produce		json
+//	@Success		200	{object}	kernel.HttpRes[system.LogLevel]
+//	@Router			/system/loglevel [get]
+func (h *System) LogLevel() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/system/loglevel",
+		Handler: h.logLevelGet,
+	}
+}
+
+// SetLogLevel changes the minimum level of the application logger at runtime.
+//
+//	@Tags			System
+//	@Summary		Change application log level.
+//	@Description	Changes the minimum level of the application logger until the application restarts. It is only
+//	@Description	served when SYSTEM_DEBUG_API_KEY is set.
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			body	body		system.LogLevel	true	"The new level, one of DEBUG, INFO, WARN or ERROR."
+//	@Success		200		{object}	kernel.HttpRes[system.LogLevel]
+//	@Failure		400		{object}	httpkit.ValidationProblem
+//	@Failure		401		{object}	problemdetail.ProblemDetail
+//	@Router			/system/loglevel [put]
+func (h *System) SetLogLevel() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPut,
+		Path:    "/system/loglevel",
+		Handler: h.logLevelSet,
+	}
+}
+
+func (h *System) info(w http.ResponseWriter, r *http.Request) error {
+	info := InfoRes{
+		AppInfo: h.app,
+		Build:   h.build,
+		Runtime: system.RuntimeInfo{
+			GOOS:       runtime.GOOS,
+			GOARCH:     runtime.GOARCH,
+			GOMAXPROCS: runtime.GOMAXPROCS(0),
+			NumCPU:     runtime.NumCPU(),
+			StartedAt:  h.startedAt.UnixMilli(),
+			Uptime:     time.Since(h.startedAt).Round(time.Millisecond).String(),
 		},
 	}
 
-	res := kernel.NewHttpResBuilder(dependencies).Build()
+	res := kernel.NewHttpResBuilderCtx(r.Context(), info).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+// readBuildInfo reads the build metadata embedded in the binary, the VCS settings are only available when built
+// from a repository without -buildvcs=false.
+func readBuildInfo() system.BuildInfo {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return system.BuildInfo{GoVersion: runtime.Version()}
+	}
+
+	info := system.BuildInfo{
+		GoVersion: bi.GoVersion,
+		Path:      bi.Path,
+		Version:   bi.Main.Version,
+	}
+
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs":
+			info.VCS = s.Value
+		case "vcs.revision":
+			info.VCSRevision = s.Value
+		case "vcs.time":
+			info.VCSTime = s.Value
+		case "vcs.modified":
+			info.VCSModified = s.Value == "true"
+		}
+	}
+	return info
+}
+
+func (h *System) healthCheck(w http.ResponseWriter, r *http.Request) error {
+	return writeHealth(w, r, h.healthReport(r, h.health.Check(r.Context())))
+}
+
+func (h *System) live(w http.ResponseWriter, r *http.Request) error {
+	return writeHealth(w, r, system.HealthReport{Status: system.StatusHealthy, Checks: []system.HealthRes{}})
+}
+
+func (h *System) ready(w http.ResponseWriter, r *http.Request) error {
+	// skip the checks while draining, the application is going away regardless of the dependencies.
+	if !h.health.Ready() {
+		return writeHealth(w, r, system.HealthReport{Status: system.StatusUnhealthy, Checks: []system.HealthRes{}})
+	}
+	return writeHealth(w, r, h.healthReport(r, h.health.Check(r.Context())))
+}
+
+// healthReport converts the check results to the health report. The errors of the checks may reveal the internals
+// of the dependencies, e.g. the addresses or the credentials in a DSN, so they are logged and replaced by a generic
+// reason.
+func (h *System) healthReport(r *http.Request, report healthkit.Report) system.HealthReport {
+	res := system.HealthReport{
+		Status: system.Status(report.Status().String()),
+		Checks: make([]system.HealthRes, 0, len(report.Results)),
+	}
+
+	for _, check := range report.Results {
+		item := system.HealthRes{
+			Name:      check.Name,
+			Status:    system.Status(check.Status.String()),
+			LatencyMs: float64(check.Latency.Microseconds()) / 1000,
+			CheckedAt: check.CheckedAt.UnixMilli(),
+		}
+		if check.Err != nil {
+			item.Error = "check " + string(item.Status)
+			h.log.WarnContext(r.Context(), "health check failed",
+				"check", check.Name, "status", item.Status, "error", check.Err)
+		}
+		res.Checks = append(res.Checks, item)
+	}
+	return res
+}
+
+// writeHealth writes the health report, the status code is 503 if unhealthy, so the probes don't have to parse the
+// body. Degraded is still served.
+func writeHealth(w http.ResponseWriter, r *http.Request, report system.HealthReport) error {
+	code := http.StatusOK
+	if report.Status == system.StatusUnhealthy {
+		code = http.StatusServiceUnavailable
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), report).Code(code).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *System) logLevelGet(w http.ResponseWriter, r *http.Request) error {
+	res := kernel.NewHttpResBuilderCtx(r.Context(), system.LogLevel{Level: h.logLevel.Level().String()}).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *System) logLevelSet(w http.ResponseWriter, r *http.Request) error {
+	var req system.LogLevel
+	if err := httpkit.ReadJSON(r.Body, &req); err != nil {
+		return fmt.Errorf("read json: %w: %w", httpkit.NewInvalidRequestProblem(), err)
+	}
+
+	level, err := logkit.ParseLevel(req.Level)
+	if err != nil {
+		return fmt.Errorf("%w: %w", httpkit.NewInvalidRequestProblem(httpkit.FieldError{
+			Field:   "level",
+			Code:    "oneof",
+			Message: "must be one of DEBUG, INFO, WARN or ERROR",
+		}), err)
+	}
+
+	h.logLevel.Set(level)
+	res := kernel.NewHttpResBuilderCtx(r.Context(), system.LogLevel{Level: level.String()}).Build()
 	return httpkit.WriteJSON(w, res, res.Code)
 }
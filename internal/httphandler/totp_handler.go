@@ -0,0 +1,216 @@
+package httphandler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/josestg/swe-be-mono/internal/domain/auth"
+	"github.com/josestg/swe-be-mono/internal/kernel"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+)
+
+// TOTP is a handler for the TOTP two-factor authentication of the authenticated user.
+type TOTP struct {
+	totp     *auth.TOTPService
+	validate *validator.Validate
+}
+
+// ServeTOTP registers the TOTP handler to the given mux. The authn middleware authenticates all the routes, e.g.
+// httpmiddleware.JWTAuth.
+func ServeTOTP(mux *httpkit.ServeMux, totp *auth.TOTPService, authn httpkit.MuxMiddleware) {
+	h := &TOTP{
+		totp:     totp,
+		validate: httpkit.NewValidator(),
+	}
+	mux.Route(h.Setup(), authn)
+	mux.Route(h.Confirm(), authn)
+	mux.Route(h.RecoveryCodes(), authn)
+	mux.Route(h.Disable(), authn)
+}
+
+// Setup returns the route for provisioning the TOTP secret.
+//
+//	@Tags			Enduser
+//	@Summary		Set up the TOTP.
+//	@Description	Provisions a new TOTP secret, replacing the unconfirmed one. The uri is the payload of the QR code
+//	@Description	for the authenticator apps, the secret is for entering it manually. The TOTP isn't active until it is
+//	@Description	confirmed.
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	kernel.HttpRes[auth.TOTPSetup]
+//	@Failure		401	{object}	problemdetail.ProblemDetail
+//	@Failure		409	{object}	problemdetail.ProblemDetail
+//	@Router			/auth/totp [post]
+func (h *TOTP) Setup() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPost,
+		Path:    "/auth/totp",
+		Handler: h.setup,
+		Name:    "auth.totp.setup",
+	}
+}
+
+// Confirm returns the route for activating the TOTP.
+//
+//	@Tags			Enduser
+//	@Summary		Confirm the TOTP.
+//	@Description	Activates the TOTP by a code of the authenticator app, and generates the recovery codes, which are
+//	@Description	shown only once. The login requires the otp afterward.
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			body	body		auth.TOTPCodeReq	true	"The code of the authenticator app."
+//	@Success		200		{object}	kernel.HttpRes[auth.RecoveryCodesRes]
+//	@Failure		400		{object}	httpkit.ValidationProblem
+//	@Failure		401		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
+//	@Failure		409		{object}	problemdetail.ProblemDetail
+//	@Failure		422		{object}	httpkit.ValidationProblem
+//	@Failure		429		{object}	problemdetail.ProblemDetail
+//	@Router			/auth/totp/confirm [post]
+func (h *TOTP) Confirm() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPost,
+		Path:    "/auth/totp/confirm",
+		Handler: h.confirm,
+		Name:    "auth.totp.confirm",
+	}
+}
+
+// RecoveryCodes returns the route for regenerating the recovery codes.
+//
+//	@Tags			Enduser
+//	@Summary		Regenerate the recovery codes.
+//	@Description	Replaces the recovery codes, verified by a code of the authenticator app or a recovery code.
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			body	body		auth.TOTPCodeReq	true	"The code of the authenticator app or a recovery code."
+//	@Success		200		{object}	kernel.HttpRes[auth.RecoveryCodesRes]
+//	@Failure		400		{object}	httpkit.ValidationProblem
+//	@Failure		401		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
+//	@Failure		422		{object}	httpkit.ValidationProblem
+//	@Failure		429		{object}	problemdetail.ProblemDetail
+//	@Router			/auth/totp/recovery-codes [post]
+func (h *TOTP) RecoveryCodes() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPost,
+		Path:    "/auth/totp/recovery-codes",
+		Handler: h.recoveryCodes,
+		Name:    "auth.totp.recovery_codes",
+	}
+}
+
+// Disable returns the route for deactivating the TOTP.
+//
+//	@Tags			Enduser
+//	@Summary		Disable the TOTP.
+//	@Description	Deactivates the TOTP and deletes the recovery codes, verified by a code of the authenticator app or
+//	@Description	a recovery code.
+//	@Accept			json
+//	@Security		ApiKeyAuth
+//	@Param			body	body	auth.TOTPCodeReq	true	"The code of the authenticator app or a recovery code."
+//	@Success		204
+//	@Failure		400	{object}	httpkit.ValidationProblem
+//	@Failure		401	{object}	problemdetail.ProblemDetail
+//	@Failure		404	{object}	problemdetail.ProblemDetail
+//	@Failure		422	{object}	httpkit.ValidationProblem
+//	@Failure		429	{object}	problemdetail.ProblemDetail
+//	@Router			/auth/totp/disable [post]
+func (h *TOTP) Disable() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPost,
+		Path:    "/auth/totp/disable",
+		Handler: h.disable,
+		Name:    "auth.totp.disable",
+	}
+}
+
+func (h *TOTP) setup(w http.ResponseWriter, r *http.Request) error {
+	id, err := subjectUUID(r)
+	if err != nil {
+		return fmt.Errorf("setup totp: %w", err)
+	}
+
+	setup, err := h.totp.Setup(r.Context(), id)
+	if err != nil {
+		return fmt.Errorf("setup totp: %w", err)
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), setup).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *TOTP) confirm(w http.ResponseWriter, r *http.Request) error {
+	id, code, err := h.readCode(r)
+	if err != nil {
+		return fmt.Errorf("confirm totp: %w", err)
+	}
+
+	codes, err := h.totp.Confirm(r.Context(), id, code)
+	if err != nil {
+		return fmt.Errorf("confirm totp: %w", otpProblem(err))
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), auth.RecoveryCodesRes{Codes: codes}).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *TOTP) recoveryCodes(w http.ResponseWriter, r *http.Request) error {
+	id, code, err := h.readCode(r)
+	if err != nil {
+		return fmt.Errorf("regenerate recovery codes: %w", err)
+	}
+
+	codes, err := h.totp.RegenerateRecoveryCodes(r.Context(), id, code)
+	if err != nil {
+		return fmt.Errorf("regenerate recovery codes: %w", otpProblem(err))
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), auth.RecoveryCodesRes{Codes: codes}).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *TOTP) disable(w http.ResponseWriter, r *http.Request) error {
+	id, code, err := h.readCode(r)
+	if err != nil {
+		return fmt.Errorf("disable totp: %w", err)
+	}
+
+	if err := h.totp.Disable(r.Context(), id, code); err != nil {
+		return fmt.Errorf("disable totp: %w", otpProblem(err))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// readCode reads the authenticated user ID and the code of the request.
+func (h *TOTP) readCode(r *http.Request) (id idkit.UUID, code string, err error) {
+	id, err = subjectUUID(r)
+	if err != nil {
+		return id, "", err
+	}
+
+	var req auth.TOTPCodeReq
+	if err := readValidRequest(r, h.validate, &req); err != nil {
+		return id, "", err
+	}
+	return id, req.Code, nil
+}
+
+// otpProblem converts the invalid OTP to the error of the code field, the other errors are returned as they are.
+func otpProblem(err error) error {
+	if !errors.Is(err, auth.ErrInvalidOTP) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", httpkit.NewValidationProblem(httpkit.FieldError{
+		Field:   "code",
+		Code:    "otp",
+		Message: "the code is incorrect, expired or already used",
+	}), err)
+}
@@ -0,0 +1,119 @@
+//go:build cgo
+
+package httphandler
+
+import (
+	"encoding/base32"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/swe-be-mono/internal/domain/auth"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/internal/httpmiddleware"
+	"github.com/josestg/swe-be-mono/internal/kernel"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/otpkit"
+	"github.com/josestg/swe-be-mono/pkg/passwd"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit/sqlxkittest"
+	"github.com/josestg/swe-be-mono/resources/migrations"
+)
+
+func TestTOTP(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+
+	db, teardown := sqlxkittest.Setup(t, migrations.Postgre())
+	t.Cleanup(teardown)
+
+	repo, err := user.NewRepository(db)
+	expectTrue(t, err == nil)
+	users := user.NewService(repo)
+
+	tokens, err := auth.NewTokenService(db, auth.TokenConfig{Keys: map[string]string{"k1": "HS256:" + secret}})
+	expectTrue(t, err == nil)
+
+	totp, err := auth.NewTOTPService(db, users, auth.TOTPConfig{Key: secret})
+	expectTrue(t, err == nil)
+
+	hasher := passwd.NewHasher(passwd.Argon2id{Memory: 64, Time: 1, Parallelism: 1})
+	authService, err := auth.NewService(db, users, tokens, auth.ServiceConfig{Hasher: hasher, TOTP: totp})
+	expectTrue(t, err == nil)
+
+	mux := newTestMux()
+	authn := httpmiddleware.JWTAuth(httpmiddleware.JWTAuthConfig{KeySet: tokens.KeySet()})
+	ServeAuth(mux, authService, tokens, users, authn)
+	ServeTOTP(mux, totp, authn)
+
+	do := func(method, path, body, token string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	register := `{"email": "alice@example.com", "name": "Alice", "password": "Tr0ub4dor&3x"}`
+	rec := do(http.MethodPost, "/auth/register", register, "")
+	expectTrue(t, rec.Code == http.StatusCreated)
+
+	login := func(otp string) *httptest.ResponseRecorder {
+		return do(http.MethodPost, "/auth/token",
+			`{"email": "alice@example.com", "password": "Tr0ub4dor&3x", "otp": "`+otp+`"}`, "")
+	}
+
+	rec = login("")
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	var token kernel.HttpRes[auth.TokenRes]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&token) == nil)
+	access := token.Data.AccessToken
+
+	rec = do(http.MethodPost, "/auth/totp", "", "")
+	expectTrue(t, rec.Code == http.StatusUnauthorized)
+
+	rec = do(http.MethodPost, "/auth/totp", "", access)
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	var setup kernel.HttpRes[auth.TOTPSetup]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&setup) == nil)
+	expectTrue(t, strings.HasPrefix(setup.Data.URI, "otpauth://totp/"))
+
+	rec = do(http.MethodPost, "/auth/totp/confirm", `{"code": "000000"}`, access)
+	expectTrue(t, rec.Code == http.StatusUnprocessableEntity)
+
+	var problem httpkit.ValidationProblem
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&problem) == nil)
+	expectTrue(t, len(problem.Errors) == 1 && problem.Errors[0].Field == "code")
+
+	b, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(setup.Data.Secret)
+	expectTrue(t, err == nil)
+	code := otpkit.New(otpkit.Config{}).Code(b, time.Now())
+
+	rec = do(http.MethodPost, "/auth/totp/confirm", `{"code": "`+code+`"}`, access)
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	var codes kernel.HttpRes[auth.RecoveryCodesRes]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&codes) == nil)
+	expectTrue(t, len(codes.Data.Codes) == auth.RecoveryCodeCount)
+
+	rec = login("")
+	expectTrue(t, rec.Code == http.StatusUnauthorized)
+	expectTrue(t, strings.Contains(rec.Body.String(), "otp-required"))
+
+	rec = login(code) // replayed.
+	expectTrue(t, rec.Code == http.StatusUnauthorized)
+
+	rec = login(codes.Data.Codes[0])
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	rec = do(http.MethodPost, "/auth/totp/disable", `{"code": "`+codes.Data.Codes[1]+`"}`, access)
+	expectTrue(t, rec.Code == http.StatusNoContent)
+
+	rec = do(http.MethodPost, "/auth/totp/disable", `{"code": "`+codes.Data.Codes[2]+`"}`, access)
+	expectTrue(t, rec.Code == http.StatusNotFound)
+}
@@ -0,0 +1,250 @@
+package httphandler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/josestg/swe-be-mono/internal/domain/rbac"
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/internal/httpmiddleware"
+	"github.com/josestg/swe-be-mono/internal/kernel"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+// Set of the pagination defaults of the list endpoints.
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// User is a handler for managing the user accounts.
+type User struct {
+	users    *user.Service
+	validate *validator.Validate
+}
+
+// ServeUser registers the user handler to the given mux. The routes declare the required permissions by
+// httpmiddleware.MetaScopes, which are checked by httpmiddleware.Authorize if the mux uses it.
+func ServeUser(mux *httpkit.ServeMux, users *user.Service) {
+	h := &User{
+		users:    users,
+		validate: httpkit.NewValidator(),
+	}
+	mux.Route(h.Create())
+	mux.Route(h.List())
+	mux.Route(h.Get())
+	mux.Route(h.Update())
+	mux.Route(h.Delete())
+}
+
+// Create returns the route for creating a user.
+//
+//	@Tags			Admin
+//	@Summary		Create a user.
+//	@Description	Creates a user account, the email is lowercased and must be unique.
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			body	body		user.CreateReq	true	"The user to create."
+//	@Success		201		{object}	kernel.HttpRes[user.UserRes]
+//	@Failure		400		{object}	httpkit.ValidationProblem
+//	@Failure		401		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		409		{object}	problemdetail.ProblemDetail
+//	@Failure		422		{object}	httpkit.ValidationProblem
+//	@Router			/users [post]
+func (h *User) Create() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPost,
+		Path:    "/users",
+		Handler: h.create,
+		Name:    "users.create",
+		Meta:    map[string]any{httpmiddleware.MetaScopes: []string{rbac.PermUsersWrite}},
+	}
+}
+
+// List returns the route for listing the users.
+//
+//	@Tags			Admin
+//	@Summary		List the users.
+//	@Description	Returns a page of the users ordered by the creation time.
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			page		query		int	false	"The 1-based page number."		default(1)	minimum(1)
+//	@Param			per_page	query		int	false	"The number of users per page."	default(20)	minimum(1)	maximum(100)
+//	@Success		200			{object}	kernel.HttpPageRes[user.UserRes]
+//	@Failure		400			{object}	httpkit.ValidationProblem
+//	@Failure		401			{object}	problemdetail.ProblemDetail
+//	@Failure		403			{object}	problemdetail.ProblemDetail
+//	@Router			/users [get]
+func (h *User) List() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/users",
+		Handler: h.list,
+		Name:    "users.list",
+		Meta:    map[string]any{httpmiddleware.MetaScopes: []string{rbac.PermUsersRead}},
+	}
+}
+
+// Get returns the route for getting a user.
+//
+//	@Tags			Admin
+//	@Summary		Get a user.
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string	true	"The user ID."	format(uuid)
+//	@Success		200	{object}	kernel.HttpRes[user.UserRes]
+//	@Failure		400	{object}	httpkit.ValidationProblem
+//	@Failure		401	{object}	problemdetail.ProblemDetail
+//	@Failure		403	{object}	problemdetail.ProblemDetail
+//	@Failure		404	{object}	problemdetail.ProblemDetail
+//	@Router			/users/{id} [get]
+func (h *User) Get() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/users/:id",
+		Handler: h.get,
+		Name:    "users.get",
+		Meta:    map[string]any{httpmiddleware.MetaScopes: []string{rbac.PermUsersRead}},
+	}
+}
+
+// Update returns the route for updating a user.
+//
+//	@Tags			Admin
+//	@Summary		Update a user.
+//	@Description	Replaces the email and the name of a user, the email is lowercased and must be unique.
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string			true	"The user ID."	format(uuid)
+//	@Param			body	body		user.UpdateReq	true	"The new values."
+//	@Success		200		{object}	kernel.HttpRes[user.UserRes]
+//	@Failure		400		{object}	httpkit.ValidationProblem
+//	@Failure		401		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
+//	@Failure		409		{object}	problemdetail.ProblemDetail
+//	@Failure		422		{object}	httpkit.ValidationProblem
+//	@Router			/users/{id} [put]
+func (h *User) Update() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodPut,
+		Path:    "/users/:id",
+		Handler: h.update,
+		Name:    "users.update",
+		Meta:    map[string]any{httpmiddleware.MetaScopes: []string{rbac.PermUsersWrite}},
+	}
+}
+
+// Delete returns the route for deleting a user.
+//
+//	@Tags			Admin
+//	@Summary		Delete a user.
+//	@Security		ApiKeyAuth
+//	@Param			id	path	string	true	"The user ID."	format(uuid)
+//	@Success		204
+//	@Failure		400	{object}	httpkit.ValidationProblem
+//	@Failure		401	{object}	problemdetail.ProblemDetail
+//	@Failure		403	{object}	problemdetail.ProblemDetail
+//	@Failure		404	{object}	problemdetail.ProblemDetail
+//	@Router			/users/{id} [delete]
+func (h *User) Delete() httpkit.Route {
+	return httpkit.Route{
+		Method:  http.MethodDelete,
+		Path:    "/users/:id",
+		Handler: h.delete,
+		Name:    "users.delete",
+		Meta:    map[string]any{httpmiddleware.MetaScopes: []string{rbac.PermUsersWrite}},
+	}
+}
+
+func (h *User) create(w http.ResponseWriter, r *http.Request) error {
+	var req user.CreateReq
+	if err := readValidRequest(r, h.validate, &req); err != nil {
+		return err
+	}
+
+	u, err := h.users.Create(r.Context(), req)
+	if err != nil {
+		return fmt.Errorf("create user: %w", err)
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), u.Res()).Code(http.StatusCreated).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *User) list(w http.ResponseWriter, r *http.Request) error {
+	page, err := queryInt(r, "page", 1, 1, 0)
+	if err != nil {
+		return err
+	}
+	perPage, err := queryInt(r, "per_page", DefaultPerPage, 1, MaxPerPage)
+	if err != nil {
+		return err
+	}
+
+	users, total, err := h.users.List(r.Context(), page, perPage)
+	if err != nil {
+		return fmt.Errorf("list users: %w", err)
+	}
+
+	items := make([]user.UserRes, 0, len(users))
+	for _, u := range users {
+		items = append(items, u.Res())
+	}
+
+	res := kernel.NewHttpPageResBuilderCtx(r.Context(), items).Page(page, perPage).Total(total).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *User) get(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	u, err := h.users.Get(r.Context(), id)
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), u.Res()).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *User) update(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req user.UpdateReq
+	if err := readValidRequest(r, h.validate, &req); err != nil {
+		return err
+	}
+
+	u, err := h.users.Update(r.Context(), id, req)
+	if err != nil {
+		return fmt.Errorf("update user: %w", err)
+	}
+
+	res := kernel.NewHttpResBuilderCtx(r.Context(), u.Res()).Build()
+	return httpkit.WriteJSON(w, res, res.Code)
+}
+
+func (h *User) delete(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.users.Delete(r.Context(), id); err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
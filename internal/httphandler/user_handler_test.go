@@ -0,0 +1,111 @@
+//go:build cgo
+
+package httphandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/josestg/swe-be-mono/internal/domain/user"
+	"github.com/josestg/swe-be-mono/internal/httpmiddleware"
+	"github.com/josestg/swe-be-mono/internal/kernel"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit/sqlxkittest"
+	"github.com/josestg/swe-be-mono/resources/migrations"
+)
+
+func TestUser(t *testing.T) {
+	db, teardown := sqlxkittest.Setup(t, migrations.Postgre())
+	t.Cleanup(teardown)
+
+	repo, err := user.NewRepository(db)
+	expectTrue(t, err == nil)
+
+	mux := newTestMux()
+	ServeUser(mux, user.NewService(repo))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(idkit.WithProvider(req.Context(), idkit.NewSequentialUUIDProvider(0)))
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	const id = "00000000-0000-4000-8000-000000000001"
+
+	rec := do(http.MethodPost, "/users", `{"email": "Alice@Example.com", "name": "Alice"}`)
+	expectTrue(t, rec.Code == http.StatusCreated)
+
+	var created kernel.HttpRes[user.UserRes]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&created) == nil)
+	expectTrue(t, created.Data.ID == id)
+	expectTrue(t, created.Data.Email == "alice@example.com")
+
+	rec = do(http.MethodPost, "/users", `{"email": "not-an-email", "name": "Alice"}`)
+	expectTrue(t, rec.Code == http.StatusUnprocessableEntity)
+
+	rec = do(http.MethodPost, "/users", `{"email": "alice@example.com", "name": "Alice", "unknown": 1}`)
+	expectTrue(t, rec.Code == http.StatusBadRequest)
+
+	rec = do(http.MethodGet, "/users/"+id, "")
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	rec = do(http.MethodGet, "/users/not-a-uuid", "")
+	expectTrue(t, rec.Code == http.StatusBadRequest)
+
+	rec = do(http.MethodGet, "/users?page=1&per_page=10", "")
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	var page kernel.HttpPageRes[user.UserRes]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&page) == nil)
+	expectTrue(t, len(page.Items) == 1 && page.Items[0].ID == id)
+	expectTrue(t, page.Page == 1 && page.PerPage == 10)
+	expectTrue(t, page.Total != nil && *page.Total == 1)
+
+	rec = do(http.MethodGet, "/users?per_page=101", "")
+	expectTrue(t, rec.Code == http.StatusBadRequest)
+
+	rec = do(http.MethodPut, "/users/"+id, `{"email": "alice@example.org", "name": "Alice Liddell"}`)
+	expectTrue(t, rec.Code == http.StatusOK)
+
+	var updated kernel.HttpRes[user.UserRes]
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&updated) == nil)
+	expectTrue(t, updated.Data.Email == "alice@example.org")
+	expectTrue(t, updated.Data.Name == "Alice Liddell")
+
+	rec = do(http.MethodDelete, "/users/"+id, "")
+	expectTrue(t, rec.Code == http.StatusNoContent)
+
+	rec = do(http.MethodGet, "/users/"+id, "")
+	expectTrue(t, rec.Code == http.StatusNotFound)
+
+	rec = do(http.MethodDelete, "/users/"+id, "")
+	expectTrue(t, rec.Code == http.StatusNotFound)
+}
+
+// newTestMux creates a mux mapping the errors to the responses like httpmiddleware.LogAndErrHandling, followed by
+// the given middlewares.
+func newTestMux(mids ...httpkit.MuxMiddleware) *httpkit.ServeMux {
+	mapErr := func(next httpkit.Handler) httpkit.Handler {
+		return httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			if err := next.ServeHTTP(w, r); err != nil {
+				_ = httpmiddleware.MapError(w, err)
+			}
+			return nil
+		})
+	}
+	mid := httpkit.ReduceMuxMiddleware(append([]httpkit.MuxMiddleware{mapErr}, mids...)...)
+	return httpkit.NewServeMux(httpkit.Opts.Middleware(mid))
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
@@ -0,0 +1,124 @@
+package httpmiddleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+// HeaderAPIKey is the header name for carrying the API key.
+const HeaderAPIKey = "X-API-Key"
+
+// ErrAPIKeyNotFound is returned by APIKeyStore when the key ID is not found.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKey is the metadata of an API key. The API key sent by the client has the format of "<id>.<secret>", only the
+// SHA-256 digest of the secret is stored.
+type APIKey struct {
+	ID     string   // the public part of the key, used for looking up the key.
+	Owner  string   // the owner of the key, e.g. the name of the calling service.
+	Scopes []string // the scopes granted to the key.
+	Hash   []byte   // the SHA-256 digest of the secret part of the key.
+}
+
+// HashAPIKeySecret returns the SHA-256 digest of the secret to be stored as APIKey.Hash.
+func HashAPIKeySecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+// APIKeyStore knows how to find an API key.
+type APIKeyStore interface {
+	// FindAPIKey finds the API key by its ID. It returns ErrAPIKeyNotFound if the key does not exist.
+	FindAPIKey(ctx context.Context, id string) (*APIKey, error)
+}
+
+// MemoryAPIKeyStore is an in-memory APIKeyStore.
+// This store is concurrent-safe.
+type MemoryAPIKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]APIKey
+}
+
+// NewMemoryAPIKeyStore creates a new MemoryAPIKeyStore with the given keys.
+func NewMemoryAPIKeyStore(keys ...APIKey) *MemoryAPIKeyStore {
+	s := MemoryAPIKeyStore{keys: make(map[string]APIKey, len(keys))}
+	for _, k := range keys {
+		s.Put(k)
+	}
+	return &s
+}
+
+// Put adds or replaces the key.
+func (s *MemoryAPIKeyStore) Put(key APIKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.ID] = key
+}
+
+// FindAPIKey implements APIKeyStore.
+func (s *MemoryAPIKeyStore) FindAPIKey(_ context.Context, id string) (*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	return &key, nil
+}
+
+// apiKeyKey is the context key for the authenticated API key.
+type apiKeyKey struct{}
+
+// APIKeyFromContext gets the authenticated API key from the context.
+func APIKeyFromContext(ctx context.Context) (*APIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey{}).(*APIKey)
+	return key, ok
+}
+
+// _dummyAPIKeyHash is compared against when the key is not found, so the response time does not tell whether the
+// key ID exists.
+var _dummyAPIKeyHash = HashAPIKeySecret("dummy")
+
+// APIKeyAuth is a middleware that authenticates machine-to-machine callers by the X-API-Key header.
+// The secret is compared in constant time and the authenticated key is stored in the request context and can be
+// retrieved by APIKeyFromContext.
+//
+// Missing or invalid keys are rejected with business.PDTypeUnauthenticated, which is mapped to 401 by MapError.
+func APIKeyAuth(store APIKeyStore) httpkit.MuxMiddleware {
+	return func(next httpkit.Handler) httpkit.Handler {
+		return httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			id, secret, ok := strings.Cut(r.Header.Get(HeaderAPIKey), ".")
+			if !ok || id == "" || secret == "" {
+				return unauthenticated("missing or malformed api key", errors.New("api key auth: missing or malformed key"))
+			}
+
+			key, err := store.FindAPIKey(r.Context(), id)
+			if err != nil && !errors.Is(err, ErrAPIKeyNotFound) {
+				return fmt.Errorf("api key auth: find key: %w", err)
+			}
+
+			expected := _dummyAPIKeyHash
+			if key != nil {
+				expected = key.Hash
+			}
+
+			match := subtle.ConstantTimeCompare(HashAPIKeySecret(secret), expected) == 1
+			if key == nil || !match {
+				return unauthenticated("invalid api key", fmt.Errorf("api key auth: invalid key: id=%q", id))
+			}
+
+			ctx := context.WithValue(r.Context(), apiKeyKey{}, key)
+			ctx = httpkit.WithLogAttrs(ctx, slog.String("user_id", key.ID))
+			return next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package httpmiddleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+type failingAPIKeyStore struct{}
+
+func (failingAPIKeyStore) FindAPIKey(context.Context, string) (*APIKey, error) {
+	return nil, errors.New("store unavailable")
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	store := NewMemoryAPIKeyStore(APIKey{
+		ID:     "billing",
+		Owner:  "billing-service",
+		Scopes: []string{"users:read"},
+		Hash:   HashAPIKeySecret("s3cr3t"),
+	})
+
+	serve := func(store APIKeyStore, header string) (int, *APIKey, error) {
+		var key *APIKey
+		handler := APIKeyAuth(store).Then(httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			key, _ = APIKeyFromContext(r.Context())
+			return nil
+		}))
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set(HeaderAPIKey, header)
+		}
+		err := handler.ServeHTTP(rec, req)
+		if err != nil {
+			err = MapError(rec, err)
+		}
+		return rec.Code, key, err
+	}
+
+	code, key, err := serve(store, "billing.s3cr3t")
+	if err != nil || code != http.StatusOK || key == nil || key.Owner != "billing-service" {
+		t.Fatalf("expected authenticated request, got code=%d err=%v", code, err)
+	}
+
+	for _, header := range []string{"", "billing", "billing.", "billing.wrong", "unknown.s3cr3t"} {
+		code, key, _ := serve(store, header)
+		if code != http.StatusUnauthorized || key != nil {
+			t.Errorf("header %q: expected 401, got %d", header, code)
+		}
+	}
+
+	code, _, err = serve(failingAPIKeyStore{}, "billing.s3cr3t")
+	var resolvedErr *httpkit.ResolvedError
+	if code != http.StatusInternalServerError || errors.As(err, &resolvedErr) {
+		t.Errorf("expected unresolved 500, got %d", code)
+	}
+}
@@ -0,0 +1,163 @@
+package httpmiddleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+// MetaScopes is the route metadata key for the scopes required to access the route.
+// The value must be a []string, the principal must have all the scopes.
+//
+//	httpkit.Route{..., Meta: map[string]any{httpmiddleware.MetaScopes: []string{"users:read"}}}
+const MetaScopes = "scopes"
+
+// Principal is the authenticated caller of the request.
+type Principal struct {
+	ID     string   // the subject of the JWT or the ID of the API key.
+	Scopes []string // the granted scopes.
+}
+
+// HasScopes reports whether the principal has all the given scopes.
+func (p Principal) HasScopes(scopes ...string) bool {
+	for _, want := range scopes {
+		found := false
+		for _, got := range p.Scopes {
+			if got == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// PrincipalFromContext gets the principal authenticated by either JWTAuth or APIKeyAuth from the context, its scopes
+// include the permissions granted by ResolvePermissions.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := authenticatedPrincipal(ctx)
+	if !ok {
+		return Principal{}, false
+	}
+
+	if perms, ok := ctx.Value(permissionsKey{}).([]string); ok {
+		p.Scopes = append(slices.Clip(p.Scopes), perms...)
+	}
+	return p, true
+}
+
+// authenticatedPrincipal gets the principal with the scopes granted by the authentication only.
+func authenticatedPrincipal(ctx context.Context) (Principal, bool) {
+	if claims, ok := JWTClaimsFromContext(ctx); ok {
+		return Principal{ID: claims.Subject, Scopes: claims.Scopes()}, true
+	}
+
+	if key, ok := APIKeyFromContext(ctx); ok {
+		return Principal{ID: key.ID, Scopes: key.Scopes}, true
+	}
+
+	return Principal{}, false
+}
+
+// PermissionResolver knows how to resolve the permissions granted to the principal besides its scopes, e.g. by the
+// roles of the user.
+type PermissionResolver interface {
+	Permissions(ctx context.Context, p Principal) ([]string, error)
+}
+
+// PermissionResolverFunc is an adapter to allow the use of ordinary functions as PermissionResolver.
+type PermissionResolverFunc func(ctx context.Context, p Principal) ([]string, error)
+
+// Permissions calls f(ctx, p).
+func (f PermissionResolverFunc) Permissions(ctx context.Context, p Principal) ([]string, error) {
+	return f(ctx, p)
+}
+
+type permissionsKey struct{}
+
+// ResolvePermissions is a middleware that grants the permissions resolved by the resolver to the principal, so they
+// are checked like the scopes by Authorize and RequireScopes. It must be placed after the authentication middleware
+// and before the authorizers, the anonymous requests are passed through.
+func ResolvePermissions(resolver PermissionResolver) httpkit.MuxMiddleware {
+	return func(next httpkit.Handler) httpkit.Handler {
+		return httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			principal, ok := authenticatedPrincipal(r.Context())
+			if !ok {
+				return next.ServeHTTP(w, r)
+			}
+
+			perms, err := resolver.Permissions(r.Context(), principal)
+			if err != nil {
+				return fmt.Errorf("resolve permissions of principal %q: %w", principal.ID, err)
+			}
+			return next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), permissionsKey{}, perms)))
+		})
+	}
+}
+
+// RequireScopes is a middleware that only allows principals that have all the given scopes.
+// It must be placed after the authentication middleware.
+//
+// Unauthenticated requests are rejected with business.PDTypeUnauthenticated (401) and requests that lack any of
+// the scopes are rejected with business.PDTypeForbidden (403).
+func RequireScopes(scopes ...string) httpkit.MuxMiddleware {
+	return func(next httpkit.Handler) httpkit.Handler {
+		return httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			if err := checkScopes(r.Context(), scopes); err != nil {
+				return err
+			}
+			return next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Authorize is a central authorizer that reads the required scopes from the route metadata (see MetaScopes) and
+// checks them against the principal, so the routes only declare what they need. Routes without the scopes metadata
+// are allowed. It must be placed after the authentication middleware.
+func Authorize() httpkit.MuxMiddleware {
+	return func(next httpkit.Handler) httpkit.Handler {
+		return httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			route, ok := httpkit.RouteFromContext(r.Context())
+			if !ok {
+				return next.ServeHTTP(w, r)
+			}
+
+			raw, ok := route.Meta[MetaScopes]
+			if !ok {
+				return next.ServeHTTP(w, r)
+			}
+
+			scopes, ok := raw.([]string)
+			if !ok {
+				return fmt.Errorf("authorize: route %s %s: invalid %q metadata type: %T", route.Method, route.Path, MetaScopes, raw)
+			}
+
+			if err := checkScopes(r.Context(), scopes); err != nil {
+				return err
+			}
+			return next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// checkScopes checks the principal in the context against the required scopes.
+func checkScopes(ctx context.Context, scopes []string) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return unauthenticated("authentication is required", errors.New("authorize: missing principal"))
+	}
+
+	if !principal.HasScopes(scopes...) {
+		detail := fmt.Sprintf("required scopes: %s", strings.Join(scopes, " "))
+		return forbidden(detail, fmt.Errorf("authorize: principal %q lacks scopes %v", principal.ID, scopes))
+	}
+	return nil
+}
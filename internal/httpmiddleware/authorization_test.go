@@ -0,0 +1,147 @@
+package httpmiddleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+func TestAuthorize(t *testing.T) {
+	store := NewMemoryAPIKeyStore(
+		APIKey{ID: "reader", Scopes: []string{"users:read"}, Hash: HashAPIKeySecret("secret")},
+		APIKey{ID: "admin", Scopes: []string{"users:read", "users:write"}, Hash: HashAPIKeySecret("secret")},
+	)
+
+	auth := httpkit.ReduceMuxMiddleware(
+		func(next httpkit.Handler) httpkit.Handler {
+			return httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				if err := next.ServeHTTP(w, r); err != nil {
+					return MapError(w, err)
+				}
+				return nil
+			})
+		},
+		func(next httpkit.Handler) httpkit.Handler {
+			// only authenticate when the key is present, so the authorizer sees anonymous requests.
+			return httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				if r.Header.Get(HeaderAPIKey) == "" {
+					return next.ServeHTTP(w, r)
+				}
+				return APIKeyAuth(store).Then(next).ServeHTTP(w, r)
+			})
+		},
+		Authorize(),
+	)
+
+	noop := func(w http.ResponseWriter, r *http.Request) error { return nil }
+	mux := httpkit.NewServeMux(httpkit.Opts.Middleware(auth))
+	mux.Route(httpkit.Route{Method: http.MethodGet, Path: "/public", Handler: noop})
+	mux.Route(httpkit.Route{
+		Method:  http.MethodPost,
+		Path:    "/users",
+		Handler: noop,
+		Meta:    map[string]any{MetaScopes: []string{"users:write"}},
+	})
+	mux.Route(httpkit.Route{
+		Method:  http.MethodGet,
+		Path:    "/users",
+		Handler: noop,
+		Meta:    map[string]any{MetaScopes: []string{"users:read"}},
+	}, RequireScopes("users:read"))
+
+	tests := []struct {
+		method, path, key string
+		status            int
+	}{
+		{http.MethodGet, "/public", "", http.StatusOK},
+		{http.MethodGet, "/users", "", http.StatusUnauthorized},
+		{http.MethodGet, "/users", "reader.secret", http.StatusOK},
+		{http.MethodPost, "/users", "reader.secret", http.StatusForbidden},
+		{http.MethodPost, "/users", "admin.secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.key != "" {
+			req.Header.Set(HeaderAPIKey, tt.key)
+		}
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s with key %q: want %d, got %d", tt.method, tt.path, tt.key, tt.status, rec.Code)
+		}
+	}
+}
+
+func TestResolvePermissions(t *testing.T) {
+	store := NewMemoryAPIKeyStore(
+		APIKey{ID: "alice", Scopes: []string{"users:read"}, Hash: HashAPIKeySecret("secret")},
+		APIKey{ID: "bob", Hash: HashAPIKeySecret("secret")},
+		APIKey{ID: "broken", Hash: HashAPIKeySecret("secret")},
+	)
+	resolver := PermissionResolverFunc(func(ctx context.Context, p Principal) ([]string, error) {
+		switch p.ID {
+		case "alice":
+			return []string{"users:write"}, nil
+		case "broken":
+			return nil, errors.New("database is down")
+		}
+		return nil, nil
+	})
+
+	auth := httpkit.ReduceMuxMiddleware(
+		func(next httpkit.Handler) httpkit.Handler {
+			return httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				if err := next.ServeHTTP(w, r); err != nil {
+					return MapError(w, err)
+				}
+				return nil
+			})
+		},
+		APIKeyAuth(store),
+		ResolvePermissions(resolver),
+		Authorize(),
+	)
+
+	noop := func(w http.ResponseWriter, r *http.Request) error { return nil }
+	mux := httpkit.NewServeMux(httpkit.Opts.Middleware(auth))
+	mux.Route(httpkit.Route{
+		Method:  http.MethodPut,
+		Path:    "/users",
+		Handler: noop,
+		Meta:    map[string]any{MetaScopes: []string{"users:read", "users:write"}},
+	})
+
+	tests := []struct {
+		key    string
+		status int
+	}{
+		{"alice.secret", http.StatusOK}, // users:read by the key and users:write by the resolver.
+		{"bob.secret", http.StatusForbidden},
+		{"broken.secret", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/users", nil)
+		req.Header.Set(HeaderAPIKey, tt.key)
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("key %q: want %d, got %d", tt.key, tt.status, rec.Code)
+		}
+	}
+}
+
+func TestPrincipal_HasScopes(t *testing.T) {
+	p := Principal{ID: "p", Scopes: []string{"a", "b"}}
+	if !p.HasScopes() || !p.HasScopes("a") || !p.HasScopes("b", "a") {
+		t.Errorf("expected principal has the scopes")
+	}
+	if p.HasScopes("a", "c") {
+		t.Errorf("expected principal lacks the scope c")
+	}
+}
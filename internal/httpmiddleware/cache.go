@@ -0,0 +1,225 @@
+package httpmiddleware
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+// HeaderCache is the response header that tells whether the response is served from the cache, HIT or MISS.
+const HeaderCache = "X-Cache"
+
+// CacheKeyFunc returns the cache key of the request. The key must include everything that varies the response, and
+// it should start with the path so the entries can be invalidated by InvalidatePrefix. The key doesn't need to
+// include the principal, the entries are scoped by the principal anyway, see ResponseCache.Cache.
+type CacheKeyFunc func(r *http.Request) string
+
+// CacheKeyURI uses the request URI and the Accept header as the cache key.
+func CacheKeyURI(r *http.Request) string {
+	return r.URL.RequestURI() + "|" + r.Header.Get("Accept")
+}
+
+// DefaultCacheMaxEntries is the maximum number of entries of the ResponseCache when it isn't given.
+const DefaultCacheMaxEntries = 10_000
+
+// cacheScopeSep separates the key of the entry from its principal.
+const cacheScopeSep = "\x00"
+
+// ResponseCache is an in-memory cache of successful GET responses. Expired entries are evicted lazily, and when the
+// cache is full, an arbitrary entry is evicted. ResponseCache is concurrent-safe.
+type ResponseCache struct {
+	mu         sync.RWMutex
+	entries    map[string]cacheEntry
+	maxEntries int
+	sweptAt    time.Time
+}
+
+type cacheEntry struct {
+	status    int
+	header    http.Header
+	body      []byte
+	expiresAt time.Time
+}
+
+// NewResponseCache creates a new ResponseCache holding up to maxEntries, zero or negative means
+// DefaultCacheMaxEntries.
+func NewResponseCache(maxEntries int) *ResponseCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheMaxEntries
+	}
+	return &ResponseCache{entries: make(map[string]cacheEntry), maxEntries: maxEntries, sweptAt: time.Now()}
+}
+
+// DefaultResponseCache is the ResponseCache used by Cache, Invalidate and InvalidatePrefix.
+var DefaultResponseCache = NewResponseCache(DefaultCacheMaxEntries)
+
+// Cache caches the successful GET responses in the DefaultResponseCache, see ResponseCache.Cache.
+func Cache(ttl time.Duration, keyFn CacheKeyFunc) httpkit.MuxMiddleware {
+	return DefaultResponseCache.Cache(ttl, keyFn)
+}
+
+// Invalidate removes the entries from the DefaultResponseCache.
+func Invalidate(keys ...string) { DefaultResponseCache.Invalidate(keys...) }
+
+// InvalidatePrefix removes the entries whose key starts with the prefix from the DefaultResponseCache.
+func InvalidatePrefix(prefix string) { DefaultResponseCache.InvalidatePrefix(prefix) }
+
+// Cache is a middleware that caches the 200 OK responses (status, headers and body) of GET and HEAD requests for the
+// ttl. If nil key function is given, CacheKeyURI is used. Write handlers should call Invalidate or InvalidatePrefix
+// after changing the underlying data.
+//
+// The entries are scoped by the principal, so the middleware must be placed after the authentication, see
+// PrincipalFromContext. The requests carrying the Authorization or the Cookie header without a principal bypass the
+// cache, since their responses may depend on credentials the cache doesn't know.
+//
+// The response is captured from the LogEntry, so the LogEntryRecorder must be installed, and responses with the
+// discarded or truncated body are not cached. Responses that set cookies or have Cache-Control of no-store or private
+// are never cached.
+func (c *ResponseCache) Cache(ttl time.Duration, keyFn CacheKeyFunc) httpkit.MuxMiddleware {
+	if keyFn == nil {
+		keyFn = CacheKeyURI
+	}
+
+	return func(next httpkit.Handler) httpkit.Handler {
+		return httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				return next.ServeHTTP(w, r)
+			}
+
+			key, ok := cacheKey(r, keyFn)
+			if !ok {
+				return next.ServeHTTP(w, r)
+			}
+
+			if entry, ok := c.get(key); ok {
+				h := w.Header()
+				for k, v := range entry.header {
+					h[k] = v
+				}
+				h.Set(HeaderCache, "HIT")
+				w.WriteHeader(entry.status)
+				if r.Method == http.MethodHead {
+					return nil
+				}
+				if _, err := w.Write(entry.body); err != nil {
+					return fmt.Errorf("cache: write cached response: %w", err)
+				}
+				return nil
+			}
+
+			rec, ok := httpkit.GetLogEntry(w)
+			if !ok {
+				return fmt.Errorf("cache: missing log entry: method=%s path=%s", r.Method, r.URL.Path)
+			}
+
+			w.Header().Set(HeaderCache, "MISS")
+			if err := next.ServeHTTP(w, r); err != nil {
+				return err
+			}
+
+			recorded := !rec.DiscardResBody && !rec.ResponseTruncated
+			if r.Method == http.MethodGet && rec.StatusCode == http.StatusOK && recorded && cacheable(w.Header()) {
+				header := w.Header().Clone()
+				header.Del(HeaderCache)
+				c.set(key, cacheEntry{
+					status:    rec.StatusCode,
+					header:    header,
+					body:      bytes.Clone(rec.ResBody().Bytes()),
+					expiresAt: time.Now().Add(ttl),
+				})
+			}
+			return nil
+		})
+	}
+}
+
+// Invalidate removes the entries by their keys, for all the principals.
+func (c *ResponseCache) Invalidate(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.entries {
+		base, _, _ := strings.Cut(k, cacheScopeSep)
+		if slices.Contains(keys, base) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// InvalidatePrefix removes the entries whose key starts with the prefix, e.g. "/api/v1/users" removes all cached
+// user responses when CacheKeyURI is used.
+func (c *ResponseCache) InvalidatePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+func (c *ResponseCache) get(key string) (cacheEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *ResponseCache) set(key string, entry cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.sweptAt) > time.Minute {
+		for k, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		c.sweptAt = now
+	}
+
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = entry
+}
+
+// cacheKey scopes the key of the request by the principal. It reports false if the request carries credentials
+// without a principal, e.g. when the cache is placed before the authentication.
+func cacheKey(r *http.Request, keyFn CacheKeyFunc) (string, bool) {
+	key := keyFn(r)
+	if p, ok := PrincipalFromContext(r.Context()); ok {
+		return key + cacheScopeSep + p.ID, true
+	}
+	if r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "" {
+		return "", false
+	}
+	return key, true
+}
+
+// cacheable reports whether the response can be shared with other clients.
+func cacheable(h http.Header) bool {
+	if h.Get("Set-Cookie") != "" {
+		return false
+	}
+
+	for _, directive := range strings.Split(strings.ToLower(h.Get("Cache-Control")), ",") {
+		switch strings.TrimSpace(directive) {
+		case "no-store", "private":
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+package httpmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+func TestResponseCache(t *testing.T) {
+	cache := NewResponseCache(0)
+
+	var version int
+	mux := httpkit.NewServeMux()
+	mux.Route(httpkit.Route{Method: http.MethodGet, Path: "/users/:id", Handler: func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "text/plain")
+		_, err := w.Write([]byte(strconv.Itoa(version)))
+		return err
+	}}, cache.Cache(time.Minute, nil))
+	mux.Route(httpkit.Route{Method: http.MethodGet, Path: "/private", Handler: func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Cache-Control", "private")
+		_, err := w.Write([]byte(strconv.Itoa(version)))
+		return err
+	}}, cache.Cache(time.Minute, nil))
+	mux.Route(httpkit.Route{Method: http.MethodPut, Path: "/users/:id", Handler: func(w http.ResponseWriter, r *http.Request) error {
+		version++
+		cache.InvalidatePrefix("/users/" + httpkit.PathParams(r).ByName("id"))
+		return nil
+	}}, cache.Cache(time.Minute, nil))
+
+	srv := httpkit.LogEntryRecorder(mux)
+	do := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/users/1")
+	if rec.Header().Get(HeaderCache) != "MISS" || rec.Body.String() != "0" {
+		t.Errorf("want MISS 0, got %s %s", rec.Header().Get(HeaderCache), rec.Body.String())
+	}
+
+	version++
+	rec = do(http.MethodGet, "/users/1")
+	if rec.Header().Get(HeaderCache) != "HIT" || rec.Body.String() != "0" {
+		t.Errorf("want HIT 0, got %s %s", rec.Header().Get(HeaderCache), rec.Body.String())
+	}
+	if rec.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("want cached header, got %q", rec.Header().Get("Content-Type"))
+	}
+
+	_ = do(http.MethodPut, "/users/1")
+	rec = do(http.MethodGet, "/users/1")
+	if rec.Header().Get(HeaderCache) != "MISS" || rec.Body.String() != "2" {
+		t.Errorf("want MISS 2 after invalidation, got %s %s", rec.Header().Get(HeaderCache), rec.Body.String())
+	}
+
+	_ = do(http.MethodGet, "/private")
+	rec = do(http.MethodGet, "/private")
+	if rec.Header().Get(HeaderCache) != "MISS" {
+		t.Errorf("want private response is not cached, got %s", rec.Header().Get(HeaderCache))
+	}
+}
+
+func TestResponseCache_Principal(t *testing.T) {
+	cache := NewResponseCache(0)
+	store := NewMemoryAPIKeyStore(
+		APIKey{ID: "alice", Hash: HashAPIKeySecret("secret")},
+		APIKey{ID: "bob", Hash: HashAPIKeySecret("secret")},
+	)
+
+	me := func(w http.ResponseWriter, r *http.Request) error {
+		p, _ := PrincipalFromContext(r.Context())
+		_, err := w.Write([]byte(p.ID + r.Header.Get("Cookie")))
+		return err
+	}
+	mux := httpkit.NewServeMux()
+	cached := cache.Cache(time.Minute, nil)
+	mux.Route(httpkit.Route{Method: http.MethodGet, Path: "/me", Handler: me}, APIKeyAuth(store), cached)
+	mux.Route(httpkit.Route{Method: http.MethodGet, Path: "/anonymous", Handler: me}, cached)
+
+	srv := httpkit.LogEntryRecorder(mux)
+	do := func(path, header, value string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(header, value)
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	_ = do("/me", HeaderAPIKey, "alice.secret")
+	rec := do("/me", HeaderAPIKey, "bob.secret")
+	if rec.Header().Get(HeaderCache) != "MISS" || rec.Body.String() != "bob" {
+		t.Errorf("want MISS bob, got %s %s", rec.Header().Get(HeaderCache), rec.Body.String())
+	}
+	rec = do("/me", HeaderAPIKey, "alice.secret")
+	if rec.Header().Get(HeaderCache) != "HIT" || rec.Body.String() != "alice" {
+		t.Errorf("want HIT alice, got %s %s", rec.Header().Get(HeaderCache), rec.Body.String())
+	}
+
+	cache.Invalidate("/me|")
+	rec = do("/me", HeaderAPIKey, "alice.secret")
+	if rec.Header().Get(HeaderCache) != "MISS" {
+		t.Errorf("want MISS after invalidation, got %s", rec.Header().Get(HeaderCache))
+	}
+
+	// the credentials without a principal bypass the cache.
+	_ = do("/anonymous", "Cookie", "session=alice")
+	rec = do("/anonymous", "Cookie", "session=bob")
+	if rec.Header().Get(HeaderCache) != "" || rec.Body.String() != "session=bob" {
+		t.Errorf("want bypassed session=bob, got %q %s", rec.Header().Get(HeaderCache), rec.Body.String())
+	}
+}
+
+func TestResponseCache_MaxEntries(t *testing.T) {
+	cache := NewResponseCache(2)
+	for _, key := range []string{"a", "b", "c"} {
+		cache.set(key, cacheEntry{status: http.StatusOK, expiresAt: time.Now().Add(time.Minute)})
+	}
+	if len(cache.entries) != 2 {
+		t.Errorf("want 2 entries, got %d", len(cache.entries))
+	}
+	if _, ok := cache.get("c"); !ok {
+		t.Error("want the latest entry is kept")
+	}
+}
@@ -0,0 +1,186 @@
+package httpmiddleware
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/jwtkit"
+)
+
+// JWTClaims is the set of claims accepted by JWTAuth.
+type JWTClaims struct {
+	jwt.RegisteredClaims
+
+	// Scope is a space-separated list of scopes as defined in RFC 8693.
+	Scope string `json:"scope,omitempty"`
+}
+
+// Scopes returns the list of scopes.
+func (c *JWTClaims) Scopes() []string { return strings.Fields(c.Scope) }
+
+// JWTAuthConfig is the configuration for JWTAuth.
+type JWTAuthConfig struct {
+	// Algorithms is the list of accepted signing algorithms, e.g. HS256, RS256 or EdDSA.
+	// Default the algorithms of the keys of KeySet if set, so the keys rotated in later are accepted, otherwise HS256,
+	// RS256 and EdDSA.
+	Algorithms []string
+
+	// KeySet is the key set for verifying the tokens by their key ID, e.g. the one signing the tokens of this
+	// service. The other keys are ignored when it is set.
+	KeySet *jwtkit.KeySet
+
+	// HMACSecret is the secret for verifying HS* signatures.
+	HMACSecret []byte
+
+	// PublicKey is the static key for verifying RS* and EdDSA signatures.
+	// It is ignored when JWKS is set.
+	PublicKey crypto.PublicKey
+
+	// JWKS is the remote key set for verifying RS* and EdDSA signatures by the token key ID.
+	JWKS *jwtkit.JWKS
+
+	// Issuer and Audience are verified when they are not empty.
+	Issuer   string
+	Audience string
+
+	// Leeway is the allowed clock skew when verifying the time based claims.
+	Leeway time.Duration
+
+	// Authorize is an optional hook for checking the verified claims, e.g. a required role. When it returns an
+	// error, the request is rejected with 403 Forbidden.
+	Authorize func(ctx context.Context, claims *JWTClaims) error
+}
+
+// jwtClaimsKey is the context key for the JWT claims.
+type jwtClaimsKey struct{}
+
+// JWTClaimsFromContext gets the verified claims from the context.
+func JWTClaimsFromContext(ctx context.Context) (*JWTClaims, bool) {
+	claims, ok := ctx.Value(jwtClaimsKey{}).(*JWTClaims)
+	return claims, ok
+}
+
+// JWTAuth is a middleware that authenticates the request by the bearer token in the Authorization header.
+// The verified claims are stored in the request context and can be retrieved by JWTClaimsFromContext.
+//
+// Missing or invalid tokens are rejected with business.PDTypeUnauthenticated and rejections by the Authorize hook
+// with business.PDTypeForbidden, both are mapped to the response by MapError.
+func JWTAuth(cfg JWTAuthConfig) httpkit.MuxMiddleware {
+	parser := jwt.NewParser(jwtParserOptions(cfg)...)
+	return func(next httpkit.Handler) httpkit.Handler {
+		return httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			raw, ok := bearerToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer`)
+				return unauthenticated("missing bearer token", errors.New("jwt auth: missing bearer token"))
+			}
+
+			var claims JWTClaims
+			_, err := parser.ParseWithClaims(raw, &claims, func(token *jwt.Token) (any, error) {
+				return jwtVerificationKey(r.Context(), cfg, token)
+			})
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				return unauthenticated("invalid bearer token", fmt.Errorf("jwt auth: %w", err))
+			}
+
+			if cfg.Authorize != nil {
+				if err := cfg.Authorize(r.Context(), &claims); err != nil {
+					return forbidden("insufficient permission", fmt.Errorf("jwt auth: authorize: %w", err))
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), jwtClaimsKey{}, &claims)
+			ctx = httpkit.WithLogAttrs(ctx, slog.String("user_id", claims.Subject))
+			return next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func jwtParserOptions(cfg JWTAuthConfig) []jwt.ParserOption {
+	opts := []jwt.ParserOption{
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+
+	// the algorithms of the KeySet are checked per key by its Keyfunc, since they change by the rotations.
+	algorithms := cfg.Algorithms
+	if len(algorithms) == 0 && cfg.KeySet == nil {
+		algorithms = []string{"HS256", "RS256", "EdDSA"}
+	}
+	if len(algorithms) > 0 {
+		opts = append(opts, jwt.WithValidMethods(algorithms))
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	return opts
+}
+
+// jwtVerificationKey selects the key for verifying the token signature by its algorithm.
+func jwtVerificationKey(ctx context.Context, cfg JWTAuthConfig, token *jwt.Token) (any, error) {
+	if cfg.KeySet != nil {
+		return cfg.KeySet.Keyfunc(token)
+	}
+
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(cfg.HMACSecret) == 0 {
+			return nil, errors.New("hmac secret is not configured")
+		}
+		return cfg.HMACSecret, nil
+	default:
+		if cfg.JWKS != nil {
+			kid, _ := token.Header["kid"].(string)
+			return cfg.JWKS.Key(ctx, kid)
+		}
+		if cfg.PublicKey == nil {
+			return nil, errors.New("public key is not configured")
+		}
+		return cfg.PublicKey, nil
+	}
+}
+
+// bearerToken gets the token from the Authorization header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// unauthenticated creates an error that is mapped to 401 Unauthorized.
+func unauthenticated(detail string, cause error) error {
+	pd := problemdetail.New(business.PDTypeUnauthenticated,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("Unauthenticated"),
+		problemdetail.WithDetail(detail),
+	)
+	return fmt.Errorf("%w: %w", pd, cause)
+}
+
+// forbidden creates an error that is mapped to 403 Forbidden.
+func forbidden(detail string, cause error) error {
+	pd := problemdetail.New(business.PDTypeForbidden,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("Forbidden"),
+		problemdetail.WithDetail(detail),
+	)
+	return fmt.Errorf("%w: %w", pd, cause)
+}
@@ -0,0 +1,212 @@
+package httpmiddleware
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/jwtkit"
+)
+
+var jwtSecret = []byte("secret")
+
+func signHS256(t *testing.T, claims JWTClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return token
+}
+
+func validClaims() JWTClaims {
+	return JWTClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user-1",
+			Issuer:    "swe-be-mono",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		Scope: "users:read users:write",
+	}
+}
+
+func serveJWTAuth(cfg JWTAuthConfig, authorization string) (*httptest.ResponseRecorder, *JWTClaims, error) {
+	var claims *JWTClaims
+	handler := JWTAuth(cfg).Then(httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		claims, _ = JWTClaimsFromContext(r.Context())
+		return nil
+	}))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	err := handler.ServeHTTP(rec, req)
+	if err != nil {
+		err = MapError(rec, err)
+	}
+	return rec, claims, err
+}
+
+func TestJWTAuth_HS256(t *testing.T) {
+	cfg := JWTAuthConfig{Algorithms: []string{"HS256"}, HMACSecret: jwtSecret, Issuer: "swe-be-mono"}
+
+	rec, claims, err := serveJWTAuth(cfg, "Bearer "+signHS256(t, validClaims()))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if rec.Code != http.StatusOK || claims == nil || claims.Subject != "user-1" || len(claims.Scopes()) != 2 {
+		t.Fatalf("expected authenticated request, got code=%d claims=%v", rec.Code, claims)
+	}
+
+	expired := validClaims()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	wrongIssuer := validClaims()
+	wrongIssuer.Issuer = "someone-else"
+
+	unauthorized := map[string]string{
+		"missing":      "",
+		"not bearer":   "Basic dXNlcjpwYXNz",
+		"malformed":    "Bearer abc",
+		"expired":      "Bearer " + signHS256(t, expired),
+		"wrong issuer": "Bearer " + signHS256(t, wrongIssuer),
+	}
+	for name, authorization := range unauthorized {
+		t.Run(name, func(t *testing.T) {
+			rec, claims, err := serveJWTAuth(cfg, authorization)
+			var resolvedErr *httpkit.ResolvedError
+			if !errors.As(err, &resolvedErr) {
+				t.Errorf("expected resolved error, got %v", err)
+			}
+			if rec.Code != http.StatusUnauthorized || claims != nil {
+				t.Errorf("expected 401, got %d", rec.Code)
+			}
+			if rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("expected WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestJWTAuth_AlgorithmNotAllowed(t *testing.T) {
+	cfg := JWTAuthConfig{Algorithms: []string{"EdDSA"}, HMACSecret: jwtSecret}
+	rec, _, _ := serveJWTAuth(cfg, "Bearer "+signHS256(t, validClaims()))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestJWTAuth_JWKS(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jwtkit.JWKSet{Keys: []jwtkit.JWK{{
+			Kty: "OKP",
+			Kid: "k1",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}}})
+	}))
+	t.Cleanup(srv.Close)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, validClaims())
+	token.Header["kid"] = "k1"
+	raw, err := token.SignedString(priv)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	cfg := JWTAuthConfig{JWKS: jwtkit.NewJWKS(srv.URL)}
+	rec, claims, err := serveJWTAuth(cfg, "Bearer "+raw)
+	if err != nil || rec.Code != http.StatusOK || claims == nil {
+		t.Fatalf("expected authenticated request, got code=%d err=%v", rec.Code, err)
+	}
+}
+
+func TestJWTAuth_KeySet(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	keys, err := jwtkit.NewKeySet(jwtkit.SigningKey{ID: "k1", Method: jwt.SigningMethodEdDSA, Key: priv})
+	if err != nil {
+		t.Fatalf("new key set: %v", err)
+	}
+
+	raw, err := keys.Sign(validClaims())
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	cfg := JWTAuthConfig{KeySet: keys, HMACSecret: jwtSecret}
+	rec, claims, err := serveJWTAuth(cfg, "Bearer "+raw)
+	if err != nil || rec.Code != http.StatusOK || claims == nil {
+		t.Fatalf("expected authenticated request, got code=%d err=%v", rec.Code, err)
+	}
+
+	// only the algorithms of the key set are accepted, the HMAC secret is ignored.
+	rec, _, _ = serveJWTAuth(cfg, "Bearer "+signHS256(t, validClaims()))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestJWTAuth_KeySetRotation(t *testing.T) {
+	keys, err := jwtkit.NewKeySet(jwtkit.SigningKey{ID: "k1", Method: jwt.SigningMethodHS256, Key: jwtSecret})
+	if err != nil {
+		t.Fatalf("new key set: %v", err)
+	}
+
+	handler := JWTAuth(JWTAuthConfig{KeySet: keys}).Then(httpkit.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) error { return nil },
+	))
+	serve := func(raw string) error {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+raw)
+		return handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// the key of another algorithm rotated in after the middleware is created is accepted.
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	if err := keys.Rotate(jwtkit.SigningKey{ID: "k2", Method: jwt.SigningMethodEdDSA, Key: priv}); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	raw, err := keys.Sign(validClaims())
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := serve(raw); err != nil {
+		t.Errorf("expected authenticated request, got %v", err)
+	}
+
+	// the token claiming another algorithm than its key is rejected.
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+	token.Header["kid"] = "k2"
+	raw, err = token.SignedString(jwtSecret)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := serve(raw); !errors.Is(err, jwtkit.ErrAlgorithmMismatch) {
+		t.Errorf("expected algorithm mismatch, got %v", err)
+	}
+}
+
+func TestJWTAuth_Authorize(t *testing.T) {
+	cfg := JWTAuthConfig{
+		HMACSecret: jwtSecret,
+		Authorize: func(ctx context.Context, claims *JWTClaims) error {
+			return errors.New("admin only")
+		},
+	}
+
+	rec, claims, _ := serveJWTAuth(cfg, "Bearer "+signHS256(t, validClaims()))
+	if rec.Code != http.StatusForbidden || claims != nil {
+		t.Errorf("expected 403, got %d", rec.Code)
+	}
+}
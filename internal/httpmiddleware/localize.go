@@ -0,0 +1,82 @@
+package httpmiddleware
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/pkg/i18nkit"
+)
+
+// localizedProblem translates the title and detail of the problem detail after the status is written, so the title of
+// untyped problems, which is derived from the status, is translated too.
+//
+// The title is looked up by the problem type, then by the title itself. The detail is looked up by the detail itself.
+// The catalog is resolved from the context, see i18nkit.Catalog.Middleware.
+type localizedProblem struct {
+	problemdetail.ProblemDetailer
+	ctx context.Context
+}
+
+// localize wraps the problem detail to be translated if the context carries a localizer.
+func localize(ctx context.Context, pd problemdetail.ProblemDetailer) problemdetail.ProblemDetailer {
+	if _, ok := i18nkit.LocalizerFromContext(ctx); !ok {
+		return pd
+	}
+	return &localizedProblem{ProblemDetailer: pd, ctx: ctx}
+}
+
+// WriteStatus writes the status then translates the title and the detail.
+func (p *localizedProblem) WriteStatus(code int) {
+	p.ProblemDetailer.WriteStatus(code)
+
+	base := baseProblem(p.ProblemDetailer)
+	if base == nil {
+		return
+	}
+
+	if title, ok := i18nkit.Lookup(p.ctx, base.Type); ok && base.Type != problemdetail.Untyped {
+		base.Title = title
+	} else {
+		base.Title = i18nkit.Translate(p.ctx, base.Title)
+	}
+
+	if base.Detail != "" {
+		base.Detail = i18nkit.Translate(p.ctx, base.Detail)
+	}
+}
+
+// MarshalJSON encodes the wrapped problem detail as it is.
+func (p *localizedProblem) MarshalJSON() ([]byte, error) { return json.Marshal(p.ProblemDetailer) }
+
+// MarshalXML encodes the wrapped problem detail as it is.
+func (p *localizedProblem) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return e.Encode(p.ProblemDetailer)
+}
+
+// baseProblemType is the type of the problem detail that extensions embed.
+var baseProblemType = reflect.TypeOf((*problemdetail.ProblemDetail)(nil))
+
+// baseProblem gets the *problemdetail.ProblemDetail of the problem detail, either itself or the embedded one of the
+// extensions, e.g. httpkit.ValidationProblem. If not found, it returns nil.
+func baseProblem(pd problemdetail.ProblemDetailer) *problemdetail.ProblemDetail {
+	if base, ok := pd.(*problemdetail.ProblemDetail); ok {
+		return base
+	}
+
+	v := reflect.ValueOf(pd)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	v = v.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.Anonymous && f.Type == baseProblemType && !v.Field(i).IsNil() {
+			return v.Field(i).Interface().(*problemdetail.ProblemDetail)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package httpmiddleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/i18nkit"
+	"golang.org/x/text/language"
+)
+
+func TestMapError_Localized(t *testing.T) {
+	catalog := i18nkit.NewCatalog(language.English)
+	catalog.Load(language.Indonesian, map[string]string{
+		business.PDTypeUserNotFound: "Pengguna Tidak Ditemukan",
+		"user does not exist":       "pengguna tidak ada",
+		"Internal Server Error":     "Kesalahan Server Internal",
+		"Validation Failed":         "Validasi Gagal",
+	})
+
+	tests := []struct {
+		name   string
+		err    error
+		title  string
+		detail string
+	}{
+		{
+			name: "business problem",
+			err: problemdetail.New(business.PDTypeUserNotFound,
+				problemdetail.WithValidateLevel(0),
+				problemdetail.WithTitle("User Not Found"),
+				problemdetail.WithDetail("user does not exist"),
+			),
+			title:  "Pengguna Tidak Ditemukan",
+			detail: "pengguna tidak ada",
+		},
+		{
+			name:   "validation problem",
+			err:    httpkit.NewValidationProblem(httpkit.FieldError{Field: "email", Code: "email"}),
+			title:  "Validasi Gagal",
+			detail: "1 field(s) are invalid",
+		},
+		{
+			name:  "untyped",
+			err:   errors.New("an error"),
+			title: "Kesalahan Server Internal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := catalog.Middleware().Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = mapError(r.Context(), w, tt.err)
+			}))
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Language", "id-ID,id;q=0.9")
+			h.ServeHTTP(rec, req)
+
+			var body struct {
+				Title  string          `json:"title"`
+				Detail string          `json:"detail"`
+				Errors json.RawMessage `json:"errors"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+
+			if body.Title != tt.title {
+				t.Errorf("want title %q, got %q", tt.title, body.Title)
+			}
+
+			if body.Detail != tt.detail {
+				t.Errorf("want detail %q, got %q", tt.detail, body.Detail)
+			}
+
+			if _, ok := tt.err.(*httpkit.ValidationProblem); ok && len(body.Errors) == 0 {
+				t.Errorf("expected the field errors are kept")
+			}
+		})
+	}
+}
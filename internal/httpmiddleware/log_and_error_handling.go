@@ -1,6 +1,7 @@
 package httpmiddleware
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -8,8 +9,8 @@ import (
 	"time"
 
 	"github.com/josestg/problemdetail"
-	"github.com/josestg/swe-be-mono/internal/business"
 	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/problemmap"
 )
 
 // LogAndErrHandling is a middleware that logs the request and response and
@@ -25,6 +26,8 @@ func LogAndErrHandling(log *slog.Logger) httpkit.MuxMiddleware {
 			err := next.ServeHTTP(w, r)
 			if err == nil {
 				log.LogAttrs(r.Context(), slog.LevelInfo, "completed",
+					slog.String("request_id", httpkit.RequestIDFromContext(r.Context())),
+					slog.String("client_ip", httpkit.ClientIP(r)),
 					slog.String("path", r.URL.Path),
 					slog.String("method", r.Method),
 					slog.String("uri", r.RequestURI),
@@ -34,10 +37,13 @@ func LogAndErrHandling(log *slog.Logger) httpkit.MuxMiddleware {
 				return nil
 			}
 
-			err = MapError(w, err)
+			err = mapError(r.Context(), w, err)
 			var resolvedErr *httpkit.ResolvedError
 			if !errors.As(err, &resolvedErr) {
+				httpkit.ReportError(r, err)
 				log.LogAttrs(r.Context(), slog.LevelError, "unresolved_error",
+					slog.String("request_id", httpkit.RequestIDFromContext(r.Context())),
+					slog.String("client_ip", httpkit.ClientIP(r)),
 					slog.String("path", r.URL.Path),
 					slog.String("method", r.Method),
 					slog.String("uri", r.RequestURI),
@@ -47,6 +53,8 @@ func LogAndErrHandling(log *slog.Logger) httpkit.MuxMiddleware {
 				)
 			} else {
 				log.LogAttrs(r.Context(), slog.LevelInfo, "resolved_error",
+					slog.String("request_id", httpkit.RequestIDFromContext(r.Context())),
+					slog.String("client_ip", httpkit.ClientIP(r)),
 					slog.String("path", r.URL.Path),
 					slog.String("method", r.Method),
 					slog.String("uri", r.RequestURI),
@@ -67,31 +75,75 @@ func LogAndErrHandling(log *slog.Logger) httpkit.MuxMiddleware {
 // MapError maps the error to an HTTP response and marks the error as resolved if
 // it is successfully mapped.
 func MapError(w http.ResponseWriter, err error) error {
+	return mapError(context.Background(), w, err)
+}
+
+// mapError is MapError that translates the problem details by the localizer in the context, see i18nkit.
+func mapError(ctx context.Context, w http.ResponseWriter, err error) error {
+	// the handler already replied to the client, e.g. a failed websocket upgrade.
+	var resolvedErr *httpkit.ResolvedError
+	if errors.As(err, &resolvedErr) {
+		return err
+	}
+
+	// errors that describe their own HTTP response.
+	var httpErr httpkit.HTTPError
+	if errors.As(err, &httpErr) {
+		pd := httpErr.Problem()
+		if pd == nil {
+			pd = untypedProblem()
+		}
+		return sendJSONError(ctx, w, httpErr.Status(), pd, err, true)
+	}
+
+	// errors raised by httpkit helpers.
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return sendJSONError(ctx, w, http.StatusRequestEntityTooLarge, untypedProblem(), err, true)
+	case errors.Is(err, httpkit.ErrNotAcceptable):
+		return sendJSONError(ctx, w, http.StatusNotAcceptable, untypedProblem(), err, true)
+	case errors.Is(err, httpkit.ErrMultipartFileTooLarge), errors.Is(err, httpkit.ErrMultipartValueTooLarge):
+		return sendJSONError(ctx, w, http.StatusRequestEntityTooLarge, untypedProblem(), err, true)
+	case errors.Is(err, httpkit.ErrMultipartMIMETypeNotAllowed),
+		errors.Is(err, http.ErrNotMultipart),
+		errors.Is(err, httpkit.ErrNotForm):
+		return sendJSONError(ctx, w, http.StatusUnsupportedMediaType, untypedProblem(), err, true)
+	case errors.Is(err, httpkit.ErrMultipartTooManyFiles):
+		return sendJSONError(ctx, w, http.StatusBadRequest, untypedProblem(), err, true)
+	case errors.Is(err, httpkit.ErrIdempotencyKeyInUse):
+		return sendJSONError(ctx, w, http.StatusConflict, untypedProblem(), err, true)
+	case errors.Is(err, httpkit.ErrIdempotencyKeyReused):
+		return sendJSONError(ctx, w, http.StatusUnprocessableEntity, untypedProblem(), err, true)
+	case errors.Is(err, httpkit.ErrOverloaded):
+		return sendJSONError(ctx, w, http.StatusServiceUnavailable, untypedProblem(), err, true)
+	}
+
 	var pd problemdetail.ProblemDetailer
 	if !errors.As(err, &pd) {
 		// untyped error for generic error handling.
-		untyped := problemdetail.New(
-			problemdetail.Untyped,
-			problemdetail.WithValidateLevel(problemdetail.LStandard),
-		)
-		return sendJSONError(w, http.StatusInternalServerError, untyped, err, false)
+		return sendJSONError(ctx, w, http.StatusInternalServerError, untypedProblem(), err, false)
 	}
 
-	switch pd.Kind() {
-	case business.PDTypeEmailAlreadyTaken:
-		return sendJSONError(w, http.StatusConflict, pd, err, true)
-	case business.PDTypeUserNotFound:
-		return sendJSONError(w, http.StatusNotFound, pd, err, true)
-	case business.PDTypeInvalidArguments:
-		return sendJSONError(w, http.StatusBadRequest, pd, err, true)
+	// the business types are registered by their packages, see problemmap.Register.
+	if status, ok := problemmap.Status(pd.Kind()); ok {
+		return sendJSONError(ctx, w, status, pd, err, true)
 	}
 
 	return fmt.Errorf("could not map error: %w", err)
 }
 
+// untypedProblem creates an untyped problem detail, the title is derived from the status code.
+func untypedProblem() *problemdetail.ProblemDetail {
+	return problemdetail.New(
+		problemdetail.Untyped,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+	)
+}
+
 // sendJSONError sends the error as a JSON response.
-func sendJSONError(w http.ResponseWriter, code int, data problemdetail.ProblemDetailer, err error, resolved bool) error {
-	wErr := problemdetail.WriteJSON(w, data, code)
+func sendJSONError(ctx context.Context, w http.ResponseWriter, code int, data problemdetail.ProblemDetailer, err error, resolved bool) error {
+	wErr := problemdetail.WriteJSON(w, localize(ctx, data), code)
 	if wErr != nil {
 		// if we fail to write the error to the response,
 		// we will encounter two errors: the error that needs to be handled
@@ -0,0 +1,149 @@
+package httpmiddleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+func TestMapError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		resolved bool
+	}{
+		{
+			name:     "business problem",
+			err:      problemdetail.New(business.PDTypeUserNotFound, problemdetail.WithValidateLevel(0)),
+			status:   http.StatusNotFound,
+			resolved: true,
+		},
+		{
+			name:     "validation problem",
+			err:      fmt.Errorf("%w: %w", httpkit.NewValidationProblem(), errors.New("invalid")),
+			status:   http.StatusUnprocessableEntity,
+			resolved: true,
+		},
+		{
+			name:     "invalid request problem",
+			err:      httpkit.NewInvalidRequestProblem(httpkit.FieldError{Field: "age", Code: "type"}),
+			status:   http.StatusBadRequest,
+			resolved: true,
+		},
+		{
+			name:     "unregistered problem",
+			err:      problemdetail.New("https://example.com/unregistered", problemdetail.WithValidateLevel(0)),
+			status:   http.StatusOK, // nothing is written.
+			resolved: false,
+		},
+		{
+			name:     "too many requests",
+			err:      tooManyRequests("slow down", errors.New("exceeded")),
+			status:   http.StatusTooManyRequests,
+			resolved: true,
+		},
+		{
+			name:     "not acceptable",
+			err:      fmt.Errorf("write: %w", httpkit.ErrNotAcceptable),
+			status:   http.StatusNotAcceptable,
+			resolved: true,
+		},
+		{
+			name:     "multipart file too large",
+			err:      fmt.Errorf("read: %w", httpkit.ErrMultipartFileTooLarge),
+			status:   http.StatusRequestEntityTooLarge,
+			resolved: true,
+		},
+		{
+			name:     "request body too large",
+			err:      fmt.Errorf("read json: %w", &http.MaxBytesError{Limit: 1}),
+			status:   http.StatusRequestEntityTooLarge,
+			resolved: true,
+		},
+		{
+			name:     "multipart mime type not allowed",
+			err:      fmt.Errorf("read: %w", httpkit.ErrMultipartMIMETypeNotAllowed),
+			status:   http.StatusUnsupportedMediaType,
+			resolved: true,
+		},
+		{
+			name:     "idempotency key reused",
+			err:      fmt.Errorf("begin: %w", httpkit.ErrIdempotencyKeyReused),
+			status:   http.StatusUnprocessableEntity,
+			resolved: true,
+		},
+		{
+			name:     "overloaded",
+			err:      fmt.Errorf("shed: %w", httpkit.ErrOverloaded),
+			status:   http.StatusServiceUnavailable,
+			resolved: true,
+		},
+		{
+			name:     "http error",
+			err:      fmt.Errorf("get: %w", paymentRequired{}),
+			status:   http.StatusPaymentRequired,
+			resolved: true,
+		},
+		{
+			name:     "http error takes precedence",
+			err:      fmt.Errorf("%w: %w", httpkit.ErrOverloaded, paymentRequired{}),
+			status:   http.StatusPaymentRequired,
+			resolved: true,
+		},
+		{
+			name:     "untyped",
+			err:      errors.New("an error"),
+			status:   http.StatusInternalServerError,
+			resolved: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			err := MapError(rec, tt.err)
+
+			var resolvedErr *httpkit.ResolvedError
+			if got := errors.As(err, &resolvedErr); got != tt.resolved {
+				t.Errorf("want resolved %v, got %v", tt.resolved, got)
+			}
+
+			if rec.Code != tt.status {
+				t.Errorf("want status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestMapError_AlreadyResolved(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := MapError(rec, httpkit.ResolveError(errors.New("an error")))
+
+	var resolvedErr *httpkit.ResolvedError
+	if !errors.As(err, &resolvedErr) {
+		t.Errorf("expected resolved error")
+	}
+
+	if rec.Body.Len() != 0 {
+		t.Errorf("expected nothing is written, got %q", rec.Body.String())
+	}
+}
+
+// paymentRequired is an httpkit.HTTPError for testing.
+type paymentRequired struct{}
+
+func (paymentRequired) Error() string { return "payment required" }
+func (paymentRequired) Status() int   { return http.StatusPaymentRequired }
+func (paymentRequired) Problem() problemdetail.ProblemDetailer {
+	return problemdetail.New("https://example.com/payment-required",
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("Payment Required"),
+	)
+}
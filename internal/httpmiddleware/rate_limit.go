@@ -0,0 +1,122 @@
+package httpmiddleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/internal/business"
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/ratekit"
+)
+
+// Set of response headers that describe the rate limit of the client.
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+)
+
+// RateLimitKeyFunc returns the key that identifies the client of the request.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// rateLimitKeyNamespace is an internal type for grouping the key functions.
+type rateLimitKeyNamespace int
+
+// RateLimitKeys is a namespace for accessing the key functions.
+const RateLimitKeys rateLimitKeyNamespace = 0
+
+// ClientIP identifies the client by its IP, see httpkit.ClientIP.
+func (rateLimitKeyNamespace) ClientIP() RateLimitKeyFunc {
+	return func(r *http.Request) string { return "ip:" + httpkit.ClientIP(r) }
+}
+
+// APIKey identifies the client by its API key ID, falling back to the client IP when the request is not
+// authenticated by APIKeyAuth.
+func (rateLimitKeyNamespace) APIKey() RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		if key, ok := APIKeyFromContext(r.Context()); ok {
+			return "key:" + key.ID
+		}
+		return RateLimitKeys.ClientIP()(r)
+	}
+}
+
+// Principal identifies the client by the authenticated principal, e.g. the user ID, falling back to the client IP
+// when the request is not authenticated.
+func (rateLimitKeyNamespace) Principal() RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		if p, ok := PrincipalFromContext(r.Context()); ok {
+			return "principal:" + p.ID
+		}
+		return RateLimitKeys.ClientIP()(r)
+	}
+}
+
+// RateLimitConfig is the configuration for RateLimit.
+type RateLimitConfig struct {
+	// Group is the name of the route group, every group has its own quota, e.g. "login" or "api".
+	Group string
+
+	// Limit is the quota of every client in the group.
+	Limit ratekit.Limit
+
+	// Store tracks the usage of the clients.
+	Store ratekit.Store
+
+	// Key identifies the client. Default RateLimitKeys.ClientIP().
+	Key RateLimitKeyFunc
+}
+
+// RateLimit is a middleware that limits the number of requests of every client in the route group.
+// The middleware can be applied globally or for specific routes, and it must be placed after the authentication
+// middleware when the client is identified by its credential.
+//
+// The rate limit headers are sent on every response, and when the limit is reached, the request is rejected with
+// business.PDTypeTooManyRequests (429) and the Retry-After header.
+func RateLimit(cfg RateLimitConfig) httpkit.MuxMiddleware {
+	if cfg.Key == nil {
+		cfg.Key = RateLimitKeys.ClientIP()
+	}
+
+	return func(next httpkit.Handler) httpkit.Handler {
+		return httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			key := cfg.Group + ":" + cfg.Key(r)
+			res, err := cfg.Store.Allow(r.Context(), key, cfg.Limit)
+			if err != nil {
+				return fmt.Errorf("rate limit: group %q: %w", cfg.Group, err)
+			}
+
+			h := w.Header()
+			h.Set(HeaderRateLimitLimit, strconv.Itoa(res.Limit))
+			h.Set(HeaderRateLimitRemaining, strconv.Itoa(res.Remaining))
+			h.Set(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(res.ResetAfter)))
+
+			if !res.Allowed {
+				retryAfter := ceilSeconds(res.RetryAfter)
+				h.Set("Retry-After", strconv.Itoa(retryAfter))
+				detail := fmt.Sprintf("rate limit exceeded, retry after %d seconds", retryAfter)
+				return tooManyRequests(detail, fmt.Errorf("rate limit: group %q: key %q exceeded", cfg.Group, key))
+			}
+			return next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ceilSeconds rounds up the duration to seconds, so the client never retries too early.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// tooManyRequests creates an error that is mapped to 429 Too Many Requests.
+func tooManyRequests(detail string, cause error) error {
+	pd := problemdetail.New(business.PDTypeTooManyRequests,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithTitle("Too Many Requests"),
+		problemdetail.WithDetail(detail),
+	)
+	return fmt.Errorf("%w: %w", pd, cause)
+}
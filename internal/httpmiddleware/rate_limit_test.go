@@ -0,0 +1,70 @@
+package httpmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"github.com/josestg/swe-be-mono/pkg/ratekit"
+)
+
+func TestRateLimit(t *testing.T) {
+	store := ratekit.NewMemoryStore()
+	mapErr := func(next httpkit.Handler) httpkit.Handler {
+		return httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			if err := next.ServeHTTP(w, r); err != nil {
+				return MapError(w, err)
+			}
+			return nil
+		})
+	}
+
+	noop := func(w http.ResponseWriter, r *http.Request) error { return nil }
+	mux := httpkit.NewServeMux(httpkit.Opts.Middleware(mapErr))
+	mux.Route(httpkit.Route{Method: http.MethodPost, Path: "/login", Handler: noop}, RateLimit(RateLimitConfig{
+		Group: "login",
+		Limit: ratekit.Limit{Rate: 2, Period: time.Minute},
+		Store: store,
+	}))
+	mux.Route(httpkit.Route{Method: http.MethodGet, Path: "/users", Handler: noop}, RateLimit(RateLimitConfig{
+		Group: "api",
+		Limit: ratekit.PerMinute(100),
+		Store: store,
+	}))
+
+	do := func(method, path, ip string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/login", "192.0.2.1")
+	if rec.Code != http.StatusOK || rec.Header().Get(HeaderRateLimitRemaining) != "1" {
+		t.Errorf("want 200 with 1 remaining, got %d with %q", rec.Code, rec.Header().Get(HeaderRateLimitRemaining))
+	}
+
+	_ = do(http.MethodPost, "/login", "192.0.2.1")
+	rec = do(http.MethodPost, "/login", "192.0.2.1")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("want status %d, got %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("want Retry-After 30, got %q", got)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/problem+json") {
+		t.Errorf("want problem detail, got %q", got)
+	}
+
+	// other groups and other clients have their own quota.
+	if rec = do(http.MethodGet, "/users", "192.0.2.1"); rec.Code != http.StatusOK {
+		t.Errorf("want status %d for other group, got %d", http.StatusOK, rec.Code)
+	}
+	if rec = do(http.MethodPost, "/login", "192.0.2.2"); rec.Code != http.StatusOK {
+		t.Errorf("want status %d for other client, got %d", http.StatusOK, rec.Code)
+	}
+}
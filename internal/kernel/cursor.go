@@ -0,0 +1,112 @@
+package kernel
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidCursor is returned by CursorCodec.Decode when the cursor is malformed or tampered with.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorConfig is the configuration of the CursorCodec, the services decoding the cursors of each other must share
+// the same secret.
+type CursorConfig struct {
+	Secret string `env:"SECRET,secret"` // The key for signing the cursors.
+}
+
+// CursorCodec encodes the sort keys of the keyset pagination into the opaque cursors, the base64 of the JSON of the
+// direction and the keys signed by HMAC-SHA256, so the clients can't tamper with them.
+type CursorCodec struct {
+	secret []byte
+}
+
+// NewCursorCodec creates a new CursorCodec, it returns an error if the secret is empty.
+func NewCursorCodec(cfg CursorConfig) (*CursorCodec, error) {
+	if cfg.Secret == "" {
+		return nil, errors.New("cursor secret is not set")
+	}
+	return &CursorCodec{secret: []byte(cfg.Secret)}, nil
+}
+
+// CursorDirection is the direction of the page a cursor points to from its sort keys.
+type CursorDirection string
+
+// The directions of the cursors, they are signed with the keys, so a cursor can't be replayed in the other one.
+const (
+	CursorNext CursorDirection = "next" // the items after the sort keys.
+	CursorPrev CursorDirection = "prev" // the items before the sort keys.
+)
+
+// cursorPayload is the signed content of a cursor.
+type cursorPayload struct {
+	Direction CursorDirection `json:"d"`
+	Keys      json.RawMessage `json:"k"`
+}
+
+// Encode encodes the direction and the sort keys, e.g. a struct of the created time and the ID of the last row.
+func (c *CursorCodec) Encode(dir CursorDirection, keys any) (string, error) {
+	k, err := json.Marshal(keys)
+	if err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+
+	payload, err := json.Marshal(cursorPayload{Direction: dir, Keys: k})
+	if err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(append(c.sign(payload), payload...)), nil
+}
+
+// Decode verifies the cursor, decodes the sort keys into dst, and returns the direction. It returns ErrInvalidCursor
+// if the cursor is malformed or its signature doesn't match.
+func (c *CursorCodec) Decode(cursor string, dst any) (CursorDirection, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(b) < sha256.Size {
+		return "", ErrInvalidCursor
+	}
+
+	sig, payload := b[:sha256.Size], b[sha256.Size:]
+	if !hmac.Equal(sig, c.sign(payload)) {
+		return "", ErrInvalidCursor
+	}
+
+	var p cursorPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	if p.Direction != CursorNext && p.Direction != CursorPrev {
+		return "", fmt.Errorf("%w: unknown direction %q", ErrInvalidCursor, p.Direction)
+	}
+	if err := json.Unmarshal(p.Keys, dst); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	return p.Direction, nil
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	h := hmac.New(sha256.New, c.secret)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// NextCursor returns the cursor of the sort keys of the last item, or an empty cursor if the page is not full, i.e.
+// it is the last page. The items are fetched with the limit of perPage.
+func NextCursor[T, K any](c *CursorCodec, items []T, perPage int, keysOf func(T) K) (string, error) {
+	if len(items) == 0 || len(items) < perPage {
+		return "", nil
+	}
+	return c.Encode(CursorNext, keysOf(items[len(items)-1]))
+}
+
+// PrevCursor returns the cursor of the sort keys of the first item for the page before, or an empty cursor if the
+// page is the first, i.e. the request has no cursor.
+func PrevCursor[T, K any](c *CursorCodec, items []T, hasCursor bool, keysOf func(T) K) (string, error) {
+	if len(items) == 0 || !hasCursor {
+		return "", nil
+	}
+	return c.Encode(CursorPrev, keysOf(items[0]))
+}
@@ -0,0 +1,114 @@
+package kernel
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+type testCursorKeys struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+}
+
+func newTestCursorCodec(t *testing.T, secret string) *CursorCodec {
+	t.Helper()
+	c, err := NewCursorCodec(CursorConfig{Secret: secret})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return c
+}
+
+func TestCursorCodec_RoundTrip(t *testing.T) {
+	c := newTestCursorCodec(t, "0123456789abcdef0123456789abcdef")
+	keys := testCursorKeys{CreatedAt: time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC), ID: "42"}
+
+	for _, dir := range []CursorDirection{CursorNext, CursorPrev} {
+		cursor, err := c.Encode(dir, keys)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		var got testCursorKeys
+		gotDir, err := c.Decode(cursor, &got)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if gotDir != dir || !got.CreatedAt.Equal(keys.CreatedAt) || got.ID != keys.ID {
+			t.Errorf("expected %s %+v, got %s %+v", dir, keys, gotDir, got)
+		}
+	}
+}
+
+func TestCursorCodec_Invalid(t *testing.T) {
+	c := newTestCursorCodec(t, "0123456789abcdef0123456789abcdef")
+	cursor, err := c.Encode(CursorNext, testCursorKeys{ID: "42"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	b, _ := base64.RawURLEncoding.DecodeString(cursor)
+	tamperedSig := append([]byte(nil), b...)
+	tamperedSig[0] ^= 1
+	tamperedPayload := append([]byte(nil), b...)
+	tamperedPayload[len(tamperedPayload)-3] ^= 1
+
+	other := newTestCursorCodec(t, "fedcba9876543210fedcba9876543210")
+	unknownDir, err := c.Encode("sideways", testCursorKeys{ID: "42"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		codec  *CursorCodec
+		cursor string
+	}{
+		{"not base64", c, "not base64!"},
+		{"too short", c, base64.RawURLEncoding.EncodeToString([]byte("short"))},
+		{"tampered signature", c, base64.RawURLEncoding.EncodeToString(tamperedSig)},
+		{"tampered payload", c, base64.RawURLEncoding.EncodeToString(tamperedPayload)},
+		{"wrong secret", other, cursor},
+		{"unknown direction", c, unknownDir},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys testCursorKeys
+			if _, err := tt.codec.Decode(tt.cursor, &keys); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("expected %v, got %v", ErrInvalidCursor, err)
+			}
+		})
+	}
+}
+
+func TestNextCursor_PrevCursor(t *testing.T) {
+	c := newTestCursorCodec(t, "0123456789abcdef0123456789abcdef")
+	items := []string{"a", "b", "c"}
+	keysOf := func(s string) testCursorKeys { return testCursorKeys{ID: s} }
+
+	next, err := NextCursor(c, items, len(items), keysOf)
+	if err != nil || next == "" {
+		t.Fatalf("expected the next cursor, got %q, %v", next, err)
+	}
+	var keys testCursorKeys
+	if dir, err := c.Decode(next, &keys); err != nil || dir != CursorNext || keys.ID != "c" {
+		t.Errorf("expected the next cursor of c, got %s %+v, %v", dir, keys, err)
+	}
+
+	prev, err := PrevCursor(c, items, true, keysOf)
+	if err != nil || prev == "" {
+		t.Fatalf("expected the previous cursor, got %q, %v", prev, err)
+	}
+	if dir, err := c.Decode(prev, &keys); err != nil || dir != CursorPrev || keys.ID != "a" {
+		t.Errorf("expected the previous cursor of a, got %s %+v, %v", dir, keys, err)
+	}
+
+	if next, _ := NextCursor(c, items, len(items)+1, keysOf); next != "" {
+		t.Errorf("expected no next cursor for the last page, got %q", next)
+	}
+	if prev, _ := PrevCursor(c, items, false, keysOf); prev != "" {
+		t.Errorf("expected no previous cursor for the first page, got %q", prev)
+	}
+}
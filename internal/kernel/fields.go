@@ -0,0 +1,148 @@
+package kernel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// FieldsParam is the query parameter of the partial response, the comma-separated fields of the data with the nested
+// fields separated by dots, e.g. ?fields=id,name,address.city.
+const FieldsParam = "fields"
+
+// RequestedFields returns the fields of the FieldsParam query parameter, or nil if all fields are requested.
+func RequestedFields(r *http.Request) []string {
+	var fields []string
+	for _, field := range strings.Split(r.URL.Query().Get(FieldsParam), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// SelectFields returns the copy of the response whose data only has the requested fields by their JSON names, see
+// FieldsParam. The fields of the objects in the arrays are selected per item, and the unknown fields are ignored.
+// A field selects its nested fields too, e.g. address,address.city is the whole address. If no field is requested,
+// the data is kept as it is.
+func SelectFields[T any](res HttpRes[T], fields []string) (HttpRes[any], error) {
+	data, err := selectFields(res.Data, fields)
+	if err != nil {
+		return HttpRes[any]{}, err
+	}
+
+	return HttpRes[any]{
+		Code:      res.Code,
+		Data:      data,
+		Desc:      res.Desc,
+		Time:      res.Time,
+		RequestID: res.RequestID,
+		Links:     res.Links,
+		Meta:      res.Meta,
+	}, nil
+}
+
+// SelectPageFields is like SelectFields for the items of the paginated response.
+func SelectPageFields[T any](res HttpPageRes[T], fields []string) (HttpPageRes[any], error) {
+	items := make([]any, 0, len(res.Items))
+	for _, item := range res.Items {
+		selected, err := selectFields(item, fields)
+		if err != nil {
+			return HttpPageRes[any]{}, err
+		}
+		items = append(items, selected)
+	}
+
+	return HttpPageRes[any]{
+		Code:       res.Code,
+		Items:      items,
+		Page:       res.Page,
+		PerPage:    res.PerPage,
+		Total:      res.Total,
+		NextCursor: res.NextCursor,
+		PrevCursor: res.PrevCursor,
+		Desc:       res.Desc,
+		Time:       res.Time,
+		RequestID:  res.RequestID,
+		Links:      res.Links,
+		Meta:       res.Meta,
+	}, nil
+}
+
+// fieldTree is the requested fields by their names, a leaf selects the whole value.
+type fieldTree map[string]fieldTree
+
+// selectFields converts the value to its generic JSON form, then drops the fields not in the tree.
+func selectFields(v any, fields []string) (any, error) {
+	if len(fields) == 0 {
+		return v, nil
+	}
+
+	tree := make(fieldTree)
+	for _, field := range fields {
+		tree.add(strings.Split(field, "."))
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("select fields: %w", err)
+	}
+
+	// the numbers are kept as they are, so the big integers don't lose the precision.
+	var generic any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return nil, fmt.Errorf("select fields: %w", err)
+	}
+	return tree.apply(generic), nil
+}
+
+// add adds the path of the field. A leaf wins over its descendants regardless of the order, e.g. address and
+// address.city select the whole address.
+func (t fieldTree) add(path []string) {
+	node := t
+	for i, name := range path {
+		if i == len(path)-1 {
+			node[name] = make(fieldTree)
+			return
+		}
+
+		child, ok := node[name]
+		if ok && len(child) == 0 {
+			return // the ancestor is selected whole already.
+		}
+		if !ok {
+			child = make(fieldTree)
+			node[name] = child
+		}
+		node = child
+	}
+}
+
+// apply drops the fields of the value not in the tree.
+func (t fieldTree) apply(v any) any {
+	if len(t) == 0 {
+		return v
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		selected := make(map[string]any, len(t))
+		for name, child := range t {
+			if value, ok := v[name]; ok {
+				selected[name] = child.apply(value)
+			}
+		}
+		return selected
+	case []any:
+		for i := range v {
+			v[i] = t.apply(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
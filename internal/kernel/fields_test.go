@@ -0,0 +1,80 @@
+package kernel
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSelectFields(t *testing.T) {
+	type address struct {
+		City    string `json:"city"`
+		Country string `json:"country"`
+	}
+	type person struct {
+		ID      int64     `json:"id"`
+		Name    string    `json:"name"`
+		Address address   `json:"address"`
+		Friends []address `json:"friends"`
+	}
+
+	data := person{
+		ID:      9007199254740993, // loses the precision as float64.
+		Name:    "alice",
+		Address: address{City: "Jakarta", Country: "ID"},
+		Friends: []address{{City: "Bandung", Country: "ID"}, {City: "Tokyo", Country: "JP"}},
+	}
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   string // the objects of the selected fields are marshaled with the sorted keys.
+	}{
+		{"no field", nil, `{"id":9007199254740993,"name":"alice","address":{"city":"Jakarta","country":"ID"},` +
+			`"friends":[{"city":"Bandung","country":"ID"},{"city":"Tokyo","country":"JP"}]}`},
+		{"top-level", []string{"name", "id"}, `{"id":9007199254740993,"name":"alice"}`},
+		{"nested", []string{"address.city"}, `{"address":{"city":"Jakarta"}}`},
+		{"array items", []string{"friends.country"}, `{"friends":[{"country":"ID"},{"country":"JP"}]}`},
+		{"unknown", []string{"email", "address.zip"}, `{"address":{}}`},
+		{"leaf before child", []string{"address", "address.city"}, `{"address":{"city":"Jakarta","country":"ID"}}`},
+		{"leaf after child", []string{"address.city", "address"}, `{"address":{"city":"Jakarta","country":"ID"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := SelectFields(HttpRes[person]{Code: 200, Data: data}, tt.fields)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			got, err := json.Marshal(res.Data)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+			if res.Code != 200 {
+				t.Errorf("expected the response is kept, got code %d", res.Code)
+			}
+		})
+	}
+}
+
+func TestRequestedFields(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", nil},
+		{"?fields=", nil},
+		{"?fields=id,%20name%20,,address.city", []string{"id", "name", "address.city"}},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/"+tt.query, nil)
+		if got := RequestedFields(r); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: expected %q, got %q", tt.query, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package kernel
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+// HttpPageRes is a base template for the paginated HTTP response of the list endpoints. It supports both the offset
+// pagination by Page and the keyset pagination by the cursors.
+// swagger:response kernel.HttpPageResp
+type HttpPageRes[T any] struct {
+	// Code is the http status code. The code must be in the range of 200-299.
+	// Default value is 200.
+	Code int `json:"code"`
+
+	// Items is the items of the page, it is never null.
+	Items []T `json:"items"`
+
+	// Page is the 1-based page number of the offset pagination.
+	// This field is omitted for the cursor pagination.
+	Page int `json:"page,omitempty"`
+
+	// PerPage is the maximum number of the items per page.
+	// Default value is the number of the items.
+	PerPage int `json:"per_page"`
+
+	// Total is the total number of the items of all pages.
+	// This field is omitted if the total is not counted, e.g. for the cursor pagination.
+	Total *int64 `json:"total,omitempty"`
+
+	// NextCursor is the cursor of the next page, empty if it is the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+
+	// PrevCursor is the cursor of the previous page, empty if it is the first page.
+	PrevCursor string `json:"prev_cursor,omitempty"`
+
+	// Desc is a short and human-readable description of the response.
+	// This field is optional.
+	Desc string `json:"desc,omitempty"`
+
+	// Time is the time in unix milliseconds that describes when the response is
+	// created.
+	// Default value is the current time.
+	Time int64 `json:"time"`
+
+	// RequestID is the ID of the request, for correlating the response with the logs, e.g. in the support tickets.
+	// This field is optional.
+	RequestID string `json:"request_id,omitempty"`
+
+	// Links are the hypermedia links by their relations, e.g. self, next and related, see LinkBuilder.
+	// This field is optional.
+	Links map[string]string `json:"links,omitempty"`
+
+	// Meta is the ancillary data of the response, e.g. the rate-limit state, the deprecation notices or the warnings.
+	// This field is optional.
+	Meta map[string]any `json:"meta,omitempty"`
+} //@name kernel.HttpPageResp
+
+// HttpPageResBuilder is a builder for HttpPageRes.
+type HttpPageResBuilder[T any] struct {
+	state HttpPageRes[T]
+}
+
+// NewHttpPageResBuilder creates a new HttpPageResBuilder with the given items and default
+// status code (200), per page (number of items) and time (current time).
+func NewHttpPageResBuilder[T any](items []T) *HttpPageResBuilder[T] {
+	if items == nil {
+		items = make([]T, 0)
+	}
+
+	return &HttpPageResBuilder[T]{
+		state: HttpPageRes[T]{
+			Code:    http.StatusOK,
+			Items:   items,
+			PerPage: len(items),
+			Time:    time.Now().UnixMilli(),
+		},
+	}
+}
+
+// NewHttpPageResBuilderCtx creates a new HttpPageResBuilder like NewHttpPageResBuilder, with the request ID from the
+// context, see httpkit.RequestID.
+func NewHttpPageResBuilderCtx[T any](ctx context.Context, items []T) *HttpPageResBuilder[T] {
+	return NewHttpPageResBuilder(items).RequestID(httpkit.RequestIDFromContext(ctx))
+}
+
+// Code sets the status code.
+func (b *HttpPageResBuilder[T]) Code(code int) *HttpPageResBuilder[T] {
+	b.state.Code = code
+	return b
+}
+
+// Page sets the page number and the page size of the offset pagination.
+func (b *HttpPageResBuilder[T]) Page(page, perPage int) *HttpPageResBuilder[T] {
+	b.state.Page = page
+	b.state.PerPage = perPage
+	return b
+}
+
+// PerPage sets the page size.
+func (b *HttpPageResBuilder[T]) PerPage(perPage int) *HttpPageResBuilder[T] {
+	b.state.PerPage = perPage
+	return b
+}
+
+// Total sets the total number of the items of all pages.
+func (b *HttpPageResBuilder[T]) Total(total int64) *HttpPageResBuilder[T] {
+	b.state.Total = &total
+	return b
+}
+
+// NextCursor sets the cursor of the next page.
+func (b *HttpPageResBuilder[T]) NextCursor(cursor string) *HttpPageResBuilder[T] {
+	b.state.NextCursor = cursor
+	return b
+}
+
+// PrevCursor sets the cursor of the previous page.
+func (b *HttpPageResBuilder[T]) PrevCursor(cursor string) *HttpPageResBuilder[T] {
+	b.state.PrevCursor = cursor
+	return b
+}
+
+// Desc sets the description.
+func (b *HttpPageResBuilder[T]) Desc(desc string) *HttpPageResBuilder[T] {
+	b.state.Desc = desc
+	return b
+}
+
+// Time sets the time.
+func (b *HttpPageResBuilder[T]) Time(epochMillis int64) *HttpPageResBuilder[T] {
+	b.state.Time = epochMillis
+	return b
+}
+
+// RequestID sets the request ID.
+func (b *HttpPageResBuilder[T]) RequestID(id string) *HttpPageResBuilder[T] {
+	b.state.RequestID = id
+	return b
+}
+
+// Links sets the hypermedia links, see LinkBuilder.
+func (b *HttpPageResBuilder[T]) Links(links map[string]string) *HttpPageResBuilder[T] {
+	b.state.Links = links
+	return b
+}
+
+// Meta sets the ancillary data, the entries are merged into the ones set before.
+func (b *HttpPageResBuilder[T]) Meta(meta map[string]any) *HttpPageResBuilder[T] {
+	if b.state.Meta == nil {
+		b.state.Meta = make(map[string]any, len(meta))
+	}
+	for k, v := range meta {
+		b.state.Meta[k] = v
+	}
+	return b
+}
+
+// Build returns the HttpPageRes that is built.
+func (b *HttpPageResBuilder[T]) Build() HttpPageRes[T] { return b.state }
@@ -1,8 +1,11 @@
 package kernel
 
 import (
+	"context"
 	"net/http"
 	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
 )
 
 // HttpRes is a base template for HTTP response.
@@ -24,6 +27,18 @@ type HttpRes[T any] struct {
 	// created.
 	// Default value is the current time.
 	Time int64 `json:"time"`
+
+	// RequestID is the ID of the request, for correlating the response with the logs, e.g. in the support tickets.
+	// This field is optional.
+	RequestID string `json:"request_id,omitempty"`
+
+	// Links are the hypermedia links by their relations, e.g. self, next and related, see LinkBuilder.
+	// This field is optional.
+	Links map[string]string `json:"links,omitempty"`
+
+	// Meta is the ancillary data of the response, e.g. the rate-limit state, the deprecation notices or the warnings.
+	// This field is optional.
+	Meta map[string]any `json:"meta,omitempty"`
 } //@name kernel.HttpResp
 
 // HttpResBuilder is a builder for HttpRes.
@@ -43,6 +58,12 @@ func NewHttpResBuilder[T any](data T) *HttpResBuilder[T] {
 	}
 }
 
+// NewHttpResBuilderCtx creates a new HttpResBuilder like NewHttpResBuilder, with the request ID from the context,
+// see httpkit.RequestID.
+func NewHttpResBuilderCtx[T any](ctx context.Context, data T) *HttpResBuilder[T] {
+	return NewHttpResBuilder(data).RequestID(httpkit.RequestIDFromContext(ctx))
+}
+
 // Code sets the status code.
 func (b *HttpResBuilder[T]) Code(code int) *HttpResBuilder[T] {
 	b.state.Code = code
@@ -61,5 +82,28 @@ func (b *HttpResBuilder[T]) Time(epochMillis int64) *HttpResBuilder[T] {
 	return b
 }
 
+// RequestID sets the request ID.
+func (b *HttpResBuilder[T]) RequestID(id string) *HttpResBuilder[T] {
+	b.state.RequestID = id
+	return b
+}
+
+// Links sets the hypermedia links, see LinkBuilder.
+func (b *HttpResBuilder[T]) Links(links map[string]string) *HttpResBuilder[T] {
+	b.state.Links = links
+	return b
+}
+
+// Meta sets the ancillary data, the entries are merged into the ones set before.
+func (b *HttpResBuilder[T]) Meta(meta map[string]any) *HttpResBuilder[T] {
+	if b.state.Meta == nil {
+		b.state.Meta = make(map[string]any, len(meta))
+	}
+	for k, v := range meta {
+		b.state.Meta[k] = v
+	}
+	return b
+}
+
 // Build returns the HttpRes that is built.
 func (b *HttpResBuilder[T]) Build() HttpRes[T] { return b.state }
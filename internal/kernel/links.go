@@ -0,0 +1,80 @@
+package kernel
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+// Set of the common link relations of the responses.
+const (
+	LinkSelf    = "self"
+	LinkNext    = "next"
+	LinkPrev    = "prev"
+	LinkRelated = "related"
+)
+
+// Reverser builds the path of a named route, e.g. httpkit.ServeMux.URL.
+type Reverser interface {
+	URL(name string, params ...string) (string, error)
+}
+
+var _ Reverser = (*httpkit.ServeMux)(nil)
+
+// LinkBuilder is a builder for the hypermedia links of the responses. The links of the named routes are built by the
+// reverse routing of the mux, prefixed by the base path where the mux is mounted, e.g. /enduser/api/v1 is not
+// included in the routes of the mux. The errors are collected and returned by Build.
+type LinkBuilder struct {
+	base  string
+	mux   Reverser
+	links map[string]string
+	errs  []error
+}
+
+// NewLinkBuilder creates a new LinkBuilder of the mux mounted at the base path.
+func NewLinkBuilder(base string, mux Reverser) *LinkBuilder {
+	return &LinkBuilder{base: base, mux: mux, links: make(map[string]string)}
+}
+
+// Self sets the self link to the URI of the request as sent by the client, including the base path and the query.
+func (b *LinkBuilder) Self(r *http.Request) *LinkBuilder {
+	return b.Href(LinkSelf, r.RequestURI)
+}
+
+// Route sets the link of the relation to the named route with the path parameters in pairs of the name and the
+// value, see httpkit.ServeMux.URL.
+func (b *LinkBuilder) Route(rel, name string, params ...string) *LinkBuilder {
+	return b.RouteQuery(rel, nil, name, params...)
+}
+
+// RouteQuery is like Route with the query, e.g. the cursor of the next page.
+func (b *LinkBuilder) RouteQuery(rel string, query url.Values, name string, params ...string) *LinkBuilder {
+	path, err := b.mux.URL(name, params...)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("link %s: %w", rel, err))
+		return b
+	}
+
+	href := b.base + path
+	if len(query) > 0 {
+		href += "?" + query.Encode()
+	}
+	return b.Href(rel, href)
+}
+
+// Href sets the link of the relation as it is.
+func (b *LinkBuilder) Href(rel, href string) *LinkBuilder {
+	b.links[rel] = href
+	return b
+}
+
+// Build returns the links by their relations, or the errors of building them.
+func (b *LinkBuilder) Build() (map[string]string, error) {
+	if err := errors.Join(b.errs...); err != nil {
+		return nil, err
+	}
+	return b.links, nil
+}
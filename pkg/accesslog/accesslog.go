@@ -0,0 +1,147 @@
+// Package accesslog provides an access log writer fed by the httpkit.LogEntry, decoupled from the application logs.
+// The records are formatted as JSON, Apache combined or a custom template, and written to any io.Writer with
+// buffering, e.g. a RotatingFile.
+package accesslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Set of supported formats.
+const (
+	FormatJSON     = "json"     // one JSON object per line.
+	FormatCombined = "combined" // the Apache combined log format.
+	FormatTemplate = "template" // a custom text/template of the Record.
+)
+
+// Record is an access log record of a request.
+type Record struct {
+	Time      time.Time     // the time the request is received.
+	RequestID string        // the request ID, see httpkit.RequestID.
+	ClientIP  string        // the client IP, see httpkit.ResolveClientIP.
+	User      string        // the user of the URL userinfo, if any.
+	Method    string        // the request method.
+	URI       string        // the unmodified request URI.
+	Proto     string        // the request protocol, e.g. HTTP/1.1.
+	Status    int           // the response status code.
+	Bytes     int64         // the number of response body bytes.
+	Latency   time.Duration // the time taken to serve the request.
+	Referer   string        // the Referer request header.
+	UserAgent string        // the User-Agent request header.
+}
+
+// Formatter knows how to format a Record as a single line.
+type Formatter interface {
+	// Format writes the record followed by a newline to w.
+	Format(w io.Writer, r Record) error
+}
+
+// FormatterFunc is a function that implements Formatter.
+type FormatterFunc func(w io.Writer, r Record) error
+
+// Format implements Formatter.
+func (f FormatterFunc) Format(w io.Writer, r Record) error { return f(w, r) }
+
+// JSON formats the records as JSON objects.
+var JSON Formatter = FormatterFunc(func(w io.Writer, r Record) error {
+	return json.NewEncoder(w).Encode(struct {
+		Time      string  `json:"time"`
+		RequestID string  `json:"request_id,omitempty"`
+		ClientIP  string  `json:"client_ip"`
+		User      string  `json:"user,omitempty"`
+		Method    string  `json:"method"`
+		URI       string  `json:"uri"`
+		Proto     string  `json:"proto"`
+		Status    int     `json:"status"`
+		Bytes     int64   `json:"bytes"`
+		LatencyMS float64 `json:"latency_ms"`
+		Referer   string  `json:"referer,omitempty"`
+		UserAgent string  `json:"user_agent,omitempty"`
+	}{
+		Time:      r.Time.Format(time.RFC3339Nano),
+		RequestID: r.RequestID,
+		ClientIP:  r.ClientIP,
+		User:      r.User,
+		Method:    r.Method,
+		URI:       r.URI,
+		Proto:     r.Proto,
+		Status:    r.Status,
+		Bytes:     r.Bytes,
+		LatencyMS: float64(r.Latency) / float64(time.Millisecond),
+		Referer:   r.Referer,
+		UserAgent: r.UserAgent,
+	})
+})
+
+// Combined formats the records in the Apache combined log format:
+//
+//	%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-agent}i"
+var Combined Formatter = FormatterFunc(func(w io.Writer, r Record) error {
+	bytes := "-"
+	if r.Bytes > 0 {
+		bytes = strconv.FormatInt(r.Bytes, 10)
+	}
+
+	_, err := fmt.Fprintf(w, "%s - %s [%s] \"%s %s %s\" %d %s %s %s\n",
+		orDash(r.ClientIP),
+		orDash(r.User),
+		r.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method, escape(r.URI), r.Proto,
+		r.Status,
+		bytes,
+		strconv.Quote(r.Referer),
+		strconv.Quote(r.UserAgent),
+	)
+	return err
+})
+
+// NewTemplate creates a Formatter that executes the text/template with the Record as the data, a newline is appended
+// when the output does not end with one. For example:
+//
+//	{{.Time.Format "2006-01-02T15:04:05Z07:00"}} {{.Method}} {{.URI}} {{.Status}} {{.Latency}}
+func NewTemplate(text string) (Formatter, error) {
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	tmpl, err := template.New("accesslog").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("accesslog: parse template: %w", err)
+	}
+
+	return FormatterFunc(func(w io.Writer, r Record) error { return tmpl.Execute(w, r) }), nil
+}
+
+// NewFormatter creates the Formatter by its format name, the text is the template of FormatTemplate.
+func NewFormatter(format, text string) (Formatter, error) {
+	switch format {
+	case FormatJSON, "":
+		return JSON, nil
+	case FormatCombined:
+		return Combined, nil
+	case FormatTemplate:
+		return NewTemplate(text)
+	default:
+		return nil, fmt.Errorf("accesslog: unknown format: %q", format)
+	}
+}
+
+// orDash returns the value or a dash if it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// escape escapes the double quotes and the control characters, so the value can't break the log line.
+func escape(s string) string {
+	q := strconv.Quote(s)
+	return q[1 : len(q)-1]
+}
@@ -0,0 +1,123 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+func testRecord() Record {
+	return Record{
+		Time:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		RequestID: "req-1",
+		ClientIP:  "10.0.0.1",
+		Method:    http.MethodGet,
+		URI:       `/users?q="x"`,
+		Proto:     "HTTP/1.1",
+		Status:    http.StatusOK,
+		Bytes:     42,
+		Latency:   1500 * time.Microsecond,
+		Referer:   "https://example.com",
+		UserAgent: "curl/8.0",
+	}
+}
+
+func TestFormatters(t *testing.T) {
+	tmpl, err := NewTemplate(`{{.Method}} {{.URI}} {{.Status}}`)
+	expectTrue(t, err == nil)
+
+	tests := []struct {
+		name   string
+		format Formatter
+		want   string
+	}{
+		{
+			name:   "combined",
+			format: Combined,
+			want:   `10.0.0.1 - - [02/Jan/2024:03:04:05 +0000] "GET /users?q=\"x\" HTTP/1.1" 200 42 "https://example.com" "curl/8.0"` + "\n",
+		},
+		{
+			name:   "template",
+			format: tmpl,
+			want:   `GET /users?q="x" 200` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			expectTrue(t, tt.format.Format(&buf, testRecord()) == nil)
+			if buf.String() != tt.want {
+				t.Errorf("want %q, got %q", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	expectTrue(t, JSON.Format(&buf, testRecord()) == nil)
+
+	var got map[string]any
+	expectTrue(t, json.Unmarshal(buf.Bytes(), &got) == nil)
+	expectTrue(t, got["request_id"] == "req-1")
+	expectTrue(t, got["status"] == float64(200))
+	expectTrue(t, got["latency_ms"] == 1.5)
+	expectTrue(t, got["time"] == "2024-01-02T03:04:05Z")
+}
+
+func TestNewFormatter_Unknown(t *testing.T) {
+	_, err := NewFormatter("xml", "")
+	expectTrue(t, err != nil)
+
+	_, err = NewFormatter(FormatTemplate, "{{.Unclosed")
+	expectTrue(t, err != nil)
+}
+
+func TestWriter_Buffered(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, Combined, 1<<10, time.Hour)
+
+	expectTrue(t, w.Write(testRecord()) == nil)
+	expectTrue(t, out.Len() == 0)
+
+	expectTrue(t, w.Close() == nil)
+	expectTrue(t, strings.Count(out.String(), "\n") == 1)
+}
+
+func TestWriter_Middleware(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, JSON, 0, 0)
+
+	mid := httpkit.ReduceNetMiddleware(httpkit.LogEntryRecorder, w.Middleware(nil))
+	h := mid.Then(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(rw, "created")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/users", nil)
+	req.Header.Set("User-Agent", "test")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var got map[string]any
+	expectTrue(t, json.Unmarshal(out.Bytes(), &got) == nil)
+	expectTrue(t, got["method"] == http.MethodPost)
+	expectTrue(t, got["uri"] == "/users")
+	expectTrue(t, got["status"] == float64(http.StatusCreated))
+	expectTrue(t, got["bytes"] == float64(len("created")))
+	expectTrue(t, got["user_agent"] == "test")
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
@@ -0,0 +1,166 @@
+package accesslog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeLayout is the time layout of the rotated file suffix, it sorts chronologically.
+const backupTimeLayout = "20060102T150405.000"
+
+// RotatingFile is a file that is rotated when its size exceeds the maximum size. The rotated files are renamed with
+// the rotation time, e.g. access.log.20060102T150405.000, and the oldest ones are removed to keep the maximum
+// number of backups. RotatingFile is concurrent-safe.
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+
+	now func() time.Time
+}
+
+// OpenRotatingFile opens or creates the file for appending. If maxSize is zero or negative, the file is never
+// rotated. If maxBackups is zero or negative, all rotated files are kept.
+func OpenRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	f := RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		now:        time.Now,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Write implements io.Writer, the file is rotated before writing if p does not fit into the current file. A write
+// larger than the maximum size goes to a new file as a whole.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file regardless of its size, e.g. on SIGHUP.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Close closes the current file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("accesslog: create dir: %w", err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("accesslog: open file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Join(fmt.Errorf("accesslog: stat file: %w", err), file.Close())
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("accesslog: close file: %w", err)
+	}
+	f.file = nil
+
+	backup := f.path + "." + f.now().Format(backupTimeLayout)
+	if err := os.Rename(f.path, backup); err != nil {
+		return fmt.Errorf("accesslog: rename file: %w", err)
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+	return f.prune()
+}
+
+// prune removes the oldest rotated files to keep the maximum number of backups.
+func (f *RotatingFile) prune() error {
+	if f.maxBackups <= 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return fmt.Errorf("accesslog: list backups: %w", err)
+	}
+
+	prefix := f.path + "."
+	backups := matches[:0]
+	for _, m := range matches {
+		if _, err := time.Parse(backupTimeLayout, strings.TrimPrefix(m, prefix)); err == nil {
+			backups = append(backups, m)
+		}
+	}
+
+	if len(backups) <= f.maxBackups {
+		return nil
+	}
+
+	sort.Strings(backups)
+
+	var errs []error
+	for _, b := range backups[:len(backups)-f.maxBackups] {
+		if err := os.Remove(b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("accesslog: remove backups: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package accesslog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "access.log")
+	f, err := OpenRotatingFile(path, 10, 2)
+	expectTrue(t, err == nil)
+	defer func() { _ = f.Close() }()
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	f.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		_, err := f.Write([]byte(line))
+		expectTrue(t, err == nil)
+	}
+
+	current, err := os.ReadFile(path)
+	expectTrue(t, err == nil)
+	expectTrue(t, string(current) == "dddddddd\n")
+
+	backups, err := filepath.Glob(path + ".*")
+	expectTrue(t, err == nil)
+	expectTrue(t, len(backups) == 2)
+
+	oldest, err := os.ReadFile(backups[0])
+	expectTrue(t, err == nil)
+	expectTrue(t, string(oldest) == "bbbbbbbb\n")
+}
+
+func TestRotatingFile_Closed(t *testing.T) {
+	f, err := OpenRotatingFile(filepath.Join(t.TempDir(), "access.log"), 0, 0)
+	expectTrue(t, err == nil)
+	expectTrue(t, f.Close() == nil)
+
+	_, err = f.Write([]byte("x"))
+	expectTrue(t, err != nil)
+	expectTrue(t, f.Rotate() != nil)
+}
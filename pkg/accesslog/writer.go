@@ -0,0 +1,211 @@
+package accesslog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+// Config is the configuration for opening an access log Writer.
+type Config struct {
+	Enabled       bool          `env:"ENABLED"`                    // Enable the access log, when disabled the requests are not logged.
+	Format        string        `env:"FORMAT,default=json"`        // One of FormatJSON, FormatCombined or FormatTemplate, default is FormatJSON.
+	Template      string        `env:"TEMPLATE"`                   // The text/template of FormatTemplate, see NewTemplate.
+	Output        string        `env:"OUTPUT,default=stdout"`      // Either stdout, stderr or a file path, default is stdout.
+	BufferSize    int           `env:"BUFFER_SIZE"`                // The size of the write buffer in bytes, default is 64 KiB.
+	FlushInterval time.Duration `env:"FLUSH_INTERVAL,default=1s"`  // The interval of flushing the buffer, default is 1s.
+	MaxSize       int64         `env:"MAX_SIZE,default=104857600"` // The size in bytes of the output file to be rotated, zero means never rotated.
+	MaxBackups    int           `env:"MAX_BACKUPS,default=7"`      // The number of the rotated files to keep, zero means all are kept.
+}
+
+func (c Config) withDefaults() Config {
+	if c.Output == "" {
+		c.Output = "stdout"
+	}
+	if c.BufferSize <= 0 {
+		c.BufferSize = 64 << 10
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = time.Second
+	}
+	return c
+}
+
+// Open opens the output and creates a Writer by the configuration, the file output is rotated by the MaxSize.
+// The Writer must be closed to flush the remaining records.
+func Open(cfg Config) (*Writer, error) {
+	cfg = cfg.withDefaults()
+
+	format, err := NewFormatter(cfg.Format, cfg.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	var out io.Writer
+	switch cfg.Output {
+	case "stdout":
+		out = os.Stdout
+	case "stderr":
+		out = os.Stderr
+	default:
+		f, err := OpenRotatingFile(cfg.Output, cfg.MaxSize, cfg.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		out = f
+	}
+
+	return NewWriter(out, format, cfg.BufferSize, cfg.FlushInterval), nil
+}
+
+// Writer writes the access log records to the output with buffering. Writer is concurrent-safe.
+type Writer struct {
+	mu     sync.Mutex
+	out    io.Writer
+	buf    *bufio.Writer
+	line   bytes.Buffer
+	format Formatter
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewWriter creates a Writer that buffers up to the size bytes and flushes them every interval. If the size is zero
+// or negative, the records are written immediately.
+func NewWriter(out io.Writer, format Formatter, size int, interval time.Duration) *Writer {
+	w := Writer{
+		out:    out,
+		format: format,
+		done:   make(chan struct{}),
+	}
+
+	if size > 0 {
+		w.buf = bufio.NewWriterSize(out, size)
+		go w.flushEvery(interval)
+	}
+	return &w
+}
+
+// Write formats and writes the record. A record is never split across the flushes.
+func (w *Writer) Write(r Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.line.Reset()
+	if err := w.format.Format(&w.line, r); err != nil {
+		return fmt.Errorf("accesslog: format: %w", err)
+	}
+
+	var err error
+	if w.buf == nil {
+		_, err = w.out.Write(w.line.Bytes())
+	} else {
+		if w.buf.Available() < w.line.Len() {
+			err = w.buf.Flush()
+		}
+		if err == nil {
+			_, err = w.buf.Write(w.line.Bytes())
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("accesslog: write: %w", err)
+	}
+	return nil
+}
+
+// Flush writes the buffered records to the output.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buf == nil {
+		return nil
+	}
+	return w.buf.Flush()
+}
+
+// Close flushes the buffered records and closes the output if it is an io.Closer, except for stdout and stderr.
+func (w *Writer) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		err = w.Flush()
+		if c, ok := w.out.(io.Closer); ok && w.out != os.Stdout && w.out != os.Stderr {
+			err = errors.Join(err, c.Close())
+		}
+	})
+	return err
+}
+
+func (w *Writer) flushEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			_ = w.Flush()
+		}
+	}
+}
+
+// Middleware is a middleware that writes an access log record for every request after it is served. The record is
+// built from the httpkit.LogEntry, so it must be placed after the httpkit.LogEntryRecorder. The write errors are
+// reported to the errFn, if any, as the response has been sent.
+func (w *Writer) Middleware(errFn func(error)) httpkit.NetMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(rw, r)
+
+			entry, ok := httpkit.GetLogEntry(rw)
+			if !ok {
+				return
+			}
+
+			if err := w.Write(newRecord(r, entry)); err != nil && errFn != nil {
+				errFn(err)
+			}
+		})
+	}
+}
+
+// newRecord builds the record of the served request.
+func newRecord(r *http.Request, entry *httpkit.LogEntry) Record {
+	status := entry.StatusCode
+	if status == 0 {
+		// nothing is written, the server replies with 200 OK.
+		status = http.StatusOK
+	}
+
+	var user string
+	if r.URL.User != nil {
+		user = r.URL.User.Username()
+	}
+
+	requestedAt := time.Unix(0, entry.RequestedAt)
+	return Record{
+		Time:      requestedAt,
+		RequestID: httpkit.RequestIDFromContext(r.Context()),
+		ClientIP:  httpkit.ClientIP(r),
+		User:      user,
+		Method:    r.Method,
+		URI:       r.RequestURI,
+		Proto:     r.Proto,
+		Status:    status,
+		Bytes:     entry.BytesWritten,
+		Latency:   time.Since(requestedAt),
+		Referer:   r.Referer(),
+		UserAgent: r.UserAgent(),
+	}
+}
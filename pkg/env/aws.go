@@ -0,0 +1,154 @@
+package env
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SchemeAWSSecretsManager is the scheme of the AWS Secrets Manager references, see AWSSecretsManagerProvider.
+const SchemeAWSSecretsManager = "awssm"
+
+// AWSConfig is the configuration of AWSSecretsManagerProvider, it is loaded by the AWS_* variables of the AWS CLI.
+// Only the static credentials are supported, the credential chain of the AWS SDK, e.g. the shared config files, the
+// web identity, the ECS task role or the EC2 instance profile, is not. Use the temporary credentials with
+// SessionToken to rely on an assumed role.
+type AWSConfig struct {
+	Region          string       `env:"REGION"`                   // The region of the secrets, e.g. ap-southeast-1.
+	AccessKeyID     string       `env:"ACCESS_KEY_ID"`            // The access key of the credentials.
+	SecretAccessKey string       `env:"SECRET_ACCESS_KEY,secret"` // The secret key of the credentials.
+	SessionToken    string       `env:"SESSION_TOKEN,secret"`     // The session token of the temporary credentials.
+	Endpoint        string       `env:"ENDPOINT_URL"`             // Overrides the regional endpoint, e.g. for LocalStack.
+	Client          *http.Client // The HTTP client, default is http.DefaultClient.
+}
+
+func (c AWSConfig) withDefaults() AWSConfig {
+	if c.Client == nil {
+		c.Client = http.DefaultClient
+	}
+	if c.Endpoint == "" {
+		c.Endpoint = "https://secretsmanager." + c.Region + ".amazonaws.com"
+	}
+	c.Endpoint = strings.TrimSuffix(c.Endpoint, "/")
+	return c
+}
+
+// AWSSecretsManagerProvider resolves the secrets of the AWS Secrets Manager. The reference is the name or the ARN of
+// the secret, optionally followed by the field of the JSON secret, e.g. awssm://prod/db#password.
+type AWSSecretsManagerProvider struct {
+	cfg AWSConfig
+	now func() time.Time
+}
+
+// NewAWSSecretsManagerProvider creates a new AWSSecretsManagerProvider.
+func NewAWSSecretsManagerProvider(cfg AWSConfig) *AWSSecretsManagerProvider {
+	return &AWSSecretsManagerProvider{cfg: cfg.withDefaults(), now: time.Now}
+}
+
+// Resolve implements Provider.
+func (p *AWSSecretsManagerProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	id, field := splitRef(ref)
+	if p.cfg.Region == "" || p.cfg.AccessKeyID == "" || p.cfg.SecretAccessKey == "" {
+		return "", errors.New("awssm: region or static credentials are not configured")
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": id})
+	if err != nil {
+		return "", fmt.Errorf("awssm: encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.Endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("awssm: create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if p.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", p.cfg.SessionToken)
+	}
+	signV4(req, body, p.cfg, "secretsmanager", p.now())
+
+	var res struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := doJSON(p.cfg.Client, req, &res); err != nil {
+		return "", fmt.Errorf("awssm: get %s: %w", id, err)
+	}
+
+	secret, err := secretField(res.SecretString, field)
+	if err != nil {
+		return "", fmt.Errorf("awssm: %s: %w", id, err)
+	}
+	return secret, nil
+}
+
+// signV4 signs the request by the AWS Signature Version 4, all headers of the request and the host are signed.
+// see: https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html.
+func signV4(req *http.Request, body []byte, cfg AWSConfig, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hexSHA256(body),
+	}, "\n")
+
+	scope := date + "/" + cfg.Region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, cfg.Region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hexSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
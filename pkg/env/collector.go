@@ -0,0 +1,81 @@
+package env
+
+import (
+	"errors"
+	"time"
+)
+
+// Collector reads the environment variables like the package functions, but it gathers the parse errors instead of
+// panicking, so all malformed variables are reported together by Err at once. The zero value is ready to use.
+//
+//	var c env.Collector
+//	port := c.Int("HTTP_SERVER_PORT", 8080)
+//	timeout := c.Duration("HTTP_SERVER_SHUTDOWN_TIMEOUT", 5*time.Second)
+//	if err := c.Err(); err != nil {
+//		return err
+//	}
+type Collector struct {
+	errs []error
+}
+
+// Collect is like ParseE, the error is gathered into the collector.
+func Collect[T any](c *Collector, key string, parser Parser[T], fallback T) T {
+	v, err := ParseE(key, parser, fallback)
+	c.Add(err)
+	return v
+}
+
+// CollectList is like ListE, the error is gathered into the collector.
+func CollectList[T any](c *Collector, key string, parser Parser[T], fallback []T) []T {
+	v, err := ListE(key, parser, fallback)
+	c.Add(err)
+	return v
+}
+
+// Add gathers the error into the collector, nil is ignored.
+func (c *Collector) Add(err error) {
+	if err != nil {
+		c.errs = append(c.errs, err)
+	}
+}
+
+// Err returns the gathered errors joined, nil if there is none.
+func (c *Collector) Err() error { return errors.Join(c.errs...) }
+
+// String is like String, the error of reading the FileSuffix file is gathered into the collector.
+func (c *Collector) String(key, fallback string) string {
+	return Collect(c, key, Parsers.Identity(), fallback)
+}
+
+// Int is like IntE, the error is gathered into the collector.
+func (c *Collector) Int(key string, fallback int) int {
+	return Collect(c, key, Parsers.Int(), fallback)
+}
+
+// Int64 is like Int64E, the error is gathered into the collector.
+func (c *Collector) Int64(key string, fallback int64) int64 {
+	return Collect(c, key, Parsers.Int64(), fallback)
+}
+
+// Float64 is like Float64E, the error is gathered into the collector.
+func (c *Collector) Float64(key string, fallback float64) float64 {
+	return Collect(c, key, Parsers.Float64(), fallback)
+}
+
+// Duration is like DurationE, the error is gathered into the collector.
+func (c *Collector) Duration(key string, fallback time.Duration) time.Duration {
+	return Collect(c, key, Parsers.Duration(), fallback)
+}
+
+// Bool is like BoolE, the error is gathered into the collector.
+func (c *Collector) Bool(key string, fallback bool) bool {
+	return Collect(c, key, Parsers.Bool(), fallback)
+}
+
+// StringList reads the variable like the package function StringList, it never fails.
+func (c *Collector) StringList(key string, fallback []string) []string {
+	return StringList(key, fallback)
+}
+
+// Load fills dst like the package function Load, the errors are gathered into the collector.
+func (c *Collector) Load(dst any, opts ...LoadOption) { c.Add(Load(dst, opts...)) }
@@ -0,0 +1,72 @@
+package env
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseE(t *testing.T) {
+	const key = "TESTING_ENV_PARSE_E"
+
+	if got, err := IntE(key, 1); got != 1 || err != nil {
+		t.Errorf("expected using the initial value without error")
+	}
+
+	t.Setenv(key, "2")
+	if got, err := IntE(key, 1); got != 2 || err != nil {
+		t.Errorf("expected using the env value without error")
+	}
+
+	t.Setenv(key, "INVALID NUMBER FORMAT")
+	got, err := IntE(key, 1)
+	if got != 1 || !errors.Is(err, strconv.ErrSyntax) || !strings.Contains(err.Error(), key) {
+		t.Errorf("expected the fallback value and the error naming the key, got %d and %v", got, err)
+	}
+}
+
+func TestListE(t *testing.T) {
+	const key = "TESTING_ENV_LIST_E"
+
+	t.Setenv(key, "1, INVALID")
+	if got, err := ListE(key, Parsers.Int(), []int{1}); len(got) != 1 || err == nil {
+		t.Errorf("expected the fallback value and the error")
+	}
+}
+
+func TestCollector(t *testing.T) {
+	t.Setenv("TESTING_COLLECTOR_INT", "INVALID NUMBER FORMAT")
+	t.Setenv("TESTING_COLLECTOR_DURATION", "INVALID DURATION FORMAT")
+	t.Setenv("TESTING_COLLECTOR_BOOL", "true")
+
+	var c Collector
+	if got := c.Int("TESTING_COLLECTOR_INT", 1); got != 1 {
+		t.Errorf("expected using the initial value")
+	}
+	if got := c.Duration("TESTING_COLLECTOR_DURATION", time.Second); got != time.Second {
+		t.Errorf("expected using the initial value")
+	}
+	if got := c.Bool("TESTING_COLLECTOR_BOOL", false); !got {
+		t.Errorf("expected using the env value")
+	}
+	_ = CollectList(&c, "TESTING_COLLECTOR_INT", Parsers.Int(), nil)
+
+	err := c.Err()
+	if err == nil {
+		t.Fatalf("expected the errors are collected")
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 3 {
+		t.Errorf("expected 3 errors, got %d: %v", n, err)
+	}
+	if !strings.Contains(err.Error(), "TESTING_COLLECTOR_DURATION") {
+		t.Errorf("expected the error names the key, got %v", err)
+	}
+
+	var ok Collector
+	_ = ok.Bool("TESTING_COLLECTOR_BOOL", false)
+	if err := ok.Err(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package env
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// LoadDotenv sets the variables of the .env files into the environment, for the local development. The variables
+// that are already set take precedence over the files, and the former files take precedence over the latter, so the
+// files only fill the missing variables. The files that don't exist are skipped. The ${OTHER_VAR} references in the
+// values are expanded, see Expand.
+//
+// Each line is either blank, a comment starting with #, or an assignment:
+//
+//	KEY=value # the inline comment is stripped from the unquoted value.
+//	export KEY=value
+//	KEY="double-quoted, with the \n, \t, \" and \\ escapes"
+//	KEY='single-quoted, taken literally'
+//	URL=postgres://${DB_USER}@${DB_HOST}/app
+func LoadDotenv(paths ...string) error {
+	for _, path := range paths {
+		vars, err := readDotenv(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := setVars(path, vars); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readDotenv reads the key-value pairs of the .env file in order.
+func readDotenv(path string) ([][2]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("env: open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	vars, err := ParseDotenv(f)
+	if err != nil {
+		return nil, fmt.Errorf("env: %s: %w", path, err)
+	}
+	return vars, nil
+}
+
+// ParseDotenv parses the content of a .env file into the key-value pairs in order, see LoadDotenv for the syntax.
+func ParseDotenv(r io.Reader) ([][2]string, error) {
+	var vars [][2]string
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		key, raw, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid assignment %q", n, line)
+		}
+
+		value, err := parseDotenvValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", n, key, err)
+		}
+		vars = append(vars, [2]string{key, value})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// parseDotenvValue unquotes the value, or strips the inline comment if it isn't quoted.
+func parseDotenvValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch quote := raw[0]; quote {
+	case '\'':
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", errors.New("unterminated single quote")
+		}
+		return raw[1 : end+1], nil
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(raw); i++ {
+			c := raw[i]
+			switch {
+			case c == '"':
+				return b.String(), nil
+			case c == '\\' && i+1 < len(raw):
+				i++
+				switch raw[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				default:
+					b.WriteByte(raw[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errors.New("unterminated double quote")
+	default:
+		if i := strings.Index(raw, " #"); i >= 0 {
+			raw = raw[:i]
+		}
+		return strings.TrimSpace(raw), nil
+	}
+}
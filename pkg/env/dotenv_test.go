@@ -0,0 +1,82 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	const content = `
+# comment
+PLAIN=value
+export EXPORTED=exported
+SPACED = spaced value # inline comment
+HASH=a#b
+EMPTY=
+DOUBLE="line1\nline2 \"quoted\" # not a comment"
+SINGLE='literal \n $VAR'
+`
+	got, err := ParseDotenv(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := [][2]string{
+		{"PLAIN", "value"},
+		{"EXPORTED", "exported"},
+		{"SPACED", "spaced value"},
+		{"HASH", "a#b"},
+		{"EMPTY", ""},
+		{"DOUBLE", "line1\nline2 \"quoted\" # not a comment"},
+		{"SINGLE", `literal \n $VAR`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestParseDotenv_Invalid(t *testing.T) {
+	for _, content := range []string{"NO_ASSIGNMENT", "=value", "KEY='unterminated", `KEY="unterminated`} {
+		if _, err := ParseDotenv(strings.NewReader(content)); err == nil {
+			t.Errorf("expected an error for %q", content)
+		}
+	}
+}
+
+func TestLoadDotenv(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.env")
+	second := filepath.Join(dir, "second.env")
+	writeFile(t, first, "TESTING_DOTENV_A=first\nTESTING_DOTENV_B=first\n")
+	writeFile(t, second, "TESTING_DOTENV_B=second\nTESTING_DOTENV_C=second\n")
+
+	t.Setenv("TESTING_DOTENV_A", "env")
+	t.Setenv("TESTING_DOTENV_B", "")
+	t.Setenv("TESTING_DOTENV_C", "")
+	os.Unsetenv("TESTING_DOTENV_B")
+	os.Unsetenv("TESTING_DOTENV_C")
+
+	if err := LoadDotenv(first, filepath.Join(dir, "missing.env"), second); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for key, want := range map[string]string{
+		"TESTING_DOTENV_A": "env",
+		"TESTING_DOTENV_B": "first",
+		"TESTING_DOTENV_C": "second",
+	} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("expected %s=%s, got %s", key, want, got)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,116 @@
+package env
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+)
+
+// RedactedValue replaces the secret values in Dump.
+const RedactedValue = "[REDACTED]"
+
+// Dump converts the struct into a tree of maps, slices and scalars with the secrets redacted, for printing the
+// effective configuration by json.Marshal or yaml.Marshal. The fields marked by the secret option of the `env` tag,
+// e.g. `env:"PASSWORD,secret"` or `env:"-,secret"` for the fields not loaded by Load, are replaced by RedactedValue
+// unless they are empty, and the password of a url.URL is always redacted.
+//
+// The structs are keyed by the field names, the encoding.TextMarshaler and time.Duration values are written as
+// strings, and the funcs and the channels are omitted.
+func Dump(v any) any {
+	d, _ := dumpValue(reflect.ValueOf(v))
+	return d
+}
+
+var _textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// dumpValue converts the value, the flag is false if the value is omitted.
+func dumpValue(v reflect.Value) (any, bool) {
+	if !v.IsValid() {
+		return nil, true
+	}
+
+	switch v.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil, false
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, true
+		}
+	}
+
+	switch v.Type() {
+	case _durationType:
+		return time.Duration(v.Int()).String(), true
+	case _urlType:
+		u := v.Interface().(url.URL)
+		return u.Redacted(), true
+	}
+
+	if v.Type().Implements(_textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return fmt.Sprintf("!(%v)", err), true
+		}
+		return string(b), true
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return dumpValue(v.Elem())
+	case reflect.Struct:
+		return dumpStruct(v), true
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, true
+		}
+		s := make([]any, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if e, ok := dumpValue(v.Index(i)); ok {
+				s = append(s, e)
+			}
+		}
+		return s, true
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, true
+		}
+		m := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			if e, ok := dumpValue(iter.Value()); ok {
+				m[fmt.Sprint(iter.Key().Interface())] = e
+			}
+		}
+		return m, true
+	default:
+		return v.Interface(), true
+	}
+}
+
+// dumpStruct converts the exported fields of the struct into a map.
+func dumpStruct(v reflect.Value) map[string]any {
+	t := v.Type()
+	m := make(map[string]any, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fv := v.Field(i)
+		if _, opts := parseTag(field.Tag.Get("env")); opts.secret {
+			if !fv.IsZero() {
+				m[field.Name] = RedactedValue
+			} else {
+				m[field.Name] = ""
+			}
+			continue
+		}
+
+		if e, ok := dumpValue(fv); ok {
+			m[field.Name] = e
+		}
+	}
+	return m
+}
@@ -0,0 +1,69 @@
+package env
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDump(t *testing.T) {
+	type database struct {
+		URL      *url.URL `env:"URL"`
+		Password string   `env:"PASSWORD,secret"`
+		Token    string   `env:"-,secret"`
+	}
+
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+
+	cfg := struct {
+		Name     string
+		Timeout  time.Duration
+		Level    *slog.LevelVar
+		Hosts    []string
+		Labels   map[string]int
+		Database database
+		OnError  func(error)
+		Missing  *database
+		internal string
+	}{
+		Name:    "app",
+		Timeout: 5 * time.Second,
+		Level:   level,
+		Hosts:   []string{"a", "b"},
+		Labels:  map[string]int{"tier": 1},
+		Database: database{
+			URL:      &url.URL{Scheme: "postgres", User: url.UserPassword("alice", "s3cr3t"), Host: "db"},
+			Password: "s3cr3t",
+		},
+		OnError:  func(error) {},
+		internal: "internal",
+	}
+
+	got, err := json.Marshal(Dump(&cfg))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var m map[string]any
+	_ = json.Unmarshal(got, &m)
+	want := map[string]any{
+		"Name":    "app",
+		"Timeout": "5s",
+		"Level":   "WARN",
+		"Hosts":   []any{"a", "b"},
+		"Labels":  map[string]any{"tier": float64(1)},
+		"Database": map[string]any{
+			"URL":      "postgres://alice:xxxxx@db",
+			"Password": RedactedValue,
+			"Token":    "",
+		},
+		"Missing": nil,
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("expected %v, got %v", want, m)
+	}
+}
@@ -1,10 +1,13 @@
 // Package env provides a straightforward way to retrieve environment variables and offers a default value if the
-// specified key is not present.
+// specified key is not present. The values loaded from the .env and config files may reference other variables,
+// e.g. ${DB_HOST}, see Expand. A secret can also be mounted as a file, whose path is given by the key suffixed by FileSuffix, e.g.
+// DB_PASSWORD_FILE=/run/secrets/db_password.
 //
 // This package is copied from https://github.com/pkg-id/env.
 package env
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -31,28 +34,63 @@ func Duration(key string, fallback time.Duration) time.Duration {
 // Bool returns the bool value if the key exists; otherwise, it returns the fallback value.
 func Bool(key string, fallback bool) bool { return Parse(key, Parsers.Bool(), fallback) }
 
+// StringE is like String, but it returns the error of reading the FileSuffix file instead of panicking.
+func StringE(key, fallback string) (string, error) { return ParseE(key, Parsers.Identity(), fallback) }
+
+// IntE is like Int, but it returns the parse error instead of panicking.
+func IntE(key string, fallback int) (int, error) { return ParseE(key, Parsers.Int(), fallback) }
+
+// Int64E is like Int64, but it returns the parse error instead of panicking.
+func Int64E(key string, fallback int64) (int64, error) { return ParseE(key, Parsers.Int64(), fallback) }
+
+// Float64E is like Float64, but it returns the parse error instead of panicking.
+func Float64E(key string, fallback float64) (float64, error) {
+	return ParseE(key, Parsers.Float64(), fallback)
+}
+
+// DurationE is like Duration, but it returns the parse error instead of panicking.
+func DurationE(key string, fallback time.Duration) (time.Duration, error) {
+	return ParseE(key, Parsers.Duration(), fallback)
+}
+
+// BoolE is like Bool, but it returns the parse error instead of panicking.
+func BoolE(key string, fallback bool) (bool, error) { return ParseE(key, Parsers.Bool(), fallback) }
+
 // List retrieves the environment variable with the specified key and attempts to parse it into a slice of type T.
 // The Parser function is used to parse each value in the comma-separated string obtained from the environment variable.
 // If the environment variable is not set or parsing fails for any of the values,
 // the function returns the fallback value.
 func List[T any](key string, parser Parser[T], fallback []T) []T {
-	comaSeperated, exists := getEnv(key)
-	if !exists {
+	values, err := ListE(key, parser, fallback)
+	if err != nil {
 		return fallback
 	}
 
+	return values
+}
+
+// ListE is like List, but it returns the parse error instead of the fallback value.
+func ListE[T any](key string, parser Parser[T], fallback []T) ([]T, error) {
+	comaSeperated, exists, err := getEnv(key)
+	if err != nil {
+		return fallback, err
+	}
+	if !exists {
+		return fallback, nil
+	}
+
 	values := make([]T, 0)
 	words := strings.Split(comaSeperated, ",")
 	for _, word := range words {
 		word = strings.TrimSpace(word)
 		t, err := parser(word)
 		if err != nil {
-			return fallback
+			return fallback, fmt.Errorf("env: parse %s: %w", key, err)
 		}
 		values = append(values, t)
 	}
 
-	return values
+	return values, nil
 }
 
 // StringList a syntactic sugar for List(key, Parsers.Identity(), fallback).
@@ -60,10 +98,28 @@ func StringList(key string, fallback []string) []string {
 	return List(key, Parsers.Identity(), fallback)
 }
 
-// getEnv returns the value of the environment variable and a flag indicating whether the variable exists.
-func getEnv(key string) (string, bool) {
-	v, exists := os.LookupEnv(key)
-	return v, exists
+// FileSuffix is the suffix of the variable holding the path of the file to read the value from, e.g. DB_PASSWORD_FILE
+// for DB_PASSWORD, following the convention of the Docker and Kubernetes secrets mounted as files.
+const FileSuffix = "_FILE"
+
+// getEnv returns the value of the environment variable and a flag indicating whether the variable exists. If the
+// variable isn't set but the one suffixed by FileSuffix is, the value is read from that file without the trailing
+// newlines. The error is returned when the file can't be read.
+func getEnv(key string) (string, bool, error) {
+	if v, exists := os.LookupEnv(key); exists {
+		return v, true, nil
+	}
+
+	path, exists := os.LookupEnv(key + FileSuffix)
+	if !exists {
+		return "", false, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("env: read %s%s: %w", key, FileSuffix, err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), true, nil
 }
 
 // must panics if the error parameter is not nil.
@@ -82,12 +138,25 @@ type Parser[T any] func(v string) (T, error)
 // Parse is a generic function that takes the key, parser, and a fallback value as arguments.
 // It returns the parsed value of the environment variable if the key exists, or the fallback value if it does not.
 func Parse[T any](key string, parser Parser[T], fallback T) T {
-	v, exists := getEnv(key)
+	return must(ParseE(key, parser, fallback))
+}
+
+// ParseE is like Parse, but it returns the parse error instead of panicking. The error names the key, and the
+// fallback value is returned along with it.
+func ParseE[T any](key string, parser Parser[T], fallback T) (T, error) {
+	v, exists, err := getEnv(key)
+	if err != nil {
+		return fallback, err
+	}
 	if !exists {
-		return fallback
+		return fallback, nil
 	}
 
-	return must(parser(v))
+	t, err := parser(v)
+	if err != nil {
+		return fallback, fmt.Errorf("env: parse %s: %w", key, err)
+	}
+	return t, nil
 }
 
 // parsers is a type for Parsers namespace.
@@ -1,6 +1,8 @@
 package env
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -208,3 +210,29 @@ func assertPanic(t *testing.T, f func()) {
 	}()
 	f()
 }
+
+func TestString_File(t *testing.T) {
+	const key = "TESTING_ENV_SECRET"
+
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(key+FileSuffix, path)
+	if got := String(key, "initial"); got != "s3cr3t" {
+		t.Errorf("expected using the file value without the trailing newline, got %q", got)
+	}
+
+	t.Setenv(key, "env")
+	if got := String(key, "initial"); got != "env" {
+		t.Errorf("expected the env value takes precedence over the file, got %q", got)
+	}
+
+	t.Setenv(key+FileSuffix, filepath.Join(t.TempDir(), "missing"))
+	os.Unsetenv(key)
+	if _, err := ParseE(key, Parsers.Identity(), "initial"); err == nil {
+		t.Errorf("expected an error for the missing file")
+	}
+	assertPanic(t, func() { _ = String(key, "initial") })
+}
@@ -0,0 +1,120 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Expand replaces the ${OTHER_VAR} references in the value by the values of the environment variables, e.g.
+// DB_URL=postgres://${DB_USER}:${DB_PASSWORD}@${DB_HOST}/app. A $$ is an escaped $, and a $ not followed by { or $ is
+// taken literally. Referencing an unset variable is an error.
+//
+// Only the values of LoadDotenv and LoadFile are expanded by this package, when they are loaded; the variables set
+// by the environment, e.g. a password containing ${, are always taken literally.
+func Expand(value string) (string, error) { return expand(value, nil, nil) }
+
+// Escape escapes the $ of the value, so the value is taken literally by the expansion, e.g. a secret written into a
+// .env file.
+func Escape(value string) string { return strings.ReplaceAll(value, "$", "$$") }
+
+// setVars expands the values of the variables loaded from the file that aren't set yet and sets them into the
+// environment. The first of the duplicated keys wins. The values may reference each other, which are expanded
+// recursively, or the environment, which is taken literally.
+func setVars(path string, vars [][2]string) error {
+	pending := make(map[string]string, len(vars))
+	for _, kv := range vars {
+		if _, exists := os.LookupEnv(kv[0]); exists {
+			continue
+		}
+		if _, exists := pending[kv[0]]; !exists {
+			pending[kv[0]] = kv[1]
+		}
+	}
+
+	expanded := make(map[string]string, len(pending))
+	for key, value := range pending {
+		v, err := expand(value, pending, []string{key})
+		if err != nil {
+			return fmt.Errorf("env: expand %s of %s: %w", key, path, err)
+		}
+		expanded[key] = v
+	}
+
+	for _, kv := range vars {
+		v, exists := expanded[kv[0]]
+		if !exists {
+			continue
+		}
+		if err := os.Setenv(kv[0], v); err != nil {
+			return fmt.Errorf("env: set %s of %s: %w", kv[0], path, err)
+		}
+		delete(expanded, kv[0])
+	}
+	return nil
+}
+
+// expand expands the value. The references to the pending variables are expanded recursively, the stack is the keys
+// being expanded for detecting the cycles, and the other references are read from the environment literally.
+func expand(value string, pending map[string]string, stack []string) (string, error) {
+	if !strings.Contains(value, "$") {
+		return value, nil
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(value, '$')
+		if i < 0 || i == len(value)-1 {
+			b.WriteString(value)
+			return b.String(), nil
+		}
+
+		b.WriteString(value[:i])
+		switch value[i+1] {
+		case '$':
+			b.WriteByte('$')
+			value = value[i+2:]
+		case '{':
+			end := strings.IndexByte(value[i+2:], '}')
+			if end < 0 {
+				return "", errors.New("unterminated ${")
+			}
+
+			name := value[i+2 : i+2+end]
+			if name == "" {
+				return "", errors.New("empty ${}")
+			}
+
+			ref, err := expandRef(name, pending, stack)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(ref)
+			value = value[i+3+end:]
+		default:
+			b.WriteByte('$')
+			value = value[i+1:]
+		}
+	}
+}
+
+// expandRef returns the value of the referenced variable.
+func expandRef(name string, pending map[string]string, stack []string) (string, error) {
+	if slices.Contains(stack, name) {
+		return "", fmt.Errorf("cycle of references: %s -> %s", strings.Join(stack, " -> "), name)
+	}
+	if ref, exists := pending[name]; exists {
+		return expand(ref, pending, append(stack, name))
+	}
+
+	ref, exists, err := getEnv(name)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", fmt.Errorf("reference to unset variable %s", name)
+	}
+	return ref, nil
+}
@@ -0,0 +1,73 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDotenv_Expand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "TESTING_EXPAND_URL=postgres://${TESTING_EXPAND_USER}@${TESTING_EXPAND_HOST}/app?cost=$$5&raw=$x\n" +
+		"TESTING_EXPAND_HOST=db.${TESTING_EXPAND_DOMAIN}\n" +
+		"TESTING_EXPAND_DOMAIN=example.com\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TESTING_EXPAND_USER", "${alice}")
+	for _, key := range []string{"TESTING_EXPAND_URL", "TESTING_EXPAND_HOST", "TESTING_EXPAND_DOMAIN"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+
+	if err := LoadDotenv(path); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	const want = "postgres://${alice}@db.example.com/app?cost=$5&raw=$x"
+	if got := String("TESTING_EXPAND_URL", ""); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestString_NotExpanded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("pa$$${word}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TESTING_EXPAND_PASSWORD_FILE", path)
+	t.Setenv("TESTING_EXPAND_DSN", "user:${TESTING_EXPAND_PASSWORD}@host")
+
+	if got := String("TESTING_EXPAND_PASSWORD", ""); got != "pa$$${word}" {
+		t.Errorf("expected the file content is taken literally, got %q", got)
+	}
+	if got := String("TESTING_EXPAND_DSN", ""); got != "user:${TESTING_EXPAND_PASSWORD}@host" {
+		t.Errorf("expected the environment variable is taken literally, got %q", got)
+	}
+}
+
+func TestExpand_Errors(t *testing.T) {
+	for _, value := range []string{
+		"${TESTING_EXPAND_UNSET}",
+		"${TESTING_EXPAND_A",
+		"${}",
+	} {
+		if _, err := Expand(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "TESTING_EXPAND_A=${TESTING_EXPAND_B}\nTESTING_EXPAND_B=${TESTING_EXPAND_A}\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadDotenv(path); err == nil {
+		t.Errorf("expected an error for the cycle")
+	}
+	if _, exists := os.LookupEnv("TESTING_EXPAND_A"); exists {
+		t.Errorf("expected nothing is set when the expansion fails")
+	}
+}
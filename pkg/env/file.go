@@ -0,0 +1,123 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadFile sets the values of the config file into the environment, the format is chosen by the extension, either
+// .yaml, .yml, .toml or .json. Like LoadDotenv, the variables that are already set take precedence over the file, and
+// the ${OTHER_VAR} references in the values are expanded, see Expand.
+//
+// The keys of the nested tables are joined by underscores and uppercased into the variable names, and the lists are
+// joined by commas, so the file mirrors the variables:
+//
+//	http_server:
+//	  port: 8080
+//	http_cors:
+//	  allowed_origins:
+//	    - https://example.com
+//	    - https://admin.example.com
+//
+// sets HTTP_SERVER_PORT=8080 and HTTP_CORS_ALLOWED_ORIGINS=https://example.com,https://admin.example.com.
+func LoadFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("env: read %s: %w", path, err)
+	}
+
+	var values map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &values)
+	case ".toml":
+		err = toml.Unmarshal(b, &values)
+	case ".json":
+		err = json.Unmarshal(b, &values)
+	default:
+		return fmt.Errorf("env: unsupported config file extension %q of %s", ext, path)
+	}
+	if err != nil {
+		return fmt.Errorf("env: decode %s: %w", path, err)
+	}
+
+	vars, err := Flatten(values)
+	if err != nil {
+		return fmt.Errorf("env: %s: %w", path, err)
+	}
+
+	pairs := make([][2]string, 0, len(vars))
+	for key, value := range vars {
+		pairs = append(pairs, [2]string{key, value})
+	}
+	return setVars(path, pairs)
+}
+
+// Flatten converts the nested values into the variables, see LoadFile. The lists of tables aren't supported.
+func Flatten(values map[string]any) (map[string]string, error) {
+	vars := make(map[string]string)
+	if err := flatten(vars, "", values); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+func flatten(vars map[string]string, prefix string, values map[string]any) error {
+	for k, v := range values {
+		key := joinKey(prefix, strings.NewReplacer("-", "_", ".", "_").Replace(strings.ToUpper(k)))
+		switch v := v.(type) {
+		case nil:
+		case map[string]any:
+			if err := flatten(vars, key, v); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				s, err := scalarString(item)
+				if err != nil {
+					return fmt.Errorf("%s[%d]: %w", key, i, err)
+				}
+				items[i] = s
+			}
+			vars[key] = strings.Join(items, ",")
+		default:
+			s, err := scalarString(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			vars[key] = s
+		}
+	}
+	return nil
+}
+
+// scalarString formats the scalar value of the config file.
+func scalarString(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	default:
+		return "", fmt.Errorf("unsupported value of type %T", v)
+	}
+}
@@ -0,0 +1,95 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+testing_file:
+  port: 8080
+  ratio: 0.25
+  enabled: true
+  origins:
+    - https://example.com
+    - https://admin.example.com
+  empty: null
+  env: file
+`,
+		"config.toml": `
+[testing_file]
+port = 8080
+ratio = 0.25
+enabled = true
+origins = ["https://example.com", "https://admin.example.com"]
+env = "file"
+`,
+		"config.json": `{
+  "testing_file": {
+    "port": 8080,
+    "ratio": 0.25,
+    "enabled": true,
+    "origins": ["https://example.com", "https://admin.example.com"],
+    "env": "file"
+  }
+}`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			writeFile(t, path, content)
+
+			for _, key := range []string{"PORT", "RATIO", "ENABLED", "ORIGINS", "EMPTY"} {
+				t.Setenv("TESTING_FILE_"+key, "")
+				os.Unsetenv("TESTING_FILE_" + key)
+			}
+			t.Setenv("TESTING_FILE_ENV", "env")
+
+			if err := LoadFile(path); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if got := Int("TESTING_FILE_PORT", 0); got != 8080 {
+				t.Errorf("expected 8080, got %d", got)
+			}
+			if got := Float64("TESTING_FILE_RATIO", 0); got != 0.25 {
+				t.Errorf("expected 0.25, got %v", got)
+			}
+			if got := Bool("TESTING_FILE_ENABLED", false); !got {
+				t.Errorf("expected true")
+			}
+			if got := StringList("TESTING_FILE_ORIGINS", nil); len(got) != 2 || got[1] != "https://admin.example.com" {
+				t.Errorf("expected the list, got %v", got)
+			}
+			if _, exists := os.LookupEnv("TESTING_FILE_EMPTY"); exists {
+				t.Errorf("expected the null value is skipped")
+			}
+			if got := String("TESTING_FILE_ENV", ""); got != "env" {
+				t.Errorf("expected the env value takes precedence over the file, got %q", got)
+			}
+		})
+	}
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.ini":   "port = 8080",
+		"invalid.yaml": "port: [",
+		"tables.yaml":  "items:\n  - name: a\n",
+	} {
+		path := filepath.Join(dir, name)
+		writeFile(t, path, content)
+		if err := LoadFile(path); err == nil {
+			t.Errorf("expected an error for %s", name)
+		}
+	}
+
+	if err := LoadFile(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Errorf("expected an error for the missing file")
+	}
+}
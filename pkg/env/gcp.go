@@ -0,0 +1,120 @@
+package env
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SchemeGCPSecretManager is the scheme of the GCP Secret Manager references, see GCPSecretManagerProvider.
+const SchemeGCPSecretManager = "gcpsm"
+
+// GCPConfig is the configuration of GCPSecretManagerProvider.
+type GCPConfig struct {
+	// AccessToken is the OAuth2 access token for authenticating the requests, e.g. by gcloud auth print-access-token.
+	// If empty, the token of the service account is fetched from the metadata server on each resolve, which is
+	// available on GCE, GKE and Cloud Run.
+	AccessToken string `env:"ACCESS_TOKEN,secret"`
+
+	Endpoint    string       `env:"SECRET_MANAGER_ENDPOINT"` // Default is https://secretmanager.googleapis.com.
+	MetadataURL string       `env:"METADATA_URL"`            // Default is http://metadata.google.internal.
+	Client      *http.Client // The HTTP client, default is http.DefaultClient.
+}
+
+func (c GCPConfig) withDefaults() GCPConfig {
+	if c.Client == nil {
+		c.Client = http.DefaultClient
+	}
+	if c.Endpoint == "" {
+		c.Endpoint = "https://secretmanager.googleapis.com"
+	}
+	if c.MetadataURL == "" {
+		c.MetadataURL = "http://metadata.google.internal"
+	}
+	c.Endpoint = strings.TrimSuffix(c.Endpoint, "/")
+	c.MetadataURL = strings.TrimSuffix(c.MetadataURL, "/")
+	return c
+}
+
+// GCPSecretManagerProvider resolves the secrets of the GCP Secret Manager. The reference is either the resource name
+// of the secret version or the project and the secret of the latest version, optionally followed by the field of the
+// JSON secret, e.g. gcpsm://projects/acme/secrets/db/versions/3 or gcpsm://acme/db#password.
+type GCPSecretManagerProvider struct {
+	cfg GCPConfig
+}
+
+// NewGCPSecretManagerProvider creates a new GCPSecretManagerProvider.
+func NewGCPSecretManagerProvider(cfg GCPConfig) *GCPSecretManagerProvider {
+	return &GCPSecretManagerProvider{cfg: cfg.withDefaults()}
+}
+
+// Resolve implements Provider.
+func (p *GCPSecretManagerProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	name, field := splitRef(ref)
+	if !strings.HasPrefix(name, "projects/") {
+		project, secret, ok := strings.Cut(name, "/")
+		if !ok || project == "" || secret == "" {
+			return "", fmt.Errorf("gcpsm: invalid secret name %q", name)
+		}
+		name = "projects/" + project + "/secrets/" + secret + "/versions/latest"
+	}
+
+	token, err := p.accessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Endpoint+"/v1/"+name+":access", nil)
+	if err != nil {
+		return "", fmt.Errorf("gcpsm: create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var res struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := doJSON(p.cfg.Client, req, &res); err != nil {
+		return "", fmt.Errorf("gcpsm: access %s: %w", name, err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(res.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("gcpsm: decode payload of %s: %w", name, err)
+	}
+
+	secret, err := secretField(string(data), field)
+	if err != nil {
+		return "", fmt.Errorf("gcpsm: %s: %w", name, err)
+	}
+	return secret, nil
+}
+
+// accessToken returns the configured token, or the token of the service account from the metadata server.
+func (p *GCPSecretManagerProvider) accessToken(ctx context.Context) (string, error) {
+	if p.cfg.AccessToken != "" {
+		return p.cfg.AccessToken, nil
+	}
+
+	url := p.cfg.MetadataURL + "/computeMetadata/v1/instance/service-accounts/default/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("gcpsm: create token request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var res struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(p.cfg.Client, req, &res); err != nil {
+		return "", fmt.Errorf("gcpsm: fetch token from metadata server: %w", err)
+	}
+	if res.AccessToken == "" {
+		return "", errors.New("gcpsm: metadata server returned an empty token")
+	}
+	return res.AccessToken, nil
+}
@@ -0,0 +1,245 @@
+package env
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrRequired is returned by Load when a required variable isn't set.
+var ErrRequired = errors.New("env: required variable is not set")
+
+// LoadOption configures Load.
+type LoadOption func(*loadConfig)
+
+// loadConfig is the configuration of Load.
+type loadConfig struct {
+	prefix string
+}
+
+// Prefix prepends the prefix and an underscore to the names of the variables, e.g. Prefix("HTTP_SERVER") reads the
+// field tagged by `env:"PORT"` from HTTP_SERVER_PORT.
+func Prefix(prefix string) LoadOption {
+	return func(cfg *loadConfig) { cfg.prefix = joinKey(cfg.prefix, prefix) }
+}
+
+// Load fills the fields of the struct pointed by dst from the environment variables by their `env` tags. The tag is
+// the variable name followed by the comma-separated options:
+//
+//	Port    int           `env:"PORT,default=8080"`
+//	Secret  string        `env:"SECRET,required,secret"`
+//	Hosts   []string      `env:"HOSTS,default=a.example.com,b.example.com"`
+//	Timeout time.Duration `env:"TIMEOUT,default=5s"`
+//
+// The secret option marks the field to be redacted by Dump. The default option must be the last one since its value
+// takes the rest of the tag. A field whose variable isn't set and has no default keeps its value, so the defaults can
+// also be set on dst before calling Load. The tag of a nested struct is the prefix of its fields, and the fields
+// without the tag or named "-" are skipped.
+//
+// The supported types are string, bool, the integers, the floats, time.Duration, url.URL, map[string]string (see
+// Parsers.Map), encoding.TextUnmarshaler, the pointers to them, and the slices of them separated by commas. Unlike
+// the functions reading a single variable, Load doesn't panic, all invalid and missing required variables are
+// reported by the joined errors.
+func Load(dst any, opts ...LoadOption) error {
+	var cfg loadConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("env: load: dst must be a non-nil pointer to a struct, got %T", dst)
+	}
+	return loadStruct(v.Elem(), cfg.prefix)
+}
+
+// loadStruct fills the tagged fields of the struct value, the errors of all fields are joined.
+func loadStruct(v reflect.Value, prefix string) error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("env")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		name, tagOpts := parseTag(tag)
+		if name == "-" {
+			continue
+		}
+
+		key := joinKey(prefix, name)
+
+		fv := v.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != _urlType && !isTextUnmarshaler(fv) {
+			errs = append(errs, loadStruct(fv, key))
+			continue
+		}
+
+		raw, exists, err := getEnv(key)
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+			continue
+		case exists:
+		case tagOpts.hasDefault:
+			raw = tagOpts.defaultValue
+		case tagOpts.required:
+			errs = append(errs, fmt.Errorf("%w: %s", ErrRequired, key))
+			continue
+		default:
+			continue
+		}
+
+		if err := setValue(fv, raw); err != nil {
+			errs = append(errs, fmt.Errorf("env: parse %s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// tagOptions is the options of the `env` tag.
+type tagOptions struct {
+	required     bool
+	secret       bool
+	hasDefault   bool
+	defaultValue string
+}
+
+// parseTag splits the `env` tag into the variable name and the options.
+func parseTag(tag string) (string, tagOptions) {
+	var opts tagOptions
+	name, rest, _ := strings.Cut(tag, ",")
+	for rest != "" {
+		if v, ok := strings.CutPrefix(rest, "default="); ok {
+			opts.hasDefault, opts.defaultValue = true, v
+			break
+		}
+
+		var opt string
+		opt, rest, _ = strings.Cut(rest, ",")
+		switch strings.TrimSpace(opt) {
+		case "required":
+			opts.required = true
+		case "secret":
+			opts.secret = true
+		}
+	}
+	return strings.TrimSpace(name), opts
+}
+
+// joinKey joins the prefix and the name by an underscore.
+func joinKey(prefix, name string) string {
+	switch {
+	case prefix == "":
+		return name
+	case name == "":
+		return prefix
+	default:
+		return prefix + "_" + name
+	}
+}
+
+var (
+	_textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	_durationType        = reflect.TypeOf(time.Duration(0))
+	_urlType             = reflect.TypeOf(url.URL{})
+	_mapType             = reflect.TypeOf(map[string]string(nil))
+)
+
+// isTextUnmarshaler reports whether the addressable value implements encoding.TextUnmarshaler.
+func isTextUnmarshaler(v reflect.Value) bool {
+	return v.CanAddr() && v.Addr().Type().Implements(_textUnmarshalerType)
+}
+
+// setValue parses the raw value into v by its type.
+func setValue(v reflect.Value, raw string) error {
+	if isTextUnmarshaler(v) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	switch v.Type() {
+	case _urlType:
+		u, err := Parsers.URL()(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(*u))
+		return nil
+	case _mapType:
+		m, err := Parsers.Map()(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(m))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := setValue(elem.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Slice:
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+			return nil
+		}
+
+		words := strings.Split(raw, ",")
+		s := reflect.MakeSlice(v.Type(), len(words), len(words))
+		for i, word := range words {
+			if err := setValue(s.Index(i), strings.TrimSpace(word)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == _durationType {
+			d, err := time.ParseDuration(raw)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
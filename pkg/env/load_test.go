@@ -0,0 +1,97 @@
+package env
+
+import (
+	"errors"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testLoadConfig struct {
+	Port     int               `env:"PORT,default=8080"`
+	Host     string            `env:"HOST,required"`
+	Timeout  time.Duration     `env:"TIMEOUT,default=5s"`
+	Debug    bool              `env:"DEBUG"`
+	Ratio    float64           `env:"RATIO,default=0.5"`
+	MaxSize  uint32            `env:"MAX_SIZE"`
+	Hosts    []string          `env:"HOSTS,default=a.example.com, b.example.com"`
+	Ports    []int             `env:"PORTS"`
+	Level    slog.Level        `env:"LEVEL,default=info"`
+	Limit    *int              `env:"LIMIT"`
+	Endpoint *url.URL          `env:"ENDPOINT,default=https://example.com"`
+	Labels   map[string]string `env:"LABELS"`
+	Kept     string            `env:"KEPT"`
+	Skipped  string            `env:"-"`
+	Untagged string
+	TLS      struct {
+		Enabled bool   `env:"ENABLED"`
+		Cert    string `env:"CERT,default=cert.pem"`
+	} `env:"TLS"`
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("TESTING_LOAD_HOST", "localhost")
+	t.Setenv("TESTING_LOAD_DEBUG", "true")
+	t.Setenv("TESTING_LOAD_MAX_SIZE", "1024")
+	t.Setenv("TESTING_LOAD_PORTS", "80, 443")
+	t.Setenv("TESTING_LOAD_LEVEL", "warn")
+	t.Setenv("TESTING_LOAD_LIMIT", "10")
+	t.Setenv("TESTING_LOAD_LABELS", "team=core")
+	t.Setenv("TESTING_LOAD_SKIPPED", "skipped")
+	t.Setenv("TESTING_LOAD_TLS_ENABLED", "true")
+
+	cfg := testLoadConfig{Kept: "kept", Untagged: "untagged"}
+	if err := Load(&cfg, Prefix("TESTING_LOAD")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Port != 8080 || cfg.Host != "localhost" || cfg.Timeout != 5*time.Second || !cfg.Debug || cfg.Ratio != 0.5 {
+		t.Errorf("expected the scalar fields are loaded, got %+v", cfg)
+	}
+	if cfg.MaxSize != 1024 || cfg.Level != slog.LevelWarn || cfg.Limit == nil || *cfg.Limit != 10 {
+		t.Errorf("expected the typed fields are loaded, got %+v", cfg)
+	}
+	if cfg.Endpoint == nil || cfg.Endpoint.Host != "example.com" || cfg.Labels["team"] != "core" {
+		t.Errorf("expected the url and the map are loaded, got %v and %v", cfg.Endpoint, cfg.Labels)
+	}
+	if !reflect.DeepEqual(cfg.Hosts, []string{"a.example.com", "b.example.com"}) {
+		t.Errorf("expected the default list, got %v", cfg.Hosts)
+	}
+	if !reflect.DeepEqual(cfg.Ports, []int{80, 443}) {
+		t.Errorf("expected the env list, got %v", cfg.Ports)
+	}
+	if cfg.Kept != "kept" || cfg.Skipped != "" || cfg.Untagged != "untagged" {
+		t.Errorf("expected the unset and the skipped fields are kept, got %+v", cfg)
+	}
+	if !cfg.TLS.Enabled || cfg.TLS.Cert != "cert.pem" {
+		t.Errorf("expected the nested struct is loaded by its prefix, got %+v", cfg.TLS)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Setenv("TESTING_LOAD_PORT", "INVALID NUMBER FORMAT")
+	t.Setenv("TESTING_LOAD_TLS_ENABLED", "INVALID BOOL FORMAT")
+
+	var cfg testLoadConfig
+	err := Load(&cfg, Prefix("TESTING_LOAD"))
+	if !errors.Is(err, ErrRequired) {
+		t.Errorf("expected the missing required variable is reported, got %v", err)
+	}
+	for _, key := range []string{"TESTING_LOAD_HOST", "TESTING_LOAD_PORT", "TESTING_LOAD_TLS_ENABLED"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("expected %s is reported, got %v", key, err)
+		}
+	}
+}
+
+func TestLoad_InvalidDst(t *testing.T) {
+	var cfg testLoadConfig
+	for _, dst := range []any{nil, cfg, (*testLoadConfig)(nil), new(int)} {
+		if err := Load(dst); err == nil {
+			t.Errorf("expected an error for %T", dst)
+		}
+	}
+}
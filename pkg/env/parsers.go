@@ -0,0 +1,102 @@
+package env
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// URL returns the absolute *url.URL value if the key exists; otherwise, it returns the fallback value.
+func URL(key string, fallback *url.URL) *url.URL { return Parse(key, Parsers.URL(), fallback) }
+
+// Time returns the time.Time value in the layout if the key exists; otherwise, it returns the fallback value.
+func Time(key, layout string, fallback time.Time) time.Time {
+	return Parse(key, Parsers.Time(layout), fallback)
+}
+
+// Bytes returns the size in bytes if the key exists; otherwise, it returns the fallback value. See Parsers.Bytes.
+func Bytes(key string, fallback int64) int64 { return Parse(key, Parsers.Bytes(), fallback) }
+
+// Map returns the map of the comma-separated key=value pairs if the key exists; otherwise, it returns the fallback
+// value.
+func Map(key string, fallback map[string]string) map[string]string {
+	return Parse(key, Parsers.Map(), fallback)
+}
+
+// URL parses a string into an absolute *url.URL, e.g. https://example.com.
+func (parsers) URL() Parser[*url.URL] {
+	return func(v string) (*url.URL, error) {
+		u, err := url.Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		if !u.IsAbs() {
+			return nil, fmt.Errorf("url %q is not absolute", v)
+		}
+		return u, nil
+	}
+}
+
+// Time parses a string into a time.Time by the layout, e.g. time.RFC3339.
+func (parsers) Time(layout string) Parser[time.Time] {
+	return func(v string) (time.Time, error) { return time.Parse(layout, v) }
+}
+
+// _byteUnits is the multipliers of the size units, both the decimal and the binary ones.
+var _byteUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// Bytes parses a size into the number of bytes, e.g. 512, 64KiB, 10MiB or 1.5GB. The units are case-insensitive,
+// KB, MB, GB and TB are the powers of 1000, while KiB, MiB, GiB and TiB are the powers of 1024.
+func (parsers) Bytes() Parser[int64] {
+	return func(v string) (int64, error) {
+		s := strings.TrimSpace(v)
+		i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if i < 0 {
+			i = len(s)
+		}
+
+		unit, ok := _byteUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+		if !ok {
+			return 0, fmt.Errorf("unknown size unit in %q", v)
+		}
+
+		n, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid size %q: %w", v, err)
+		}
+		return int64(n * float64(unit)), nil
+	}
+}
+
+// Map parses the comma-separated key=value pairs into a map, e.g. team=core,tier=gold.
+func (parsers) Map() Parser[map[string]string] {
+	return func(v string) (map[string]string, error) {
+		m := make(map[string]string)
+		if strings.TrimSpace(v) == "" {
+			return m, nil
+		}
+
+		for _, pair := range strings.Split(v, ",") {
+			key, value, ok := strings.Cut(pair, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" {
+				return nil, fmt.Errorf("invalid pair %q, expected key=value", pair)
+			}
+			m[key] = strings.TrimSpace(value)
+		}
+		return m, nil
+	}
+}
@@ -0,0 +1,81 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestURL(t *testing.T) {
+	const key = "TESTING_ENV_URL"
+
+	if got := URL(key, nil); got != nil {
+		t.Errorf("expected using the initial value")
+	}
+
+	t.Setenv(key, "https://example.com/path?q=1")
+	if got := URL(key, nil); got == nil || got.Host != "example.com" || got.Path != "/path" {
+		t.Errorf("expected using the env value, got %v", got)
+	}
+
+	assertPanic(t, func() {
+		t.Setenv(key, "/relative")
+		_ = URL(key, nil) // this should be panic.
+	})
+}
+
+func TestTime(t *testing.T) {
+	const key = "TESTING_ENV_TIME"
+
+	t.Setenv(key, "2024-01-02")
+	want := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	if got := Time(key, time.DateOnly, time.Time{}); !got.Equal(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestBytes(t *testing.T) {
+	for v, want := range map[string]int64{
+		"512":    512,
+		"512B":   512,
+		"64KiB":  64 << 10,
+		"10 MiB": 10 << 20,
+		"1.5GB":  1.5e9,
+		"2tib":   2 << 40,
+	} {
+		got, err := Parsers.Bytes()(v)
+		if err != nil || got != want {
+			t.Errorf("expected %d for %q, got %d and %v", want, v, got, err)
+		}
+	}
+
+	for _, v := range []string{"", "MiB", "10XB", "1.2.3KB"} {
+		if _, err := Parsers.Bytes()(v); err == nil {
+			t.Errorf("expected an error for %q", v)
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	const key = "TESTING_ENV_MAP"
+
+	t.Setenv(key, "team = core, tier=gold,empty=")
+	want := map[string]string{"team": "core", "tier": "gold", "empty": ""}
+	if got := Map(key, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	assertPanic(t, func() {
+		t.Setenv(key, "team")
+		_ = Map(key, nil) // this should be panic.
+	})
+}
+
+func TestList_Bytes(t *testing.T) {
+	const key = "TESTING_ENV_LIST_BYTES"
+
+	t.Setenv(key, "1KiB, 2KiB")
+	if got := List(key, Parsers.Bytes(), nil); !reflect.DeepEqual(got, []int64{1 << 10, 2 << 10}) {
+		t.Errorf("expected using the env value, got %v", got)
+	}
+}
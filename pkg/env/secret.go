@@ -0,0 +1,128 @@
+package env
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Provider resolves the secret references of a secret manager, e.g. vault://secret/data/db#password.
+type Provider interface {
+	// Resolve returns the secret value of the reference, which is the part after the scheme, e.g.
+	// secret/data/db#password.
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// ProviderFunc is an adapter to allow the use of ordinary functions as Provider.
+type ProviderFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve implements Provider.
+func (f ProviderFunc) Resolve(ctx context.Context, ref string) (string, error) { return f(ctx, ref) }
+
+// Resolve returns the secret value if the value is a reference of one of the providers by its scheme, e.g. the
+// provider of "vault" resolves vault://secret/data/db#password. Other values are returned as is.
+func Resolve(ctx context.Context, providers map[string]Provider, value string) (string, error) {
+	scheme, ref, ok := strings.Cut(value, "://")
+	if !ok {
+		return value, nil
+	}
+
+	provider, ok := providers[scheme]
+	if !ok {
+		return value, nil
+	}
+
+	secret, err := provider.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("env: resolve %s: %w", value, err)
+	}
+	return secret, nil
+}
+
+// ResolveEnv replaces the environment variables holding a secret reference by the resolved secret, so the lookups
+// afterward read the secret as usual. It is meant to be called once at the startup, the errors of all variables are
+// joined.
+func ResolveEnv(ctx context.Context, providers map[string]Provider) error {
+	environ := os.Environ()
+	slices.Sort(environ)
+
+	var errs []error
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		secret, err := Resolve(ctx, providers, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		if secret == value {
+			continue
+		}
+		if err := os.Setenv(key, secret); err != nil {
+			errs = append(errs, fmt.Errorf("env: set %s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// splitRef splits the reference into the secret name and the field after the #, if any.
+func splitRef(ref string) (name, field string) {
+	name, field, _ = strings.Cut(ref, "#")
+	return name, field
+}
+
+// secretField returns the field of the JSON object secret, or the secret as is if the field is empty.
+func secretField(secret, field string) (string, error) {
+	if field == "" {
+		return secret, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("decode secret for field %q: %w", field, err)
+	}
+	return stringField(fields, field)
+}
+
+// stringField returns the field of the object as a string.
+func stringField(fields map[string]any, field string) (string, error) {
+	v, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %q", field)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encode field %q: %w", field, err)
+	}
+	return string(b), nil
+}
+
+// doJSON sends the request and decodes the JSON response into dst, a non-2xx status is an error.
+func doJSON(client *http.Client, req *http.Request, dst any) error {
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, dst); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package env
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveEnv(t *testing.T) {
+	providers := map[string]Provider{
+		"fake": ProviderFunc(func(_ context.Context, ref string) (string, error) {
+			if ref == "missing" {
+				return "", errors.New("not found")
+			}
+			return "secret of " + ref, nil
+		}),
+	}
+
+	t.Setenv("TESTING_RESOLVE_SECRET", "fake://db#password")
+	t.Setenv("TESTING_RESOLVE_URL", "https://example.com")
+	if err := ResolveEnv(context.Background(), providers); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := os.Getenv("TESTING_RESOLVE_SECRET"); got != "secret of db#password" {
+		t.Errorf("expected the reference is resolved, got %q", got)
+	}
+	if got := os.Getenv("TESTING_RESOLVE_URL"); got != "https://example.com" {
+		t.Errorf("expected the unknown scheme is kept, got %q", got)
+	}
+
+	t.Setenv("TESTING_RESOLVE_MISSING", "fake://missing")
+	err := ResolveEnv(context.Background(), providers)
+	if err == nil || !strings.Contains(err.Error(), "TESTING_RESOLVE_MISSING") {
+		t.Errorf("expected the error names the key, got %v", err)
+	}
+}
+
+func TestVaultProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/db" || r.Header.Get("X-Vault-Token") != "token" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data": {"data": {"password": "s3cr3t"}, "metadata": {"version": 1}}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewVaultProvider(VaultConfig{Addr: srv.URL, Token: "token"})
+	got, err := p.Resolve(context.Background(), "secret/data/db#password")
+	if err != nil || got != "s3cr3t" {
+		t.Errorf("expected the secret field, got %q and %v", got, err)
+	}
+
+	if _, err := p.Resolve(context.Background(), "secret/data/db#username"); err == nil {
+		t.Errorf("expected an error for the missing field")
+	}
+
+	if _, err := p.Resolve(context.Background(), "secret/data/other#password"); err == nil {
+		t.Errorf("expected an error for the forbidden path")
+	}
+}
+
+func TestAWSSecretsManagerProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ SecretId string }
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		auth := r.Header.Get("Authorization")
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+			!strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20240102/us-east-1/secretsmanager/aws4_request") ||
+			req.SecretId != "prod/db" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		_, _ = io.WriteString(w, `{"Name": "prod/db", "SecretString": "{\"password\": \"s3cr3t\", \"port\": 5432}"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewAWSSecretsManagerProvider(AWSConfig{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		Endpoint:        srv.URL,
+	})
+	p.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	for ref, want := range map[string]string{
+		"prod/db":          `{"password": "s3cr3t", "port": 5432}`,
+		"prod/db#password": "s3cr3t",
+		"prod/db#port":     "5432",
+	} {
+		got, err := p.Resolve(context.Background(), ref)
+		if err != nil || got != want {
+			t.Errorf("expected %q for %s, got %q and %v", want, ref, got, err)
+		}
+	}
+}
+
+func TestAWSSecretsManagerProvider_StaticCredentialsOnly(t *testing.T) {
+	// without the static credentials the provider fails instead of looking up the credential chain.
+	p := NewAWSSecretsManagerProvider(AWSConfig{Region: "us-east-1", Endpoint: "http://127.0.0.1:0"})
+	if _, err := p.Resolve(context.Background(), "prod/db"); err == nil {
+		t.Error("expected an error without the static credentials")
+	}
+}
+
+func TestSignV4(t *testing.T) {
+	// the get-vanilla case of the AWS Signature Version 4 test suite.
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	cfg := AWSConfig{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	signV4(req, nil, cfg, "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	const want = "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestGCPSecretManagerProvider(t *testing.T) {
+	payload := base64.StdEncoding.EncodeToString([]byte(`{"password": "s3cr3t"}`))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/service-accounts/default/token":
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			_, _ = io.WriteString(w, `{"access_token": "token", "expires_in": 3599, "token_type": "Bearer"}`)
+		case "/v1/projects/acme/secrets/db/versions/latest:access":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			_, _ = io.WriteString(w, `{"name": "projects/acme/secrets/db/versions/1", "payload": {"data": "`+payload+`"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewGCPSecretManagerProvider(GCPConfig{Endpoint: srv.URL, MetadataURL: srv.URL})
+	for _, ref := range []string{"acme/db#password", "projects/acme/secrets/db/versions/latest#password"} {
+		got, err := p.Resolve(context.Background(), ref)
+		if err != nil || got != "s3cr3t" {
+			t.Errorf("expected the secret field for %s, got %q and %v", ref, got, err)
+		}
+	}
+
+	if _, err := p.Resolve(context.Background(), "acme"); err == nil {
+		t.Errorf("expected an error for the invalid name")
+	}
+}
@@ -0,0 +1,79 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SchemeVault is the scheme of the HashiCorp Vault references, see VaultProvider.
+const SchemeVault = "vault"
+
+// VaultConfig is the configuration of VaultProvider, it is loaded by the VAULT_* variables of the Vault CLI.
+type VaultConfig struct {
+	Addr      string       `env:"ADDR"`         // The address of the Vault server, e.g. https://vault.example.com:8200.
+	Token     string       `env:"TOKEN,secret"` // The token for authenticating the requests.
+	Namespace string       `env:"NAMESPACE"`    // The namespace of the Vault Enterprise, empty for the root.
+	Client    *http.Client // The HTTP client, default is http.DefaultClient.
+}
+
+func (c VaultConfig) withDefaults() VaultConfig {
+	if c.Client == nil {
+		c.Client = http.DefaultClient
+	}
+	c.Addr = strings.TrimSuffix(c.Addr, "/")
+	return c
+}
+
+// VaultProvider resolves the secrets of the HashiCorp Vault KV secrets engine. The reference is the API path of the
+// secret followed by the field, e.g. vault://secret/data/db#password reads the password field of the db secret in
+// the KV version 2 engine mounted at secret.
+type VaultProvider struct {
+	cfg VaultConfig
+}
+
+// NewVaultProvider creates a new VaultProvider.
+func NewVaultProvider(cfg VaultConfig) *VaultProvider {
+	return &VaultProvider{cfg: cfg.withDefaults()}
+}
+
+// Resolve implements Provider.
+func (p *VaultProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	path, field := splitRef(ref)
+	if field == "" {
+		return "", errors.New("vault: reference has no field")
+	}
+	if p.cfg.Addr == "" {
+		return "", errors.New("vault: address is not configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Addr+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", fmt.Errorf("vault: create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.cfg.Token)
+	if p.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.cfg.Namespace)
+	}
+
+	var res struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := doJSON(p.cfg.Client, req, &res); err != nil {
+		return "", fmt.Errorf("vault: read %s: %w", path, err)
+	}
+
+	// the KV version 2 nests the fields under data.data, the version 1 has them under data.
+	fields := res.Data
+	if nested, ok := res.Data["data"].(map[string]any); ok {
+		fields = nested
+	}
+
+	secret, err := stringField(fields, field)
+	if err != nil {
+		return "", fmt.Errorf("vault: %s: %w", path, err)
+	}
+	return secret, nil
+}
@@ -0,0 +1,248 @@
+// Package healthkit provides a registry of health checks for the readiness and liveness probes.
+package healthkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout is the timeout of the checks registered without one.
+const DefaultTimeout = 5 * time.Second
+
+// Status is the health status of a component or the whole application.
+type Status uint8
+
+// Sets of statuses, ordered from the best to the worst.
+const (
+	StatusHealthy   Status = iota // the component works as expected.
+	StatusDegraded                // the component works partially, e.g. a non-critical dependency is down.
+	StatusUnhealthy               // the component doesn't work.
+)
+
+// String returns the string representation of Status.
+func (s Status) String() string {
+	switch s {
+	case StatusHealthy:
+		return "healthy"
+	case StatusDegraded:
+		return "degraded"
+	case StatusUnhealthy:
+		return "unhealthy"
+	default:
+		return "unknown"
+	}
+}
+
+// Config is the configuration of the Registry.
+type Config struct {
+	// CacheTTL is the duration the check results are reused for, so frequent probes don't overload the
+	// dependencies. Zero disables the caching.
+	CacheTTL time.Duration `env:"CACHE_TTL,default=1s"`
+}
+
+// Checker checks the health of a component, e.g. a database, a cache or a queue.
+type Checker interface {
+	// Check returns nil if the component is healthy, an error wrapped by Degraded if it works partially. The
+	// context is canceled when the check timeout exceeded.
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is an adapter to allow the use of ordinary functions as Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Check implements Checker.
+func (f CheckerFunc) Check(ctx context.Context) error { return f(ctx) }
+
+// degradedError marks the error as a degradation instead of a failure.
+type degradedError struct{ error }
+
+// Unwrap returns the original error.
+func (e degradedError) Unwrap() error { return e.error }
+
+// Degraded marks the error returned by a Checker as a degradation, e.g. one of the replicas is down or the latency
+// is above the threshold, so the component is reported as degraded instead of unhealthy.
+func Degraded(err error) error {
+	if err == nil {
+		return nil
+	}
+	return degradedError{err}
+}
+
+// NonCritical wraps the checker of a non-critical component, its failures are reported as degradations, so they
+// don't fail the readiness.
+func NonCritical(checker Checker) Checker {
+	return CheckerFunc(func(ctx context.Context) error { return Degraded(checker.Check(ctx)) })
+}
+
+// Result is the result of a check.
+type Result struct {
+	Name      string        // the name of the checked component.
+	Status    Status        // the health status of the component.
+	Err       error         // the reason the component is degraded or unhealthy, nil if healthy.
+	Latency   time.Duration // the duration of the check.
+	CheckedAt time.Time     // when the check ran, earlier than the report if the result is cached.
+}
+
+// Report is the results of all checks in the registration order.
+type Report struct {
+	Results []Result
+}
+
+// Status returns the worst status of the results, StatusHealthy if there is no result.
+func (r Report) Status() Status {
+	status := StatusHealthy
+	for _, res := range r.Results {
+		status = max(status, res.Status)
+	}
+	return status
+}
+
+// check is a registered Checker with its last result.
+type check struct {
+	name    string
+	timeout time.Duration
+	checker Checker
+
+	mu   sync.Mutex
+	last Result
+}
+
+// Registry is a registry of health checks, it also tracks whether the application is ready for serving traffic.
+// The application is ready by default, and is marked not ready during the shutdown drain, so the load balancer stops
+// routing new requests.
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []*check
+	cacheTTL time.Duration
+	notReady atomic.Bool
+	now      func() time.Time
+}
+
+// NewRegistry creates a Registry without checks.
+func NewRegistry(cfg Config) *Registry {
+	return &Registry{cacheTTL: cfg.CacheTTL, now: time.Now}
+}
+
+// Register registers the checker of the component, replacing the one with the same name. The timeout limits the
+// duration of each check, DefaultTimeout is used if zero.
+func (r *Registry) Register(name string, timeout time.Duration, checker Checker) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := &check{name: name, timeout: timeout, checker: checker}
+	for i := range r.checks {
+		if r.checks[i].name == name {
+			r.checks[i] = c
+			return
+		}
+	}
+	r.checks = append(r.checks, c)
+}
+
+// SetCacheTTL changes the duration the check results are reused for, see Config.CacheTTL.
+func (r *Registry) SetCacheTTL(ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cacheTTL = ttl
+}
+
+// SetReady marks the application ready or not ready for serving traffic.
+func (r *Registry) SetReady(ready bool) { r.notReady.Store(!ready) }
+
+// Ready tells whether the application is ready for serving traffic.
+func (r *Registry) Ready() bool { return !r.notReady.Load() }
+
+// Check runs all checks concurrently, each limited by its timeout. The results younger than the cache TTL are
+// reused instead.
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make([]*check, len(r.checks))
+	copy(checks, r.checks)
+	ttl := r.cacheTTL
+	r.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	results := make([]Result, len(checks))
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.result(ctx, ttl, r.now)
+		}(i, c)
+	}
+
+	wg.Wait()
+	return Report{Results: results}
+}
+
+// result returns the cached result if it is younger than the ttl, otherwise runs the check. Concurrent callers wait
+// for the same run, so a burst of probes runs the check once.
+func (c *check) result(ctx context.Context, ttl time.Duration, now func() time.Time) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ttl > 0 && !c.last.CheckedAt.IsZero() && now().Sub(c.last.CheckedAt) < ttl {
+		return c.last
+	}
+
+	start := now()
+	err := c.run(ctx)
+	res := Result{
+		Name:      c.name,
+		Status:    statusOf(err),
+		Err:       err,
+		Latency:   now().Sub(start),
+		CheckedAt: start,
+	}
+
+	// the failure caused by the canceled probe request isn't the component's fault, so it isn't cached.
+	if ctx.Err() == nil {
+		c.last = res
+	}
+	return res
+}
+
+// statusOf returns the status reported by the error of a check.
+func statusOf(err error) Status {
+	if err == nil {
+		return StatusHealthy
+	}
+
+	var degraded degradedError
+	if errors.As(err, &degraded) {
+		return StatusDegraded
+	}
+	return StatusUnhealthy
+}
+
+// run runs the checker with the timeout, a panic is reported as the error so a faulty checker can't crash the probe.
+func (c *check) run(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	// the result is abandoned when the timeout exceeded, for the checkers that ignore the context.
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				done <- fmt.Errorf("healthkit: check panicked: %v", v)
+			}
+		}()
+		done <- c.checker.Check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("healthkit: check timed out: %w", ctx.Err())
+	}
+}
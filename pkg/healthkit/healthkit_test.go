@@ -0,0 +1,140 @@
+package healthkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Check(t *testing.T) {
+	anError := errors.New("an error")
+
+	reg := NewRegistry(Config{})
+	reg.Register("db", 0, CheckerFunc(func(ctx context.Context) error { return nil }))
+	reg.Register("cache", 0, CheckerFunc(func(ctx context.Context) error { return anError }))
+	reg.Register("queue", 0, CheckerFunc(func(ctx context.Context) error { return nil }))
+
+	report := reg.Check(context.Background())
+	expectTrue(t, report.Status() == StatusUnhealthy)
+	expectTrue(t, len(report.Results) == 3)
+	expectTrue(t, report.Results[0].Name == "db" && report.Results[0].Status == StatusHealthy)
+	expectTrue(t, report.Results[1].Name == "cache" && report.Results[1].Status == StatusUnhealthy)
+	expectTrue(t, errors.Is(report.Results[1].Err, anError))
+	expectTrue(t, report.Results[2].Name == "queue" && report.Results[2].Err == nil)
+	expectTrue(t, !report.Results[0].CheckedAt.IsZero())
+
+	// replaces the check with the same name.
+	reg.Register("cache", 0, CheckerFunc(func(ctx context.Context) error { return nil }))
+	report = reg.Check(context.Background())
+	expectTrue(t, report.Status() == StatusHealthy)
+	expectTrue(t, len(report.Results) == 3)
+}
+
+func TestRegistry_CheckDegraded(t *testing.T) {
+	anError := errors.New("an error")
+
+	reg := NewRegistry(Config{})
+	reg.Register("db", 0, CheckerFunc(func(ctx context.Context) error { return nil }))
+	reg.Register("replica", 0, CheckerFunc(func(ctx context.Context) error { return Degraded(anError) }))
+	reg.Register("search", 0, NonCritical(CheckerFunc(func(ctx context.Context) error { return anError })))
+	reg.Register("mail", 0, NonCritical(CheckerFunc(func(ctx context.Context) error { return nil })))
+
+	report := reg.Check(context.Background())
+	expectTrue(t, report.Status() == StatusDegraded)
+	expectTrue(t, report.Results[1].Status == StatusDegraded)
+	expectTrue(t, errors.Is(report.Results[1].Err, anError))
+	expectTrue(t, report.Results[2].Status == StatusDegraded)
+	expectTrue(t, report.Results[3].Status == StatusHealthy)
+	expectTrue(t, Degraded(nil) == nil)
+}
+
+func TestRegistry_CheckCache(t *testing.T) {
+	now := time.Now()
+	calls := 0
+
+	reg := NewRegistry(Config{CacheTTL: time.Second})
+	reg.now = func() time.Time { return now }
+	reg.Register("db", 0, CheckerFunc(func(ctx context.Context) error {
+		calls++
+		return nil
+	}))
+
+	reg.Check(context.Background())
+	reg.Check(context.Background())
+	expectTrue(t, calls == 1)
+
+	now = now.Add(time.Second)
+	report := reg.Check(context.Background())
+	expectTrue(t, calls == 2)
+	expectTrue(t, report.Results[0].CheckedAt.Equal(now))
+
+	reg.SetCacheTTL(0)
+	reg.Check(context.Background())
+	expectTrue(t, calls == 3)
+}
+
+func TestRegistry_CheckCanceledNotCached(t *testing.T) {
+	reg := NewRegistry(Config{CacheTTL: time.Minute})
+	reg.Register("db", 0, CheckerFunc(func(ctx context.Context) error { return ctx.Err() }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := reg.Check(ctx)
+	expectTrue(t, report.Status() == StatusUnhealthy)
+
+	report = reg.Check(context.Background())
+	expectTrue(t, report.Status() == StatusHealthy)
+}
+
+func TestRegistry_CheckTimeout(t *testing.T) {
+	reg := NewRegistry(Config{})
+	reg.Register("respects context", 10*time.Millisecond, CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	reg.Register("ignores context", 10*time.Millisecond, CheckerFunc(func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}))
+
+	start := time.Now()
+	report := reg.Check(context.Background())
+	expectTrue(t, time.Since(start) < 500*time.Millisecond)
+	expectTrue(t, errors.Is(report.Results[0].Err, context.DeadlineExceeded))
+	expectTrue(t, errors.Is(report.Results[1].Err, context.DeadlineExceeded))
+	expectTrue(t, report.Results[1].Latency >= 10*time.Millisecond)
+}
+
+func TestRegistry_CheckPanic(t *testing.T) {
+	reg := NewRegistry(Config{})
+	reg.Register("faulty", 0, CheckerFunc(func(ctx context.Context) error { panic("boom") }))
+
+	report := reg.Check(context.Background())
+	expectTrue(t, report.Results[0].Status == StatusUnhealthy)
+}
+
+func TestRegistry_Ready(t *testing.T) {
+	reg := NewRegistry(Config{})
+	expectTrue(t, reg.Ready())
+
+	reg.SetReady(false)
+	expectTrue(t, !reg.Ready())
+
+	reg.SetReady(true)
+	expectTrue(t, reg.Ready())
+}
+
+func TestStatus_String(t *testing.T) {
+	expectTrue(t, StatusHealthy.String() == "healthy")
+	expectTrue(t, StatusDegraded.String() == "degraded")
+	expectTrue(t, StatusUnhealthy.String() == "unhealthy")
+	expectTrue(t, Status(99).String() == "unknown")
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
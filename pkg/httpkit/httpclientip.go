@@ -0,0 +1,174 @@
+package httpkit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// clientIPKey is the context key for the resolved client IP.
+type clientIPKey struct{}
+
+// WithClientIP returns a copy of the context carrying the client IP.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIPFromContext gets the client IP from the context, if not found, it returns an empty string.
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// ClientIP returns the IP of the client. The IP resolved by the ResolveClientIP middleware is preferred, otherwise
+// the IP of the peer is returned, and if the peer address is not an IP, the remote address is returned as it is.
+func ClientIP(r *http.Request) string {
+	if ip := ClientIPFromContext(r.Context()); ip != "" {
+		return ip
+	}
+
+	if addr, ok := peerAddr(r); ok {
+		return addr.String()
+	}
+	return r.RemoteAddr
+}
+
+// ParseTrustedProxies parses the list of CIDRs or IPs of the trusted proxies, an IP is treated as a single address
+// prefix, e.g. "10.0.0.1" equals to "10.0.0.1/32".
+func ParseTrustedProxies(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, s := range cidrs {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, fmt.Errorf("httpkit: parse trusted proxy %q: %w", s, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("httpkit: parse trusted proxy %q: %w", s, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// ResolveClientIP is a middleware that resolves the real IP of the client and stores it in the request context,
+// see ClientIP and ClientIPFromContext.
+//
+// The forwarding headers are only considered when the peer is one of the trusted proxies, otherwise anyone could
+// spoof their IP. The headers are considered in order of Forwarded, X-Forwarded-For and X-Real-IP. The forwarded
+// chain is walked from the nearest hop and the first address that is not a trusted proxy is the client IP.
+func ResolveClientIP(trusted []netip.Prefix) NetMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := resolveClientIP(r, trusted); ip != "" {
+				r = r.WithContext(WithClientIP(r.Context(), ip))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func resolveClientIP(r *http.Request, trusted []netip.Prefix) string {
+	peer, ok := peerAddr(r)
+	if !ok {
+		return ""
+	}
+
+	if !isTrustedProxy(peer, trusted) {
+		return peer.String()
+	}
+
+	var hops []string
+	switch {
+	case r.Header.Get("Forwarded") != "":
+		hops = forwardedFor(r.Header.Values("Forwarded"))
+	case r.Header.Get("X-Forwarded-For") != "":
+		for _, v := range r.Header.Values("X-Forwarded-For") {
+			hops = append(hops, strings.Split(v, ",")...)
+		}
+	case r.Header.Get("X-Real-IP") != "":
+		hops = []string{r.Header.Get("X-Real-IP")}
+	}
+
+	// walk from the nearest hop, an invalid hop can't be trusted, so the last valid hop is used.
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseHopAddr(hops[i])
+		if !ok {
+			break
+		}
+
+		client = addr
+		if !isTrustedProxy(addr, trusted) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// peerAddr returns the IP address of the peer.
+func peerAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+func isTrustedProxy(addr netip.Addr, trusted []netip.Prefix) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedFor extracts the "for" parameters from the Forwarded header values as defined in RFC 7239.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, elem := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(elem, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hops = append(hops, strings.Trim(val, `"`))
+				}
+			}
+		}
+	}
+	return hops
+}
+
+// parseHopAddr parses the address of a forwarded hop which may contain a port, e.g. "192.0.2.1:8080" or
+// "[2001:db8::1]:8080". Obfuscated identifiers and "unknown" are not valid.
+func parseHopAddr(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
@@ -0,0 +1,101 @@
+package httpkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolveClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "::1"})
+	expectTrue(t, err == nil)
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:   "untrusted peer ignores the headers",
+			remote: "203.0.113.1:1234",
+			headers: map[string]string{
+				"X-Forwarded-For": "198.51.100.1",
+			},
+			want: "203.0.113.1",
+		},
+		{
+			name:    "trusted peer without headers",
+			remote:  "10.0.0.1:1234",
+			headers: nil,
+			want:    "10.0.0.1",
+		},
+		{
+			name:   "x-forwarded-for skips the trusted hops",
+			remote: "10.0.0.1:1234",
+			headers: map[string]string{
+				"X-Forwarded-For": "1.1.1.1, 198.51.100.1, 10.0.0.2",
+			},
+			want: "198.51.100.1",
+		},
+		{
+			name:   "forwarded takes precedence",
+			remote: "[::1]:1234",
+			headers: map[string]string{
+				"Forwarded":       `for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"`,
+				"X-Forwarded-For": "198.51.100.1",
+			},
+			want: "2001:db8:cafe::17",
+		},
+		{
+			name:   "x-real-ip",
+			remote: "10.0.0.1:1234",
+			headers: map[string]string{
+				"X-Real-IP": "198.51.100.1",
+			},
+			want: "198.51.100.1",
+		},
+		{
+			name:   "invalid hop stops the walk",
+			remote: "10.0.0.1:1234",
+			headers: map[string]string{
+				"X-Forwarded-For": "198.51.100.1, unknown, 10.0.0.2",
+			},
+			want: "10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := ResolveClientIP(trusted).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			expectTrue(t, got == tt.want)
+		})
+	}
+}
+
+func TestClientIP_WithoutMiddleware(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "[::ffff:192.0.2.1]:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	expectTrue(t, ClientIP(req) == "192.0.2.1")
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	_, err := ParseTrustedProxies([]string{"not-an-ip"})
+	expectTrue(t, err != nil)
+
+	prefixes, err := ParseTrustedProxies([]string{"", "192.168.1.7/16"})
+	expectTrue(t, err == nil)
+	expectTrue(t, len(prefixes) == 1)
+	expectTrue(t, prefixes[0].String() == "192.168.0.0/16")
+}
@@ -0,0 +1,81 @@
+package httpkit
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CoalesceKeyFunc returns the key that identifies identical requests.
+type CoalesceKeyFunc func(r *http.Request) string
+
+// CoalesceKeyURI identifies identical requests by the method, the request URI and the Accept header.
+func CoalesceKeyURI(r *http.Request) string {
+	return r.Method + " " + r.URL.RequestURI() + " " + r.Header.Get("Accept")
+}
+
+// CoalesceRequests is an opt-in middleware that collapses concurrent identical requests into a single handler
+// execution, the buffered response is fanned out to all of them. It protects expensive read endpoints from
+// stampedes, so it must only be applied to safe methods like GET. If nil key is given, CoalesceKeyURI is used.
+//
+// The key must include everything that varies the response, e.g. the user ID for user specific responses,
+// otherwise the response is leaked to other users. The handler runs with a context that is not canceled when the
+// first client goes away, since the others are still waiting. Streaming responses are not supported.
+func CoalesceRequests(key CoalesceKeyFunc) MuxMiddleware {
+	if key == nil {
+		key = CoalesceKeyURI
+	}
+
+	var group singleflight.Group
+	return func(next Handler) Handler {
+		return HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			v, err, _ := group.Do(key(r), func() (any, error) {
+				buf := bufferedResponse{header: make(http.Header)}
+				err := next.ServeHTTP(&buf, r.WithContext(context.WithoutCancel(r.Context())))
+				return &buf, err
+			})
+
+			buf := v.(*bufferedResponse)
+			if buf.code == 0 && buf.body.Len() == 0 && err != nil {
+				// nothing is written, let the error handling chain reply.
+				return err
+			}
+
+			h := w.Header()
+			for k, vv := range buf.header {
+				h[k] = append([]string(nil), vv...)
+			}
+			if buf.code != 0 {
+				w.WriteHeader(buf.code)
+			}
+			if _, wErr := w.Write(buf.body.Bytes()); wErr != nil && err == nil {
+				err = wErr
+			}
+			return err
+		})
+	}
+}
+
+// bufferedResponse is an http.ResponseWriter that buffers the response, so it can be shared.
+type bufferedResponse struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	if b.code == 0 {
+		b.code = code
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.code == 0 {
+		b.code = http.StatusOK
+	}
+	return b.body.Write(p)
+}
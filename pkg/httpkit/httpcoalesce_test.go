@@ -0,0 +1,66 @@
+package httpkit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalesceRequests(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := CoalesceRequests(nil).Then(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		calls.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusAccepted)
+		_, err := w.Write([]byte("expensive"))
+		return err
+	}))
+
+	const n = 5
+	var (
+		wg      sync.WaitGroup
+		started sync.WaitGroup
+		recs    [n]*httptest.ResponseRecorder
+	)
+
+	started.Add(n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		recs[i] = httptest.NewRecorder()
+		go func(rec *httptest.ResponseRecorder) {
+			defer wg.Done()
+			started.Done()
+			_ = handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports?year=2024", nil))
+		}(recs[i])
+	}
+
+	// give all requests the time to join the in-flight execution.
+	started.Wait()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	expectTrue(t, calls.Load() == 1)
+	for _, rec := range recs {
+		expectTrue(t, rec.Code == http.StatusAccepted)
+		expectTrue(t, rec.Body.String() == "expensive")
+		expectTrue(t, rec.Header().Get("Content-Type") == "text/plain")
+	}
+}
+
+func TestCoalesceRequests_Error(t *testing.T) {
+	handler := CoalesceRequests(nil).Then(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("an error")
+	}))
+
+	rec := httptest.NewRecorder()
+	err := handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	expectTrue(t, err != nil)
+	expectTrue(t, rec.Body.Len() == 0)
+}
@@ -2,8 +2,12 @@ package httpkit
 
 import (
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"io"
 	"net/http"
+
+	"google.golang.org/protobuf/proto"
 )
 
 // ReadJSON reads json from the reader and decodes it to the data.
@@ -21,6 +25,44 @@ func WriteJSON(w http.ResponseWriter, data any, code int) error {
 	return json.NewEncoder(w).Encode(data)
 }
 
+// ReadXML reads xml from the reader and decodes it to the data.
+// The decoder runs in the strict mode of encoding/xml, so malformed documents (e.g. unmatched tags or unknown
+// entities) are rejected. Unlike ReadJSON, the unknown elements and attributes are ignored, since encoding/xml can't
+// disallow them.
+func ReadXML(r io.Reader, data any) error {
+	dec := xml.NewDecoder(r)
+	dec.Strict = true
+	return dec.Decode(data)
+}
+
+// WriteXML writes the data to the response writer as XML.
+// By default, it sets the content type to application/xml; charset=utf-8.
+func WriteXML(w http.ResponseWriter, data any, code int) error {
+	writeContentTypeAndStatus(w, contentTypeApplicationXMLCharsetUTF8, code)
+	return xml.NewEncoder(w).Encode(data)
+}
+
+// ReadProto reads protobuf wire-format bytes from the reader and decodes it to the message.
+func ReadProto(r io.Reader, m proto.Message) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read proto: %w", err)
+	}
+	return proto.Unmarshal(b, m)
+}
+
+// WriteProto writes the message to the response writer in protobuf wire-format.
+// By default, it sets the content type to application/x-protobuf.
+func WriteProto(w http.ResponseWriter, m proto.Message, code int) error {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("marshal proto: %w", err)
+	}
+	writeContentTypeAndStatus(w, contentTypeApplicationProtobuf, code)
+	_, err = w.Write(b)
+	return err
+}
+
 // writeContentTypeAndStatus writes the content type and status code to the response writer.
 func writeContentTypeAndStatus(w http.ResponseWriter, value string, code int) {
 	w.Header().Add("Content-Type", value)
@@ -32,4 +74,9 @@ const (
 	charsetUTF8                           = "charset=UTF-8"
 	contextTypeApplicationJSON            = "application/json"
 	contentTypeApplicationJSONCharsetUTF8 = contextTypeApplicationJSON + "; " + charsetUTF8
+	contentTypeApplicationXML             = "application/xml"
+	contentTypeApplicationXMLCharsetUTF8  = contentTypeApplicationXML + "; " + charsetUTF8
+	contentTypeApplicationMsgpack         = "application/msgpack"
+	contentTypeApplicationProtobuf        = "application/x-protobuf"
+	contentTypeApplicationFormURLEncoded  = "application/x-www-form-urlencoded"
 )
@@ -1,9 +1,13 @@
 package httpkit
 
 import (
+	"bytes"
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestReadJSON(t *testing.T) {
@@ -36,3 +40,61 @@ func TestWriteJSON(t *testing.T) {
 	expectTrue(t, body == "{\"name\":\"John Doe\"}\n")
 
 }
+
+func TestReadXML(t *testing.T) {
+	var data struct {
+		Name string `xml:"name"`
+	}
+
+	err := ReadXML(strings.NewReader(`<user><name>John Doe</name></user>`), &data)
+	expectTrue(t, err == nil)
+	expectTrue(t, data.Name == "John Doe")
+
+	err = ReadXML(strings.NewReader(`<user><name>John Doe</user>`), &data)
+	expectTrue(t, err != nil) // unmatched tag.
+
+	// the unknown elements and attributes are ignored.
+	err = ReadXML(strings.NewReader(`<user role="admin"><name>Jane Doe</name><age>42</age></user>`), &data)
+	expectTrue(t, err == nil)
+	expectTrue(t, data.Name == "Jane Doe")
+}
+
+func TestWriteXML(t *testing.T) {
+	type user struct {
+		Name string `xml:"name"`
+	}
+
+	rec := httptest.NewRecorder()
+	err := WriteXML(rec, user{Name: "John Doe"}, 200)
+	expectTrue(t, err == nil)
+	expectTrue(t, rec.Code == 200)
+	expectTrue(t, rec.Header().Get("Content-Type") == contentTypeApplicationXMLCharsetUTF8)
+
+	body := rec.Body.String()
+	expectTrue(t, body == "<user><name>John Doe</name></user>")
+}
+
+func TestReadProto(t *testing.T) {
+	raw, err := proto.Marshal(wrapperspb.String("John Doe"))
+	expectTrue(t, err == nil)
+
+	var msg wrapperspb.StringValue
+	err = ReadProto(bytes.NewReader(raw), &msg)
+	expectTrue(t, err == nil)
+	expectTrue(t, msg.GetValue() == "John Doe")
+
+	err = ReadProto(strings.NewReader("\xff\xff"), &msg)
+	expectTrue(t, err != nil) // invalid wire-format.
+}
+
+func TestWriteProto(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := WriteProto(rec, wrapperspb.String("John Doe"), 200)
+	expectTrue(t, err == nil)
+	expectTrue(t, rec.Code == 200)
+	expectTrue(t, rec.Header().Get("Content-Type") == contentTypeApplicationProtobuf)
+
+	var msg wrapperspb.StringValue
+	expectTrue(t, proto.Unmarshal(rec.Body.Bytes(), &msg) == nil)
+	expectTrue(t, msg.GetValue() == "John Doe")
+}
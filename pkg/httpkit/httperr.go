@@ -1,5 +1,14 @@
 package httpkit
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/josestg/problemdetail"
+)
+
 // ResolvedError is an error that has been resolved.
 // When an error is resolved, it means that the error has been mapped to an HTTP response and the no error will be
 // handled by the LastResortErrorHandler.
@@ -14,3 +23,71 @@ func ResolveError(err error) error {
 
 // Error implements error interface.
 func (e *ResolvedError) Error() string { return e.Err.Error() }
+
+// HTTPError is an error that describes its own HTTP response, so the error handling middleware can reply with it
+// without knowing the error in advance, e.g. the errors of the domain packages.
+type HTTPError interface {
+	error
+
+	// Status returns the HTTP status code of the response.
+	Status() int
+
+	// Problem returns the Problem Details of the response body.
+	Problem() problemdetail.ProblemDetailer
+}
+
+// PanicError is the error recovered from a panicking handler.
+type PanicError struct {
+	Value any    // the value passed to panic.
+	Stack []byte // the stack trace of the panicking goroutine.
+}
+
+// Error implements error interface.
+func (e *PanicError) Error() string { return fmt.Sprintf("panic: %v", e.Value) }
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// ErrorReporter knows how to report errors to an error tracking service, e.g. Sentry or Rollbar.
+type ErrorReporter interface {
+	// Report reports the error of the request. It is called synchronously, so it should not block.
+	// The request carries the request ID in its context, and except for the recovered panics, the matched route,
+	// see RequestIDFromContext and RouteFromContext.
+	Report(ctx context.Context, r *http.Request, err error)
+}
+
+// ErrorReporterFunc is a function that implements ErrorReporter.
+type ErrorReporterFunc func(ctx context.Context, r *http.Request, err error)
+
+// Report implements ErrorReporter.
+func (f ErrorReporterFunc) Report(ctx context.Context, r *http.Request, err error) { f(ctx, r, err) }
+
+// errorReporterKey is the context key for the ErrorReporter.
+type errorReporterKey struct{}
+
+// ReportErrors is a middleware that makes the reporter available for all ServeMux behind it, so the unresolved errors
+// and the recovered panics are reported without configuring every ServeMux. The reporter set by Opts.ErrorReporter
+// takes precedence.
+func ReportErrors(reporter ErrorReporter) NetMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), errorReporterKey{}, reporter)))
+		})
+	}
+}
+
+// ReportError reports the error to the reporter of the request, if any. Resolved errors are not reported.
+// It is used by the error handling middlewares that swallow the unresolved errors after logging them.
+func ReportError(r *http.Request, err error) {
+	var resolvedErr *ResolvedError
+	if err == nil || errors.As(err, &resolvedErr) {
+		return
+	}
+
+	if reporter, ok := r.Context().Value(errorReporterKey{}).(ErrorReporter); ok && reporter != nil {
+		reporter.Report(r.Context(), r, err)
+	}
+}
@@ -0,0 +1,156 @@
+package httpkit
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrNotForm is returned by ReadForm when the request body is not application/x-www-form-urlencoded.
+var ErrNotForm = errors.New("httpkit: request is not application/x-www-form-urlencoded")
+
+// ReadForm reads the application/x-www-form-urlencoded request body and decodes it to the data, which must be a
+// pointer to a struct.
+//
+// The field names follow the same convention as ReadJSON: the `json` tag name is used when present (a "-" tag
+// skips the field), otherwise the field name is used. Like ReadJSON, unknown fields are disallowed. Query string
+// values are not considered.
+//
+// Supported field types are string, bool, integers, floats, encoding.TextUnmarshaler, pointers to them and slices
+// of them for repeated keys.
+func ReadForm(r *http.Request, data any) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != contentTypeApplicationFormURLEncoded {
+		return ErrNotForm
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("parse form: %w", err)
+	}
+	return DecodeForm(r.PostForm, data)
+}
+
+// DecodeForm decodes the values to the data using the same rules as ReadForm.
+func DecodeForm(values url.Values, data any) error {
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("httpkit: DecodeForm: data must be a non-nil pointer to struct, got %T", data)
+	}
+
+	fields := make(map[string]reflect.Value)
+	collectFormFields(rv.Elem(), fields)
+
+	for key, vals := range values {
+		field, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("form: unknown field %q", key)
+		}
+
+		if err := setFormField(field, vals); err != nil {
+			return fmt.Errorf("form: field %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// collectFormFields collects the settable fields of the struct by their names, embedded structs are flattened.
+func collectFormFields(v reflect.Value, fields map[string]reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// like encoding/json, exported fields of embedded structs are promoted even if the struct is unexported.
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+			collectFormFields(v.Field(i), fields)
+			continue
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		fields[name] = v.Field(i)
+	}
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setFormField sets the values to the field.
+func setFormField(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && !field.Type().Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := setFormValue(slice.Index(i), v); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	// like url.Values.Get, the first value is used for non-slice fields.
+	return setFormValue(field, values[0])
+}
+
+// setFormValue sets a single value to the field.
+func setFormValue(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Pointer {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setFormValue(ptr.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	if field.CanAddr() {
+		if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(value))
+		}
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package httpkit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type formAudit struct {
+	Note string `json:"note"`
+}
+
+type formData struct {
+	formAudit
+	Name     string     `json:"name"`
+	Age      int        `json:"age,omitempty"`
+	Active   bool       `json:"active"`
+	Score    *float64   `json:"score"`
+	Tags     []string   `json:"tags"`
+	Born     time.Time  `json:"born"`
+	Internal string     `json:"-"`
+	Untagged uint8      `json:""`
+	Ignored  chan error `json:"ignored"`
+}
+
+func TestReadForm(t *testing.T) {
+	form := url.Values{
+		"note":     {"hello"},
+		"name":     {"John Doe"},
+		"age":      {"20"},
+		"active":   {"true"},
+		"score":    {"9.5"},
+		"tags":     {"a", "b"},
+		"born":     {"2000-01-02T00:00:00Z"},
+		"Untagged": {"7"},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/?name=query", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	var data formData
+	err := ReadForm(req, &data)
+	expectTrue(t, err == nil)
+	expectTrue(t, data.Note == "hello")
+	expectTrue(t, data.Name == "John Doe")
+	expectTrue(t, data.Age == 20)
+	expectTrue(t, data.Active)
+	expectTrue(t, data.Score != nil && *data.Score == 9.5)
+	expectTrue(t, strings.Join(data.Tags, ",") == "a,b")
+	expectTrue(t, data.Born.Equal(time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)))
+	expectTrue(t, data.Untagged == 7)
+}
+
+func TestReadForm_Errors(t *testing.T) {
+	newReq := func(contentType, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+
+	var data formData
+	err := ReadForm(newReq("application/json", "{}"), &data)
+	expectTrue(t, errors.Is(err, ErrNotForm))
+
+	err = ReadForm(newReq(contentTypeApplicationFormURLEncoded, "unknown=1"), &data)
+	expectTrue(t, err != nil)
+
+	err = ReadForm(newReq(contentTypeApplicationFormURLEncoded, "Internal=1"), &data)
+	expectTrue(t, err != nil) // skipped field is unknown.
+
+	err = ReadForm(newReq(contentTypeApplicationFormURLEncoded, "age=abc"), &data)
+	expectTrue(t, err != nil)
+
+	err = ReadForm(newReq(contentTypeApplicationFormURLEncoded, "ignored=abc"), &data)
+	expectTrue(t, err != nil) // unsupported type.
+
+	err = ReadForm(newReq(contentTypeApplicationFormURLEncoded, "name=abc"), data)
+	expectTrue(t, err != nil) // not a pointer.
+}
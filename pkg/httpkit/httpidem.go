@@ -0,0 +1,272 @@
+package httpkit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Set of headers for idempotent requests.
+const (
+	// HeaderIdempotencyKey is the request header for carrying the idempotency key generated by the client.
+	HeaderIdempotencyKey = "Idempotency-Key"
+
+	// HeaderIdempotentReplayed is the response header that tells the response is replayed.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// Set of errors returned by Idempotency.
+var (
+	// ErrIdempotencyKeyInUse is returned when another request with the same key is still in progress, it should be
+	// mapped to 409 Conflict.
+	ErrIdempotencyKeyInUse = errors.New("httpkit: idempotency key in use")
+
+	// ErrIdempotencyKeyReused is returned when the key is reused with a different request, it should be mapped to
+	// 422 Unprocessable Entity.
+	ErrIdempotencyKeyReused = errors.New("httpkit: idempotency key reused with different request")
+)
+
+// IdempotentResponse is the stored response of an idempotent request.
+type IdempotentResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// IdempotencyStore knows how to store the responses of idempotent requests.
+type IdempotencyStore interface {
+	// Begin reserves the key for the request identified by the fingerprint. When the key has completed, the stored
+	// response is returned. It returns ErrIdempotencyKeyInUse when the key is reserved by another in-flight request
+	// and ErrIdempotencyKeyReused when the fingerprint does not match.
+	Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotentResponse, error)
+
+	// Complete stores the response of the reserved key, the response is kept until the ttl expires.
+	Complete(ctx context.Context, key string, res IdempotentResponse, ttl time.Duration) error
+
+	// Release abandons the reservation, so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyScopeFunc returns the scope of the idempotency keys of the request, e.g. the authenticated user ID, so
+// the clients can't replay the responses of each other by guessing their keys.
+type IdempotencyScopeFunc func(r *http.Request) string
+
+// IdempotencyScopeClientIP scopes the idempotency keys by the client IP, see ClientIP.
+func IdempotencyScopeClientIP(r *http.Request) string { return "ip:" + ClientIP(r) }
+
+// IdempotencyConfig is the configuration for Idempotency.
+type IdempotencyConfig struct {
+	// Store stores the responses, it is required.
+	Store IdempotencyStore
+
+	// TTL is how long the responses are kept. Default 24 hours.
+	TTL time.Duration
+
+	// Scope scopes the keys, it should identify the authenticated principal so the middleware must be placed after
+	// the authentication. Default IdempotencyScopeClientIP.
+	Scope IdempotencyScopeFunc
+
+	// MaxBodyBytes is the maximum size of the request body read for the fingerprint, the larger requests are rejected
+	// with *http.MaxBytesError. Default 1 MiB.
+	MaxBodyBytes int64
+}
+
+// Idempotency is a middleware that makes the request carrying the Idempotency-Key header safe to be retried, e.g.
+// for payment-like POST endpoints. The first request is served and its response is stored for the ttl, retries with
+// the same key in the same scope get the stored response replayed with the Idempotent-Replayed header. Requests
+// without the header are served as usual.
+//
+// The response is captured from the LogEntry, so the LogEntryRecorder must be installed and the response body must
+// neither be discarded nor truncated, the request body may be. Responses are only stored when the handler succeeds
+// with a non-5xx status, otherwise the key is released so the client can retry. The Set-Cookie headers are never
+// stored.
+func Idempotency(cfg IdempotencyConfig) MuxMiddleware {
+	if cfg.Store == nil {
+		panic("httpkit: Idempotency: Store is required")
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if cfg.Scope == nil {
+		cfg.Scope = IdempotencyScopeClientIP
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 1 << 20
+	}
+
+	return func(next Handler) Handler {
+		return HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			idemKey := r.Header.Get(HeaderIdempotencyKey)
+			if idemKey == "" {
+				return next.ServeHTTP(w, r)
+			}
+
+			entry, ok := GetLogEntry(w)
+			if !ok {
+				return fmt.Errorf("idempotency: missing log entry: method=%s path=%s", r.Method, r.URL.Path)
+			}
+
+			fingerprint, err := fingerprintRequest(r, cfg.MaxBodyBytes)
+			if err != nil {
+				return fmt.Errorf("idempotency: %w", err)
+			}
+
+			key := cfg.Scope(r) + " " + r.Method + " " + r.URL.Path + " " + idemKey
+			stored, err := cfg.Store.Begin(r.Context(), key, fingerprint, cfg.TTL)
+			if err != nil {
+				return fmt.Errorf("idempotency: begin %q: %w", idemKey, err)
+			}
+
+			if stored != nil {
+				return replayResponse(w, stored)
+			}
+
+			if err := next.ServeHTTP(w, r); err != nil {
+				return errors.Join(err, releaseKey(r.Context(), cfg.Store, key))
+			}
+
+			status := entry.StatusCode
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			if status >= http.StatusInternalServerError || entry.DiscardResBody || entry.ResponseTruncated {
+				return releaseKey(r.Context(), cfg.Store, key)
+			}
+
+			header := w.Header().Clone()
+			header.Del("Set-Cookie")
+			res := IdempotentResponse{
+				StatusCode: status,
+				Header:     header,
+				Body:       bytes.Clone(entry.ResBody().Bytes()),
+			}
+
+			if err := cfg.Store.Complete(r.Context(), key, res, cfg.TTL); err != nil {
+				// the response has been sent, the error can only be logged.
+				return ResolveError(fmt.Errorf("idempotency: complete %q: %w", idemKey, err))
+			}
+			return nil
+		})
+	}
+}
+
+// fingerprintRequest digests the request body up to the limit, the body is buffered so the handler can still read
+// it. It returns *http.MaxBytesError if the body exceeds the limit.
+func fingerprintRequest(r *http.Request, limit int64) (string, error) {
+	if r.ContentLength > limit {
+		return "", &http.MaxBytesError{Limit: limit}
+	}
+
+	h := sha256.New()
+	if r.Body != nil {
+		b, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+		if err != nil {
+			return "", fmt.Errorf("read body: %w", err)
+		}
+		if int64(len(b)) > limit {
+			return "", &http.MaxBytesError{Limit: limit}
+		}
+		_, _ = h.Write(b)
+		r.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func replayResponse(w http.ResponseWriter, res *IdempotentResponse) error {
+	h := w.Header()
+	for k, v := range res.Header {
+		h[k] = v
+	}
+	h.Set(HeaderIdempotentReplayed, strconv.FormatBool(true))
+	w.WriteHeader(res.StatusCode)
+	if _, err := w.Write(res.Body); err != nil {
+		return fmt.Errorf("idempotency: replay response: %w", err)
+	}
+	return nil
+}
+
+func releaseKey(ctx context.Context, store IdempotencyStore, key string) error {
+	if err := store.Release(ctx, key); err != nil {
+		return fmt.Errorf("idempotency: release: %w", err)
+	}
+	return nil
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore, it is suitable for tests and single instance deployments.
+// Expired keys are evicted lazily. MemoryIdempotencyStore is concurrent-safe.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]idempotencyEntry
+	sweptAt time.Time
+}
+
+type idempotencyEntry struct {
+	fingerprint string
+	res         *IdempotentResponse // nil while the request is in-flight.
+	expiresAt   time.Time
+}
+
+// NewMemoryIdempotencyStore creates a new MemoryIdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]idempotencyEntry), sweptAt: time.Now()}
+}
+
+// Begin implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Begin(_ context.Context, key, fingerprint string, ttl time.Duration) (*IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.sweptAt) > time.Minute {
+		for k, e := range s.entries {
+			if now.After(e.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.sweptAt = now
+	}
+
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.expiresAt) {
+		s.entries[key] = idempotencyEntry{fingerprint: fingerprint, expiresAt: now.Add(ttl)}
+		return nil, nil
+	}
+
+	if entry.fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	if entry.res == nil {
+		return nil, ErrIdempotencyKeyInUse
+	}
+	return entry.res, nil
+}
+
+// Complete implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key string, res IdempotentResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entries[key]
+	entry.res = &res
+	entry.expiresAt = time.Now().Add(ttl)
+	s.entries[key] = entry
+	return nil
+}
+
+// Release implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
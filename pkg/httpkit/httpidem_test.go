@@ -0,0 +1,121 @@
+package httpkit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	var calls int
+	scope := func(r *http.Request) string { return r.Header.Get("X-User") }
+	cfg := IdempotencyConfig{Store: NewMemoryIdempotencyStore(), TTL: time.Hour, Scope: scope, MaxBodyBytes: 16}
+	handler := Idempotency(cfg).Then(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if string(body) == "fail" {
+			return errors.New("an error")
+		}
+		w.Header().Set("X-Charge-ID", "ch_1")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusCreated)
+		_, err := w.Write(body)
+		return err
+	}))
+
+	doAs := func(user, key, body string) (*httptest.ResponseRecorder, error) {
+		var err error
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/charges", strings.NewReader(body))
+		req.Header.Set("X-User", user)
+		if key != "" {
+			req.Header.Set(HeaderIdempotencyKey, key)
+		}
+		LogEntryRecorder(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err = handler.ServeHTTP(w, r)
+		})).ServeHTTP(rec, req)
+		return rec, err
+	}
+	do := func(key, body string) (*httptest.ResponseRecorder, error) { return doAs("alice", key, body) }
+
+	rec, err := do("k1", "amount=10")
+	expectTrue(t, err == nil)
+	expectTrue(t, rec.Code == http.StatusCreated)
+	expectTrue(t, rec.Header().Get(HeaderIdempotentReplayed) == "")
+
+	// the retry is replayed without calling the handler.
+	rec, err = do("k1", "amount=10")
+	expectTrue(t, err == nil)
+	expectTrue(t, calls == 1)
+	expectTrue(t, rec.Code == http.StatusCreated)
+	expectTrue(t, rec.Body.String() == "amount=10")
+	expectTrue(t, rec.Header().Get("X-Charge-ID") == "ch_1")
+	expectTrue(t, rec.Header().Get(HeaderIdempotentReplayed) == "true")
+	expectTrue(t, rec.Header().Get("Set-Cookie") == "")
+
+	// the keys are scoped, so another user with the same key isn't replayed the response.
+	rec, err = doAs("bob", "k1", "amount=10")
+	expectTrue(t, err == nil)
+	expectTrue(t, calls == 2)
+	expectTrue(t, rec.Header().Get(HeaderIdempotentReplayed) == "")
+
+	var maxBytesErr *http.MaxBytesError
+	_, err = do("k3", strings.Repeat("x", 17))
+	expectTrue(t, errors.As(err, &maxBytesErr))
+
+	_, err = do("k1", "amount=20")
+	expectTrue(t, errors.Is(err, ErrIdempotencyKeyReused))
+
+	// failed requests release the key.
+	_, err = do("k2", "fail")
+	expectTrue(t, err != nil)
+	_, err = do("k2", "fail")
+	expectTrue(t, err != nil && !errors.Is(err, ErrIdempotencyKeyInUse))
+	expectTrue(t, calls == 4)
+
+	// requests without the key are not deduplicated.
+	_, _ = do("", "amount=10")
+	_, _ = do("", "amount=10")
+	expectTrue(t, calls == 6)
+}
+
+func TestIdempotency_DefaultTTL(t *testing.T) {
+	var calls int
+	handler := Idempotency(IdempotencyConfig{Store: NewMemoryIdempotencyStore()}).Then(HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) error {
+			calls++
+			w.WriteHeader(http.StatusCreated)
+			return nil
+		},
+	))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/charges", strings.NewReader("amount=10"))
+		req.Header.Set(HeaderIdempotencyKey, "k1")
+		LogEntryRecorder(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			expectTrue(t, handler.ServeHTTP(w, r) == nil)
+		})).ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// the zero TTL defaults, so the retry is replayed instead of served twice.
+	expectTrue(t, calls == 1)
+}
+
+func TestIdempotency_MissingStore(t *testing.T) {
+	defer func() { expectTrue(t, recover() != nil) }()
+	Idempotency(IdempotencyConfig{})
+}
+
+func TestMemoryIdempotencyStore_InUse(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	res, err := store.Begin(context.Background(), "k", "f", time.Hour)
+	expectTrue(t, res == nil && err == nil)
+
+	_, err = store.Begin(context.Background(), "k", "f", time.Hour)
+	expectTrue(t, errors.Is(err, ErrIdempotencyKeyInUse))
+}
@@ -0,0 +1,18 @@
+package httpkit
+
+import (
+	"net/http"
+
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+)
+
+// IDProvider is a middleware that stores the UUID provider in the request context, so the handlers and the services
+// get it by idkit.FromContext instead of a global, and the tests can swap it per request, e.g. by
+// idkit.NewSequentialUUIDProvider.
+func IDProvider(provider idkit.UUIDProvider) NetMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(idkit.WithProvider(r.Context(), provider)))
+		})
+	}
+}
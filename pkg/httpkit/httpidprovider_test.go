@@ -0,0 +1,19 @@
+package httpkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+)
+
+func TestIDProvider(t *testing.T) {
+	var got idkit.UUIDProvider
+	handler := IDProvider(idkit.UUIDv254).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = idkit.FromContext(r.Context())
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	expectTrue(t, got == idkit.UUIDv254)
+}
@@ -0,0 +1,24 @@
+package httpkit
+
+import "net/http"
+
+// LimitRequestBody is a middleware that limits the size of the request body to the given number of bytes using
+// http.MaxBytesReader. It can be applied globally by Opts.Middleware or per route by ServeMux.Route.
+//
+// When the Content-Length is known to exceed the limit, the handler is not called and *http.MaxBytesError is
+// returned immediately. Otherwise, reading beyond the limit fails with *http.MaxBytesError, which the handler is
+// expected to return (wrapped or not) so the error handling chain can map it to 413 Request Entity Too Large.
+func LimitRequestBody(limit int64) MuxMiddleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			if r.ContentLength > limit {
+				return &http.MaxBytesError{Limit: limit}
+			}
+
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			return next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package httpkit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimitRequestBody(t *testing.T) {
+	handler := LimitRequestBody(8).Then(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var data map[string]string
+		return ReadJSON(r.Body, &data)
+	}))
+
+	var maxBytesErr *http.MaxBytesError
+
+	t.Run("within limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+		err := handler.ServeHTTP(httptest.NewRecorder(), req)
+		expectTrue(t, err == nil)
+	})
+
+	t.Run("content length exceeds limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"John Doe"}`))
+		err := handler.ServeHTTP(httptest.NewRecorder(), req)
+		expectTrue(t, errors.As(err, &maxBytesErr))
+		expectTrue(t, maxBytesErr.Limit == 8)
+	})
+
+	t.Run("unknown content length exceeds limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"John Doe"}`))
+		req.ContentLength = -1
+		err := handler.ServeHTTP(httptest.NewRecorder(), req)
+		expectTrue(t, errors.As(err, &maxBytesErr))
+	})
+}
@@ -1,8 +1,12 @@
 package httpkit
 
 import (
+	"bufio"
 	"io"
+	"mime"
+	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,13 +26,25 @@ type LogEntry struct {
 	RespondedAt int64
 	RequestedAt int64
 
+	// BytesWritten is the number of response body bytes written to the client, regardless of the recording.
+	BytesWritten int64
+
 	// DiscardReqBody and DiscardResBody are used to indicate whether the request
 	// or response body should be discarded. By default, both are false.
 	DiscardReqBody bool
 	DiscardResBody bool
 
-	reqBody *bytebufferpool.ByteBuffer
-	resBody *bytebufferpool.ByteBuffer
+	// RequestTruncated and ResponseTruncated report whether the request or the response body is recorded partially
+	// as it exceeds the LogRecorderConfig.MaxBodyBytes.
+	RequestTruncated  bool
+	ResponseTruncated bool
+
+	// Panic is the recovered panic of the handler, if any.
+	Panic *PanicError
+
+	redaction Redaction
+	reqBody   *bytebufferpool.ByteBuffer
+	resBody   *bytebufferpool.ByteBuffer
 }
 
 // ReqBody returns the request body.
@@ -37,17 +53,68 @@ func (l *LogEntry) ReqBody() LogBodyReader { return l.reqBody }
 // ResBody returns the response body.
 func (l *LogEntry) ResBody() LogBodyReader { return l.resBody }
 
+// RedactedReqBody returns a copy of the request body redacted by the LogRecorderConfig.Redaction.
+func (l *LogEntry) RedactedReqBody() []byte { return l.redaction.Body(l.reqBody.Bytes()) }
+
+// RedactedResBody returns a copy of the response body redacted by the LogRecorderConfig.Redaction.
+func (l *LogEntry) RedactedResBody() []byte { return l.redaction.Body(l.resBody.Bytes()) }
+
+// RedactedHeader returns a copy of the request or response header redacted by the LogRecorderConfig.Redaction.
+func (l *LogEntry) RedactedHeader(h http.Header) http.Header { return l.redaction.Header(h) }
+
+// LogRecorderConfig is the configuration of the LogEntryRecorder.
+type LogRecorderConfig struct {
+	// Redaction is the rules for masking the sensitive values, it is applied when the recorded entry is read by
+	// LogEntry.RedactedReqBody, LogEntry.RedactedResBody and LogEntry.RedactedHeader, so the raw bodies are still
+	// available for the middlewares that replay them, e.g. Idempotency.
+	Redaction Redaction
+
+	// MaxBodyBytes is the maximum number of bytes recorded per request and response body, the rest is not recorded
+	// and LogEntry.RequestTruncated or LogEntry.ResponseTruncated is set. Zero or negative means unlimited.
+	MaxBodyBytes int `env:"MAX_BODY_BYTES,default=65536"`
+
+	// DiscardContentTypes is the media types whose request or response body is not recorded, LogEntry.DiscardReqBody
+	// and LogEntry.DiscardResBody are set by the Content-Type of the request and the response respectively. A media
+	// type may have a wildcard subtype, e.g. image/*. See DefaultDiscardContentTypes.
+	DiscardContentTypes []string `env:"DISCARD_CONTENT_TYPES"`
+}
+
+// DefaultDiscardContentTypes is the media types of the binary and the file upload bodies, which are rarely useful
+// in the logs.
+var DefaultDiscardContentTypes = []string{
+	"multipart/*",
+	"application/octet-stream",
+	"application/pdf",
+	"application/zip",
+	"image/*",
+	"audio/*",
+	"video/*",
+}
+
 // LogEntryRecorder is a middleware that records the request and response on demand.
 // The request body is not recorded until it is read by the handler. And the response
 // body is not recorded until it is written by the handler.
 //
 // The recorded entry can be retrieved by calling GetLogEntry(w http.ResponseWriter).
 func LogEntryRecorder(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rec := newLogEntryRecorder(w, r)
-		defer putLogEntryRecorder(rec)
-		next.ServeHTTP(rec, rec.withRequest(r))
-	})
+	return NewLogEntryRecorder(LogRecorderConfig{})(next)
+}
+
+// NewLogEntryRecorder creates a LogEntryRecorder with the given configuration.
+func NewLogEntryRecorder(cfg LogRecorderConfig) NetMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := newLogEntryRecorder(w, r)
+			defer putLogEntryRecorder(rec)
+			rec.log.redaction = cfg.Redaction
+			rec.maxBody = cfg.MaxBodyBytes
+			rec.discardTypes = cfg.DiscardContentTypes
+			if matchContentType(r.Header.Get("Content-Type"), rec.discardTypes) {
+				rec.log.DiscardReqBody = true
+			}
+			next.ServeHTTP(rec.writer(), rec.withRequest(r))
+		})
+	}
 }
 
 // GetLogEntry gets the recorded LogEntry from the given http.ResponseWriter by unwrapping
@@ -56,8 +123,8 @@ func GetLogEntry(w http.ResponseWriter) (*LogEntry, bool) {
 	rw := w
 	for {
 		switch t := rw.(type) {
-		case *logEntryRecorder:
-			return t.log, true
+		case interface{ logEntry() *LogEntry }:
+			return t.logEntry(), true
 		case interface{ Unwrap() http.ResponseWriter }:
 			rw = t.Unwrap()
 		default:
@@ -78,8 +145,13 @@ func newLogEntryRecorder(w http.ResponseWriter, r *http.Request) *logEntryRecord
 	rec.ResponseWriter = w
 	rec.log.StatusCode = 0
 	rec.log.RespondedAt = 0
+	rec.log.BytesWritten = 0
 	rec.log.DiscardReqBody = false
 	rec.log.DiscardResBody = false
+	rec.log.RequestTruncated = false
+	rec.log.ResponseTruncated = false
+	rec.log.Panic = nil
+	rec.hijacked = false
 	rec.log.reqBody = bytebufferpool.Get()
 	rec.log.resBody = bytebufferpool.Get()
 	rec.log.RequestedAt = time.Now().UnixNano()
@@ -97,23 +169,41 @@ func putLogEntryRecorder(rec *logEntryRecorder) {
 	}
 	rec.req = nil
 	rec.ResponseWriter = nil
+	rec.maxBody = 0
+	rec.discardTypes = nil
 	_recorderPool.Put(rec)
 }
 
 type logEntryRecorder struct {
 	http.ResponseWriter
-	req io.ReadCloser
-	log *LogEntry
+	req      io.ReadCloser
+	log      *LogEntry
+	maxBody  int
+	hijacked bool
+
+	discardTypes []string
 }
 
 func (l *logEntryRecorder) Read(p []byte) (n int, err error) {
 	n, err = l.req.Read(p)
 	if !l.log.DiscardReqBody && n > 0 {
-		n, err = l.log.reqBody.Write(p[:n])
+		l.record(l.log.reqBody, p[:n], &l.log.RequestTruncated)
 	}
 	return
 }
 
+// record appends b to the recorded body up to the maximum body size, the truncated flag is set if it doesn't fit.
+func (l *logEntryRecorder) record(buf *bytebufferpool.ByteBuffer, b []byte, truncated *bool) {
+	if l.maxBody > 0 {
+		remaining := l.maxBody - buf.Len()
+		if len(b) > remaining {
+			b = b[:max(remaining, 0)]
+			*truncated = true
+		}
+	}
+	_, _ = buf.Write(b)
+}
+
 func (l *logEntryRecorder) Close() (err error) {
 	if l.req != nil {
 		// propagate the close to the original request body.
@@ -137,6 +227,10 @@ func (l *logEntryRecorder) WriteHeader(code int) {
 		return
 	}
 
+	if matchContentType(l.Header().Get("Content-Type"), l.discardTypes) {
+		l.log.DiscardResBody = true
+	}
+
 	// delegate to the original ResponseWriter.
 	l.ResponseWriter.WriteHeader(code)
 	l.log.StatusCode = code
@@ -144,16 +238,262 @@ func (l *logEntryRecorder) WriteHeader(code int) {
 }
 
 func (l *logEntryRecorder) Write(b []byte) (int, error) {
+	if l.hijacked {
+		// the connection is owned by the hijacker, nothing can be written through
+		// the ResponseWriter anymore.
+		return 0, http.ErrHijacked
+	}
+
 	if l.log.RespondedAt <= 0 {
 		// if not committed yet, commit it with http.StatusOK as default.
 		l.WriteHeader(http.StatusOK)
 	}
 
 	n, err := l.ResponseWriter.Write(b)
-	if !l.log.DiscardReqBody && err == nil {
-		n, err = l.log.resBody.Write(b[:n])
+	l.log.BytesWritten += int64(n)
+	if !l.log.DiscardResBody && err == nil {
+		l.record(l.log.resBody, b[:n], &l.log.ResponseTruncated)
 	}
 	return n, err
 }
 
+// matchContentType reports whether the media type of the Content-Type value matches any of the patterns.
+func matchContentType(contentType string, patterns []string) bool {
+	if contentType == "" || len(patterns) == 0 {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if pattern == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 func (l *logEntryRecorder) Unwrap() http.ResponseWriter { return l.ResponseWriter }
+
+func (l *logEntryRecorder) logEntry() *LogEntry { return l.log }
+
+// writer returns the recorder as an http.ResponseWriter that implements http.Flusher, http.Hijacker, http.Pusher and
+// io.ReaderFrom only when the original ResponseWriter does, so the handlers that check those interfaces, e.g. SSE,
+// WebSocket and sendfile, behave the same as without the recorder.
+func (l *logEntryRecorder) writer() http.ResponseWriter {
+	var (
+		f, canFlush     = underlying[http.Flusher](l.ResponseWriter)
+		h, canHijack    = underlying[http.Hijacker](l.ResponseWriter)
+		p, canPush      = underlying[http.Pusher](l.ResponseWriter)
+		rf, canReadFrom = underlying[io.ReaderFrom](l.ResponseWriter)
+	)
+
+	// the adapters are only allocated for the supported interfaces.
+	var (
+		flush    http.Flusher
+		hijack   http.Hijacker
+		push     http.Pusher = p
+		readFrom io.ReaderFrom
+	)
+	if canFlush {
+		flush = flushFunc(func() { l.flush(f) })
+	}
+	if canHijack {
+		hijack = hijackFunc(func() (net.Conn, *bufio.ReadWriter, error) { return l.hijack(h) })
+	}
+	if canReadFrom {
+		readFrom = readFromFunc(func(src io.Reader) (int64, error) { return l.readFrom(rf, src) })
+	}
+
+	type (
+		rec = *logEntryRecorder
+		fl  = http.Flusher
+		hj  = http.Hijacker
+		ps  = http.Pusher
+		rd  = io.ReaderFrom
+	)
+
+	switch {
+	case canFlush && canHijack && canPush && canReadFrom:
+		return struct {
+			rec
+			fl
+			hj
+			ps
+			rd
+		}{l, flush, hijack, push, readFrom}
+	case canFlush && canHijack && canPush:
+		return struct {
+			rec
+			fl
+			hj
+			ps
+		}{l, flush, hijack, push}
+	case canFlush && canHijack && canReadFrom:
+		return struct {
+			rec
+			fl
+			hj
+			rd
+		}{l, flush, hijack, readFrom}
+	case canFlush && canPush && canReadFrom:
+		return struct {
+			rec
+			fl
+			ps
+			rd
+		}{l, flush, push, readFrom}
+	case canHijack && canPush && canReadFrom:
+		return struct {
+			rec
+			hj
+			ps
+			rd
+		}{l, hijack, push, readFrom}
+	case canFlush && canHijack:
+		return struct {
+			rec
+			fl
+			hj
+		}{l, flush, hijack}
+	case canFlush && canPush:
+		return struct {
+			rec
+			fl
+			ps
+		}{l, flush, push}
+	case canFlush && canReadFrom:
+		return struct {
+			rec
+			fl
+			rd
+		}{l, flush, readFrom}
+	case canHijack && canPush:
+		return struct {
+			rec
+			hj
+			ps
+		}{l, hijack, push}
+	case canHijack && canReadFrom:
+		return struct {
+			rec
+			hj
+			rd
+		}{l, hijack, readFrom}
+	case canPush && canReadFrom:
+		return struct {
+			rec
+			ps
+			rd
+		}{l, push, readFrom}
+	case canFlush:
+		return struct {
+			rec
+			fl
+		}{l, flush}
+	case canHijack:
+		return struct {
+			rec
+			hj
+		}{l, hijack}
+	case canPush:
+		return struct {
+			rec
+			ps
+		}{l, push}
+	case canReadFrom:
+		return struct {
+			rec
+			rd
+		}{l, readFrom}
+	default:
+		return l
+	}
+}
+
+// flush commits the entry with http.StatusOK if not committed yet, as the flush sends the header.
+func (l *logEntryRecorder) flush(f http.Flusher) {
+	if l.hijacked {
+		return
+	}
+
+	if l.log.RespondedAt <= 0 {
+		l.WriteHeader(http.StatusOK)
+	}
+	f.Flush()
+}
+
+// hijack delegates to the original ResponseWriter. Once hijacked, the entry is committed with
+// http.StatusSwitchingProtocols and any further write through the recorder returns http.ErrHijacked.
+func (l *logEntryRecorder) hijack(h http.Hijacker) (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := h.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l.hijacked = true
+	if l.log.RespondedAt <= 0 {
+		l.log.StatusCode = http.StatusSwitchingProtocols
+		l.log.RespondedAt = time.Now().UnixNano()
+	}
+	return conn, brw, nil
+}
+
+// readFrom delegates to the original ResponseWriter when the response body is discarded, so the sendfile
+// optimization is kept. Otherwise, the body is copied through Write to be recorded.
+func (l *logEntryRecorder) readFrom(rf io.ReaderFrom, src io.Reader) (int64, error) {
+	if l.hijacked {
+		return 0, http.ErrHijacked
+	}
+
+	// commit first, the content type may discard the response body.
+	if l.log.RespondedAt <= 0 {
+		l.WriteHeader(http.StatusOK)
+	}
+
+	if !l.log.DiscardResBody {
+		return io.Copy(writerOnly{l}, src)
+	}
+
+	n, err := rf.ReadFrom(src)
+	l.log.BytesWritten += n
+	return n, err
+}
+
+// underlying finds the first ResponseWriter in the Unwrap chain that implements T.
+func underlying[T any](w http.ResponseWriter) (T, bool) {
+	for {
+		if t, ok := w.(T); ok {
+			return t, true
+		}
+
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		w = u.Unwrap()
+	}
+}
+
+// writerOnly hides the io.ReaderFrom of the writer, so io.Copy does not call it back.
+type writerOnly struct{ io.Writer }
+
+type flushFunc func()
+
+func (f flushFunc) Flush() { f() }
+
+type hijackFunc func() (net.Conn, *bufio.ReadWriter, error)
+
+func (f hijackFunc) Hijack() (net.Conn, *bufio.ReadWriter, error) { return f() }
+
+type readFromFunc func(src io.Reader) (int64, error)
+
+func (f readFromFunc) ReadFrom(src io.Reader) (int64, error) { return f(src) }
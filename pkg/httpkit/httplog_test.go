@@ -149,3 +149,142 @@ type responseWriter struct{}
 func (r *responseWriter) Header() http.Header         { return nil }
 func (r *responseWriter) Write(i []byte) (int, error) { return io.Discard.Write(i) }
 func (r *responseWriter) WriteHeader(_ int)           {}
+
+func TestLogEntryRecorder_MaxBodyBytes(t *testing.T) {
+	mid := NewLogEntryRecorder(LogRecorderConfig{MaxBodyBytes: 4})
+
+	tests := []struct {
+		name         string
+		req          string
+		res          []string
+		reqBody      string
+		resBody      string
+		reqTruncated bool
+		resTruncated bool
+	}{
+		{name: "within limit", req: "abcd", res: []string{"ab", "cd"}, reqBody: "abcd", resBody: "abcd"},
+		{name: "request exceeds", req: "abcdef", res: []string{"ab"}, reqBody: "abcd", resBody: "ab",
+			reqTruncated: true},
+		{name: "response exceeds", res: []string{"abc", "def", "g"}, resBody: "abcd", resTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.req))
+			mid(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				expectTrue(t, string(body) == tt.req)
+				for _, s := range tt.res {
+					_, _ = io.WriteString(w, s)
+				}
+
+				rec, ok := GetLogEntry(w)
+				expectTrue(t, ok)
+				expectTrue(t, string(rec.ReqBody().Bytes()) == tt.reqBody)
+				expectTrue(t, string(rec.ResBody().Bytes()) == tt.resBody)
+				expectTrue(t, rec.RequestTruncated == tt.reqTruncated)
+				expectTrue(t, rec.ResponseTruncated == tt.resTruncated)
+			})).ServeHTTP(res, req)
+
+			expectTrue(t, res.Body.String() == strings.Join(tt.res, ""))
+		})
+	}
+}
+
+func TestLogEntryRecorder_DiscardContentTypes(t *testing.T) {
+	mid := NewLogEntryRecorder(LogRecorderConfig{DiscardContentTypes: DefaultDiscardContentTypes})
+
+	tests := []struct {
+		reqType    string
+		resType    string
+		discardReq bool
+		discardRes bool
+	}{
+		{reqType: "application/json", resType: "application/json"},
+		{reqType: "multipart/form-data; boundary=abc", resType: "application/json", discardReq: true},
+		{reqType: "application/json", resType: "image/png", discardRes: true},
+		{reqType: "Application/Octet-Stream", resType: "application/pdf", discardReq: true, discardRes: true},
+		{reqType: "invalid;;", resType: ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("body"))
+		req.Header.Set("Content-Type", tt.reqType)
+		mid(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", tt.resType)
+			_, _ = io.WriteString(w, "body")
+
+			rec, ok := GetLogEntry(w)
+			expectTrue(t, ok)
+			expectTrue(t, rec.DiscardReqBody == tt.discardReq)
+			expectTrue(t, rec.DiscardResBody == tt.discardRes)
+			expectTrue(t, (rec.ReqBody().Len() == 0) == tt.discardReq)
+			expectTrue(t, (rec.ResBody().Len() == 0) == tt.discardRes)
+		})).ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+func TestLogEntryRecorder_Passthrough(t *testing.T) {
+	t.Run("flusher only", func(t *testing.T) {
+		res := httptest.NewRecorder()
+		LogEntryRecorder(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, canHijack := w.(http.Hijacker)
+			_, canPush := w.(http.Pusher)
+			_, canReadFrom := w.(io.ReaderFrom)
+			expectFalse(t, canHijack)
+			expectFalse(t, canPush)
+			expectFalse(t, canReadFrom)
+
+			f, ok := w.(http.Flusher)
+			expectTrue(t, ok)
+			f.Flush()
+
+			rec, ok := GetLogEntry(w)
+			expectTrue(t, ok)
+			expectTrue(t, rec.StatusCode == http.StatusOK)
+		})).ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		expectTrue(t, res.Flushed)
+	})
+
+	t.Run("reader from", func(t *testing.T) {
+		tests := []struct {
+			discard  bool
+			readFrom bool
+			recorded string
+		}{
+			{discard: false, readFrom: false, recorded: "body"},
+			{discard: true, readFrom: true, recorded: ""},
+		}
+
+		for _, tt := range tests {
+			res := &readerFromWriter{ResponseRecorder: httptest.NewRecorder()}
+			LogEntryRecorder(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				rec, ok := GetLogEntry(w)
+				expectTrue(t, ok)
+				rec.DiscardResBody = tt.discard
+
+				n, err := w.(io.ReaderFrom).ReadFrom(strings.NewReader("body"))
+				expectTrue(t, err == nil)
+				expectTrue(t, n == 4)
+				expectTrue(t, rec.BytesWritten == 4)
+				expectTrue(t, string(rec.ResBody().Bytes()) == tt.recorded)
+			})).ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			expectTrue(t, res.Body.String() == "body")
+			expectTrue(t, res.readFrom == tt.readFrom)
+		}
+	})
+}
+
+type readerFromWriter struct {
+	*httptest.ResponseRecorder
+	readFrom bool
+}
+
+func (w *readerFromWriter) ReadFrom(src io.Reader) (int64, error) {
+	w.readFrom = true
+	return io.Copy(w.ResponseRecorder, src)
+}
@@ -0,0 +1,47 @@
+package httpkit
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// loggerKey is the context key for the request-scoped logger.
+type loggerKey struct{}
+
+// WithLogger returns a copy of the context carrying the logger.
+func WithLogger(ctx context.Context, log *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, log)
+}
+
+// LoggerFromContext gets the request-scoped logger from the context, if not found, it returns slog.Default().
+// The logger installed by RequestLogger carries the request ID, the method and the path, and the ServeMux adds the
+// matched route, so the business code logs with the correlation fields automatically.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if log, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return log
+	}
+	return slog.Default()
+}
+
+// WithLogAttrs returns a copy of the context carrying the request-scoped logger with the given attributes added, e.g.
+// the user ID once the request is authenticated. The args are handled the same way as slog.Logger.With.
+func WithLogAttrs(ctx context.Context, args ...any) context.Context {
+	return WithLogger(ctx, LoggerFromContext(ctx).With(args...))
+}
+
+// RequestLogger is a middleware that stores a request-scoped logger derived from the base logger in the request
+// context, see LoggerFromContext. It must be placed after RequestID and ResolveClientIP.
+func RequestLogger(base *slog.Logger) NetMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := base.With(
+				slog.String("request_id", RequestIDFromContext(r.Context())),
+				slog.String("client_ip", ClientIP(r)),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+			)
+			next.ServeHTTP(w, r.WithContext(WithLogger(r.Context(), log)))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package httpkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	mux := NewServeMux()
+	mux.Handle(http.MethodGet, "/users/:id", HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		ctx := WithLogAttrs(r.Context(), "user_id", "u-1")
+		LoggerFromContext(ctx).InfoContext(ctx, "hello")
+		return nil
+	}))
+
+	h := ReduceNetMiddleware(
+		func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), "req-1")))
+			})
+		},
+		RequestLogger(base),
+	).Then(mux)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	var got map[string]any
+	expectTrue(t, json.Unmarshal(buf.Bytes(), &got) == nil)
+	expectTrue(t, got["msg"] == "hello")
+	expectTrue(t, got["request_id"] == "req-1")
+	expectTrue(t, got["method"] == http.MethodGet)
+	expectTrue(t, got["path"] == "/users/1")
+	expectTrue(t, got["route"] == "/users/:id")
+	expectTrue(t, got["user_id"] == "u-1")
+}
+
+func TestLoggerFromContext_Default(t *testing.T) {
+	expectTrue(t, LoggerFromContext(context.Background()) == slog.Default())
+}
@@ -0,0 +1,230 @@
+package httpkit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Set of errors returned by ReadMultipart.
+var (
+	// ErrMultipartFileTooLarge is returned when a file part exceeds MultipartConfig.MaxFileSize.
+	ErrMultipartFileTooLarge = errors.New("httpkit: multipart file too large")
+
+	// ErrMultipartTooManyFiles is returned when the number of file parts exceeds MultipartConfig.MaxFiles.
+	ErrMultipartTooManyFiles = errors.New("httpkit: too many multipart files")
+
+	// ErrMultipartValueTooLarge is returned when a non-file part exceeds MultipartConfig.MaxValueSize.
+	ErrMultipartValueTooLarge = errors.New("httpkit: multipart value too large")
+
+	// ErrMultipartMIMETypeNotAllowed is returned when the detected MIME type of a file part is not allowed.
+	ErrMultipartMIMETypeNotAllowed = errors.New("httpkit: multipart mime type not allowed")
+)
+
+// MultipartConfig is the configuration for ReadMultipart.
+type MultipartConfig struct {
+	MaxFileSize  int64 // Maximum size in bytes of a single file. Default 10 MiB.
+	MaxFiles     int   // Maximum number of files. Default 10.
+	MaxValueSize int64 // Maximum size in bytes of a single non-file value. Default 1 MiB.
+
+	// AllowedMIMETypes is the list of allowed MIME types for files, e.g. "image/png" or "image/*".
+	// The MIME type is detected from the file content instead of trusting the client. Empty means all are allowed.
+	AllowedMIMETypes []string
+}
+
+// withDefaults returns a copy of the config with default values for unset fields.
+func (c MultipartConfig) withDefaults() MultipartConfig {
+	if c.MaxFileSize <= 0 {
+		c.MaxFileSize = 10 << 20
+	}
+	if c.MaxFiles <= 0 {
+		c.MaxFiles = 10
+	}
+	if c.MaxValueSize <= 0 {
+		c.MaxValueSize = 1 << 20
+	}
+	return c
+}
+
+// allowed reports whether the MIME type is allowed.
+func (c MultipartConfig) allowed(mimeType string) bool {
+	if len(c.AllowedMIMETypes) == 0 {
+		return true
+	}
+
+	typ, subtype, _ := strings.Cut(mimeType, "/")
+	for _, allowed := range c.AllowedMIMETypes {
+		allowedType, allowedSubtype, _ := strings.Cut(strings.ToLower(allowed), "/")
+		if allowedType == typ && (allowedSubtype == "*" || allowedSubtype == subtype) {
+			return true
+		}
+	}
+	return false
+}
+
+// MultipartFile is the metadata of an uploaded file.
+type MultipartFile struct {
+	FieldName   string // the form field name.
+	FileName    string // the file name sent by the client.
+	ContentType string // the MIME type detected from the file content.
+	Size        int64  // the number of bytes stored.
+	Location    string // the location returned by the MultipartStorage.
+}
+
+// MultipartResult is the result of ReadMultipart.
+type MultipartResult struct {
+	Values url.Values      // the non-file values.
+	Files  []MultipartFile // the stored files in the order they are received.
+}
+
+// MultipartStorage knows how to store an uploaded file.
+type MultipartStorage interface {
+	// Store consumes the reader and stores the file content. The returned location is recorded in the
+	// MultipartFile metadata. When the file is larger than allowed, the reader returns ErrMultipartFileTooLarge.
+	Store(ctx context.Context, file MultipartFile, r io.Reader) (location string, err error)
+}
+
+// MultipartStorageFunc is a function that implements MultipartStorage.
+type MultipartStorageFunc func(ctx context.Context, file MultipartFile, r io.Reader) (string, error)
+
+// Store implements MultipartStorage.
+func (f MultipartStorageFunc) Store(ctx context.Context, file MultipartFile, r io.Reader) (string, error) {
+	return f(ctx, file, r)
+}
+
+// MultipartWriterStorage creates a MultipartStorage that streams every file to the writer returned by the given
+// function. The location of the stored file is empty.
+func MultipartWriterStorage(open func(file MultipartFile) (io.Writer, error)) MultipartStorage {
+	return MultipartStorageFunc(func(_ context.Context, file MultipartFile, r io.Reader) (string, error) {
+		w, err := open(file)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(w, r)
+		return "", err
+	})
+}
+
+// ReadMultipart reads the multipart/form-data request body part by part without buffering files in memory or on
+// disk, file parts are streamed to the storage while the limits in the config are enforced.
+//
+// When the request is not a multipart request, http.ErrNotMultipart is returned.
+func ReadMultipart(r *http.Request, storage MultipartStorage, cfg MultipartConfig) (*MultipartResult, error) {
+	cfg = cfg.withDefaults()
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("multipart reader: %w", err)
+	}
+
+	res := MultipartResult{Values: make(url.Values), Files: make([]MultipartFile, 0)}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return &res, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("next part: %w", err)
+		}
+
+		if part.FileName() == "" {
+			err = readMultipartValue(&res, part.FormName(), part, cfg.MaxValueSize)
+		} else {
+			err = readMultipartFile(r.Context(), &res, part.FormName(), part.FileName(), part, storage, cfg)
+		}
+		_ = part.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func readMultipartValue(res *MultipartResult, name string, r io.Reader, limit int64) error {
+	b, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return fmt.Errorf("read value %q: %w", name, err)
+	}
+	if int64(len(b)) > limit {
+		return fmt.Errorf("value %q exceeds %d bytes: %w", name, limit, ErrMultipartValueTooLarge)
+	}
+	res.Values.Add(name, string(b))
+	return nil
+}
+
+func readMultipartFile(
+	ctx context.Context,
+	res *MultipartResult,
+	field, filename string,
+	r io.Reader,
+	storage MultipartStorage,
+	cfg MultipartConfig,
+) error {
+	if len(res.Files) >= cfg.MaxFiles {
+		return fmt.Errorf("exceeds %d files: %w", cfg.MaxFiles, ErrMultipartTooManyFiles)
+	}
+
+	// sniff the content type from the first 512 bytes, the same amount http.DetectContentType considers.
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("sniff file %q: %w", filename, err)
+	}
+
+	mimeType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !cfg.allowed(mimeType) {
+		return fmt.Errorf("file %q has type %s: %w", filename, mimeType, ErrMultipartMIMETypeNotAllowed)
+	}
+
+	file := MultipartFile{
+		FieldName:   field,
+		FileName:    filename,
+		ContentType: mimeType,
+	}
+
+	lr := &multipartLimitReader{r: br, remaining: cfg.MaxFileSize}
+	location, err := storage.Store(ctx, file, lr)
+	if err != nil {
+		return fmt.Errorf("store file %q: %w", filename, err)
+	}
+
+	// make sure the limit is enforced even when the storage does not consume the whole part.
+	if _, err := io.Copy(io.Discard, lr); err != nil {
+		return fmt.Errorf("store file %q: %w", filename, err)
+	}
+
+	file.Size = cfg.MaxFileSize - lr.remaining
+	file.Location = location
+	res.Files = append(res.Files, file)
+	return nil
+}
+
+// multipartLimitReader is like io.LimitedReader, but returns ErrMultipartFileTooLarge instead of io.EOF when the
+// underlying reader has more data than allowed.
+type multipartLimitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *multipartLimitReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// probe one byte to differentiate the exact size from the oversize.
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			return 0, ErrMultipartFileTooLarge
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
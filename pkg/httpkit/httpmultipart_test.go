@@ -0,0 +1,112 @@
+package httpkit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A")
+
+func newMultipartRequest(t *testing.T, values map[string]string, files map[string][]byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range values {
+		expectTrue(t, mw.WriteField(k, v) == nil)
+	}
+	for name, content := range files {
+		fw, err := mw.CreateFormFile("files", name)
+		expectTrue(t, err == nil)
+		_, err = fw.Write(content)
+		expectTrue(t, err == nil)
+	}
+	expectTrue(t, mw.Close() == nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestReadMultipart(t *testing.T) {
+	png := append(append([]byte{}, pngHeader...), []byte("image-data")...)
+	req := newMultipartRequest(t,
+		map[string]string{"title": "avatar"},
+		map[string][]byte{"avatar.png": png},
+	)
+
+	stored := make(map[string][]byte)
+	storage := MultipartStorageFunc(func(_ context.Context, file MultipartFile, r io.Reader) (string, error) {
+		b, err := io.ReadAll(r)
+		stored[file.FileName] = b
+		return "mem://" + file.FileName, err
+	})
+
+	res, err := ReadMultipart(req, storage, MultipartConfig{AllowedMIMETypes: []string{"image/*"}})
+	expectTrue(t, err == nil)
+	expectTrue(t, res.Values.Get("title") == "avatar")
+	expectTrue(t, len(res.Files) == 1)
+
+	file := res.Files[0]
+	expectTrue(t, file.FieldName == "files")
+	expectTrue(t, file.FileName == "avatar.png")
+	expectTrue(t, file.ContentType == "image/png")
+	expectTrue(t, file.Size == int64(len(png)))
+	expectTrue(t, file.Location == "mem://avatar.png")
+	expectTrue(t, bytes.Equal(stored["avatar.png"], png))
+}
+
+func TestReadMultipart_Limits(t *testing.T) {
+	discard := MultipartWriterStorage(func(MultipartFile) (io.Writer, error) { return io.Discard, nil })
+
+	t.Run("file too large", func(t *testing.T) {
+		req := newMultipartRequest(t, nil, map[string][]byte{"a.txt": []byte(strings.Repeat("a", 11))})
+		_, err := ReadMultipart(req, discard, MultipartConfig{MaxFileSize: 10})
+		expectTrue(t, errors.Is(err, ErrMultipartFileTooLarge))
+	})
+
+	t.Run("file exactly at the limit", func(t *testing.T) {
+		req := newMultipartRequest(t, nil, map[string][]byte{"a.txt": []byte(strings.Repeat("a", 10))})
+		res, err := ReadMultipart(req, discard, MultipartConfig{MaxFileSize: 10})
+		expectTrue(t, err == nil)
+		expectTrue(t, res.Files[0].Size == 10)
+	})
+
+	t.Run("storage does not consume the file", func(t *testing.T) {
+		lazy := MultipartStorageFunc(func(context.Context, MultipartFile, io.Reader) (string, error) { return "", nil })
+		req := newMultipartRequest(t, nil, map[string][]byte{"a.txt": []byte(strings.Repeat("a", 11))})
+		_, err := ReadMultipart(req, lazy, MultipartConfig{MaxFileSize: 10})
+		expectTrue(t, errors.Is(err, ErrMultipartFileTooLarge))
+	})
+
+	t.Run("too many files", func(t *testing.T) {
+		req := newMultipartRequest(t, nil, map[string][]byte{"a.txt": []byte("a"), "b.txt": []byte("b")})
+		_, err := ReadMultipart(req, discard, MultipartConfig{MaxFiles: 1})
+		expectTrue(t, errors.Is(err, ErrMultipartTooManyFiles))
+	})
+
+	t.Run("value too large", func(t *testing.T) {
+		req := newMultipartRequest(t, map[string]string{"title": "too long"}, nil)
+		_, err := ReadMultipart(req, discard, MultipartConfig{MaxValueSize: 3})
+		expectTrue(t, errors.Is(err, ErrMultipartValueTooLarge))
+	})
+
+	t.Run("mime type not allowed", func(t *testing.T) {
+		req := newMultipartRequest(t, nil, map[string][]byte{"fake.png": []byte("plain text")})
+		_, err := ReadMultipart(req, discard, MultipartConfig{AllowedMIMETypes: []string{"image/png"}})
+		expectTrue(t, errors.Is(err, ErrMultipartMIMETypeNotAllowed))
+	})
+
+	t.Run("not multipart", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		_, err := ReadMultipart(req, discard, MultipartConfig{})
+		expectTrue(t, errors.Is(err, http.ErrNotMultipart))
+	})
+}
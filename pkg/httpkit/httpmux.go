@@ -1,9 +1,15 @@
 package httpkit
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
 
+	"github.com/josestg/problemdetail"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -42,6 +48,31 @@ type Route struct {
 	Method  string
 	Path    string
 	Handler HandlerFunc
+
+	// Name is an optional unique name of the route for building its URL by ServeMux.URL, e.g. users.get.
+	Name string
+
+	// Meta is an optional route metadata, e.g. the scopes required to access the route. The metadata is available
+	// for middlewares and handlers through RouteFromContext.
+	Meta map[string]any
+}
+
+// RouteInfo describes the matched route of the current request.
+type RouteInfo struct {
+	Name   string         // the registered name, empty if the route is not named.
+	Method string         // the registered method.
+	Path   string         // the registered path pattern, e.g. /users/:id.
+	Meta   map[string]any // the route metadata.
+}
+
+// routeInfoKey is the context key for RouteInfo.
+type routeInfoKey struct{}
+
+// RouteFromContext gets the matched route from the context, if not found, it returns false.
+// The route is available for all MuxMiddleware and the handler of routes registered to the ServeMux.
+func RouteFromContext(ctx context.Context) (RouteInfo, bool) {
+	info, ok := ctx.Value(routeInfoKey{}).(RouteInfo)
+	return info, ok
 }
 
 // ServeMux is a wrapper of httprouter.Router with modified Handler.
@@ -54,15 +85,16 @@ type Route struct {
 //
 // The ServeMux only exposes 3 methods: Route, Handle, and ServeHTTP, which are more simple than the original.
 type ServeMux struct {
-	core *httprouter.Router
-	conf *MuxConfig
-	midl MuxMiddleware
+	core  *httprouter.Router
+	conf  *MuxConfig
+	midl  MuxMiddleware
+	names map[string]string // the paths of the named routes.
 }
 
 // NewServeMux creates a new ServeMux with given options.
 // If no option is given, the Default option is applied.
 func NewServeMux(opts ...MuxOption) *ServeMux {
-	mux := ServeMux{conf: &MuxConfig{
+	mux := ServeMux{names: make(map[string]string), conf: &MuxConfig{
 		RedirectTrailingSlash:  true,
 		RedirectFixedPath:      true,
 		HandleMethodNotAllowed: true,
@@ -83,7 +115,7 @@ func NewServeMux(opts ...MuxOption) *ServeMux {
 		GlobalOPTIONS:          mux.conf.GlobalOPTIONS,
 		NotFound:               mux.conf.NotFound,
 		MethodNotAllowed:       mux.conf.MethodNotAllowed,
-		PanicHandler:           mux.conf.PanicHandler,
+		PanicHandler:           mux.recover,
 	}
 	return &mux
 }
@@ -91,19 +123,100 @@ func NewServeMux(opts ...MuxOption) *ServeMux {
 // Route is a syntactic sugar for Handle(method, path, handler) by using Route struct.
 // This route also accepts variadic MuxMiddleware, which is applied to the route handler.
 func (mux *ServeMux) Route(r Route, mid ...MuxMiddleware) {
-	mux.Handle(r.Method, r.Path, reduceMuxMiddleware(mid).Then(r.Handler))
+	if r.Name != "" {
+		if _, ok := mux.names[r.Name]; ok {
+			panic(fmt.Sprintf("httpkit: route name %q is already registered", r.Name))
+		}
+		mux.names[r.Name] = r.Path
+	}
+	info := RouteInfo{Name: r.Name, Method: r.Method, Path: r.Path, Meta: r.Meta}
+	mux.handle(info, reduceMuxMiddleware(mid).Then(r.Handler))
+}
+
+// URL builds the path of the named route by the path parameters given in pairs of the name and the value, e.g.
+// URL("users.get", "id", "42") for /users/:id returns /users/42. The values are escaped, except the slashes of the
+// catch-all parameter.
+func (mux *ServeMux) URL(name string, params ...string) (string, error) {
+	path, ok := mux.names[name]
+	if !ok {
+		return "", fmt.Errorf("httpkit: route %q is not found", name)
+	}
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("httpkit: route %q: params must be pairs of name and value", name)
+	}
+
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+
+		value, ok := values[seg[1:]]
+		if !ok {
+			return "", fmt.Errorf("httpkit: route %q: missing param %q", name, seg[1:])
+		}
+		if seg[0] == ':' {
+			segments[i] = url.PathEscape(value)
+			continue
+		}
+
+		parts := strings.Split(strings.TrimPrefix(value, "/"), "/")
+		for j := range parts {
+			parts[j] = url.PathEscape(parts[j])
+		}
+		segments[i] = strings.Join(parts, "/")
+	}
+	return strings.Join(segments, "/"), nil
 }
 
 // Handle registers a new request handler with the given method and path.
 func (mux *ServeMux) Handle(method, path string, handler Handler) {
-	mux.core.HandlerFunc(method, path, func(w http.ResponseWriter, r *http.Request) {
+	mux.handle(RouteInfo{Method: method, Path: path}, handler)
+}
+
+func (mux *ServeMux) handle(info RouteInfo, handler Handler) {
+	mux.core.HandlerFunc(info.Method, info.Path, func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), routeInfoKey{}, info)
+		if log, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			ctx = WithLogger(ctx, log.With(slog.String("route", info.Path)))
+		}
+		if mux.conf.ErrorReporter != nil {
+			ctx = context.WithValue(ctx, errorReporterKey{}, mux.conf.ErrorReporter)
+		}
+
+		r = r.WithContext(ctx)
 		err := mux.midl.Then(handler).ServeHTTP(w, r)
 		if err != nil {
+			ReportError(r, err)
 			mux.conf.LastResortErrorHandler(w, r, err)
 		}
 	})
 }
 
+// recover captures the stack trace of the panic, records it into the LogEntry, reports it to the ErrorReporter,
+// and then delegates to the PanicHandler with the *PanicError.
+func (mux *ServeMux) recover(w http.ResponseWriter, r *http.Request, v any) {
+	pe := &PanicError{Value: v, Stack: debug.Stack()}
+	if entry, ok := GetLogEntry(w); ok {
+		entry.Panic = pe
+	}
+
+	reporter := mux.conf.ErrorReporter
+	if reporter == nil {
+		reporter, _ = r.Context().Value(errorReporterKey{}).(ErrorReporter)
+	}
+
+	if reporter != nil {
+		reporter.Report(r.Context(), r, pe)
+	}
+	mux.conf.PanicHandler(w, r, pe)
+}
+
 // ServeHTTP satisfies http.Handler.
 func (mux *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	mux.core.ServeHTTP(w, r)
@@ -162,8 +275,16 @@ type MuxConfig struct {
 	// 500 (Internal Server Error).
 	// The handler can be used to keep your server from crashing because of
 	// unrecoverable panics.
+	//
+	// The recovered value is wrapped in *PanicError which carries the stack trace.
 	PanicHandler func(http.ResponseWriter, *http.Request, any)
 
+	// ErrorReporter is an optional reporter for the recovered panics and the unresolved errors that reach the
+	// LastResortErrorHandler. If it is not set, the reporter installed by the ReportErrors middleware is used.
+	//
+	// This reporter is not part of the httprouter.Router, it is used by the ServeMux.
+	ErrorReporter ErrorReporter
+
 	// LastResortErrorHandler is the error handler that is called if after all middlewares,
 	// there is still an error occurs. This handler is used to catch errors that are not handled by the middlewares.
 	//
@@ -266,12 +387,18 @@ func (muxOptionNamespace) MethodNotAllowedHandler(handler http.Handler) MuxOptio
 	return func(mux *ServeMux) { mux.conf.MethodNotAllowed = handler }
 }
 
-// PanicHandler sets the handler that is called when a panic occurs.
-// If no handler is set, the DefaultHandler.LastResortError is used.
+// PanicHandler sets the handler that is called when a panic occurs, the recovered value is a *PanicError.
+// If no handler is set, the DefaultHandler.Panic is used.
 func (muxOptionNamespace) PanicHandler(handler func(http.ResponseWriter, *http.Request, any)) MuxOption {
 	return func(mux *ServeMux) { mux.conf.PanicHandler = handler }
 }
 
+// ErrorReporter sets the reporter for the recovered panics and the unresolved errors, e.g. for forwarding them to
+// Sentry. If it is not set, the reporter installed by the ReportErrors middleware is used.
+func (muxOptionNamespace) ErrorReporter(reporter ErrorReporter) MuxOption {
+	return func(mux *ServeMux) { mux.conf.ErrorReporter = reporter }
+}
+
 // LastResortErrorHandler sets the handler that is called if after all middlewares,
 // there is still an error occurs.
 // This handler is used to catch errors that are not handled by the middlewares.
@@ -314,8 +441,24 @@ func (defaultHandlerNamespace) MethodNotAllowed() http.HandlerFunc {
 	}
 }
 
-// Panic is the default panic handler.
+// Panic is the default panic handler. It logs the panic with its stack trace using slog.Default and replies with a
+// generic RFC 7807 Problem Details, so the internal details are not leaked to the client.
 func (defaultHandlerNamespace) Panic(w http.ResponseWriter, r *http.Request, v any) {
-	w.WriteHeader(http.StatusInternalServerError)
-	_, _ = fmt.Fprintf(w, "default panic handler: method: %s, path: %s, error: %v", r.Method, r.URL.Path, v)
+	attrs := []slog.Attr{
+		slog.String("request_id", RequestIDFromContext(r.Context())),
+		slog.String("path", r.URL.Path),
+		slog.String("method", r.Method),
+	}
+	if pe, ok := v.(*PanicError); ok {
+		attrs = append(attrs, slog.Any("panic", pe.Value), slog.String("stack", string(pe.Stack)))
+	} else {
+		attrs = append(attrs, slog.Any("panic", v))
+	}
+	slog.Default().LogAttrs(r.Context(), slog.LevelError, "panic_recovered", attrs...)
+
+	pd := problemdetail.New(problemdetail.Untyped,
+		problemdetail.WithValidateLevel(problemdetail.LStandard),
+		problemdetail.WithDetail("an unexpected error occurred"),
+	)
+	_ = problemdetail.WriteJSON(w, pd, http.StatusInternalServerError)
 }
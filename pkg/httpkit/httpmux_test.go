@@ -1,6 +1,7 @@
 package httpkit
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -34,8 +35,34 @@ func TestDefaultHandlerNamespace_NotFound(t *testing.T) {
 func TestDefaultHandlerNamespace_PanicHandler(t *testing.T) {
 	res := httptest.NewRecorder()
 	req := httptest.NewRequest("GET", "/", nil)
-	DefaultHandler.Panic(res, req, "any value")
+	DefaultHandler.Panic(res, req, &PanicError{Value: "secret internal detail", Stack: []byte("stack")})
 	expectTrue(t, res.Code == 500)
+	expectTrue(t, strings.HasPrefix(res.Header().Get("Content-Type"), "application/problem+json"))
+	expectTrue(t, !strings.Contains(res.Body.String(), "secret internal detail"))
+}
+
+func TestServeMux_Panic(t *testing.T) {
+	var reported error
+	mux := NewServeMux(Opts.ErrorReporter(ErrorReporterFunc(func(_ context.Context, _ *http.Request, err error) {
+		reported = err
+	})))
+	mux.Route(Route{Method: http.MethodGet, Path: "/", Handler: func(w http.ResponseWriter, r *http.Request) error {
+		panic("boom")
+	}})
+
+	var entry *LogEntry
+	res := httptest.NewRecorder()
+	LogEntryRecorder(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry, _ = GetLogEntry(w)
+		mux.ServeHTTP(w, r)
+		expectTrue(t, entry.Panic != nil)
+		expectTrue(t, entry.Panic.Value == "boom")
+		expectTrue(t, strings.Contains(string(entry.Panic.Stack), "TestServeMux_Panic"))
+	})).ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	expectTrue(t, res.Code == http.StatusInternalServerError)
+	var pe *PanicError
+	expectTrue(t, errors.As(reported, &pe))
 }
 
 func TestHandlerFunc_ServeHTTP(t *testing.T) {
@@ -140,6 +167,33 @@ func TestServeMux_RouteWithPathParams(t *testing.T) {
 	expectTrue(t, visited)
 }
 
+func TestServeMux_URL(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) error { return nil }
+	mux := NewServeMux()
+	mux.Route(Route{Method: "GET", Path: "/users/:id/orders/:order", Name: "orders.get", Handler: noop})
+	mux.Route(Route{Method: "GET", Path: "/files/*path", Name: "files.get", Handler: noop})
+
+	u, err := mux.URL("orders.get", "id", "42", "order", "a b")
+	expectTrue(t, err == nil)
+	expectTrue(t, u == "/users/42/orders/a%20b")
+
+	u, err = mux.URL("files.get", "path", "/docs/read me.md")
+	expectTrue(t, err == nil)
+	expectTrue(t, u == "/files/docs/read%20me.md")
+
+	_, err = mux.URL("orders.get", "id", "42")
+	expectTrue(t, err != nil)
+
+	_, err = mux.URL("orders.get", "id")
+	expectTrue(t, err != nil)
+
+	_, err = mux.URL("users.list")
+	expectTrue(t, err != nil)
+
+	defer func() { expectTrue(t, recover() != nil) }()
+	mux.Route(Route{Method: "POST", Path: "/orders", Name: "orders.get", Handler: noop})
+}
+
 func TestServeMux_RouteWithMiddleware(t *testing.T) {
 	mid := MuxMiddleware(func(next Handler) Handler {
 		return HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
@@ -268,6 +322,34 @@ func TestServeMux_RouteWithRouteSpecificMiddleware(t *testing.T) {
 	})
 }
 
+func TestServeMux_RouteFromContext(t *testing.T) {
+	var info RouteInfo
+	var found bool
+	mux := NewServeMux(Opts.Middleware(func(next Handler) Handler {
+		return HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			// the route is already available for the outermost middleware.
+			info, found = RouteFromContext(r.Context())
+			return next.ServeHTTP(w, r)
+		})
+	}))
+
+	mux.Route(Route{
+		Method:  http.MethodGet,
+		Path:    "/users/:id",
+		Handler: func(w http.ResponseWriter, r *http.Request) error { return nil },
+		Meta:    map[string]any{"scopes": []string{"users:read"}},
+	})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	expectTrue(t, found)
+	expectTrue(t, info.Method == http.MethodGet)
+	expectTrue(t, info.Path == "/users/:id")
+	expectTrue(t, info.Meta["scopes"].([]string)[0] == "users:read")
+
+	_, found = RouteFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context())
+	expectFalse(t, found)
+}
+
 func expectTrue(t *testing.T, actual bool) {
 	t.Helper()
 	if !actual {
@@ -281,3 +363,31 @@ func expectFalse(t *testing.T, actual bool) {
 		t.Fatalf("expected false, got true")
 	}
 }
+
+func TestReportErrors(t *testing.T) {
+	var reported []error
+	reporter := ErrorReporterFunc(func(ctx context.Context, r *http.Request, err error) {
+		reported = append(reported, err)
+	})
+
+	mux := NewServeMux()
+	mux.Route(Route{Method: http.MethodGet, Path: "/unresolved", Handler: func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("unresolved")
+	}})
+	mux.Route(Route{Method: http.MethodGet, Path: "/resolved", Handler: func(w http.ResponseWriter, r *http.Request) error {
+		return ResolveError(errors.New("resolved"))
+	}})
+	mux.Route(Route{Method: http.MethodGet, Path: "/panic", Handler: func(w http.ResponseWriter, r *http.Request) error {
+		panic("boom")
+	}})
+
+	h := ReportErrors(reporter).Then(mux)
+	for _, path := range []string{"/unresolved", "/resolved", "/panic"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	expectTrue(t, len(reported) == 2)
+	expectTrue(t, reported[0].Error() == "unresolved")
+	var pe *PanicError
+	expectTrue(t, errors.As(reported[1], &pe))
+}
@@ -0,0 +1,281 @@
+package httpkit
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/valyala/bytebufferpool"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrNotAcceptable is returned when none of the registered encoders satisfies the Accept header.
+	ErrNotAcceptable = errors.New("httpkit: not acceptable")
+
+	// ErrNotProtoMessage is returned by the protobuf encoder when the data is not a proto.Message. The negotiation
+	// skips the protobuf encoder for such data, so it is only returned by calling Encode directly.
+	ErrNotProtoMessage = errors.New("httpkit: data is not a proto.Message")
+)
+
+// Encoder knows how to encode data into a specific media type.
+type Encoder struct {
+	// MediaType is the media type used to match the Accept header, e.g. application/json.
+	MediaType string
+
+	// ContentType is the value of the Content-Type header, e.g. application/json; charset=UTF-8.
+	// If empty, MediaType is used.
+	ContentType string
+
+	// Encode encodes the data into the writer.
+	Encode func(w io.Writer, data any) error
+
+	// Accepts reports whether the encoder can encode the data, the encoders that can't are skipped by the
+	// negotiation of EncoderRegistry.Write. If nil, any data is accepted.
+	Accepts func(data any) bool
+}
+
+// EncoderRegistry is a set of Encoder that is used for content negotiation.
+// The registration order is the server preference: when the client accepts several media types with the same
+// quality, the first registered one wins. If the client does not send the Accept header, the first registered
+// encoder is used.
+type EncoderRegistry struct {
+	mu       sync.RWMutex
+	encoders []Encoder
+}
+
+// NewEncoderRegistry creates a new EncoderRegistry with the given encoders.
+func NewEncoderRegistry(encoders ...Encoder) *EncoderRegistry {
+	reg := EncoderRegistry{}
+	for _, enc := range encoders {
+		reg.Register(enc)
+	}
+	return &reg
+}
+
+// Register registers the encoder. If an encoder with the same media type is already registered, it is replaced
+// and keeps its original preference.
+// This method is concurrent-safe.
+func (reg *EncoderRegistry) Register(enc Encoder) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	enc.MediaType = strings.ToLower(enc.MediaType)
+	if enc.ContentType == "" {
+		enc.ContentType = enc.MediaType
+	}
+
+	for i := range reg.encoders {
+		if reg.encoders[i].MediaType == enc.MediaType {
+			reg.encoders[i] = enc
+			return
+		}
+	}
+	reg.encoders = append(reg.encoders, enc)
+}
+
+// Negotiate selects the encoder that best satisfies the given Accept header value.
+// It returns ErrNotAcceptable if no encoder is acceptable.
+func (reg *EncoderRegistry) Negotiate(accept string) (Encoder, error) {
+	return reg.negotiate(accept, func(Encoder) bool { return true })
+}
+
+// NegotiateFor is Negotiate among the encoders that can encode the data, see Encoder.Accepts. It returns
+// ErrNotAcceptable if none of them is acceptable, e.g. the client only accepts protobuf but the data isn't a
+// proto.Message.
+func (reg *EncoderRegistry) NegotiateFor(accept string, data any) (Encoder, error) {
+	return reg.negotiate(accept, func(enc Encoder) bool { return enc.Accepts == nil || enc.Accepts(data) })
+}
+
+// negotiate selects the encoder that best satisfies the Accept header value among the eligible encoders.
+func (reg *EncoderRegistry) negotiate(accept string, eligible func(Encoder) bool) (Encoder, error) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	ranges := parseAccept(accept)
+	noAccept := strings.TrimSpace(accept) == ""
+	best, bestQuality := -1, 0.0
+	for i := range reg.encoders {
+		if !eligible(reg.encoders[i]) {
+			continue
+		}
+		if noAccept {
+			return reg.encoders[i], nil
+		}
+
+		q := mediaQuality(ranges, reg.encoders[i].MediaType)
+		if q > bestQuality {
+			best, bestQuality = i, q
+		}
+	}
+
+	if best < 0 {
+		return Encoder{}, ErrNotAcceptable
+	}
+	return reg.encoders[best], nil
+}
+
+// Write writes the data to the response writer using the encoder negotiated from the request Accept header among the
+// encoders that can encode the data, see NegotiateFor. The data is encoded before the status code is written, so
+// when no encoder is acceptable (ErrNotAcceptable) or the encoding fails, nothing is written and the error can still
+// be mapped to a proper response.
+func (reg *EncoderRegistry) Write(w http.ResponseWriter, r *http.Request, data any, code int) error {
+	enc, err := reg.NegotiateFor(r.Header.Get("Accept"), data)
+	if err != nil {
+		return err
+	}
+
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+	if err := enc.Encode(buf, data); err != nil {
+		return fmt.Errorf("encode %s: %w", enc.MediaType, err)
+	}
+
+	w.Header().Add("Vary", "Accept")
+	writeContentTypeAndStatus(w, enc.ContentType, code)
+	_, err = w.Write(buf.B)
+	return err
+}
+
+// DefaultEncoderRegistry is the registry used by WriteNegotiated.
+// By default, it supports JSON (preferred), XML, MessagePack and Protocol Buffers. The protobuf encoder is only
+// selected when the client explicitly asks for it and the data is a proto.Message.
+var DefaultEncoderRegistry = NewEncoderRegistry(
+	Encoders.JSON(),
+	Encoders.XML(),
+	Encoders.Msgpack(),
+	Encoders.Proto(),
+)
+
+// WriteNegotiated writes the data to the response writer in the format requested by the Accept header using the
+// DefaultEncoderRegistry.
+func WriteNegotiated(w http.ResponseWriter, r *http.Request, data any, code int) error {
+	return DefaultEncoderRegistry.Write(w, r, data, code)
+}
+
+// encoderNamespace is an internal type for grouping encoders.
+type encoderNamespace int
+
+// Encoders is a namespace for accessing the built-in encoders.
+const Encoders encoderNamespace = 0
+
+// JSON encodes data as application/json.
+func (encoderNamespace) JSON() Encoder {
+	return Encoder{
+		MediaType:   contextTypeApplicationJSON,
+		ContentType: contentTypeApplicationJSONCharsetUTF8,
+		Encode:      func(w io.Writer, data any) error { return json.NewEncoder(w).Encode(data) },
+	}
+}
+
+// XML encodes data as application/xml.
+func (encoderNamespace) XML() Encoder {
+	return Encoder{
+		MediaType:   contentTypeApplicationXML,
+		ContentType: contentTypeApplicationXMLCharsetUTF8,
+		Encode:      func(w io.Writer, data any) error { return xml.NewEncoder(w).Encode(data) },
+	}
+}
+
+// Msgpack encodes data as application/msgpack.
+func (encoderNamespace) Msgpack() Encoder {
+	return Encoder{
+		MediaType: contentTypeApplicationMsgpack,
+		Encode:    func(w io.Writer, data any) error { return msgpack.NewEncoder(w).Encode(data) },
+	}
+}
+
+// Proto encodes data as application/x-protobuf. The data must be a proto.Message, otherwise ErrNotProtoMessage
+// is returned, so it accepts only the proto.Message on negotiation.
+func (encoderNamespace) Proto() Encoder {
+	return Encoder{
+		MediaType: contentTypeApplicationProtobuf,
+		Accepts: func(data any) bool {
+			_, ok := data.(proto.Message)
+			return ok
+		},
+		Encode: func(w io.Writer, data any) error {
+			m, ok := data.(proto.Message)
+			if !ok {
+				return fmt.Errorf("%w: %T", ErrNotProtoMessage, data)
+			}
+
+			b, err := proto.Marshal(m)
+			if err != nil {
+				return fmt.Errorf("marshal proto: %w", err)
+			}
+			_, err = w.Write(b)
+			return err
+		},
+	}
+}
+
+// acceptRange is a media range of the Accept header.
+type acceptRange struct {
+	typ     string
+	subtype string
+	quality float64
+}
+
+// specificity returns how specific the media range is: */* < type/* < type/subtype.
+func (a acceptRange) specificity() int {
+	switch {
+	case a.typ == "*":
+		return 0
+	case a.subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matches reports whether the media range covers the given media type.
+func (a acceptRange) matches(mediaType string) bool {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	return (a.typ == "*" || a.typ == typ) && (a.subtype == "*" || a.subtype == subtype)
+}
+
+// parseAccept parses the Accept header value into media ranges. Malformed ranges are ignored.
+func parseAccept(accept string) []acceptRange {
+	ranges := make([]acceptRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{typ: typ, subtype: subtype, quality: quality})
+	}
+	return ranges
+}
+
+// mediaQuality returns the quality of the media type according to the most specific matching media range.
+func mediaQuality(ranges []acceptRange, mediaType string) float64 {
+	quality, specificity := 0.0, -1
+	for _, r := range ranges {
+		if r.matches(mediaType) && r.specificity() > specificity {
+			quality, specificity = r.quality, r.specificity()
+		}
+	}
+	return quality
+}
@@ -0,0 +1,143 @@
+package httpkit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type negotiatedData struct {
+	Name string `json:"name" xml:"name" msgpack:"name"`
+}
+
+func TestWriteNegotiated(t *testing.T) {
+	data := negotiatedData{Name: "John Doe"}
+
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{accept: "", contentType: contentTypeApplicationJSONCharsetUTF8},
+		{accept: "*/*", contentType: contentTypeApplicationJSONCharsetUTF8},
+		{accept: "application/xml", contentType: contentTypeApplicationXMLCharsetUTF8},
+		{accept: "application/*;q=0.5, application/msgpack", contentType: contentTypeApplicationMsgpack},
+		{accept: "application/json;q=0, */*", contentType: contentTypeApplicationXMLCharsetUTF8},
+		{accept: "text/html, application/xml;q=0.9", contentType: contentTypeApplicationXMLCharsetUTF8},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", tt.accept)
+
+		err := WriteNegotiated(rec, req, data, http.StatusOK)
+		expectTrue(t, err == nil)
+		expectTrue(t, rec.Code == http.StatusOK)
+		expectTrue(t, rec.Header().Get("Content-Type") == tt.contentType)
+		expectTrue(t, rec.Header().Get("Vary") == "Accept")
+	}
+}
+
+func TestWriteNegotiated_Bodies(t *testing.T) {
+	data := negotiatedData{Name: "John Doe"}
+
+	write := func(accept string) []byte {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", accept)
+		expectTrue(t, WriteNegotiated(rec, req, data, http.StatusOK) == nil)
+		body, _ := io.ReadAll(rec.Body)
+		return body
+	}
+
+	expectTrue(t, string(write("application/json")) == "{\"name\":\"John Doe\"}\n")
+	expectTrue(t, string(write("application/xml")) == "<negotiatedData><name>John Doe</name></negotiatedData>")
+
+	var decoded negotiatedData
+	expectTrue(t, msgpack.Unmarshal(write("application/msgpack"), &decoded) == nil)
+	expectTrue(t, decoded == data)
+}
+
+func TestWriteNegotiated_NotAcceptable(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/html")
+
+	err := WriteNegotiated(rec, req, negotiatedData{}, http.StatusOK)
+	expectTrue(t, errors.Is(err, ErrNotAcceptable))
+	expectTrue(t, rec.Body.Len() == 0)
+}
+
+func TestEncoderRegistry_Register(t *testing.T) {
+	reg := NewEncoderRegistry()
+	_, err := reg.Negotiate("*/*")
+	expectTrue(t, errors.Is(err, ErrNotAcceptable))
+
+	reg.Register(Encoders.JSON())
+	reg.Register(Encoder{
+		MediaType: "text/plain",
+		Encode: func(w io.Writer, data any) error {
+			_, err := io.WriteString(w, "plain")
+			return err
+		},
+	})
+
+	enc, err := reg.Negotiate("text/plain")
+	expectTrue(t, err == nil)
+	expectTrue(t, enc.ContentType == "text/plain")
+
+	// replacing keeps the preference.
+	reg.Register(Encoder{
+		MediaType:   "APPLICATION/JSON",
+		ContentType: "application/json",
+		Encode:      Encoders.JSON().Encode,
+	})
+
+	enc, err = reg.Negotiate("")
+	expectTrue(t, err == nil)
+	expectTrue(t, enc.ContentType == "application/json")
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/*")
+	expectTrue(t, reg.Write(rec, req, nil, http.StatusCreated) == nil)
+	expectTrue(t, rec.Code == http.StatusCreated)
+	expectTrue(t, strings.TrimSpace(rec.Body.String()) == "plain")
+}
+
+func TestWriteNegotiated_Proto(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/x-protobuf")
+
+	err := WriteNegotiated(rec, req, wrapperspb.String("John Doe"), http.StatusOK)
+	expectTrue(t, err == nil)
+	expectTrue(t, rec.Header().Get("Content-Type") == contentTypeApplicationProtobuf)
+
+	var msg wrapperspb.StringValue
+	expectTrue(t, proto.Unmarshal(rec.Body.Bytes(), &msg) == nil)
+	expectTrue(t, msg.GetValue() == "John Doe")
+
+	// non proto message isn't acceptable, it is rejected before anything is written.
+	rec = httptest.NewRecorder()
+	err = WriteNegotiated(rec, req, negotiatedData{}, http.StatusOK)
+	expectTrue(t, errors.Is(err, ErrNotAcceptable))
+	expectTrue(t, rec.Body.Len() == 0)
+	expectTrue(t, rec.Header().Get("Content-Type") == "")
+
+	// the next acceptable encoder is used instead.
+	rec = httptest.NewRecorder()
+	req.Header.Set("Accept", "application/x-protobuf, application/json;q=0.5")
+	err = WriteNegotiated(rec, req, negotiatedData{}, http.StatusOK)
+	expectTrue(t, err == nil)
+	expectTrue(t, rec.Header().Get("Content-Type") == contentTypeApplicationJSONCharsetUTF8)
+
+	expectTrue(t, errors.Is(Encoders.Proto().Encode(io.Discard, negotiatedData{}), ErrNotProtoMessage))
+}
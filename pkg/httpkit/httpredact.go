@@ -0,0 +1,161 @@
+package httpkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// RedactedMask is the default replacement of the redacted values.
+const RedactedMask = "[REDACTED]"
+
+// Redaction is the rules for masking the sensitive values of the recorded requests and responses, so they can be
+// logged safely. The zero value redacts nothing.
+type Redaction struct {
+	// Headers is the header names whose values are masked, case-insensitive, e.g. Authorization.
+	Headers []string
+
+	// Fields is the JSON fields whose values are masked, case-insensitive. A single name, e.g. password, matches the
+	// field at any depth, while a dotted path, e.g. card.number, matches from the root. Arrays are traversed as if
+	// they are not there, so items.token matches {"items": [{"token": "..."}]}.
+	Fields []string
+
+	// CardNumbers masks the digit sequences that look like payment card numbers, the ones with 13 to 19 digits
+	// passing the Luhn check, anywhere in the body, including the non-JSON ones.
+	CardNumbers bool
+
+	// Mask replaces the redacted values, default is RedactedMask.
+	Mask string
+}
+
+// DefaultRedaction redacts the common credentials and the payment card numbers.
+var DefaultRedaction = Redaction{
+	Headers:     []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key"},
+	Fields:      []string{"password", "secret", "token", "access_token", "refresh_token", "api_key"},
+	CardNumbers: true,
+}
+
+// Enabled reports whether the redaction has any rule.
+func (r Redaction) Enabled() bool {
+	return len(r.Headers) > 0 || len(r.Fields) > 0 || r.CardNumbers
+}
+
+// Header returns a copy of the header with the values of the redacted headers masked.
+func (r Redaction) Header(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range r.Headers {
+		values := h.Values(name)
+		for i := range values {
+			values[i] = r.mask()
+		}
+	}
+	return h
+}
+
+// Body returns a copy of the body with the redacted values masked. The JSON bodies are re-encoded, so the field order
+// and the formatting may change. The non-JSON bodies are only subject to the card numbers masking.
+func (r Redaction) Body(body []byte) []byte {
+	if !r.Enabled() || len(body) == 0 {
+		return bytes.Clone(body)
+	}
+
+	if len(r.Fields) > 0 {
+		if redacted, ok := r.json(body); ok {
+			body = redacted
+		}
+	}
+
+	if r.CardNumbers {
+		body = cardNumberPattern.ReplaceAllFunc(body, func(b []byte) []byte {
+			if !luhn(b) {
+				return b
+			}
+			return []byte(r.mask())
+		})
+	}
+	return bytes.Clone(body)
+}
+
+// json masks the redacted fields of the JSON body, if the body is not a valid JSON, it returns false.
+func (r Redaction) json(body []byte) ([]byte, bool) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return nil, false
+	}
+
+	fields := make(map[string]struct{}, len(r.Fields))
+	for _, f := range r.Fields {
+		fields[strings.ToLower(f)] = struct{}{}
+	}
+
+	v = r.walk(v, "", fields)
+	redacted, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	return redacted, true
+}
+
+// walk masks the values of the matched fields in the decoded JSON value, the path is the dotted path of the value.
+func (r Redaction) walk(v any, path string, fields map[string]struct{}) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			name := strings.ToLower(k)
+			p := name
+			if path != "" {
+				p = path + "." + name
+			}
+
+			_, byName := fields[name]
+			_, byPath := fields[p]
+			if byName || byPath {
+				t[k] = r.mask()
+				continue
+			}
+			t[k] = r.walk(val, p, fields)
+		}
+	case []any:
+		for i, val := range t {
+			t[i] = r.walk(val, path, fields)
+		}
+	}
+	return v
+}
+
+func (r Redaction) mask() string {
+	if r.Mask == "" {
+		return RedactedMask
+	}
+	return r.Mask
+}
+
+// cardNumberPattern matches 13 to 19 digits, optionally separated by a space or a dash.
+var cardNumberPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+// luhn reports whether the digits in b pass the Luhn check, the separators are ignored.
+func luhn(b []byte) bool {
+	var sum int
+	double := false
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < '0' || b[i] > '9' {
+			continue
+		}
+
+		d := int(b[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
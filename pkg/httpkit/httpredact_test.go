@@ -0,0 +1,95 @@
+package httpkit
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedaction_Body(t *testing.T) {
+	r := Redaction{
+		Fields:      []string{"password", "card.cvv"},
+		CardNumbers: true,
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "field at any depth",
+			body: `{"user":{"name":"john","Password":"s3cret"},"items":[{"password":"x"}]}`,
+			want: `{"items":[{"password":"[REDACTED]"}],"user":{"Password":"[REDACTED]","name":"john"}}`,
+		},
+		{
+			name: "dotted path",
+			body: `{"card":{"cvv":123},"cvv":456}`,
+			want: `{"card":{"cvv":"[REDACTED]"},"cvv":456}`,
+		},
+		{
+			name: "card number in json",
+			body: `{"pan":"4111 1111 1111 1111","phone":"1234567890123"}`,
+			want: `{"pan":"[REDACTED]","phone":"1234567890123"}`,
+		},
+		{
+			name: "card number in text",
+			body: `pan=4111-1111-1111-1111&amount=10`,
+			want: `pan=[REDACTED]&amount=10`,
+		},
+		{
+			name: "invalid json",
+			body: `{"password":`,
+			want: `{"password":`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(r.Body([]byte(tt.body)))
+			if got != tt.want {
+				t.Errorf("want %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRedaction_Header(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer token")
+	h.Set("Accept", "application/json")
+
+	got := DefaultRedaction.Header(h)
+	expectTrue(t, got.Get("Authorization") == RedactedMask)
+	expectTrue(t, got.Get("Accept") == "application/json")
+	expectTrue(t, h.Get("Authorization") == "Bearer token")
+}
+
+func TestLogEntry_Redacted(t *testing.T) {
+	mid := NewLogEntryRecorder(LogRecorderConfig{Redaction: DefaultRedaction})
+	h := mid(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		_, _ = io.WriteString(w, `{"access_token":"abc"}`)
+
+		rec, ok := GetLogEntry(w)
+		expectTrue(t, ok)
+
+		var req, res map[string]string
+		expectTrue(t, json.Unmarshal(rec.RedactedReqBody(), &req) == nil)
+		expectTrue(t, json.Unmarshal(rec.RedactedResBody(), &res) == nil)
+		expectTrue(t, req["password"] == RedactedMask)
+		expectTrue(t, req["email"] == "john@example.com")
+		expectTrue(t, res["access_token"] == RedactedMask)
+
+		// the raw bodies are kept.
+		expectTrue(t, strings.Contains(string(rec.ReqBody().Bytes()), "s3cret"))
+		expectTrue(t, rec.RedactedHeader(r.Header).Get("Cookie") == RedactedMask)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"john@example.com","password":"s3cret"}`))
+	req.Header.Set("Cookie", "session_id=abc")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+}
@@ -0,0 +1,69 @@
+package httpkit
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+)
+
+// HeaderRequestID is the header name for carrying the request ID.
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength is the maximum length of the request ID accepted from the client.
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key for the request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of the context carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext gets the request ID from the context, if not found, it returns an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID is a middleware that makes sure every request has a request ID.
+// The ID from the X-Request-ID request header is reused when it is a reasonable value, e.g. set by a load balancer
+// or the upstream service. Otherwise, a new ID is generated by the provider. The ID is stored in the request context
+// and written to the X-Request-ID response header.
+//
+// If the provider fails to generate an ID, the request is served without a request ID.
+func RequestID(provider idkit.UUIDProvider) NetMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(HeaderRequestID)
+			if !validRequestID(id) {
+				id = ""
+				if uid, err := provider.Request(r.Context()); err == nil {
+					id = uid.String()
+				}
+			}
+
+			if id != "" {
+				w.Header().Set(HeaderRequestID, id)
+				r = r.WithContext(WithRequestID(r.Context(), id))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// validRequestID reports whether the request ID from the client is safe to be reused, it must be non-empty, not too
+// long and only contains printable ASCII characters, so it can't be used for log or header injection.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package httpkit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/josestg/swe-be-mono/pkg/idkit"
+)
+
+func TestRequestID(t *testing.T) {
+	serve := func(provider idkit.UUIDProvider, header string) (string, string) {
+		var fromCtx string
+		handler := RequestID(provider).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fromCtx = RequestIDFromContext(r.Context())
+		}))
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set(HeaderRequestID, header)
+		}
+		handler.ServeHTTP(rec, req)
+		return fromCtx, rec.Header().Get(HeaderRequestID)
+	}
+
+	static := idkit.StaticUUID().String()
+
+	fromCtx, fromHeader := serve(idkit.UUIDv254, "")
+	expectTrue(t, fromCtx == static)
+	expectTrue(t, fromHeader == static)
+
+	fromCtx, fromHeader = serve(idkit.UUIDv254, "upstream-id")
+	expectTrue(t, fromCtx == "upstream-id")
+	expectTrue(t, fromHeader == "upstream-id")
+
+	fromCtx, _ = serve(idkit.UUIDv254, "invalid id\n")
+	expectTrue(t, fromCtx == static)
+
+	fromCtx, _ = serve(idkit.UUIDv254, strings.Repeat("a", maxRequestIDLength+1))
+	expectTrue(t, fromCtx == static)
+
+	fromCtx, fromHeader = serve(idkit.UUIDv255, "")
+	expectTrue(t, fromCtx == "")
+	expectTrue(t, fromHeader == "")
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	expectTrue(t, RequestIDFromContext(context.Background()) == "")
+	expectTrue(t, RequestIDFromContext(WithRequestID(context.Background(), "abc")) == "abc")
+}
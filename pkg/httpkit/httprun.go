@@ -2,25 +2,55 @@ package httpkit
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
+
+	"golang.org/x/crypto/acme/autocert"
 )
 
 // RunConfig is a configuration for creating a http Runner.
 type RunConfig struct {
-	Port            int           // Port to listen to.
-	ShutdownTimeout time.Duration // Maximum duration for waiting all active connections to be closed before force close.
+	Port            int           `env:"PORT,default=8080"`           // Port to listen to.
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT,default=5s"` // Maximum duration for waiting all active connections to be closed before force close.
 
 	// RequestReadTimeout and RequestWriteTimeout are timeouts for http.Server.
 	// These timeouts are used to limit the time spent reading or writing the request body.
 	// see: https://blog.cloudflare.com/the-complete-guide-to-golang-net-http-timeouts.
-	RequestReadTimeout  time.Duration // Maximum duration for reading the entire request, including the body.
-	RequestWriteTimeout time.Duration // Maximum duration before timing out writes of the response.
+	RequestReadTimeout  time.Duration `env:"REQUEST_READ_TIMEOUT,default=5s"`   // Maximum duration for reading the entire request, including the body.
+	RequestWriteTimeout time.Duration `env:"REQUEST_WRITE_TIMEOUT,default=10s"` // Maximum duration before timing out writes of the response.
+
+	// Addr overrides the Port, either a TCP address or a Unix domain socket path prefixed by unix://, see Listen.
+	Addr string `env:"ADDR"`
+
+	// H2C enables HTTP/2 over cleartext TCP, e.g. for a service mesh or a gRPC gateway that talks to the server
+	// without TLS. HTTP/1.1 requests are still served.
+	H2C bool `env:"H2C"`
+
+	// AutoTLSHosts and AutoTLSCacheDir enable HTTPS with the certificates obtained from Let's Encrypt, see
+	// RunOpts.AutoTLS. AutoTLS is disabled if no host is given.
+	AutoTLSHosts    []string `env:"AUTO_TLS_HOSTS"`                   // The hosts allowed to obtain certificates for.
+	AutoTLSCacheDir string   `env:"AUTO_TLS_CACHE_DIR,default=certs"` // The directory for caching the certificates across restarts.
+
+	// AutoTLSFallbackCert and AutoTLSFallbackKey are the PEM files of the certificate served when AutoTLS fails to
+	// obtain one, see RunOpts.AutoTLSFallback. Without them, the server fails to start if the certificates can't be
+	// obtained.
+	AutoTLSFallbackCert string `env:"AUTO_TLS_FALLBACK_CERT"`
+	AutoTLSFallbackKey  string `env:"AUTO_TLS_FALLBACK_KEY"`
+
+	// Upgrade enables the zero-downtime binary upgrade on SIGUSR2, see RunOpts.Upgrade.
+	Upgrade bool `env:"UPGRADE"`
+
+	// DrainDelay is the duration to wait after the shutdown signal before shutting down the server, so the load
+	// balancer has time to stop routing new requests to the server, see RunOpts.DrainDelay.
+	DrainDelay time.Duration `env:"DRAIN_DELAY"`
 }
 
 // Runner is contract for server that can be started, shutdown gracefully and
@@ -65,13 +95,111 @@ func (e RunEvent) String() string {
 	}
 }
 
+// RunEventData is the typed payload of a run event, one of RunInfo, RunAddr, RunError or RunSignal.
+type RunEventData interface {
+	// Event returns the flag of the event.
+	Event() RunEvent
+
+	// String returns the payload as the data string of the legacy event listener.
+	String() string
+}
+
+// RunInfo is the payload of RunEventInfo.
+type RunInfo struct {
+	Message string
+}
+
+// Event implements RunEventData.
+func (RunInfo) Event() RunEvent { return RunEventInfo }
+
+// String implements RunEventData.
+func (e RunInfo) String() string { return e.Message }
+
+// RunAddr is the payload of RunEventAddr.
+type RunAddr struct {
+	Addr net.Addr // the address the server listens to.
+}
+
+// Event implements RunEventData.
+func (RunAddr) Event() RunEvent { return RunEventAddr }
+
+// String implements RunEventData.
+func (e RunAddr) String() string { return e.Addr.String() }
+
+// RunError is the payload of RunEventError.
+type RunError struct {
+	Message string // what failed, e.g. graceful shutdown failed.
+	Err     error  // the cause.
+}
+
+// Event implements RunEventData.
+func (RunError) Event() RunEvent { return RunEventError }
+
+// String implements RunEventData.
+func (e RunError) String() string { return e.Message }
+
+// RunSignal is the payload of RunEventSignal.
+type RunSignal struct {
+	Signal os.Signal // the received signal.
+}
+
+// Event implements RunEventData.
+func (RunSignal) Event() RunEvent { return RunEventSignal }
+
+// String implements RunEventData.
+func (e RunSignal) String() string { return e.Signal.String() }
+
+// RunEventHandler handles the typed run events.
+type RunEventHandler func(data RunEventData)
+
+// AdaptRunEventListener adapts the legacy (RunEvent, string) event listener to RunEventHandler.
+func AdaptRunEventListener(listener func(event RunEvent, data string)) RunEventHandler {
+	return func(data RunEventData) { listener(data.Event(), data.String()) }
+}
+
+// ShutdownHook is a function that is called after the shutdown signal received, but before the server is shut down,
+// e.g. for flipping the readiness to false or flushing the telemetry. The context is canceled when the hook timeout
+// exceeded, then the shutdown goes on without waiting for the hook to return.
+type ShutdownHook func(ctx context.Context) error
+
+// noHookTimeout is the timeout of the shutdown hooks bounded by themselves, e.g. the drain delay.
+const noHookTimeout time.Duration = -1
+
+// shutdownHook is a registered ShutdownHook with its timeout, zero means the default hook timeout and noHookTimeout
+// means no timeout.
+type shutdownHook struct {
+	fn      ShutdownHook
+	timeout time.Duration
+}
+
 // GracefulRunner is a wrapper of http.Server that can be shutdown gracefully.
 type GracefulRunner struct {
 	Runner
 	signalListener chan os.Signal
 	waitTimeout    time.Duration
 	shutdownDone   chan struct{}
-	eventListener  func(event RunEvent, data string)
+	eventListener  RunEventHandler
+
+	// hooks are called in the registration order before shutting down the server.
+	hooks       []shutdownHook
+	hookTimeout time.Duration
+
+	// listener is the listener given by RunOpts.Listener, if nil, the *http.Server listens on its Addr.
+	listener net.Listener
+
+	// autoTLS obtains the certificates, and the challenge server answers its HTTP-01 challenges.
+	autoTLS      *autocert.Manager
+	autoTLSHosts []string
+	challenge    *http.Server
+
+	// fallbackCert and fallbackKey are the files of the certificate served when autoTLS fails, see
+	// RunOpts.AutoTLSFallback.
+	fallbackCert string
+	fallbackKey  string
+
+	// upgradeSignal receives the signal for starting the new process, see RunOpts.Upgrade.
+	upgradeSignal  chan os.Signal
+	upgradeTimeout time.Duration
 }
 
 // RunOption is the option for customizing the GracefulRunner.
@@ -101,64 +229,304 @@ func NewGracefulRunner(server Runner, opts ...RunOption) *GracefulRunner {
 }
 
 // ListenAndServe starts listening and serving the server gracefully.
+// If the Runner is an *http.Server, the address is announced by Listen, so the actual bound address, e.g. of an
+// ephemeral port, is reported by the RunAddr event.
 func (s *GracefulRunner) ListenAndServe() error {
-	if std, ok := s.Runner.(*http.Server); ok {
-		s.eventListener(RunEventAddr, std.Addr)
+	ln, inherited, err := s.listen()
+	if err != nil {
+		s.eventListener(RunError{Message: "server failed", Err: err})
+		return fmt.Errorf("server failed to start: %w", err)
+	}
+
+	if inherited {
+		s.eventListener(RunInfo{Message: "listener inherited from the old process"})
+	}
+
+	if ln != nil {
+		s.eventListener(RunAddr{Addr: ln.Addr()})
 	} else {
-		s.eventListener(RunEventInfo, "server is listening")
+		s.eventListener(RunInfo{Message: "server is listening"})
 	}
 
-	serverErr := make(chan error, 1)
+	// both the original server and the challenge server may fail.
+	serverErr := make(chan error, 2)
 	shutdownCompleted := make(chan struct{})
+
+	// the challenge server is started first, since the original server obtains the certificates before serving.
+	if s.challenge != nil {
+		go func() {
+			err := s.challenge.ListenAndServe()
+			if !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- fmt.Errorf("acme challenge server: %w", err)
+				s.eventListener(RunError{Message: "acme challenge server failed", Err: err})
+			}
+		}()
+	}
+
 	// start the original server.
 	go func() {
-		err := s.Runner.ListenAndServe()
+		err := s.serve(ln)
 		// if shutdown succeeded, http.ErrServerClosed will be returned.
 		if errors.Is(err, http.ErrServerClosed) {
 			shutdownCompleted <- struct{}{}
 		} else {
 			// only send error if it's not http.ErrServerClosed.
 			serverErr <- err
-			s.eventListener(RunEventError, "server failed")
+			s.eventListener(RunError{Message: "server failed", Err: err})
 		}
 	}()
 
+	if inherited {
+		if err := notifyReady(); err != nil {
+			s.eventListener(RunError{Message: "upgrade ready notification failed", Err: err})
+		}
+	}
+
 	// block until signalListener received or mux failed to start.
-	select {
-	case sig := <-s.signalListener:
-		s.eventListener(RunEventSignal, sig.String())
-		s.eventListener(RunEventInfo, "graceful shutdown initiated")
-
-		ctx, cancel := context.WithTimeout(context.Background(), s.waitTimeout)
-		defer cancel()
-
-		err := s.Runner.Shutdown(ctx)
-		// only force shutdown if deadline exceeded.
-		if errors.Is(err, context.DeadlineExceeded) {
-			s.eventListener(RunEventInfo, "forced shutdown initiated")
-			closeErr := s.Runner.Close()
-			if closeErr != nil {
-				s.eventListener(RunEventError, "forced shutdown failed")
-				return fmt.Errorf("deadline exceeded, force shutdown failed: %w", closeErr)
+	for {
+		select {
+		case sig := <-s.upgradeSignal:
+			s.eventListener(RunSignal{Signal: sig})
+			s.eventListener(RunInfo{Message: "upgrade initiated"})
+			if err := upgrade(ln, s.upgradeTimeout); err != nil {
+				// keep serving, the new process is killed.
+				s.eventListener(RunError{Message: "upgrade failed", Err: err})
+				continue
 			}
-			// force shutdown succeeded.
-			s.eventListener(RunEventInfo, "forced shutdown completed")
-			return nil
+			s.eventListener(RunInfo{Message: "upgrade completed, the new process is ready"})
+			return s.shutdownGracefully(sig, shutdownCompleted)
+		case sig := <-s.signalListener:
+			s.eventListener(RunSignal{Signal: sig})
+			return s.shutdownGracefully(sig, shutdownCompleted)
+		case err := <-serverErr:
+			return fmt.Errorf("server failed to start: %w", err)
 		}
+	}
+}
 
-		// unexpected error.
-		if err != nil {
-			s.eventListener(RunEventError, "graceful shutdown failed")
-			return fmt.Errorf("shutdown failed, signal: %s: %w", sig, err)
+// shutdownGracefully runs the shutdown hooks and shuts down the server, it force closes the server if the wait
+// timeout exceeded.
+func (s *GracefulRunner) shutdownGracefully(sig os.Signal, shutdownCompleted <-chan struct{}) error {
+	hookErr := s.runHooks()
+	s.eventListener(RunInfo{Message: "graceful shutdown initiated"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.waitTimeout)
+	defer cancel()
+
+	err := s.shutdown(ctx)
+	// only force shutdown if deadline exceeded.
+	if errors.Is(err, context.DeadlineExceeded) {
+		s.eventListener(RunInfo{Message: "forced shutdown initiated"})
+		closeErr := s.close()
+		if closeErr != nil {
+			s.eventListener(RunError{Message: "forced shutdown failed", Err: closeErr})
+			return fmt.Errorf("deadline exceeded, force shutdown failed: %w", closeErr)
 		}
+		// force shutdown succeeded.
+		s.eventListener(RunInfo{Message: "forced shutdown completed"})
+		return hookErr
+	}
+
+	// unexpected error.
+	if err != nil {
+		s.eventListener(RunError{Message: "graceful shutdown failed", Err: err})
+		return fmt.Errorf("shutdown failed, signal: %s: %w", sig, errors.Join(err, hookErr))
+	}
+
+	// make sure shutdown completed.
+	<-shutdownCompleted
+	s.eventListener(RunInfo{Message: "graceful shutdown completed"})
+	return hookErr
+}
 
-		// make sure shutdown completed.
-		<-shutdownCompleted
-		s.eventListener(RunEventInfo, "graceful shutdown completed")
+// runHooks calls the shutdown hooks in the registration order, each with its own timeout. A failed hook doesn't stop
+// the shutdown, the errors are reported and joined instead.
+func (s *GracefulRunner) runHooks() error {
+	if len(s.hooks) == 0 {
 		return nil
-	case err := <-serverErr:
-		return fmt.Errorf("server failed to start: %w", err)
 	}
+
+	s.eventListener(RunInfo{Message: "shutdown hooks started"})
+	var errs []error
+	for i, hook := range s.hooks {
+		timeout := hook.timeout
+		if timeout == 0 {
+			timeout = s.hookTimeout
+		}
+
+		if err := runHook(hook.fn, timeout); err != nil {
+			err = fmt.Errorf("shutdown hook #%d: %w", i, err)
+			s.eventListener(RunError{Message: "shutdown hook failed", Err: err})
+			errs = append(errs, err)
+		}
+	}
+	s.eventListener(RunInfo{Message: "shutdown hooks completed"})
+	return errors.Join(errs...)
+}
+
+// runHook calls the hook in its own goroutine, so a hook that ignores its context doesn't block the shutdown, it is
+// abandoned with the context error when the timeout exceeded. A negative timeout means no timeout.
+func runHook(hook ShutdownHook, timeout time.Duration) error {
+	if timeout < 0 {
+		return hook(context.Background())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- hook(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// listen returns the listener of the *http.Server, either the one inherited from the old process on upgrade, the one
+// given by RunOpts.Listener or a new one on its Addr. Other Runners listen by themselves, so it returns nil.
+func (s *GracefulRunner) listen() (ln net.Listener, inherited bool, err error) {
+	std, ok := s.Runner.(*http.Server)
+	if !ok {
+		if s.listener != nil || s.autoTLS != nil || s.upgradeSignal != nil {
+			return nil, false, errors.New("httpkit: custom listener, auto tls and upgrade require *http.Server")
+		}
+		return nil, false, nil
+	}
+
+	if s.upgradeSignal != nil {
+		ln, err := inheritListener()
+		if err != nil || ln != nil {
+			return ln, ln != nil, err
+		}
+	}
+
+	if s.listener != nil {
+		return s.listener, false, nil
+	}
+
+	addr := std.Addr
+	if addr == "" {
+		addr = ":http"
+		if s.autoTLS != nil {
+			addr = ":https"
+		}
+	}
+	ln, err = Listen(addr)
+	return ln, false, err
+}
+
+// serve starts the original server, over TLS with the certificates of the autocert.Manager if AutoTLS is enabled.
+func (s *GracefulRunner) serve(ln net.Listener) error {
+	std, ok := s.Runner.(*http.Server)
+	if !ok {
+		return s.Runner.ListenAndServe()
+	}
+
+	if s.autoTLS != nil {
+		cfg, err := s.tlsConfig()
+		if err != nil {
+			return err
+		}
+		std.TLSConfig = cfg
+		return std.ServeTLS(ln, "", "")
+	}
+	return std.Serve(ln)
+}
+
+// tlsConfig returns the TLS configuration of AutoTLS. The certificates are obtained before serving, so a failure,
+// e.g. a rate limit of Let's Encrypt or a host not pointing to the server, fails the start instead of every TLS
+// handshake. If the fallback certificate is given, it is served instead, both at the start and on the later
+// failures, e.g. of the renewals.
+func (s *GracefulRunner) tlsConfig() (*tls.Config, error) {
+	cfg := s.autoTLS.TLSConfig()
+	if s.fallbackCert == "" {
+		if err := s.obtainCertificates(); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+
+	fallback, err := tls.LoadX509KeyPair(s.fallbackCert, s.fallbackKey)
+	if err != nil {
+		return nil, fmt.Errorf("load fallback certificate: %w", err)
+	}
+	if err := s.obtainCertificates(); err != nil {
+		s.eventListener(RunError{Message: "serving the fallback certificate", Err: err})
+	}
+
+	getCertificate := cfg.GetCertificate
+	cfg.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		cert, err := getCertificate(hello)
+		if err != nil {
+			s.eventListener(RunError{Message: "serving the fallback certificate", Err: err})
+			return &fallback, nil
+		}
+		return cert, nil
+	}
+	return cfg, nil
+}
+
+// obtainCertificates obtains both the ECDSA and the RSA certificates of the hosts of AutoTLS, or loads them from the
+// cache. autocert picks the key type by the client hello, so the ECDSA certificate that most of the clients get is
+// only obtained by a hello supporting it.
+func (s *GracefulRunner) obtainCertificates() error {
+	for _, host := range s.autoTLSHosts {
+		ecdsaHello := &tls.ClientHelloInfo{
+			ServerName:       host,
+			SignatureSchemes: []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+			SupportedCurves:  []tls.CurveID{tls.CurveP256},
+			CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		}
+		rsaHello := &tls.ClientHelloInfo{ServerName: host}
+		for _, hello := range []*tls.ClientHelloInfo{ecdsaHello, rsaHello} {
+			if _, err := s.autoTLS.GetCertificate(hello); err != nil {
+				return fmt.Errorf("obtain certificate of %s: %w", host, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Listen announces on the address, either a TCP address, e.g. 0.0.0.0:8080 or :0 for an ephemeral port, or a Unix
+// domain socket path prefixed by unix://, e.g. unix:///run/app.sock. A stale socket file left by a crashed process
+// is removed before listening, but not the one that is still accepting connections.
+func Listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, "unix://")
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("httpkit: listen %s: socket is in use", addr)
+		}
+
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("httpkit: remove stale socket: %w", err)
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// shutdown gracefully shuts down the original server and the challenge server, if any.
+func (s *GracefulRunner) shutdown(ctx context.Context) error {
+	err := s.Runner.Shutdown(ctx)
+	if s.challenge != nil {
+		err = errors.Join(err, s.challenge.Shutdown(ctx))
+	}
+	return err
+}
+
+// close force closes the original server and the challenge server, if any.
+func (s *GracefulRunner) close() error {
+	err := s.Runner.Close()
+	if s.challenge != nil {
+		err = errors.Join(err, s.challenge.Close())
+	}
+	return err
 }
 
 // runOptionNamespace is type for grouping run options.
@@ -177,9 +545,13 @@ func (runOptionNamespace) Default() RunOption {
 			RunOpts.WaitTimeout(5 * time.Second).apply(s)
 		}
 
+		if s.hookTimeout <= 0 {
+			RunOpts.HookTimeout(5 * time.Second).apply(s)
+		}
+
 		if s.eventListener == nil {
 			// noop event listener.
-			RunOpts.EventListener(func(event RunEvent, data string) {}).apply(s)
+			RunOpts.OnEvent(func(RunEventData) {}).apply(s)
 		}
 	}
 }
@@ -198,7 +570,75 @@ func (runOptionNamespace) WaitTimeout(timeout time.Duration) RunOption {
 	return func(s *GracefulRunner) { s.waitTimeout = timeout }
 }
 
+// OnEvent sets the handler that will be called with the typed payload when an event occurred.
+func (runOptionNamespace) OnEvent(handler RunEventHandler) RunOption {
+	return func(s *GracefulRunner) { s.eventListener = handler }
+}
+
+// OnShutdown registers the hook that will be called after the shutdown signal received, but before the server is
+// shut down. Hooks are called sequentially in the registration order, each limited by the hook timeout.
+func (runOptionNamespace) OnShutdown(hook ShutdownHook) RunOption {
+	return func(s *GracefulRunner) { s.hooks = append(s.hooks, shutdownHook{fn: hook}) }
+}
+
+// HookTimeout sets the maximum duration of each shutdown hook. The default is 5 seconds.
+func (runOptionNamespace) HookTimeout(timeout time.Duration) RunOption {
+	return func(s *GracefulRunner) { s.hookTimeout = timeout }
+}
+
+// DrainDelay registers a shutdown hook that waits for the delay, e.g. for the load balancer to notice the readiness
+// is flipped and stop routing new requests, while the server still serves the in-flight and late requests. Register it
+// after the hooks that flip the readiness. The delay isn't limited by the hook timeout.
+func (runOptionNamespace) DrainDelay(delay time.Duration) RunOption {
+	return func(s *GracefulRunner) {
+		if delay <= 0 {
+			return
+		}
+
+		drain := func(context.Context) error {
+			time.Sleep(delay)
+			return nil
+		}
+		s.hooks = append(s.hooks, shutdownHook{fn: drain, timeout: noHookTimeout})
+	}
+}
+
+// Listener serves the *http.Server on the given listener instead of its Addr, e.g. a listener inherited from the
+// parent process or created by Listen.
+func (runOptionNamespace) Listener(ln net.Listener) RunOption {
+	return func(s *GracefulRunner) { s.listener = ln }
+}
+
+// AutoTLS serves HTTPS with the certificates obtained from Let's Encrypt for the hosts, the certificates are cached in
+// the cacheDir. It also starts a server on port 80 for answering the HTTP-01 challenges, which redirects the other
+// requests to HTTPS. The Runner must be an *http.Server, usually listening on port 443. The certificates are obtained
+// before serving, the server fails to start if they can't be, unless the AutoTLSFallback is given.
+func (runOptionNamespace) AutoTLS(hosts []string, cacheDir string) RunOption {
+	return func(s *GracefulRunner) {
+		s.autoTLSHosts = hosts
+		s.autoTLS = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(hosts...),
+			Cache:      autocert.DirCache(cacheDir),
+		}
+		s.challenge = &http.Server{
+			Addr:              ":http",
+			Handler:           s.autoTLS.HTTPHandler(nil),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+	}
+}
+
+// AutoTLSFallback serves the certificate of the PEM files when AutoTLS fails to obtain one, e.g. a self-signed or a
+// manually issued certificate, instead of failing the start or the TLS handshakes. The server fails to start if the
+// files can't be loaded.
+func (runOptionNamespace) AutoTLSFallback(certFile, keyFile string) RunOption {
+	return func(s *GracefulRunner) { s.fallbackCert, s.fallbackKey = certFile, keyFile }
+}
+
 // EventListener sets the listener that will be called when an event occurred.
+//
+// Deprecated: use OnEvent, the payloads are typed, so listeners don't have to parse the data string.
 func (runOptionNamespace) EventListener(listener func(event RunEvent, data string)) RunOption {
-	return func(s *GracefulRunner) { s.eventListener = listener }
+	return RunOpts.OnEvent(AdaptRunEventListener(listener))
 }
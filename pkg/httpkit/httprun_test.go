@@ -2,12 +2,25 @@ package httpkit
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
+	"math/big"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
+
+	"golang.org/x/crypto/acme"
 )
 
 func TestNewGracefulRunner_DefaultOption(t *testing.T) {
@@ -17,6 +30,7 @@ func TestNewGracefulRunner_DefaultOption(t *testing.T) {
 	expectTrue(t, run.eventListener != nil)
 	expectTrue(t, run.signalListener != nil)
 	expectTrue(t, run.waitTimeout == 5*time.Second)
+	expectTrue(t, run.hookTimeout == 5*time.Second)
 }
 
 func TestNewGracefulRunner_CustomOption(t *testing.T) {
@@ -207,3 +221,372 @@ func shutdown(err error) func(context.Context) error {
 		return err
 	}
 }
+
+func TestGracefulRunner_OnEvent(t *testing.T) {
+	var anError = errors.New("an error")
+	server := &serverMock{
+		tracer:             visitedNone,
+		ListenAndServeFunc: listener(100*time.Millisecond, http.ErrServerClosed),
+		ShutdownFunc:       shutdown(anError),
+	}
+
+	var (
+		mu     sync.Mutex
+		events []RunEventData
+	)
+	run := NewGracefulRunner(server, RunOpts.OnEvent(func(data RunEventData) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, data)
+	}))
+	time.AfterFunc(50*time.Millisecond, func() { run.signalListener <- os.Interrupt })
+
+	err := run.ListenAndServe()
+	expectTrue(t, errors.Is(err, anError))
+
+	mu.Lock()
+	defer mu.Unlock()
+	expectTrue(t, len(events) == 4)
+	expectTrue(t, events[0] == RunInfo{Message: "server is listening"})
+	expectTrue(t, events[1] == RunSignal{Signal: os.Interrupt})
+	expectTrue(t, events[2].Event() == RunEventInfo)
+
+	runErr, ok := events[3].(RunError)
+	expectTrue(t, ok)
+	expectTrue(t, errors.Is(runErr.Err, anError))
+}
+
+func TestAdaptRunEventListener(t *testing.T) {
+	var (
+		gotEvent RunEvent
+		gotData  string
+	)
+	listener := AdaptRunEventListener(func(event RunEvent, data string) {
+		gotEvent, gotData = event, data
+	})
+
+	listener(RunAddr{Addr: &net.TCPAddr{IP: net.IPv4zero, Port: 8080}})
+	expectTrue(t, gotEvent == RunEventAddr)
+	expectTrue(t, gotData == "0.0.0.0:8080")
+
+	listener(RunError{Message: "server failed", Err: errors.New("an error")})
+	expectTrue(t, gotEvent == RunEventError)
+	expectTrue(t, gotData == "server failed")
+}
+
+func TestRunOpts_AutoTLS(t *testing.T) {
+	run := NewGracefulRunner(&http.Server{}, RunOpts.AutoTLS([]string{"example.com"}, t.TempDir()))
+	expectTrue(t, run.autoTLS != nil)
+	expectTrue(t, run.challenge != nil)
+	expectTrue(t, run.challenge.Addr == ":http")
+
+	expectTrue(t, run.autoTLS.HostPolicy(context.Background(), "example.com") == nil)
+	expectTrue(t, run.autoTLS.HostPolicy(context.Background(), "evil.com") != nil)
+}
+
+func TestGracefulRunner_AutoTLSRequiresHTTPServer(t *testing.T) {
+	server := &serverMock{tracer: visitedNone}
+	run := NewGracefulRunner(server, RunOpts.AutoTLS([]string{"example.com"}, t.TempDir()))
+	run.challenge = nil // don't bind port 80 in tests.
+
+	err := run.ListenAndServe()
+	expectTrue(t, err != nil)
+	expectFalse(t, server.Tracer().has(listenAndServeVisited))
+}
+
+// newAutoTLSRunner creates the runner of the AutoTLS whose certificates can't be obtained, since the ACME directory
+// doesn't exist.
+func newAutoTLSRunner(t *testing.T, opts ...RunOption) (*GracefulRunner, chan net.Addr) {
+	t.Helper()
+	addrs := make(chan net.Addr, 1)
+	opts = append([]RunOption{
+		RunOpts.AutoTLS([]string{"example.com"}, t.TempDir()),
+		RunOpts.OnEvent(func(data RunEventData) {
+			if evt, ok := data.(RunAddr); ok {
+				addrs <- evt.Addr
+			}
+		}),
+	}, opts...)
+
+	run := NewGracefulRunner(&http.Server{Addr: "127.0.0.1:0"}, opts...)
+	run.challenge = nil // don't bind port 80 in tests.
+	run.autoTLS.Client = &acme.Client{DirectoryURL: "http://127.0.0.1:1/directory"}
+	return run, addrs
+}
+
+// writeSelfSignedCert writes the PEM files of a self-signed certificate of the host.
+func writeSelfSignedCert(t *testing.T, host string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	expectTrue(t, err == nil)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	expectTrue(t, err == nil)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	expectTrue(t, err == nil)
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	expectTrue(t, os.WriteFile(certFile, certPEM, 0o600) == nil)
+	expectTrue(t, os.WriteFile(keyFile, keyPEM, 0o600) == nil)
+	return certFile, keyFile
+}
+
+func TestGracefulRunner_AutoTLSFailsToStart(t *testing.T) {
+	run, _ := newAutoTLSRunner(t)
+	err := run.ListenAndServe()
+	expectTrue(t, err != nil)
+}
+
+func TestGracefulRunner_AutoTLSFallback(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, "example.com")
+	run, addrs := newAutoTLSRunner(t, RunOpts.AutoTLSFallback(certFile, keyFile))
+
+	done := make(chan error, 1)
+	go func() { done <- run.ListenAndServe() }()
+	addr := <-addrs
+
+	var conn *tls.Conn
+	var err error
+	for i := 0; i < 50; i++ { // the certificates are obtained before serving.
+		conn, err = tls.Dial("tcp", addr.String(), &tls.Config{ServerName: "example.com", InsecureSkipVerify: true})
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	expectTrue(t, err == nil)
+	expectTrue(t, conn.ConnectionState().PeerCertificates[0].Subject.CommonName == "example.com")
+	_ = conn.Close()
+
+	run.signalListener <- os.Interrupt
+	expectTrue(t, <-done == nil)
+
+	// the fallback certificate must be loadable.
+	run, _ = newAutoTLSRunner(t, RunOpts.AutoTLSFallback(certFile, filepath.Join(t.TempDir(), "missing.pem")))
+	expectTrue(t, run.ListenAndServe() != nil)
+}
+
+func TestGracefulRunner_AutoTLSObtainsBothKeyTypes(t *testing.T) {
+	run, _ := newAutoTLSRunner(t)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	expectTrue(t, err == nil)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	expectTrue(t, err == nil)
+
+	// only the RSA certificate is cached, the ECDSA one can't be obtained.
+	cacheCert(t, run, "example.com+rsa", rsaKey, &rsaKey.PublicKey)
+	expectTrue(t, run.obtainCertificates() != nil)
+
+	run, _ = newAutoTLSRunner(t)
+	cacheCert(t, run, "example.com+rsa", rsaKey, &rsaKey.PublicKey)
+	cacheCert(t, run, "example.com", ecdsaKey, &ecdsaKey.PublicKey)
+	expectTrue(t, run.obtainCertificates() == nil)
+}
+
+// cacheCert stores a self-signed certificate of example.com in the autocert cache of the runner.
+func cacheCert(t *testing.T, run *GracefulRunner, name string, key, pub any) {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, pub, key)
+	expectTrue(t, err == nil)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	expectTrue(t, err == nil)
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	expectTrue(t, run.autoTLS.Cache.Put(context.Background(), name, data) == nil)
+}
+
+func TestGracefulRunner_EphemeralPort(t *testing.T) {
+	srv := &http.Server{
+		Addr: "127.0.0.1:0",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	}
+
+	addrs := make(chan net.Addr, 1)
+	run := NewGracefulRunner(srv, RunOpts.OnEvent(func(data RunEventData) {
+		if evt, ok := data.(RunAddr); ok {
+			addrs <- evt.Addr
+		}
+	}))
+
+	done := make(chan error, 1)
+	go func() { done <- run.ListenAndServe() }()
+
+	addr := <-addrs
+	expectTrue(t, addr.(*net.TCPAddr).Port != 0)
+
+	res, err := http.Get("http://" + addr.String())
+	expectTrue(t, err == nil)
+	_ = res.Body.Close()
+	expectTrue(t, res.StatusCode == http.StatusNoContent)
+
+	run.signalListener <- os.Interrupt
+	expectTrue(t, <-done == nil)
+}
+
+func TestListen_Unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+
+	ln, err := Listen("unix://" + path)
+	expectTrue(t, err == nil)
+	expectTrue(t, ln.Addr().Network() == "unix")
+
+	// the socket is still accepting connections.
+	_, err = Listen("unix://" + path)
+	expectTrue(t, err != nil)
+
+	// a stale socket file is replaced.
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	expectTrue(t, ln.Close() == nil)
+
+	ln, err = Listen("unix://" + path)
+	expectTrue(t, err == nil)
+	expectTrue(t, ln.Close() == nil)
+}
+
+func TestRunOpts_ListenerRequiresHTTPServer(t *testing.T) {
+	ln, err := Listen("127.0.0.1:0")
+	expectTrue(t, err == nil)
+	defer func() { _ = ln.Close() }()
+
+	server := &serverMock{tracer: visitedNone}
+	err = NewGracefulRunner(server, RunOpts.Listener(ln)).ListenAndServe()
+	expectTrue(t, err != nil)
+	expectFalse(t, server.Tracer().has(listenAndServeVisited))
+}
+
+func TestGracefulRunner_OnShutdown(t *testing.T) {
+	var anError = errors.New("an error")
+
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	hook := func(name string, err error) ShutdownHook {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			_, ok := ctx.Deadline()
+			expectTrue(t, ok)
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	server := &serverMock{
+		tracer:             visitedNone,
+		ListenAndServeFunc: listener(100*time.Millisecond, http.ErrServerClosed),
+		ShutdownFunc: func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, "shutdown")
+			return nil
+		},
+	}
+
+	run := NewGracefulRunner(server,
+		RunOpts.OnShutdown(hook("readiness", nil)),
+		RunOpts.OnShutdown(hook("telemetry", anError)),
+		RunOpts.OnShutdown(hook("cache", nil)),
+	)
+	time.AfterFunc(50*time.Millisecond, func() { run.signalListener <- os.Interrupt })
+
+	err := run.ListenAndServe()
+	expectTrue(t, errors.Is(err, anError))
+
+	mu.Lock()
+	defer mu.Unlock()
+	expectTrue(t, len(calls) == 4)
+	expectTrue(t, calls[0] == "readiness")
+	expectTrue(t, calls[1] == "telemetry")
+	expectTrue(t, calls[2] == "cache")
+	expectTrue(t, calls[3] == "shutdown")
+}
+
+func TestGracefulRunner_OnShutdownTimeout(t *testing.T) {
+	server := &serverMock{
+		tracer:             visitedNone,
+		ListenAndServeFunc: listener(100*time.Millisecond, http.ErrServerClosed),
+		ShutdownFunc:       shutdown(nil),
+	}
+
+	run := NewGracefulRunner(server,
+		RunOpts.HookTimeout(10*time.Millisecond),
+		RunOpts.OnShutdown(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	)
+	time.AfterFunc(50*time.Millisecond, func() { run.signalListener <- os.Interrupt })
+
+	err := run.ListenAndServe()
+	expectTrue(t, errors.Is(err, context.DeadlineExceeded))
+	expectTrue(t, server.Tracer().has(shutdownVisited))
+	expectFalse(t, server.Tracer().has(closeVisited))
+}
+
+func TestGracefulRunner_OnShutdownIgnoringTimeout(t *testing.T) {
+	server := &serverMock{
+		tracer:             visitedNone,
+		ListenAndServeFunc: listener(100*time.Millisecond, http.ErrServerClosed),
+		ShutdownFunc:       shutdown(nil),
+	}
+
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	// the hook ignores its context, so it is abandoned when the hook timeout exceeded.
+	run := NewGracefulRunner(server,
+		RunOpts.HookTimeout(10*time.Millisecond),
+		RunOpts.OnShutdown(func(context.Context) error {
+			<-release
+			return nil
+		}),
+	)
+	time.AfterFunc(50*time.Millisecond, func() { run.signalListener <- os.Interrupt })
+
+	err := run.ListenAndServe()
+	expectTrue(t, errors.Is(err, context.DeadlineExceeded))
+	expectTrue(t, server.Tracer().has(shutdownVisited))
+}
+
+func TestRunOpts_DrainDelay(t *testing.T) {
+	var shutdownAt time.Time
+	server := &serverMock{
+		tracer:             visitedNone,
+		ListenAndServeFunc: listener(200*time.Millisecond, http.ErrServerClosed),
+		ShutdownFunc: func(ctx context.Context) error {
+			shutdownAt = time.Now()
+			return nil
+		},
+	}
+
+	// the drain delay exceeds the hook timeout.
+	run := NewGracefulRunner(server, RunOpts.HookTimeout(time.Millisecond), RunOpts.DrainDelay(50*time.Millisecond))
+	signaledAt := time.Now()
+	run.signalListener <- os.Interrupt
+
+	err := run.ListenAndServe()
+	expectTrue(t, err == nil)
+	expectTrue(t, shutdownAt.Sub(signaledAt) >= 50*time.Millisecond)
+	expectTrue(t, len(NewGracefulRunner(server, RunOpts.DrainDelay(0)).hooks) == 0)
+}
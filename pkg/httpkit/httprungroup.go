@@ -0,0 +1,80 @@
+package httpkit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// runGroup is a Runner that runs several Runners as one.
+type runGroup struct {
+	runners []Runner
+}
+
+// RunGroup groups the runners into a single Runner, e.g. the public API, the internal admin and the metrics servers,
+// so they are started together and shut down gracefully on a single signal by wrapping the group with
+// NewGracefulRunner:
+//
+//	NewGracefulRunner(RunGroup(api, admin, metrics)).ListenAndServe()
+//
+// If any of the runners fails, the others are force closed and the first error is returned by ListenAndServe.
+func RunGroup(runners ...Runner) Runner {
+	return &runGroup{runners: runners}
+}
+
+// ListenAndServe starts all runners and blocks until all of them are stopped. It returns the first error other than
+// http.ErrServerClosed, otherwise http.ErrServerClosed.
+func (g *runGroup) ListenAndServe() error {
+	if len(g.runners) == 0 {
+		return errors.New("httpkit: empty run group")
+	}
+
+	errs := make(chan error, len(g.runners))
+	for _, r := range g.runners {
+		go func(r Runner) { errs <- r.ListenAndServe() }(r)
+	}
+
+	var fatal error
+	for range g.runners {
+		err := <-errs
+		if err == nil || errors.Is(err, http.ErrServerClosed) || fatal != nil {
+			continue
+		}
+
+		// stop the others, as the group can't serve partially.
+		fatal = err
+		_ = g.Close()
+	}
+
+	if fatal != nil {
+		return fatal
+	}
+	return http.ErrServerClosed
+}
+
+// Shutdown gracefully shuts down all runners concurrently, so they share the same deadline.
+func (g *runGroup) Shutdown(ctx context.Context) error {
+	return g.each(func(r Runner) error { return r.Shutdown(ctx) })
+}
+
+// Close force closes all runners.
+func (g *runGroup) Close() error {
+	return g.each(Runner.Close)
+}
+
+// each calls fn for all runners concurrently and joins the errors.
+func (g *runGroup) each(fn func(Runner) error) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(g.runners))
+	for i, r := range g.runners {
+		wg.Add(1)
+		go func(i int, r Runner) {
+			defer wg.Done()
+			errs[i] = fn(r)
+		}(i, r)
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,79 @@
+package httpkit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRunGroup_Shutdown(t *testing.T) {
+	api := &serverMock{
+		tracer:             visitedNone,
+		ListenAndServeFunc: listener(100*time.Millisecond, http.ErrServerClosed),
+		ShutdownFunc:       shutdown(nil),
+	}
+	admin := &serverMock{
+		tracer:             visitedNone,
+		ListenAndServeFunc: listener(150*time.Millisecond, http.ErrServerClosed),
+		ShutdownFunc:       shutdown(nil),
+	}
+
+	run := NewGracefulRunner(RunGroup(api, admin))
+	time.AfterFunc(50*time.Millisecond, func() { run.signalListener <- os.Interrupt })
+
+	err := run.ListenAndServe()
+	expectTrue(t, err == nil)
+	for _, s := range []*serverMock{api, admin} {
+		expectTrue(t, s.Tracer().has(listenAndServeVisited))
+		expectTrue(t, s.Tracer().has(shutdownVisited))
+		expectFalse(t, s.Tracer().has(closeVisited))
+	}
+}
+
+func TestRunGroup_FirstFatalError(t *testing.T) {
+	var anError = errors.New("an error")
+
+	closed := make(chan struct{})
+	api := &serverMock{
+		tracer:             visitedNone,
+		ListenAndServeFunc: listener(10*time.Millisecond, anError),
+		CloseFunc:          func() error { return nil },
+	}
+	admin := &serverMock{
+		tracer: visitedNone,
+		ListenAndServeFunc: func() error {
+			<-closed
+			return http.ErrServerClosed
+		},
+		CloseFunc: func() error {
+			close(closed)
+			return nil
+		},
+	}
+
+	err := NewGracefulRunner(RunGroup(api, admin)).ListenAndServe()
+	expectTrue(t, errors.Is(err, anError))
+	expectTrue(t, admin.Tracer().has(closeVisited))
+	expectFalse(t, admin.Tracer().has(shutdownVisited))
+}
+
+func TestRunGroup_ShutdownDeadline(t *testing.T) {
+	api := &serverMock{ShutdownFunc: shutdown(nil)}
+	admin := &serverMock{ShutdownFunc: shutdown(context.DeadlineExceeded)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := RunGroup(api, admin).Shutdown(ctx)
+	expectTrue(t, errors.Is(err, context.DeadlineExceeded))
+	expectTrue(t, api.Tracer().has(shutdownVisited))
+}
+
+func TestRunGroup_Empty(t *testing.T) {
+	err := RunGroup().ListenAndServe()
+	expectTrue(t, err != nil)
+	expectFalse(t, errors.Is(err, http.ErrServerClosed))
+}
@@ -0,0 +1,134 @@
+package httpkit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// ErrOverloaded is returned by LimitConcurrency when the request is shed, it should be mapped to 503 Service
+// Unavailable.
+var ErrOverloaded = errors.New("httpkit: server overloaded")
+
+// ConcurrencyEvent is a flag to differentiate concurrency limiter events.
+type ConcurrencyEvent uint8
+
+// Sets of concurrency limiter events.
+const (
+	ConcurrencyEventAcquired ConcurrencyEvent = iota // for telling the request is admitted.
+	ConcurrencyEventReleased                         // for telling the admitted request is completed.
+	ConcurrencyEventRejected                         // for telling the request is shed.
+)
+
+// String returns the string representation of ConcurrencyEvent for logging readability.
+func (e ConcurrencyEvent) String() string {
+	switch e {
+	case ConcurrencyEventAcquired:
+		return "acquired"
+	case ConcurrencyEventReleased:
+		return "released"
+	case ConcurrencyEventRejected:
+		return "rejected"
+	default:
+		return "unknown"
+	}
+}
+
+// ConcurrencyStats is the state of the concurrency limiter when an event happens.
+type ConcurrencyStats struct {
+	InFlight int           // the number of admitted requests that are being served.
+	Waited   time.Duration // the time the request waited in the queue.
+}
+
+// ConcurrencyConfig is the configuration for LimitConcurrency.
+type ConcurrencyConfig struct {
+	// MaxInFlight is the maximum number of requests served at the same time.
+	MaxInFlight int
+
+	// MaxWait is the maximum time a request waits for a slot before it is shed. Zero sheds immediately.
+	MaxWait time.Duration
+
+	// RetryAfter is the value of the Retry-After header of the shed requests. Default 1 second.
+	RetryAfter time.Duration
+
+	// Listener is an optional hook for observing the limiter, e.g. for counting the rejection rate.
+	// It is called synchronously, so it must be fast.
+	Listener func(evt ConcurrencyEvent, stats ConcurrencyStats)
+}
+
+// LimitConcurrency is a middleware that caps the number of in-flight requests. It can be applied globally by
+// Opts.Middleware or per route by ServeMux.Route, every call creates an independent limit.
+//
+// When all slots are taken, the request waits up to MaxWait for a slot, and if none is released in time, the
+// handler is not called and ErrOverloaded is returned with the Retry-After header set.
+func LimitConcurrency(cfg ConcurrencyConfig) MuxMiddleware {
+	if cfg.MaxInFlight <= 0 {
+		panic(fmt.Sprintf("httpkit: LimitConcurrency: MaxInFlight must be positive, got %d", cfg.MaxInFlight))
+	}
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = time.Second
+	}
+	if cfg.Listener == nil {
+		cfg.Listener = func(ConcurrencyEvent, ConcurrencyStats) {}
+	}
+
+	var inFlight atomic.Int64
+	slots := make(chan struct{}, cfg.MaxInFlight)
+	retryAfter := strconv.Itoa(int(math.Ceil(cfg.RetryAfter.Seconds())))
+
+	return func(next Handler) Handler {
+		return HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			start := time.Now()
+			if err := acquireSlot(r, slots, cfg.MaxWait); err != nil {
+				cfg.Listener(ConcurrencyEventRejected, ConcurrencyStats{
+					InFlight: int(inFlight.Load()),
+					Waited:   time.Since(start),
+				})
+
+				if errors.Is(err, ErrOverloaded) {
+					w.Header().Set("Retry-After", retryAfter)
+				}
+				return err
+			}
+
+			waited := time.Since(start)
+			cfg.Listener(ConcurrencyEventAcquired, ConcurrencyStats{InFlight: int(inFlight.Add(1)), Waited: waited})
+			defer func() {
+				<-slots
+				cfg.Listener(ConcurrencyEventReleased, ConcurrencyStats{InFlight: int(inFlight.Add(-1)), Waited: waited})
+			}()
+
+			return next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// acquireSlot takes a slot, waiting at most maxWait. It returns ErrOverloaded when no slot is released in time, or
+// the context error when the client goes away while waiting.
+func acquireSlot(r *http.Request, slots chan struct{}, maxWait time.Duration) error {
+	select {
+	case slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if maxWait <= 0 {
+		return fmt.Errorf("no slot available: %w", ErrOverloaded)
+	}
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("no slot available after %s: %w", maxWait, ErrOverloaded)
+	case <-r.Context().Done():
+		return fmt.Errorf("wait for slot: %w", r.Context().Err())
+	}
+}
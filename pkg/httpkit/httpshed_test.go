@@ -0,0 +1,78 @@
+package httpkit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimitConcurrency(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events = make(map[ConcurrencyEvent]int)
+	)
+
+	mid := LimitConcurrency(ConcurrencyConfig{
+		MaxInFlight: 1,
+		MaxWait:     20 * time.Millisecond,
+		RetryAfter:  1500 * time.Millisecond,
+		Listener: func(evt ConcurrencyEvent, _ ConcurrencyStats) {
+			mu.Lock()
+			events[evt]++
+			mu.Unlock()
+		},
+	})
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	slow := mid(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		close(entered)
+		<-release
+		return nil
+	}))
+
+	done := make(chan error)
+	go func() {
+		done <- slow.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	<-entered
+
+	// the only slot is taken, the request is shed after waiting.
+	rec := httptest.NewRecorder()
+	err := slow.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	expectTrue(t, errors.Is(err, ErrOverloaded))
+	expectTrue(t, rec.Header().Get("Retry-After") == "2")
+
+	close(release)
+	expectTrue(t, <-done == nil)
+
+	// the slot is released.
+	fast := mid(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error { return nil }))
+	expectTrue(t, fast.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)) == nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	expectTrue(t, events[ConcurrencyEventAcquired] == 2)
+	expectTrue(t, events[ConcurrencyEventReleased] == 2)
+	expectTrue(t, events[ConcurrencyEventRejected] == 1)
+}
+
+func TestLimitConcurrency_WaitForSlot(t *testing.T) {
+	mid := LimitConcurrency(ConcurrencyConfig{MaxInFlight: 1, MaxWait: time.Second})
+
+	entered := make(chan struct{})
+	first := mid(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		close(entered)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}))
+
+	go func() { _ = first.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)) }()
+	<-entered
+
+	second := mid(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error { return nil }))
+	expectTrue(t, second.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)) == nil)
+}
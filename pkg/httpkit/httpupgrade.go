@@ -0,0 +1,28 @@
+package httpkit
+
+import "time"
+
+// The environment variables telling the new process the file descriptors passed by the old process on upgrade.
+const (
+	envUpgradeListenerFD = "HTTPKIT_UPGRADE_LISTENER_FD" // the inherited listener.
+	envUpgradeReadyFD    = "HTTPKIT_UPGRADE_READY_FD"    // the pipe for telling the old process the new one is ready.
+)
+
+// Upgrade enables the zero-downtime binary upgrade, e.g. for deployments without an orchestrator. On SIGUSR2, the
+// runner starts a new process of the current executable with the same arguments, passing its listener, and shuts
+// down gracefully once the new process serves on the inherited listener. The pending connections are queued by the
+// shared socket, so none is dropped. If the new process fails or isn't ready within the readyTimeout, it is killed
+// and the old process keeps serving. The default readyTimeout is 30 seconds.
+//
+// The new process adopts the listener only when the Upgrade option is set, so the option must always be given. The
+// Runner must be an *http.Server, and only its listener is handed over, not the challenge server of RunOpts.AutoTLS.
+// The upgrade is supported on Unix only, on other platforms there is no SIGUSR2 and the option is a no-op.
+func (runOptionNamespace) Upgrade(readyTimeout time.Duration) RunOption {
+	return func(s *GracefulRunner) {
+		if readyTimeout <= 0 {
+			readyTimeout = 30 * time.Second
+		}
+		s.upgradeSignal = notifyUpgrade()
+		s.upgradeTimeout = readyTimeout
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !unix
+
+package httpkit
+
+import (
+	"errors"
+	"net"
+	"os"
+	"time"
+)
+
+// notifyUpgrade returns nil, there is no upgrade signal on this platform.
+func notifyUpgrade() chan os.Signal { return nil }
+
+// upgrade is not supported on this platform.
+func upgrade(net.Listener, time.Duration) error {
+	return errors.New("httpkit: upgrade is not supported on this platform")
+}
+
+// inheritListener returns nil, there is no inherited listener on this platform.
+func inheritListener() (net.Listener, error) { return nil, nil }
+
+// notifyReady is a no-op on this platform.
+func notifyReady() error { return nil }
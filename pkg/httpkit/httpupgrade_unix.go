@@ -0,0 +1,162 @@
+//go:build unix
+
+package httpkit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// notifyUpgrade returns the channel that receives SIGUSR2 for initiating the upgrade.
+func notifyUpgrade() chan os.Signal {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGUSR2)
+	return sig
+}
+
+// upgrade starts the new process with the listener and waits until it is ready. The new process is killed if it
+// isn't ready within the timeout.
+func upgrade(ln net.Listener, timeout time.Duration) error {
+	filer, ok := ln.(interface{ File() (*os.File, error) })
+	if !ok {
+		return fmt.Errorf("httpkit: upgrade: listener %T has no file descriptor", ln)
+	}
+
+	// the socket file is shared with the new process, so it mustn't be removed when the old one stops listening.
+	if unix, ok := ln.(*net.UnixListener); ok {
+		unix.SetUnlinkOnClose(false)
+	}
+
+	lnFile, err := filer.File()
+	if err != nil {
+		return fmt.Errorf("httpkit: upgrade: listener file: %w", err)
+	}
+	defer func() { _ = lnFile.Close() }()
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("httpkit: upgrade: ready pipe: %w", err)
+	}
+	defer func() { _ = readyR.Close() }()
+
+	exe, err := os.Executable()
+	if err != nil {
+		_ = readyW.Close()
+		return fmt.Errorf("httpkit: upgrade: executable: %w", err)
+	}
+
+	// the extra files start at the file descriptor 3, after stdin, stdout and stderr.
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = []*os.File{lnFile, readyW}
+	cmd.Env = append(upgradeEnviron(),
+		envUpgradeListenerFD+"=3",
+		envUpgradeReadyFD+"=4",
+	)
+
+	err = cmd.Start()
+	_ = readyW.Close()
+	if err != nil {
+		return fmt.Errorf("httpkit: upgrade: start new process: %w", err)
+	}
+
+	if err := waitReady(readyR, timeout); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("httpkit: upgrade: new process pid %d: %w", cmd.Process.Pid, err)
+	}
+
+	// the new process outlives the old one, so it is released instead of waited.
+	return cmd.Process.Release()
+}
+
+// waitReady waits until the new process writes to the ready pipe. Reading EOF means the new process exited or
+// closed the pipe without being ready.
+func waitReady(r *os.File, timeout time.Duration) error {
+	if err := r.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	var b [1]byte
+	if _, err := r.Read(b[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("exited before ready")
+		}
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return errors.New("not ready within the timeout")
+		}
+		return err
+	}
+	return nil
+}
+
+// upgradeEnviron returns the environment of the current process without the upgrade variables, which may be
+// inherited from the previous upgrade.
+func upgradeEnviron() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, envUpgradeListenerFD+"=") || strings.HasPrefix(kv, envUpgradeReadyFD+"=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// inheritListener returns the listener passed by the old process on upgrade, or nil if the process isn't started
+// by an upgrade.
+func inheritListener() (net.Listener, error) {
+	fd, ok, err := upgradeFD(envUpgradeListenerFD)
+	if !ok || err != nil {
+		return nil, err
+	}
+
+	f := os.NewFile(fd, "httpkit-upgrade-listener")
+	defer func() { _ = f.Close() }()
+
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("httpkit: inherit listener: %w", err)
+	}
+	return ln, nil
+}
+
+// notifyReady tells the old process the new one is ready, if the process is started by an upgrade.
+func notifyReady() error {
+	fd, ok, err := upgradeFD(envUpgradeReadyFD)
+	if !ok || err != nil {
+		return err
+	}
+
+	f := os.NewFile(fd, "httpkit-upgrade-ready")
+	defer func() { _ = f.Close() }()
+
+	if _, err := f.Write([]byte{1}); err != nil {
+		return fmt.Errorf("httpkit: notify ready: %w", err)
+	}
+	return nil
+}
+
+// upgradeFD reads the file descriptor from the environment variable and unsets it, so it is used only once.
+func upgradeFD(key string) (uintptr, bool, error) {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return 0, false, nil
+	}
+	_ = os.Unsetenv(key)
+
+	fd, err := strconv.ParseUint(val, 10, 32)
+	if err != nil {
+		return 0, false, fmt.Errorf("httpkit: invalid %s: %w", key, err)
+	}
+	return uintptr(fd), true, nil
+}
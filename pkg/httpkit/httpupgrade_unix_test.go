@@ -0,0 +1,114 @@
+//go:build unix
+
+package httpkit
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestUpgradeHelperProcess is not a real test, it is the new process started by upgrade in the tests below.
+func TestUpgradeHelperProcess(t *testing.T) {
+	mode := os.Getenv("HTTPKIT_UPGRADE_HELPER")
+	if mode == "" {
+		return
+	}
+
+	if mode == "fail" {
+		os.Exit(1)
+	}
+
+	ln, err := inheritListener()
+	if err != nil || ln == nil {
+		os.Exit(2)
+	}
+
+	served := make(chan struct{})
+	srv := http.Server{
+		ReadHeaderTimeout: time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "new process")
+			close(served)
+		}),
+	}
+	go func() { _ = srv.Serve(ln) }()
+
+	if err := notifyReady(); err != nil {
+		os.Exit(3)
+	}
+
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+	}
+	os.Exit(0)
+}
+
+// helperArgs makes upgrade start the helper process instead of the whole tests.
+func helperArgs(t *testing.T, mode string) {
+	t.Helper()
+	t.Setenv("HTTPKIT_UPGRADE_HELPER", mode)
+
+	args := os.Args
+	os.Args = []string{args[0], "-test.run=^TestUpgradeHelperProcess$"}
+	t.Cleanup(func() { os.Args = args })
+}
+
+func TestUpgrade(t *testing.T) {
+	helperArgs(t, "serve")
+
+	ln, err := Listen("127.0.0.1:0")
+	expectTrue(t, err == nil)
+
+	err = upgrade(ln, 10*time.Second)
+	expectTrue(t, err == nil)
+
+	// the old process stops listening, the new one serves on the same socket.
+	expectTrue(t, ln.Close() == nil)
+
+	res, err := http.Get("http://" + ln.Addr().String())
+	expectTrue(t, err == nil)
+	defer func() { _ = res.Body.Close() }()
+
+	body, _ := io.ReadAll(res.Body)
+	expectTrue(t, string(body) == "new process")
+}
+
+func TestUpgrade_NewProcessFailed(t *testing.T) {
+	helperArgs(t, "fail")
+
+	ln, err := Listen("127.0.0.1:0")
+	expectTrue(t, err == nil)
+	defer func() { _ = ln.Close() }()
+
+	err = upgrade(ln, 10*time.Second)
+	expectTrue(t, err != nil)
+	expectTrue(t, strings.Contains(err.Error(), "exited before ready"))
+}
+
+func TestInheritListener_NotUpgraded(t *testing.T) {
+	ln, err := inheritListener()
+	expectTrue(t, err == nil)
+	expectTrue(t, ln == nil)
+	expectTrue(t, notifyReady() == nil)
+}
+
+func TestInheritListener_InvalidFD(t *testing.T) {
+	t.Setenv(envUpgradeListenerFD, "invalid")
+
+	_, err := inheritListener()
+	expectTrue(t, err != nil)
+	_, ok := os.LookupEnv(envUpgradeListenerFD)
+	expectFalse(t, ok)
+}
+
+func TestRunOpts_UpgradeRequiresHTTPServer(t *testing.T) {
+	server := &serverMock{tracer: visitedNone}
+	err := NewGracefulRunner(server, RunOpts.Upgrade(0)).ListenAndServe()
+	expectTrue(t, err != nil)
+	expectFalse(t, server.Tracer().has(listenAndServeVisited))
+}
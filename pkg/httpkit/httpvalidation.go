@@ -0,0 +1,144 @@
+package httpkit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/josestg/problemdetail"
+	"github.com/josestg/swe-be-mono/pkg/problemmap"
+)
+
+// Set of Problem Details types for validation problems.
+const (
+	// PDTypeInvalidRequest is for the malformed requests, e.g. missing or wrongly typed fields, it is mapped to
+	// 400 Bad Request.
+	PDTypeInvalidRequest = "https://httpstatuses.com/invalid-request"
+
+	// PDTypeValidationFailed is for the well-formed requests that violate the validation rules, it is mapped to
+	// 422 Unprocessable Entity.
+	PDTypeValidationFailed = "https://httpstatuses.com/validation-failed"
+)
+
+func init() {
+	problemmap.Register(PDTypeInvalidRequest, http.StatusBadRequest)
+	problemmap.Register(PDTypeValidationFailed, http.StatusUnprocessableEntity)
+}
+
+// FieldError describes why a single field is invalid.
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`     // the path of the field, e.g. address.city.
+	Code    string `json:"code" xml:"code"`       // the machine-readable code, e.g. required.
+	Message string `json:"message" xml:"message"` // the human-readable message.
+}
+
+// ValidationProblem is a Problem Details extension that carries the per-field errors:
+//
+//	{"type": "...", "title": "...", "status": 422, "errors": [{"field": "email", "code": "email", "message": "..."}]}
+type ValidationProblem struct {
+	*problemdetail.ProblemDetail
+	Errors []FieldError `json:"errors" xml:"errors>error"`
+}
+
+// NewValidationProblem creates a ValidationProblem of PDTypeValidationFailed.
+func NewValidationProblem(errs ...FieldError) *ValidationProblem {
+	return newValidationProblem(PDTypeValidationFailed, "Validation Failed", errs)
+}
+
+// NewInvalidRequestProblem creates a ValidationProblem of PDTypeInvalidRequest.
+func NewInvalidRequestProblem(errs ...FieldError) *ValidationProblem {
+	return newValidationProblem(PDTypeInvalidRequest, "Invalid Request", errs)
+}
+
+func newValidationProblem(pdType, title string, errs []FieldError) *ValidationProblem {
+	if errs == nil {
+		errs = make([]FieldError, 0)
+	}
+
+	return &ValidationProblem{
+		ProblemDetail: problemdetail.New(pdType,
+			problemdetail.WithValidateLevel(problemdetail.LStandard),
+			problemdetail.WithTitle(title),
+			problemdetail.WithDetail(fmt.Sprintf("%d field(s) are invalid", len(errs))),
+		),
+		Errors: errs,
+	}
+}
+
+// NewValidator creates a validator that reports the field names by their `json` tag, so the FieldError.Field
+// matches the request body.
+func NewValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(sf reflect.StructField) string {
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return sf.Name
+		}
+		return name
+	})
+	return v
+}
+
+// ValidationProblemFrom converts the error returned by validator.Validate.Struct to a ValidationProblem of
+// PDTypeValidationFailed. If the error is not validator.ValidationErrors, it is returned as it is.
+//
+// The returned error wraps both the ValidationProblem and the original error.
+func ValidationProblemFrom(err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	fields := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Code:    fe.Tag(),
+			Message: fieldMessage(fe),
+		})
+	}
+	return fmt.Errorf("%w: %w", NewValidationProblem(fields...), err)
+}
+
+// fieldPath strips the root struct name from the namespace, e.g. CreateUser.address.city to address.city.
+func fieldPath(namespace string) string {
+	_, path, ok := strings.Cut(namespace, ".")
+	if !ok {
+		return namespace
+	}
+	return path
+}
+
+// fieldMessage returns a human-readable message for the common validation tags.
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "len":
+		return "must have length of " + fe.Param()
+	case "min", "gte":
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	default:
+		return fmt.Sprintf("failed on the %q rule", fe.Tag())
+	}
+}
@@ -0,0 +1,63 @@
+package httpkit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/josestg/problemdetail"
+)
+
+type validationAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type validationRequest struct {
+	Email   string            `json:"email" validate:"required,email"`
+	Role    string            `json:"role" validate:"oneof=admin member"`
+	Address validationAddress `json:"address"`
+}
+
+func TestValidationProblemFrom(t *testing.T) {
+	err := NewValidator().Struct(validationRequest{Email: "not-an-email", Role: "root"})
+	err = ValidationProblemFrom(err)
+
+	var vp *ValidationProblem
+	expectTrue(t, errors.As(err, &vp))
+	expectTrue(t, vp.Kind() == PDTypeValidationFailed)
+
+	var verrs validator.ValidationErrors
+	expectTrue(t, errors.As(err, &verrs))
+
+	want := []FieldError{
+		{Field: "email", Code: "email", Message: "must be a valid email address"},
+		{Field: "role", Code: "oneof", Message: "must be one of: admin, member"},
+		{Field: "address.city", Code: "required", Message: "is required"},
+	}
+	expectTrue(t, len(vp.Errors) == len(want))
+	for i := range want {
+		expectTrue(t, vp.Errors[i] == want[i])
+	}
+
+	// other errors are returned as they are.
+	other := errors.New("an error")
+	expectTrue(t, ValidationProblemFrom(other) == other)
+}
+
+func TestValidationProblem_JSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	vp := NewInvalidRequestProblem(FieldError{Field: "age", Code: "type", Message: "must be a number"})
+	expectTrue(t, problemdetail.WriteJSON(rec, vp, 400) == nil)
+
+	var body struct {
+		Type   string       `json:"type"`
+		Status int          `json:"status"`
+		Errors []FieldError `json:"errors"`
+	}
+	expectTrue(t, json.NewDecoder(rec.Body).Decode(&body) == nil)
+	expectTrue(t, body.Type == PDTypeInvalidRequest)
+	expectTrue(t, body.Status == 400)
+	expectTrue(t, len(body.Errors) == 1 && body.Errors[0].Field == "age")
+}
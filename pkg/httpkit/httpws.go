@@ -0,0 +1,40 @@
+package httpkit
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocketUpgrader is alias of websocket.Upgrader.
+type WebSocketUpgrader = websocket.Upgrader
+
+// WebSocketConn is alias of websocket.Conn.
+type WebSocketConn = websocket.Conn
+
+// WebSocketHandler handles an upgraded WebSocket connection.
+// The connection is closed after the handler returns.
+type WebSocketHandler func(conn *WebSocketConn, r *http.Request) error
+
+// UpgradeWebSocket creates a HandlerFunc that upgrades the request to the WebSocket protocol and then calls the
+// given handler with the upgraded connection.
+//
+// If the upgrade fails, the upgrader has already replied to the client, so the returned error is marked as resolved.
+// Errors returned by the handler happen after the connection is hijacked, so they cannot be written as HTTP
+// responses anymore; they are marked as resolved too, e.g. the client closing the session isn't a failure. The
+// handler should log the errors it cares about itself.
+func UpgradeWebSocket(upgrader *WebSocketUpgrader, handler WebSocketHandler) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return ResolveError(fmt.Errorf("httpkit: upgrade websocket: %w", err))
+		}
+		defer func() { _ = conn.Close() }()
+
+		if err := handler(conn, r); err != nil {
+			return ResolveError(fmt.Errorf("httpkit: websocket session: %w", err))
+		}
+		return nil
+	}
+}
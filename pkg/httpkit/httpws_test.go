@@ -0,0 +1,109 @@
+package httpkit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestUpgradeWebSocket(t *testing.T) {
+	recorded := make(chan LogEntry, 1)
+	mux := NewServeMux()
+	mux.Route(Route{
+		Method: http.MethodGet,
+		Path:   "/ws",
+		Handler: UpgradeWebSocket(&WebSocketUpgrader{}, func(conn *WebSocketConn, r *http.Request) error {
+			typ, msg, err := conn.ReadMessage()
+			if err != nil {
+				return err
+			}
+			return conn.WriteMessage(typ, msg)
+		}),
+	}, func(next Handler) Handler {
+		return HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			err := next.ServeHTTP(w, r)
+			if rec, ok := GetLogEntry(w); ok {
+				recorded <- *rec
+			}
+			return err
+		})
+	})
+
+	srv := httptest.NewServer(LogEntryRecorder(mux))
+	t.Cleanup(srv.Close)
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	conn, res, err := websocket.DefaultDialer.Dial(url, nil)
+	expectTrue(t, err == nil)
+	t.Cleanup(func() { _ = conn.Close() })
+	expectTrue(t, res.StatusCode == http.StatusSwitchingProtocols)
+
+	expectTrue(t, conn.WriteMessage(websocket.TextMessage, []byte("ping")) == nil)
+	_, msg, err := conn.ReadMessage()
+	expectTrue(t, err == nil)
+	expectTrue(t, string(msg) == "ping")
+
+	entry := <-recorded
+	expectTrue(t, entry.StatusCode == http.StatusSwitchingProtocols)
+	expectTrue(t, entry.RespondedAt >= entry.RequestedAt)
+}
+
+func TestUpgradeWebSocket_UpgradeFailed(t *testing.T) {
+	handler := UpgradeWebSocket(&WebSocketUpgrader{}, func(conn *WebSocketConn, r *http.Request) error {
+		t.Fatalf("should not be called")
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	err := handler.ServeHTTP(rec, req)
+
+	var resolvedErr *ResolvedError
+	expectTrue(t, errors.As(err, &resolvedErr))
+	expectTrue(t, rec.Code == http.StatusBadRequest)
+}
+
+func TestUpgradeWebSocket_SessionError(t *testing.T) {
+	errs := make(chan error, 1)
+	handler := UpgradeWebSocket(&WebSocketUpgrader{}, func(conn *WebSocketConn, r *http.Request) error {
+		_, _, err := conn.ReadMessage()
+		return err
+	})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		errs <- handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	expectTrue(t, err == nil)
+	_ = conn.Close()
+
+	// the session ended by the client happens after the hijack, so it's resolved.
+	var resolvedErr *ResolvedError
+	expectTrue(t, errors.As(<-errs, &resolvedErr))
+}
+
+func TestLogEntryRecorder_WriteAfterHijack(t *testing.T) {
+	writeErr := make(chan error, 1)
+	srv := httptest.NewServer(LogEntryRecorder(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			writeErr <- err
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		_, err = w.Write([]byte("late write"))
+		writeErr <- err
+	})))
+	t.Cleanup(srv.Close)
+
+	_, err := http.Get(srv.URL)
+	expectTrue(t, err != nil) // connection closed without response.
+	expectTrue(t, errors.Is(<-writeErr, http.ErrHijacked))
+}
@@ -0,0 +1,149 @@
+// Package i18nkit provides message catalogs and the locale resolution of the requests.
+//
+// The messages are looked up by keys, a key can be an identifier like a Problem Details type or the source message
+// itself. When a message is not translated, the key is used as it is.
+package i18nkit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"golang.org/x/text/language"
+)
+
+// Catalog is a set of translated messages by their locales. Catalog is concurrent-safe.
+type Catalog struct {
+	mu       sync.RWMutex
+	fallback language.Tag
+	tags     []language.Tag
+	messages map[language.Tag]map[string]string
+	matcher  language.Matcher
+}
+
+// NewCatalog creates a new Catalog, the fallback locale is used when none of the requested locales is supported.
+func NewCatalog(fallback language.Tag) *Catalog {
+	c := Catalog{
+		fallback: fallback,
+		tags:     []language.Tag{fallback},
+		messages: map[language.Tag]map[string]string{fallback: {}},
+	}
+	c.matcher = language.NewMatcher(c.tags)
+	return &c
+}
+
+// Set sets the translated message of the key for the locale.
+func (c *Catalog) Set(tag language.Tag, key, message string) {
+	c.Load(tag, map[string]string{key: message})
+}
+
+// Load sets the translated messages by their keys for the locale.
+func (c *Catalog) Load(tag language.Tag, messages map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m, ok := c.messages[tag]
+	if !ok {
+		m = make(map[string]string, len(messages))
+		c.messages[tag] = m
+		c.tags = append(c.tags, tag)
+		c.matcher = language.NewMatcher(c.tags)
+	}
+
+	for k, v := range messages {
+		m[k] = v
+	}
+}
+
+// Match returns the supported locale that best matches the Accept-Language header value.
+func (c *Catalog) Match(acceptLanguage string) language.Tag {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	prefs, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(prefs) == 0 {
+		return c.fallback
+	}
+
+	_, i, confidence := c.matcher.Match(prefs...)
+	if confidence == language.No {
+		return c.fallback
+	}
+	return c.tags[i]
+}
+
+// Lookup gets the message of the key for the locale, falling back to the fallback locale.
+func (c *Catalog) Lookup(tag language.Tag, key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if msg, ok := c.messages[tag][key]; ok {
+		return msg, true
+	}
+	msg, ok := c.messages[c.fallback][key]
+	return msg, ok
+}
+
+// Middleware is a middleware that resolves the locale of the request from the Accept-Language header and stores it
+// with the catalog in the request context, see Translate and LocaleFromContext. The resolved locale is sent in the
+// Content-Language response header.
+func (c *Catalog) Middleware() httpkit.NetMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tag := c.Match(r.Header.Get("Accept-Language"))
+			w.Header().Set("Content-Language", tag.String())
+			next.ServeHTTP(w, r.WithContext(WithLocalizer(r.Context(), Localizer{Catalog: c, Locale: tag})))
+		})
+	}
+}
+
+// Localizer translates messages to a locale.
+type Localizer struct {
+	Catalog *Catalog
+	Locale  language.Tag
+}
+
+// localizerKey is the context key for the Localizer.
+type localizerKey struct{}
+
+// WithLocalizer returns a copy of the context carrying the localizer.
+func WithLocalizer(ctx context.Context, l Localizer) context.Context {
+	return context.WithValue(ctx, localizerKey{}, l)
+}
+
+// LocalizerFromContext gets the localizer from the context, if not found, it returns false.
+func LocalizerFromContext(ctx context.Context) (Localizer, bool) {
+	l, ok := ctx.Value(localizerKey{}).(Localizer)
+	return l, ok && l.Catalog != nil
+}
+
+// LocaleFromContext gets the locale from the context, if not found, it returns language.Und.
+func LocaleFromContext(ctx context.Context) language.Tag {
+	l, _ := ctx.Value(localizerKey{}).(Localizer)
+	return l.Locale
+}
+
+// Lookup gets the message of the key for the locale in the context, if not translated, it returns false.
+func Lookup(ctx context.Context, key string) (string, bool) {
+	l, ok := LocalizerFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	return l.Catalog.Lookup(l.Locale, key)
+}
+
+// Translate translates the key for the locale in the context, the args are applied by fmt.Sprintf. If not translated,
+// the key itself is used as the message.
+func Translate(ctx context.Context, key string, args ...any) string {
+	msg, ok := Lookup(ctx, key)
+	if !ok {
+		msg = key
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
@@ -0,0 +1,64 @@
+package i18nkit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestCatalog_Middleware(t *testing.T) {
+	c := NewCatalog(language.English)
+	c.Set(language.English, "greeting", "Hello, %s")
+	c.Load(language.Indonesian, map[string]string{"greeting": "Halo, %s"})
+
+	tests := []struct {
+		accept string
+		locale language.Tag
+		want   string
+	}{
+		{accept: "", locale: language.English, want: "Hello, John"},
+		{accept: "id-ID,id;q=0.9,en;q=0.8", locale: language.Indonesian, want: "Halo, John"},
+		{accept: "fr-FR", locale: language.English, want: "Hello, John"},
+		{accept: "invalid;;;", locale: language.English, want: "Hello, John"},
+	}
+
+	for _, tt := range tests {
+		var (
+			locale language.Tag
+			got    string
+		)
+		h := c.Middleware().Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			locale = LocaleFromContext(r.Context())
+			got = Translate(r.Context(), "greeting", "John")
+		}))
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Language", tt.accept)
+		h.ServeHTTP(rec, req)
+
+		expectTrue(t, locale == tt.locale)
+		expectTrue(t, got == tt.want)
+		expectTrue(t, rec.Header().Get("Content-Language") == tt.locale.String())
+	}
+}
+
+func TestTranslate_Untranslated(t *testing.T) {
+	expectTrue(t, Translate(context.Background(), "unknown %d", 1) == "unknown 1")
+
+	c := NewCatalog(language.English)
+	ctx := WithLocalizer(context.Background(), Localizer{Catalog: c, Locale: language.Indonesian})
+	_, ok := Lookup(ctx, "unknown")
+	expectTrue(t, !ok)
+	expectTrue(t, Translate(ctx, "unknown") == "unknown")
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
@@ -0,0 +1,20 @@
+package idkit
+
+import "context"
+
+// providerKey is the context key for the UUIDProvider.
+type providerKey struct{}
+
+// WithProvider returns a copy of the context carrying the UUIDProvider.
+func WithProvider(ctx context.Context, p UUIDProvider) context.Context {
+	return context.WithValue(ctx, providerKey{}, p)
+}
+
+// FromContext gets the UUIDProvider from the context, if not found, it returns UUIDv4, so the handlers and the
+// services can always request IDs from it.
+func FromContext(ctx context.Context) UUIDProvider {
+	if p, ok := ctx.Value(providerKey{}).(UUIDProvider); ok {
+		return p
+	}
+	return UUIDv4
+}
@@ -0,0 +1,11 @@
+package idkit
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	expectTrue(t, FromContext(context.Background()) == UUIDv4)
+	expectTrue(t, FromContext(WithProvider(context.Background(), UUIDv254)) == UUIDv254)
+}
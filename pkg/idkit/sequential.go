@@ -0,0 +1,42 @@
+package idkit
+
+import (
+	"context"
+	"encoding/binary"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// SequentialUUID returns the n-th UUID of the NewSequentialUUIDProvider of the seed, starting from 1, so the tests can
+// assert on the IDs of the created entities, e.g. SequentialUUID(0, 1) is 00000000-0000-4000-8000-000000000001.
+func SequentialUUID(seed, n uint64) uuid.UUID {
+	var u uuid.UUID
+	binary.BigEndian.PutUint64(u[:8], seed)
+	binary.BigEndian.PutUint64(u[8:], n)
+	u[6] = u[6]&0x0f | 0x40 // version 4.
+	u[8] = u[8]&0x3f | 0x80 // variant RFC 4122.
+	return u
+}
+
+// NewSequentialUUIDProvider creates a UUIDProvider that yields the deterministic, incrementing sequence of
+// SequentialUUID of the seed, so the tests creating multiple entities get distinct but predictable IDs. The UUIDs
+// are valid UUIDv4, and FromStr behaves like UUIDv4. It is safe for concurrent use.
+// This provider is useful for testing.
+func NewSequentialUUIDProvider(seed uint64) UUIDProvider {
+	return &sequentialProvider{seed: seed}
+}
+
+// sequentialProvider is type that implements UUIDProvider, the type is private to avoid direct usage of the type.
+type sequentialProvider struct {
+	seed uint64
+	n    atomic.Uint64
+}
+
+func (p *sequentialProvider) Request(_ context.Context) (uuid.UUID, error) {
+	return SequentialUUID(p.seed, p.n.Add(1)), nil
+}
+
+func (p *sequentialProvider) FromStr(ctx context.Context, s string) (uuid.UUID, error) {
+	return UUIDv4.FromStr(ctx, s)
+}
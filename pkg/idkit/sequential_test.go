@@ -0,0 +1,28 @@
+package idkit
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSequentialUUIDProvider(t *testing.T) {
+	p := NewSequentialUUIDProvider(7)
+	for n := uint64(1); n <= 3; n++ {
+		u, err := p.Request(context.Background())
+		expectNoError(t, err)
+		expectTrue(t, u == SequentialUUID(7, n))
+		expectTrue(t, u.Version() == 0x04)
+	}
+
+	expectTrue(t, SequentialUUID(0, 1).String() == "00000000-0000-4000-8000-000000000001")
+	expectTrue(t, SequentialUUID(1, 1) != SequentialUUID(2, 1))
+
+	// the same seed yields the same sequence.
+	u, err := NewSequentialUUIDProvider(7).Request(context.Background())
+	expectNoError(t, err)
+	expectTrue(t, u == SequentialUUID(7, 1))
+
+	parsed, err := p.FromStr(context.Background(), u.String())
+	expectNoError(t, err)
+	expectTrue(t, parsed == u)
+}
@@ -0,0 +1,234 @@
+package idkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Set of the bit lengths of the Snowflake IDs: 1 unused sign bit, 41 bits of the milliseconds since the epoch,
+// 10 bits of the worker ID and 12 bits of the sequence within a millisecond.
+const (
+	snowflakeTimeBits     = 41
+	snowflakeWorkerBits   = 10
+	snowflakeSequenceBits = 12
+
+	// SnowflakeMaxWorkerID is the maximum worker ID, so there are up to 1024 workers.
+	SnowflakeMaxWorkerID = 1<<snowflakeWorkerBits - 1
+
+	snowflakeMaxSequence = 1<<snowflakeSequenceBits - 1
+)
+
+// ErrClockSkew is returned by the Snowflake when the clock moved backwards more than SnowflakeConfig.MaxClockSkew.
+var ErrClockSkew = errors.New("snowflake: clock moved backwards")
+
+// SnowflakeID is a 64-bit time-ordered ID, see Snowflake. It is encoded as a decimal string in the text and JSON,
+// because the JavaScript numbers lose the precision above 2^53.
+type SnowflakeID int64
+
+// String returns the decimal string of the ID.
+func (id SnowflakeID) String() string { return strconv.FormatInt(int64(id), 10) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (id SnowflakeID) MarshalText() ([]byte, error) { return []byte(id.String()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (id *SnowflakeID) UnmarshalText(b []byte) error {
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("snowflake: invalid id %q", b)
+	}
+	*id = SnowflakeID(n)
+	return nil
+}
+
+// WorkerIDSource returns the worker ID of the process, which must be unique among the running generators, e.g. by
+// WorkerIDFromEnv, WorkerIDFromIP or a coordination hook leasing the IDs from Redis or ZooKeeper.
+type WorkerIDSource func(ctx context.Context) (int64, error)
+
+// StaticWorkerID returns the WorkerIDSource of the given ID.
+func StaticWorkerID(id int64) WorkerIDSource {
+	return func(context.Context) (int64, error) { return id, nil }
+}
+
+// WorkerIDFromEnv returns the WorkerIDSource reading the ID from the environment variable, e.g. the ordinal of the
+// Kubernetes StatefulSet pod.
+func WorkerIDFromEnv(key string) WorkerIDSource {
+	return func(context.Context) (int64, error) {
+		v, ok := os.LookupEnv(key)
+		if !ok {
+			return 0, fmt.Errorf("snowflake: %s is not set", key)
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("snowflake: parse %s: %w", key, err)
+		}
+		return id, nil
+	}
+}
+
+// WorkerIDFromIP returns the WorkerIDSource taking the lower 10 bits of the first private IPv4 address of the host,
+// which is unique as long as the workers are in the same /22 network.
+func WorkerIDFromIP() WorkerIDSource {
+	return func(context.Context) (int64, error) {
+		addrs, err := net.InterfaceAddrs()
+		if err != nil {
+			return 0, fmt.Errorf("snowflake: list interface addresses: %w", err)
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if ip := ipNet.IP.To4(); ip != nil && ip.IsPrivate() {
+				return (int64(ip[2])<<8 | int64(ip[3])) & SnowflakeMaxWorkerID, nil
+			}
+		}
+		return 0, errors.New("snowflake: no private IPv4 address")
+	}
+}
+
+// SnowflakeConfig is the configuration of the Snowflake.
+type SnowflakeConfig struct {
+	// Epoch is the start of the time of the IDs, the IDs last for 69 years after it. Default is 2024-01-01 UTC.
+	// It must never change once the IDs are stored.
+	Epoch time.Time
+
+	// WorkerID derives the worker ID, default is StaticWorkerID(0).
+	WorkerID WorkerIDSource
+
+	// MaxClockSkew is how long the Snowflake waits for the clock moved backwards, e.g. by NTP, to catch up before
+	// returning ErrClockSkew. Default is 10 milliseconds, a negative value disables the waiting.
+	MaxClockSkew time.Duration
+
+	Now func() time.Time // The clock, default is time.Now.
+}
+
+func (c SnowflakeConfig) withDefaults() SnowflakeConfig {
+	if c.Epoch.IsZero() {
+		c.Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if c.WorkerID == nil {
+		c.WorkerID = StaticWorkerID(0)
+	}
+	if c.MaxClockSkew == 0 {
+		c.MaxClockSkew = 10 * time.Millisecond
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+	return c
+}
+
+// Snowflake generates the 64-bit time-ordered IDs, unique across the workers of distinct worker IDs.
+// It is safe for concurrent use, and generates up to 4096 IDs per millisecond.
+type Snowflake struct {
+	cfg      SnowflakeConfig
+	workerID int64
+
+	mu       sync.Mutex
+	lastMs   int64
+	sequence int64
+}
+
+// NewSnowflake creates a new Snowflake, the worker ID is derived once.
+func NewSnowflake(ctx context.Context, cfg SnowflakeConfig) (*Snowflake, error) {
+	cfg = cfg.withDefaults()
+	workerID, err := cfg.WorkerID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("derive worker id: %w", err)
+	}
+	if workerID < 0 || workerID > SnowflakeMaxWorkerID {
+		return nil, fmt.Errorf("snowflake: worker id must be in the range of [0, %d], got %d", SnowflakeMaxWorkerID,
+			workerID)
+	}
+	return &Snowflake{cfg: cfg, workerID: workerID, lastMs: -1}, nil
+}
+
+// WorkerID returns the worker ID of the Snowflake.
+func (s *Snowflake) WorkerID() int64 { return s.workerID }
+
+// Request requests a new ID. It waits for the next millisecond when the sequence is exhausted, and for the clock
+// moved backwards up to MaxClockSkew.
+func (s *Snowflake) Request(ctx context.Context) (SnowflakeID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms, err := s.now(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if ms == s.lastMs {
+		s.sequence = (s.sequence + 1) & snowflakeMaxSequence
+		if s.sequence == 0 {
+			if ms, err = s.waitAfter(ctx, s.lastMs); err != nil {
+				return 0, err
+			}
+		}
+	} else {
+		s.sequence = 0
+	}
+
+	if ms >= 1<<snowflakeTimeBits {
+		return 0, errors.New("snowflake: timestamp overflow")
+	}
+	s.lastMs = ms
+
+	id := ms<<(snowflakeWorkerBits+snowflakeSequenceBits) | s.workerID<<snowflakeSequenceBits | s.sequence
+	return SnowflakeID(id), nil
+}
+
+// FromStr converts a decimal string to SnowflakeID.
+func (s *Snowflake) FromStr(_ context.Context, str string) (SnowflakeID, error) {
+	var id SnowflakeID
+	if err := id.UnmarshalText([]byte(str)); err != nil {
+		return 0, fmt.Errorf("from string: %w", err)
+	}
+	return id, nil
+}
+
+// Parts returns the time, the worker ID and the sequence of the ID by the epoch of the Snowflake.
+func (s *Snowflake) Parts(id SnowflakeID) (t time.Time, workerID, sequence int64) {
+	ms := int64(id) >> (snowflakeWorkerBits + snowflakeSequenceBits)
+	workerID = int64(id) >> snowflakeSequenceBits & SnowflakeMaxWorkerID
+	sequence = int64(id) & snowflakeMaxSequence
+	return s.cfg.Epoch.Add(time.Duration(ms) * time.Millisecond), workerID, sequence
+}
+
+// now returns the milliseconds since the epoch, not before the last one. The caller must hold the lock.
+func (s *Snowflake) now(ctx context.Context) (int64, error) {
+	ms := s.cfg.Now().Sub(s.cfg.Epoch).Milliseconds()
+	if ms >= s.lastMs {
+		return ms, nil
+	}
+
+	skew := time.Duration(s.lastMs-ms) * time.Millisecond
+	if skew > s.cfg.MaxClockSkew {
+		return 0, fmt.Errorf("%w by %s", ErrClockSkew, skew)
+	}
+	return s.waitAfter(ctx, s.lastMs-1)
+}
+
+// waitAfter waits until the milliseconds since the epoch are after the given one. The caller must hold the lock.
+func (s *Snowflake) waitAfter(ctx context.Context, after int64) (int64, error) {
+	for {
+		ms := s.cfg.Now().Sub(s.cfg.Epoch).Milliseconds()
+		if ms > after {
+			return ms, nil
+		}
+
+		timer := time.NewTimer(time.Duration(after-ms+1) * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
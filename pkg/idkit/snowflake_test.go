@@ -0,0 +1,113 @@
+package idkit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSnowflake_Request(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := epoch.Add(time.Hour)
+
+	// the clock moves to the next millisecond after the sequence is exhausted.
+	var calls int
+	clock := func() time.Time {
+		calls++
+		if calls > snowflakeMaxSequence+1 {
+			return now.Add(time.Millisecond)
+		}
+		return now
+	}
+
+	s, err := NewSnowflake(context.Background(), SnowflakeConfig{Epoch: epoch, WorkerID: StaticWorkerID(42), Now: clock})
+	expectNoError(t, err)
+	expectTrue(t, s.WorkerID() == 42)
+
+	var prev SnowflakeID = -1
+	for i := 0; i <= snowflakeMaxSequence+1; i++ {
+		id, err := s.Request(context.Background())
+		expectNoError(t, err)
+		expectTrue(t, id > prev)
+		prev = id
+	}
+
+	ts, workerID, sequence := s.Parts(prev)
+	expectTrue(t, ts.Equal(now.Add(time.Millisecond)))
+	expectTrue(t, workerID == 42)
+	expectTrue(t, sequence == 0)
+
+	parsed, err := s.FromStr(context.Background(), prev.String())
+	expectNoError(t, err)
+	expectTrue(t, parsed == prev)
+
+	_, err = s.FromStr(context.Background(), "-1")
+	expectTrue(t, err != nil)
+}
+
+func TestSnowflake_ClockSkew(t *testing.T) {
+	now := time.Now()
+	s, err := NewSnowflake(context.Background(), SnowflakeConfig{Now: func() time.Time { return now }})
+	expectNoError(t, err)
+
+	first, err := s.Request(context.Background())
+	expectNoError(t, err)
+
+	// within the tolerance, the last time is kept.
+	now = now.Add(-5 * time.Millisecond)
+	s.cfg.Now = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+	next, err := s.Request(context.Background())
+	expectNoError(t, err)
+	expectTrue(t, next > first)
+
+	now = now.Add(-time.Second)
+	_, err = s.Request(context.Background())
+	expectTrue(t, errors.Is(err, ErrClockSkew))
+}
+
+func TestSnowflake_Concurrent(t *testing.T) {
+	s, err := NewSnowflake(context.Background(), SnowflakeConfig{})
+	expectNoError(t, err)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[SnowflakeID]struct{})
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				id, err := s.Request(context.Background())
+				expectNoError(t, err)
+				mu.Lock()
+				seen[id] = struct{}{}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	expectTrue(t, len(seen) == 8000)
+}
+
+func TestNewSnowflake_WorkerID(t *testing.T) {
+	_, err := NewSnowflake(context.Background(), SnowflakeConfig{WorkerID: StaticWorkerID(SnowflakeMaxWorkerID + 1)})
+	expectTrue(t, err != nil)
+
+	t.Setenv("SNOWFLAKE_WORKER_ID", "7")
+	s, err := NewSnowflake(context.Background(), SnowflakeConfig{WorkerID: WorkerIDFromEnv("SNOWFLAKE_WORKER_ID")})
+	expectNoError(t, err)
+	expectTrue(t, s.WorkerID() == 7)
+
+	_, err = NewSnowflake(context.Background(), SnowflakeConfig{WorkerID: WorkerIDFromEnv("SNOWFLAKE_WORKER_ID_UNSET")})
+	expectTrue(t, err != nil)
+
+	hookErr := errors.New("lease failed")
+	hook := func(context.Context) (int64, error) { return 0, hookErr }
+	_, err = NewSnowflake(context.Background(), SnowflakeConfig{WorkerID: hook})
+	expectTrue(t, errors.Is(err, hookErr))
+}
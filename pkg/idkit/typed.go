@@ -0,0 +1,108 @@
+package idkit
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// Prefixer is implemented by the entity marker types of TypedID, e.g.
+//
+//	type User struct{}
+//
+//	func (User) IDPrefix() string { return "usr" }
+type Prefixer interface {
+	// IDPrefix returns the prefix of the IDs of the entity, without the underscore.
+	IDPrefix() string
+}
+
+// TypedID is a ULID of the entity T rendered with the prefix of the entity, e.g. usr_01HGW2N7EHJZ3Q4X5V6T7Y8Z9A.
+// The prefix is validated on parse, so the IDs of different entities can't be mixed up by the handlers and the
+// repositories. The zero TypedID is rendered as an empty string and stored as NULL.
+type TypedID[T Prefixer] struct {
+	id ULID
+}
+
+// NewTypedID requests a new ULID of the provider as the TypedID of T.
+func NewTypedID[T Prefixer](ctx context.Context, p ULIDProvider) (TypedID[T], error) {
+	id, err := p.Request(ctx)
+	if err != nil {
+		return TypedID[T]{}, err
+	}
+	return TypedID[T]{id: id}, nil
+}
+
+// ParseTypedID parses the prefixed ID of T, the prefix must match the one of T.
+func ParseTypedID[T Prefixer](s string) (TypedID[T], error) {
+	prefix := typedPrefix[T]()
+	rest, ok := strings.CutPrefix(s, prefix+"_")
+	if !ok {
+		return TypedID[T]{}, fmt.Errorf("typed id: %q doesn't have the prefix %s_", s, prefix)
+	}
+
+	id, err := ParseULID(rest)
+	if err != nil {
+		return TypedID[T]{}, fmt.Errorf("typed id: %w", err)
+	}
+	return TypedID[T]{id: id}, nil
+}
+
+func typedPrefix[T Prefixer]() string {
+	var entity T
+	return entity.IDPrefix()
+}
+
+// ULID returns the ULID of the ID without the prefix.
+func (t TypedID[T]) ULID() ULID { return t.id }
+
+// IsZero reports whether the ID is the zero TypedID.
+func (t TypedID[T]) IsZero() bool { return t.id == ULID{} }
+
+// String returns the ID with the prefix, or an empty string if the ID is zero.
+func (t TypedID[T]) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return typedPrefix[T]() + "_" + t.id.String()
+}
+
+// MarshalText implements encoding.TextMarshaler, it is used by json.Marshal too.
+func (t TypedID[T]) MarshalText() ([]byte, error) { return []byte(t.String()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler, it is used by json.Unmarshal too.
+func (t *TypedID[T]) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		*t = TypedID[T]{}
+		return nil
+	}
+	parsed, err := ParseTypedID[T](string(b))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, the ID is stored with the prefix.
+func (t TypedID[T]) Value() (driver.Value, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+	return t.String(), nil
+}
+
+// Scan implements sql.Scanner.
+func (t *TypedID[T]) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*t = TypedID[T]{}
+		return nil
+	case string:
+		return t.UnmarshalText([]byte(v))
+	case []byte:
+		return t.UnmarshalText(v)
+	default:
+		return fmt.Errorf("typed id: unsupported source type: %T", src)
+	}
+}
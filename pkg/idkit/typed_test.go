@@ -0,0 +1,71 @@
+package idkit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type testUser struct{}
+
+func (testUser) IDPrefix() string { return "usr" }
+
+type testOrder struct{}
+
+func (testOrder) IDPrefix() string { return "ord" }
+
+func TestTypedID(t *testing.T) {
+	id, err := NewTypedID[testUser](context.Background(), NewULIDProvider(ULIDConfig{}))
+	expectNoError(t, err)
+	expectTrue(t, strings.HasPrefix(id.String(), "usr_"))
+	expectTrue(t, len(id.String()) == len("usr_")+26)
+
+	parsed, err := ParseTypedID[testUser](id.String())
+	expectNoError(t, err)
+	expectTrue(t, parsed == id)
+
+	// the IDs of other entities are rejected.
+	_, err = ParseTypedID[testOrder](id.String())
+	expectTrue(t, err != nil)
+
+	_, err = ParseTypedID[testUser]("usr_invalid")
+	expectTrue(t, err != nil)
+
+	b, err := json.Marshal(map[string]TypedID[testUser]{"id": id})
+	expectNoError(t, err)
+	expectTrue(t, string(b) == `{"id":"`+id.String()+`"}`)
+
+	var body struct {
+		ID TypedID[testUser] `json:"id"`
+	}
+	expectNoError(t, json.Unmarshal(b, &body))
+	expectTrue(t, body.ID == id)
+
+	var order struct {
+		ID TypedID[testOrder] `json:"id"`
+	}
+	expectTrue(t, json.Unmarshal(b, &order) != nil)
+}
+
+func TestTypedID_SQL(t *testing.T) {
+	id, err := NewTypedID[testUser](context.Background(), NewULIDProvider(ULIDConfig{}))
+	expectNoError(t, err)
+
+	v, err := id.Value()
+	expectNoError(t, err)
+	expectTrue(t, v == id.String())
+
+	var scanned TypedID[testUser]
+	expectNoError(t, scanned.Scan([]byte(id.String())))
+	expectTrue(t, scanned == id)
+
+	expectNoError(t, scanned.Scan(nil))
+	expectTrue(t, scanned.IsZero())
+
+	v, err = scanned.Value()
+	expectNoError(t, err)
+	expectTrue(t, v == nil)
+
+	expectTrue(t, scanned.Scan(42) != nil)
+}
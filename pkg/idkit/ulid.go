@@ -0,0 +1,182 @@
+package idkit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// _crockford is the Crockford's base32 alphabet used by ULID.
+const _crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// _crockfordIndex maps the upper-case characters of _crockford to their values, the others are 0xff.
+var _crockfordIndex = func() [256]byte {
+	var index [256]byte
+	for i := range index {
+		index[i] = 0xff
+	}
+	for i := 0; i < len(_crockford); i++ {
+		index[_crockford[i]] = byte(i)
+	}
+	return index
+}()
+
+// ErrULIDOverflow is returned by the monotonic ULIDProvider when the random part overflows within a millisecond.
+var ErrULIDOverflow = errors.New("ulid: monotonic entropy overflow")
+
+// ULID is a Universally Unique Lexicographically Sortable Identifier: 48 bits of the Unix time in milliseconds
+// followed by 80 random bits, encoded as 26 characters of the Crockford's base32.
+// see: https://github.com/ulid/spec.
+type ULID [16]byte
+
+// ParseULID parses the 26 characters of the Crockford's base32, case-insensitively.
+func ParseULID(s string) (ULID, error) {
+	var id ULID
+	if len(s) != 26 {
+		return id, fmt.Errorf("ulid: invalid length %d", len(s))
+	}
+	if s[0] > '7' {
+		return id, errors.New("ulid: timestamp overflow")
+	}
+
+	s = strings.ToUpper(s)
+	for i := 0; i < len(s); i++ {
+		v := _crockfordIndex[s[i]]
+		if v == 0xff {
+			return ULID{}, fmt.Errorf("ulid: invalid character %q", s[i])
+		}
+		// the 26 characters carry 130 bits, the 2 leading bits of the first are always 0.
+		for b := 0; b < 5; b++ {
+			pos := i*5 + b - 2
+			if pos >= 0 && v&(0x10>>b) != 0 {
+				id[pos/8] |= 0x80 >> (pos % 8)
+			}
+		}
+	}
+	return id, nil
+}
+
+// String returns the 26 characters of the Crockford's base32.
+func (id ULID) String() string {
+	var b [26]byte
+	for i := range b {
+		var v byte
+		for bit := 0; bit < 5; bit++ {
+			v <<= 1
+			if pos := i*5 + bit - 2; pos >= 0 && id[pos/8]&(0x80>>(pos%8)) != 0 {
+				v |= 1
+			}
+		}
+		b[i] = _crockford[v]
+	}
+	return string(b[:])
+}
+
+// Time returns the time of the ULID in milliseconds.
+func (id ULID) Time() time.Time { return time.UnixMilli(int64(id.ms())) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (id ULID) MarshalText() ([]byte, error) { return []byte(id.String()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (id *ULID) UnmarshalText(b []byte) error {
+	parsed, err := ParseULID(string(b))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+func (id ULID) ms() uint64 {
+	return uint64(id[0])<<40 | uint64(id[1])<<32 | uint64(binary.BigEndian.Uint32(id[2:6]))
+}
+
+// ULIDProvider provides an API to generate and parse ULID.
+type ULIDProvider interface {
+	// Request requests a new ULID based on the provider.
+	Request(ctx context.Context) (ULID, error)
+
+	// FromStr converts a string to ULID.
+	// If the string is not a valid ULID, it will return an error.
+	FromStr(ctx context.Context, s string) (ULID, error)
+}
+
+// ULIDConfig is the configuration of the ULIDProvider.
+type ULIDConfig struct {
+	// Monotonic makes the ULIDs of the same millisecond strictly increasing by incrementing the random part of the
+	// previous one, instead of drawing new random bits, so they are sortable within a millisecond too.
+	Monotonic bool
+
+	Entropy io.Reader        // The source of the random bits, default is crypto/rand.Reader.
+	Now     func() time.Time // The clock, default is time.Now.
+}
+
+func (c ULIDConfig) withDefaults() ULIDConfig {
+	if c.Entropy == nil {
+		c.Entropy = rand.Reader
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+	return c
+}
+
+// NewULIDProvider creates a new ULIDProvider, it is safe for concurrent use.
+func NewULIDProvider(cfg ULIDConfig) ULIDProvider {
+	return &ulidProvider{cfg: cfg.withDefaults()}
+}
+
+// ulidProvider is type that implements ULIDProvider, the type is private to avoid direct usage of the type.
+type ulidProvider struct {
+	cfg ULIDConfig
+
+	mu   sync.Mutex
+	last ULID
+}
+
+func (p *ulidProvider) Request(_ context.Context) (ULID, error) {
+	ms := uint64(p.cfg.Now().UnixMilli())
+	if ms >= 1<<48 {
+		return ULID{}, errors.New("ulid: timestamp overflow")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cfg.Monotonic && p.last != (ULID{}) && ms <= p.last.ms() {
+		// the clock didn't move forward, the previous time is kept, so the order holds even if the clock goes back.
+		id := p.last
+		for i := len(id) - 1; i >= 6; i-- {
+			id[i]++
+			if id[i] != 0 {
+				p.last = id
+				return id, nil
+			}
+		}
+		return ULID{}, ErrULIDOverflow
+	}
+
+	var id ULID
+	id[0], id[1] = byte(ms>>40), byte(ms>>32)
+	binary.BigEndian.PutUint32(id[2:6], uint32(ms))
+	if _, err := io.ReadFull(p.cfg.Entropy, id[6:]); err != nil {
+		return ULID{}, fmt.Errorf("ulid: read entropy: %w", err)
+	}
+	p.last = id
+	return id, nil
+}
+
+func (p *ulidProvider) FromStr(_ context.Context, s string) (ULID, error) {
+	id, err := ParseULID(s)
+	if err != nil {
+		return ULID{}, fmt.Errorf("from string: %w", err)
+	}
+	return id, nil
+}
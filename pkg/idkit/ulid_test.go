@@ -0,0 +1,79 @@
+package idkit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestULID_String(t *testing.T) {
+	// the example of the ULID spec.
+	const s = "01ARZ3NDEKTSV4RRFFQ69G5FAV"
+
+	id, err := ParseULID(s)
+	expectNoError(t, err)
+	expectTrue(t, id.String() == s)
+	expectTrue(t, id.Time().UnixMilli() == 1469922850259)
+
+	lower, err := ParseULID(strings.ToLower(s))
+	expectNoError(t, err)
+	expectTrue(t, lower == id)
+
+	largest, err := ParseULID("7ZZZZZZZZZZZZZZZZZZZZZZZZZ")
+	expectNoError(t, err)
+	expectTrue(t, largest == ULID{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+
+	invalids := []string{"", "01ARZ3NDEKTSV4RRFFQ69G5FA", "81ARZ3NDEKTSV4RRFFQ69G5FAV", "01ARZ3NDEKTSV4RRFFQ69G5FAU"}
+	for _, invalid := range invalids {
+		_, err := ParseULID(invalid)
+		expectTrue(t, err != nil)
+	}
+}
+
+func TestULIDProvider_Request(t *testing.T) {
+	now := time.UnixMilli(1469922850259)
+	clock := func() time.Time { return now }
+
+	p := NewULIDProvider(ULIDConfig{Now: clock, Entropy: bytes.NewReader(bytes.Repeat([]byte{0x01}, 20))})
+	a, err := p.Request(context.Background())
+	expectNoError(t, err)
+	expectTrue(t, a.Time().Equal(now))
+	expectTrue(t, strings.HasPrefix(a.String(), "01ARZ3NDEK"))
+
+	b, err := p.Request(context.Background())
+	expectNoError(t, err)
+	expectTrue(t, a == b) // the same entropy without the monotonic option.
+
+	p = NewULIDProvider(ULIDConfig{Now: clock, Monotonic: true})
+	prev, err := p.Request(context.Background())
+	expectNoError(t, err)
+	for i := 0; i < 100; i++ {
+		next, err := p.Request(context.Background())
+		expectNoError(t, err)
+		expectTrue(t, prev.String() < next.String())
+		prev = next
+	}
+
+	// the random part overflows within the millisecond.
+	p = NewULIDProvider(ULIDConfig{Now: clock, Monotonic: true, Entropy: bytes.NewReader(bytes.Repeat([]byte{0xff}, 10))})
+	_, err = p.Request(context.Background())
+	expectNoError(t, err)
+	_, err = p.Request(context.Background())
+	expectTrue(t, errors.Is(err, ErrULIDOverflow))
+}
+
+func TestULIDProvider_FromStr(t *testing.T) {
+	p := NewULIDProvider(ULIDConfig{})
+	id, err := p.Request(context.Background())
+	expectNoError(t, err)
+
+	parsed, err := p.FromStr(context.Background(), id.String())
+	expectNoError(t, err)
+	expectTrue(t, parsed == id)
+
+	_, err = p.FromStr(context.Background(), "invalid-ulid")
+	expectTrue(t, err != nil)
+}
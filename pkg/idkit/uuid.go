@@ -42,6 +42,10 @@ const (
 	// UUIDv4 is a UUID provider that generates and parses UUIDv4.
 	UUIDv4 = uuidProvider(0x04)
 
+	// UUIDv7 is a UUID provider that generates and parses UUIDv7, which is ordered by the creation time,
+	// so it makes index-friendly primary keys.
+	UUIDv7 = uuidProvider(0x07)
+
 	// UUIDv253 is a special UUID provider that always returns uuid.Nil for Request and FromStr.
 	// This provider is useful for testing.
 	UUIDv253 = uuidProvider(0xfd)
@@ -61,6 +65,8 @@ func (u uuidProvider) Request(_ context.Context) (uuid.UUID, error) {
 		return uuid.NewUUID()
 	case UUIDv4:
 		return uuid.NewRandom()
+	case UUIDv7:
+		return uuid.NewV7()
 	case UUIDv253:
 		return uuid.Nil, nil
 	case UUIDv254:
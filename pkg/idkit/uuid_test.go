@@ -17,6 +17,14 @@ func TestUUIDProvider_Request(t *testing.T) {
 	expectNoError(t, err)
 	expectTrue(t, uv4.Version() == 0x04)
 
+	uv7, err := UUIDv7.Request(context.Background())
+	expectNoError(t, err)
+	expectTrue(t, uv7.Version() == 0x07)
+
+	next, err := UUIDv7.Request(context.Background())
+	expectNoError(t, err)
+	expectTrue(t, uv7.String() < next.String())
+
 	uv0, err := UUIDv253.Request(context.Background())
 	expectNoError(t, err)
 	expectTrue(t, uv0 == uuid.Nil)
@@ -35,6 +43,7 @@ func TestUUIDProvider_Request(t *testing.T) {
 func TestUUIDProvider_FromStr(t *testing.T) {
 	const v1str = "ffd42014-69c5-11ee-8c99-0242ac120002"
 	const v4str = "a4c670b4-0dd8-4958-908c-55865b7ce52f"
+	const v7str = "018b2f1e-8c4a-7cc3-98c4-dc0c0c07398f"
 
 	uv1, err := UUIDv1.FromStr(context.Background(), v1str)
 	expectNoError(t, err)
@@ -44,6 +53,13 @@ func TestUUIDProvider_FromStr(t *testing.T) {
 	expectNoError(t, err)
 	expectTrue(t, uv4 == uuid.MustParse(v4str))
 
+	uv7, err := UUIDv7.FromStr(context.Background(), v7str)
+	expectNoError(t, err)
+	expectTrue(t, uv7 == uuid.MustParse(v7str))
+
+	_, err = UUIDv7.FromStr(context.Background(), v4str)
+	expectTrue(t, err != nil)
+
 	uv0, err := UUIDv253.FromStr(context.Background(), v1str)
 	expectNoError(t, err)
 	expectTrue(t, uv0 == uuid.Nil)
@@ -0,0 +1,126 @@
+package idkit
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// UUID is a uuid.UUID that is stored as its string in the databases and encoded as its string in the JSON, so the
+// repositories and the handlers use it directly instead of converting it to string.
+type UUID uuid.UUID
+
+// NilUUID is the zero UUID.
+var NilUUID = UUID(uuid.Nil)
+
+// ParseUUID parses the UUID in any of the formats accepted by uuid.Parse.
+func ParseUUID(s string) (UUID, error) {
+	u, err := uuid.Parse(s)
+	if err != nil {
+		return NilUUID, fmt.Errorf("uuid: %w", err)
+	}
+	return UUID(u), nil
+}
+
+// UUID returns the uuid.UUID.
+func (u UUID) UUID() uuid.UUID { return uuid.UUID(u) }
+
+// IsNil reports whether the UUID is NilUUID.
+func (u UUID) IsNil() bool { return u == NilUUID }
+
+// String returns the string form of the UUID, xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx.
+func (u UUID) String() string { return uuid.UUID(u).String() }
+
+// MarshalText implements encoding.TextMarshaler.
+func (u UUID) MarshalText() ([]byte, error) { return []byte(u.String()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *UUID) UnmarshalText(b []byte) error {
+	parsed, err := ParseUUID(string(b))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (u UUID) MarshalJSON() ([]byte, error) { return json.Marshal(u.String()) }
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (u *UUID) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("uuid: %w", err)
+	}
+	return u.UnmarshalText([]byte(s))
+}
+
+// Value implements driver.Valuer.
+func (u UUID) Value() (driver.Value, error) { return u.String(), nil }
+
+// Scan implements sql.Scanner, it accepts the string form and the 16 raw bytes.
+func (u *UUID) Scan(src any) error {
+	if src == nil {
+		return fmt.Errorf("uuid: cannot scan NULL, use NullUUID")
+	}
+
+	var parsed uuid.UUID
+	if err := parsed.Scan(src); err != nil {
+		return fmt.Errorf("uuid: %w", err)
+	}
+	*u = UUID(parsed)
+	return nil
+}
+
+// NullUUID is a UUID that may be null, it is stored as NULL in the databases and encoded as null in the JSON when
+// it is not valid.
+type NullUUID struct {
+	UUID  UUID
+	Valid bool // Valid is true if UUID is not NULL.
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n NullUUID) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return n.UUID.MarshalJSON()
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *NullUUID) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		*n = NullUUID{}
+		return nil
+	}
+	if err := n.UUID.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (n NullUUID) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.UUID.Value()
+}
+
+// Scan implements sql.Scanner.
+func (n *NullUUID) Scan(src any) error {
+	if src == nil {
+		*n = NullUUID{}
+		return nil
+	}
+	if err := n.UUID.Scan(src); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
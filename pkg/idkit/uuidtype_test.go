@@ -0,0 +1,68 @@
+package idkit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUUID(t *testing.T) {
+	u := UUID(StaticUUID())
+	expectTrue(t, u.String() == StaticUUID().String())
+	expectTrue(t, u.UUID() == StaticUUID())
+
+	b, err := json.Marshal(map[string]UUID{"id": u})
+	expectNoError(t, err)
+	expectTrue(t, string(b) == `{"id":"`+u.String()+`"}`)
+
+	var body struct {
+		ID UUID `json:"id"`
+	}
+	expectNoError(t, json.Unmarshal(b, &body))
+	expectTrue(t, body.ID == u)
+	expectTrue(t, json.Unmarshal([]byte(`{"id":"invalid"}`), &body) != nil)
+	expectTrue(t, json.Unmarshal([]byte(`{"id":42}`), &body) != nil)
+
+	v, err := u.Value()
+	expectNoError(t, err)
+	expectTrue(t, v == u.String())
+
+	var scanned UUID
+	expectNoError(t, scanned.Scan(u.String()))
+	expectTrue(t, scanned == u)
+
+	raw := StaticUUID()
+	expectNoError(t, scanned.Scan(raw[:]))
+	expectTrue(t, scanned == u)
+
+	expectTrue(t, scanned.Scan(nil) != nil)
+	expectTrue(t, scanned.Scan(42) != nil)
+}
+
+func TestNullUUID(t *testing.T) {
+	var n NullUUID
+	b, err := json.Marshal(n)
+	expectNoError(t, err)
+	expectTrue(t, string(b) == "null")
+
+	v, err := n.Value()
+	expectNoError(t, err)
+	expectTrue(t, v == nil)
+
+	expectNoError(t, n.Scan(StaticUUID().String()))
+	expectTrue(t, n.Valid && n.UUID.UUID() == StaticUUID())
+
+	b, err = json.Marshal(n)
+	expectNoError(t, err)
+	expectTrue(t, string(b) == `"`+StaticUUID().String()+`"`)
+
+	expectNoError(t, n.Scan(nil))
+	expectTrue(t, !n.Valid && n.UUID.IsNil())
+
+	expectNoError(t, json.Unmarshal([]byte(`"`+uuid.Nil.String()+`"`), &n))
+	expectTrue(t, n.Valid)
+
+	expectNoError(t, json.Unmarshal([]byte("null"), &n))
+	expectTrue(t, !n.Valid)
+}
@@ -0,0 +1,221 @@
+// Package jwtkit provides helpers for working with JSON Web Tokens on top of github.com/golang-jwt/jwt/v5.
+package jwtkit
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrKeyNotFound is returned when the key ID is not found in the key set.
+var ErrKeyNotFound = errors.New("jwtkit: key not found")
+
+// JWK is a JSON Web Key as defined in RFC 7517, only the members needed for RSA and Ed25519 public keys.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// PublicKey converts the JWK to crypto.PublicKey. Only RSA and OKP (Ed25519) keys are supported.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decode modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decode exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve: %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("decode x: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 public key size: %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %q", k.Kty)
+	}
+}
+
+// JWKSet is a JSON Web Key Set.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// ParseJWKSet parses the JSON Web Key Set into public keys by their key ID.
+// Keys with unsupported types or not for signature use are skipped.
+func ParseJWKSet(b []byte) (map[string]crypto.PublicKey, error) {
+	var set JWKSet
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("jwtkit: parse jwk set: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		pub, err := k.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	return keys, nil
+}
+
+// JWKS is a remote JSON Web Key Set that is fetched lazily and cached.
+// The key set is refreshed when it becomes stale or when an unknown key ID is requested, which happens when the
+// issuer rotates its keys. Refreshing due to unknown key IDs is rate limited, and the concurrent refreshes share a
+// single request that doesn't block the lookups of the cached keys.
+// JWKS is concurrent-safe.
+type JWKS struct {
+	url                string
+	client             *http.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+
+	group     singleflight.Group
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// JWKSOption is an option for customizing the JWKS.
+type JWKSOption func(*JWKS)
+
+// WithHTTPClient sets the http client for fetching the key set. Default http.Client with 10 seconds timeout. The
+// timeout of the client bounds the refresh, since it isn't canceled when the caller waiting for it goes away.
+func WithHTTPClient(client *http.Client) JWKSOption {
+	return func(s *JWKS) { s.client = client }
+}
+
+// WithRefreshInterval sets the maximum age of the cached key set. Default 1 hour.
+func WithRefreshInterval(d time.Duration) JWKSOption {
+	return func(s *JWKS) { s.refreshInterval = d }
+}
+
+// WithMinRefreshInterval sets the minimum interval between refreshes caused by unknown key IDs. Default 1 minute.
+func WithMinRefreshInterval(d time.Duration) JWKSOption {
+	return func(s *JWKS) { s.minRefreshInterval = d }
+}
+
+// NewJWKS creates a new JWKS for the given URL.
+func NewJWKS(url string, opts ...JWKSOption) *JWKS {
+	s := JWKS{
+		url:                url,
+		client:             &http.Client{Timeout: 10 * time.Second},
+		refreshInterval:    time.Hour,
+		minRefreshInterval: time.Minute,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return &s
+}
+
+// Key returns the public key by its key ID.
+func (s *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	key, ok := s.keys[kid]
+	age := time.Since(s.fetchedAt)
+	s.mu.Unlock()
+
+	stale := age > s.refreshInterval
+	if ok && !stale {
+		return key, nil
+	}
+
+	if stale || age > s.minRefreshInterval {
+		if err := s.Refresh(ctx); err != nil {
+			// serve the stale key while the issuer is unreachable.
+			if ok {
+				return key, nil
+			}
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	key, ok = s.keys[kid]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: kid=%q", ErrKeyNotFound, kid)
+	}
+	return key, nil
+}
+
+// Refresh fetches the key set regardless of the cache state, it joins the refresh in flight if any.
+func (s *JWKS) Refresh(ctx context.Context) error {
+	ch := s.group.DoChan("", func() (any, error) {
+		// the waiting callers share the request, so it outlives the one that started it.
+		return nil, s.refresh(context.WithoutCancel(ctx))
+	})
+
+	select {
+	case res := <-ch:
+		return res.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *JWKS) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return fmt.Errorf("jwtkit: new jwks request: %w", err)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("jwtkit: fetch jwks: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwtkit: fetch jwks: unexpected status code %d", res.StatusCode)
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&raw); err != nil {
+		return fmt.Errorf("jwtkit: decode jwks: %w", err)
+	}
+
+	keys, err := ParseJWKSet(raw)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
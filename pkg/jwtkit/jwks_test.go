@@ -0,0 +1,186 @@
+package jwtkit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func rsaJWK(t *testing.T, kid string, pub *rsa.PublicKey) JWK {
+	t.Helper()
+	return JWK{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+func ed25519JWK(kid string, pub ed25519.PublicKey) JWK {
+	return JWK{Kty: "OKP", Kid: kid, Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(pub)}
+}
+
+func TestParseJWKSet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	expectNoError(t, err)
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	expectNoError(t, err)
+
+	set := JWKSet{Keys: []JWK{
+		rsaJWK(t, "rsa", &rsaKey.PublicKey),
+		ed25519JWK("ed", edPub),
+		{Kty: "EC", Kid: "unsupported"},
+		{Kty: "RSA", Kid: "enc", Use: "enc"},
+	}}
+	raw, err := json.Marshal(set)
+	expectNoError(t, err)
+
+	keys, err := ParseJWKSet(raw)
+	expectNoError(t, err)
+	expectTrue(t, len(keys) == 2)
+	expectTrue(t, keys["rsa"].(*rsa.PublicKey).Equal(&rsaKey.PublicKey))
+	expectTrue(t, keys["ed"].(ed25519.PublicKey).Equal(edPub))
+
+	_, err = ParseJWKSet([]byte("not json"))
+	expectTrue(t, err != nil)
+}
+
+func TestJWKS_Key(t *testing.T) {
+	edPub1, _, _ := ed25519.GenerateKey(rand.Reader)
+	edPub2, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	var fetched atomic.Int32
+	var rotated atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched.Add(1)
+		set := JWKSet{Keys: []JWK{ed25519JWK("k1", edPub1)}}
+		if rotated.Load() {
+			set.Keys = append(set.Keys, ed25519JWK("k2", edPub2))
+		}
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(srv.Close)
+
+	jwks := NewJWKS(srv.URL, WithMinRefreshInterval(0))
+	ctx := context.Background()
+
+	key, err := jwks.Key(ctx, "k1")
+	expectNoError(t, err)
+	expectTrue(t, key.(ed25519.PublicKey).Equal(edPub1))
+
+	// cached.
+	_, err = jwks.Key(ctx, "k1")
+	expectNoError(t, err)
+	expectTrue(t, fetched.Load() == 1)
+
+	// unknown key triggers refresh.
+	rotated.Store(true)
+	key, err = jwks.Key(ctx, "k2")
+	expectNoError(t, err)
+	expectTrue(t, key.(ed25519.PublicKey).Equal(edPub2))
+	expectTrue(t, fetched.Load() == 2)
+
+	_, err = jwks.Key(ctx, "k3")
+	expectTrue(t, errors.Is(err, ErrKeyNotFound))
+}
+
+func TestJWKS_RefreshRateLimited(t *testing.T) {
+	var fetched atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched.Add(1)
+		_, _ = w.Write([]byte(`{"keys":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	jwks := NewJWKS(srv.URL, WithMinRefreshInterval(time.Hour))
+	for i := 0; i < 3; i++ {
+		_, err := jwks.Key(context.Background(), "unknown")
+		expectTrue(t, errors.Is(err, ErrKeyNotFound))
+	}
+	expectTrue(t, fetched.Load() == 1)
+}
+
+func TestJWKS_RefreshShared(t *testing.T) {
+	edPub1, _, _ := ed25519.GenerateKey(rand.Reader)
+	edPub2, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	var fetched atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		set := JWKSet{Keys: []JWK{ed25519JWK("k1", edPub1)}}
+		if fetched.Add(1) > 1 {
+			<-release
+			set.Keys = append(set.Keys, ed25519JWK("k2", edPub2))
+		}
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(srv.Close)
+
+	jwks := NewJWKS(srv.URL, WithMinRefreshInterval(0))
+	_, err := jwks.Key(context.Background(), "k1")
+	expectNoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if key, err := jwks.Key(context.Background(), "k2"); err != nil || !key.(ed25519.PublicKey).Equal(edPub2) {
+				t.Errorf("expected the rotated key, got %v, %v", key, err)
+			}
+		}()
+	}
+
+	// the cached key is served while the refresh is in flight.
+	for fetched.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	_, err = jwks.Key(context.Background(), "k1")
+	expectNoError(t, err)
+
+	// the waiting caller gives up on its own context.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = jwks.Key(ctx, "k2")
+	expectTrue(t, errors.Is(err, context.DeadlineExceeded))
+
+	close(release)
+	wg.Wait()
+	expectTrue(t, fetched.Load() == 2)
+}
+
+func TestJWKS_Refresh_Failed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+
+	err := NewJWKS(srv.URL).Refresh(context.Background())
+	expectTrue(t, err != nil)
+}
+
+func expectNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
@@ -0,0 +1,267 @@
+package jwtkit
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrAlgorithmMismatch is returned when the algorithm of the token doesn't match the one of the key of its key ID,
+// e.g. an HS256 token claiming the kid of an RS256 key, which is the algorithm confusion attack.
+var ErrAlgorithmMismatch = errors.New("jwtkit: algorithm mismatch")
+
+// SigningKey is a private key for signing the tokens, identified by the kid header.
+type SigningKey struct {
+	ID     string            // the key ID, the kid header of the signed tokens.
+	Method jwt.SigningMethod // one of the HS*, RS* or EdDSA methods of jwt.
+	Key    crypto.PrivateKey // []byte for HS*, *rsa.PrivateKey for RS* and ed25519.PrivateKey for EdDSA.
+}
+
+// ParseSigningKey parses the key given in the form of <alg>:<key>, where the key is the secret for HS256, HS384 and
+// HS512, or the base64 of the PKCS#8 DER private key for RS256, RS384, RS512 (PKCS#1 is accepted too) and EdDSA,
+// e.g. the output of `openssl genpkey -algorithm ed25519 -outform DER | base64`.
+func ParseSigningKey(id, s string) (SigningKey, error) {
+	alg, raw, ok := strings.Cut(s, ":")
+	if !ok || raw == "" {
+		return SigningKey{}, fmt.Errorf("jwtkit: key %q: expected <alg>:<key>", id)
+	}
+
+	key := SigningKey{ID: id, Method: jwt.GetSigningMethod(alg)}
+	switch key.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		key.Key = []byte(raw)
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
+		der, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil {
+			return SigningKey{}, fmt.Errorf("jwtkit: key %q: decode base64: %w", id, err)
+		}
+		if key.Key, err = parsePrivateKey(der); err != nil {
+			return SigningKey{}, fmt.Errorf("jwtkit: key %q: %w", id, err)
+		}
+	default:
+		return SigningKey{}, fmt.Errorf("jwtkit: key %q: unsupported algorithm %q", id, alg)
+	}
+	return key, key.validate()
+}
+
+// parsePrivateKey parses the PKCS#8 or PKCS#1 DER private key.
+func parsePrivateKey(der []byte) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS1PrivateKey(der)
+	if err != nil {
+		return nil, errors.New("parse private key: neither PKCS#8 nor PKCS#1")
+	}
+	return key, nil
+}
+
+// validate checks the key type matches the method.
+func (k SigningKey) validate() error {
+	if k.ID == "" {
+		return errors.New("jwtkit: key id is empty")
+	}
+
+	var ok bool
+	switch k.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		var secret []byte
+		secret, ok = k.Key.([]byte)
+		ok = ok && len(secret) > 0
+	case *jwt.SigningMethodRSA:
+		_, ok = k.Key.(*rsa.PrivateKey)
+	case *jwt.SigningMethodEd25519:
+		_, ok = k.Key.(ed25519.PrivateKey)
+	default:
+		return fmt.Errorf("jwtkit: key %q: unsupported method %v", k.ID, k.Method)
+	}
+	if !ok {
+		return fmt.Errorf("jwtkit: key %q: %T isn't a key of %s", k.ID, k.Key, k.Method.Alg())
+	}
+	return nil
+}
+
+// verificationKey returns the key for verifying the signatures, the public key of the asymmetric keys.
+func (k SigningKey) verificationKey() any {
+	switch key := k.Key.(type) {
+	case *rsa.PrivateKey:
+		return &key.PublicKey
+	case ed25519.PrivateKey:
+		return key.Public()
+	default:
+		return key
+	}
+}
+
+// JWK returns the public key as a JWK, it returns false for the HS* keys since their secrets must not be published.
+func (k SigningKey) JWK() (JWK, bool) {
+	switch key := k.Key.(type) {
+	case *rsa.PrivateKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: k.ID,
+			Use: "sig",
+			Alg: k.Method.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, true
+	case ed25519.PrivateKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: k.ID,
+			Use: "sig",
+			Alg: k.Method.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
+
+// KeySet signs the tokens by the active key and verifies them by the key of their kid, so the keys are rotated
+// without invalidating the issued tokens: Rotate to the new key, then Retire the old one after the tokens signed by
+// it expire. KeySet is concurrent-safe.
+type KeySet struct {
+	mu     sync.RWMutex
+	active string
+	keys   map[string]SigningKey
+}
+
+// NewKeySet creates a new KeySet signing by the active key, the others are only for verifying, e.g. the retiring
+// keys.
+func NewKeySet(active SigningKey, others ...SigningKey) (*KeySet, error) {
+	s := KeySet{keys: make(map[string]SigningKey, len(others)+1)}
+	for _, key := range others {
+		if err := s.add(key); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.add(active); err != nil {
+		return nil, err
+	}
+	s.active = active.ID
+	return &s, nil
+}
+
+// add adds the key, the key ID must be unique.
+func (s *KeySet) add(key SigningKey) error {
+	if err := key.validate(); err != nil {
+		return err
+	}
+	if _, ok := s.keys[key.ID]; ok {
+		return fmt.Errorf("jwtkit: key %q is already in the set", key.ID)
+	}
+	s.keys[key.ID] = key
+	return nil
+}
+
+// Rotate adds the key and signs the new tokens by it, the previous active key is kept for verifying.
+func (s *KeySet) Rotate(key SigningKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.add(key); err != nil {
+		return err
+	}
+	s.active = key.ID
+	return nil
+}
+
+// Retire removes the key, so the tokens signed by it are rejected. The active key can't be retired.
+func (s *KeySet) Retire(kid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if kid == s.active {
+		return fmt.Errorf("jwtkit: key %q is active", kid)
+	}
+	if _, ok := s.keys[kid]; !ok {
+		return fmt.Errorf("%w: kid=%q", ErrKeyNotFound, kid)
+	}
+	delete(s.keys, kid)
+	return nil
+}
+
+// Sign signs the claims by the active key, the kid header is set to its ID.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	s.mu.RLock()
+	key := s.keys[s.active]
+	s.mu.RUnlock()
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.Key)
+	if err != nil {
+		return "", fmt.Errorf("jwtkit: sign: %w", err)
+	}
+	return signed, nil
+}
+
+// Parse verifies the token by the key of its kid and decodes its claims, the options are the ones of jwt.Parser, e.g.
+// jwt.WithIssuer. Only the algorithms of the keys are accepted.
+func (s *KeySet) Parse(token string, claims jwt.Claims, opts ...jwt.ParserOption) (*jwt.Token, error) {
+	opts = append([]jwt.ParserOption{jwt.WithValidMethods(s.Algorithms())}, opts...)
+	parsed, err := jwt.NewParser(opts...).ParseWithClaims(token, claims, s.Keyfunc)
+	if err != nil {
+		return nil, fmt.Errorf("jwtkit: parse: %w", err)
+	}
+	return parsed, nil
+}
+
+// Keyfunc is the jwt.Keyfunc returning the verification key of the token kid. It returns ErrKeyNotFound if the kid
+// is unknown, or ErrAlgorithmMismatch if the token algorithm isn't the one of the key.
+func (s *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: kid=%q", ErrKeyNotFound, kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("%w: kid=%q alg=%q", ErrAlgorithmMismatch, kid, token.Method.Alg())
+	}
+	return key.verificationKey(), nil
+}
+
+// Algorithms returns the algorithms of the keys, sorted.
+func (s *KeySet) Algorithms() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	algorithms := make([]string, 0, len(s.keys))
+	for _, key := range s.keys {
+		if alg := key.Method.Alg(); !slices.Contains(algorithms, alg) {
+			algorithms = append(algorithms, alg)
+		}
+	}
+	slices.Sort(algorithms)
+	return algorithms
+}
+
+// JWKSet returns the public keys of the asymmetric keys for publishing, e.g. at /.well-known/jwks.json, so the other
+// services verify the tokens by JWKS. The HS* keys are never included.
+func (s *KeySet) JWKSet() JWKSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := JWKSet{Keys: make([]JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		if jwk, ok := key.JWK(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	slices.SortFunc(set.Keys, func(a, b JWK) int { return strings.Compare(a.Kid, b.Kid) })
+	return set
+}
@@ -0,0 +1,134 @@
+package jwtkit
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testClaims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   "alice",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}
+}
+
+func TestParseSigningKey(t *testing.T) {
+	_, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	expectNoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edPriv)
+	expectNoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	expectNoError(t, err)
+	rsaDER := x509.MarshalPKCS1PrivateKey(rsaKey)
+
+	key, err := ParseSigningKey("hs", "HS256:0123456789abcdef0123456789abcdef")
+	expectNoError(t, err)
+	expectTrue(t, key.Method == jwt.SigningMethodHS256)
+	expectTrue(t, string(key.Key.([]byte)) == "0123456789abcdef0123456789abcdef")
+
+	key, err = ParseSigningKey("ed", "EdDSA:"+base64.StdEncoding.EncodeToString(edDER))
+	expectNoError(t, err)
+	expectTrue(t, key.Key.(ed25519.PrivateKey).Equal(edPriv))
+
+	key, err = ParseSigningKey("rsa", "RS256:"+base64.StdEncoding.EncodeToString(rsaDER))
+	expectNoError(t, err)
+	expectTrue(t, key.Key.(*rsa.PrivateKey).Equal(rsaKey))
+
+	invalid := []string{
+		"no-algorithm",
+		"HS256:",
+		"none:secret",
+		"ES256:" + base64.StdEncoding.EncodeToString(edDER),
+		"EdDSA:not base64",
+		"EdDSA:" + base64.StdEncoding.EncodeToString([]byte("not a key")),
+		"RS256:" + base64.StdEncoding.EncodeToString(edDER), // not an RSA key.
+	}
+	for _, s := range invalid {
+		_, err := ParseSigningKey("invalid", s)
+		expectTrue(t, err != nil)
+	}
+}
+
+func TestKeySet(t *testing.T) {
+	_, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	expectNoError(t, err)
+
+	hs := SigningKey{ID: "hs", Method: jwt.SigningMethodHS256, Key: []byte("0123456789abcdef0123456789abcdef")}
+	ed := SigningKey{ID: "ed", Method: jwt.SigningMethodEdDSA, Key: edPriv}
+
+	keys, err := NewKeySet(hs)
+	expectNoError(t, err)
+
+	old, err := keys.Sign(testClaims())
+	expectNoError(t, err)
+
+	expectNoError(t, keys.Rotate(ed))
+	expectTrue(t, keys.Rotate(ed) != nil) // duplicate key ID.
+	expectTrue(t, len(keys.Algorithms()) == 2)
+
+	current, err := keys.Sign(testClaims())
+	expectNoError(t, err)
+
+	// the tokens of both the active and the previous key are accepted.
+	var claims jwt.RegisteredClaims
+	token, err := keys.Parse(current, &claims)
+	expectNoError(t, err)
+	expectTrue(t, token.Header["kid"] == "ed" && claims.Subject == "alice")
+
+	token, err = keys.Parse(old, &jwt.RegisteredClaims{})
+	expectNoError(t, err)
+	expectTrue(t, token.Header["kid"] == "hs")
+
+	// only the public key of the asymmetric key is published.
+	set := keys.JWKSet()
+	expectTrue(t, len(set.Keys) == 1 && set.Keys[0].Kid == "ed")
+	pub, err := set.Keys[0].PublicKey()
+	expectNoError(t, err)
+	expectTrue(t, pub.(ed25519.PublicKey).Equal(edPriv.Public()))
+
+	expectTrue(t, keys.Retire("ed") != nil) // the active key.
+	expectNoError(t, keys.Retire("hs"))
+	expectTrue(t, errors.Is(keys.Retire("hs"), ErrKeyNotFound))
+	_, err = keys.Parse(old, &jwt.RegisteredClaims{})
+	expectTrue(t, err != nil)
+}
+
+func TestKeySet_AlgorithmMismatch(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	expectNoError(t, err)
+
+	keys, err := NewKeySet(
+		SigningKey{ID: "rsa", Method: jwt.SigningMethodRS256, Key: rsaKey},
+		SigningKey{ID: "hs", Method: jwt.SigningMethodHS256, Key: []byte("secret")},
+	)
+	expectNoError(t, err)
+
+	// an HS256 token claiming the kid of the RSA key, signed by its public key as the HMAC secret.
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	forged.Header["kid"] = "rsa"
+	raw, err := forged.SignedString(x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey))
+	expectNoError(t, err)
+
+	_, err = keys.Parse(raw, &jwt.RegisteredClaims{})
+	expectTrue(t, errors.Is(err, ErrAlgorithmMismatch))
+}
+
+func TestNewKeySet_Invalid(t *testing.T) {
+	_, err := NewKeySet(SigningKey{ID: "", Method: jwt.SigningMethodHS256, Key: []byte("secret")})
+	expectTrue(t, err != nil)
+
+	_, err = NewKeySet(SigningKey{ID: "hs", Method: jwt.SigningMethodHS256, Key: "not bytes"})
+	expectTrue(t, err != nil)
+
+	_, err = NewKeySet(SigningKey{ID: "ed", Method: jwt.SigningMethodEdDSA, Key: []byte("secret")})
+	expectTrue(t, err != nil)
+}
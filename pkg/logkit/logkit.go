@@ -0,0 +1,67 @@
+// Package logkit provides the construction of the application logger, the slog handler is chosen by its format and
+// the level can be changed at runtime.
+package logkit
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Set of supported formats.
+const (
+	FormatText = "text" // key=value pairs, easier to read in development.
+	FormatJSON = "json" // one JSON object per line, easier to be parsed by the log collectors.
+)
+
+// Config is the configuration for creating a logger.
+type Config struct {
+	Format    string         `env:"FORMAT,default=text"` // One of FormatText or FormatJSON, default is FormatText.
+	Level     *slog.LevelVar `env:"LEVEL,default=info"`  // The minimum level, it can be changed at runtime. Default is slog.LevelInfo.
+	AddSource bool           `env:"ADD_SOURCE"`          // Include the source file and line of the log call.
+}
+
+// New creates a logger writing to w by the configuration. If the Config.Level is nil, a new one is created, so the
+// level can only be changed through the Config.Level given by the caller.
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	level := cfg.Level
+	if level == nil {
+		level = new(slog.LevelVar)
+	}
+
+	opts := slog.HandlerOptions{
+		AddSource: cfg.AddSource,
+		Level:     level,
+	}
+
+	switch cfg.Format {
+	case FormatText, "":
+		return slog.New(slog.NewTextHandler(w, &opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, &opts)), nil
+	default:
+		return nil, fmt.Errorf("logkit: unknown format: %q", cfg.Format)
+	}
+}
+
+// ParseLevel parses the level name, e.g. debug, INFO, warn+2 or error, case-insensitive.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("logkit: %w", err)
+	}
+	return level, nil
+}
+
+// NewLevelVar creates a slog.LevelVar set to the parsed level name, see ParseLevel.
+func NewLevelVar(s string) (*slog.LevelVar, error) {
+	level, err := ParseLevel(s)
+	if err != nil {
+		return nil, err
+	}
+
+	v := new(slog.LevelVar)
+	v.Set(level)
+	return v, nil
+}
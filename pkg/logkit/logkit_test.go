@@ -0,0 +1,72 @@
+package logkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	level, err := NewLevelVar("warn")
+	expectTrue(t, err == nil)
+
+	var buf bytes.Buffer
+	log, err := New(&buf, Config{Format: FormatJSON, Level: level})
+	expectTrue(t, err == nil)
+
+	log.Info("hidden")
+	expectTrue(t, buf.Len() == 0)
+
+	log.Warn("shown")
+	var got map[string]any
+	expectTrue(t, json.Unmarshal(buf.Bytes(), &got) == nil)
+	expectTrue(t, got["msg"] == "shown")
+
+	// changed at runtime.
+	buf.Reset()
+	level.Set(slog.LevelDebug)
+	log.Debug("debug")
+	expectTrue(t, strings.Contains(buf.String(), `"msg":"debug"`))
+}
+
+func TestNew_Text(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := New(&buf, Config{})
+	expectTrue(t, err == nil)
+
+	log.Debug("hidden")
+	log.Info("shown")
+	expectTrue(t, strings.Contains(buf.String(), "msg=shown"))
+	expectTrue(t, !strings.Contains(buf.String(), "hidden"))
+
+	_, err = New(&buf, Config{Format: "xml"})
+	expectTrue(t, err != nil)
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want slog.Level
+		ok   bool
+	}{
+		{in: "debug", want: slog.LevelDebug, ok: true},
+		{in: " INFO ", want: slog.LevelInfo, ok: true},
+		{in: "warn+2", want: slog.LevelWarn + 2, ok: true},
+		{in: "verbose", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		expectTrue(t, (err == nil) == tt.ok)
+		expectTrue(t, got == tt.want)
+	}
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
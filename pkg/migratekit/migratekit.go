@@ -0,0 +1,309 @@
+// Package migratekit applies the versioned SQL migrations and tracks the applied versions in a schema table.
+//
+// The migrations are read from a file system, usually an embed.FS, where each migration is a pair of files named
+// "<version>_<name>.up.sql" and "<version>_<name>.down.sql", e.g. "20240101120000_create_users.up.sql". The version
+// is a positive integer, the timestamp of the creation is recommended for avoiding conflicts between branches. The
+// down file is optional, but a migration without one can't be rolled back.
+package migratekit
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+// DefaultTable is the name of the schema table used when Config.Table is empty.
+const DefaultTable = "schema_migrations"
+
+// ErrNoDown is returned when rolling back a migration that has no down file.
+var ErrNoDown = errors.New("migratekit: no down migration")
+
+// Migration is a versioned schema change.
+type Migration struct {
+	Version int64  // the version parsed from the file name, the migrations are applied in ascending order.
+	Name    string // the descriptive part of the file name.
+	Up      string // the SQL applying the change.
+	Down    string // the SQL reverting the change, empty if there is no down file.
+}
+
+// String returns the string representation of the Migration, e.g. "20240101120000_create_users".
+func (m Migration) String() string { return fmt.Sprintf("%d_%s", m.Version, m.Name) }
+
+// Load reads the migrations from the root of fsys, sorted by version. Files other than "*.up.sql" and "*.down.sql"
+// are ignored, so the directory may contain e.g. a README.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migratekit: read dir: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		base, isUp := strings.CutSuffix(entry.Name(), ".up.sql")
+		if !isUp {
+			var isDown bool
+			if base, isDown = strings.CutSuffix(entry.Name(), ".down.sql"); !isDown {
+				continue
+			}
+		}
+
+		rawVersion, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(rawVersion, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migratekit: invalid version of %q: must be a positive integer", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("migratekit: read %q: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migratekit: version %d is used by both %q and %q", version, m.Name, name)
+		}
+
+		if isUp {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migratekit: migration %s has no up file", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return migrations, nil
+}
+
+// Config is the configuration of the Migrator.
+type Config struct {
+	// Table is the name of the schema table tracking the applied versions. Default DefaultTable.
+	Table string
+
+	// DryRun reports the migrations that would be applied or rolled back without executing them. The schema table
+	// is still created if it doesn't exist, since it is needed for finding the pending migrations.
+	DryRun bool
+
+	// OnMigrate is called after a migration is applied or rolled back, or would be in DryRun mode, e.g. for logging
+	// the progress.
+	OnMigrate func(m Migration, direction Direction, elapsed time.Duration)
+}
+
+// withDefaults returns the config with the zero values replaced by the defaults.
+func (c Config) withDefaults() Config {
+	if c.Table == "" {
+		c.Table = DefaultTable
+	}
+	if c.OnMigrate == nil {
+		c.OnMigrate = func(Migration, Direction, time.Duration) {}
+	}
+	return c
+}
+
+// Direction is the direction of a migration.
+type Direction uint8
+
+// Sets of directions.
+const (
+	DirectionUp   Direction = iota // applying the migration.
+	DirectionDown                  // rolling back the migration.
+)
+
+// String returns the string representation of Direction.
+func (d Direction) String() string {
+	switch d {
+	case DirectionUp:
+		return "up"
+	case DirectionDown:
+		return "down"
+	default:
+		return "unknown"
+	}
+}
+
+// Status is the state of a migration in the database.
+type Status struct {
+	Migration
+	AppliedAt time.Time // zero if the migration is pending.
+}
+
+// Applied reports whether the migration is applied.
+func (s Status) Applied() bool { return !s.AppliedAt.IsZero() }
+
+// Migrator applies and rolls back the migrations.
+//
+// Each migration is executed in its own transaction together with the update of the schema table, so a failed
+// migration leaves no trace on the databases supporting transactional DDL, e.g. PostgreSQL. The Migrator doesn't lock
+// the schema table, run it from a single process, e.g. a deployment job, when there are many replicas.
+type Migrator struct {
+	db         sqlxkit.DB
+	migrations []Migration
+	cfg        Config
+}
+
+// New creates a Migrator of the migrations in fsys, see Load.
+func New(db sqlxkit.DB, fsys fs.FS, cfg Config) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, cfg: cfg.withDefaults()}, nil
+}
+
+// Migrations returns the loaded migrations, sorted by version.
+func (m *Migrator) Migrations() []Migration { return slices.Clone(m.migrations) }
+
+// Status returns the state of every migration, sorted by version. The applied versions that are unknown to the
+// Migrator, e.g. applied by a newer release, are not reported.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, mig := range m.migrations {
+		statuses[i] = Status{Migration: mig, AppliedAt: applied[mig.Version]}
+	}
+	return statuses, nil
+}
+
+// Up applies all the pending migrations in ascending order and returns them. It stops at the first failure, the
+// migrations applied before it stay applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, s := range statuses {
+		if s.Applied() {
+			continue
+		}
+		if err := m.run(ctx, s.Migration, DirectionUp); err != nil {
+			return done, err
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// Down rolls back the last steps applied migrations in descending order and returns them, steps less than one rolls
+// back only the last one. It stops at the first failure, the migrations rolled back before it stay rolled back.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	steps = max(steps, 1)
+	var done []Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		s := statuses[i]
+		if !s.Applied() {
+			continue
+		}
+		if strings.TrimSpace(s.Down) == "" {
+			return done, fmt.Errorf("migratekit: roll back %s: %w", s.Migration, ErrNoDown)
+		}
+		if err := m.run(ctx, s.Migration, DirectionDown); err != nil {
+			return done, err
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// run executes the migration in the direction and updates the schema table in the same transaction.
+func (m *Migrator) run(ctx context.Context, mig Migration, direction Direction) error {
+	start := time.Now()
+	if m.cfg.DryRun {
+		m.cfg.OnMigrate(mig, direction, time.Since(start))
+		return nil
+	}
+
+	script, track := mig.Up, m.insertVersion(mig)
+	if direction == DirectionDown {
+		script, track = mig.Down, m.deleteVersion(mig)
+	}
+
+	exec := func(ctx context.Context, tx sqlxkit.Tx) (context.Context, error) {
+		_, err := tx.ExecContext(ctx, script)
+		return ctx, err
+	}
+
+	if err := sqlxkit.ExecTransaction(ctx, m.db, exec, track); err != nil {
+		return fmt.Errorf("migratekit: migrate %s %s: %w", direction, mig, err)
+	}
+	m.cfg.OnMigrate(mig, direction, time.Since(start))
+	return nil
+}
+
+// insertVersion records the migration as applied.
+func (m *Migrator) insertVersion(mig Migration) sqlxkit.Atomic {
+	query := m.db.Rebind(fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (?, ?, ?)", m.cfg.Table))
+	return func(ctx context.Context, tx sqlxkit.Tx) (context.Context, error) {
+		_, err := tx.ExecContext(ctx, query, mig.Version, mig.Name, time.Now().UTC())
+		return ctx, err
+	}
+}
+
+// deleteVersion records the migration as rolled back.
+func (m *Migrator) deleteVersion(mig Migration) sqlxkit.Atomic {
+	query := m.db.Rebind(fmt.Sprintf("DELETE FROM %s WHERE version = ?", m.cfg.Table))
+	return func(ctx context.Context, tx sqlxkit.Tx) (context.Context, error) {
+		_, err := tx.ExecContext(ctx, query, mig.Version)
+		return ctx, err
+	}
+}
+
+// appliedRow is a row of the schema table.
+type appliedRow struct {
+	Version   int64     `sql:"version"`
+	AppliedAt time.Time `sql:"applied_at"`
+}
+
+// applied returns the applied versions and when they were applied, creating the schema table if it doesn't exist.
+func (m *Migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	ddl := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	version    BIGINT PRIMARY KEY,
+	name       VARCHAR(255) NOT NULL,
+	applied_at TIMESTAMP NOT NULL
+)`, m.cfg.Table)
+	if _, err := m.db.ExecContext(ctx, ddl); err != nil {
+		return nil, fmt.Errorf("migratekit: create schema table: %w", err)
+	}
+
+	rows, err := sqlxkit.All[appliedRow](ctx, m.db, fmt.Sprintf("SELECT version, applied_at FROM %s", m.cfg.Table))
+	if err != nil {
+		return nil, fmt.Errorf("migratekit: read schema table: %w", err)
+	}
+
+	applied := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+	return applied, nil
+}
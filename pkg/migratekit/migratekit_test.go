@@ -0,0 +1,165 @@
+package migratekit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+var _testFS = fstest.MapFS{
+	"2_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD email TEXT")},
+	"2_add_email.down.sql":    {Data: []byte("ALTER TABLE users DROP email")},
+	"1_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id BIGINT)")},
+	"1_create_users.down.sql": {Data: []byte("DROP TABLE users")},
+	"3_seed_admin.up.sql":     {Data: []byte("INSERT INTO users (id) VALUES (1)")},
+	"README.md":               {Data: []byte("ignored")},
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(_testFS)
+	expectTrue(t, err == nil)
+	expectTrue(t, len(migrations) == 3)
+	expectTrue(t, migrations[0] == Migration{
+		Version: 1,
+		Name:    "create_users",
+		Up:      "CREATE TABLE users (id BIGINT)",
+		Down:    "DROP TABLE users",
+	})
+	expectTrue(t, migrations[1].String() == "2_add_email")
+	expectTrue(t, migrations[2].Down == "")
+
+	_, err = Load(fstest.MapFS{"x_bad.up.sql": {Data: []byte("SELECT 1")}})
+	expectTrue(t, err != nil)
+
+	_, err = Load(fstest.MapFS{"1_only_down.down.sql": {Data: []byte("SELECT 1")}})
+	expectTrue(t, err != nil)
+
+	_, err = Load(fstest.MapFS{
+		"1_a.up.sql": {Data: []byte("SELECT 1")},
+		"1_b.up.sql": {Data: []byte("SELECT 2")},
+	})
+	expectTrue(t, err != nil)
+}
+
+func TestMigrator_Up(t *testing.T) {
+	db, mock := setup(t)
+	m, err := New(db, _testFS, Config{})
+	expectTrue(t, err == nil)
+
+	expectApplied(mock, 1)
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE users ADD email TEXT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(2, "add_email", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO users \(id\) VALUES \(1\)`).WillReturnError(errExample)
+	mock.ExpectRollback()
+
+	done, err := m.Up(context.Background())
+	expectTrue(t, errors.Is(err, errExample))
+	expectTrue(t, len(done) == 1 && done[0].Version == 2)
+}
+
+func TestMigrator_Down(t *testing.T) {
+	db, mock := setup(t)
+	m, err := New(db, _testFS, Config{})
+	expectTrue(t, err == nil)
+
+	expectApplied(mock, 1, 2)
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE users DROP email").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM schema_migrations WHERE version = ?").WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	done, err := m.Down(context.Background(), 0)
+	expectTrue(t, err == nil)
+	expectTrue(t, len(done) == 1 && done[0].Version == 2)
+
+	expectApplied(mock, 1, 2, 3)
+	done, err = m.Down(context.Background(), 3)
+	expectTrue(t, errors.Is(err, ErrNoDown))
+	expectTrue(t, len(done) == 0)
+}
+
+func TestMigrator_DryRun(t *testing.T) {
+	db, mock := setup(t)
+
+	var reported []string
+	m, err := New(db, _testFS, Config{
+		Table:  "versions",
+		DryRun: true,
+		OnMigrate: func(m Migration, direction Direction, _ time.Duration) {
+			reported = append(reported, direction.String()+" "+m.String())
+		},
+	})
+	expectTrue(t, err == nil)
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS versions").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, applied_at FROM versions").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, time.Now()))
+
+	done, err := m.Up(context.Background())
+	expectTrue(t, err == nil)
+	expectTrue(t, len(done) == 2)
+	expectTrue(t, len(reported) == 2 && reported[0] == "up 2_add_email" && reported[1] == "up 3_seed_admin")
+}
+
+func TestMigrator_Status(t *testing.T) {
+	db, mock := setup(t)
+	m, err := New(db, _testFS, Config{})
+	expectTrue(t, err == nil)
+
+	expectApplied(mock, 1)
+	statuses, err := m.Status(context.Background())
+	expectTrue(t, err == nil)
+	expectTrue(t, len(statuses) == 3)
+	expectTrue(t, statuses[0].Applied())
+	expectTrue(t, !statuses[1].Applied() && !statuses[2].Applied())
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnError(errExample)
+	_, err = m.Status(context.Background())
+	expectTrue(t, errors.Is(err, errExample))
+}
+
+var errExample = errors.New("example error")
+
+func setup(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	dbx := sqlxkit.ApplyConfig(sqlx.NewDb(db, "sql-mock"))
+	t.Cleanup(func() {
+		defer func() { _ = dbx.Close() }()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+	return dbx, mock
+}
+
+func expectApplied(mock sqlmock.Sqlmock, versions ...int64) {
+	rows := sqlmock.NewRows([]string{"version", "applied_at"})
+	for _, v := range versions {
+		rows.AddRow(v, time.Now())
+	}
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, applied_at FROM schema_migrations").WillReturnRows(rows)
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
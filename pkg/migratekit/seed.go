@@ -0,0 +1,103 @@
+package migratekit
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+// Seed is a named set of data, e.g. the reference data or the fixtures of the development environment.
+//
+// The seeds are run every time the Seeder runs, so they must be idempotent, e.g. by using
+// "INSERT ... ON CONFLICT DO NOTHING" or by checking the existence of the data first.
+type Seed struct {
+	Name string         // the name for reporting, e.g. "001_admin_user".
+	Run  sqlxkit.Atomic // inserts the data within the transaction of the seed.
+}
+
+// SeedSQL creates a Seed executing the SQL script.
+func SeedSQL(name, script string) Seed {
+	return Seed{
+		Name: name,
+		Run: func(ctx context.Context, tx sqlxkit.Tx) (context.Context, error) {
+			_, err := tx.ExecContext(ctx, script)
+			return ctx, err
+		},
+	}
+}
+
+// LoadSeeds reads the "*.sql" files from the root of fsys as seeds, sorted by the file name, so prefix the names with
+// a number for ordering them, e.g. "001_roles.sql" and "002_admin_user.sql". The other files are ignored.
+func LoadSeeds(fsys fs.FS) ([]Seed, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("migratekit: glob seeds: %w", err)
+	}
+	slices.Sort(names)
+
+	seeds := make([]Seed, 0, len(names))
+	for _, name := range names {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("migratekit: read %q: %w", name, err)
+		}
+		seeds = append(seeds, SeedSQL(strings.TrimSuffix(path.Base(name), ".sql"), string(content)))
+	}
+	return seeds, nil
+}
+
+// Seeder runs the seeds in order.
+type Seeder struct {
+	db     sqlxkit.DB
+	seeds  []Seed
+	onSeed func(s Seed, elapsed time.Duration)
+}
+
+// NewSeeder creates a Seeder of the seeds, onSeed is called after each seed runs, e.g. for logging the progress, and
+// may be nil.
+func NewSeeder(db sqlxkit.DB, onSeed func(s Seed, elapsed time.Duration), seeds ...Seed) *Seeder {
+	if onSeed == nil {
+		onSeed = func(Seed, time.Duration) {}
+	}
+	return &Seeder{db: db, seeds: seeds, onSeed: onSeed}
+}
+
+// Run runs every seed in its own transaction, in order. It stops at the first failure, the seeds run before it stay
+// committed.
+func (s *Seeder) Run(ctx context.Context) error {
+	for _, seed := range s.seeds {
+		start := time.Now()
+		if err := sqlxkit.ExecTransaction(ctx, s.db, seed.Run); err != nil {
+			return fmt.Errorf("migratekit: seed %s: %w", seed.Name, err)
+		}
+		s.onSeed(seed, time.Since(start))
+	}
+	return nil
+}
+
+// Prepare applies the pending migrations in migrations and then runs the seeds, so the integration tests start from a
+// known state of the database:
+//
+//	func TestMain(m *testing.M) {
+//		db := ... // open the test database.
+//		if err := migratekit.Prepare(ctx, db, migrations.Postgre(), fixtures...); err != nil {
+//			log.Fatal(err)
+//		}
+//		os.Exit(m.Run())
+//	}
+func Prepare(ctx context.Context, db sqlxkit.DB, migrations fs.FS, seeds ...Seed) error {
+	migrator, err := New(db, migrations, Config{})
+	if err != nil {
+		return err
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		return err
+	}
+	return NewSeeder(db, nil, seeds...).Run(ctx)
+}
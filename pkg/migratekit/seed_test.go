@@ -0,0 +1,69 @@
+package migratekit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+func TestLoadSeeds(t *testing.T) {
+	seeds, err := LoadSeeds(fstest.MapFS{
+		"002_admin_user.sql": {Data: []byte("INSERT INTO users (id) VALUES (1)")},
+		"001_roles.sql":      {Data: []byte("INSERT INTO roles (id) VALUES (1)")},
+		"README.md":          {Data: []byte("ignored")},
+	})
+	expectTrue(t, err == nil)
+	expectTrue(t, len(seeds) == 2)
+	expectTrue(t, seeds[0].Name == "001_roles")
+	expectTrue(t, seeds[1].Name == "002_admin_user")
+}
+
+func TestSeeder_Run(t *testing.T) {
+	db, mock := setup(t)
+
+	var ran []string
+	goSeed := Seed{
+		Name: "go_seed",
+		Run: func(ctx context.Context, tx sqlxkit.Tx) (context.Context, error) {
+			_, err := tx.ExecContext(ctx, "INSERT INTO settings")
+			return ctx, err
+		},
+	}
+	seeder := NewSeeder(db, func(s Seed, _ time.Duration) { ran = append(ran, s.Name) },
+		SeedSQL("sql_seed", "INSERT INTO roles"),
+		goSeed,
+		SeedSQL("failing_seed", "INSERT INTO users"),
+		SeedSQL("skipped_seed", "INSERT INTO posts"),
+	)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO roles").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO settings").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users").WillReturnError(errExample)
+	mock.ExpectRollback()
+
+	err := seeder.Run(context.Background())
+	expectTrue(t, errors.Is(err, errExample))
+	expectTrue(t, len(ran) == 2 && ran[0] == "sql_seed" && ran[1] == "go_seed")
+}
+
+func TestPrepare(t *testing.T) {
+	db, mock := setup(t)
+
+	expectApplied(mock, 1, 2, 3)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO roles").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := Prepare(context.Background(), db, _testFS, SeedSQL("roles", "INSERT INTO roles"))
+	expectTrue(t, err == nil)
+}
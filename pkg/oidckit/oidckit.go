@@ -0,0 +1,248 @@
+// Package oidckit is a client of the OAuth 2.0 authorization code flow with PKCE (RFC 7636) and OpenID Connect: the
+// provider discovery, the code exchange and the verification of the ID tokens.
+package oidckit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josestg/swe-be-mono/pkg/jwtkit"
+)
+
+// ErrNoIDToken is returned by VerifyIDToken when the provider doesn't publish its keys, i.e. it isn't an OpenID
+// Connect provider.
+var ErrNoIDToken = errors.New("oidckit: the provider has no jwks uri")
+
+// Endpoints are the endpoints of the provider, the subset of the OpenID Provider Metadata used by the Client.
+type Endpoints struct {
+	Issuer      string `json:"issuer"`
+	AuthURL     string `json:"authorization_endpoint"`
+	TokenURL    string `json:"token_endpoint"`
+	UserInfoURL string `json:"userinfo_endpoint"` // empty if not supported.
+	JWKSURL     string `json:"jwks_uri"`          // empty if not an OpenID Connect provider.
+}
+
+// Discover fetches the endpoints of the OpenID Connect provider from its discovery document at
+// <issuer>/.well-known/openid-configuration. The issuer of the document must be the given issuer.
+func Discover(ctx context.Context, client *http.Client, issuer string) (Endpoints, error) {
+	if client == nil {
+		client = _defaultHTTPClient
+	}
+
+	u := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return Endpoints{}, fmt.Errorf("oidckit: new discovery request: %w", err)
+	}
+
+	var e Endpoints
+	if err := doJSON(client, req, &e); err != nil {
+		return Endpoints{}, fmt.Errorf("oidckit: discover %s: %w", issuer, err)
+	}
+	if e.Issuer != issuer {
+		return Endpoints{}, fmt.Errorf("oidckit: discover %s: the issuer of the document is %q", issuer, e.Issuer)
+	}
+	if e.AuthURL == "" || e.TokenURL == "" {
+		return Endpoints{}, fmt.Errorf("oidckit: discover %s: missing authorization or token endpoint", issuer)
+	}
+	return e, nil
+}
+
+// _defaultHTTPClient is the http client used when none is given.
+var _defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// Config is the configuration of the Client.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string       // the callback URL, registered at the provider.
+	Scopes       []string     // e.g. openid, email and profile.
+	Endpoints    Endpoints    // see Discover.
+	HTTPClient   *http.Client // Default http.Client with 10 seconds timeout.
+}
+
+// withDefaults returns a copy of the config with default values for unset fields.
+func (c Config) withDefaults() Config {
+	if c.HTTPClient == nil {
+		c.HTTPClient = _defaultHTTPClient
+	}
+	return c
+}
+
+// Token is the token response of the provider.
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"` // empty if the provider isn't an OpenID Connect provider.
+	Scope       string `json:"scope"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// IDTokenClaims is the claims of the ID tokens, including the standard claims of the email and the profile scopes.
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+// Client is a client of a provider. Client is concurrent-safe.
+type Client struct {
+	cfg  Config
+	jwks *jwtkit.JWKS // nil if the provider has no jwks uri.
+}
+
+// NewClient creates a new Client.
+func NewClient(cfg Config) *Client {
+	cfg = cfg.withDefaults()
+	c := Client{cfg: cfg}
+	if cfg.Endpoints.JWKSURL != "" {
+		c.jwks = jwtkit.NewJWKS(cfg.Endpoints.JWKSURL, jwtkit.WithHTTPClient(cfg.HTTPClient))
+	}
+	return &c
+}
+
+// AuthCodeURL returns the URL of the authorization request redirecting the user to the provider. The state is for
+// preventing the CSRF, the nonce is for binding the ID token to the request (omitted if empty), and the verifier is
+// the PKCE code verifier, see NewVerifier.
+func (c *Client) AuthCodeURL(state, nonce, verifier string) string {
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", c.cfg.ClientID)
+	q.Set("redirect_uri", c.cfg.RedirectURL)
+	q.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("code_challenge", Challenge(verifier))
+	q.Set("code_challenge_method", "S256")
+	if nonce != "" {
+		q.Set("nonce", nonce)
+	}
+
+	sep := "?"
+	if strings.Contains(c.cfg.Endpoints.AuthURL, "?") {
+		sep = "&"
+	}
+	return c.cfg.Endpoints.AuthURL + sep + q.Encode()
+}
+
+// Exchange exchanges the authorization code for the tokens, proving the request by the PKCE code verifier.
+func (c *Client) Exchange(ctx context.Context, code, verifier string) (Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.cfg.RedirectURL)
+	form.Set("client_id", c.cfg.ClientID)
+	form.Set("client_secret", c.cfg.ClientSecret)
+	form.Set("code_verifier", verifier)
+
+	body := strings.NewReader(form.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.Endpoints.TokenURL, body)
+	if err != nil {
+		return Token{}, fmt.Errorf("oidckit: new token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var t Token
+	if err := doJSON(c.cfg.HTTPClient, req, &t); err != nil {
+		return Token{}, fmt.Errorf("oidckit: exchange code: %w", err)
+	}
+	if t.AccessToken == "" {
+		return Token{}, errors.New("oidckit: exchange code: no access token in the response")
+	}
+	return t, nil
+}
+
+// VerifyIDToken verifies the ID token by the keys of the provider, its issuer, audience, expiry and the nonce of the
+// authorization request. It returns ErrNoIDToken if the provider has no jwks uri.
+func (c *Client) VerifyIDToken(ctx context.Context, raw, nonce string) (IDTokenClaims, error) {
+	if c.jwks == nil {
+		return IDTokenClaims{}, ErrNoIDToken
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "EdDSA"}),
+		jwt.WithIssuer(c.cfg.Endpoints.Issuer),
+		jwt.WithAudience(c.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+
+	var claims IDTokenClaims
+	_, err := parser.ParseWithClaims(raw, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return c.jwks.Key(ctx, kid)
+	})
+	if err != nil {
+		return IDTokenClaims{}, fmt.Errorf("oidckit: verify id token: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return IDTokenClaims{}, errors.New("oidckit: verify id token: nonce mismatch")
+	}
+	return claims, nil
+}
+
+// UserInfo fetches the JSON resource at the URL authorized by the access token into dst, e.g. the userinfo endpoint
+// of the provider, or the APIs of the plain OAuth 2.0 providers.
+func (c *Client) UserInfo(ctx context.Context, resource, accessToken string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, resource, nil)
+	if err != nil {
+		return fmt.Errorf("oidckit: new userinfo request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	if err := doJSON(c.cfg.HTTPClient, req, dst); err != nil {
+		return fmt.Errorf("oidckit: fetch %s: %w", resource, err)
+	}
+	return nil
+}
+
+// Endpoints returns the endpoints of the provider.
+func (c *Client) Endpoints() Endpoints { return c.cfg.Endpoints }
+
+// doJSON sends the request and decodes the JSON response into dst, the non-2xx responses are errors.
+func doJSON(client *http.Client, req *http.Request, dst any) error {
+	req.Header.Set("Accept", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d: %.200s", res.StatusCode, body)
+	}
+	if err := json.Unmarshal(body, dst); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// NewVerifier generates a PKCE code verifier of 256 random bits, also suitable for the state and the nonce.
+func NewVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("oidckit: generate verifier: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Challenge returns the S256 PKCE code challenge of the verifier.
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,154 @@
+package oidckit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josestg/swe-be-mono/pkg/jwtkit"
+)
+
+// testProvider is a fake OpenID Connect provider issuing an ID token for the code "good-code".
+type testProvider struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	verifier string // the expected PKCE code verifier.
+	nonce    string // the nonce of the issued ID token.
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	expectNoError(t, err)
+
+	p := &testProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Endpoints{
+			Issuer:   p.URL,
+			AuthURL:  p.URL + "/authorize",
+			TokenURL: p.URL + "/token",
+			JWKSURL:  p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jwtkit.JWKSet{Keys: []jwtkit.JWK{{
+			Kty: "RSA",
+			Kid: "k1",
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("code") != "good-code" || r.PostFormValue("code_verifier") != p.verifier ||
+			r.PostFormValue("client_id") != "client" || r.PostFormValue("client_secret") != "secret" {
+			http.Error(w, `{"error": "invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(Token{AccessToken: "access", TokenType: "Bearer", IDToken: p.idToken(t)})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *testProvider) idToken(t *testing.T) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    p.URL,
+			Subject:   "1234",
+			Audience:  jwt.ClaimStrings{"client"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		Nonce:         p.nonce,
+		Email:         "alice@example.com",
+		EmailVerified: true,
+	})
+	token.Header["kid"] = "k1"
+	raw, err := token.SignedString(p.key)
+	expectNoError(t, err)
+	return raw
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProvider(t)
+
+	endpoints, err := Discover(ctx, nil, p.URL)
+	expectNoError(t, err)
+	expectTrue(t, endpoints.TokenURL == p.URL+"/token")
+
+	client := NewClient(Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "https://app.example.com/callback",
+		Scopes:       []string{"openid", "email"},
+		Endpoints:    endpoints,
+	})
+
+	verifier, err := NewVerifier()
+	expectNoError(t, err)
+	p.verifier, p.nonce = verifier, "nonce-1"
+
+	u, err := url.Parse(client.AuthCodeURL("state-1", "nonce-1", verifier))
+	expectNoError(t, err)
+	q := u.Query()
+	expectTrue(t, strings.HasPrefix(u.String(), p.URL+"/authorize?"))
+	expectTrue(t, q.Get("state") == "state-1" && q.Get("nonce") == "nonce-1" && q.Get("scope") == "openid email")
+	expectTrue(t, q.Get("code_challenge") == Challenge(verifier) && q.Get("code_challenge_method") == "S256")
+
+	_, err = client.Exchange(ctx, "good-code", "another verifier")
+	expectTrue(t, err != nil)
+
+	token, err := client.Exchange(ctx, "good-code", verifier)
+	expectNoError(t, err)
+
+	claims, err := client.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+	expectNoError(t, err)
+	expectTrue(t, claims.Subject == "1234" && claims.Email == "alice@example.com" && claims.EmailVerified)
+
+	_, err = client.VerifyIDToken(ctx, token.IDToken, "another nonce")
+	expectTrue(t, err != nil)
+
+	// an ID token of another audience.
+	other := NewClient(Config{ClientID: "another client", Endpoints: endpoints})
+	_, err = other.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+	expectTrue(t, err != nil)
+}
+
+func TestDiscover_IssuerMismatch(t *testing.T) {
+	p := newTestProvider(t)
+	_, err := Discover(context.Background(), nil, p.URL+"/another")
+	expectTrue(t, err != nil)
+}
+
+func TestChallenge(t *testing.T) {
+	// the example of RFC 7636, appendix B.
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	expectTrue(t, Challenge(verifier) == "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM")
+}
+
+func expectNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("expect no error; got an error: %v", err)
+	}
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expect true; got false")
+	}
+}
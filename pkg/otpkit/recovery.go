@@ -0,0 +1,58 @@
+package otpkit
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// recoveryCodeSize is the size of the recovery codes in bytes, 80 bits, so a fast hash is enough for storing them.
+const recoveryCodeSize = 10
+
+// NewRecoveryCodes generates n random recovery codes of 16 base32 characters in groups of 4, e.g.
+// ABCD-EFGH-IJKL-MNOP.
+func NewRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	b := make([]byte, recoveryCodeSize)
+	for i := range codes {
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("otpkit: generate recovery code: %w", err)
+		}
+		s := _b32.EncodeToString(b)
+		codes[i] = s[0:4] + "-" + s[4:8] + "-" + s[8:12] + "-" + s[12:16]
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode uppercases the recovery code and removes its separators and spaces, so the codes are
+// accepted however the users type them.
+func NormalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '-' || r == ' ':
+			return -1
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		default:
+			return r
+		}
+	}, code)
+}
+
+// IsRecoveryCode reports whether the code looks like a recovery code, rather than a TOTP code.
+func IsRecoveryCode(code string) bool {
+	code = NormalizeRecoveryCode(code)
+	if len(code) != 16 {
+		return false
+	}
+	_, err := _b32.DecodeString(code)
+	return err == nil
+}
+
+// HashRecoveryCode returns the hex SHA-256 of the normalized recovery code for storing.
+func HashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(NormalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,129 @@
+// Package otpkit implements the time-based one-time passwords (TOTP) of RFC 6238, compatible with the authenticator
+// apps such as Google Authenticator, and the single-use recovery codes for when the authenticator is lost.
+package otpkit
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SecretSize is the size of the generated secrets in bytes, 160 bits as recommended by RFC 4226.
+const SecretSize = 20
+
+// _b32 is the encoding of the secrets in the URIs, the authenticator apps expect base32 without padding.
+var _b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Config is the configuration of TOTP. The defaults are the only parameters supported by most authenticator apps.
+type Config struct {
+	Digits int           // The length of the codes, 6 to 8. Default 6.
+	Period time.Duration // The lifetime of a code, the time step. Default 30 seconds.
+	Skew   int           // The number of the steps accepted before and after the current one. Default 1.
+}
+
+// withDefaults returns a copy of the config with default values for unset fields.
+func (c Config) withDefaults() Config {
+	if c.Digits < 6 || c.Digits > 8 {
+		c.Digits = 6
+	}
+	if c.Period < time.Second {
+		c.Period = 30 * time.Second
+	}
+	if c.Skew <= 0 {
+		c.Skew = 1
+	}
+	return c
+}
+
+// TOTP generates and validates the codes by HMAC-SHA1.
+type TOTP struct {
+	cfg Config
+}
+
+// New creates a new TOTP.
+func New(cfg Config) *TOTP {
+	return &TOTP{cfg: cfg.withDefaults()}
+}
+
+// GenerateSecret generates a random secret of SecretSize bytes.
+func GenerateSecret() ([]byte, error) {
+	secret := make([]byte, SecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("otpkit: generate secret: %w", err)
+	}
+	return secret, nil
+}
+
+// EncodeSecret encodes the secret in base32 without padding, for entering it into the authenticator apps manually.
+func EncodeSecret(secret []byte) string { return _b32.EncodeToString(secret) }
+
+// URI returns the otpauth:// URI of the secret, which is the payload of the QR code scanned by the authenticator
+// apps, see https://github.com/google/google-authenticator/wiki/Key-Uri-Format. The account is usually the email.
+func (t *TOTP) URI(secret []byte, issuer, account string) string {
+	q := url.Values{}
+	q.Set("secret", EncodeSecret(secret))
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", strconv.Itoa(t.cfg.Digits))
+	q.Set("period", strconv.Itoa(int(t.cfg.Period/time.Second)))
+
+	label := account
+	if issuer != "" {
+		q.Set("issuer", issuer)
+		label = issuer + ":" + account
+	}
+	u := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + label, RawQuery: q.Encode()}
+	return u.String()
+}
+
+// Step returns the time step at the time.
+func (t *TOTP) Step(at time.Time) int64 { return at.Unix() / int64(t.cfg.Period/time.Second) }
+
+// Code returns the code of the secret at the time.
+func (t *TOTP) Code(secret []byte, at time.Time) string { return t.code(secret, t.Step(at)) }
+
+// Validate reports whether the code is the one of the secret at the time, or at the steps within the skew. It
+// returns the matched step, so the callers can reject the replays by accepting only the steps after the last used
+// one.
+func (t *TOTP) Validate(secret []byte, code string, at time.Time) (step int64, ok bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != t.cfg.Digits {
+		return 0, false
+	}
+
+	current := t.Step(at)
+	for i := -t.cfg.Skew; i <= t.cfg.Skew; i++ {
+		step := current + int64(i)
+		if subtle.ConstantTimeCompare([]byte(t.code(secret, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// code returns the HOTP code of RFC 4226 of the secret at the counter.
+func (t *TOTP) code(secret []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation.
+	offset := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < t.cfg.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", t.cfg.Digits, bin%mod)
+}
@@ -0,0 +1,108 @@
+package otpkit
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// _rfcSecret is the SHA1 secret of the test vectors of RFC 6238, appendix B.
+var _rfcSecret = []byte("12345678901234567890")
+
+func TestTOTP_Code(t *testing.T) {
+	totp := New(Config{Digits: 8})
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		if got := totp.Code(_rfcSecret, time.Unix(tt.unix, 0)); got != tt.code {
+			t.Errorf("at %d: expect %s; got %s", tt.unix, tt.code, got)
+		}
+	}
+}
+
+func TestTOTP_Validate(t *testing.T) {
+	totp := New(Config{})
+	secret, err := GenerateSecret()
+	expectNoError(t, err)
+
+	now := time.Unix(1_700_000_000, 0)
+	code := totp.Code(secret, now)
+	if len(code) != 6 {
+		t.Fatalf("expect 6 digits; got %q", code)
+	}
+
+	step, ok := totp.Validate(secret, code, now)
+	expectTrue(t, ok && step == totp.Step(now))
+
+	// the previous and the next steps are accepted within the skew.
+	step, ok = totp.Validate(secret, code, now.Add(30*time.Second))
+	expectTrue(t, ok && step == totp.Step(now))
+	_, ok = totp.Validate(secret, code, now.Add(-30*time.Second))
+	expectTrue(t, ok)
+
+	_, ok = totp.Validate(secret, code, now.Add(90*time.Second))
+	expectTrue(t, !ok)
+	_, ok = totp.Validate(secret, "12345", now)
+	expectTrue(t, !ok)
+	_, ok = totp.Validate([]byte("another secret"), code, now)
+	expectTrue(t, !ok)
+}
+
+func TestTOTP_URI(t *testing.T) {
+	totp := New(Config{})
+	uri := totp.URI(_rfcSecret, "Acme Inc", "alice@example.com")
+
+	u, err := url.Parse(uri)
+	expectNoError(t, err)
+	expectTrue(t, u.Scheme == "otpauth" && u.Host == "totp")
+	expectTrue(t, u.Path == "/Acme Inc:alice@example.com")
+
+	q := u.Query()
+	expectTrue(t, q.Get("secret") == "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	expectTrue(t, q.Get("issuer") == "Acme Inc")
+	expectTrue(t, q.Get("digits") == "6" && q.Get("period") == "30")
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := NewRecoveryCodes(10)
+	expectNoError(t, err)
+	expectTrue(t, len(codes) == 10)
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		expectTrue(t, len(code) == 19 && IsRecoveryCode(code))
+		expectTrue(t, !seen[code])
+		seen[code] = true
+	}
+
+	code := codes[0]
+	lower := NormalizeRecoveryCode(code)
+	expectTrue(t, HashRecoveryCode(code) == HashRecoveryCode(" "+lower[:8]+" "+lower[8:]))
+	expectTrue(t, HashRecoveryCode(code) != HashRecoveryCode(codes[1]))
+
+	expectTrue(t, !IsRecoveryCode("123456"))
+	expectTrue(t, !IsRecoveryCode("1111-1111-1111-1111")) // 1 isn't in the base32 alphabet.
+}
+
+func expectNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("expect no error; got an error: %v", err)
+	}
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expect true; got false")
+	}
+}
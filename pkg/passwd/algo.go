@@ -22,3 +22,9 @@ func (b bcryptImpl) Compare(hash string, plain string) error {
 
 // BcryptDefaultCost is a bcrypt algorithm with default cost.
 const BcryptDefaultCost = bcryptImpl(bcrypt.DefaultCost)
+
+// NeedsRehash reports whether the hash is not a bcrypt hash of the configured cost.
+func (b bcryptImpl) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != int(b)
+}
@@ -1,7 +1,11 @@
 package passwd
 
 import (
+	"errors"
+	"strings"
 	"testing"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestBcryptImpl(t *testing.T) {
@@ -22,3 +26,123 @@ func TestBcryptImpl(t *testing.T) {
 		}
 	}
 }
+
+func TestArgon2id(t *testing.T) {
+	impl := Argon2id{Memory: 1024, Time: 1, Parallelism: 1}
+
+	const plain = "abc123"
+
+	hash, err := impl.Hash(plain)
+	if err != nil {
+		t.Fatalf("expect no error; got an error: %v", err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("expect PHC-format hash; got %q", hash)
+	}
+
+	// the parameters are read from the hash, not from the comparer.
+	if err := Argon2idDefault.Compare(hash, plain); err != nil {
+		t.Errorf("expect password is match; got an error: %v", err)
+	}
+
+	if err := impl.Compare(hash, "abc124"); !errors.Is(err, ErrMismatch) {
+		t.Errorf("expect error %v; got %v", ErrMismatch, err)
+	}
+
+	invalids := []string{
+		"",
+		"$2a$10$abcdefghijklmnopqrstuv",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=1024,t=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$!!",
+	}
+	for _, invalid := range invalids {
+		if err := impl.Compare(invalid, plain); !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("expect error %v for %q; got %v", ErrInvalidHash, invalid, err)
+		}
+	}
+}
+
+func TestScrypt(t *testing.T) {
+	impl := Scrypt{LogN: 10, BlockSize: 8, Parallelism: 1}
+
+	const plain = "abc123"
+
+	hash, err := impl.Hash(plain)
+	if err != nil {
+		t.Fatalf("expect no error; got an error: %v", err)
+	}
+
+	if !strings.HasPrefix(hash, "$scrypt$ln=10,r=8,p=1$") {
+		t.Errorf("expect PHC-format hash; got %q", hash)
+	}
+
+	// the parameters are read from the hash, not from the comparer.
+	if err := ScryptDefault.Compare(hash, plain); err != nil {
+		t.Errorf("expect password is match; got an error: %v", err)
+	}
+
+	if err := impl.Compare(hash, "abc124"); !errors.Is(err, ErrMismatch) {
+		t.Errorf("expect error %v; got %v", ErrMismatch, err)
+	}
+
+	invalids := []string{
+		"",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$aGFzaA",
+		"$scrypt$ln=0,r=8,p=1$c2FsdA$aGFzaA",
+		"$scrypt$ln=10,r=8$c2FsdA$aGFzaA",
+		"$scrypt$ln=10,r=8,p=1$c2FsdA$!!",
+	}
+	for _, invalid := range invalids {
+		if err := impl.Compare(invalid, plain); !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("expect error %v for %q; got %v", ErrInvalidHash, invalid, err)
+		}
+	}
+}
+
+func TestMulti(t *testing.T) {
+	argon := Argon2id{Memory: 1024, Time: 1, Parallelism: 1}
+	scrypt := Scrypt{LogN: 10, BlockSize: 8, Parallelism: 1}
+	multi := NewMulti(argon)
+
+	const plain = "abc123"
+
+	hashes := make(map[HashComparer]string)
+	for _, impl := range []HashComparer{bcryptImpl(bcrypt.MinCost), argon, scrypt} {
+		hash, err := impl.Hash(plain)
+		if err != nil {
+			t.Fatalf("expect no error; got an error: %v", err)
+		}
+		hashes[impl] = hash
+
+		if err := multi.Compare(hash, plain); err != nil {
+			t.Errorf("expect password is match for %q; got an error: %v", hash, err)
+		}
+		if err := multi.Compare(hash, "abc124"); err == nil {
+			t.Errorf("expect password is not match for %q", hash)
+		}
+	}
+
+	if multi.NeedsRehash(hashes[argon]) {
+		t.Errorf("expect the hash of the preferred algorithm doesn't need rehash")
+	}
+	if !multi.NeedsRehash(hashes[scrypt]) || !multi.NeedsRehash(hashes[bcryptImpl(bcrypt.MinCost)]) {
+		t.Errorf("expect the hash of another algorithm needs rehash")
+	}
+	if !NewMulti(Argon2id{Memory: 2048, Time: 1, Parallelism: 1}).NeedsRehash(hashes[argon]) {
+		t.Errorf("expect the hash of other parameters needs rehash")
+	}
+
+	hash, err := multi.Hash(plain)
+	if err != nil {
+		t.Fatalf("expect no error; got an error: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		t.Errorf("expect hash by the preferred algorithm; got %q", hash)
+	}
+
+	if err := multi.Compare("$md5$abc", plain); !errors.Is(err, ErrInvalidHash) {
+		t.Errorf("expect error %v; got %v", ErrInvalidHash, err)
+	}
+}
@@ -0,0 +1,129 @@
+package passwd
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id is a HashComparer using the Argon2id algorithm, the hashes are in the PHC string format, e.g.
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>. The parameters of the hash are used by Compare, so they can be tuned
+// without invalidating the stored hashes.
+type Argon2id struct {
+	Memory      uint32 // The memory in KiB.
+	Time        uint32 // The number of passes over the memory.
+	Parallelism uint8  // The number of threads.
+	SaltLength  uint32 // The length of the random salt in bytes, default is 16.
+	KeyLength   uint32 // The length of the hash in bytes, default is 32.
+}
+
+// Argon2idDefault is the Argon2id with the second recommended option of RFC 9106, 64 MiB memory, 3 passes and 4
+// threads.
+var Argon2idDefault = Argon2id{Memory: 64 << 10, Time: 3, Parallelism: 4}
+
+func (a Argon2id) withDefaults() Argon2id {
+	if a.Memory == 0 {
+		a.Memory = Argon2idDefault.Memory
+	}
+	if a.Time == 0 {
+		a.Time = Argon2idDefault.Time
+	}
+	if a.Parallelism == 0 {
+		a.Parallelism = Argon2idDefault.Parallelism
+	}
+	if a.SaltLength == 0 {
+		a.SaltLength = 16
+	}
+	if a.KeyLength == 0 {
+		a.KeyLength = 32
+	}
+	return a
+}
+
+// Hash generates an Argon2id hash from the specified plaintext password with a random salt.
+func (a Argon2id) Hash(plain string) (string, error) {
+	a = a.withDefaults()
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("passwd: generate salt: %w", err)
+	}
+
+	hash := argon2.IDKey([]byte(plain), salt, a.Time, a.Memory, a.Parallelism, a.KeyLength)
+	return a.phc(salt, hash).format("m", "t", "p"), nil
+}
+
+// Compare compares the specified plaintext password with the specified Argon2id hash by the parameters of the hash.
+// It returns ErrMismatch if the password doesn't match, or ErrInvalidHash if the hash is malformed.
+func (a Argon2id) Compare(hash string, plain string) error {
+	p, err := a.parse(hash)
+	if err != nil {
+		return err
+	}
+
+	key := argon2.IDKey([]byte(plain), p.salt, p.Time, p.Memory, p.Parallelism, uint32(len(p.hash)))
+	if subtle.ConstantTimeCompare(key, p.hash) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+func (a Argon2id) phc(salt, hash []byte) phc {
+	return phc{
+		id:      "argon2id",
+		version: strconv.Itoa(argon2.Version),
+		params: map[string]string{
+			"m": strconv.FormatUint(uint64(a.Memory), 10),
+			"t": strconv.FormatUint(uint64(a.Time), 10),
+			"p": strconv.FormatUint(uint64(a.Parallelism), 10),
+		},
+		salt: salt,
+		hash: hash,
+	}
+}
+
+// argon2idHash is a decoded Argon2id hash.
+type argon2idHash struct {
+	Argon2id
+	salt []byte
+	hash []byte
+}
+
+// parse decodes the Argon2id hash, only the version of the argon2 package is supported.
+func (Argon2id) parse(hash string) (argon2idHash, error) {
+	p, err := parsePHC("argon2id", hash)
+	if err != nil {
+		return argon2idHash{}, err
+	}
+	if p.version != strconv.Itoa(argon2.Version) {
+		return argon2idHash{}, fmt.Errorf("%w: unsupported argon2 version %q", ErrInvalidHash, p.version)
+	}
+
+	m, err := p.uintParam("m", 32)
+	if err != nil {
+		return argon2idHash{}, err
+	}
+	t, err := p.uintParam("t", 32)
+	if err != nil {
+		return argon2idHash{}, err
+	}
+	par, err := p.uintParam("p", 8)
+	if err != nil {
+		return argon2idHash{}, err
+	}
+	if m == 0 || t == 0 || par == 0 || len(p.hash) == 0 {
+		return argon2idHash{}, fmt.Errorf("%w: zero argon2 parameter", ErrInvalidHash)
+	}
+
+	params := Argon2id{Memory: uint32(m), Time: uint32(t), Parallelism: uint8(par), SaltLength: uint32(len(p.salt)),
+		KeyLength: uint32(len(p.hash))}
+	return argon2idHash{Argon2id: params, salt: p.salt, hash: p.hash}, nil
+}
+
+// NeedsRehash reports whether the hash is not an Argon2id hash of the configured parameters.
+func (a Argon2id) NeedsRehash(hash string) bool {
+	p, err := a.parse(hash)
+	return err != nil || p.Argon2id != a.withDefaults()
+}
@@ -0,0 +1,169 @@
+package passwd
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BreachChecker checks whether a password has appeared in a data breach, see Policy.Breach.
+type BreachChecker interface {
+	// Breached reports whether the plaintext password has appeared in a data breach.
+	Breached(ctx context.Context, plain string) (bool, error)
+}
+
+// HIBPConfig is the configuration of HIBPChecker.
+type HIBPConfig struct {
+	Endpoint  string        // The range API, default is https://api.pwnedpasswords.com/range.
+	Timeout   time.Duration // The timeout of a request, default is 3 seconds.
+	CacheTTL  time.Duration // How long the responses are cached by the hash prefix, default is 1 hour.
+	CacheSize int           // The maximum number of the cached prefixes, default is 1000.
+	Threshold int           // The minimum number of the breaches to be reported, default is 1.
+	Client    *http.Client  // The HTTP client, default is http.DefaultClient.
+}
+
+func (c HIBPConfig) withDefaults() HIBPConfig {
+	if c.Endpoint == "" {
+		c.Endpoint = "https://api.pwnedpasswords.com/range"
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 3 * time.Second
+	}
+	if c.CacheTTL == 0 {
+		c.CacheTTL = time.Hour
+	}
+	if c.CacheSize == 0 {
+		c.CacheSize = 1000
+	}
+	if c.Threshold == 0 {
+		c.Threshold = 1
+	}
+	if c.Client == nil {
+		c.Client = http.DefaultClient
+	}
+	c.Endpoint = strings.TrimSuffix(c.Endpoint, "/")
+	return c
+}
+
+// HIBPChecker is a BreachChecker using the Have I Been Pwned range API with the k-anonymity model: only the first 5
+// characters of the SHA-1 of the password are sent, and the suffixes of the response are matched locally.
+// see: https://haveibeenpwned.com/API/v3#PwnedPasswords.
+type HIBPChecker struct {
+	cfg HIBPConfig
+	now func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]hibpRange
+}
+
+// hibpRange is the cached response of a prefix, the breach counts by the hash suffixes.
+type hibpRange struct {
+	counts    map[string]int
+	expiresAt time.Time
+}
+
+// NewHIBPChecker creates a new HIBPChecker.
+func NewHIBPChecker(cfg HIBPConfig) *HIBPChecker {
+	return &HIBPChecker{cfg: cfg.withDefaults(), now: time.Now, cache: make(map[string]hibpRange)}
+}
+
+// Breached implements BreachChecker.
+func (c *HIBPChecker) Breached(ctx context.Context, plain string) (bool, error) {
+	sum := sha1.Sum([]byte(plain))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	counts, err := c.lookup(ctx, prefix)
+	if err != nil {
+		return false, err
+	}
+	return counts[suffix] >= c.cfg.Threshold, nil
+}
+
+// lookup returns the breach counts of the prefix from the cache, or fetches them.
+func (c *HIBPChecker) lookup(ctx context.Context, prefix string) (map[string]int, error) {
+	now := c.now()
+	c.mu.Lock()
+	r, ok := c.cache[prefix]
+	c.mu.Unlock()
+	if ok && now.Before(r.expiresAt) {
+		return r.counts, nil
+	}
+
+	counts, err := c.fetch(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.cache) >= c.cfg.CacheSize {
+		c.evict(now)
+	}
+	c.cache[prefix] = hibpRange{counts: counts, expiresAt: now.Add(c.cfg.CacheTTL)}
+	return counts, nil
+}
+
+// evict removes the expired prefixes, or an arbitrary one if none has expired. The caller must hold the lock.
+func (c *HIBPChecker) evict(now time.Time) {
+	for prefix, r := range c.cache {
+		if !now.Before(r.expiresAt) {
+			delete(c.cache, prefix)
+		}
+	}
+	for prefix := range c.cache {
+		if len(c.cache) < c.cfg.CacheSize {
+			return
+		}
+		delete(c.cache, prefix)
+	}
+}
+
+// fetch queries the range API of the prefix with the padding, so the response size doesn't reveal the prefix.
+func (c *HIBPChecker) fetch(ctx context.Context, prefix string) (map[string]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.Endpoint+"/"+prefix, nil)
+	if err != nil {
+		return nil, fmt.Errorf("passwd: hibp: create request: %w", err)
+	}
+	req.Header.Set("Add-Padding", "true")
+
+	res, err := c.cfg.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("passwd: hibp: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("passwd: hibp: unexpected status %s", res.Status)
+	}
+
+	counts := make(map[string]int)
+	sc := bufio.NewScanner(res.Body)
+	for sc.Scan() {
+		suffix, count, ok := strings.Cut(strings.TrimSpace(sc.Text()), ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return nil, fmt.Errorf("passwd: hibp: invalid count of %s: %w", suffix, err)
+		}
+		if n > 0 { // the padding entries have zero count.
+			counts[strings.ToUpper(suffix)] = n
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("passwd: hibp: read response: %w", err)
+	}
+	return counts, nil
+}
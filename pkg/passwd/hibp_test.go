@@ -0,0 +1,82 @@
+package passwd
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHIBPChecker(t *testing.T) {
+	sum := sha1.Sum([]byte("password"))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/range/"+hash[:5] {
+			t.Errorf("expect only the prefix is sent; got path %q", r.URL.Path)
+		}
+		if r.Header.Get("Add-Padding") != "true" {
+			t.Errorf("expect padding is requested")
+		}
+		_, _ = fmt.Fprintf(w, "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n%s:3861493\r\n00D4F6E8FA6EECAD2A3AA415EEC418D38EC:0\r\n",
+			hash[5:])
+	}))
+	defer srv.Close()
+
+	checker := NewHIBPChecker(HIBPConfig{Endpoint: srv.URL + "/range/", Client: srv.Client()})
+	now := time.Now()
+	checker.now = func() time.Time { return now }
+
+	breached, err := checker.Breached(context.Background(), "password")
+	if err != nil {
+		t.Fatalf("expect no error; got an error: %v", err)
+	}
+	if !breached {
+		t.Errorf("expect password is breached")
+	}
+
+	// a password of the same prefix is served from the cache.
+	if _, err := checker.Breached(context.Background(), "password"); err != nil {
+		t.Fatalf("expect no error; got an error: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expect the range is cached; got %d requests", requests)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := checker.Breached(context.Background(), "password"); err != nil {
+		t.Fatalf("expect no error; got an error: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("expect the expired range is fetched again; got %d requests", requests)
+	}
+
+	policy := Policy{MinLength: 8, Breach: checker}
+	var violations Violations
+	if err := policy.Validate("password"); !errors.As(err, &violations) || violations[0].Code != CodeBreached {
+		t.Errorf("expect violation %s; got %v", CodeBreached, err)
+	}
+	if err := policy.Validate("short"); !errors.As(err, &violations) || violations[0].Code != CodeMinLength {
+		t.Errorf("expect violation %s without the breach check; got %v", CodeMinLength, err)
+	}
+}
+
+func TestHIBPChecker_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	policy := Policy{Breach: NewHIBPChecker(HIBPConfig{Endpoint: srv.URL, Client: srv.Client()})}
+	if err := policy.Validate("password"); !errors.Is(err, ErrBreachCheck) {
+		t.Errorf("expect error %v; got %v", ErrBreachCheck, err)
+	}
+}
@@ -0,0 +1,67 @@
+package passwd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rehasher is implemented by the HashComparer that can tell whether a hash is produced by other parameters than its
+// own, e.g. a lower bcrypt cost, so the hash can be upgraded when the password is known at login.
+type Rehasher interface {
+	// NeedsRehash reports whether the hash should be regenerated by Hash.
+	NeedsRehash(hash string) bool
+}
+
+// _algorithms maps the hash prefixes to the implementation comparing them, the parameters are read from the hash.
+var _algorithms = []struct {
+	prefix string
+	hc     HashComparer
+}{
+	{prefix: "$2a$", hc: BcryptDefaultCost},
+	{prefix: "$2b$", hc: BcryptDefaultCost},
+	{prefix: "$2y$", hc: BcryptDefaultCost},
+	{prefix: "$argon2id$", hc: Argon2idDefault},
+	{prefix: "$scrypt$", hc: ScryptDefault},
+}
+
+// Detect returns the HashComparer of the algorithm of the hash by its prefix, e.g. $2a$ for bcrypt or $argon2id$
+// for Argon2id. It returns ErrInvalidHash if the algorithm is unknown.
+func Detect(hash string) (HashComparer, error) {
+	for _, alg := range _algorithms {
+		if strings.HasPrefix(hash, alg.prefix) {
+			return alg.hc, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown algorithm", ErrInvalidHash)
+}
+
+// Multi is a HashComparer that generates the hashes by the preferred algorithm and compares them by the algorithm
+// detected from the hash, so the algorithm can be changed without invalidating the stored hashes.
+type Multi struct {
+	preferred HashComparer
+}
+
+// NewMulti creates a new Multi generating the hashes by the preferred algorithm.
+func NewMulti(preferred HashComparer) *Multi {
+	return &Multi{preferred: preferred}
+}
+
+// Hash generates a hash from the specified plaintext password by the preferred algorithm.
+func (m *Multi) Hash(plain string) (string, error) { return m.preferred.Hash(plain) }
+
+// Compare compares the specified plaintext password with the specified hash by the algorithm of the hash.
+// It returns ErrInvalidHash if the algorithm is unknown.
+func (m *Multi) Compare(hash string, plain string) error {
+	hc, err := Detect(hash)
+	if err != nil {
+		return err
+	}
+	return hc.Compare(hash, plain)
+}
+
+// NeedsRehash reports whether the hash is produced by another algorithm than the preferred one or by other
+// parameters. It is always false if the preferred algorithm doesn't implement Rehasher.
+func (m *Multi) NeedsRehash(hash string) bool {
+	r, ok := m.preferred.(Rehasher)
+	return ok && r.NeedsRehash(hash)
+}
@@ -16,7 +16,7 @@ import (
 var ErrPasswordNotSet = fmt.Errorf("password is not set")
 
 // HashComparer is a contract for the hashing algorithm that can generate and compare hashes.
-// To provide a custom implementation use the SetHashComparer function.
+// To provide a custom implementation use the SetHashComparer or NewHasher function.
 type HashComparer interface {
 	// Hash generates a hash from the specified plaintext password using the configured algorithm.
 	// It returns the resulting hash as a string and any errors that occur during the hash generation.
@@ -27,19 +27,88 @@ type HashComparer interface {
 	Compare(hash string, plain string) error
 }
 
-// hashComparer is a global variable that represents the current hash algorithm used for hashing and comparing passwords.
-// By default, hashComparer is set to the bcrypt algorithm with the default cost.
-var hashComparer HashComparer = BcryptDefaultCost
+// Hasher hashes and compares the passwords by its own HashComparer, so different apps or tenants can use different
+// algorithms or costs concurrently. The Password methods use the default Hasher, see SetHashComparer.
+type Hasher struct {
+	hc    HashComparer
+	dummy string // the hash compared by CompareDummy.
+}
+
+// NewHasher creates a new Hasher using the specified hash comparer. The hash of CompareDummy is generated upfront, so
+// it panics if the hash comparer can't hash, i.e. it is misconfigured.
+func NewHasher(hc HashComparer) *Hasher {
+	dummy, err := hc.Hash("passwd: dummy password")
+	if err != nil {
+		panic(fmt.Errorf("passwd: hash the dummy password: %w", err))
+	}
+	return &Hasher{hc: hc, dummy: dummy}
+}
+
+// Hash generates a hash from the password. It returns the resulting hash as a string and any errors that occur
+// during the hash generation.
+func (h *Hasher) Hash(p Password) (string, error) { return h.hc.Hash(string(p)) }
+
+// Compare compares the password hash with plain text. It returns an error if the comparison fails.
+// When the password is not set, it returns ErrPasswordNotSet.
+func (h *Hasher) Compare(p Password, plain string) error {
+	// to differentiate between an empty password and
+	// password that actually not match with the plain text.
+	if !p.IsSet() {
+		return ErrPasswordNotSet
+	}
+	return h.hc.Compare(string(p), plain)
+}
+
+// NeedsRehash reports whether the password hash should be regenerated by the hash comparer, e.g. after switching to
+// NewMulti(Argon2idDefault) from bcrypt. It is false if the password is not set or the hash comparer doesn't
+// implement Rehasher. It is meant to be checked after a successful Compare, when the plain text is known to hash it
+// again.
+func (h *Hasher) NeedsRehash(p Password) bool {
+	r, ok := h.hc.(Rehasher)
+	return p.IsSet() && ok && r.NeedsRehash(string(p))
+}
+
+// CompareDummy compares a fixed password hash generated by the hash comparer, so it takes as long as Compare. It is
+// meant for the login of the unknown users, so the response time doesn't reveal whether the account exists.
+func (h *Hasher) CompareDummy() {
+	_ = h.hc.Compare(h.dummy, "passwd: compared password")
+}
+
+// defaultHasher is the Hasher used by the Password methods.
+// By default, it uses the bcrypt algorithm with the default cost, it is created on the first use since creating a
+// Hasher hashes the dummy password.
+var defaultHasher *Hasher
 var lock sync.RWMutex
 
-// SetHashComparer sets the global hash comparer to the specified value.
+// Default returns the default Hasher used by the Password methods.
+// This function is concurrent-safe.
+func Default() *Hasher {
+	lock.RLock()
+	h := defaultHasher
+	lock.RUnlock()
+	if h != nil {
+		return h
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if defaultHasher == nil {
+		defaultHasher = NewHasher(BcryptDefaultCost)
+	}
+	return defaultHasher
+}
+
+// SetHashComparer sets the hash comparer of the default Hasher to the specified value.
 // This function is concurrent-safe.
 func SetHashComparer(hc HashComparer) {
 	lock.Lock()
 	defer lock.Unlock()
-	hashComparer = hc
+	defaultHasher = NewHasher(hc)
 }
 
+// CompareDummy compares a fixed password hash by the default Hasher, see Hasher.CompareDummy.
+func CompareDummy() { Default().CompareDummy() }
+
 // Password is a type that represents a password.
 // It provides additional functionality for securely hashing and comparing passwords.
 type Password string
@@ -54,7 +123,8 @@ func (p Password) Value() (driver.Value, error) {
 	return driver.Value(hash), err
 }
 
-func (p Password) Hash() (string, error) { return hashComparer.Hash(string(p)) }
+// Hash generates a hash from the password by the default Hasher.
+func (p Password) Hash() (string, error) { return Default().Hash(p) }
 
 // Scan implements the sql.Scanner interface. It sets the password value to an empty string if the source value is nil.
 // Otherwise, it sets the password value to the source value.
@@ -76,16 +146,12 @@ func (p *Password) Scan(src any) error {
 	return nil
 }
 
-// Compare compares the password with plain text. It returns an error if the comparison fails.
+// Compare compares the password with plain text by the default Hasher. It returns an error if the comparison fails.
 // When the password is not set, it returns ErrPasswordNotSet.
-func (p Password) Compare(plain string) error {
-	// to differentiate between an empty password and
-	// password that actually not match with the plain text.
-	if !p.IsSet() {
-		return ErrPasswordNotSet
-	}
-	return hashComparer.Compare(string(p), plain)
-}
+func (p Password) Compare(plain string) error { return Default().Compare(p, plain) }
+
+// NeedsRehash reports whether the password hash should be regenerated by the default Hasher, see Hasher.NeedsRehash.
+func (p Password) NeedsRehash() bool { return Default().NeedsRehash(p) }
 
 // String returns a string representation of the password. It hides the actual password value by returning "FILTERED".
 func (p Password) String() string { return "FILTERED" }
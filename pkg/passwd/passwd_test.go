@@ -90,3 +90,84 @@ func TestPassword_MarshalJSON(t *testing.T) {
 		t.Errorf("expect FILTERED, but got %s!", raw)
 	}
 }
+
+func TestPassword_NeedsRehash(t *testing.T) {
+	SetHashComparer(BcryptDefaultCost)
+	defer SetHashComparer(BcryptDefaultCost)
+
+	hash, err := Password("pass1234").Hash()
+	if err != nil {
+		t.Fatalf("hash: expected no error, but got an error: %v", err)
+	}
+
+	if Password(hash).NeedsRehash() {
+		t.Errorf("needs rehash: expected false for the hash of the current comparer")
+	}
+
+	SetHashComparer(NewMulti(Argon2id{Memory: 1024, Time: 1, Parallelism: 1}))
+	if err := Password(hash).Compare("pass1234"); err != nil {
+		t.Errorf("compare: expected no error, but got an error: %v", err)
+	}
+	if !Password(hash).NeedsRehash() {
+		t.Errorf("needs rehash: expected true for the hash of the previous comparer")
+	}
+
+	if Password("").NeedsRehash() {
+		t.Errorf("needs rehash: expected false when the password is not set")
+	}
+}
+
+func TestHasher(t *testing.T) {
+	SetHashComparer(BcryptDefaultCost)
+	argon := NewHasher(Argon2id{Memory: 1024, Time: 1, Parallelism: 1})
+	bcrypter := NewHasher(bcryptImpl(bcrypt.MinCost))
+
+	hash, err := argon.Hash("pass1234")
+	if err != nil {
+		t.Fatalf("hash: expected no error, but got an error: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		t.Errorf("hash: expected hash by the comparer of the hasher, but got %q", hash)
+	}
+
+	if err := argon.Compare(Password(hash), "pass1234"); err != nil {
+		t.Errorf("compare: expected no error, but got an error: %v", err)
+	}
+	if err := bcrypter.Compare(Password(hash), "pass1234"); err == nil {
+		t.Errorf("compare: expected an error by the other hasher")
+	}
+	if err := argon.Compare("", "pass1234"); err != ErrPasswordNotSet {
+		t.Errorf("compare: expected error %v, but got %v", ErrPasswordNotSet, err)
+	}
+
+	// the default hasher is not affected.
+	hash, err = Password("pass1234").Hash()
+	if err != nil {
+		t.Fatalf("hash: expected no error, but got an error: %v", err)
+	}
+	if cost, err := bcrypt.Cost([]byte(hash)); err != nil || cost != bcrypt.DefaultCost {
+		t.Errorf("hash: expected hash by the default hasher, but got %q", hash)
+	}
+}
+
+func TestHasher_CompareDummy(t *testing.T) {
+	h := NewHasher(bcryptImpl(bcrypt.MinCost))
+	if cost, err := bcrypt.Cost([]byte(h.dummy)); err != nil || cost != bcrypt.MinCost {
+		t.Errorf("compare dummy: expected hash by the comparer of the hasher, but got %q", h.dummy)
+	}
+
+	dummy := h.dummy
+	h.CompareDummy()
+	if h.dummy != dummy {
+		t.Errorf("compare dummy: expected the hash is generated once")
+	}
+}
+
+func TestNewHasher_Misconfigured(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("new hasher: expected panic for the hash comparer failing to hash")
+		}
+	}()
+	NewHasher(bcryptImpl(bcrypt.MaxCost + 1))
+}
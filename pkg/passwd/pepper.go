@@ -0,0 +1,139 @@
+package passwd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// _pepperPrefix is the prefix of the peppered hashes, followed by the pepper version and the hash of the inner
+// HashComparer, e.g. $pepper$v=2$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>.
+const _pepperPrefix = "$pepper$v="
+
+// PepperConfig is the configuration of Peppered. The peppers are server-side secrets, so they should be given by the
+// secret manager references, e.g. PASSWORD_PEPPERS=vault://secret/data/passwd#peppers.
+type PepperConfig struct {
+	// Peppers are the secrets by their version, e.g. 1=<secret>,2=<secret>. The retired versions are kept until all
+	// their hashes are rehashed, the secrets should be at least 32 random bytes, e.g. in base64.
+	Peppers map[string]string `env:"PEPPERS,secret"`
+
+	// Version is the version of the pepper of the new hashes, default is the only version if there is one.
+	Version string `env:"PEPPER_VERSION"`
+}
+
+func (c PepperConfig) withDefaults() PepperConfig {
+	if c.Version == "" && len(c.Peppers) == 1 {
+		for version := range c.Peppers {
+			c.Version = version
+		}
+	}
+	return c
+}
+
+// Enabled reports whether the peppers are configured.
+func (c PepperConfig) Enabled() bool { return len(c.Peppers) > 0 }
+
+// Validate checks the versions and the secrets of the peppers, and the current version has a pepper.
+func (c PepperConfig) Validate() error {
+	c = c.withDefaults()
+	var errs []error
+	for version, secret := range c.Peppers {
+		if version == "" || strings.Contains(version, "$") {
+			errs = append(errs, fmt.Errorf("invalid pepper version %q", version))
+		}
+		if secret == "" {
+			errs = append(errs, fmt.Errorf("pepper %q is empty", version))
+		}
+	}
+	if _, ok := c.Peppers[c.Version]; !ok {
+		errs = append(errs, fmt.Errorf("pepper version %q is not configured", c.Version))
+	}
+	return errors.Join(errs...)
+}
+
+// Peppered is a HashComparer that pre-hashes the passwords by the HMAC-SHA256 of a pepper before the inner
+// HashComparer, so the stored hashes can't be cracked without the pepper. The version of the pepper is recorded in
+// the hash, so the pepper can be rotated: the hashes are compared by the pepper of their version, and NeedsRehash
+// reports the ones of the other versions. The hashes without pepper are compared by the inner HashComparer as they
+// are, so the pepper can be introduced to the existing hashes.
+//
+// The inner hash must begin with '$', like the bcrypt, Argon2id and scrypt hashes.
+type Peppered struct {
+	inner   HashComparer
+	peppers map[string][]byte
+	version string
+}
+
+// NewPeppered creates a new Peppered wrapping the inner HashComparer, it returns an error if the config is invalid.
+func NewPeppered(inner HashComparer, cfg PepperConfig) (*Peppered, error) {
+	cfg = cfg.withDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("passwd: %w", err)
+	}
+
+	peppers := make(map[string][]byte, len(cfg.Peppers))
+	for version, secret := range cfg.Peppers {
+		peppers[version] = []byte(secret)
+	}
+	return &Peppered{inner: inner, peppers: peppers, version: cfg.Version}, nil
+}
+
+// Hash generates a hash from the specified plaintext password peppered by the current version.
+func (p *Peppered) Hash(plain string) (string, error) {
+	hash, err := p.inner.Hash(p.pepper(p.peppers[p.version], plain))
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(hash, "$") {
+		return "", errors.New("passwd: peppered hash must begin with '$'")
+	}
+	return _pepperPrefix + p.version + hash, nil
+}
+
+// Compare compares the specified plaintext password with the specified hash by the pepper of its version.
+// It returns ErrInvalidHash if the version is unknown.
+func (p *Peppered) Compare(hash string, plain string) error {
+	version, inner, ok := splitPeppered(hash)
+	if !ok {
+		return p.inner.Compare(hash, plain)
+	}
+
+	pepper, ok := p.peppers[version]
+	if !ok {
+		return fmt.Errorf("%w: unknown pepper version %q", ErrInvalidHash, version)
+	}
+	return p.inner.Compare(inner, p.pepper(pepper, plain))
+}
+
+// NeedsRehash reports whether the hash is not peppered by the current version, or the inner HashComparer reports so.
+func (p *Peppered) NeedsRehash(hash string) bool {
+	version, inner, ok := splitPeppered(hash)
+	if !ok || version != p.version {
+		return true
+	}
+	r, ok := p.inner.(Rehasher)
+	return ok && r.NeedsRehash(inner)
+}
+
+// pepper returns the base64 of the HMAC-SHA256, which fits the 72 bytes limit of bcrypt and has no NUL bytes.
+func (p *Peppered) pepper(pepper []byte, plain string) string {
+	h := hmac.New(sha256.New, pepper)
+	h.Write([]byte(plain))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// splitPeppered splits the peppered hash into the version and the inner hash.
+func splitPeppered(hash string) (version, inner string, ok bool) {
+	rest, ok := strings.CutPrefix(hash, _pepperPrefix)
+	if !ok {
+		return "", "", false
+	}
+	i := strings.Index(rest, "$")
+	if i < 1 {
+		return "", "", false
+	}
+	return rest[:i], rest[i:], true
+}
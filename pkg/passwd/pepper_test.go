@@ -0,0 +1,85 @@
+package passwd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPeppered(t *testing.T) {
+	inner := NewMulti(bcryptImpl(bcrypt.MinCost))
+	v1, err := NewPeppered(inner, PepperConfig{Peppers: map[string]string{"1": "pepper-one"}})
+	if err != nil {
+		t.Fatalf("expect no error; got an error: %v", err)
+	}
+	v2, err := NewPeppered(inner, PepperConfig{Peppers: map[string]string{"1": "pepper-one", "2": "pepper-two"}, Version: "2"})
+	if err != nil {
+		t.Fatalf("expect no error; got an error: %v", err)
+	}
+
+	const plain = "abc123"
+
+	hash, err := v1.Hash(plain)
+	if err != nil {
+		t.Fatalf("expect no error; got an error: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$pepper$v=1$2a$") {
+		t.Errorf("expect the pepper version is recorded; got %q", hash)
+	}
+
+	// the pepper of the recorded version is used.
+	if err := v2.Compare(hash, plain); err != nil {
+		t.Errorf("expect password is match; got an error: %v", err)
+	}
+	if err := v2.Compare(hash, "abc124"); err == nil {
+		t.Errorf("expect password is not match")
+	}
+	if v1.NeedsRehash(hash) {
+		t.Errorf("expect the hash of the current version doesn't need rehash")
+	}
+	if !v2.NeedsRehash(hash) {
+		t.Errorf("expect the hash of the previous version needs rehash")
+	}
+
+	// the hash without the pepper can't be compared without it.
+	innerHash := strings.TrimPrefix(hash, "$pepper$v=1")
+	if err := inner.Compare(innerHash, plain); err == nil {
+		t.Errorf("expect password is not match without the pepper")
+	}
+
+	// the existing hash without pepper is compared as it is.
+	unpeppered, err := inner.Hash(plain)
+	if err != nil {
+		t.Fatalf("expect no error; got an error: %v", err)
+	}
+	if err := v1.Compare(unpeppered, plain); err != nil {
+		t.Errorf("expect password is match; got an error: %v", err)
+	}
+	if !v1.NeedsRehash(unpeppered) {
+		t.Errorf("expect the hash without pepper needs rehash")
+	}
+
+	if err := v1.Compare("$pepper$v=3"+innerHash, plain); !errors.Is(err, ErrInvalidHash) {
+		t.Errorf("expect error %v; got %v", ErrInvalidHash, err)
+	}
+}
+
+func TestPepperConfig_Validate(t *testing.T) {
+	invalids := []PepperConfig{
+		{Peppers: map[string]string{"1": "a", "2": "b"}},
+		{Peppers: map[string]string{"1": "a"}, Version: "2"},
+		{Peppers: map[string]string{"1": ""}},
+		{Peppers: map[string]string{"$1": "a"}, Version: "$1"},
+	}
+	for _, cfg := range invalids {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expect an error for %+v", cfg)
+		}
+	}
+
+	if err := (PepperConfig{Peppers: map[string]string{"1": "a"}}).Validate(); err != nil {
+		t.Errorf("expect no error; got an error: %v", err)
+	}
+}
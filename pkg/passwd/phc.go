@@ -0,0 +1,93 @@
+package passwd
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrMismatch is returned by the Compare of the PHC-format implementations when the password doesn't match the hash.
+var ErrMismatch = errors.New("passwd: hash and password mismatch")
+
+// ErrInvalidHash is returned when the hash is malformed or produced by another algorithm.
+var ErrInvalidHash = errors.New("passwd: invalid hash")
+
+// _b64 is the base64 encoding of the PHC string format, the standard alphabet without padding.
+var _b64 = base64.RawStdEncoding
+
+// phc is a hash in the PHC string format, e.g. $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>.
+// see: https://github.com/P-H-C/phc-string-format/blob/master/phc-sf-spec.md.
+type phc struct {
+	id      string
+	version string // the value of the v= field, empty if there is none.
+	params  map[string]string
+	salt    []byte
+	hash    []byte
+}
+
+// format encodes the hash, the params are written in the given order.
+func (p phc) format(params ...string) string {
+	var b strings.Builder
+	b.WriteString("$" + p.id)
+	if p.version != "" {
+		b.WriteString("$v=" + p.version)
+	}
+
+	b.WriteString("$")
+	for i, name := range params {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(name + "=" + p.params[name])
+	}
+
+	b.WriteString("$" + _b64.EncodeToString(p.salt))
+	b.WriteString("$" + _b64.EncodeToString(p.hash))
+	return b.String()
+}
+
+// parsePHC decodes the hash of the algorithm id, all fields are required except the version.
+func parsePHC(id, s string) (phc, error) {
+	parts := strings.Split(s, "$")
+	if len(parts) < 5 || parts[0] != "" || parts[1] != id {
+		return phc{}, fmt.Errorf("%w: not a %s hash", ErrInvalidHash, id)
+	}
+
+	p := phc{id: id, params: make(map[string]string)}
+	rest := parts[2:]
+	if v, ok := strings.CutPrefix(rest[0], "v="); ok {
+		p.version = v
+		rest = rest[1:]
+	}
+	if len(rest) != 3 {
+		return phc{}, fmt.Errorf("%w: malformed %s hash", ErrInvalidHash, id)
+	}
+
+	for _, kv := range strings.Split(rest[0], ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return phc{}, fmt.Errorf("%w: malformed parameter %q", ErrInvalidHash, kv)
+		}
+		p.params[k] = v
+	}
+
+	var err error
+	if p.salt, err = _b64.DecodeString(rest[1]); err != nil {
+		return phc{}, fmt.Errorf("%w: decode salt: %w", ErrInvalidHash, err)
+	}
+	if p.hash, err = _b64.DecodeString(rest[2]); err != nil {
+		return phc{}, fmt.Errorf("%w: decode hash: %w", ErrInvalidHash, err)
+	}
+	return p, nil
+}
+
+// uintParam returns the numeric parameter that fits in the bits.
+func (p phc) uintParam(name string, bits int) (uint64, error) {
+	n, err := strconv.ParseUint(p.params[name], 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("%w: parameter %s: %w", ErrInvalidHash, name, err)
+	}
+	return n, nil
+}
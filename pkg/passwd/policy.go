@@ -0,0 +1,152 @@
+package passwd
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Set of Violation codes.
+const (
+	CodeMinLength = "min_length" // shorter than Policy.MinLength.
+	CodeMaxLength = "max_length" // longer than Policy.MaxLength.
+	CodeUppercase = "uppercase"  // no uppercase letter.
+	CodeLowercase = "lowercase"  // no lowercase letter.
+	CodeDigit     = "digit"      // no digit.
+	CodeSymbol    = "symbol"     // no symbol, i.e. neither a letter nor a digit.
+	CodeCommon    = "common"     // a commonly used password.
+	CodeBreached  = "breached"   // appeared in a data breach, see Policy.Breach.
+)
+
+// ErrBreachCheck is returned by Policy.ValidateContext when the breach check fails, e.g. on a timeout.
+var ErrBreachCheck = errors.New("passwd: breach check failed")
+
+// BcryptMaxLength is the maximum length of the password in bytes hashed by bcrypt, the rest is ignored.
+const BcryptMaxLength = 72
+
+//go:embed common.txt
+var _commonPasswords string
+
+// _common is the set of the common passwords in lower case.
+var _common = func() map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, line := range strings.Split(_commonPasswords, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			set[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	return set
+}()
+
+// Policy is the rules of the plaintext passwords, the zero values disable the rules.
+type Policy struct {
+	MinLength int // The minimum number of characters.
+	MaxLength int // The maximum number of bytes, e.g. BcryptMaxLength.
+
+	RequireUppercase bool // Requires at least one uppercase letter.
+	RequireLowercase bool // Requires at least one lowercase letter.
+	RequireDigit     bool // Requires at least one digit.
+	RequireSymbol    bool // Requires at least one character that is neither a letter nor a digit.
+
+	DenyCommon bool     // Denies the most common passwords of the public breach corpora.
+	Denylist   []string // Additional denied passwords, e.g. the name of the application, compared case-insensitively.
+
+	// Breach checks whether the password has appeared in a data breach, e.g. NewHIBPChecker. It is optional, and only
+	// checked if the password satisfies the other rules.
+	Breach BreachChecker
+}
+
+// DefaultPolicy follows the NIST SP 800-63B guideline: at least 8 characters, no common passwords and no composition
+// rules. The maximum length is the bcrypt limit, so no part of the password is silently ignored.
+var DefaultPolicy = Policy{MinLength: 8, MaxLength: BcryptMaxLength, DenyCommon: true}
+
+// Violation describes why a password violates a Policy, the Code and the Message fit httpkit.FieldError.
+type Violation struct {
+	Code    string `json:"code"`    // the machine-readable code, one of the Code constants.
+	Message string `json:"message"` // the human-readable message.
+}
+
+// Violations is the error returned by Policy.Validate, it lists all violated rules.
+type Violations []Violation
+
+// Error implements the error interface.
+func (v Violations) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, violation := range v {
+		messages = append(messages, violation.Message)
+	}
+	return "passwd: password " + strings.Join(messages, ", ")
+}
+
+// Validate checks the plaintext password against the rules by ValidateContext with the background context.
+func (p Policy) Validate(plain string) error { return p.ValidateContext(context.Background(), plain) }
+
+// ValidateContext checks the plaintext password against the rules. It returns Violations listing all violated rules,
+// or nil if the password is valid. If the breach check fails, the returned error wraps ErrBreachCheck, so the caller
+// can decide whether to accept the password anyway.
+func (p Policy) ValidateContext(ctx context.Context, plain string) error {
+	var v Violations
+	add := func(ok bool, code, format string, args ...any) {
+		if !ok {
+			v = append(v, Violation{Code: code, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	add(p.MinLength <= 0 || utf8.RuneCountInString(plain) >= p.MinLength, CodeMinLength,
+		"must be at least %d characters", p.MinLength)
+	add(p.MaxLength <= 0 || len(plain) <= p.MaxLength, CodeMaxLength, "must be at most %d bytes", p.MaxLength)
+
+	var upper, lower, digit, symbol bool
+	for _, r := range plain {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			symbol = true
+		}
+	}
+	add(!p.RequireUppercase || upper, CodeUppercase, "must contain an uppercase letter")
+	add(!p.RequireLowercase || lower, CodeLowercase, "must contain a lowercase letter")
+	add(!p.RequireDigit || digit, CodeDigit, "must contain a digit")
+	add(!p.RequireSymbol || symbol, CodeSymbol, "must contain a symbol")
+
+	add(!p.denied(plain), CodeCommon, "is too common")
+
+	if len(v) > 0 {
+		return v
+	}
+
+	if p.Breach != nil {
+		breached, err := p.Breach.Breached(ctx, plain)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrBreachCheck, err)
+		}
+		if breached {
+			return Violations{{Code: CodeBreached, Message: "has appeared in a data breach"}}
+		}
+	}
+	return nil
+}
+
+// denied reports whether the password is common or in the denylist.
+func (p Policy) denied(plain string) bool {
+	lower := strings.ToLower(plain)
+	if _, ok := _common[lower]; ok && p.DenyCommon {
+		return true
+	}
+	for _, denied := range p.Denylist {
+		if strings.EqualFold(denied, plain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package passwd
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPolicy_Validate(t *testing.T) {
+	strict := Policy{
+		MinLength:        10,
+		MaxLength:        BcryptMaxLength,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		DenyCommon:       true,
+		Denylist:         []string{"Acme-Corp-2024"},
+	}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		plain  string
+		codes  []string
+	}{
+		{name: "valid", policy: DefaultPolicy, plain: "correct horse battery staple"},
+		{name: "too short", policy: DefaultPolicy, plain: "abc12", codes: []string{CodeMinLength}},
+		{name: "too long", policy: DefaultPolicy, plain: strings.Repeat("a", 73), codes: []string{CodeMaxLength}},
+		{name: "multibyte length", policy: DefaultPolicy, plain: "пароль-ключ", codes: nil},
+		{name: "common", policy: DefaultPolicy, plain: "Password123", codes: []string{CodeCommon}},
+		{name: "denylist", policy: strict, plain: "acme-corp-2024", codes: []string{CodeUppercase, CodeCommon}},
+		{name: "strict valid", policy: strict, plain: "Tr0ub4dor&3x", codes: nil},
+		{name: "strict", policy: strict, plain: "abcdefghij", codes: []string{CodeUppercase, CodeDigit, CodeSymbol}},
+		{name: "zero policy", policy: Policy{}, plain: "", codes: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.plain)
+			if tt.codes == nil {
+				if err != nil {
+					t.Errorf("expect no error; got an error: %v", err)
+				}
+				return
+			}
+
+			var violations Violations
+			if !errors.As(err, &violations) {
+				t.Fatalf("expect violations; got %v", err)
+			}
+
+			codes := make([]string, 0, len(violations))
+			for _, v := range violations {
+				codes = append(codes, v.Code)
+			}
+			if !slices.Equal(codes, tt.codes) {
+				t.Errorf("expect codes %v; got %v", tt.codes, codes)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package passwd
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Scrypt is a HashComparer using the scrypt algorithm, the hashes are in the PHC string format, e.g.
+// $scrypt$ln=17,r=8,p=1$<salt>$<hash>. The parameters of the hash are used by Compare, so they can be tuned without
+// invalidating the stored hashes.
+type Scrypt struct {
+	LogN        uint8  // The base-2 logarithm of the CPU/memory cost N.
+	BlockSize   uint32 // The block size r, the memory is 128 * N * r bytes.
+	Parallelism uint32 // The parallelization p.
+	SaltLength  uint32 // The length of the random salt in bytes, default is 16.
+	KeyLength   uint32 // The length of the hash in bytes, default is 32.
+}
+
+// ScryptDefault is the Scrypt with the parameters recommended by OWASP, N=2^17, r=8 and p=1, which uses 128 MiB.
+var ScryptDefault = Scrypt{LogN: 17, BlockSize: 8, Parallelism: 1}
+
+func (s Scrypt) withDefaults() Scrypt {
+	if s.LogN == 0 {
+		s.LogN = ScryptDefault.LogN
+	}
+	if s.BlockSize == 0 {
+		s.BlockSize = ScryptDefault.BlockSize
+	}
+	if s.Parallelism == 0 {
+		s.Parallelism = ScryptDefault.Parallelism
+	}
+	if s.SaltLength == 0 {
+		s.SaltLength = 16
+	}
+	if s.KeyLength == 0 {
+		s.KeyLength = 32
+	}
+	return s
+}
+
+// Hash generates an scrypt hash from the specified plaintext password with a random salt.
+func (s Scrypt) Hash(plain string) (string, error) {
+	s = s.withDefaults()
+	salt := make([]byte, s.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("passwd: generate salt: %w", err)
+	}
+
+	hash, err := s.key(plain, salt, int(s.KeyLength))
+	if err != nil {
+		return "", err
+	}
+	return s.phc(salt, hash).format("ln", "r", "p"), nil
+}
+
+// Compare compares the specified plaintext password with the specified scrypt hash by the parameters of the hash.
+// It returns ErrMismatch if the password doesn't match, or ErrInvalidHash if the hash is malformed.
+func (s Scrypt) Compare(hash string, plain string) error {
+	p, err := s.parse(hash)
+	if err != nil {
+		return err
+	}
+
+	key, err := p.key(plain, p.salt, len(p.hash))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	if subtle.ConstantTimeCompare(key, p.hash) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+func (s Scrypt) key(plain string, salt []byte, keyLen int) ([]byte, error) {
+	key, err := scrypt.Key([]byte(plain), salt, 1<<s.LogN, int(s.BlockSize), int(s.Parallelism), keyLen)
+	if err != nil {
+		return nil, fmt.Errorf("passwd: scrypt: %w", err)
+	}
+	return key, nil
+}
+
+func (s Scrypt) phc(salt, hash []byte) phc {
+	return phc{
+		id: "scrypt",
+		params: map[string]string{
+			"ln": strconv.FormatUint(uint64(s.LogN), 10),
+			"r":  strconv.FormatUint(uint64(s.BlockSize), 10),
+			"p":  strconv.FormatUint(uint64(s.Parallelism), 10),
+		},
+		salt: salt,
+		hash: hash,
+	}
+}
+
+// scryptHash is a decoded scrypt hash.
+type scryptHash struct {
+	Scrypt
+	salt []byte
+	hash []byte
+}
+
+// parse decodes the scrypt hash.
+func (Scrypt) parse(hash string) (scryptHash, error) {
+	p, err := parsePHC("scrypt", hash)
+	if err != nil {
+		return scryptHash{}, err
+	}
+	if p.version != "" {
+		return scryptHash{}, fmt.Errorf("%w: unexpected scrypt version %q", ErrInvalidHash, p.version)
+	}
+
+	ln, err := p.uintParam("ln", 8)
+	if err != nil {
+		return scryptHash{}, err
+	}
+	r, err := p.uintParam("r", 32)
+	if err != nil {
+		return scryptHash{}, err
+	}
+	par, err := p.uintParam("p", 32)
+	if err != nil {
+		return scryptHash{}, err
+	}
+	if ln == 0 || ln >= 63 || r == 0 || par == 0 || len(p.hash) == 0 {
+		return scryptHash{}, fmt.Errorf("%w: invalid scrypt parameter", ErrInvalidHash)
+	}
+
+	params := Scrypt{LogN: uint8(ln), BlockSize: uint32(r), Parallelism: uint32(par), SaltLength: uint32(len(p.salt)),
+		KeyLength: uint32(len(p.hash))}
+	return scryptHash{Scrypt: params, salt: p.salt, hash: p.hash}, nil
+}
+
+// NeedsRehash reports whether the hash is not an scrypt hash of the configured parameters.
+func (s Scrypt) NeedsRehash(hash string) bool {
+	p, err := s.parse(hash)
+	return err != nil || p.Scrypt != s.withDefaults()
+}
@@ -0,0 +1,58 @@
+// Package problemmap maps the Problem Details types to HTTP status codes.
+//
+// Feature packages register their types at init, so the error handling middleware can map new business errors
+// without being edited:
+//
+//	func init() {
+//		problemmap.Register(PDTypeUserNotFound, http.StatusNotFound)
+//	}
+package problemmap
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Registry maps the Problem Details types to HTTP status codes. Registry is concurrent-safe.
+type Registry struct {
+	mu       sync.RWMutex
+	statuses map[string]int
+}
+
+// NewRegistry creates a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{statuses: make(map[string]int)}
+}
+
+// Register maps the type to the status code. It panics if the status code is not a 4xx or 5xx, or the type is
+// already registered with a different status code, since both are programming errors.
+func (r *Registry) Register(pdType string, status int) {
+	if status < http.StatusBadRequest || status > 599 {
+		panic(fmt.Sprintf("problemmap: invalid status %d for type %q", status, pdType))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if registered, ok := r.statuses[pdType]; ok && registered != status {
+		panic(fmt.Sprintf("problemmap: type %q is already registered with status %d", pdType, registered))
+	}
+	r.statuses[pdType] = status
+}
+
+// Status gets the status code of the type, if not registered, it returns false.
+func (r *Registry) Status(pdType string) (int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	status, ok := r.statuses[pdType]
+	return status, ok
+}
+
+// Default is the Registry used by Register and Status.
+var Default = NewRegistry()
+
+// Register maps the type to the status code in the Default registry, see Registry.Register.
+func Register(pdType string, status int) { Default.Register(pdType, status) }
+
+// Status gets the status code of the type from the Default registry.
+func Status(pdType string) (int, bool) { return Default.Status(pdType) }
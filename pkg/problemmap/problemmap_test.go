@@ -0,0 +1,42 @@
+package problemmap
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	_, ok := reg.Status("https://example.com/not-found")
+	expectTrue(t, !ok)
+
+	reg.Register("https://example.com/not-found", http.StatusNotFound)
+	reg.Register("https://example.com/not-found", http.StatusNotFound) // idempotent.
+
+	status, ok := reg.Status("https://example.com/not-found")
+	expectTrue(t, ok)
+	expectTrue(t, status == http.StatusNotFound)
+}
+
+func TestRegistry_Panics(t *testing.T) {
+	expectPanic := func(fn func()) {
+		t.Helper()
+		defer func() {
+			t.Helper()
+			expectTrue(t, recover() != nil)
+		}()
+		fn()
+	}
+
+	reg := NewRegistry()
+	reg.Register("https://example.com/conflict", http.StatusConflict)
+	expectPanic(func() { reg.Register("https://example.com/conflict", http.StatusBadRequest) })
+	expectPanic(func() { reg.Register("https://example.com/ok", http.StatusOK) })
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
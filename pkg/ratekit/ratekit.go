@@ -0,0 +1,139 @@
+// Package ratekit provides rate limiting algorithms with pluggable stores.
+package ratekit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrInvalidLimit is returned when the Limit has non-positive rate or period.
+var ErrInvalidLimit = errors.New("ratekit: invalid limit")
+
+// Limit describes how many requests are allowed in a period.
+type Limit struct {
+	Rate   int           `env:"RATE"`   // the number of requests allowed per period.
+	Period time.Duration `env:"PERIOD"` // the period of the rate.
+	Burst  int           `env:"BURST"`  // the maximum number of requests allowed at once. Default equals to Rate.
+}
+
+// PerSecond creates a Limit that allows n requests per second.
+func PerSecond(n int) Limit { return Limit{Rate: n, Period: time.Second} }
+
+// PerMinute creates a Limit that allows n requests per minute.
+func PerMinute(n int) Limit { return Limit{Rate: n, Period: time.Minute} }
+
+// PerHour creates a Limit that allows n requests per hour.
+func PerHour(n int) Limit { return Limit{Rate: n, Period: time.Hour} }
+
+// validate validates the limit.
+func (l Limit) validate() error {
+	if l.Rate <= 0 || l.Period <= 0 {
+		return fmt.Errorf("%w: rate=%d period=%s", ErrInvalidLimit, l.Rate, l.Period)
+	}
+	return nil
+}
+
+// burst returns the burst with the default value applied.
+func (l Limit) burst() int {
+	if l.Burst <= 0 {
+		return l.Rate
+	}
+	return l.Burst
+}
+
+// interval returns the time needed to earn a single token.
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+// Result is the decision of the rate limiter.
+type Result struct {
+	Allowed    bool          // whether the request is allowed.
+	Limit      int           // the maximum number of requests allowed at once.
+	Remaining  int           // the number of requests left before the limit is reached.
+	RetryAfter time.Duration // the time to wait before the next request is allowed, zero when allowed.
+	ResetAfter time.Duration // the time until the limit is fully restored.
+}
+
+// Store knows how to track the usage of the keys.
+type Store interface {
+	// Allow takes a single request from the key's quota.
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// MemoryStore is an in-memory token bucket Store, limits are only enforced within a single instance.
+// Buckets that have been fully restored are evicted periodically. MemoryStore is concurrent-safe.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	sweptAt time.Time
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+// NewMemoryStore creates a new MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), sweptAt: time.Now(), now: time.Now}
+}
+
+// Allow implements Store.
+func (s *MemoryStore) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	if err := limit.validate(); err != nil {
+		return Result{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	capacity := float64(limit.burst())
+	interval := limit.interval()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.updatedAt)
+	b.tokens = math.Min(capacity, b.tokens+float64(elapsed)/float64(interval))
+	b.updatedAt = now
+
+	res := Result{Limit: limit.burst()}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) * float64(interval))
+	}
+
+	res.Remaining = int(b.tokens)
+	res.ResetAfter = time.Duration((capacity - b.tokens) * float64(interval))
+	b.fullAt = now.Add(res.ResetAfter)
+	return res, nil
+}
+
+// sweep evicts the fully restored buckets at most once a minute, they are equivalent to absent buckets.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < time.Minute {
+		return
+	}
+
+	for k, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, k)
+		}
+	}
+	s.sweptAt = now
+}
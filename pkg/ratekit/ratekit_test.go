@@ -0,0 +1,74 @@
+package ratekit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_Allow(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	limit := Limit{Rate: 2, Period: time.Second, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		res, err := store.Allow(ctx, "k", limit)
+		expectTrue(t, err == nil)
+		expectTrue(t, res.Allowed)
+		expectTrue(t, res.Remaining == i)
+		expectTrue(t, res.Limit == 3)
+	}
+
+	res, err := store.Allow(ctx, "k", limit)
+	expectTrue(t, err == nil)
+	expectTrue(t, !res.Allowed)
+	expectTrue(t, res.RetryAfter == 500*time.Millisecond)
+	expectTrue(t, res.ResetAfter == 1500*time.Millisecond)
+
+	// other keys have their own bucket.
+	res, _ = store.Allow(ctx, "other", limit)
+	expectTrue(t, res.Allowed)
+
+	// a single token is earned every 500ms.
+	now = now.Add(500 * time.Millisecond)
+	res, _ = store.Allow(ctx, "k", limit)
+	expectTrue(t, res.Allowed)
+	expectTrue(t, res.Remaining == 0)
+
+	res, _ = store.Allow(ctx, "k", limit)
+	expectTrue(t, !res.Allowed)
+}
+
+func TestMemoryStore_InvalidLimit(t *testing.T) {
+	_, err := NewMemoryStore().Allow(context.Background(), "k", Limit{Rate: 0, Period: time.Second})
+	expectTrue(t, errors.Is(err, ErrInvalidLimit))
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	_, _ = store.Allow(ctx, "a", PerSecond(1))
+	_, _ = store.Allow(ctx, "b", PerHour(1))
+
+	now = now.Add(2 * time.Minute)
+	_, _ = store.Allow(ctx, "c", PerSecond(1))
+
+	_, ok := store.buckets["a"]
+	expectTrue(t, !ok)
+	_, ok = store.buckets["b"]
+	expectTrue(t, ok)
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
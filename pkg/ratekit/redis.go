@@ -0,0 +1,86 @@
+package ratekit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript implements the sliding window log, every allowed request is recorded in a sorted set scored by
+// its time in microseconds. The Redis clock is used so the instances agree on the time.
+//
+// It returns {allowed, remaining, retry after in microseconds, reset after in microseconds}.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local member = ARGV[3]
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, member)
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, math.ceil(window / 1000))
+
+local retry = 0
+if allowed == 0 then
+	local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+	retry = tonumber(oldest[2]) + window - now
+end
+
+local newest = redis.call('ZRANGE', key, -1, -1, 'WITHSCORES')
+local reset = tonumber(newest[2]) + window - now
+
+return {allowed, limit - count, retry, reset}
+`)
+
+// RedisStore is a sliding window Store backed by Redis, the limits are shared by all instances that use the same
+// Redis server. Unlike the token bucket, the sliding window does not allow bursts, so Limit.Burst is ignored.
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+// NewRedisStore creates a new RedisStore, the keys are prefixed by the prefix, e.g. "ratelimit:".
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Allow implements Store.
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	if err := limit.validate(); err != nil {
+		return Result{}, err
+	}
+
+	// the member must be unique, otherwise requests in the same microsecond are counted once.
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return Result{}, fmt.Errorf("ratekit: generate member: %w", err)
+	}
+
+	window := limit.Period.Microseconds()
+	vals, err := slidingWindowScript.Run(ctx, s.client, []string{s.prefix + key}, limit.Rate, window, hex.EncodeToString(b[:])).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratekit: run sliding window script: %w", err)
+	}
+
+	return Result{
+		Allowed:    vals[0] == 1,
+		Limit:      limit.Rate,
+		Remaining:  int(vals[1]),
+		RetryAfter: time.Duration(vals[2]) * time.Microsecond,
+		ResetAfter: time.Duration(vals[3]) * time.Microsecond,
+	}, nil
+}
@@ -0,0 +1,49 @@
+package ratekit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisStore_Allow(t *testing.T) {
+	mr := miniredis.RunT(t)
+	now := time.Now()
+	mr.SetTime(now)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	// two stores simulate two instances sharing the same quota.
+	a := NewRedisStore(client, "ratelimit:")
+	b := NewRedisStore(client, "ratelimit:")
+
+	ctx := context.Background()
+	limit := PerMinute(2)
+
+	res, err := a.Allow(ctx, "k", limit)
+	expectTrue(t, err == nil)
+	expectTrue(t, res.Allowed && res.Remaining == 1 && res.Limit == 2)
+
+	mr.SetTime(now.Add(20 * time.Second))
+	res, err = b.Allow(ctx, "k", limit)
+	expectTrue(t, err == nil)
+	expectTrue(t, res.Allowed && res.Remaining == 0)
+	expectTrue(t, res.ResetAfter == time.Minute)
+
+	res, err = a.Allow(ctx, "k", limit)
+	expectTrue(t, err == nil)
+	expectTrue(t, !res.Allowed)
+	expectTrue(t, res.RetryAfter == 40*time.Second)
+
+	// the first request slides out of the window.
+	mr.SetTime(now.Add(time.Minute))
+	res, err = b.Allow(ctx, "k", limit)
+	expectTrue(t, err == nil)
+	expectTrue(t, res.Allowed && res.Remaining == 0)
+
+	expectTrue(t, mr.Exists("ratelimit:k"))
+}
@@ -0,0 +1,54 @@
+// Package rediskit provides helpers for connecting to Redis on top of github.com/redis/go-redis/v9.
+package rediskit
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Config holds the Redis connection configuration.
+type Config struct {
+	Addr         string        `env:"ADDR"`            // host:port of the Redis server, empty means Redis is disabled.
+	Username     string        `env:"USERNAME"`        // ACL username, empty for the default user.
+	Password     string        `env:"PASSWORD,secret"` // password of the user.
+	DB           int           `env:"DB"`              // database to be selected.
+	PoolSize     int           `env:"POOL_SIZE"`       // maximum number of connections. Default 10 per CPU.
+	DialTimeout  time.Duration `env:"DIAL_TIMEOUT"`    // Default 5 seconds.
+	ReadTimeout  time.Duration `env:"READ_TIMEOUT"`    // Default 3 seconds.
+	WriteTimeout time.Duration `env:"WRITE_TIMEOUT"`   // Default equals to ReadTimeout.
+	TLS          bool          `env:"TLS"`             // connect using TLS.
+}
+
+// Enabled reports whether the Redis address is configured.
+func (c Config) Enabled() bool { return c.Addr != "" }
+
+// New creates a new Redis client. The connections are established lazily, use Ping to verify the connectivity.
+func New(cfg Config) *redis.Client {
+	opts := redis.Options{
+		Addr:         cfg.Addr,
+		Username:     cfg.Username,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	}
+
+	if cfg.TLS {
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return redis.NewClient(&opts)
+}
+
+// Ping verifies the connectivity to the Redis server.
+func Ping(ctx context.Context, client redis.UniversalClient) error {
+	if err := client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("rediskit: ping: %w", err)
+	}
+	return nil
+}
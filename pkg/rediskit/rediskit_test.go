@@ -0,0 +1,33 @@
+package rediskit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestNew(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.RequireAuth("secret")
+
+	client := New(Config{Addr: mr.Addr(), Password: "secret"})
+	t.Cleanup(func() { _ = client.Close() })
+	expectTrue(t, Ping(context.Background(), client) == nil)
+
+	unauthorized := New(Config{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = unauthorized.Close() })
+	expectTrue(t, Ping(context.Background(), unauthorized) != nil)
+}
+
+func TestConfig_Enabled(t *testing.T) {
+	expectTrue(t, !Config{}.Enabled())
+	expectTrue(t, Config{Addr: "localhost:6379"}.Enabled())
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
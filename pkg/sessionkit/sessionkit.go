@@ -0,0 +1,325 @@
+// Package sessionkit provides cookie-based sessions backed by pluggable stores.
+//
+// The cookie only carries an opaque random session ID, the values are kept in the Store.
+package sessionkit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+// ErrSessionNotFound is returned by the Store when the session does not exist or has expired.
+var ErrSessionNotFound = errors.New("sessionkit: session not found")
+
+// Store knows how to persist the session values.
+type Store interface {
+	// Load loads the values of the session, returns ErrSessionNotFound if the session does not exist or has expired.
+	Load(ctx context.Context, id string) (map[string]string, error)
+
+	// Save saves the values of the session, the session expires after the ttl.
+	Save(ctx context.Context, id string, values map[string]string, ttl time.Duration) error
+
+	// Delete deletes the session. Deleting a session that does not exist is not an error.
+	Delete(ctx context.Context, id string) error
+}
+
+// Session is the session of the current request. Session is concurrent-safe.
+type Session struct {
+	mu        sync.Mutex
+	id        string
+	staleID   string // the persisted ID that must be deleted on commit.
+	values    map[string]string
+	modified  bool
+	destroyed bool
+}
+
+// ID returns the session ID, empty for a new session that has not been committed yet.
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.id
+}
+
+// Get gets the value by its key.
+func (s *Session) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Set sets the value of the key.
+func (s *Session) Set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	s.modified = true
+}
+
+// Delete deletes the key.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.modified = true
+	}
+}
+
+// Regenerate issues a new session ID while keeping the values, the old session is deleted on commit.
+// It must be called when the privilege level changes, e.g. after login, to prevent session fixation.
+func (s *Session) Regenerate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.staleID == "" {
+		s.staleID = s.id
+	}
+	s.id = ""
+	s.modified = true
+}
+
+// Destroy deletes the session and expires the cookie on commit, e.g. on logout.
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.staleID == "" {
+		s.staleID = s.id
+	}
+	s.id = ""
+	s.values = make(map[string]string)
+	s.destroyed = true
+}
+
+type sessionKey struct{}
+
+// FromContext gets the session from the context, the session is available in handlers behind Manager.Middleware.
+func FromContext(ctx context.Context) (*Session, bool) {
+	s, ok := ctx.Value(sessionKey{}).(*Session)
+	return s, ok
+}
+
+// Config is the configuration for the session Manager.
+type Config struct {
+	CookieName string        `env:"COOKIE_NAME,default=session_id"` // Name of the session cookie. Default "session_id".
+	Path       string        `env:"COOKIE_PATH"`                    // Path of the session cookie. Default "/".
+	Domain     string        `env:"COOKIE_DOMAIN"`                  // Domain of the session cookie. Default host-only.
+	SameSite   http.SameSite // SameSite mode of the session cookie. Default http.SameSiteLaxMode.
+	TTL        time.Duration `env:"TTL,default=24h"` // Idle timeout, extended whenever the session is modified. Default 24 hours.
+
+	// Insecure allows the cookie to be sent over plain HTTP, only for local development.
+	Insecure bool `env:"COOKIE_INSECURE"`
+}
+
+// withDefaults returns a copy of the config with default values for unset fields.
+func (c Config) withDefaults() Config {
+	if c.CookieName == "" {
+		c.CookieName = "session_id"
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	if c.TTL <= 0 {
+		c.TTL = 24 * time.Hour
+	}
+	return c
+}
+
+// Manager loads and saves the sessions of the requests.
+type Manager struct {
+	store Store
+	cfg   Config
+}
+
+// NewManager creates a new Manager.
+func NewManager(store Store, cfg Config) *Manager {
+	return &Manager{store: store, cfg: cfg.withDefaults()}
+}
+
+// Middleware loads the session from the cookie and puts it in the request context, see FromContext.
+// The changes are saved and the cookie is sent right before the response header is written. When the handler
+// returns an error before writing the response, the changes are discarded.
+func (m *Manager) Middleware() httpkit.MuxMiddleware {
+	return func(next httpkit.Handler) httpkit.Handler {
+		return httpkit.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			sess, err := m.load(r)
+			if err != nil {
+				return err
+			}
+
+			sw := &sessionWriter{ResponseWriter: w, commit: func() error { return m.commit(r.Context(), w, sess) }}
+			if err := next.ServeHTTP(sw.writer(), r.WithContext(context.WithValue(r.Context(), sessionKey{}, sess))); err != nil {
+				return err
+			}
+
+			if !sw.committed {
+				return sw.commitOnce()
+			}
+
+			if sw.err != nil {
+				// the response has been sent, the error can only be logged.
+				return httpkit.ResolveError(sw.err)
+			}
+			return nil
+		})
+	}
+}
+
+// load loads the session from the request cookie, a new session is returned when the cookie is absent or the
+// session has expired.
+func (m *Manager) load(r *http.Request) (*Session, error) {
+	sess := Session{values: make(map[string]string)}
+
+	cookie, err := r.Cookie(m.cfg.CookieName)
+	if err != nil || cookie.Value == "" {
+		return &sess, nil
+	}
+
+	values, err := m.store.Load(r.Context(), cookie.Value)
+	if errors.Is(err, ErrSessionNotFound) {
+		return &sess, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sessionkit: load session: %w", err)
+	}
+
+	sess.id = cookie.Value
+	sess.values = values
+	return &sess, nil
+}
+
+// commit persists the session changes and sets the cookie.
+func (m *Manager) commit(ctx context.Context, w http.ResponseWriter, s *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.staleID != "" {
+		if err := m.store.Delete(ctx, s.staleID); err != nil {
+			return fmt.Errorf("sessionkit: delete session: %w", err)
+		}
+		s.staleID = ""
+	}
+
+	if s.destroyed {
+		http.SetCookie(w, m.cookie("", -1))
+		return nil
+	}
+
+	if !s.modified {
+		return nil
+	}
+
+	if s.id == "" {
+		id, err := newID()
+		if err != nil {
+			return err
+		}
+		s.id = id
+	}
+
+	if err := m.store.Save(ctx, s.id, s.values, m.cfg.TTL); err != nil {
+		return fmt.Errorf("sessionkit: save session: %w", err)
+	}
+
+	http.SetCookie(w, m.cookie(s.id, int(m.cfg.TTL.Seconds())))
+	s.modified = false
+	return nil
+}
+
+func (m *Manager) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.cfg.CookieName,
+		Value:    value,
+		Path:     m.cfg.Path,
+		Domain:   m.cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   !m.cfg.Insecure,
+		HttpOnly: true,
+		SameSite: m.cfg.SameSite,
+	}
+}
+
+// newID generates a random 256-bit session ID.
+func newID() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("sessionkit: generate session id: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
+
+// sessionWriter commits the session right before the response header is written.
+type sessionWriter struct {
+	http.ResponseWriter
+	commit    func() error
+	committed bool
+	err       error
+}
+
+func (w *sessionWriter) commitOnce() error {
+	if !w.committed {
+		w.committed = true
+		w.err = w.commit()
+	}
+	return w.err
+}
+
+func (w *sessionWriter) WriteHeader(code int) {
+	_ = w.commitOnce()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	_ = w.commitOnce()
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the original http.ResponseWriter.
+func (w *sessionWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// writer returns the session writer as an http.ResponseWriter that implements http.Flusher only when the original
+// ResponseWriter does, so the handlers that check it, e.g. SSE, behave the same as without the session.
+func (w *sessionWriter) writer() http.ResponseWriter {
+	f, ok := underlyingFlusher(w.ResponseWriter)
+	if !ok {
+		return w
+	}
+	return &flushSessionWriter{sessionWriter: w, flusher: f}
+}
+
+// flushSessionWriter is the sessionWriter of the ResponseWriter that implements http.Flusher.
+type flushSessionWriter struct {
+	*sessionWriter
+	flusher http.Flusher
+}
+
+// Flush implements http.Flusher, flushing sends the header, so the session is committed first.
+func (w *flushSessionWriter) Flush() {
+	_ = w.commitOnce()
+	w.flusher.Flush()
+}
+
+// underlyingFlusher finds the http.Flusher in the chain of the wrapped ResponseWriters.
+func underlyingFlusher(w http.ResponseWriter) (http.Flusher, bool) {
+	for {
+		if f, ok := w.(http.Flusher); ok {
+			return f, true
+		}
+
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil, false
+		}
+		w = u.Unwrap()
+	}
+}
@@ -0,0 +1,160 @@
+package sessionkit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+)
+
+func newTestServer(store Store) http.Handler {
+	mgr := NewManager(store, Config{})
+	mux := httpkit.NewServeMux(httpkit.Opts.Middleware(mgr.Middleware()))
+
+	mux.Route(httpkit.Route{Method: http.MethodPost, Path: "/login", Handler: func(w http.ResponseWriter, r *http.Request) error {
+		sess, _ := FromContext(r.Context())
+		sess.Regenerate()
+		sess.Set("user_id", r.URL.Query().Get("user"))
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}})
+
+	mux.Route(httpkit.Route{Method: http.MethodGet, Path: "/me", Handler: func(w http.ResponseWriter, r *http.Request) error {
+		sess, _ := FromContext(r.Context())
+		userID, ok := sess.Get("user_id")
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return nil
+		}
+		_, err := w.Write([]byte(userID))
+		return err
+	}})
+
+	mux.Route(httpkit.Route{Method: http.MethodPost, Path: "/fail", Handler: func(w http.ResponseWriter, r *http.Request) error {
+		sess, _ := FromContext(r.Context())
+		sess.Set("user_id", "mallory")
+		return errors.New("an error")
+	}})
+
+	mux.Route(httpkit.Route{Method: http.MethodPost, Path: "/logout", Handler: func(w http.ResponseWriter, r *http.Request) error {
+		sess, _ := FromContext(r.Context())
+		sess.Destroy()
+		return nil
+	}})
+
+	return mux
+}
+
+func do(h http.Handler, method, path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func sessionCookie(rec *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "session_id" {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestManager_Middleware(t *testing.T) {
+	store := NewMemoryStore()
+	srv := newTestServer(store)
+
+	// anonymous request does not create a session.
+	rec := do(srv, http.MethodGet, "/me", nil)
+	expectTrue(t, rec.Code == http.StatusUnauthorized)
+	expectTrue(t, sessionCookie(rec) == nil)
+
+	rec = do(srv, http.MethodPost, "/login?user=john", nil)
+	expectTrue(t, rec.Code == http.StatusNoContent)
+	first := sessionCookie(rec)
+	expectTrue(t, first != nil)
+	expectTrue(t, first.HttpOnly && first.Secure && first.SameSite == http.SameSiteLaxMode)
+	expectTrue(t, first.MaxAge == int((24*time.Hour).Seconds()))
+
+	rec = do(srv, http.MethodGet, "/me", first)
+	expectTrue(t, rec.Body.String() == "john")
+
+	// login again regenerates the session ID and deletes the old session.
+	rec = do(srv, http.MethodPost, "/login?user=jane", first)
+	second := sessionCookie(rec)
+	expectTrue(t, second != nil && second.Value != first.Value)
+	_, err := store.Load(context.Background(), first.Value)
+	expectTrue(t, errors.Is(err, ErrSessionNotFound))
+
+	// changes are discarded when the handler fails.
+	rec = do(srv, http.MethodPost, "/fail", second)
+	expectTrue(t, sessionCookie(rec) == nil)
+	rec = do(srv, http.MethodGet, "/me", second)
+	expectTrue(t, rec.Body.String() == "jane")
+
+	rec = do(srv, http.MethodPost, "/logout", second)
+	expired := sessionCookie(rec)
+	expectTrue(t, expired != nil && expired.MaxAge < 0)
+	rec = do(srv, http.MethodGet, "/me", second)
+	expectTrue(t, rec.Code == http.StatusUnauthorized)
+}
+
+func TestManager_MiddlewareFlusher(t *testing.T) {
+	var canFlush bool
+	handler := NewManager(NewMemoryStore(), Config{}).Middleware()(httpkit.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) error {
+			sess, _ := FromContext(r.Context())
+			sess.Set("user_id", "alice")
+
+			var f http.Flusher
+			f, canFlush = w.(http.Flusher)
+			if canFlush {
+				f.Flush()
+			}
+			return nil
+		},
+	))
+
+	// flushing commits the session before the header is sent.
+	rec := httptest.NewRecorder()
+	expectTrue(t, handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil)) == nil)
+	expectTrue(t, canFlush && rec.Flushed)
+	expectTrue(t, len(rec.Result().Cookies()) == 1)
+
+	// the writer that can't flush isn't told it can.
+	w := struct{ http.ResponseWriter }{httptest.NewRecorder()}
+	expectTrue(t, handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil)) == nil)
+	expectTrue(t, !canFlush)
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	values := map[string]string{"k": "v"}
+	expectTrue(t, store.Save(ctx, "id", values, time.Hour) == nil)
+	values["k"] = "changed"
+
+	got, err := store.Load(ctx, "id")
+	expectTrue(t, err == nil)
+	expectTrue(t, got["k"] == "v")
+
+	expectTrue(t, store.Save(ctx, "id", got, -time.Second) == nil)
+	_, err = store.Load(ctx, "id")
+	expectTrue(t, errors.Is(err, ErrSessionNotFound))
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
@@ -0,0 +1,131 @@
+package sessionkit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MemoryStore is an in-memory Store, it is suitable for tests and single instance deployments.
+// Expired sessions are evicted lazily. MemoryStore is concurrent-safe.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]memorySession
+	sweptAt  time.Time
+}
+
+type memorySession struct {
+	values    map[string]string
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates a new MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]memorySession), sweptAt: time.Now()}
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(_ context.Context, id string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	if time.Now().After(sess.expiresAt) {
+		delete(s.sessions, id)
+		return nil, ErrSessionNotFound
+	}
+	return copyValues(sess.values), nil
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(_ context.Context, id string, values map[string]string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.sweptAt) > time.Minute {
+		for k, sess := range s.sessions {
+			if now.After(sess.expiresAt) {
+				delete(s.sessions, k)
+			}
+		}
+		s.sweptAt = now
+	}
+
+	s.sessions[id] = memorySession{values: copyValues(values), expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+func copyValues(values map[string]string) map[string]string {
+	c := make(map[string]string, len(values))
+	for k, v := range values {
+		c[k] = v
+	}
+	return c
+}
+
+// RedisStore is a Store backed by Redis, the values are stored as JSON with the session TTL.
+type RedisStore struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisStore creates a new RedisStore, the keys are prefixed by the prefix, e.g. "session:".
+func NewRedisStore(client redis.Cmdable, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Load implements Store.
+func (s *RedisStore) Load(ctx context.Context, id string) (map[string]string, error) {
+	b, err := s.client.Get(ctx, s.prefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis get: %w", err)
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, fmt.Errorf("decode session: %w", err)
+	}
+	return values, nil
+}
+
+// Save implements Store.
+func (s *RedisStore) Save(ctx context.Context, id string, values map[string]string, ttl time.Duration) error {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("encode session: %w", err)
+	}
+
+	if err := s.client.Set(ctx, s.prefix+id, b, ttl).Err(); err != nil {
+		return fmt.Errorf("redis set: %w", err)
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (s *RedisStore) Delete(ctx context.Context, id string) error {
+	if err := s.client.Del(ctx, s.prefix+id).Err(); err != nil {
+		return fmt.Errorf("redis del: %w", err)
+	}
+	return nil
+}
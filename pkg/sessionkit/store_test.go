@@ -0,0 +1,38 @@
+package sessionkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	store := NewRedisStore(client, "session:")
+	ctx := context.Background()
+
+	_, err := store.Load(ctx, "id")
+	expectTrue(t, errors.Is(err, ErrSessionNotFound))
+
+	expectTrue(t, store.Save(ctx, "id", map[string]string{"user_id": "john"}, time.Minute) == nil)
+	expectTrue(t, mr.TTL("session:id") == time.Minute)
+
+	values, err := store.Load(ctx, "id")
+	expectTrue(t, err == nil)
+	expectTrue(t, values["user_id"] == "john")
+
+	mr.FastForward(time.Minute)
+	_, err = store.Load(ctx, "id")
+	expectTrue(t, errors.Is(err, ErrSessionNotFound))
+
+	expectTrue(t, store.Save(ctx, "id", map[string]string{}, time.Minute) == nil)
+	expectTrue(t, store.Delete(ctx, "id") == nil)
+	expectTrue(t, !mr.Exists("session:id"))
+}
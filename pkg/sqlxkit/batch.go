@@ -0,0 +1,105 @@
+package sqlxkit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+)
+
+// DefaultBatchSize is the batch size of NamedExecBatch used when the given one is less than one.
+const DefaultBatchSize = 100
+
+// _valuesClause matches the VALUES clause of an INSERT query, the same way sqlx expands it for the slice arguments.
+var _valuesClause = regexp.MustCompile(`\)\s*(?i)VALUES\s*\(`)
+
+// NamedExecBatch is NamedExec for many args, e.g. for the high-volume ingestion endpoints.
+//
+// If the query has a VALUES clause, e.g. "INSERT INTO users (id, name) VALUES (:id, :name)", the clause is expanded
+// for batchSize args at once, so each batch is a single round trip. Otherwise, e.g. for an UPDATE, the query is
+// executed once per arg. Keep batchSize times the number of placeholders under the limit of the driver, e.g. 65535
+// for PostgreSQL.
+//
+// The batches aren't atomic by themselves, run the Atomic with ExecTransaction to commit all or nothing. The
+// ExecOption applies to the aggregation of all batches, e.g. WithVerifyAffectedRows verifies the total affected rows,
+// except WithReadLastInsertedID which reads the one of the last batch.
+func NamedExecBatch[T any](query string, args []T, batchSize int, opts ...ExecOption) Atomic {
+	var conf execOption
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	if batchSize < 1 {
+		batchSize = DefaultBatchSize
+	}
+
+	expand := _valuesClause.MatchString(query)
+	return func(ctx context.Context, tx Tx) (context.Context, error) {
+		total := batchResult{countAffected: conf.verifyAffected || conf.readAffected}
+		for start := 0; start < len(args); start += batchSize {
+			end := min(start+batchSize, len(args))
+
+			var (
+				res sql.Result
+				err error
+			)
+			if expand {
+				res, err = tx.NamedExecContext(ctx, query, args[start:end])
+				if err == nil {
+					err = total.add(res)
+				}
+			} else {
+				for i := start; i < end && err == nil; i++ {
+					if res, err = tx.NamedExecContext(ctx, query, args[i]); err == nil {
+						err = total.add(res)
+					}
+				}
+			}
+			if err != nil {
+				return ctx, fmt.Errorf("sqlxkit: NamedExecBatch: exec args[%d:%d]: %w", start, end, err)
+			}
+			total.last = res
+		}
+
+		if err := doAffectedRowsAction(&conf, total); err != nil {
+			return ctx, fmt.Errorf("sqlxkit: NamedExecBatch: process read affected rows: %w", err)
+		}
+
+		if err := doLastInsertIDAction(&conf, total); err != nil {
+			return ctx, fmt.Errorf("sqlxkit: NamedExecBatch: process read last insert id: %w", err)
+		}
+
+		return ctx, nil
+	}
+}
+
+// batchResult aggregates the results of the batches.
+type batchResult struct {
+	countAffected bool
+	affected      int64
+	last          sql.Result
+}
+
+// add adds the affected rows of res to the total if needed, since not all drivers support it.
+func (b *batchResult) add(res sql.Result) error {
+	if !b.countAffected {
+		return nil
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	b.affected += n
+	return nil
+}
+
+// RowsAffected returns the total affected rows.
+func (b batchResult) RowsAffected() (int64, error) { return b.affected, nil }
+
+// LastInsertId returns the last inserted ID of the last batch.
+func (b batchResult) LastInsertId() (int64, error) {
+	if b.last == nil {
+		return 0, nil
+	}
+	return b.last.LastInsertId()
+}
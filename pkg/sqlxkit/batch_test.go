@@ -0,0 +1,110 @@
+package sqlxkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestNamedExecBatch(t *testing.T) {
+	users := []queryUser{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob"}, {ID: 3, Name: "carol"}}
+
+	t.Run("values expanded per batch", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+		ApplyConfig(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO users (id, name) VALUES (?, ?),(?, ?)").
+			WithArgs(1, "alice", 2, "bob").
+			WillReturnResult(sqlmock.NewResult(2, 2))
+		mock.ExpectExec("INSERT INTO users (id, name) VALUES (?, ?)").
+			WithArgs(3, "carol").
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectCommit()
+
+		var affected, lastID int64
+		err := ExecTransaction(context.Background(), db, NamedExecBatch(
+			"INSERT INTO users (id, name) VALUES (:id, :name)", users, 2,
+			WithVerifyAffectedRows(3),
+			WithReadAffectedRows(&affected),
+			WithReadLastInsertedID(&lastID),
+		))
+		expectNoError(t, err)
+		expectTrue(t, affected == 3)
+		expectTrue(t, lastID == 3)
+	})
+
+	t.Run("executed per arg", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+		ApplyConfig(db)
+
+		mock.ExpectBegin()
+		for _, u := range users {
+			mock.ExpectExec("UPDATE users SET name = ? WHERE id = ?").
+				WithArgs(u.Name, u.ID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+
+		var affected int64
+		err := ExecTransaction(context.Background(), db, NamedExecBatch(
+			"UPDATE users SET name = :name WHERE id = :id", users, 0,
+			WithReadAffectedRows(&affected),
+		))
+		expectNoError(t, err)
+		expectTrue(t, affected == 3)
+	})
+
+	t.Run("unexpected affected rows", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+		ApplyConfig(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO users (id, name) VALUES (?, ?),(?, ?),(?, ?)").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectRollback()
+
+		err := ExecTransaction(context.Background(), db, NamedExecBatch(
+			"INSERT INTO users (id, name) VALUES (:id, :name)", users, 10,
+			WithVerifyAffectedRows(3),
+		))
+		expectTrue(t, errors.Is(err, ErrUnexpectedAffectedRows))
+	})
+
+	t.Run("batch failed", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+		ApplyConfig(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO users (id, name) VALUES (?, ?)").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO users (id, name) VALUES (?, ?)").WillReturnError(errExample)
+		mock.ExpectRollback()
+
+		err := ExecTransaction(context.Background(), db, NamedExecBatch(
+			"INSERT INTO users (id, name) VALUES (:id, :name)", users, 1,
+		))
+		expectTrue(t, errors.Is(err, errExample))
+	})
+
+	t.Run("no args", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		var affected int64 = -1
+		err := ExecTransaction(context.Background(), db, NamedExecBatch[queryUser](
+			"INSERT INTO users (id, name) VALUES (:id, :name)", nil, 10,
+			WithReadAffectedRows(&affected),
+		))
+		expectNoError(t, err)
+		expectTrue(t, affected == 0)
+	})
+}
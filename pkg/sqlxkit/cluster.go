@@ -0,0 +1,188 @@
+package sqlxkit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Balance is the policy for choosing the replica of a read.
+type Balance uint8
+
+// Sets of balance policies.
+const (
+	BalanceRoundRobin Balance = iota // the replicas take turns.
+	BalanceLeastConn                 // the replica with the fewest connections in use.
+)
+
+// ClusterConfig is the configuration for opening a Cluster.
+type ClusterConfig struct {
+	Driver         string   // the driver name of all connections.
+	PrimaryDSN     string   // the DSN of the primary, which serves the writes and the transactions.
+	ReplicaDSNs    []string // the DSNs of the replicas, which serve the reads. The primary serves them if empty.
+	PrimaryOptions []Option // the options of the primary connection, e.g. the pool size.
+	ReplicaOptions []Option // the options of each replica connection.
+	Balance        Balance  // the policy for choosing the replica of a read.
+}
+
+// Cluster is a Conn that splits the reads and the writes, the Reader calls are routed to the replicas and the
+// Writer, Binder, Preparer and transaction calls are routed to the primary. The replication lag means a read right
+// after a write may not see it, use Primary for reading your own writes.
+type Cluster struct {
+	primary  Conn
+	replicas []Conn
+	balance  Balance
+	next     atomic.Uint64
+}
+
+// NewCluster creates a Cluster of the opened connections, the primary serves the reads if there is no replica.
+func NewCluster(primary Conn, replicas []Conn, balance Balance) *Cluster {
+	return &Cluster{primary: primary, replicas: replicas, balance: balance}
+}
+
+// OpenCluster opens the connections of the primary and the replicas. All opened connections are closed if one of
+// them fails.
+func OpenCluster(cfg ClusterConfig) (*Cluster, error) {
+	primary, err := Open(cfg.Driver, cfg.PrimaryDSN, cfg.PrimaryOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlxkit: open primary: %w", err)
+	}
+
+	replicas := make([]Conn, 0, len(cfg.ReplicaDSNs))
+	for i, dsn := range cfg.ReplicaDSNs {
+		replica, err := Open(cfg.Driver, dsn, cfg.ReplicaOptions...)
+		if err != nil {
+			_ = NewCluster(primary, replicas, cfg.Balance).Close()
+			return nil, fmt.Errorf("sqlxkit: open replicas[%d]: %w", i, err)
+		}
+		replicas = append(replicas, replica)
+	}
+
+	return NewCluster(primary, replicas, cfg.Balance), nil
+}
+
+// Primary returns the primary connection, e.g. for reading your own writes.
+func (c *Cluster) Primary() Conn { return c.primary }
+
+// Replicas returns the replica connections.
+func (c *Cluster) Replicas() []Conn { return c.replicas }
+
+// replica chooses the connection of a read by the balance policy.
+func (c *Cluster) replica() Conn {
+	switch len(c.replicas) {
+	case 0:
+		return c.primary
+	case 1:
+		return c.replicas[0]
+	}
+
+	if c.balance == BalanceLeastConn {
+		if replica, ok := c.leastConn(); ok {
+			return replica
+		}
+	}
+
+	n := c.next.Add(1) - 1
+	return c.replicas[n%uint64(len(c.replicas))]
+}
+
+// leastConn returns the replica with the fewest connections in use, it reports false if one of the replicas doesn't
+// expose its stats.
+func (c *Cluster) leastConn() (Conn, bool) {
+	type statser interface{ Stats() sql.DBStats }
+
+	var (
+		best  Conn
+		inUse int
+	)
+	for _, replica := range c.replicas {
+		s, ok := replica.(statser)
+		if !ok {
+			return nil, false
+		}
+
+		n := s.Stats().InUse
+		if best == nil || n < inUse {
+			best, inUse = replica, n
+		}
+	}
+	return best, true
+}
+
+// QueryxContext implements Reader, it is routed to a replica.
+func (c *Cluster) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	return c.replica().QueryxContext(ctx, query, args...)
+}
+
+// QueryRowxContext implements Reader, it is routed to a replica.
+func (c *Cluster) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	return c.replica().QueryRowxContext(ctx, query, args...)
+}
+
+// ExecContext implements Writer, it is routed to the primary.
+func (c *Cluster) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return c.primary.ExecContext(ctx, query, args...)
+}
+
+// NamedExecContext implements Writer, it is routed to the primary.
+func (c *Cluster) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	return c.primary.NamedExecContext(ctx, query, arg)
+}
+
+// Rebind implements Binder, all connections share the driver, so it is routed to the primary.
+func (c *Cluster) Rebind(query string) string { return c.primary.Rebind(query) }
+
+// BindNamed implements Binder, all connections share the driver, so it is routed to the primary.
+func (c *Cluster) BindNamed(query string, arg any) (string, []any, error) {
+	return c.primary.BindNamed(query, arg)
+}
+
+// PreparexContext implements Preparer, the statement may write, so it is routed to the primary.
+func (c *Cluster) PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error) {
+	return c.primary.PreparexContext(ctx, query)
+}
+
+// PrepareNamedContext implements Preparer, the statement may write, so it is routed to the primary.
+func (c *Cluster) PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error) {
+	return c.primary.PrepareNamedContext(ctx, query)
+}
+
+// BeginTxx implements DB, the transaction is routed to the primary, including its reads.
+func (c *Cluster) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	return c.primary.BeginTxx(ctx, opts)
+}
+
+// Driver implements Conn, it returns the driver of the primary.
+func (c *Cluster) Driver() driver.Driver { return c.primary.Driver() }
+
+// Conn implements Conn, it returns a connection of the primary.
+func (c *Cluster) Conn(ctx context.Context) (*sql.Conn, error) { return c.primary.Conn(ctx) }
+
+// PingContext implements Conn, it pings the primary and all replicas.
+func (c *Cluster) PingContext(ctx context.Context) error {
+	err := c.primary.PingContext(ctx)
+	if err != nil {
+		err = fmt.Errorf("ping primary: %w", err)
+	}
+
+	for i, replica := range c.replicas {
+		if pingErr := replica.PingContext(ctx); pingErr != nil {
+			err = errors.Join(err, fmt.Errorf("ping replicas[%d]: %w", i, pingErr))
+		}
+	}
+	return err
+}
+
+// Close implements Conn, it closes the primary and all replicas.
+func (c *Cluster) Close() error {
+	err := c.primary.Close()
+	for _, replica := range c.replicas {
+		err = errors.Join(err, replica.Close())
+	}
+	return err
+}
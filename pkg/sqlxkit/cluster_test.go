@@ -0,0 +1,123 @@
+package sqlxkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+// Conn is satisfied by the Cluster, so it can be used wherever a single connection is.
+var _ Conn = (*Cluster)(nil)
+
+func TestCluster_Routing(t *testing.T) {
+	primary, primaryMock, teardownPrimary := Setup(t)
+	t.Cleanup(teardownPrimary)
+	replica1, replica1Mock, teardownReplica1 := Setup(t)
+	t.Cleanup(teardownReplica1)
+	replica2, replica2Mock, teardownReplica2 := Setup(t)
+	t.Cleanup(teardownReplica2)
+
+	ctx := context.Background()
+	cluster := NewCluster(primary, []Conn{replica1, replica2}, BalanceRoundRobin)
+
+	// reads take turns.
+	replica1Mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+	replica2Mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(2))
+	replica1Mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+
+	for _, want := range []int{1, 2, 1} {
+		got, err := One[int](ctx, cluster, "SELECT 1")
+		expectNoError(t, err)
+		expectTrue(t, got == want)
+	}
+
+	// writes and transactions go to the primary.
+	primaryMock.ExpectExec("DELETE FROM foo").WillReturnResult(sqlmock.NewResult(0, 1))
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(0))
+	primaryMock.ExpectCommit()
+
+	_, err := cluster.ExecContext(ctx, "DELETE FROM foo")
+	expectNoError(t, err)
+
+	err = ExecTransaction(ctx, cluster, func(ctx context.Context, tx Tx) (context.Context, error) {
+		n, err := One[int](ctx, tx, "SELECT 1")
+		expectTrue(t, n == 0)
+		return ctx, err
+	})
+	expectNoError(t, err)
+}
+
+func TestCluster_NoReplica(t *testing.T) {
+	primary, primaryMock, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	cluster := NewCluster(primary, nil, BalanceLeastConn)
+	primaryMock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+
+	_, err := One[int](context.Background(), cluster, "SELECT 1")
+	expectNoError(t, err)
+}
+
+func TestCluster_LeastConn(t *testing.T) {
+	primary, _, teardownPrimary := Setup(t)
+	t.Cleanup(teardownPrimary)
+	busy, busyMock, teardownBusy := Setup(t)
+	t.Cleanup(teardownBusy)
+	idle, idleMock, teardownIdle := Setup(t)
+	t.Cleanup(teardownIdle)
+
+	ctx := context.Background()
+	cluster := NewCluster(primary, []Conn{busy, idle}, BalanceLeastConn)
+
+	// keep a connection of the busy replica in use by the open rows.
+	busyMock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+	rows, err := busy.QueryxContext(ctx, "SELECT 1")
+	expectNoError(t, err)
+	t.Cleanup(func() { _ = rows.Close() })
+
+	idleMock.ExpectQuery("SELECT 2").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(2))
+	idleMock.ExpectQuery("SELECT 2").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(2))
+	for i := 0; i < 2; i++ {
+		_, err = One[int](ctx, cluster, "SELECT 2")
+		expectNoError(t, err)
+	}
+}
+
+func TestCluster_PingAndClose(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	expectNoError(t, err)
+	replica, replicaMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	expectNoError(t, err)
+
+	cluster := NewCluster(sqlx.NewDb(primary, "sql-mock"), []Conn{sqlx.NewDb(replica, "sql-mock")}, BalanceRoundRobin)
+
+	primaryMock.ExpectPing()
+	replicaMock.ExpectPing().WillReturnError(errExample)
+	err = cluster.PingContext(context.Background())
+	expectTrue(t, errors.Is(err, errExample))
+
+	primaryMock.ExpectClose()
+	replicaMock.ExpectClose()
+	expectNoError(t, cluster.Close())
+	expectNoError(t, primaryMock.ExpectationsWereMet())
+	expectNoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestOpenCluster(t *testing.T) {
+	cluster, err := OpenCluster(ClusterConfig{
+		Driver:      simpleMock,
+		PrimaryDSN:  "primary",
+		ReplicaDSNs: []string{"replica1", "replica2"},
+	})
+	expectNoError(t, err)
+	t.Cleanup(func() { _ = cluster.Close() })
+	expectTrue(t, cluster.Primary() != nil)
+	expectTrue(t, len(cluster.Replicas()) == 2)
+
+	_, err = OpenCluster(ClusterConfig{Driver: simpleMock + "unregistered"})
+	expectTrue(t, err != nil)
+}
@@ -0,0 +1,158 @@
+package sqlxkit
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/lib/pq"
+)
+
+// Sets of the constraint violations translated by TranslateError.
+var (
+	ErrDuplicate        = errors.New("sqlxkit: duplicate")             // a unique constraint is violated.
+	ErrFKViolation      = errors.New("sqlxkit: foreign key violation") // a foreign key constraint is violated.
+	ErrNotNullViolation = errors.New("sqlxkit: not null violation")    // a not-null constraint is violated.
+)
+
+// ConstraintError is a constraint violation translated from a driver error. It matches both the violation, e.g.
+// ErrDuplicate, and the driver error with errors.Is and errors.As.
+type ConstraintError struct {
+	Violation  error  // one of the violations, e.g. ErrDuplicate.
+	Constraint string // the name of the violated constraint, if reported by the driver.
+	Table      string // the table of the constraint, if reported by the driver.
+	Column     string // the column of the constraint, if reported by the driver.
+	Err        error  // the driver error.
+}
+
+// Error returns the message of the driver error.
+func (e *ConstraintError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the violation and the driver error.
+func (e *ConstraintError) Unwrap() []error { return []error{e.Violation, e.Err} }
+
+// ErrorTranslator translates the driver error into a ConstraintError, it returns nil if err isn't a constraint
+// violation of the driver.
+type ErrorTranslator func(err error) *ConstraintError
+
+var (
+	_translatorsMu sync.RWMutex
+	_translators   = []ErrorTranslator{PostgresErrorTranslator, MySQLErrorTranslator}
+)
+
+// RegisterErrorTranslator registers the translator of a driver, e.g. SQLite, in addition to the built-in ones of
+// PostgreSQL and MySQL. It is usually called in an init function.
+func RegisterErrorTranslator(t ErrorTranslator) {
+	_translatorsMu.Lock()
+	defer _translatorsMu.Unlock()
+	_translators = append(_translators, t)
+}
+
+// TranslateError translates the constraint violations of the driver errors anywhere in the chain of err into a
+// ConstraintError, so the business code checks errors.Is(err, sqlxkit.ErrDuplicate) instead of matching the driver
+// messages. The other errors, including nil, are returned as is.
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	_translatorsMu.RLock()
+	defer _translatorsMu.RUnlock()
+	for _, translate := range _translators {
+		if ce := translate(err); ce != nil {
+			return ce
+		}
+	}
+	return err
+}
+
+// sqlStater is implemented by the errors reporting the SQLSTATE code, e.g. the ones of pgx.
+type sqlStater interface{ SQLState() string }
+
+// PostgresErrorTranslator translates the errors of lib/pq and the ones reporting the SQLSTATE code, e.g. pgx.
+func PostgresErrorTranslator(err error) *ConstraintError {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		ce := newConstraintError(sqlStateViolation(string(pqErr.Code)), err)
+		if ce != nil {
+			ce.Constraint, ce.Table, ce.Column = pqErr.Constraint, pqErr.Table, pqErr.Column
+		}
+		return ce
+	}
+
+	var stater sqlStater
+	if errors.As(err, &stater) {
+		return newConstraintError(sqlStateViolation(stater.SQLState()), err)
+	}
+	return nil
+}
+
+// sqlStateViolation returns the violation of the SQLSTATE code, nil if it isn't one.
+func sqlStateViolation(code string) error {
+	switch code {
+	case "23505": // unique_violation.
+		return ErrDuplicate
+	case "23503": // foreign_key_violation.
+		return ErrFKViolation
+	case "23502": // not_null_violation.
+		return ErrNotNullViolation
+	default:
+		return nil
+	}
+}
+
+// MySQLErrorTranslator translates the errors of go-sql-driver/mysql by their error number, without depending on the
+// driver. The driver error is a *mysql.MySQLError, which reports the number in its Number field.
+func MySQLErrorTranslator(err error) *ConstraintError {
+	number, ok := mysqlErrorNumber(err)
+	if !ok {
+		return nil
+	}
+
+	var violation error
+	switch number {
+	case 1062: // ER_DUP_ENTRY.
+		violation = ErrDuplicate
+	case 1216, 1217, 1451, 1452: // ER_NO_REFERENCED_ROW, ER_ROW_IS_REFERENCED and their _2 variants.
+		violation = ErrFKViolation
+	case 1048, 1364: // ER_BAD_NULL_ERROR, ER_NO_DEFAULT_FOR_FIELD.
+		violation = ErrNotNullViolation
+	}
+	return newConstraintError(violation, err)
+}
+
+// mysqlErrorNumber finds the first *MySQLError in the chain of err and returns its number.
+func mysqlErrorNumber(err error) (uint64, bool) {
+	for err != nil {
+		v := reflect.ValueOf(err)
+		if v.Kind() == reflect.Pointer && !v.IsNil() && v.Elem().Kind() == reflect.Struct &&
+			v.Elem().Type().Name() == "MySQLError" {
+			if f := v.Elem().FieldByName("Number"); f.IsValid() && f.CanUint() {
+				return f.Uint(), true
+			}
+		}
+
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, e := range u.Unwrap() {
+				if number, ok := mysqlErrorNumber(e); ok {
+					return number, true
+				}
+			}
+			return 0, false
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// newConstraintError returns the ConstraintError of the violation, nil if there is no violation.
+func newConstraintError(violation, err error) *ConstraintError {
+	if violation == nil {
+		return nil
+	}
+	return &ConstraintError{Violation: violation, Err: err}
+}
@@ -0,0 +1,63 @@
+package sqlxkit
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sqlstate " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+// MySQLError mirrors the error of go-sql-driver/mysql, which is matched by its type name and number.
+type MySQLError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *MySQLError) Error() string { return fmt.Sprintf("Error %d: %s", e.Number, e.Message) }
+
+func TestTranslateError(t *testing.T) {
+	expectTrue(t, TranslateError(nil) == nil)
+	expectTrue(t, TranslateError(errExample) == errExample)
+
+	pqErr := &pq.Error{Code: "23505", Constraint: "users_email_key", Table: "users"}
+	err := TranslateError(fmt.Errorf("insert user: %w", pqErr))
+	expectTrue(t, errors.Is(err, ErrDuplicate))
+
+	var ce *ConstraintError
+	expectTrue(t, errors.As(err, &ce))
+	expectTrue(t, ce.Constraint == "users_email_key" && ce.Table == "users")
+
+	var driverErr *pq.Error
+	expectTrue(t, errors.As(err, &driverErr) && driverErr == pqErr)
+
+	expectTrue(t, errors.Is(TranslateError(&pq.Error{Code: "23503"}), ErrFKViolation))
+	expectTrue(t, errors.Is(TranslateError(&pq.Error{Code: "23502"}), ErrNotNullViolation))
+	expectTrue(t, !errors.As(TranslateError(&pq.Error{Code: "42P01"}), &ce))
+
+	expectTrue(t, errors.Is(TranslateError(sqlStateError("23505")), ErrDuplicate))
+
+	expectTrue(t, errors.Is(TranslateError(&MySQLError{Number: 1062}), ErrDuplicate))
+	expectTrue(t, errors.Is(TranslateError(&MySQLError{Number: 1452}), ErrFKViolation))
+	expectTrue(t, errors.Is(TranslateError(&MySQLError{Number: 1048}), ErrNotNullViolation))
+	expectTrue(t, !errors.Is(TranslateError(&MySQLError{Number: 1064}), ErrDuplicate))
+	expectTrue(t, errors.Is(TranslateError(fmt.Errorf("insert: %w", &MySQLError{Number: 1062})), ErrDuplicate))
+	expectTrue(t, errors.Is(TranslateError(errors.Join(errExample, &MySQLError{Number: 1062})), ErrDuplicate))
+}
+
+func TestRegisterErrorTranslator(t *testing.T) {
+	custom := errors.New("custom driver error")
+	RegisterErrorTranslator(func(err error) *ConstraintError {
+		if errors.Is(err, custom) {
+			return &ConstraintError{Violation: ErrDuplicate, Err: err}
+		}
+		return nil
+	})
+
+	expectTrue(t, errors.Is(TranslateError(custom), ErrDuplicate))
+}
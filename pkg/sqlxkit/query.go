@@ -0,0 +1,166 @@
+package sqlxkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrNotFound is returned by One when the query returns no rows. It wraps sql.ErrNoRows too, so both can be used
+// with errors.Is.
+var ErrNotFound = errors.New("sqlxkit: not found")
+
+// One queries a single row and scans it into T, either a struct whose fields are mapped by the struct tag, see
+// Config.StructTagName, or a scannable type, e.g. int, string, time.Time or sql.NullString. It returns ErrNotFound if
+// the query returns no rows.
+func One[T any](ctx context.Context, q Reader, query string, args ...any) (T, error) {
+	// the row is scanned with the context of the query, so it is released afterward.
+	ctx, cancel := queryContext(ctx, q)
+	defer cancel()
+
+	var dst T
+	if err := scanRow(q.QueryRowxContext(ctx, query, args...), &dst); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dst, fmt.Errorf("sqlxkit: One: %w: %w", ErrNotFound, err)
+		}
+		return dst, fmt.Errorf("sqlxkit: One: %w", err)
+	}
+	return dst, nil
+}
+
+// All queries the rows and scans each of them into T, see One for the supported types. It returns an empty slice if
+// the query returns no rows.
+func All[T any](ctx context.Context, q Reader, query string, args ...any) ([]T, error) {
+	// the rows are read with the context of the query, so it is released afterward.
+	ctx, cancel := queryContext(ctx, q)
+	defer cancel()
+
+	rows, err := q.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlxkit: All: %w", err)
+	}
+
+	res, err := scanRows[T](rows)
+	if err != nil {
+		return nil, fmt.Errorf("sqlxkit: All: %w", err)
+	}
+	return res, nil
+}
+
+// scanRow scans the row into dst by its type.
+func scanRow[T any](row *sqlx.Row, dst *T) error {
+	if scannable(reflect.TypeOf(dst).Elem()) {
+		return row.Scan(dst)
+	}
+	return row.StructScan(structTarget(dst))
+}
+
+// scanRows scans all rows into T by its type and closes the rows.
+func scanRows[T any](rows *sqlx.Rows) (_ []T, err error) {
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("close rows: %w", closeErr))
+		}
+	}()
+
+	var (
+		res  = make([]T, 0)
+		scan = func(dst *T) error { return rows.StructScan(structTarget(dst)) }
+	)
+	if scannable(reflect.TypeOf((*T)(nil)).Elem()) {
+		scan = func(dst *T) error { return rows.Scan(dst) }
+	}
+
+	for rows.Next() {
+		var dst T
+		if err := scan(&dst); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		res = append(res, dst)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+	return res, nil
+}
+
+// structTarget returns the struct dst points to, allocating it if T is a pointer to struct.
+func structTarget[T any](dst *T) any {
+	v := reflect.ValueOf(dst).Elem()
+	if v.Kind() != reflect.Pointer {
+		return dst
+	}
+
+	v.Set(reflect.New(v.Type().Elem()))
+	return v.Interface()
+}
+
+// _scannerType is the reflect type of sql.Scanner.
+var _scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// scannable tells whether the type is scanned as a single column, it follows sqlx: non-struct types, the
+// sql.Scanner implementations and the structs without exported fields, e.g. time.Time.
+func scannable(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(_scannerType) {
+		return true
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return false
+		}
+	}
+	return true
+}
+
+// NamedGet is the read counterpart of NamedExec, it binds the named parameters of the query with the fields of arg,
+// e.g. a struct or a map, and scans the single row into dst, see One. It returns ErrNotFound if the query returns no
+// rows.
+func NamedGet[T any](query string, arg any, dst *T) Atomic {
+	return func(ctx context.Context, tx Tx) (context.Context, error) {
+		bound, args, err := tx.BindNamed(query, arg)
+		if err != nil {
+			return ctx, fmt.Errorf("sqlxkit: NamedGet: bind named: %w", err)
+		}
+
+		res, err := One[T](ctx, tx, bound, args...)
+		if err != nil {
+			return ctx, fmt.Errorf("sqlxkit: NamedGet: %w", err)
+		}
+
+		*dst = res
+		return ctx, nil
+	}
+}
+
+// NamedQuery is the read counterpart of NamedExec, it binds the named parameters of the query with the fields of
+// arg, e.g. a struct or a map, and scans the rows into dst, see All.
+func NamedQuery[T any](query string, arg any, dst *[]T) Atomic {
+	return func(ctx context.Context, tx Tx) (context.Context, error) {
+		bound, args, err := tx.BindNamed(query, arg)
+		if err != nil {
+			return ctx, fmt.Errorf("sqlxkit: NamedQuery: bind named: %w", err)
+		}
+
+		res, err := All[T](ctx, tx, bound, args...)
+		if err != nil {
+			return ctx, fmt.Errorf("sqlxkit: NamedQuery: %w", err)
+		}
+
+		*dst = res
+		return ctx, nil
+	}
+}
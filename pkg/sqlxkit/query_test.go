@@ -0,0 +1,258 @@
+package sqlxkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type queryUser struct {
+	ID   int64  `sql:"id"`
+	Name string `sql:"name"`
+}
+
+func TestOne(t *testing.T) {
+	const query = "SELECT id, name FROM users WHERE id = ?"
+
+	t.Run("struct", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+		ApplyConfig(db)
+
+		mock.ExpectQuery(query).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "alice"))
+
+		user, err := One[queryUser](context.Background(), db, query, 1)
+		expectNoError(t, err)
+		expectTrue(t, user == queryUser{ID: 1, Name: "alice"})
+	})
+
+	t.Run("pointer to struct", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+		ApplyConfig(db)
+
+		mock.ExpectQuery(query).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "alice"))
+
+		user, err := One[*queryUser](context.Background(), db, query, 1)
+		expectNoError(t, err)
+		expectTrue(t, *user == queryUser{ID: 1, Name: "alice"})
+	})
+
+	t.Run("scalar", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		now := time.Now()
+		mock.ExpectQuery("SELECT count(*), now()").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+		mock.ExpectQuery("SELECT now()").
+			WillReturnRows(sqlmock.NewRows([]string{"now"}).AddRow(now))
+
+		count, err := One[int](context.Background(), db, "SELECT count(*), now()")
+		expectNoError(t, err)
+		expectTrue(t, count == 42)
+
+		got, err := One[time.Time](context.Background(), db, "SELECT now()")
+		expectNoError(t, err)
+		expectTrue(t, got.Equal(now))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		mock.ExpectQuery(query).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+		_, err := One[queryUser](context.Background(), db, query, 1)
+		expectTrue(t, errors.Is(err, ErrNotFound))
+		expectTrue(t, errors.Is(err, sql.ErrNoRows))
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		mock.ExpectQuery(query).WithArgs(1).WillReturnError(errExample)
+
+		_, err := One[queryUser](context.Background(), db, query, 1)
+		expectTrue(t, errors.Is(err, errExample))
+		expectTrue(t, !errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestAll(t *testing.T) {
+	const query = "SELECT id, name FROM users"
+
+	t.Run("struct", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+		ApplyConfig(db)
+
+		mock.ExpectQuery(query).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "alice").AddRow(2, "bob"))
+
+		users, err := All[queryUser](context.Background(), db, query)
+		expectNoError(t, err)
+		expectTrue(t, len(users) == 2)
+		expectTrue(t, users[0] == queryUser{ID: 1, Name: "alice"})
+		expectTrue(t, users[1] == queryUser{ID: 2, Name: "bob"})
+	})
+
+	t.Run("scalar", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		mock.ExpectQuery("SELECT name FROM users").
+			WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("alice").AddRow("bob"))
+
+		names, err := All[string](context.Background(), db, "SELECT name FROM users")
+		expectNoError(t, err)
+		expectTrue(t, len(names) == 2 && names[0] == "alice" && names[1] == "bob")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+		users, err := All[queryUser](context.Background(), db, query)
+		expectNoError(t, err)
+		expectTrue(t, users != nil && len(users) == 0)
+	})
+
+	t.Run("query failed", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		mock.ExpectQuery(query).WillReturnError(errExample)
+
+		_, err := All[queryUser](context.Background(), db, query)
+		expectTrue(t, errors.Is(err, errExample))
+	})
+
+	t.Run("iterate failed", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+		ApplyConfig(db)
+
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "alice").
+			RowError(0, errExample))
+
+		_, err := All[queryUser](context.Background(), db, query)
+		expectTrue(t, errors.Is(err, errExample))
+	})
+}
+
+func TestNamedGet(t *testing.T) {
+	const (
+		namedQuery = "SELECT id, name FROM users WHERE id = :id"
+		query      = "SELECT id, name FROM users WHERE id = ?"
+	)
+	arg := map[string]any{"id": 1}
+
+	t.Run("success", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+		ApplyConfig(db)
+
+		mock.ExpectQuery(query).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "alice"))
+
+		var user queryUser
+		_, err := NamedGet(namedQuery, arg, &user).Exec(context.Background(), db)
+		expectNoError(t, err)
+		expectTrue(t, user == queryUser{ID: 1, Name: "alice"})
+	})
+
+	t.Run("in transaction", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+		ApplyConfig(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(query).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "alice"))
+		mock.ExpectCommit()
+
+		var user queryUser
+		err := ExecTransaction(context.Background(), db, NamedGet(namedQuery, arg, &user))
+		expectNoError(t, err)
+		expectTrue(t, user.Name == "alice")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		mock.ExpectQuery(query).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+		var user queryUser
+		_, err := NamedGet(namedQuery, arg, &user).Exec(context.Background(), db)
+		expectTrue(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("bind failed", func(t *testing.T) {
+		db, _, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		var user queryUser
+		_, err := NamedGet(namedQuery, map[string]any{}, &user).Exec(context.Background(), db)
+		expectTrue(t, err != nil)
+	})
+}
+
+func TestNamedQuery(t *testing.T) {
+	const (
+		namedQuery = "SELECT id, name FROM users WHERE name LIKE :name"
+		query      = "SELECT id, name FROM users WHERE name LIKE ?"
+	)
+	arg := struct {
+		Name string `db:"name"`
+	}{Name: "a%"}
+
+	t.Run("success", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+		ApplyConfig(db)
+
+		mock.ExpectQuery(query).WithArgs("a%").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "alice").AddRow(3, "anna"))
+
+		var users []queryUser
+		_, err := NamedQuery(namedQuery, map[string]any{"name": "a%"}, &users).Exec(context.Background(), db)
+		expectNoError(t, err)
+		expectTrue(t, len(users) == 2)
+		expectTrue(t, users[1] == queryUser{ID: 3, Name: "anna"})
+	})
+
+	t.Run("struct arg", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		mock.ExpectQuery(query).WithArgs("a%").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+		var users []queryUser
+		_, err := NamedQuery(namedQuery, arg, &users).Exec(context.Background(), db)
+		expectNoError(t, err)
+		expectTrue(t, len(users) == 0)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		mock.ExpectQuery(query).WithArgs("a%").WillReturnError(errExample)
+
+		var users []queryUser
+		_, err := NamedQuery(namedQuery, map[string]any{"name": "a%"}, &users).Exec(context.Background(), db)
+		expectTrue(t, errors.Is(err, errExample))
+	})
+}
@@ -0,0 +1,221 @@
+package sqlxkit
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// instrumentedDB is implemented by the DB wrappers, e.g. WithQueryLog and WithTracing, for also instrumenting the
+// transactions begun by ExecTransaction, since BeginTxx returns the concrete *sqlx.Tx. The wrappers delegate to the
+// DB they wrap, so they can be stacked.
+type instrumentedDB interface {
+	// wrapTx wraps the transaction begun with ctx for instrumenting its queries.
+	wrapTx(ctx context.Context, tx Tx) Tx
+
+	// startTx is called before beginning the transaction, the returned function is called with the result of the
+	// whole transaction.
+	startTx(ctx context.Context, opts TxOptions) (context.Context, func(err error))
+}
+
+// wrapTx wraps the transaction with the instrumentation of the db, if any.
+func wrapTx(ctx context.Context, db DB, tx Tx) Tx {
+	if w, ok := db.(instrumentedDB); ok {
+		return w.wrapTx(ctx, tx)
+	}
+	return tx
+}
+
+// startTx starts the instrumentation of the transaction of the db, if any.
+func startTx(ctx context.Context, db DB, opts TxOptions) (context.Context, func(err error)) {
+	if w, ok := db.(instrumentedDB); ok {
+		return w.startTx(ctx, opts)
+	}
+	return ctx, func(error) {}
+}
+
+// QueryLogConfig is the configuration of WithQueryLog.
+type QueryLogConfig struct {
+	Logger        *slog.Logger  // the logger, default slog.Default.
+	Level         slog.Leveler  // the level of the queries, default slog.LevelDebug.
+	SlowThreshold time.Duration // the queries slower than this are logged at WARN, zero disables it.
+	LogArgs       bool          // logs the argument values, they may contain sensitive data, so only the count by default.
+}
+
+// withDefaults returns the config with the zero values replaced by the defaults.
+func (c QueryLogConfig) withDefaults() QueryLogConfig {
+	if c.Logger == nil {
+		c.Logger = slog.Default()
+	}
+	if c.Level == nil {
+		c.Level = slog.LevelDebug
+	}
+	return c
+}
+
+// queryLogger logs the queries.
+type queryLogger struct {
+	cfg QueryLogConfig
+}
+
+// log logs the query, rows is negative if unknown, e.g. for the queries returning rows.
+func (l *queryLogger) log(ctx context.Context, op, query string, args []any, rows int64, err error, start time.Time) {
+	elapsed := time.Since(start)
+
+	level := l.cfg.Level.Level()
+	switch {
+	case err != nil:
+		level = slog.LevelError
+	case l.cfg.SlowThreshold > 0 && elapsed >= l.cfg.SlowThreshold:
+		level = slog.LevelWarn
+	}
+
+	if !l.cfg.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("op", op),
+		slog.String("query", query),
+		slog.Duration("duration", elapsed),
+	}
+	if l.cfg.LogArgs {
+		attrs = append(attrs, slog.Any("args", args))
+	} else if args != nil {
+		attrs = append(attrs, slog.Int("args", len(args)))
+	}
+	if rows >= 0 {
+		attrs = append(attrs, slog.Int64("rows_affected", rows))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+
+	msg := "sql query"
+	if level == slog.LevelWarn {
+		msg = "sql slow query"
+	}
+	l.cfg.Logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// queryx logs QueryxContext.
+func (l *queryLogger) queryx(ctx context.Context, r Reader, query string, args []any) (*sqlx.Rows, error) {
+	start := time.Now()
+	rows, err := r.QueryxContext(ctx, query, args...)
+	l.log(ctx, "query", query, args, -1, err, start)
+	return rows, err
+}
+
+// queryRowx logs QueryRowxContext.
+func (l *queryLogger) queryRowx(ctx context.Context, r Reader, query string, args []any) *sqlx.Row {
+	start := time.Now()
+	row := r.QueryRowxContext(ctx, query, args...)
+	l.log(ctx, "query_row", query, args, -1, row.Err(), start)
+	return row
+}
+
+// exec logs ExecContext.
+func (l *queryLogger) exec(ctx context.Context, w Writer, query string, args []any) (sql.Result, error) {
+	start := time.Now()
+	res, err := w.ExecContext(ctx, query, args...)
+	l.log(ctx, "exec", query, args, rowsAffected(res, err), err, start)
+	return res, err
+}
+
+// namedExec logs NamedExecContext, the args are bound from the named arg, so they aren't logged.
+func (l *queryLogger) namedExec(ctx context.Context, w Writer, query string, arg any) (sql.Result, error) {
+	start := time.Now()
+	res, err := w.NamedExecContext(ctx, query, arg)
+	l.log(ctx, "named_exec", query, nil, rowsAffected(res, err), err, start)
+	return res, err
+}
+
+// rowsAffected returns the rows affected of the result, -1 if unknown.
+func rowsAffected(res sql.Result, err error) int64 {
+	if err != nil || res == nil {
+		return -1
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// WithQueryLog wraps the db for logging each query with its duration, the number of the args and the rows affected,
+// including the queries of the transactions begun by ExecTransaction. The queries of the prepared statements aren't
+// logged.
+func WithQueryLog(db DB, cfg QueryLogConfig) DB {
+	return &loggedDB{DB: db, log: &queryLogger{cfg: cfg.withDefaults()}}
+}
+
+// loggedDB is a DB that logs the queries.
+type loggedDB struct {
+	DB
+	log *queryLogger
+}
+
+func (d *loggedDB) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	return d.log.queryx(ctx, d.DB, query, args)
+}
+
+func (d *loggedDB) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	return d.log.queryRowx(ctx, d.DB, query, args)
+}
+
+func (d *loggedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return d.log.exec(ctx, d.DB, query, args)
+}
+
+func (d *loggedDB) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	return d.log.namedExec(ctx, d.DB, query, arg)
+}
+
+// wrapTx implements instrumentedDB, the inner wrappers are applied first.
+func (d *loggedDB) wrapTx(ctx context.Context, tx Tx) Tx {
+	return &loggedTx{Tx: wrapTx(ctx, d.DB, tx), log: d.log}
+}
+
+// startTx implements instrumentedDB, the transaction boundaries aren't logged, only its queries.
+func (d *loggedDB) startTx(ctx context.Context, opts TxOptions) (context.Context, func(err error)) {
+	return startTx(ctx, d.DB, opts)
+}
+
+// unwrapDB implements wrapperDB.
+func (d *loggedDB) unwrapDB() DB { return d.DB }
+
+// queryContext implements queryContexter, the timeout is the one of the inner wrappers.
+func (d *loggedDB) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return queryContext(ctx, d.DB)
+}
+
+// loggedTx is a Tx that logs the queries.
+type loggedTx struct {
+	Tx
+	log *queryLogger
+}
+
+func (t *loggedTx) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	return t.log.queryx(ctx, t.Tx, query, args)
+}
+
+func (t *loggedTx) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	return t.log.queryRowx(ctx, t.Tx, query, args)
+}
+
+func (t *loggedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return t.log.exec(ctx, t.Tx, query, args)
+}
+
+func (t *loggedTx) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	return t.log.namedExec(ctx, t.Tx, query, arg)
+}
+
+// queryContext implements queryContexter, the timeout is the one of the inner wrappers.
+func (t *loggedTx) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return queryContext(ctx, t.Tx)
+}
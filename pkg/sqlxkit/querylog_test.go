@@ -0,0 +1,116 @@
+package sqlxkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// logLines decodes the JSON log lines.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		expectNoError(t, json.Unmarshal([]byte(line), &m))
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestWithQueryLog(t *testing.T) {
+	sqlDB, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	db := WithQueryLog(sqlDB, QueryLogConfig{Logger: logger})
+
+	ctx := context.Background()
+	mock.ExpectQuery("SELECT name FROM users WHERE id = ?").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("alice"))
+	mock.ExpectExec("DELETE FROM users WHERE id = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET name = ?").WithArgs("bob").WillReturnError(errExample)
+
+	_, err := One[string](ctx, db, "SELECT name FROM users WHERE id = ?", 1)
+	expectNoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM users WHERE id = ?", 1)
+	expectNoError(t, err)
+	_, err = db.ExecContext(ctx, "UPDATE users SET name = ?", "bob")
+	expectTrue(t, err != nil)
+
+	lines := logLines(t, &buf)
+	expectTrue(t, len(lines) == 3)
+
+	expectTrue(t, lines[0]["level"] == "DEBUG")
+	expectTrue(t, lines[0]["op"] == "query_row")
+	expectTrue(t, lines[0]["args"] == float64(1))
+	_, hasRows := lines[0]["rows_affected"]
+	expectTrue(t, !hasRows)
+
+	expectTrue(t, lines[1]["op"] == "exec")
+	expectTrue(t, lines[1]["rows_affected"] == float64(1))
+	expectTrue(t, lines[1]["duration"] != nil)
+
+	expectTrue(t, lines[2]["level"] == "ERROR")
+	expectTrue(t, lines[2]["error"] == errExample.Error())
+	// the values aren't logged by default.
+	expectTrue(t, !strings.Contains(buf.String(), "bob"))
+}
+
+func TestWithQueryLog_SlowAndArgs(t *testing.T) {
+	sqlDB, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	db := WithQueryLog(sqlDB, QueryLogConfig{Logger: logger, SlowThreshold: 10 * time.Millisecond, LogArgs: true})
+
+	ctx := context.Background()
+	mock.ExpectExec("UPDATE users SET name = ?").WithArgs("bob").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET name = ?").WithArgs("carol").
+		WillDelayFor(20 * time.Millisecond).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err := db.ExecContext(ctx, "UPDATE users SET name = ?", "bob")
+	expectNoError(t, err)
+	_, err = db.ExecContext(ctx, "UPDATE users SET name = ?", "carol")
+	expectNoError(t, err)
+
+	// the fast query is below the logger level.
+	lines := logLines(t, &buf)
+	expectTrue(t, len(lines) == 1)
+	expectTrue(t, lines[0]["level"] == "WARN")
+	expectTrue(t, lines[0]["msg"] == "sql slow query")
+	expectTrue(t, strings.Contains(buf.String(), "carol"))
+}
+
+func TestWithQueryLog_Transaction(t *testing.T) {
+	sqlDB, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	db := WithQueryLog(sqlDB, QueryLogConfig{Logger: logger})
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users (name) VALUES (?)").WithArgs("alice").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := ExecTransaction(context.Background(), db, NamedExec("INSERT INTO users (name) VALUES (:name)", map[string]any{"name": "alice"}))
+	expectNoError(t, err)
+
+	lines := logLines(t, &buf)
+	expectTrue(t, len(lines) == 1)
+	expectTrue(t, lines[0]["op"] == "named_exec")
+	expectTrue(t, lines[0]["rows_affected"] == float64(1))
+}
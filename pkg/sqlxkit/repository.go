@@ -0,0 +1,107 @@
+package sqlxkit
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Repository is the basic CRUD of the entity T stored as a row of a table, so the simple entities don't need the
+// hand-written SQL. The columns are the "sql" tags of the fields of T, or the lowercased field names if untagged, see
+// UpsertQuery.
+//
+// The methods join the active transaction in the context if any, see TxOrDB, so they compose with ExecTransaction.
+// Write the SQL for anything else, e.g. the filters, the pagination or the joins.
+type Repository[T any] struct {
+	db       DB
+	table    string
+	key      string
+	insertQ  string
+	updateQ  string
+	deleteQ  string
+	getByIDQ string
+}
+
+// NewRepository creates a Repository of T stored in the table whose primary key is the key column. It fails if T isn't
+// a struct or the key isn't one of its columns.
+func NewRepository[T any](db DB, table, key string) (*Repository[T], error) {
+	var zero T
+	columns, binds := structColumns(reflect.TypeOf(zero))
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("sqlxkit: repository of %T: %w", zero, ErrNoColumns)
+	}
+
+	k := slices.Index(columns, key)
+	if k < 0 {
+		return nil, fmt.Errorf("sqlxkit: repository of %T: key %q isn't a column", zero, key)
+	}
+
+	sets := make([]string, 0, len(columns)-1)
+	for i, c := range columns {
+		if i != k {
+			sets = append(sets, c+" = :"+binds[i])
+		}
+	}
+
+	r := Repository[T]{
+		db:    db,
+		table: table,
+		key:   key,
+		insertQ: fmt.Sprintf("INSERT INTO %s (%s) VALUES (:%s)",
+			table, strings.Join(columns, ", "), strings.Join(binds, ", :")),
+		updateQ: fmt.Sprintf("UPDATE %s SET %s WHERE %s = :%s",
+			table, strings.Join(sets, ", "), key, binds[k]),
+		deleteQ:  db.Rebind(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", table, key)),
+		getByIDQ: db.Rebind(fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", strings.Join(columns, ", "), table, key)),
+	}
+	return &r, nil
+}
+
+// Insert inserts the entity, including its key, so generate the key before, e.g. with idkit.
+func (r *Repository[T]) Insert(ctx context.Context, entity T) error {
+	if _, err := TxOrDB(ctx, r.db).NamedExecContext(ctx, r.insertQ, entity); err != nil {
+		return fmt.Errorf("sqlxkit: insert into %s: %w", r.table, err)
+	}
+	return nil
+}
+
+// Update updates all the columns of the entity identified by its key. It returns ErrNotFound if there is no such
+// entity. MySQL reports no affected rows if nothing changes, set clientFoundRows=true in its DSN to avoid it.
+func (r *Repository[T]) Update(ctx context.Context, entity T) error {
+	res, err := TxOrDB(ctx, r.db).NamedExecContext(ctx, r.updateQ, entity)
+	if err != nil {
+		return fmt.Errorf("sqlxkit: update %s: %w", r.table, err)
+	}
+	return r.expectAffected(res.RowsAffected())
+}
+
+// Delete deletes the entity identified by the id. It returns ErrNotFound if there is no such entity.
+func (r *Repository[T]) Delete(ctx context.Context, id any) error {
+	res, err := TxOrDB(ctx, r.db).ExecContext(ctx, r.deleteQ, id)
+	if err != nil {
+		return fmt.Errorf("sqlxkit: delete from %s: %w", r.table, err)
+	}
+	return r.expectAffected(res.RowsAffected())
+}
+
+// GetByID gets the entity identified by the id. It returns ErrNotFound if there is no such entity.
+func (r *Repository[T]) GetByID(ctx context.Context, id any) (T, error) {
+	entity, err := One[T](ctx, TxOrDB(ctx, r.db), r.getByIDQ, id)
+	if err != nil {
+		return entity, fmt.Errorf("sqlxkit: get %s by %s: %w", r.table, r.key, err)
+	}
+	return entity, nil
+}
+
+// expectAffected returns ErrNotFound if no rows are affected.
+func (r *Repository[T]) expectAffected(n int64, err error) error {
+	if err != nil {
+		return fmt.Errorf("sqlxkit: get affected rows of %s: %w", r.table, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("sqlxkit: %s: %w", r.table, ErrNotFound)
+	}
+	return nil
+}
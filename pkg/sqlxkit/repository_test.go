@@ -0,0 +1,70 @@
+package sqlxkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestNewRepository(t *testing.T) {
+	db, _, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	_, err := NewRepository[int](db, "numbers", "id")
+	expectTrue(t, errors.Is(err, ErrNoColumns))
+
+	_, err = NewRepository[queryUser](db, "users", "uuid")
+	expectTrue(t, err != nil)
+}
+
+func TestRepository(t *testing.T) {
+	db, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+	ApplyConfig(db)
+
+	repo, err := NewRepository[queryUser](db, "users", "id")
+	expectNoError(t, err)
+
+	ctx := context.Background()
+	alice := queryUser{ID: 1, Name: "alice"}
+
+	mock.ExpectExec("INSERT INTO users (id, name) VALUES (?, ?)").WithArgs(1, "alice").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectNoError(t, repo.Insert(ctx, alice))
+
+	mock.ExpectQuery("SELECT id, name FROM users WHERE id = ?").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "alice"))
+	got, err := repo.GetByID(ctx, 1)
+	expectNoError(t, err)
+	expectTrue(t, got == alice)
+
+	mock.ExpectQuery("SELECT id, name FROM users WHERE id = ?").WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	_, err = repo.GetByID(ctx, 2)
+	expectTrue(t, errors.Is(err, ErrNotFound))
+
+	mock.ExpectExec("UPDATE users SET name = ? WHERE id = ?").WithArgs("bob", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectNoError(t, repo.Update(ctx, queryUser{ID: 1, Name: "bob"}))
+
+	mock.ExpectExec("UPDATE users SET name = ? WHERE id = ?").WithArgs("bob", 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectTrue(t, errors.Is(repo.Update(ctx, queryUser{ID: 2, Name: "bob"}), ErrNotFound))
+
+	mock.ExpectExec("DELETE FROM users WHERE id = ?").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectTrue(t, errors.Is(repo.Delete(ctx, 2), ErrNotFound))
+
+	// the operations join the active transaction.
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM users WHERE id = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+	err = ExecTransaction(ctx, db, func(ctx context.Context, _ Tx) (context.Context, error) {
+		if err := repo.Delete(ctx, 1); err != nil {
+			return ctx, err
+		}
+		return ctx, errExample
+	})
+	expectTrue(t, errors.Is(err, errExample))
+}
@@ -0,0 +1,69 @@
+package sqlxkit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RetryPolicy is the exponential backoff policy of OpenWithRetry.
+type RetryPolicy struct {
+	InitialInterval time.Duration // the wait before the second attempt. Default 500 milliseconds.
+	MaxInterval     time.Duration // the maximum wait between attempts. Default 10 seconds.
+	Multiplier      float64       // the growth of the wait after each attempt. Default 2.
+	MaxAttempts     int           // the maximum number of attempts, zero means until the context expires.
+
+	// OnRetry is called before waiting for the next attempt, e.g. for logging the database is still unreachable.
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+// withDefaults returns the policy with the zero values replaced by the defaults.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.InitialInterval <= 0 {
+		p.InitialInterval = 500 * time.Millisecond
+	}
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = 10 * time.Second
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = 2
+	}
+	if p.OnRetry == nil {
+		p.OnRetry = func(int, error, time.Duration) {}
+	}
+	return p
+}
+
+// OpenWithRetry opens the database connection and pings it with the exponential backoff until the database is
+// reachable, so the application doesn't crash at startup when the database is still booting. It gives up when the
+// context expires or the attempts are exhausted, returning the last ping error.
+func OpenWithRetry(ctx context.Context, driver, dsn string, policy RetryPolicy, options ...Option) (Conn, error) {
+	db, err := Open(driver, dsn, options...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlxkit: open: %w", err)
+	}
+
+	policy = policy.withDefaults()
+	wait := policy.InitialInterval
+	for attempt := 1; ; attempt++ {
+		err = db.PingContext(ctx)
+		if err == nil {
+			return db, nil
+		}
+
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			_ = db.Close()
+			return nil, fmt.Errorf("sqlxkit: ping: gave up after %d attempts: %w", attempt, err)
+		}
+
+		policy.OnRetry(attempt, err, wait)
+		select {
+		case <-ctx.Done():
+			_ = db.Close()
+			return nil, fmt.Errorf("sqlxkit: ping: %w: %w", ctx.Err(), err)
+		case <-time.After(wait):
+		}
+
+		wait = min(time.Duration(float64(wait)*policy.Multiplier), policy.MaxInterval)
+	}
+}
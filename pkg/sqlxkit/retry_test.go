@@ -0,0 +1,82 @@
+package sqlxkit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const flakyMock = "sqlxkit_test_flaky_mock"
+
+// _flakyDriver fails to connect until the number of failures reaches the DSN's.
+var _flakyDriver = &flakyDriver{}
+
+func init() {
+	sql.Register(flakyMock, _flakyDriver)
+}
+
+type flakyDriver struct {
+	attempts atomic.Int64
+}
+
+func (d *flakyDriver) Open(dsn string) (driver.Conn, error) {
+	fails, _ := time.ParseDuration(dsn) // e.g. "3ns" means three failures.
+	if d.attempts.Add(1) <= int64(fails) {
+		return nil, errExample
+	}
+	return &flakyConn{}, nil
+}
+
+type flakyConn struct{ driver.Conn }
+
+func (c *flakyConn) Close() error { return nil }
+
+func TestOpenWithRetry(t *testing.T) {
+	t.Run("reachable after retries", func(t *testing.T) {
+		_flakyDriver.attempts.Store(0)
+
+		var waits []time.Duration
+		policy := RetryPolicy{
+			InitialInterval: time.Millisecond,
+			MaxInterval:     3 * time.Millisecond,
+			OnRetry:         func(_ int, _ error, wait time.Duration) { waits = append(waits, wait) },
+		}
+
+		db, err := OpenWithRetry(context.Background(), flakyMock, "3ns", policy)
+		expectNoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+
+		expectTrue(t, len(waits) == 3)
+		expectTrue(t, waits[0] == time.Millisecond)
+		expectTrue(t, waits[1] == 2*time.Millisecond)
+		expectTrue(t, waits[2] == 3*time.Millisecond)
+	})
+
+	t.Run("max attempts", func(t *testing.T) {
+		_flakyDriver.attempts.Store(0)
+
+		policy := RetryPolicy{InitialInterval: time.Millisecond, MaxAttempts: 2}
+		_, err := OpenWithRetry(context.Background(), flakyMock, "3ns", policy)
+		expectTrue(t, errors.Is(err, errExample))
+		expectTrue(t, _flakyDriver.attempts.Load() == 2)
+	})
+
+	t.Run("context expired", func(t *testing.T) {
+		_flakyDriver.attempts.Store(0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := OpenWithRetry(ctx, flakyMock, "1h", RetryPolicy{InitialInterval: time.Millisecond})
+		expectTrue(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("unknown driver", func(t *testing.T) {
+		_, err := OpenWithRetry(context.Background(), flakyMock+"unregistered", "", RetryPolicy{})
+		expectTrue(t, err != nil)
+	})
+}
@@ -0,0 +1,234 @@
+package sqlxkit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrNoShardKey is returned by the Router when the context has no shard key.
+var ErrNoShardKey = errors.New("sqlxkit: no shard key")
+
+// shardKey is the context key for the shard key.
+type shardKey struct{}
+
+// WithShardKey stashes the shard key in the context, e.g. the tenant ID resolved by a middleware, for routing the
+// queries by the Router.
+func WithShardKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, shardKey{}, key)
+}
+
+// ShardKeyFromContext gets the shard key from the context.
+func ShardKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(shardKey{}).(string)
+	return key, ok
+}
+
+// ShardFunc chooses the index of the shard of the key among n shards.
+type ShardFunc func(key string, n int) int
+
+// HashShard is the default ShardFunc, it spreads the keys evenly by their FNV-1a hash. Adding a shard moves most of
+// the keys, so use a lookup table as the ShardFunc if the shards change.
+func HashShard(key string, n int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
+
+// Router is a Conn that routes every call to one of the shards by the shard key in the context, see WithShardKey, so
+// the repositories stay shard-agnostic. The calls without the shard key fail with ErrNoShardKey.
+//
+// A transaction stays on the shard it begins on, so a transaction across shards isn't possible.
+type Router struct {
+	shards []Conn
+	shard  ShardFunc
+}
+
+// NewRouter creates a Router of the opened shards, the shard is nil for HashShard. It panics if there is no shard.
+func NewRouter(shards []Conn, shard ShardFunc) *Router {
+	if len(shards) == 0 {
+		panic("sqlxkit: router has no shard")
+	}
+	if shard == nil {
+		shard = HashShard
+	}
+	return &Router{shards: shards, shard: shard}
+}
+
+// Shards returns the shard connections.
+func (r *Router) Shards() []Conn { return r.shards }
+
+// Shard returns the shard of the shard key in the context.
+func (r *Router) Shard(ctx context.Context) (Conn, error) {
+	key, ok := ShardKeyFromContext(ctx)
+	if !ok {
+		return nil, ErrNoShardKey
+	}
+
+	i := r.shard(key, len(r.shards))
+	if i < 0 || i >= len(r.shards) {
+		return nil, fmt.Errorf("sqlxkit: shard %d of key %q is out of range [0, %d)", i, key, len(r.shards))
+	}
+	return r.shards[i], nil
+}
+
+// QueryxContext implements Reader, it is routed by the shard key.
+func (r *Router) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	shard, err := r.Shard(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return shard.QueryxContext(ctx, query, args...)
+}
+
+// QueryRowxContext implements Reader, it is routed by the shard key.
+func (r *Router) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	shard, err := r.Shard(ctx)
+	if err != nil {
+		// the Row can't be created with an error, so it is failed by a db whose connections fail with the error.
+		return shardErrDB().QueryRowxContext(context.WithValue(ctx, shardErrKey{}, err), query, args...)
+	}
+	return shard.QueryRowxContext(ctx, query, args...)
+}
+
+// ExecContext implements Writer, it is routed by the shard key.
+func (r *Router) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	shard, err := r.Shard(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return shard.ExecContext(ctx, query, args...)
+}
+
+// NamedExecContext implements Writer, it is routed by the shard key.
+func (r *Router) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	shard, err := r.Shard(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return shard.NamedExecContext(ctx, query, arg)
+}
+
+// Rebind implements Binder, all shards are expected to share the driver.
+func (r *Router) Rebind(query string) string { return r.shards[0].Rebind(query) }
+
+// BindNamed implements Binder, all shards are expected to share the driver.
+func (r *Router) BindNamed(query string, arg any) (string, []any, error) {
+	return r.shards[0].BindNamed(query, arg)
+}
+
+// PreparexContext implements Preparer, it is routed by the shard key.
+func (r *Router) PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error) {
+	shard, err := r.Shard(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return shard.PreparexContext(ctx, query)
+}
+
+// PrepareNamedContext implements Preparer, it is routed by the shard key.
+func (r *Router) PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error) {
+	shard, err := r.Shard(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return shard.PrepareNamedContext(ctx, query)
+}
+
+// BeginTxx implements DB, the transaction is routed by the shard key.
+func (r *Router) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	shard, err := r.Shard(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return shard.BeginTxx(ctx, opts)
+}
+
+// wrapTx implements instrumentedDB, the transaction is instrumented like the queries of its shard.
+func (r *Router) wrapTx(ctx context.Context, tx Tx) Tx {
+	shard, err := r.Shard(ctx)
+	if err != nil {
+		return tx
+	}
+	return wrapTx(ctx, shard, tx)
+}
+
+// startTx implements instrumentedDB, the transaction is instrumented like the queries of its shard.
+func (r *Router) startTx(ctx context.Context, opts TxOptions) (context.Context, func(err error)) {
+	shard, err := r.Shard(ctx)
+	if err != nil {
+		return ctx, func(error) {}
+	}
+	return startTx(ctx, shard, opts)
+}
+
+// queryContext implements queryContexter, the timeout is the one of the shard.
+func (r *Router) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	shard, err := r.Shard(ctx)
+	if err != nil {
+		return ctx, func() {}
+	}
+	return queryContext(ctx, shard)
+}
+
+// Driver implements Conn, all shards are expected to share the driver.
+func (r *Router) Driver() driver.Driver { return r.shards[0].Driver() }
+
+// Conn implements Conn, it returns a connection of the shard of the shard key.
+func (r *Router) Conn(ctx context.Context) (*sql.Conn, error) {
+	shard, err := r.Shard(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return shard.Conn(ctx)
+}
+
+// PingContext implements Conn, it pings all shards.
+func (r *Router) PingContext(ctx context.Context) error {
+	var err error
+	for i, shard := range r.shards {
+		if pingErr := shard.PingContext(ctx); pingErr != nil {
+			err = errors.Join(err, fmt.Errorf("ping shards[%d]: %w", i, pingErr))
+		}
+	}
+	return err
+}
+
+// Close implements Conn, it closes all shards.
+func (r *Router) Close() error {
+	var err error
+	for _, shard := range r.shards {
+		err = errors.Join(err, shard.Close())
+	}
+	return err
+}
+
+// shardErrDB is the db whose connections fail with the error in the context by shardErrKey, without reaching any
+// database. It is opened once, since every opened db starts its own goroutine.
+var shardErrDB = sync.OnceValue(func() *sqlx.DB { return sqlx.NewDb(sql.OpenDB(shardErrConnector{}), "") })
+
+// shardErrKey is the context key for the error of choosing the shard.
+type shardErrKey struct{}
+
+// shardErrConnector is a driver.Connector whose connections fail with the error of choosing the shard.
+type shardErrConnector struct{}
+
+// Connect implements driver.Connector.
+func (c shardErrConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err, ok := ctx.Value(shardErrKey{}).(error); ok {
+		return nil, err
+	}
+	return nil, ErrNoShardKey
+}
+
+// Driver implements driver.Connector.
+func (c shardErrConnector) Driver() driver.Driver { return c }
+
+// Open implements driver.Driver.
+func (c shardErrConnector) Open(string) (driver.Conn, error) { return nil, ErrNoShardKey }
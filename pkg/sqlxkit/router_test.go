@@ -0,0 +1,106 @@
+package sqlxkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// Conn is satisfied by the Router, so it can be used wherever a single connection is.
+var _ Conn = (*Router)(nil)
+
+func TestRouter(t *testing.T) {
+	shard0, shard0Mock, teardown0 := Setup(t)
+	t.Cleanup(teardown0)
+	shard1, shard1Mock, teardown1 := Setup(t)
+	t.Cleanup(teardown1)
+
+	tenants := map[string]int{"acme": 0, "globex": 1}
+	router := NewRouter([]Conn{shard0, shard1}, func(key string, _ int) int { return tenants[key] })
+
+	acme := WithShardKey(context.Background(), "acme")
+	globex := WithShardKey(context.Background(), "globex")
+
+	shard0Mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(0))
+	shard1Mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+
+	n, err := One[int](acme, router, "SELECT 1")
+	expectNoError(t, err)
+	expectTrue(t, n == 0)
+
+	n, err = One[int](globex, router, "SELECT 1")
+	expectNoError(t, err)
+	expectTrue(t, n == 1)
+
+	// the transactions stay on the shard.
+	shard1Mock.ExpectBegin()
+	shard1Mock.ExpectExec("DELETE FROM foo").WillReturnResult(sqlmock.NewResult(0, 1))
+	shard1Mock.ExpectCommit()
+
+	err = ExecTransaction(globex, router, func(ctx context.Context, tx Tx) (context.Context, error) {
+		_, err := tx.ExecContext(ctx, "DELETE FROM foo")
+		return ctx, err
+	})
+	expectNoError(t, err)
+}
+
+func TestRouter_NoShardKey(t *testing.T) {
+	shard, _, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	router := NewRouter([]Conn{shard}, nil)
+	ctx := context.Background()
+
+	_, err := router.ExecContext(ctx, "DELETE FROM foo")
+	expectTrue(t, errors.Is(err, ErrNoShardKey))
+
+	err = ExecTransaction(ctx, router, NoopTransaction)
+	expectTrue(t, errors.Is(err, ErrNoShardKey))
+
+	_, err = One[int](ctx, router, "SELECT 1")
+	expectTrue(t, errors.Is(err, ErrNoShardKey))
+
+	err = router.QueryRowxContext(ctx, "SELECT 1").Err()
+	expectTrue(t, errors.Is(err, ErrNoShardKey))
+}
+
+func TestRouter_InstrumentedShard(t *testing.T) {
+	sqlDB, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	shard := &timeoutConn{DB: sqlDB, db: &timeoutDB{DB: sqlDB, t: queryTimeout(time.Minute)}}
+	router := NewRouter([]Conn{shard}, nil)
+	ctx := WithShardKey(context.Background(), "acme")
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	// the transaction is instrumented like the queries of its shard.
+	err := ExecTransaction(ctx, router, func(ctx context.Context, tx Tx) (context.Context, error) {
+		_, ok := tx.(*timeoutTx)
+		expectTrue(t, ok)
+		return ctx, nil
+	})
+	expectNoError(t, err)
+	expectNoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRouter_OutOfRange(t *testing.T) {
+	shard, _, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	router := NewRouter([]Conn{shard}, func(string, int) int { return 1 })
+	_, err := router.Shard(WithShardKey(context.Background(), "acme"))
+	expectTrue(t, err != nil)
+}
+
+func TestHashShard(t *testing.T) {
+	for _, key := range []string{"", "acme", "globex", "initech"} {
+		i := HashShard(key, 3)
+		expectTrue(t, i >= 0 && i < 3)
+		expectTrue(t, HashShard(key, 3) == i)
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/jmoiron/sqlx/reflectx"
@@ -19,6 +22,9 @@ type Config struct {
 	MaxOpenConnections int
 	MaxIdleConnections int
 	StructTagName      string // default: sql
+
+	// QueryTimeout limits the duration of each query without a deadline, see WithQueryTimeout. Zero means no timeout.
+	QueryTimeout time.Duration
 }
 
 // Option is function to customize Config.
@@ -121,21 +127,36 @@ func Open(driver, dsn string, options ...Option) (Conn, error) {
 	if err != nil {
 		return nil, err
 	}
-	ApplyConfig(db, options...)
+
+	cfg := newConfig(options...)
+	applyConfig(db, cfg)
+	if cfg.QueryTimeout > 0 {
+		return &timeoutConn{DB: db, db: &timeoutDB{DB: db, t: queryTimeout(cfg.QueryTimeout)}}, nil
+	}
 	return db, nil
 }
 
 // ApplyConfig applies given options to db.
 // This function is useful when you want to apply options to existing db.
 // For example, using mock in test but want the same config as production.
+// The QueryTimeout isn't applied since it needs wrapping the db, see WithQueryTimeout.
 func ApplyConfig(db *sqlx.DB, options ...Option) *sqlx.DB {
+	return applyConfig(db, newConfig(options...))
+}
+
+// newConfig returns the default Config overridden by the options.
+func newConfig(options ...Option) Config {
 	var cfg Config
 	DefaultOption().apply(&cfg)
 	// override default config.
 	for _, opt := range options {
 		opt.apply(&cfg)
 	}
+	return cfg
+}
 
+// applyConfig applies the cfg to db.
+func applyConfig(db *sqlx.DB, cfg Config) *sqlx.DB {
 	db.SetMaxIdleConns(cfg.MaxIdleConnections)
 	db.SetMaxOpenConns(cfg.MaxOpenConnections)
 	db.Mapper = reflectx.NewMapperFunc(cfg.StructTagName, strings.ToLower)
@@ -161,14 +182,80 @@ func NoopTransaction(ctx context.Context, _ Tx) (context.Context, error) { retur
 // If one of the transaction cause error, the next transactions will not be executed and all transactions will be
 // rolling back. Otherwise, all transactions will be committed.
 func ExecTransaction(ctx context.Context, db DB, transactions ...Atomic) error {
-	tx, err := db.BeginTxx(ctx, nil)
+	return ExecTransactionWith(ctx, db, TxOptions{}, transactions...)
+}
+
+// TxOptions holds the options of the transaction executed by ExecTransactionWith.
+type TxOptions struct {
+	// Isolation is the isolation level of the transaction, the default is the driver's default, usually
+	// sql.LevelReadCommitted. The driver returns an error if the level isn't supported.
+	Isolation sql.IsolationLevel
+
+	// ReadOnly makes the transaction read-only, so the writes are rejected by the database.
+	ReadOnly bool
+
+	// Timeout limits the duration of the whole transaction, it is rolled back if exceeded. Zero means no timeout
+	// other than the deadline of the context.
+	Timeout time.Duration
+}
+
+// sqlOptions returns the sql.TxOptions, nil if the defaults are used.
+func (o TxOptions) sqlOptions() *sql.TxOptions {
+	if o.Isolation == sql.LevelDefault && !o.ReadOnly {
+		return nil
+	}
+	return &sql.TxOptions{Isolation: o.Isolation, ReadOnly: o.ReadOnly}
+}
+
+// ExecTransactionWith is ExecTransaction with the transaction options, e.g. for serializable or read-only
+// transactions.
+//
+// The transaction is stashed in the context passed to the transactions, see TxOrDB. If the context already carries a
+// transaction, the transactions join it instead of beginning a new one, and the outer transaction commits or rolls
+// back all of them. Only the transaction begun by ExecTransaction on the same db is joined, and only if it satisfies
+// the isolation and the read-only of the options, otherwise ErrTxMismatch is returned. The timeout of the options
+// limits the joined transactions. The transactions may register the commit hooks, see OnBeforeCommit and
+// OnAfterCommit.
+func ExecTransactionWith(ctx context.Context, db DB, opts TxOptions, transactions ...Atomic) (err error) {
+	if tx, ok := TxFromContext(ctx); ok {
+		if err = joinable(ctx, db, opts); err != nil {
+			return err
+		}
+		if opts.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+			defer cancel()
+		}
+		for i := 0; i < len(transactions); i++ {
+			if ctx, err = transactions[i].Exec(ctx, tx); err != nil {
+				return fmt.Errorf("evaluating joined transactions[%d]: %w", i, err)
+			}
+		}
+		return nil
+	}
+
+	ctx, end := startTx(ctx, db, opts)
+	defer func() { end(err) }()
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	tx, err := db.BeginTxx(ctx, opts.sqlOptions())
 	if err != nil {
 		return fmt.Errorf("begin transaction: %w", err)
 	}
 
+	// the queries of the transaction are instrumented like the db's, e.g. by WithQueryLog.
+	atx := wrapTx(ctx, db, tx)
+	hooks := new(txHooks)
+	ctx = context.WithValue(WithTx(ctx, atx), txHooksKey{}, hooks)
+	ctx = context.WithValue(ctx, txOwnerKey{}, txOwner{db: db, opts: opts})
 	for i := 0; i < len(transactions); i++ {
 		// don't use `:=`, because we need to replace ctx with the returned ctx to next calls.
-		ctx, err = transactions[i].Exec(ctx, tx)
+		ctx, err = transactions[i].Exec(ctx, atx)
 		// if one of transaction cause error, it should be rollback.
 		if err != nil {
 			if rollbackErr := tx.Rollback(); rollbackErr != nil {
@@ -181,13 +268,209 @@ func ExecTransaction(ctx context.Context, db DB, transactions ...Atomic) error {
 		}
 	}
 
+	if err = hooks.runBeforeCommit(ctx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Join(err, fmt.Errorf("rolling back transaction failed with error: %w", rollbackErr))
+		}
+		return err
+	}
+
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("committing transaction: %w", err)
 	}
 
+	hooks.runAfterCommit(ctx)
+	return nil
+}
+
+// ErrTxMismatch is returned by ExecTransactionWith when the active transaction in the context can't be joined.
+var ErrTxMismatch = errors.New("sqlxkit: transaction mismatch")
+
+// txOwnerKey is the context key for the owner of the active transaction begun by ExecTransactionWith.
+type txOwnerKey struct{}
+
+// txOwner is the db and the options the active transaction is begun with.
+type txOwner struct {
+	db   DB
+	opts TxOptions
+}
+
+// joinable checks whether the active transaction in the context can be joined by the transactions of the db with
+// the options.
+func joinable(ctx context.Context, db DB, opts TxOptions) error {
+	owner, ok := ctx.Value(txOwnerKey{}).(txOwner)
+	if !ok {
+		return fmt.Errorf("%w: the active transaction isn't begun by ExecTransaction", ErrTxMismatch)
+	}
+	if !sameDB(owner.db, db) {
+		return fmt.Errorf("%w: the active transaction is begun on another db", ErrTxMismatch)
+	}
+	if opts.Isolation != sql.LevelDefault && opts.Isolation != owner.opts.Isolation {
+		return fmt.Errorf("%w: the active transaction is %s, not %s", ErrTxMismatch, owner.opts.Isolation, opts.Isolation)
+	}
+	if opts.ReadOnly && !owner.opts.ReadOnly {
+		return fmt.Errorf("%w: the active transaction isn't read-only", ErrTxMismatch)
+	}
+	return nil
+}
+
+// sameDB reports whether a and b are the same db, the wrappers, e.g. WithQueryLog, are unwrapped since they share
+// the connections of the db they wrap.
+func sameDB(a, b DB) bool {
+	a, b = unwrapDB(a), unwrapDB(b)
+	if a == nil || b == nil {
+		return a == b
+	}
+	// the comparison of the uncomparable dbs panics.
+	if ta, tb := reflect.TypeOf(a), reflect.TypeOf(b); ta != tb || !ta.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// wrapperDB is implemented by the DB wrappers, it returns the DB they wrap.
+type wrapperDB interface {
+	unwrapDB() DB
+}
+
+// unwrapDB returns the innermost db of the wrappers.
+func unwrapDB(db DB) DB {
+	for {
+		w, ok := db.(wrapperDB)
+		if !ok {
+			return db
+		}
+		db = w.unwrapDB()
+	}
+}
+
+// txHooksKey is the context key for the commit hooks of the active transaction.
+type txHooksKey struct{}
+
+// txHooks holds the commit hooks registered by OnBeforeCommit and OnAfterCommit.
+type txHooks struct {
+	mu     sync.Mutex
+	before []func(ctx context.Context) error
+	after  []func(ctx context.Context)
+}
+
+// runBeforeCommit runs the before-commit hooks in order, stopping at the first error.
+func (h *txHooks) runBeforeCommit(ctx context.Context) error {
+	// the hooks may register other hooks, e.g. a validation that publishes an event, so don't hold the lock.
+	for i := 0; ; i++ {
+		h.mu.Lock()
+		if i >= len(h.before) {
+			h.mu.Unlock()
+			return nil
+		}
+		fn := h.before[i]
+		h.mu.Unlock()
+
+		if err := fn(ctx); err != nil {
+			return fmt.Errorf("before commit hooks[%d]: %w", i, err)
+		}
+	}
+}
+
+// runAfterCommit runs the after-commit hooks in order, with the context detached from the committed transaction.
+func (h *txHooks) runAfterCommit(ctx context.Context) {
+	ctx = context.WithValue(context.WithValue(ctx, txKey{}, nil), txHooksKey{}, nil)
+	ctx = context.WithValue(ctx, txOwnerKey{}, nil)
+
+	h.mu.Lock()
+	after := h.after
+	h.mu.Unlock()
+	for _, fn := range after {
+		fn(ctx)
+	}
+}
+
+// OnBeforeCommit registers fn to run just before the active transaction in the context commits, e.g. for a final
+// validation of the changes made by the whole chain of Atomic. The transaction is rolled back if fn fails. If the
+// context carries no transaction, fn runs immediately.
+func OnBeforeCommit(ctx context.Context, fn func(ctx context.Context) error) error {
+	hooks, ok := ctx.Value(txHooksKey{}).(*txHooks)
+	if !ok || hooks == nil {
+		return fn(ctx)
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.before = append(hooks.before, fn)
 	return nil
 }
 
+// OnAfterCommit registers fn to run after the active transaction in the context commits successfully, e.g. for
+// publishing the events or invalidating the cache, so they aren't invoked for the rolled back changes. The context
+// passed to fn carries no transaction. If the context carries no transaction, fn runs immediately.
+func OnAfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	hooks, ok := ctx.Value(txHooksKey{}).(*txHooks)
+	if !ok || hooks == nil {
+		fn(ctx)
+		return
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.after = append(hooks.after, fn)
+}
+
+// BeforeCommit is OnBeforeCommit as an Atomic, for registering the hook in the chain of ExecTransaction.
+func BeforeCommit(fn func(ctx context.Context) error) Atomic {
+	return func(ctx context.Context, _ Tx) (context.Context, error) {
+		return ctx, OnBeforeCommit(ctx, fn)
+	}
+}
+
+// AfterCommit is OnAfterCommit as an Atomic, for registering the hook in the chain of ExecTransaction:
+//
+//	sqlxkit.ExecTransaction(ctx, db,
+//		sqlxkit.NamedExec(insertOrder, order),
+//		sqlxkit.AfterCommit(func(ctx context.Context) { events.Publish(ctx, OrderCreated{order.ID}) }),
+//	)
+func AfterCommit(fn func(ctx context.Context)) Atomic {
+	return func(ctx context.Context, _ Tx) (context.Context, error) {
+		OnAfterCommit(ctx, fn)
+		return ctx, nil
+	}
+}
+
+// txKey is the context key for the active transaction.
+type txKey struct{}
+
+// WithTx stashes the active transaction in the context, so the repositories called by the service layer join it
+// without threading the Tx through every function signature. ExecTransaction does it for its transactions.
+func WithTx(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext gets the active transaction from the context.
+func TxFromContext(ctx context.Context) (Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(Tx)
+	return tx, ok
+}
+
+// TxOrDB returns the active transaction in the context if any, otherwise the db. The repositories use it for
+// querying in the ongoing transaction of the caller, or directly if there is none:
+//
+//	func (r *Repo) Create(ctx context.Context, u User) error {
+//		_, err := sqlxkit.TxOrDB(ctx, r.db).NamedExecContext(ctx, insertUser, u)
+//		return err
+//	}
+//
+// The transaction begun by ExecTransaction on another db is ignored, so the repository of a db never queries in the
+// transaction of another db, e.g. of another shard of the Router.
+func TxOrDB(ctx context.Context, db DB) Tx {
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		return db
+	}
+	if owner, ok := ctx.Value(txOwnerKey{}).(txOwner); ok && !sameDB(owner.db, db) {
+		return db
+	}
+	return tx
+}
+
 // ErrUnexpectedAffectedRows is an error that is returned when the affected rows
 // is not equal to the expected.
 var ErrUnexpectedAffectedRows = errors.New("unexpected affected rows")
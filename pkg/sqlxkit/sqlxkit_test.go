@@ -6,6 +6,7 @@ import (
 	"database/sql/driver"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
@@ -302,3 +303,239 @@ func Setup(t *testing.T, matchers ...sqlmock.QueryMatcher) (*sqlx.DB, sqlmock.Sq
 	}
 	return dbx, mock, teardown
 }
+
+func TestExecTransactionWith(t *testing.T) {
+	t.Run("options", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		opts := TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}
+		err := ExecTransactionWith(context.Background(), db, opts, NoopTransaction)
+		expectNoError(t, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		var deadline bool
+		wait := func(ctx context.Context, tx Tx) (context.Context, error) {
+			_, deadline = ctx.Deadline()
+			<-ctx.Done()
+			return ctx, ctx.Err()
+		}
+
+		err := ExecTransactionWith(context.Background(), db, TxOptions{Timeout: 10 * time.Millisecond}, wait)
+		expectTrue(t, deadline)
+		expectTrue(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("sql options", func(t *testing.T) {
+		expectTrue(t, TxOptions{Timeout: time.Second}.sqlOptions() == nil)
+
+		opts := TxOptions{Isolation: sql.LevelSerializable}.sqlOptions()
+		expectTrue(t, opts != nil && opts.Isolation == sql.LevelSerializable && !opts.ReadOnly)
+
+		opts = TxOptions{ReadOnly: true}.sqlOptions()
+		expectTrue(t, opts != nil && opts.ReadOnly)
+	})
+}
+
+func TestTxOrDB(t *testing.T) {
+	db, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	ctx := context.Background()
+	_, ok := TxFromContext(ctx)
+	expectTrue(t, !ok)
+	expectTrue(t, TxOrDB(ctx, db) == Tx(db))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM foo").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	var joined Tx
+	repo := func(ctx context.Context, _ Tx) (context.Context, error) {
+		// the repository doesn't receive the tx explicitly.
+		joined = TxOrDB(ctx, db)
+		_, err := joined.ExecContext(ctx, "DELETE FROM foo")
+		return ctx, err
+	}
+
+	err := ExecTransaction(ctx, db, repo)
+	expectNoError(t, err)
+	_, isTx := joined.(*sqlx.Tx)
+	expectTrue(t, isTx)
+}
+
+func TestTxOrDB_OtherDB(t *testing.T) {
+	db, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+	other, otherMock, teardownOther := Setup(t)
+	t.Cleanup(teardownOther)
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	otherMock.ExpectExec("DELETE FROM bar").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var sameTx, otherTx Tx
+	repo := func(ctx context.Context, _ Tx) (context.Context, error) {
+		sameTx = TxOrDB(ctx, WithQueryTimeout(db, time.Minute))
+		// the repository of the other db doesn't query in the transaction of db.
+		otherTx = TxOrDB(ctx, other)
+		_, err := otherTx.ExecContext(ctx, "DELETE FROM bar")
+		return ctx, err
+	}
+
+	expectNoError(t, ExecTransaction(context.Background(), db, repo))
+	_, isTx := sameTx.(*sqlx.Tx)
+	expectTrue(t, isTx)
+	expectTrue(t, otherTx == Tx(other))
+	expectNoError(t, otherMock.ExpectationsWereMet())
+}
+
+func TestExecTransaction_Join(t *testing.T) {
+	db, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	// a single transaction, the inner one joins the outer.
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM foo").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	var outerTx, innerTx Tx
+	inner := func(ctx context.Context, tx Tx) (context.Context, error) {
+		innerTx = tx
+		_, err := tx.ExecContext(ctx, "DELETE FROM foo")
+		return ctx, err
+	}
+	outer := func(ctx context.Context, tx Tx) (context.Context, error) {
+		outerTx = tx
+		if err := ExecTransaction(ctx, db, inner); err != nil {
+			return ctx, err
+		}
+		return ctx, errExample
+	}
+
+	err := ExecTransaction(context.Background(), db, outer)
+	expectTrue(t, errors.Is(err, errExample))
+	expectTrue(t, outerTx == innerTx)
+}
+
+func TestExecTransaction_JoinMismatch(t *testing.T) {
+	db, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+	other, _, teardownOther := Setup(t)
+	t.Cleanup(teardownOther)
+
+	tests := []struct {
+		name string
+		db   DB
+		opts TxOptions
+		want error
+	}{
+		{name: "wrapped db", db: WithQueryTimeout(db, time.Minute), opts: TxOptions{Timeout: time.Minute}},
+		{name: "same isolation", db: db, opts: TxOptions{Isolation: sql.LevelRepeatableRead}},
+		{name: "other db", db: other, want: ErrTxMismatch},
+		{name: "other isolation", db: db, opts: TxOptions{Isolation: sql.LevelSerializable}, want: ErrTxMismatch},
+		{name: "read-only", db: db, opts: TxOptions{ReadOnly: true}, want: ErrTxMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectCommit()
+
+			var joinErr error
+			outer := func(ctx context.Context, _ Tx) (context.Context, error) {
+				joinErr = ExecTransactionWith(ctx, tt.db, tt.opts, NoopTransaction)
+				return ctx, nil
+			}
+			err := ExecTransactionWith(context.Background(), db, TxOptions{Isolation: sql.LevelRepeatableRead}, outer)
+			expectNoError(t, err)
+			expectTrue(t, errors.Is(joinErr, tt.want))
+		})
+	}
+
+	// the transaction stashed by WithTx alone isn't begun by ExecTransaction.
+	err := ExecTransaction(WithTx(context.Background(), db), db, NoopTransaction)
+	expectTrue(t, errors.Is(err, ErrTxMismatch))
+}
+
+func TestExecTransaction_CommitHooks(t *testing.T) {
+	t.Run("committed", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		var calls []string
+		var afterHasTx bool
+		inner := func(ctx context.Context, tx Tx) (context.Context, error) {
+			OnAfterCommit(ctx, func(ctx context.Context) {
+				_, afterHasTx = TxFromContext(ctx)
+				calls = append(calls, "inner after")
+			})
+			return ctx, nil
+		}
+		outer := func(ctx context.Context, tx Tx) (context.Context, error) {
+			return ctx, ExecTransaction(ctx, db, inner)
+		}
+
+		err := ExecTransaction(context.Background(), db,
+			AfterCommit(func(context.Context) { calls = append(calls, "after") }),
+			BeforeCommit(func(context.Context) error { calls = append(calls, "before"); return nil }),
+			outer,
+		)
+		expectNoError(t, err)
+		expectTrue(t, len(calls) == 3)
+		expectTrue(t, calls[0] == "before" && calls[1] == "after" && calls[2] == "inner after")
+		expectTrue(t, !afterHasTx)
+	})
+
+	t.Run("before commit failed", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		var afterCalled bool
+		err := ExecTransaction(context.Background(), db,
+			AfterCommit(func(context.Context) { afterCalled = true }),
+			BeforeCommit(func(context.Context) error { return errExample }),
+		)
+		expectTrue(t, errors.Is(err, errExample))
+		expectTrue(t, !afterCalled)
+	})
+
+	t.Run("rolled back", func(t *testing.T) {
+		db, mock, teardown := Setup(t)
+		t.Cleanup(teardown)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		var hookCalled bool
+		err := ExecTransaction(context.Background(), db,
+			AfterCommit(func(context.Context) { hookCalled = true }),
+			BeforeCommit(func(context.Context) error { hookCalled = true; return nil }),
+			func(ctx context.Context, _ Tx) (context.Context, error) { return ctx, errExample },
+		)
+		expectTrue(t, errors.Is(err, errExample))
+		expectTrue(t, !hookCalled)
+	})
+
+	t.Run("no transaction", func(t *testing.T) {
+		var called bool
+		OnAfterCommit(context.Background(), func(context.Context) { called = true })
+		expectTrue(t, called)
+		expectTrue(t, errors.Is(OnBeforeCommit(context.Background(), func(context.Context) error { return errExample }), errExample))
+	})
+}
@@ -0,0 +1,6 @@
+// Package sqlxkittest provides an in-memory SQLite database for the repository tests, so they run the real queries
+// instead of depending on the sqlmock expectations. It requires cgo, see github.com/mattn/go-sqlite3.
+//
+// SQLite doesn't support every feature of PostgreSQL, so keep the queries and the migrations under test portable, or
+// test the rest against a real PostgreSQL.
+package sqlxkittest
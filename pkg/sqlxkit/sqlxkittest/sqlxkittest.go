@@ -0,0 +1,90 @@
+//go:build cgo
+
+package sqlxkittest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/josestg/swe-be-mono/pkg/migratekit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Driver is the sql driver name of SQLite.
+const Driver = "sqlite3"
+
+func init() { sqlxkit.RegisterErrorTranslator(SQLiteErrorTranslator) }
+
+// _seq makes the database names unique, so the parallel tests don't share a database.
+var _seq atomic.Uint64
+
+// Setup opens a fresh in-memory SQLite database, applies the migrations in fsys if not nil, and runs the seeds. The
+// test fails immediately if any of them fails. The returned teardown closes the database, which drops it.
+//
+//	func TestUserRepo(t *testing.T) {
+//		db, teardown := sqlxkittest.Setup(t, migrations, fixtures...)
+//		t.Cleanup(teardown)
+//		...
+//	}
+func Setup(tb testing.TB, migrations fs.FS, seeds ...migratekit.Seed) (sqlxkit.Conn, func()) {
+	tb.Helper()
+
+	// the shared cache keeps the database alive across the connections of the pool until the last one is closed.
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(tb.Name())
+	dsn := fmt.Sprintf("file:%s_%d?mode=memory&cache=shared&_foreign_keys=on", name, _seq.Add(1))
+	db, err := sqlxkit.Open(Driver, dsn)
+	if err != nil {
+		tb.Fatalf("sqlxkittest: open database: %v", err)
+	}
+
+	teardown := func() {
+		if err := db.Close(); err != nil {
+			tb.Errorf("sqlxkittest: close database: %v", err)
+		}
+	}
+
+	if migrations == nil {
+		migrations = emptyFS{}
+	}
+
+	if err := migratekit.Prepare(context.Background(), db, migrations, seeds...); err != nil {
+		teardown()
+		tb.Fatalf("sqlxkittest: prepare database: %v", err)
+	}
+	return db, teardown
+}
+
+// emptyFS is a file system without files, for Setup without migrations.
+type emptyFS struct{}
+
+func (emptyFS) Open(string) (fs.File, error) { return nil, fs.ErrNotExist }
+
+func (emptyFS) ReadDir(string) ([]fs.DirEntry, error) { return nil, nil }
+
+// SQLiteErrorTranslator translates the constraint violations of SQLite, see sqlxkit.TranslateError. It is registered
+// by importing this package.
+func SQLiteErrorTranslator(err error) *sqlxkit.ConstraintError {
+	var liteErr sqlite3.Error
+	if !errors.As(err, &liteErr) {
+		return nil
+	}
+
+	var violation error
+	switch liteErr.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		violation = sqlxkit.ErrDuplicate
+	case sqlite3.ErrConstraintForeignKey:
+		violation = sqlxkit.ErrFKViolation
+	case sqlite3.ErrConstraintNotNull:
+		violation = sqlxkit.ErrNotNullViolation
+	default:
+		return nil
+	}
+	return &sqlxkit.ConstraintError{Violation: violation, Err: err}
+}
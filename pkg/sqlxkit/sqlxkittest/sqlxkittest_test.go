@@ -0,0 +1,81 @@
+//go:build cgo
+
+package sqlxkittest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/josestg/swe-be-mono/pkg/migratekit"
+	"github.com/josestg/swe-be-mono/pkg/sqlxkit"
+)
+
+type team struct {
+	ID   int64  `sql:"id"`
+	Name string `sql:"name"`
+}
+
+type member struct {
+	ID     int64  `sql:"id"`
+	TeamID int64  `sql:"team_id"`
+	Email  string `sql:"email"`
+}
+
+var _migrations = fstest.MapFS{
+	"1_teams.up.sql":   {Data: []byte("CREATE TABLE teams (id INTEGER PRIMARY KEY, name TEXT NOT NULL)")},
+	"1_teams.down.sql": {Data: []byte("DROP TABLE teams")},
+	"2_members.up.sql": {Data: []byte(`CREATE TABLE members (
+		id      INTEGER PRIMARY KEY,
+		team_id INTEGER NOT NULL REFERENCES teams (id),
+		email   TEXT NOT NULL UNIQUE
+	)`)},
+}
+
+func TestSetup(t *testing.T) {
+	db, teardown := Setup(t, _migrations, migratekit.SeedSQL("teams", "INSERT INTO teams (id, name) VALUES (1, 'core')"))
+	t.Cleanup(teardown)
+
+	ctx := context.Background()
+	teams, err := sqlxkit.NewRepository[team](db, "teams", "id")
+	expectTrue(t, err == nil)
+
+	got, err := teams.GetByID(ctx, 1)
+	expectTrue(t, err == nil)
+	expectTrue(t, got == team{ID: 1, Name: "core"})
+
+	members, err := sqlxkit.NewRepository[member](db, "members", "id")
+	expectTrue(t, err == nil)
+	expectTrue(t, members.Insert(ctx, member{ID: 1, TeamID: 1, Email: "alice@example.com"}) == nil)
+
+	err = sqlxkit.TranslateError(members.Insert(ctx, member{ID: 2, TeamID: 1, Email: "alice@example.com"}))
+	expectTrue(t, errors.Is(err, sqlxkit.ErrDuplicate))
+
+	err = sqlxkit.TranslateError(members.Insert(ctx, member{ID: 3, TeamID: 42, Email: "bob@example.com"}))
+	expectTrue(t, errors.Is(err, sqlxkit.ErrFKViolation))
+
+	_, err = db.ExecContext(ctx, "INSERT INTO teams (id) VALUES (2)")
+	expectTrue(t, errors.Is(sqlxkit.TranslateError(err), sqlxkit.ErrNotNullViolation))
+}
+
+func TestSetup_Isolated(t *testing.T) {
+	db1, teardown1 := Setup(t, _migrations)
+	t.Cleanup(teardown1)
+	db2, teardown2 := Setup(t, nil)
+	t.Cleanup(teardown2)
+
+	_, err := db1.ExecContext(context.Background(), "INSERT INTO teams (id, name) VALUES (1, 'core')")
+	expectTrue(t, err == nil)
+
+	// the second database has neither the tables of the first one nor the migrations.
+	_, err = db2.ExecContext(context.Background(), "INSERT INTO teams (id, name) VALUES (1, 'core')")
+	expectTrue(t, err != nil)
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
@@ -0,0 +1,225 @@
+package sqlxkit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// QueryTimeout sets Config.QueryTimeout.
+func QueryTimeout(timeout time.Duration) Option {
+	return func(cfg *Config) { cfg.QueryTimeout = timeout }
+}
+
+// WithQueryTimeout wraps the db for limiting the duration of each query to the timeout, unless the context of the
+// query already has a deadline, e.g. the one of TxOptions.Timeout. It includes the queries of the transactions begun
+// by ExecTransaction and the preparation of the statements, but not the queries of the prepared statements, which
+// take their own context. Zero timeout returns the db as is.
+//
+// The context of a query returning rows is released by One, All, NamedGet and NamedQuery as soon as the rows are
+// read. The rows queried directly are read after the call returns, so their context is released at the deadline.
+func WithQueryTimeout(db DB, timeout time.Duration) DB {
+	if timeout <= 0 {
+		return db
+	}
+	return &timeoutDB{DB: db, t: queryTimeout(timeout)}
+}
+
+// queryContexter is implemented by the DB and Tx limiting the duration of the queries, so the helpers reading the
+// rows release the context of the query as soon as they are done.
+type queryContexter interface {
+	queryContext(ctx context.Context) (context.Context, context.CancelFunc)
+}
+
+// queryContext returns the context of a query of q, with the timeout of q if it implements queryContexter.
+func queryContext(ctx context.Context, q any) (context.Context, context.CancelFunc) {
+	if qc, ok := q.(queryContexter); ok {
+		return qc.queryContext(ctx)
+	}
+	return ctx, func() {}
+}
+
+// queryTimeout applies the default timeout to the queries.
+type queryTimeout time.Duration
+
+// context returns the context of a query, with the timeout if it has no deadline.
+func (t queryTimeout) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, time.Duration(t))
+}
+
+func (t queryTimeout) queryx(ctx context.Context, r Reader, query string, args []any) (*sqlx.Rows, error) {
+	ctx, cancel := t.context(ctx)
+	rows, err := r.QueryxContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// the rows are read with the context after returning, so it is released by its timer at the deadline.
+	_ = cancel
+	return rows, nil
+}
+
+func (t queryTimeout) queryRowx(ctx context.Context, r Reader, query string, args []any) *sqlx.Row {
+	// the row is scanned with the context after returning, so it is released by its timer at the deadline.
+	ctx, _ = t.context(ctx)
+	return r.QueryRowxContext(ctx, query, args...)
+}
+
+func (t queryTimeout) exec(ctx context.Context, w Writer, query string, args []any) (sql.Result, error) {
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+	return w.ExecContext(ctx, query, args...)
+}
+
+func (t queryTimeout) namedExec(ctx context.Context, w Writer, query string, arg any) (sql.Result, error) {
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+	return w.NamedExecContext(ctx, query, arg)
+}
+
+func (t queryTimeout) preparex(ctx context.Context, p Preparer, query string) (*sqlx.Stmt, error) {
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+	return p.PreparexContext(ctx, query)
+}
+
+func (t queryTimeout) prepareNamed(ctx context.Context, p Preparer, query string) (*sqlx.NamedStmt, error) {
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+	return p.PrepareNamedContext(ctx, query)
+}
+
+// timeoutDB is a DB that limits the duration of the queries.
+type timeoutDB struct {
+	DB
+	t queryTimeout
+}
+
+func (d *timeoutDB) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	return d.t.queryx(ctx, d.DB, query, args)
+}
+
+func (d *timeoutDB) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	return d.t.queryRowx(ctx, d.DB, query, args)
+}
+
+func (d *timeoutDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return d.t.exec(ctx, d.DB, query, args)
+}
+
+func (d *timeoutDB) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	return d.t.namedExec(ctx, d.DB, query, arg)
+}
+
+func (d *timeoutDB) PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error) {
+	return d.t.preparex(ctx, d.DB, query)
+}
+
+func (d *timeoutDB) PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error) {
+	return d.t.prepareNamed(ctx, d.DB, query)
+}
+
+// queryContext implements queryContexter.
+func (d *timeoutDB) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return d.t.context(ctx)
+}
+
+// wrapTx implements instrumentedDB, the inner wrappers are applied first.
+func (d *timeoutDB) wrapTx(ctx context.Context, tx Tx) Tx {
+	return &timeoutTx{Tx: wrapTx(ctx, d.DB, tx), t: d.t}
+}
+
+// startTx implements instrumentedDB, the transaction itself is limited by TxOptions.Timeout instead.
+func (d *timeoutDB) startTx(ctx context.Context, opts TxOptions) (context.Context, func(err error)) {
+	return startTx(ctx, d.DB, opts)
+}
+
+// unwrapDB implements wrapperDB.
+func (d *timeoutDB) unwrapDB() DB { return d.DB }
+
+// timeoutTx is a Tx that limits the duration of the queries.
+type timeoutTx struct {
+	Tx
+	t queryTimeout
+}
+
+func (t *timeoutTx) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	return t.t.queryx(ctx, t.Tx, query, args)
+}
+
+func (t *timeoutTx) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	return t.t.queryRowx(ctx, t.Tx, query, args)
+}
+
+func (t *timeoutTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return t.t.exec(ctx, t.Tx, query, args)
+}
+
+func (t *timeoutTx) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	return t.t.namedExec(ctx, t.Tx, query, arg)
+}
+
+func (t *timeoutTx) PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error) {
+	return t.t.preparex(ctx, t.Tx, query)
+}
+
+func (t *timeoutTx) PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error) {
+	return t.t.prepareNamed(ctx, t.Tx, query)
+}
+
+// queryContext implements queryContexter.
+func (t *timeoutTx) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return t.t.context(ctx)
+}
+
+// timeoutConn is the Conn opened with Config.QueryTimeout, the *sqlx.DB is embedded for keeping its other methods,
+// e.g. Stats.
+type timeoutConn struct {
+	*sqlx.DB
+	db *timeoutDB
+}
+
+func (c *timeoutConn) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	return c.db.QueryxContext(ctx, query, args...)
+}
+
+func (c *timeoutConn) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	return c.db.QueryRowxContext(ctx, query, args...)
+}
+
+func (c *timeoutConn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return c.db.ExecContext(ctx, query, args...)
+}
+
+func (c *timeoutConn) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	return c.db.NamedExecContext(ctx, query, arg)
+}
+
+func (c *timeoutConn) PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error) {
+	return c.db.PreparexContext(ctx, query)
+}
+
+func (c *timeoutConn) PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error) {
+	return c.db.PrepareNamedContext(ctx, query)
+}
+
+// queryContext implements queryContexter.
+func (c *timeoutConn) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return c.db.queryContext(ctx)
+}
+
+// wrapTx implements instrumentedDB.
+func (c *timeoutConn) wrapTx(ctx context.Context, tx Tx) Tx { return c.db.wrapTx(ctx, tx) }
+
+// startTx implements instrumentedDB.
+func (c *timeoutConn) startTx(ctx context.Context, opts TxOptions) (context.Context, func(err error)) {
+	return c.db.startTx(ctx, opts)
+}
+
+// unwrapDB implements wrapperDB.
+func (c *timeoutConn) unwrapDB() DB { return c.db }
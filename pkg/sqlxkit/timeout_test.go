@@ -0,0 +1,142 @@
+package sqlxkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+// deadlineRecorder is a DB that records the deadline and the context of the queries.
+type deadlineRecorder struct {
+	DB
+	deadlines []time.Duration
+	contexts  []context.Context
+}
+
+func (d *deadlineRecorder) record(ctx context.Context) {
+	var left time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		left = time.Until(deadline)
+	}
+	d.deadlines = append(d.deadlines, left)
+	d.contexts = append(d.contexts, ctx)
+}
+
+func (d *deadlineRecorder) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	d.record(ctx)
+	return d.DB.QueryRowxContext(ctx, query, args...)
+}
+
+func (d *deadlineRecorder) PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error) {
+	d.record(ctx)
+	return d.DB.PreparexContext(ctx, query)
+}
+
+func (d *deadlineRecorder) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	d.record(ctx)
+	return d.DB.QueryxContext(ctx, query, args...)
+}
+
+func (d *deadlineRecorder) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	d.record(ctx)
+	return d.DB.ExecContext(ctx, query, args...)
+}
+
+func TestWithQueryTimeout(t *testing.T) {
+	db, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	expectTrue(t, WithQueryTimeout(db, 0) == DB(db))
+
+	rec := &deadlineRecorder{DB: db}
+	tdb := WithQueryTimeout(rec, time.Minute)
+
+	mock.ExpectExec("DELETE FROM foo").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err := tdb.ExecContext(context.Background(), "DELETE FROM foo")
+	expectNoError(t, err)
+
+	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+	rows, err := tdb.QueryxContext(context.Background(), "SELECT 1")
+	expectNoError(t, err)
+	expectTrue(t, rows.Next())
+	expectNoError(t, rows.Close())
+
+	// the deadline of the caller is kept.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	t.Cleanup(cancel)
+	mock.ExpectExec("DELETE FROM foo").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = tdb.ExecContext(ctx, "DELETE FROM foo")
+	expectNoError(t, err)
+
+	expectTrue(t, len(rec.deadlines) == 3)
+	expectTrue(t, rec.deadlines[0] > 0 && rec.deadlines[0] <= time.Minute)
+	expectTrue(t, rec.deadlines[1] > 0 && rec.deadlines[1] <= time.Minute)
+	expectTrue(t, rec.deadlines[2] > time.Minute)
+}
+
+func TestWithQueryTimeout_Released(t *testing.T) {
+	db, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	rec := &deadlineRecorder{DB: db}
+	tdb := WithQueryTimeout(rec, time.Minute)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+	n, err := One[int](ctx, tdb, "SELECT 1")
+	expectNoError(t, err)
+	expectTrue(t, n == 1)
+
+	mock.ExpectQuery("SELECT 2").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(2).AddRow(3))
+	all, err := All[int](ctx, tdb, "SELECT 2")
+	expectNoError(t, err)
+	expectTrue(t, len(all) == 2)
+
+	mock.ExpectPrepare("SELECT 4")
+	stmt, err := tdb.PreparexContext(ctx, "SELECT 4")
+	expectNoError(t, err)
+	t.Cleanup(func() { _ = stmt.Close() })
+
+	// the contexts are limited by the timeout and released as soon as the rows are read or the statement is prepared.
+	expectTrue(t, len(rec.contexts) == 3)
+	for i, ctx := range rec.contexts {
+		expectTrue(t, rec.deadlines[i] > 0 && rec.deadlines[i] <= time.Minute)
+		expectTrue(t, errors.Is(ctx.Err(), context.Canceled))
+	}
+}
+
+func TestWithQueryTimeout_Transaction(t *testing.T) {
+	db, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	var wrapped bool
+	tdb := WithQueryTimeout(db, time.Minute)
+	err := ExecTransaction(context.Background(), tdb, func(ctx context.Context, tx Tx) (context.Context, error) {
+		_, ok := tx.(*timeoutTx)
+		wrapped = ok
+		return ctx, nil
+	})
+	expectNoError(t, err)
+	expectTrue(t, wrapped)
+}
+
+func TestOpen_QueryTimeout(t *testing.T) {
+	db, err := Open(simpleMock, "foo", QueryTimeout(time.Second))
+	expectNoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, ok := db.(*timeoutConn)
+	expectTrue(t, ok)
+
+	// the other methods of *sqlx.DB are kept, e.g. for BalanceLeastConn.
+	_, ok = db.(interface{ Stats() sql.DBStats })
+	expectTrue(t, ok)
+}
@@ -0,0 +1,212 @@
+package sqlxkit
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/josestg/swe-be-mono/pkg/tracekit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracing wraps the db for starting a client span per query and per transaction begun by ExecTransaction. The
+// spans are the children of the span in the context, e.g. the server span of tracekit.HTTPMiddleware, and carry the
+// db.statement with the literals replaced by ?, so the values aren't exported. The queries of the prepared statements
+// aren't traced.
+func WithTracing(db DB, tp trace.TracerProvider) DB {
+	return &tracedDB{DB: db, tracer: &queryTracer{tracer: tp.Tracer(tracekit.InstrumentationName)}}
+}
+
+// queryTracer starts the spans of the queries.
+type queryTracer struct {
+	tracer trace.Tracer
+}
+
+// start starts the span of the query.
+func (t *queryTracer) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, spanName(query),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.statement", SanitizeQuery(query))),
+	)
+}
+
+// end ends the span with the result of the query, rows is negative if unknown.
+func (t *queryTracer) end(span trace.Span, rows int64, err error) {
+	if rows >= 0 {
+		span.SetAttributes(attribute.Int64("db.rows_affected", rows))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// queryx traces QueryxContext.
+func (t *queryTracer) queryx(ctx context.Context, r Reader, query string, args []any) (*sqlx.Rows, error) {
+	ctx, span := t.start(ctx, query)
+	rows, err := r.QueryxContext(ctx, query, args...)
+	t.end(span, -1, err)
+	return rows, err
+}
+
+// queryRowx traces QueryRowxContext.
+func (t *queryTracer) queryRowx(ctx context.Context, r Reader, query string, args []any) *sqlx.Row {
+	ctx, span := t.start(ctx, query)
+	row := r.QueryRowxContext(ctx, query, args...)
+	t.end(span, -1, row.Err())
+	return row
+}
+
+// exec traces ExecContext.
+func (t *queryTracer) exec(ctx context.Context, w Writer, query string, args []any) (sql.Result, error) {
+	ctx, span := t.start(ctx, query)
+	res, err := w.ExecContext(ctx, query, args...)
+	t.end(span, rowsAffected(res, err), err)
+	return res, err
+}
+
+// namedExec traces NamedExecContext.
+func (t *queryTracer) namedExec(ctx context.Context, w Writer, query string, arg any) (sql.Result, error) {
+	ctx, span := t.start(ctx, query)
+	res, err := w.NamedExecContext(ctx, query, arg)
+	t.end(span, rowsAffected(res, err), err)
+	return res, err
+}
+
+// tracedDB is a DB that traces the queries.
+type tracedDB struct {
+	DB
+	tracer *queryTracer
+}
+
+func (d *tracedDB) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	return d.tracer.queryx(ctx, d.DB, query, args)
+}
+
+func (d *tracedDB) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	return d.tracer.queryRowx(ctx, d.DB, query, args)
+}
+
+func (d *tracedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return d.tracer.exec(ctx, d.DB, query, args)
+}
+
+func (d *tracedDB) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	return d.tracer.namedExec(ctx, d.DB, query, arg)
+}
+
+// wrapTx implements instrumentedDB, the inner wrappers are applied first.
+func (d *tracedDB) wrapTx(ctx context.Context, tx Tx) Tx {
+	return &tracedTx{Tx: wrapTx(ctx, d.DB, tx), tracer: d.tracer}
+}
+
+// startTx implements instrumentedDB, it starts the span of the whole transaction, the parent of its queries.
+func (d *tracedDB) startTx(ctx context.Context, opts TxOptions) (context.Context, func(err error)) {
+	ctx, span := d.tracer.tracer.Start(ctx, "TRANSACTION",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.transaction.isolation", opts.Isolation.String()),
+			attribute.Bool("db.transaction.read_only", opts.ReadOnly),
+		),
+	)
+
+	ctx, end := startTx(ctx, d.DB, opts)
+	return ctx, func(err error) {
+		end(err)
+		d.tracer.end(span, -1, err)
+	}
+}
+
+// unwrapDB implements wrapperDB.
+func (d *tracedDB) unwrapDB() DB { return d.DB }
+
+// queryContext implements queryContexter, the timeout is the one of the inner wrappers.
+func (d *tracedDB) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return queryContext(ctx, d.DB)
+}
+
+// tracedTx is a Tx that traces the queries.
+type tracedTx struct {
+	Tx
+	tracer *queryTracer
+}
+
+func (t *tracedTx) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	return t.tracer.queryx(ctx, t.Tx, query, args)
+}
+
+func (t *tracedTx) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	return t.tracer.queryRowx(ctx, t.Tx, query, args)
+}
+
+func (t *tracedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return t.tracer.exec(ctx, t.Tx, query, args)
+}
+
+func (t *tracedTx) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	return t.tracer.namedExec(ctx, t.Tx, query, arg)
+}
+
+// queryContext implements queryContexter, the timeout is the one of the inner wrappers.
+func (t *tracedTx) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return queryContext(ctx, t.Tx)
+}
+
+// spanName returns the operation of the query, e.g. SELECT or INSERT, as the span name.
+func spanName(query string) string {
+	op, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	if op == "" {
+		return "SQL"
+	}
+	return strings.ToUpper(op)
+}
+
+// SanitizeQuery replaces the string and the numeric literals of the query with ?, e.g. for exporting the query
+// without the values inlined by the caller. The placeholders, e.g. ?, $1 and :name, and the identifiers are kept.
+func SanitizeQuery(query string) string {
+	var (
+		b    strings.Builder
+		prev rune
+		rs   = []rune(query)
+	)
+	b.Grow(len(query))
+
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '\'':
+			// skip to the closing quote, the doubled quote is an escaped one.
+			for i++; i < len(rs); i++ {
+				if rs[i] == '\'' {
+					if i+1 < len(rs) && rs[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			b.WriteRune('?')
+			prev = '\''
+		case unicode.IsDigit(r) && !isIdentRune(prev):
+			for i+1 < len(rs) && (unicode.IsDigit(rs[i+1]) || rs[i+1] == '.') {
+				i++
+			}
+			b.WriteRune('?')
+			prev = '0'
+		default:
+			b.WriteRune(r)
+			prev = r
+		}
+	}
+	return b.String()
+}
+
+// isIdentRune tells whether the rune continues an identifier or a placeholder, e.g. table1, $1 or :id2.
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '$' || r == ':' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,105 @@
+package sqlxkit
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanAttr returns the value of the span attribute.
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestWithTracing(t *testing.T) {
+	sqlDB, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	db := WithTracing(sqlDB, tp)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	mock.ExpectQuery("SELECT name FROM users WHERE id = 42").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("alice"))
+	mock.ExpectExec("DELETE FROM users WHERE name = ?").WithArgs("bob").WillReturnError(errExample)
+
+	_, err := One[string](ctx, db, "SELECT name FROM users WHERE id = 42")
+	expectNoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM users WHERE name = ?", "bob")
+	expectTrue(t, err != nil)
+	parent.End()
+
+	spans := rec.Ended()
+	expectTrue(t, len(spans) == 3)
+
+	query := spans[0]
+	expectTrue(t, query.Name() == "SELECT")
+	expectTrue(t, query.Parent().SpanID() == parent.SpanContext().SpanID())
+	stmt, _ := spanAttr(query, "db.statement")
+	expectTrue(t, stmt.AsString() == "SELECT name FROM users WHERE id = ?")
+
+	exec := spans[1]
+	expectTrue(t, exec.Name() == "DELETE")
+	expectTrue(t, exec.Status().Code == codes.Error)
+}
+
+func TestWithTracing_Transaction(t *testing.T) {
+	sqlDB, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	db := WithQueryLog(WithTracing(sqlDB, tp), QueryLogConfig{})
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET name = ?").WithArgs("alice").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	update := func(ctx context.Context, tx Tx) (context.Context, error) {
+		_, err := tx.ExecContext(ctx, "UPDATE users SET name = ?", "alice")
+		return ctx, err
+	}
+	err := ExecTransactionWith(context.Background(), db, TxOptions{Isolation: sql.LevelSerializable}, update)
+	expectNoError(t, err)
+
+	spans := rec.Ended()
+	expectTrue(t, len(spans) == 2)
+
+	exec, tx := spans[0], spans[1]
+	expectTrue(t, tx.Name() == "TRANSACTION")
+	expectTrue(t, exec.Parent().SpanID() == tx.SpanContext().SpanID())
+
+	rows, _ := spanAttr(exec, "db.rows_affected")
+	expectTrue(t, rows.AsInt64() == 3)
+	isolation, _ := spanAttr(tx, "db.transaction.isolation")
+	expectTrue(t, isolation.AsString() == "Serializable")
+	expectTrue(t, tx.Status().Code != codes.Error)
+}
+
+func TestSanitizeQuery(t *testing.T) {
+	tests := map[string]string{
+		"SELECT * FROM users WHERE id = 1":                    "SELECT * FROM users WHERE id = ?",
+		"SELECT * FROM users WHERE name = 'o''brien' AND x=2": "SELECT * FROM users WHERE name = ? AND x=?",
+		"SELECT * FROM t1 WHERE a = $1 AND b = :id2 LIMIT 10": "SELECT * FROM t1 WHERE a = $1 AND b = :id2 LIMIT ?",
+		"UPDATE users SET score = 1.5, name = ?":              "UPDATE users SET score = ?, name = ?",
+		"INSERT INTO logs (msg) VALUES ('unterminated":        "INSERT INTO logs (msg) VALUES (?",
+	}
+	for query, want := range tests {
+		got := SanitizeQuery(query)
+		if got != want {
+			t.Errorf("SanitizeQuery(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
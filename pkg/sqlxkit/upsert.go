@@ -0,0 +1,146 @@
+package sqlxkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// Dialect is the SQL dialect of a database, for generating the queries that aren't standard.
+type Dialect uint8
+
+// Sets of dialects.
+const (
+	DialectPostgres Dialect = iota // PostgreSQL, INSERT ... ON CONFLICT.
+	DialectMySQL                   // MySQL and MariaDB, INSERT ... ON DUPLICATE KEY UPDATE.
+	DialectSQLite                  // SQLite 3.24 or newer, INSERT ... ON CONFLICT.
+)
+
+// String returns the string representation of Dialect.
+func (d Dialect) String() string {
+	switch d {
+	case DialectPostgres:
+		return "postgres"
+	case DialectMySQL:
+		return "mysql"
+	case DialectSQLite:
+		return "sqlite"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrNoColumns is returned when generating a query of a type that has no mapped fields.
+var ErrNoColumns = errors.New("sqlxkit: no columns")
+
+// _structMapper maps the struct fields to the columns of the generated queries, it follows the default Config.
+var _structMapper = reflectx.NewMapperFunc("sql", strings.ToLower)
+
+// Upsert inserts arg into the table, or updates the existing row when it conflicts with the conflict columns, see
+// UpsertQuery. The arg is a struct, a pointer to struct or a slice of structs for the bulk upsert.
+//
+//	sqlxkit.ExecTransaction(ctx, db, sqlxkit.Upsert(sqlxkit.DialectPostgres, "users", user, []string{"id"}))
+func Upsert(dialect Dialect, table string, arg any, conflict []string, opts ...ExecOption) Atomic {
+	return func(ctx context.Context, tx Tx) (context.Context, error) {
+		query, err := UpsertQuery(dialect, table, arg, conflict)
+		if err != nil {
+			return ctx, fmt.Errorf("sqlxkit: Upsert: %w", err)
+		}
+		return NamedExec(query, arg, opts...).Exec(ctx, tx)
+	}
+}
+
+// UpsertQuery generates the named upsert query of the fields of arg, where the columns are the "sql" tags of the
+// fields, or the lowercased field names if untagged. The columns other than the conflict ones are updated with the
+// inserted values on conflict, or nothing is done if there are no other columns.
+//
+// The conflict columns must be covered by a unique index. MySQL ignores them since it detects the conflict by any
+// unique index of the table.
+func UpsertQuery(dialect Dialect, table string, arg any, conflict []string) (string, error) {
+	columns, binds := structColumns(reflect.TypeOf(arg))
+	if len(columns) == 0 {
+		return "", fmt.Errorf("upsert %T: %w", arg, ErrNoColumns)
+	}
+
+	var updates []string
+	for _, c := range columns {
+		if !slices.Contains(conflict, c) {
+			updates = append(updates, c)
+		}
+	}
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "INSERT INTO %s (%s) VALUES (:%s)",
+		table, strings.Join(columns, ", "), strings.Join(binds, ", :"))
+
+	switch dialect {
+	default:
+		return "", fmt.Errorf("upsert: unsupported dialect %s", dialect)
+	case DialectPostgres, DialectSQLite:
+		if len(conflict) == 0 {
+			return "", errors.New("upsert: conflict columns are required")
+		}
+		_, _ = fmt.Fprintf(&sb, " ON CONFLICT (%s)", strings.Join(conflict, ", "))
+		if len(updates) == 0 {
+			sb.WriteString(" DO NOTHING")
+			break
+		}
+		sb.WriteString(" DO UPDATE SET ")
+		for i, c := range updates {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			_, _ = fmt.Fprintf(&sb, "%s = EXCLUDED.%s", c, c)
+		}
+	case DialectMySQL:
+		sb.WriteString(" ON DUPLICATE KEY UPDATE ")
+		if len(updates) == 0 {
+			// assigning a column to itself is the idiomatic "do nothing" of MySQL.
+			_, _ = fmt.Fprintf(&sb, "%s = %s", columns[0], columns[0])
+			break
+		}
+		for i, c := range updates {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			_, _ = fmt.Fprintf(&sb, "%s = VALUES(%s)", c, c)
+		}
+	}
+	return sb.String(), nil
+}
+
+// structColumns returns the columns of the struct type t and their named parameters. The fields of the embedded
+// structs are flattened, the other structs are skipped unless they are scanned as a single column, e.g. time.Time.
+func structColumns(t reflect.Type) (columns, binds []string) {
+	if t == nil {
+		return nil, nil
+	}
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var walk func(fields []*reflectx.FieldInfo)
+	walk = func(fields []*reflectx.FieldInfo) {
+		for _, fi := range fields {
+			switch {
+			case fi == nil || !fi.Field.IsExported() && !fi.Embedded:
+				continue
+			case scannable(fi.Field.Type):
+				columns = append(columns, fi.Name)
+				binds = append(binds, fi.Path)
+			case fi.Embedded:
+				walk(fi.Children)
+			}
+		}
+	}
+	walk(_structMapper.TypeMap(t).Tree.Children)
+	return columns, binds
+}
@@ -0,0 +1,77 @@
+package sqlxkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type upsertAudit struct {
+	UpdatedAt time.Time `sql:"updated_at"`
+}
+
+type upsertUser struct {
+	ID      int64  `sql:"id"`
+	Name    string `sql:"name"`
+	Email   string
+	Ignored string `sql:"-"`
+	Address struct{ City string }
+	upsertAudit
+}
+
+func TestUpsertQuery(t *testing.T) {
+	conflict := []string{"id"}
+
+	query, err := UpsertQuery(DialectPostgres, "users", upsertUser{}, conflict)
+	expectNoError(t, err)
+	expectTrue(t, query == "INSERT INTO users (id, name, email, updated_at) VALUES (:id, :name, :email, :updated_at)"+
+		" ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email, updated_at = EXCLUDED.updated_at")
+
+	query, err = UpsertQuery(DialectMySQL, "users", &upsertUser{}, conflict)
+	expectNoError(t, err)
+	expectTrue(t, query == "INSERT INTO users (id, name, email, updated_at) VALUES (:id, :name, :email, :updated_at)"+
+		" ON DUPLICATE KEY UPDATE name = VALUES(name), email = VALUES(email), updated_at = VALUES(updated_at)")
+
+	query, err = UpsertQuery(DialectSQLite, "users", []queryUser{}, []string{"id", "name"})
+	expectNoError(t, err)
+	expectTrue(t, query == "INSERT INTO users (id, name) VALUES (:id, :name) ON CONFLICT (id, name) DO NOTHING")
+
+	query, err = UpsertQuery(DialectMySQL, "users", queryUser{}, []string{"id", "name"})
+	expectNoError(t, err)
+	expectTrue(t, query == "INSERT INTO users (id, name) VALUES (:id, :name) ON DUPLICATE KEY UPDATE id = id")
+
+	_, err = UpsertQuery(DialectPostgres, "users", upsertUser{}, nil)
+	expectTrue(t, err != nil)
+
+	_, err = UpsertQuery(Dialect(42), "users", upsertUser{}, conflict)
+	expectTrue(t, err != nil)
+
+	_, err = UpsertQuery(DialectPostgres, "users", map[string]any{"id": 1}, conflict)
+	expectTrue(t, errors.Is(err, ErrNoColumns))
+}
+
+func TestUpsert(t *testing.T) {
+	db, mock, teardown := Setup(t)
+	t.Cleanup(teardown)
+	ApplyConfig(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users (id, name) VALUES (?, ?),(?, ?) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name").
+		WithArgs(1, "alice", 2, "bob").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	users := []queryUser{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob"}}
+	err := ExecTransaction(context.Background(), db,
+		Upsert(DialectPostgres, "users", users, []string{"id"}, WithVerifyAffectedRows(2)),
+	)
+	expectNoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err = ExecTransaction(context.Background(), db, Upsert(DialectPostgres, "users", 42, []string{"id"}))
+	expectTrue(t, errors.Is(err, ErrNoColumns))
+}
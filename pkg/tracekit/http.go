@@ -0,0 +1,95 @@
+package tracekit
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HTTPMiddleware is a middleware that starts a server span per request. The parent span is extracted from the
+// W3C traceparent header. The middleware must be placed after httpkit.LogEntryRecorder, so the span can be
+// annotated from the recorded httpkit.LogEntry (status code and latency).
+func HTTPMiddleware(tp trace.TracerProvider) httpkit.NetMiddleware {
+	tracer := tp.Tracer(InstrumentationName)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := Extract(r.Context(), r.Header)
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+					semconv.UserAgentOriginal(r.UserAgent()),
+				),
+			)
+			defer span.End()
+
+			if id := httpkit.RequestIDFromContext(ctx); id != "" {
+				span.SetAttributes(attribute.String("http.request.id", id))
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			rec, ok := httpkit.GetLogEntry(w)
+			if !ok || rec.StatusCode == 0 {
+				return
+			}
+
+			span.SetAttributes(
+				semconv.HTTPResponseStatusCode(rec.StatusCode),
+				attribute.Int64("http.server.latency_ms", time.Duration(rec.RespondedAt-rec.RequestedAt).Milliseconds()),
+			)
+			if rec.StatusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rec.StatusCode))
+			}
+		})
+	}
+}
+
+// Transport wraps the base http.RoundTripper to start a client span per request and propagate the trace context to
+// the downstream service. If base is nil, http.DefaultTransport is used.
+func Transport(tp trace.TracerProvider, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{tracer: tp.Tracer(InstrumentationName), base: base}
+}
+
+type transport struct {
+	tracer trace.Tracer
+	base   http.RoundTripper
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx, span := t.tracer.Start(r.Context(), r.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.URLFull(r.URL.String()),
+		),
+	)
+	defer span.End()
+
+	// the request must not be modified by RoundTripper, so inject to a clone.
+	r = r.Clone(ctx)
+	Inject(ctx, r.Header)
+
+	res, err := t.base.RoundTrip(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(res.StatusCode))
+	if res.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, fmt.Sprintf("status code %d", res.StatusCode))
+	}
+	return res, nil
+}
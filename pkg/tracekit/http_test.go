@@ -0,0 +1,77 @@
+package tracekit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/josestg/swe-be-mono/pkg/httpkit"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newRecordingProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	rec := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)), rec
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	_, _ = Setup(Config{})
+	tp, spans := newRecordingProvider()
+
+	var traceID string
+	handler := httpkit.ReduceNetMiddleware(
+		httpkit.LogEntryRecorder,
+		HTTPMiddleware(tp),
+	).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceID = TraceIDFromContext(r.Context())
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set("traceparent", traceparent)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	ended := spans.Ended()
+	expectTrue(t, len(ended) == 1)
+	span := ended[0]
+	expectTrue(t, span.Name() == http.MethodGet)
+	expectTrue(t, span.SpanKind() == trace.SpanKindServer)
+	expectTrue(t, span.Parent().SpanID().String() == "00f067aa0ba902b7")
+	expectTrue(t, traceID == "4bf92f3577b34da6a3ce929d0e0e4736")
+	expectTrue(t, span.Status().Code == codes.Error)
+
+	var status int64
+	for _, attr := range span.Attributes() {
+		if attr.Key == "http.response.status_code" {
+			status = attr.Value.AsInt64()
+		}
+	}
+	expectTrue(t, status == http.StatusBadGateway)
+}
+
+func TestTransport(t *testing.T) {
+	_, _ = Setup(Config{})
+	tp, spans := newRecordingProvider()
+
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := http.Client{Transport: Transport(tp, nil)}
+	res, err := client.Get(srv.URL)
+	expectNoError(t, err)
+	_ = res.Body.Close()
+
+	ended := spans.Ended()
+	expectTrue(t, len(ended) == 1)
+	expectTrue(t, ended[0].SpanKind() == trace.SpanKindClient)
+	expectTrue(t, ended[0].Status().Code == codes.Error)
+	expectTrue(t, strings.Contains(received, ended[0].SpanContext().TraceID().String()))
+}
@@ -0,0 +1,114 @@
+// Package tracekit provides a thin setup layer on top of OpenTelemetry tracing: installing the global tracer
+// provider and W3C propagators, and instrumenting incoming and outgoing HTTP requests.
+package tracekit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer used by this package and the other kits.
+const InstrumentationName = "github.com/josestg/swe-be-mono/pkg/tracekit"
+
+// Set of supported exporters.
+const (
+	ExporterNone   = "none"   // spans are sampled and propagated but not exported.
+	ExporterStdout = "stdout" // spans are exported to stdout as JSON, useful for development.
+)
+
+// Config is the configuration for setting up tracing.
+type Config struct {
+	Enabled        bool    `env:"ENABLED"` // Enable tracing, when disabled a no-op tracer provider is used.
+	ServiceName    string  // The service.name resource attribute.
+	ServiceVersion string  // The service.version resource attribute.
+	Exporter       string  `env:"EXPORTER,default=stdout"` // One of ExporterNone or ExporterStdout.
+	SampleRatio    float64 `env:"SAMPLE_RATIO,default=1"`  // Ratio of root spans to be sampled, in the range of [0, 1].
+}
+
+// ShutdownFunc flushes the remaining spans and releases the exporter resources.
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup installs the global tracer provider and the W3C trace context and baggage propagators.
+// The propagators are always installed, so the trace context of the upstream is still forwarded even if tracing is
+// disabled.
+func Setup(cfg Config) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	noop := func(context.Context) error { return nil }
+	if !cfg.Enabled {
+		return noop, nil
+	}
+
+	exporter, err := newExporter(cfg.Exporter, os.Stdout)
+	if err != nil {
+		return noop, fmt.Errorf("tracekit: new exporter: %w", err)
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+	)
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// newExporter creates the span exporter by its name, nil exporter means no exporter.
+func newExporter(name string, w io.Writer) (sdktrace.SpanExporter, error) {
+	switch name {
+	case ExporterNone, "":
+		return nil, nil
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(w))
+	default:
+		return nil, fmt.Errorf("unknown exporter: %q", name)
+	}
+}
+
+// TracerProvider returns the global tracer provider installed by Setup.
+func TracerProvider() trace.TracerProvider { return otel.GetTracerProvider() }
+
+// Tracer returns the tracer of this package from the global tracer provider.
+func Tracer() trace.Tracer { return otel.Tracer(InstrumentationName) }
+
+// Inject injects the trace context from the context into the header, e.g. the traceparent header.
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Extract extracts the trace context from the header into a copy of the context.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// TraceIDFromContext returns the trace ID of the span in the context, if not found, it returns an empty string.
+func TraceIDFromContext(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
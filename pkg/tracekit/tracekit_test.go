@@ -0,0 +1,71 @@
+package tracekit
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(Config{Enabled: false})
+	expectNoError(t, err)
+	expectNoError(t, shutdown(context.Background()))
+
+	_, err = Setup(Config{Enabled: true, Exporter: "unknown"})
+	expectTrue(t, err != nil)
+
+	shutdown, err = Setup(Config{Enabled: true, Exporter: ExporterNone, ServiceName: "test", SampleRatio: 1})
+	expectNoError(t, err)
+	t.Cleanup(func() { otel.SetTracerProvider(sdktrace.NewTracerProvider()) })
+
+	_, span := Tracer().Start(context.Background(), "test")
+	expectTrue(t, span.SpanContext().IsSampled())
+	span.End()
+	expectNoError(t, shutdown(context.Background()))
+}
+
+func TestNewExporter(t *testing.T) {
+	var buf bytes.Buffer
+	exp, err := newExporter(ExporterStdout, &buf)
+	expectNoError(t, err)
+	expectTrue(t, exp != nil)
+
+	exp, err = newExporter("", &buf)
+	expectNoError(t, err)
+	expectTrue(t, exp == nil)
+}
+
+func TestInjectExtract(t *testing.T) {
+	_, _ = Setup(Config{})
+
+	header := http.Header{}
+	header.Set("traceparent", traceparent)
+	ctx := Extract(context.Background(), header)
+	expectTrue(t, TraceIDFromContext(ctx) == "4bf92f3577b34da6a3ce929d0e0e4736")
+
+	out := http.Header{}
+	Inject(ctx, out)
+	expectTrue(t, out.Get("traceparent") == traceparent)
+
+	expectTrue(t, TraceIDFromContext(context.Background()) == "")
+}
+
+func expectNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func expectTrue(t *testing.T, b bool) {
+	t.Helper()
+	if !b {
+		t.Fatal("expected true, got false")
+	}
+}
@@ -0,0 +1,17 @@
+// Package migrations embeds the SQL migrations of the databases, so the binaries can apply them without shipping the
+// files alongside, see migratekit.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed postgre
+var _postgre embed.FS
+
+// Postgre returns the migrations of the PostgreSQL database.
+func Postgre() fs.FS {
+	sub, _ := fs.Sub(_postgre, "postgre") // never fails, the directory is embedded above.
+	return sub
+}
@@ -0,0 +1,10 @@
+CREATE TABLE users
+(
+    id         UUID         NOT NULL,
+    email      VARCHAR(320) NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP    NOT NULL, -- UTC.
+    updated_at TIMESTAMP    NOT NULL, -- UTC.
+    CONSTRAINT users_pkey PRIMARY KEY (id),
+    CONSTRAINT users_email_key UNIQUE (email)
+);
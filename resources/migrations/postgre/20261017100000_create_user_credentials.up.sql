@@ -0,0 +1,8 @@
+CREATE TABLE user_credentials
+(
+    user_id       UUID         NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    updated_at    TIMESTAMP    NOT NULL, -- UTC.
+    CONSTRAINT user_credentials_pkey PRIMARY KEY (user_id),
+    CONSTRAINT user_credentials_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
@@ -0,0 +1,17 @@
+CREATE TABLE refresh_tokens
+(
+    id          UUID      NOT NULL,
+    user_id     UUID      NOT NULL,
+    family_id   UUID      NOT NULL, -- the ID of the first token of the rotation chain.
+    token_hash  CHAR(64)  NOT NULL, -- hex SHA-256 of the token, the token itself isn't stored.
+    created_at  TIMESTAMP NOT NULL, -- UTC.
+    expires_at  TIMESTAMP NOT NULL, -- UTC.
+    revoked_at  TIMESTAMP NULL,     -- UTC, set when the token is rotated or revoked.
+    replaced_by UUID      NULL,     -- the ID of the token rotated to.
+    CONSTRAINT refresh_tokens_pkey PRIMARY KEY (id),
+    CONSTRAINT refresh_tokens_token_hash_key UNIQUE (token_hash),
+    CONSTRAINT refresh_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
@@ -0,0 +1,20 @@
+CREATE TABLE user_totp
+(
+    user_id      UUID         NOT NULL,
+    secret       VARCHAR(255) NOT NULL, -- AES-GCM sealed, base64.
+    last_step    BIGINT       NOT NULL, -- the last used time step, the codes of it and before are rejected.
+    confirmed_at TIMESTAMP    NULL,     -- UTC, the TOTP is required on login once it is confirmed.
+    created_at   TIMESTAMP    NOT NULL, -- UTC.
+    CONSTRAINT user_totp_pkey PRIMARY KEY (user_id),
+    CONSTRAINT user_totp_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_recovery_codes
+(
+    user_id    UUID      NOT NULL,
+    code_hash  CHAR(64)  NOT NULL, -- hex SHA-256 of the normalized code, see otpkit.HashRecoveryCode.
+    used_at    TIMESTAMP NULL,     -- UTC.
+    created_at TIMESTAMP NOT NULL, -- UTC.
+    CONSTRAINT user_recovery_codes_pkey PRIMARY KEY (user_id, code_hash),
+    CONSTRAINT user_recovery_codes_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
@@ -0,0 +1,11 @@
+CREATE TABLE user_identities
+(
+    provider   VARCHAR(32)  NOT NULL, -- e.g. google or github.
+    subject    VARCHAR(255) NOT NULL, -- the stable ID of the user at the provider.
+    user_id    UUID         NOT NULL,
+    email      VARCHAR(320) NOT NULL, -- the verified email at the provider when linked.
+    created_at TIMESTAMP    NOT NULL, -- UTC.
+    CONSTRAINT user_identities_pkey PRIMARY KEY (provider, subject),
+    CONSTRAINT user_identities_user_id_provider_key UNIQUE (user_id, provider),
+    CONSTRAINT user_identities_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
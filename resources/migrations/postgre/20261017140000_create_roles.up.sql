@@ -0,0 +1,30 @@
+CREATE TABLE roles
+(
+    id          UUID         NOT NULL,
+    name        VARCHAR(64)  NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    created_at  TIMESTAMP    NOT NULL, -- UTC.
+    updated_at  TIMESTAMP    NOT NULL, -- UTC.
+    CONSTRAINT roles_pkey PRIMARY KEY (id),
+    CONSTRAINT roles_name_key UNIQUE (name)
+);
+
+CREATE TABLE role_permissions
+(
+    role_id    UUID        NOT NULL,
+    permission VARCHAR(64) NOT NULL, -- e.g. users:read, see rbac.Permissions.
+    CONSTRAINT role_permissions_pkey PRIMARY KEY (role_id, permission),
+    CONSTRAINT role_permissions_role_id_fkey FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_roles
+(
+    user_id    UUID      NOT NULL,
+    role_id    UUID      NOT NULL,
+    created_at TIMESTAMP NOT NULL, -- UTC.
+    CONSTRAINT user_roles_pkey PRIMARY KEY (user_id, role_id),
+    CONSTRAINT user_roles_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT user_roles_role_id_fkey FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX user_roles_role_id_idx ON user_roles (role_id);
@@ -0,0 +1,2 @@
+ALTER TABLE user_totp ADD COLUMN failed_attempts INTEGER NOT NULL DEFAULT 0; -- the consecutive wrong codes.
+ALTER TABLE user_totp ADD COLUMN locked_until TIMESTAMP NULL; -- UTC, the codes are rejected until then.
@@ -0,0 +1,17 @@
+// Package seeds embeds the SQL seeds of the databases, e.g. the reference data and the fixtures of the development
+// environment, see migratekit.Seeder.
+package seeds
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed postgre
+var _postgre embed.FS
+
+// Postgre returns the seeds of the PostgreSQL database.
+func Postgre() fs.FS {
+	sub, _ := fs.Sub(_postgre, "postgre") // never fails, the directory is embedded above.
+	return sub
+}